@@ -0,0 +1,100 @@
+package auth
+
+import (
+	time "time"
+	models "wave-messaging-management-service/models"
+)
+
+// ACLExpiresAt : Return expiry date of users ACLs created or renewed now, nil if ACLs don't expire
+func ACLExpiresAt(env *models.Env) *time.Time {
+
+	if env.Config.ACLExpiry.TTL <= 0 {
+		return nil
+	}
+
+	expiresAt := time.Now().UTC().Add(time.Duration(env.Config.ACLExpiry.TTL) * time.Second)
+
+	return &expiresAt
+}
+
+// RenewACLs : Push back expiry date of internalWaveUserID ACLs after a successful authentication.
+// Return false if its ACLs expired and were removed meanwhile
+func RenewACLs(env *models.Env, internalWaveUserID string) (bool, error) {
+
+	expiresAt := ACLExpiresAt(env)
+
+	if expiresAt == nil {
+		return true, nil
+	}
+
+	return env.Store.RenewACLs(env.TraceContext(), internalWaveUserID, *expiresAt)
+}
+
+// PurgeExpiredACLs : Remove expired ACLs (Guests and stale users) and disconnect their sessions.
+// Also run at startup, so that ACLs expired while the service was down are not kept until the first cleanup interval
+func PurgeExpiredACLs(env *models.Env) error {
+
+	now := time.Now().UTC()
+
+	verneMQACLs, err := env.Store.GetExpiredACLs(env.TraceContext(), now)
+
+	if err != nil {
+		return err
+	}
+
+	if len(verneMQACLs) == 0 {
+		return nil
+	}
+
+	entries := make([]*models.AuditEntry, 0, len(verneMQACLs))
+
+	for _, verneMQACL := range verneMQACLs {
+		entries = append(entries, models.NewAuditEntry(models.SystemActor(), models.AuditACLExpire, verneMQACL.ClientID, map[string]string{"username": verneMQACL.Username}))
+	}
+
+	err = env.Store.RemoveExpiredACLs(env.TraceContext(), now, LifecycleEvents(env, entries...)...)
+
+	if err != nil {
+		return err
+	}
+
+	// Broker only checks ACLs on connection, so active sessions are closed
+	for i, verneMQACL := range verneMQACLs {
+
+		Audit(env, entries[i])
+
+		err = env.Broker.DisconnectSession(verneMQACL.ClientID)
+
+		if err != nil {
+			env.Logger.WithError(err).WithField("clientID", verneMQACL.ClientID).Warn("Failed to disconnect expired session")
+		}
+	}
+
+	return nil
+}
+
+// StartACLCleanup : Remove expired ACLs every configured cleanup interval, in background until workers are stopped
+func StartACLCleanup(env *models.Env) {
+
+	interval := func() time.Duration {
+
+		interval := env.Config.ACLExpiry.CleanupInterval
+
+		if interval <= 0 {
+			interval = models.DefaultACLCleanupInterval
+		}
+
+		return time.Duration(interval) * time.Second
+	}
+
+	env.Workers.Every(interval, func() {
+		env.Guard(func() {
+
+			err := PurgeExpiredACLs(env)
+
+			if err != nil {
+				env.Logger.WithError(err).Error("Failed to remove expired ACLs")
+			}
+		})
+	})
+}
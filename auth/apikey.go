@@ -0,0 +1,79 @@
+package auth
+
+import (
+	rand "crypto/rand"
+	sha256 "crypto/sha256"
+	base64 "encoding/base64"
+	hex "encoding/hex"
+	errors "errors"
+	models "wave-messaging-management-service/models"
+
+	logruswrapper "github.com/terryvogelsang/logruswrapper"
+)
+
+const (
+	// apiKeyLength : Random bytes of generated API keys
+	apiKeyLength = 32
+)
+
+var (
+	// ErrMissingScope : Service credentials are valid but were not granted the required scope
+	ErrMissingScope = errors.New("Missing scope")
+)
+
+// HashAPIKey : Hash API key using SHA-256.
+// Keys are random and long, so a fast hash allows looking them up directly
+func HashAPIKey(key string) string {
+
+	hash := sha256.Sum256([]byte(key))
+
+	return hex.EncodeToString(hash[:])
+}
+
+// CreateAPIKey : Generate a new API key for serviceName and store its hash in database.
+// Key is bound to the tenant of env, if any. Returned key can't be retrieved afterwards
+func CreateAPIKey(env *models.Env, serviceName string, scopes []string) (string, error) {
+
+	data := make([]byte, apiKeyLength)
+
+	_, err := rand.Read(data)
+
+	if err != nil {
+		return "", err
+	}
+
+	key := base64.RawURLEncoding.EncodeToString(data)
+
+	err = env.Store.AddAPIKey(env.TraceContext(), models.NewAPIKey(HashAPIKey(key), serviceName, env.TenantID, scopes))
+
+	if err != nil {
+		return "", err
+	}
+
+	return key, nil
+}
+
+// CheckAPIKey : Return API key infos if provided key is valid and was granted scope
+func CheckAPIKey(env *models.Env, key string, scope string) (*models.APIKey, error) {
+
+	// If no key, return an error
+	if key == "" {
+		return nil, errors.New("No API Key Provided")
+	}
+
+	apiKey, err := env.Store.GetAPIKey(env.TraceContext(), HashAPIKey(key))
+
+	if err != nil {
+		return nil, errors.New(logruswrapper.CodeInvalidToken)
+	}
+
+	if apiKey.Disabled {
+		return nil, errors.New(logruswrapper.CodeInvalidToken)
+	}
+
+	if !apiKey.HasScope(scope) {
+		return nil, ErrMissingScope
+	}
+
+	return apiKey, nil
+}
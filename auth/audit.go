@@ -0,0 +1,57 @@
+package auth
+
+import (
+	models "wave-messaging-management-service/models"
+	utils "wave-messaging-management-service/utils"
+
+	logrus "github.com/sirupsen/logrus"
+)
+
+// Audit : Append entry to the audit log, in the environment tenant and request, and publish it as a real-time event.
+// Failures are logged with the entry, so that the action can still be traced, but never fail the action itself.
+// Lifecycle events of entries are not emitted here, they are written to the outbox along with their mutation (See LifecycleEvents)
+func Audit(env *models.Env, entry *models.AuditEntry) {
+
+	entry.TenantID = env.TenantID
+
+	if entry.RequestID == "" {
+		entry.RequestID = env.RequestID
+	}
+
+	actor := entry.Actor
+
+	env.Events.Publish(&models.Event{
+		Type:      entry.Action,
+		Actor:     &actor,
+		Target:    entry.Target,
+		TenantID:  entry.TenantID,
+		Details:   entry.Details,
+		Timestamp: entry.Timestamp,
+	})
+
+	err := env.Store.AddAuditEntry(env.TraceContext(), entry)
+
+	if err != nil {
+		env.Logger.WithError(err).WithFields(logrus.Fields{
+			"action":    entry.Action,
+			"target":    entry.Target,
+			"actorType": entry.Actor.Type,
+			"actorID":   entry.Actor.ID,
+		}).Error("Failed to audit action")
+	}
+}
+
+// GetAuditLog : Return page of audit entries matching filter, most recent first
+func GetAuditLog(env *models.Env, filter *models.AuditFilter) (*models.AuditPage, error) {
+
+	entries, total, err := env.Store.GetAuditEntries(env.TraceContext(), filter)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.AuditPage{
+		Page:    utils.NewPage(filter.Page, total),
+		Entries: entries,
+	}, nil
+}
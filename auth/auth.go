@@ -5,66 +5,235 @@ import (
 	errors "errors"
 	fmt "fmt"
 	http "net/http"
+	sync "sync"
 	models "wave-messaging-management-service/models"
 	utils "wave-messaging-management-service/utils"
 
 	uuid "github.com/satori/go.uuid"
 	logruswrapper "github.com/terryvogelsang/logruswrapper"
-	bcrypt "golang.org/x/crypto/bcrypt"
 )
 
-// CheckAuthentication : Return MQTT Auth Infos if provided auth token is valid,
-// an error if present, a boolean flag indicating if user was already cached and a boolean flag indicating wether token was updated in Redis
-func CheckAuthentication(env *models.Env, token string) (*models.MQTTAuthInfos, bool, bool, error) {
+// UserVerifier : Resolve application user ID of a token (External endpoint, JWT, OAuth2, static file, ...)
+type UserVerifier interface {
+
+	// VerifyToken : Return user of token according to identity provider settings, an error if token is invalid
+	VerifyToken(env *models.Env, identityProvider *models.IdentityProviderConfig, token string) (*VerifiedUser, error)
+
+	// IsLocal : Local verifiers are cheap and verify cached tokens too (e.g. expiry),
+	// remote ones are only called when token is not cached yet
+	IsLocal() bool
+}
+
+// VerifiedUser : Application user of a verified token, TenantID is set when verifier resolved its tenant
+type VerifiedUser struct {
+	OriginalUserID string
+	TenantID       string
+}
+
+// Provider : Default AuthProvider, verifying tokens with the verifier of configured authentication mode.
+// Verified tokens are cached in Redis and application users mapped with internal Wave user IDs
+type Provider struct {
+	Env       *models.Env
+	Verifiers map[string]UserVerifier
+
+	flights       flightGroup
+	breakers      map[string]*CircuitBreaker
+	breakersMutex sync.Mutex
+}
+
+// NewProvider : Return a new Provider with all built-in verifiers.
+// Deployments may register their own verifiers under custom authentication modes
+func NewProvider(env *models.Env) *Provider {
+	return &Provider{
+		Env: env,
+		Verifiers: map[string]UserVerifier{
+			models.AuthenticationModeEndpoint:      &EndpointVerifier{},
+			models.AuthenticationModeJWT:           &JWTVerifier{},
+			models.AuthenticationModeIntrospection: &IntrospectionVerifier{},
+			models.AuthenticationModeStaticFile:    &StaticFileVerifier{},
+		},
+	}
+}
+
+// CheckAuthentication : Return MQTT Auth Infos if provided auth token is valid for identityProvider (Selected by token prefix, or default one, if empty),
+// an error if present, a boolean flag indicating if user was already cached and a boolean flag indicating wether token was updated in Redis.
+// env is the environment of the request, whose ID is forwarded to remote verifiers
+func (provider *Provider) CheckAuthentication(env *models.Env, token string, identityProvider string) (*models.MQTTAuthInfos, bool, bool, error) {
 
 	// If no token, return an error
 	if token == "" {
 		return nil, false, false, errors.New("No Token Provided")
 	}
 
-	hashedToken, err := HashPassword(token)
+	ctx, span := models.Tracer.Start(env.TraceContext(), "auth.CheckAuthentication")
+	defer span.End()
+
+	env = env.WithTraceContext(ctx)
+
+	// Revoked tokens are refused, even if their verifier still accepts them
+	err := CheckRevocation(env, token, "")
+
 	if err != nil {
 		return nil, false, false, err
 	}
 
+	// Verified token is stripped from identity provider prefix, full token is cached
+	idp, verifiedToken, err := SelectIdentityProvider(&env.Config, identityProvider, token)
+
+	if err != nil {
+		return nil, false, false, err
+	}
+
+	mode := idp.AuthenticationMode
+
+	if mode == "" {
+		mode = models.AuthenticationModeEndpoint
+	}
+
+	verifier, ok := provider.Verifiers[mode]
+
+	if !ok {
+		return nil, false, false, fmt.Errorf("Unknown authentication mode %s", mode)
+	}
+
+	// Local verifiers check tokens on every request, even cached ones
+	var user *VerifiedUser
+
+	if verifier.IsLocal() {
+
+		user, err = verifier.VerifyToken(env, idp, verifiedToken)
+
+		if err != nil {
+			return nil, false, false, err
+		}
+
+		user, err = namespaceVerifiedUser(idp, user)
+
+		if err != nil {
+			return nil, false, false, err
+		}
+
+		err = CheckRevocation(env, "", user.OriginalUserID)
+
+		if err != nil {
+			return nil, false, false, err
+		}
+	}
+
 	// Check if token is cached in Redis, Get UserID if it is
 	cachedInternalUserID, _ := CheckIfTokenIsCached(env, token)
 
 	if cachedInternalUserID != "" {
 
+		models.AuthCacheLookups.WithLabelValues("hit").Inc()
+
+		// Passhash may have been generated with a previous passhash config
+		upgradeLegacyPasshash(env, cachedInternalUserID, token)
+
 		// If yes : Return the cached infos
-		return models.NewMQTTAuthInfos(cachedInternalUserID, hashedToken), true, false, nil
+		infos := models.NewMQTTAuthInfos(cachedInternalUserID, token)
+		infos.TenantID = GetUserTenant(env, cachedInternalUserID)
 
-	} else {
+		return infos, true, false, nil
+	}
 
-		// If no : Verify with external endpoint
-		err = env.RefreshConfig()
+	models.AuthCacheLookups.WithLabelValues("miss").Inc()
 
-		if err != nil {
-			return nil, false, false, err
+	// If no : Verify with remote verifier, once for concurrent requests with the same token
+	return provider.flights.do(idp.Name+":"+token, func() (*models.MQTTAuthInfos, bool, bool, error) {
+
+		var err error
+
+		if user == nil {
+
+			user, err = provider.verifyRemotely(env, verifier, idp, token, verifiedToken)
+
+			if err != nil {
+				return nil, false, false, err
+			}
+
+			err = CheckRevocation(env, "", user.OriginalUserID)
+
+			if err != nil {
+				return nil, false, false, err
+			}
 		}
 
-		MQTTAuthInfos, wasCached, wasTokenUpdated, err := VerifyTokenWithExternalEndpoint(env, token, hashedToken)
+		return MapOriginalUser(env, user, token)
+	})
+}
+
+// verifyRemotely : Verify token with remote verifier through circuit breaker of identity provider, return namespaced user.
+// While verifier is unavailable, previously verified tokens are accepted (Fallback to cached auth state)
+func (provider *Provider) verifyRemotely(env *models.Env, verifier UserVerifier, idp *models.IdentityProviderConfig, token string, verifiedToken string) (*VerifiedUser, error) {
+
+	user, err := provider.breaker(idp.Name).Call(&env.Config.CircuitBreaker, func() (*VerifiedUser, error) {
+		return verifier.VerifyToken(env, idp, verifiedToken)
+	})
+
+	if err == nil {
+
+		user, err = namespaceVerifiedUser(idp, user)
 
 		if err != nil {
-			return nil, false, false, err
+			return nil, err
 		}
 
-		return MQTTAuthInfos, wasCached, wasTokenUpdated, nil
+		rememberVerifiedUser(env, token, user)
+		return user, nil
 	}
+
+	if err.Error() == logruswrapper.CodeInvalidToken {
+		return nil, err
+	}
+
+	fallbackUser := getVerifiedUser(env, token)
+
+	if fallbackUser == nil {
+		return nil, err
+	}
+
+	env.Logger.WithError(err).WithField("identityProvider", idp.Name).Warn("Remote verifier unavailable, falling back to cached auth state")
+
+	return fallbackUser, nil
 }
 
-// HashPassword : Hash password using bcrypt
-func HashPassword(password string) (string, error) {
-	bytes, err := bcrypt.GenerateFromPassword([]byte(password), 14)
-	return string(bytes), err
+// breaker : Return circuit breaker of identity provider, so that a failing provider doesn't affect the others
+func (provider *Provider) breaker(identityProvider string) *CircuitBreaker {
+
+	provider.breakersMutex.Lock()
+	defer provider.breakersMutex.Unlock()
+
+	if provider.breakers == nil {
+		provider.breakers = map[string]*CircuitBreaker{}
+	}
+
+	breaker, ok := provider.breakers[identityProvider]
+
+	if !ok {
+		breaker = &CircuitBreaker{Logger: provider.Env.Logger.WithField("identityProvider", identityProvider), Name: "Authentication"}
+		provider.breakers[identityProvider] = breaker
+	}
+
+	return breaker
+}
+
+// InvalidateToken : Remove token from auth cache, without revoking MQTT credentials
+func (provider *Provider) InvalidateToken(token string) error {
+
+	// If no token, return an error
+	if token == "" {
+		return errors.New("No Token Provided")
+	}
+
+	return InvalidateCachedToken(provider.Env, token)
 }
 
 // CheckIfTokenIsCached : Check if token is cached in Redis
 func CheckIfTokenIsCached(env *models.Env, token string) (string, error) {
 
 	// Check if token is cached in redis
-	cachedInternalUserID, err := env.Redis.Get(fmt.Sprintf("session:%s", token))
+	cachedInternalUserID, err := env.Redis.Get(env.TraceContext(), fmt.Sprintf("session:%s", token))
 
 	if err != nil {
 		return "", err
@@ -77,26 +246,42 @@ func CheckIfTokenIsCached(env *models.Env, token string) (string, error) {
 	return "", nil
 }
 
-// VerifyTokenWithExternalEndpoint : Verify token with provided external auth endpoint
-func VerifyTokenWithExternalEndpoint(env *models.Env, token string, hashedToken string) (*models.MQTTAuthInfos, bool, bool, error) {
+// EndpointVerifier : Verify tokens with provided external auth endpoint
+type EndpointVerifier struct{}
+
+// IsLocal : External endpoint is only called for tokens not cached yet
+func (verifier *EndpointVerifier) IsLocal() bool {
+	return false
+}
+
+// VerifyToken : Verify token with provided external auth endpoint
+func (verifier *EndpointVerifier) VerifyToken(env *models.Env, identityProvider *models.IdentityProviderConfig, token string) (*VerifiedUser, error) {
 
-	// Create HTTP Client
-	client := &http.Client{}
+	// Execute request with shared HTTP client, retried on server errors
+	res, err := doWithRetry(env, func() (*http.Request, error) {
 
-	// Init request
-	req, err := http.NewRequest("GET", env.Config.AuthenticationCheckEndpoint, nil)
+		// Init request
+		req, err := http.NewRequest("GET", identityProvider.AuthenticationCheckEndpoint, nil)
+
+		if err != nil {
+			return nil, err
+		}
+
+		// Add token header
+		req.Header.Add("token", token)
+
+		return req, nil
+	})
 
 	if err != nil {
-		return nil, false, false, err
+		return nil, err
 	}
 
-	// Add token header
-	req.Header.Add("token", token)
+	defer res.Body.Close()
 
-	// Execute request
-	res, err := client.Do(req)
-	if err != nil {
-		return nil, false, false, err
+	// Server errors are endpoint failures, not invalid tokens
+	if res.StatusCode >= 500 {
+		return nil, fmt.Errorf("Authentication endpoint error : %s", res.Status)
 	}
 
 	//=============================================================================
@@ -109,7 +294,7 @@ func VerifyTokenWithExternalEndpoint(env *models.Env, token string, hashedToken
 	//
 	// HTTP Status Code : 200 (OK)
 	// Header(s) : content-type:application/json
-	// {"UserID": "userID"}
+	// {"UserID": "userID", "tenantID": "tenantID"} (tenantID is optional)
 	//=============================================================================
 	if res.StatusCode == 200 {
 
@@ -117,41 +302,108 @@ func VerifyTokenWithExternalEndpoint(env *models.Env, token string, hashedToken
 		authCheckerBody := utils.AuthCheckerBody{}
 		json.NewDecoder(res.Body).Decode(&authCheckerBody)
 
-		// Check if user already has a cached token
-		cachedInternalWaveUserID, cachedOldToken, _ := CheckIfUserAlreadyHasToken(env, authCheckerBody.OriginalUserID)
+		if authCheckerBody.OriginalUserID != "" {
+			return &VerifiedUser{OriginalUserID: authCheckerBody.OriginalUserID, TenantID: authCheckerBody.TenantID}, nil
+		}
+	}
 
-		if cachedOldToken != "" {
+	return nil, errors.New(logruswrapper.CodeInvalidToken)
+}
 
-			// If yes : Update Redis with new token and revoke the older token
-			UpdateRedisAndMongoDBWithNewToken(env, authCheckerBody.OriginalUserID, cachedInternalWaveUserID, cachedOldToken, token, hashedToken)
+// MapOriginalUser : Map authenticated application user with an internal Wave user ID and cache its token
+// Returned MQTTAuthInfos hold the user tenant and its token as MQTT password, passhashes being only computed when written
+func MapOriginalUser(env *models.Env, user *VerifiedUser, token string) (*models.MQTTAuthInfos, bool, bool, error) {
 
-			// Return MQTTAuthInfos
-			return models.NewMQTTAuthInfos(cachedInternalWaveUserID, hashedToken), true, true, nil
+	infos, wasCached, wasTokenUpdated, err := mapOriginalUser(env, user.OriginalUserID, token)
 
-		} else {
+	if err != nil {
+		return nil, false, false, err
+	}
 
-			// If no : Create new token store + mapping in Redis
-			newInternalWaveUserID := uuid.NewV4().String()
+	if user.TenantID != "" {
 
-			// Store token in Redis
-			env.Redis.Set(fmt.Sprintf("session:%s", token), []byte(newInternalWaveUserID))
+		err = env.Redis.Set(env.TraceContext(), fmt.Sprintf("tenant:%s", infos.ClientID), []byte(user.TenantID))
+
+		if err != nil {
+			return nil, false, false, err
+		}
+	}
+
+	infos.TenantID = user.TenantID
+
+	if wasTokenUpdated {
+		Audit(env.ForTenant(user.TenantID), models.NewAuditEntry(models.UserActor(infos.ClientID), models.AuditCredentialsRotate, infos.ClientID, map[string]string{"token": TokenFingerprint(token)}))
+	}
+
+	return infos, wasCached, wasTokenUpdated, nil
+}
+
+// mapOriginalUser : Map originalUserID with an internal Wave user ID and cache its token
+func mapOriginalUser(env *models.Env, originalUserID string, token string) (*models.MQTTAuthInfos, bool, bool, error) {
+
+	// Check if user already has a cached token
+	cachedInternalWaveUserID, cachedOldToken, _ := CheckIfUserAlreadyHasToken(env, originalUserID)
+
+	if cachedOldToken == "" {
 
-			// Store mapping in Redis
-			env.Redis.HSet(fmt.Sprintf("mapping:%s", authCheckerBody.OriginalUserID), "token", []byte(token), "internalWaveUserID", []byte(newInternalWaveUserID))
+		// If no : Create new mapping in Redis, unless a concurrent authentication of the user created one meanwhile
+		newInternalWaveUserID := uuid.NewV4().String()
+
+		internalWaveUserID, mappedToken, created, err := createMapping(env, originalUserID, token, newInternalWaveUserID)
+
+		if err != nil {
+			return nil, false, false, err
+		}
+
+		if created {
+
+			// Store token in Redis
+			CacheToken(env, token, newInternalWaveUserID)
 
 			// Return MQTTAuthInfos
-			return models.NewMQTTAuthInfos(newInternalWaveUserID, hashedToken), false, false, nil
+			return models.NewMQTTAuthInfos(newInternalWaveUserID, token), false, false, nil
 		}
+
+		// Mapping created meanwhile is used as a cached one
+		cachedInternalWaveUserID, cachedOldToken = internalWaveUserID, mappedToken
+	}
+
+	if cachedOldToken == token {
+
+		// If token is the same (Cache entry expired or was invalidated) : Cache it again and restore credentials.
+		// Credentials deliberately rotated are not restored : logged out and revoked tokens are in denylist
+		err := CheckRevocation(env, token, "")
+
+		if err != nil {
+			return nil, false, false, err
+		}
+
+		err = UpdateRedisAndMongoDBWithNewToken(env, originalUserID, cachedInternalWaveUserID, token, token)
+
+		if err != nil {
+			return nil, false, false, err
+		}
+
+		return models.NewMQTTAuthInfos(cachedInternalWaveUserID, token), true, false, nil
+
 	}
 
-	return nil, false, false, errors.New(logruswrapper.CodeInvalidToken)
+	// If yes : Rotate passhash of user ACLs, update Redis with new token and revoke the older token
+	err := UpdateRedisAndMongoDBWithNewToken(env, originalUserID, cachedInternalWaveUserID, cachedOldToken, token)
+
+	if err != nil {
+		return nil, false, false, err
+	}
+
+	// Return MQTTAuthInfos
+	return models.NewMQTTAuthInfos(cachedInternalWaveUserID, token), true, true, nil
 }
 
 // CheckIfUserAlreadyHasToken : Check if originalUserID is already matched with one token in redis
 func CheckIfUserAlreadyHasToken(env *models.Env, originalUserID string) (string, string, error) {
 
-	cachedOldToken, err := env.Redis.HGet(fmt.Sprintf("mapping:%s", originalUserID), "token")
-	cachedInternalUserID, err := env.Redis.HGet(fmt.Sprintf("mapping:%s", originalUserID), "internalWaveUserID")
+	cachedOldToken, err := env.Redis.HGet(env.TraceContext(), fmt.Sprintf("mapping:%s", originalUserID), "token")
+	cachedInternalUserID, err := env.Redis.HGet(env.TraceContext(), fmt.Sprintf("mapping:%s", originalUserID), "internalWaveUserID")
 
 	if err != nil {
 		return "", "", err
@@ -160,11 +412,17 @@ func CheckIfUserAlreadyHasToken(env *models.Env, originalUserID string) (string,
 	return string(cachedInternalUserID), string(cachedOldToken), nil
 }
 
-// UpdateRedisAndMongoDBWithNewToken : Update old token store and mapping with new token
-func UpdateRedisAndMongoDBWithNewToken(env *models.Env, originalUserID string, internalWaveUserID string, oldToken string, newToken string, newHashedToken string) error {
+// UpdateRedisAndMongoDBWithNewToken : Update old token store and mapping with new token, hashing it for the rewritten passhash
+func UpdateRedisAndMongoDBWithNewToken(env *models.Env, originalUserID string, internalWaveUserID string, oldToken string, newToken string) error {
+
+	newHashedToken, err := HashPassword(env.Config.Passhash, newToken)
 
-	// Update MongoDB Profile
-	err := env.MongoDB.UpdatePassHash(internalWaveUserID, newHashedToken)
+	if err != nil {
+		return err
+	}
+
+	// Update MongoDB Profile, legacy passhashes are replaced with configured algorithm ones
+	err = updatePassHash(env, internalWaveUserID, newHashedToken)
 
 	if err != nil {
 		return err
@@ -172,19 +430,74 @@ func UpdateRedisAndMongoDBWithNewToken(env *models.Env, originalUserID string, i
 
 	// Update Redis Token Store Key :
 	// session:{oldToken} -> session:{newToken}
-	err = env.Redis.Rename(fmt.Sprintf("session:%s", oldToken), fmt.Sprintf("session:%s", newToken))
+	// Old session may already have been revoked, so new session is set rather than renamed
+	err = CacheToken(env, newToken, internalWaveUserID)
 
 	if err != nil {
 		return err
 	}
 
+	if oldToken != newToken {
+
+		err = env.Redis.Delete(env.TraceContext(), fmt.Sprintf("session:%s", oldToken))
+
+		if err != nil {
+			return err
+		}
+	}
+
 	// Update Redis Mapping Values :
 	// mapping:{originalUserID} token {oldToken} ... --> mapping:{originalUserID} token {newToken} ...
-	err = env.Redis.HSet(fmt.Sprintf("mapping:%s", originalUserID), "token", []byte(newToken), "internalWaveUserID", nil)
+	err = env.Redis.HSet(env.TraceContext(), fmt.Sprintf("mapping:%s", originalUserID), "token", []byte(newToken), "internalWaveUserID", []byte(internalWaveUserID))
 
 	if err != nil {
 		return err
 	}
 
+	// Reverse index is written with the mapping, so that it is repaired if it failed to be written along with its creation
+	return indexMapping(env, originalUserID, internalWaveUserID)
+}
+
+// RevokeCredentials : Invalidate MQTT credentials of internalWaveUserID on all its devices.
+// Passhash is rotated to an unknown secret along with events, cached token is removed from Redis and active broker sessions are disconnected
+func RevokeCredentials(env *models.Env, internalWaveUserID string, token string, events ...*models.LifecycleEvent) error {
+
+	// Rotate passhash so that token is not accepted anymore by the broker
+	hashedSecret, err := HashPassword(env.Config.Passhash, uuid.NewV4().String())
+
+	if err != nil {
+		return err
+	}
+
+	err = updatePassHash(env, internalWaveUserID, hashedSecret, events...)
+
+	if err != nil {
+		return err
+	}
+
+	// Revoke cached token :
+	// Next authentication will have to go through external endpoint again
+	err = InvalidateCachedToken(env, token)
+
+	if err != nil {
+		return err
+	}
+
+	// Kick active MQTT sessions of every device of the user
+	verneMQACLs, err := env.Store.GetUserACLs(env.TraceContext(), internalWaveUserID)
+
+	if err != nil {
+		return err
+	}
+
+	for _, verneMQACL := range verneMQACLs {
+
+		err = env.Broker.DisconnectSession(verneMQACL.ClientID)
+
+		if err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
@@ -0,0 +1,162 @@
+package auth
+
+import (
+	errors "errors"
+	fmt "fmt"
+	sync "sync"
+	time "time"
+	models "wave-messaging-management-service/models"
+
+	logrus "github.com/sirupsen/logrus"
+	logruswrapper "github.com/terryvogelsang/logruswrapper"
+)
+
+const (
+	// DefaultBreakerFailureThreshold : Consecutive failures opening the circuit, used when none is configured
+	DefaultBreakerFailureThreshold = 5
+
+	// DefaultBreakerOpenTimeout : Seconds the circuit stays open before a trial call, used when none is configured
+	DefaultBreakerOpenTimeout = 30
+
+	// DefaultBreakerFallbackTTL : Seconds verified tokens are accepted while circuit is open, used when none is configured
+	DefaultBreakerFallbackTTL = 86400
+)
+
+var (
+	// ErrCircuitOpen : Returned while remote verifier is not called
+	ErrCircuitOpen = errors.New("Authentication circuit open")
+)
+
+// CircuitBreaker : Stop calling remote verifiers (Or other remote endpoints) after consecutive failures (Closed -> Open),
+// then let a single trial call through once open timeout elapsed (Half-open). Circuit openings are reported to Logger, prefixed by Name
+type CircuitBreaker struct {
+	Logger *logrus.Entry
+	Name   string
+
+	mutex    sync.Mutex
+	failures int
+	openedAt time.Time
+	trial    bool
+}
+
+// Call : Run fn unless circuit is open. Invalid tokens are answers, only other errors count as failures
+func (breaker *CircuitBreaker) Call(config *models.CircuitBreakerConfig, fn func() (*VerifiedUser, error)) (*VerifiedUser, error) {
+
+	err := breaker.Allow(config)
+
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := fn()
+
+	breaker.Record(config, err, err != nil && err.Error() != logruswrapper.CodeInvalidToken)
+
+	return result, err
+}
+
+// Allow : Return ErrCircuitOpen if the circuit is open, otherwise let the call through. Allowed calls must be recorded
+func (breaker *CircuitBreaker) Allow(config *models.CircuitBreakerConfig) error {
+
+	breaker.mutex.Lock()
+	defer breaker.mutex.Unlock()
+
+	if breaker.failures < breakerFailureThreshold(config) {
+		return nil
+	}
+
+	// Open : Wait for timeout, then only one trial call at a time
+	if breaker.trial || time.Since(breaker.openedAt) < BreakerOpenTimeout(config) {
+		return ErrCircuitOpen
+	}
+
+	breaker.trial = true
+
+	return nil
+}
+
+// Record : Record outcome of an allowed call, failed calls counting towards opening the circuit, err being reported when it opens
+func (breaker *CircuitBreaker) Record(config *models.CircuitBreakerConfig, err error, failed bool) {
+
+	breaker.mutex.Lock()
+	defer breaker.mutex.Unlock()
+
+	breaker.trial = false
+
+	if !failed {
+		breaker.failures = 0
+		return
+	}
+
+	breaker.failures++
+
+	if breaker.failures >= breakerFailureThreshold(config) {
+		breaker.openedAt = time.Now()
+		breaker.Logger.WithError(err).WithField("failures", breaker.failures).Warn(breaker.Name + " circuit open")
+	}
+}
+
+// BreakerOpenTimeout : Return duration circuits of config stay open before a trial call
+func BreakerOpenTimeout(config *models.CircuitBreakerConfig) time.Duration {
+
+	openTimeout := config.OpenTimeout
+
+	if openTimeout <= 0 {
+		openTimeout = DefaultBreakerOpenTimeout
+	}
+
+	return time.Duration(openTimeout) * time.Second
+}
+
+// breakerFailureThreshold : Return consecutive failures opening circuits of config
+func breakerFailureThreshold(config *models.CircuitBreakerConfig) int {
+
+	if config.FailureThreshold <= 0 {
+		return DefaultBreakerFailureThreshold
+	}
+
+	return config.FailureThreshold
+}
+
+// rememberVerifiedUser : Keep application user of a remotely verified token, used as fallback while remote verifier is unavailable
+func rememberVerifiedUser(env *models.Env, token string, user *VerifiedUser) error {
+
+	key := fmt.Sprintf("verified:%s", token)
+
+	// Entries used to be plain values, they are replaced rather than updated
+	err := env.Redis.Delete(env.TraceContext(), key)
+
+	if err != nil {
+		return err
+	}
+
+	err = env.Redis.HSet(env.TraceContext(), key, "originalUserID", []byte(user.OriginalUserID), "tenantID", []byte(user.TenantID))
+
+	if err != nil {
+		return err
+	}
+
+	fallbackTTL := env.Config.CircuitBreaker.FallbackTTL
+
+	if fallbackTTL <= 0 {
+		fallbackTTL = DefaultBreakerFallbackTTL
+	}
+
+	return env.Redis.Expire(env.TraceContext(), key, fallbackTTL)
+}
+
+// getVerifiedUser : Return application user of a previously verified token, nil if unknown
+func getVerifiedUser(env *models.Env, token string) *VerifiedUser {
+
+	key := fmt.Sprintf("verified:%s", token)
+
+	originalUserID, _ := env.Redis.HGet(env.TraceContext(), key, "originalUserID")
+
+	if len(originalUserID) == 0 {
+		return nil
+	}
+
+	tenantID, _ := env.Redis.HGet(env.TraceContext(), key, "tenantID")
+
+	return &VerifiedUser{OriginalUserID: string(originalUserID), TenantID: string(tenantID)}
+}
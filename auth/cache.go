@@ -0,0 +1,85 @@
+package auth
+
+import (
+	fmt "fmt"
+	sync "sync"
+	models "wave-messaging-management-service/models"
+)
+
+// CacheToken : Cache verified token in Redis (session:{token} -> internalWaveUserID).
+// Entry expires after configured TTL, so that token is verified again afterwards
+func CacheToken(env *models.Env, token string, internalWaveUserID string) error {
+
+	key := fmt.Sprintf("session:%s", token)
+
+	if env.Config.AuthCache.TTL > 0 {
+		return env.Redis.SetEx(env.TraceContext(), key, []byte(internalWaveUserID), env.Config.AuthCache.TTL)
+	}
+
+	return env.Redis.Set(env.TraceContext(), key, []byte(internalWaveUserID))
+}
+
+// InvalidateCachedToken : Remove cached token from Redis.
+// Next authentication with this token will go through the verifier again
+func InvalidateCachedToken(env *models.Env, token string) error {
+
+	err := env.Redis.Delete(env.TraceContext(), fmt.Sprintf("session:%s", token))
+
+	if err != nil {
+		return err
+	}
+
+	// Invalidated tokens can't be accepted as fallback either
+	return env.Redis.Delete(env.TraceContext(), fmt.Sprintf("verified:%s", token))
+}
+
+// flightGroup : Deduplicate concurrent verifications of the same token (Cache stampede protection)
+type flightGroup struct {
+	mutex sync.Mutex
+	calls map[string]*flight
+}
+
+// flight : Verification in progress, followers wait for its result
+type flight struct {
+	wait            sync.WaitGroup
+	infos           *models.MQTTAuthInfos
+	wasTokenUpdated bool
+	wasCached       bool
+	err             error
+}
+
+// do : Run fn once for all concurrent callers of key.
+// Followers get leader results flagged as cached, as leader already mapped the user
+func (group *flightGroup) do(key string, fn func() (*models.MQTTAuthInfos, bool, bool, error)) (*models.MQTTAuthInfos, bool, bool, error) {
+
+	group.mutex.Lock()
+
+	if group.calls == nil {
+		group.calls = map[string]*flight{}
+	}
+
+	if call, ok := group.calls[key]; ok {
+		group.mutex.Unlock()
+		call.wait.Wait()
+
+		if call.err != nil {
+			return nil, false, false, call.err
+		}
+
+		return call.infos, true, false, nil
+	}
+
+	call := &flight{}
+	call.wait.Add(1)
+	group.calls[key] = call
+	group.mutex.Unlock()
+
+	call.infos, call.wasCached, call.wasTokenUpdated, call.err = fn()
+	call.wait.Done()
+
+	group.mutex.Lock()
+	delete(group.calls, key)
+	group.mutex.Unlock()
+
+	return call.infos, call.wasCached, call.wasTokenUpdated, call.err
+}
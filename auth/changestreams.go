@@ -0,0 +1,118 @@
+package auth
+
+import (
+	context "context"
+	errors "errors"
+	fmt "fmt"
+	sort "sort"
+	strings "strings"
+	time "time"
+	models "wave-messaging-management-service/models"
+)
+
+// StartChangeStreams : Watch changes of VerneMQ ACLs and group conversations when change streams are enabled, in background until workers are stopped.
+// Failed change streams are watched again after the last handled change, or from now when it is too old to be resumed.
+// Store backends without change streams are not watched
+func StartChangeStreams(env *models.Env) {
+
+	if !env.Config.ChangeStreams.Enabled {
+		return
+	}
+
+	// Change stream lives as long as the instance, it is canceled once workers are being stopped
+	ctx, cancel := context.WithCancel(context.Background())
+	detached := env.WithTraceContext(ctx)
+
+	interval := func() time.Duration {
+
+		interval := env.Config.ChangeStreams.RetryInterval
+
+		if interval <= 0 {
+			interval = models.DefaultChangeStreamsRetryInterval
+		}
+
+		return time.Duration(interval) * time.Second
+	}
+
+	env.Workers.Go(func() {
+		<-env.Workers.Stopping()
+		cancel()
+	})
+
+	env.Workers.Go(func() {
+
+		var resumeToken []byte
+
+		for {
+
+			var err error
+
+			detached.Guard(func() {
+				resumeToken, err = detached.Store.WatchChanges(ctx, resumeToken, func(change *models.DataChange) {
+					HandleDataChange(detached, change)
+				})
+			})
+
+			select {
+			case <-env.Workers.Stopping():
+				return
+			default:
+			}
+
+			if errors.Is(err, models.ErrNotSupported) {
+				env.Logger.WithError(err).Warn("Change streams are not supported by the store backend")
+				return
+			}
+
+			if models.IsChangeStreamHistoryLost(err) {
+				env.Logger.WithError(err).Warn("Change stream can't be resumed, changes since its last change are missed")
+				resumeToken = nil
+			} else if err != nil {
+				env.Logger.WithError(err).Error("Change stream failed")
+			}
+
+			select {
+			case <-env.Workers.Stopping():
+				return
+			case <-time.After(interval()):
+			}
+		}
+	})
+}
+
+// HandleDataChange : Invalidate Redis caches of change and publish it as a real-time event of the instance.
+// Passhash schemes cached for ACLs whose passhash changed are removed, so that the passhash is checked again on the next authentication
+func HandleDataChange(env *models.Env, change *models.DataChange) {
+
+	models.DataChanges.WithLabelValues(change.Collection, change.Operation).Inc()
+
+	tenantID := change.TenantID
+
+	if change.ACL != nil {
+
+		tenantID = GetUserTenant(env, change.ACL.Username)
+
+		if change.IsUpdated("passhash") {
+
+			err := env.Redis.Delete(env.TraceContext(), fmt.Sprintf("passhash:%s", change.ACL.Username))
+
+			if err != nil {
+				env.Logger.WithError(err).WithField(models.LogFieldUserID, change.ACL.Username).Error("Failed to invalidate cached passhash scheme")
+			}
+		}
+	}
+
+	details := map[string]string{}
+
+	if len(change.UpdatedFields) > 0 {
+		sort.Strings(change.UpdatedFields)
+		details["fields"] = strings.Join(change.UpdatedFields, ",")
+	}
+
+	env.Events.Publish(&models.Event{
+		Type:     change.EventType(),
+		Target:   change.Target(),
+		TenantID: tenantID,
+		Details:  details,
+	})
+}
@@ -0,0 +1,120 @@
+package auth
+
+import (
+	context "context"
+	time "time"
+	models "wave-messaging-management-service/models"
+)
+
+const (
+	// DependencyStateUp : State of a dependency which answered in time
+	DependencyStateUp = "up"
+
+	// DependencyStateDegraded : State of a dependency which answered in time, but slower than the readiness degraded latency
+	DependencyStateDegraded = "degraded"
+
+	// DependencyStateDown : State of a dependency which failed or did not answer in time
+	DependencyStateDown = "down"
+)
+
+// DependencyPing : Outcome of a ping of a dependency
+type DependencyPing struct {
+	State   string
+	Latency time.Duration
+	Err     error
+}
+
+// PingDependencies : Ping the store (Named after its backend) and Redis within the readiness timeout, returning outcomes by dependency
+func PingDependencies(env *models.Env) map[string]*DependencyPing {
+
+	pings := map[string]func(ctx context.Context) error{
+		env.Config.Datastores.StoreBackend(): env.Store.Ping,
+		models.DatastoreRedis:                env.Redis.Ping,
+	}
+
+	outcomes := map[string]*DependencyPing{}
+
+	for name, ping := range pings {
+
+		ctx, cancel := context.WithTimeout(env.TraceContext(), env.Config.Readiness.TimeoutDuration())
+
+		start := time.Now()
+		err := ping(ctx)
+
+		cancel()
+
+		outcome := &DependencyPing{State: DependencyStateUp, Latency: time.Since(start), Err: err}
+
+		if err != nil {
+			outcome.State = DependencyStateDown
+		} else if env.Config.Readiness.Degraded(outcome.Latency) {
+			outcome.State = DependencyStateDegraded
+		}
+
+		outcomes[name] = outcome
+	}
+
+	return outcomes
+}
+
+// StartDependencyMonitor : Ping dependencies every configured monitor interval, in background until workers are stopped.
+// Their state is exposed as metrics, and logged when it changes
+func StartDependencyMonitor(env *models.Env) {
+
+	interval := func() time.Duration {
+
+		interval := env.Config.DependencyMonitor.Interval
+
+		if interval <= 0 {
+			interval = models.DefaultDependencyMonitorInterval
+		}
+
+		return time.Duration(interval) * time.Second
+	}
+
+	// States of the last pings, dependencies being assumed up at startup
+	states := map[string]string{}
+
+	env.Workers.Every(interval, func() {
+		env.Guard(func() {
+
+			for name, outcome := range PingDependencies(env) {
+
+				up, degraded := 0.0, 0.0
+
+				if outcome.State != DependencyStateDown {
+					up = 1
+				}
+
+				if outcome.State != DependencyStateUp {
+					degraded = 1
+				}
+
+				models.DependencyUp.WithLabelValues(name).Set(up)
+				models.DependencyDegraded.WithLabelValues(name).Set(degraded)
+
+				previous, ok := states[name]
+				states[name] = outcome.State
+
+				if !ok {
+					previous = DependencyStateUp
+				}
+
+				if outcome.State == previous {
+					continue
+				}
+
+				logger := env.Logger.WithField("dependency", name).WithField("state", outcome.State).WithField("latency", int64(outcome.Latency/time.Millisecond))
+
+				switch outcome.State {
+				case DependencyStateDown:
+					logger.WithError(outcome.Err).Warn("Dependency down")
+				case DependencyStateDegraded:
+					logger.Warn("Dependency degraded")
+				default:
+					logger.Info("Dependency recovered")
+				}
+			}
+		})
+	})
+}
@@ -0,0 +1,62 @@
+package auth
+
+import (
+	errors "errors"
+	time "time"
+	models "wave-messaging-management-service/models"
+
+	uuid "github.com/satori/go.uuid"
+)
+
+const (
+	// GuestClientIDPrefix : Prefix of guests MQTT client IDs
+	GuestClientIDPrefix = "guest-"
+)
+
+var (
+	// ErrGuestAccessDisabled : Guest access is disabled or has no topic
+	ErrGuestAccessDisabled = errors.New("Guest access disabled")
+)
+
+// CreateGuest : Provision a short-lived, subscribe-only VerneMQ ACL on configured public topics.
+// ACL is removed and session disconnected by the ACL cleanup worker once expired (See StartACLCleanup)
+func CreateGuest(env *models.Env) (*models.GuestMQTTAuthInfos, error) {
+
+	config := env.Config.Guest
+
+	if !config.Enabled || len(config.Topics) == 0 {
+		return nil, ErrGuestAccessDisabled
+	}
+
+	ttl := config.TTL
+
+	if ttl <= 0 {
+		ttl = models.DefaultGuestTTL
+	}
+
+	clientID := GuestClientIDPrefix + uuid.NewV4().String()
+	password := uuid.NewV4().String()
+
+	passhash, err := HashPassword(env.Config.Passhash, password)
+
+	if err != nil {
+		return nil, err
+	}
+
+	expiresAt := time.Now().UTC().Add(time.Duration(ttl) * time.Second)
+
+	err = env.Store.AddProfileACL(env.TraceContext(), models.NewGuestVerneMQACL(clientID, passhash, config.Topics, expiresAt))
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.GuestMQTTAuthInfos{
+		MQTTAuthInfos: models.MQTTAuthInfos{
+			ClientID: clientID,
+			Username: clientID,
+			Password: password,
+		},
+		ExpiresAt: expiresAt,
+	}, nil
+}
@@ -0,0 +1,167 @@
+package auth
+
+import (
+	context "context"
+	fmt "fmt"
+	ioutil "io/ioutil"
+	rand "math/rand"
+	net "net"
+	http "net/http"
+	sync "sync"
+	time "time"
+	models "wave-messaging-management-service/models"
+
+	otel "go.opentelemetry.io/otel"
+	propagation "go.opentelemetry.io/otel/propagation"
+	trace "go.opentelemetry.io/otel/trace"
+)
+
+const (
+	// DefaultHTTPConnectTimeout : Milliseconds to establish connections to remote verifiers, used when none is configured
+	DefaultHTTPConnectTimeout = 2000
+
+	// DefaultHTTPReadTimeout : Milliseconds to wait for remote verifiers responses, used when none is configured
+	DefaultHTTPReadTimeout = 5000
+
+	// DefaultHTTPRetryBaseDelay : Milliseconds before first retry, doubled on each retry, used when none is configured
+	DefaultHTTPRetryBaseDelay = 100
+
+	// DefaultHTTPMaxIdleConns : Idle connections kept per remote verifier host, used when none is configured
+	DefaultHTTPMaxIdleConns = 100
+)
+
+var (
+	// httpClient : HTTP client shared by remote verifiers, rebuilt when its config changes
+	httpClient       *http.Client
+	httpClientConfig models.HTTPClientConfig
+	httpClientMutex  sync.Mutex
+)
+
+// getHTTPClient : Return shared HTTP client matching config, keeping connections alive between calls
+func getHTTPClient(config models.HTTPClientConfig) *http.Client {
+
+	httpClientMutex.Lock()
+	defer httpClientMutex.Unlock()
+
+	if httpClient != nil && httpClientConfig == config {
+		return httpClient
+	}
+
+	connectTimeout := durationOrDefault(config.ConnectTimeout, DefaultHTTPConnectTimeout)
+	readTimeout := durationOrDefault(config.ReadTimeout, DefaultHTTPReadTimeout)
+
+	maxIdleConns := config.MaxIdleConns
+
+	if maxIdleConns <= 0 {
+		maxIdleConns = DefaultHTTPMaxIdleConns
+	}
+
+	httpClient = &http.Client{
+		// Whole attempt, body included
+		Timeout: connectTimeout + readTimeout,
+		Transport: &http.Transport{
+			Proxy: http.ProxyFromEnvironment,
+			DialContext: (&net.Dialer{
+				Timeout:   connectTimeout,
+				KeepAlive: 30 * time.Second,
+			}).DialContext,
+			TLSHandshakeTimeout:   connectTimeout,
+			ResponseHeaderTimeout: readTimeout,
+			MaxIdleConns:          maxIdleConns,
+			MaxIdleConnsPerHost:   maxIdleConns,
+			IdleConnTimeout:       90 * time.Second,
+		},
+	}
+	httpClientConfig = config
+
+	return httpClient
+}
+
+// doWithRetry : Send request built by newRequest with shared HTTP client, forwarding ID and trace context of the request being handled.
+// Network errors and 5xx responses are retried up to MaxRetries times, with exponential backoff and jitter
+func doWithRetry(env *models.Env, newRequest func() (*http.Request, error)) (*http.Response, error) {
+
+	config := env.Config.AuthHTTPClient
+	client := getHTTPClient(config)
+	delay := durationOrDefault(config.RetryBaseDelay, DefaultHTTPRetryBaseDelay)
+
+	ctx, span := models.Tracer.Start(env.TraceContext(), "auth.remoteVerification", trace.WithSpanKind(trace.SpanKindClient))
+	defer span.End()
+
+	for attempt := 0; ; attempt++ {
+
+		// Requests are built again as their body is consumed
+		req, err := newRequest()
+
+		if err != nil {
+			return nil, err
+		}
+
+		if env.RequestID != "" {
+			req.Header.Set(models.RequestIDHeader, env.RequestID)
+		}
+
+		otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(req.Header))
+
+		res, err := client.Do(req)
+
+		if (err == nil && res.StatusCode < 500) || attempt >= config.MaxRetries {
+			return res, err
+		}
+
+		// Drain body so that connection can be reused
+		if res != nil {
+			ioutil.ReadAll(res.Body)
+			res.Body.Close()
+		}
+
+		// Full jitter between half and whole delay, so that instances don't retry in sync.
+		// Retries are given up once the request being handled is past its deadline
+		select {
+		case <-time.After(delay/2 + time.Duration(rand.Int63n(int64(delay/2)+1))):
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+
+		delay *= 2
+	}
+}
+
+// durationOrDefault : Milliseconds to duration, defaultMilliseconds if not set
+func durationOrDefault(milliseconds int, defaultMilliseconds int) time.Duration {
+
+	if milliseconds <= 0 {
+		milliseconds = defaultMilliseconds
+	}
+
+	return time.Duration(milliseconds) * time.Millisecond
+}
+
+// PingAuthenticationEndpoint : Check external authentication endpoint of the default identity provider answers within timeout.
+// Endpoint is reachable as long as it does not answer with a 5xx status, refusing the empty request is expected
+func PingAuthenticationEndpoint(env *models.Env, timeout time.Duration) error {
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	req, err := http.NewRequest("HEAD", env.Config.AuthenticationCheckEndpoint, nil)
+
+	if err != nil {
+		return err
+	}
+
+	res, err := getHTTPClient(env.Config.AuthHTTPClient).Do(req.WithContext(ctx))
+
+	if err != nil {
+		return err
+	}
+
+	ioutil.ReadAll(res.Body)
+	res.Body.Close()
+
+	if res.StatusCode >= 500 {
+		return fmt.Errorf("Authentication endpoint answered %d", res.StatusCode)
+	}
+
+	return nil
+}
@@ -0,0 +1,133 @@
+package auth
+
+import (
+	errors "errors"
+	fmt "fmt"
+	strings "strings"
+	models "wave-messaging-management-service/models"
+
+	logruswrapper "github.com/terryvogelsang/logruswrapper"
+)
+
+var (
+	// userIDEscaper : Percent-encode namespace separators of user IDs, so that e.g. user "b/c" of tenant "a" is not user "c" of tenant "a/b"
+	userIDEscaper = strings.NewReplacer("%", "%25", "/", "%2F", ":", "%3A")
+)
+
+// SelectIdentityProvider : Return identity provider of token and token to verify with it.
+// Provider is chosen by name when given, by token prefix otherwise (Prefix is stripped), default provider is used as last resort
+func SelectIdentityProvider(config *models.Config, name string, token string) (*models.IdentityProviderConfig, string, error) {
+
+	if name != "" {
+
+		for _, identityProvider := range config.IdentityProviders {
+			if identityProvider.Name == name {
+				return identityProvider, strings.TrimPrefix(token, identityProvider.TokenPrefix), nil
+			}
+		}
+
+		return nil, "", fmt.Errorf("Unknown identity provider %s", name)
+	}
+
+	for _, identityProvider := range config.IdentityProviders {
+		if identityProvider.TokenPrefix != "" && strings.HasPrefix(token, identityProvider.TokenPrefix) {
+			return identityProvider, strings.TrimPrefix(token, identityProvider.TokenPrefix), nil
+		}
+	}
+
+	return config.DefaultIdentityProvider(), token, nil
+}
+
+// ValidateIdentityProviders : Check settings of identity providers (Default one included) and tenants of service identities,
+// so that the service refuses to start with an unsafe one
+func ValidateIdentityProviders(config *models.Config) error {
+
+	identityProviders := append([]*models.IdentityProviderConfig{config.DefaultIdentityProvider()}, config.IdentityProviders...)
+
+	for _, identityProvider := range identityProviders {
+
+		err := models.ValidateNamespace("identity provider name", identityProvider.Name)
+
+		if err != nil {
+			return err
+		}
+
+		err = models.ValidateNamespace("tenant ID", identityProvider.Tenant)
+
+		if err != nil {
+			return err
+		}
+
+		if identityProvider.AuthenticationMode != models.AuthenticationModeJWT {
+			continue
+		}
+
+		err = ValidateJWTConfig(&identityProvider.JWT)
+
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, identity := range config.TLS.ServiceIdentities {
+
+		err := models.ValidateNamespace("tenant ID", identity.TenantID)
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// NamespaceUserID : Prefix user IDs with their tenant ({tenantID}/...) and additional identity providers name ({name}:...),
+// so that users of different applications can't collide. Separators held by user IDs are escaped (See userIDEscaper), tenant IDs and names can't hold any
+func NamespaceUserID(identityProvider *models.IdentityProviderConfig, tenantID string, originalUserID string) string {
+
+	originalUserID = userIDEscaper.Replace(originalUserID)
+
+	if identityProvider.Name != "" {
+		originalUserID = identityProvider.Name + ":" + originalUserID
+	}
+
+	if tenantID != "" {
+		originalUserID = tenantID + "/" + originalUserID
+	}
+
+	return originalUserID
+}
+
+// namespaceVerifiedUser : Resolve tenant of verified user and namespace its user ID.
+// Tenant resolved by verifier must be a valid tenant ID (See ValidateNamespace), and match the identity provider one if set
+func namespaceVerifiedUser(identityProvider *models.IdentityProviderConfig, user *VerifiedUser) (*VerifiedUser, error) {
+
+	tenantID := user.TenantID
+
+	// Tenants name collections and prefix user IDs, verifiers can't resolve arbitrary ones
+	if models.ValidateNamespace("tenant ID", tenantID) != nil {
+		return nil, errors.New(logruswrapper.CodeInvalidToken)
+	}
+
+	if identityProvider.Tenant != "" {
+
+		if tenantID != "" && tenantID != identityProvider.Tenant {
+			return nil, errors.New(logruswrapper.CodeInvalidToken)
+		}
+
+		tenantID = identityProvider.Tenant
+	}
+
+	return &VerifiedUser{
+		OriginalUserID: NamespaceUserID(identityProvider, tenantID, user.OriginalUserID),
+		TenantID:       tenantID,
+	}, nil
+}
+
+// GetUserTenant : Return tenant of internalWaveUserID, empty for users of the default tenant
+func GetUserTenant(env *models.Env, internalWaveUserID string) string {
+
+	tenantID, _ := env.Redis.Get(env.TraceContext(), fmt.Sprintf("tenant:%s", internalWaveUserID))
+
+	return string(tenantID)
+}
@@ -0,0 +1,56 @@
+package auth
+
+import (
+	time "time"
+	models "wave-messaging-management-service/models"
+)
+
+// EnsureIndexes : Create indexes (And PostgreSQL tables) of the store unless they exist, recording the outcome for readiness checks
+func EnsureIndexes(env *models.Env) error {
+
+	err := env.Store.EnsureIndexes(env.TraceContext())
+
+	env.Indexes.Set(err)
+
+	return err
+}
+
+// StartIndexCreation : Create store indexes in background, then every indexes.retryInterval seconds until created.
+// The instance is not ready meanwhile, as queries would scan whole collections and duplicates could be written
+func StartIndexCreation(env *models.Env) {
+
+	env.Indexes = &models.IndexesStatus{}
+
+	create := func() {
+		env.Guard(func() {
+
+			// Retries stop once indexes are created
+			if env.Indexes.Err() == nil {
+				return
+			}
+
+			err := EnsureIndexes(env)
+
+			if err != nil {
+				env.Logger.WithError(err).Error("Failed to create store indexes")
+				return
+			}
+
+			env.Logger.Info("Store indexes created")
+		})
+	}
+
+	interval := func() time.Duration {
+
+		interval := env.Config.Indexes.RetryInterval
+
+		if interval <= 0 {
+			interval = models.DefaultIndexesRetryInterval
+		}
+
+		return time.Duration(interval) * time.Second
+	}
+
+	env.Workers.Go(create)
+	env.Workers.Every(interval, create)
+}
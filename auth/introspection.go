@@ -0,0 +1,125 @@
+package auth
+
+import (
+	json "encoding/json"
+	errors "errors"
+	fmt "fmt"
+	http "net/http"
+	url "net/url"
+	strings "strings"
+	models "wave-messaging-management-service/models"
+
+	logruswrapper "github.com/terryvogelsang/logruswrapper"
+)
+
+// IntrospectionVerifier : Verify OAuth2 access tokens with configured introspection endpoint (RFC 7662)
+type IntrospectionVerifier struct{}
+
+// IsLocal : Introspection endpoint is only called for tokens not cached yet
+func (verifier *IntrospectionVerifier) IsLocal() bool {
+	return false
+}
+
+// VerifyToken : Introspect token and return user (ID and tenant) of active tokens
+func (verifier *IntrospectionVerifier) VerifyToken(env *models.Env, identityProvider *models.IdentityProviderConfig, token string) (*VerifiedUser, error) {
+
+	config := identityProvider.Introspection
+
+	form := url.Values{}
+	form.Set("token", token)
+	form.Set("token_type_hint", "access_token")
+
+	// Execute request with shared HTTP client, retried on server errors
+	res, err := doWithRetry(env, func() (*http.Request, error) {
+
+		req, err := http.NewRequest("POST", config.Endpoint, strings.NewReader(form.Encode()))
+
+		if err != nil {
+			return nil, err
+		}
+
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		req.Header.Set("Accept", "application/json")
+		req.SetBasicAuth(url.QueryEscape(config.ClientID), url.QueryEscape(config.ClientSecret))
+
+		return req, nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer res.Body.Close()
+
+	// Server errors are endpoint failures, not invalid tokens
+	if res.StatusCode >= 500 {
+		return nil, fmt.Errorf("Introspection endpoint error : %s", res.Status)
+	}
+
+	if res.StatusCode != http.StatusOK {
+		return nil, errors.New(logruswrapper.CodeInvalidToken)
+	}
+
+	//=============================================================================
+	// Introspection endpoint returns the following for an active token :
+	//
+	// HTTP Status Code : 200 (OK)
+	// {"active": true, "sub": "userID", "scope": "scope1 scope2", "aud": ...}
+	//
+	// And {"active": false} for any invalid, expired or revoked token
+	//=============================================================================
+	claims := map[string]interface{}{}
+
+	err = json.NewDecoder(res.Body).Decode(&claims)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if active, _ := claims["active"].(bool); !active {
+		return nil, errors.New(logruswrapper.CodeInvalidToken)
+	}
+
+	if config.RequiredScope != "" && !hasScope(claims, config.RequiredScope) {
+		return nil, errors.New(logruswrapper.CodeInvalidToken)
+	}
+
+	if config.Audience != "" && !hasAudience(claims, config.Audience) {
+		return nil, errors.New(logruswrapper.CodeInvalidToken)
+	}
+
+	userIDClaim := config.UserIDClaim
+
+	if userIDClaim == "" {
+		userIDClaim = "sub"
+	}
+
+	originalUserID, ok := claims[userIDClaim].(string)
+
+	if !ok || originalUserID == "" {
+		return nil, errors.New(logruswrapper.CodeInvalidToken)
+	}
+
+	// Tenant claim is optional, users of identity provider tenant otherwise
+	tenantID := ""
+
+	if config.TenantClaim != "" {
+		tenantID, _ = claims[config.TenantClaim].(string)
+	}
+
+	return &VerifiedUser{OriginalUserID: originalUserID, TenantID: tenantID}, nil
+}
+
+// hasScope : Check space separated scope claim
+func hasScope(claims map[string]interface{}, scope string) bool {
+
+	scopes, _ := claims["scope"].(string)
+
+	for _, s := range strings.Fields(scopes) {
+		if s == scope {
+			return true
+		}
+	}
+
+	return false
+}
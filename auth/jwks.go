@@ -0,0 +1,230 @@
+package auth
+
+import (
+	ecdsa "crypto/ecdsa"
+	elliptic "crypto/elliptic"
+	rsa "crypto/rsa"
+	base64 "encoding/base64"
+	json "encoding/json"
+	errors "errors"
+	fmt "fmt"
+	big "math/big"
+	http "net/http"
+	sync "sync"
+	time "time"
+)
+
+const (
+	// DefaultJWKSRefreshInterval : Seconds after which JWKS is fetched again, used when none is configured
+	DefaultJWKSRefreshInterval = 3600
+
+	// jwksMinRefreshInterval : Unknown key IDs can't trigger more than one fetch per interval
+	jwksMinRefreshInterval = 30 * time.Second
+)
+
+// JWKS : Cached JSON Web Key Set
+type JWKS struct {
+	Client *http.Client
+
+	mutex     sync.Mutex
+	url       string
+	keys      map[string]interface{}
+	fetchedAt time.Time
+}
+
+// jwk : JSON Web Key (RFC 7517), RSA and EC public keys only
+type jwk struct {
+	Kid string `json:"kid"`
+	Kty string `json:"kty"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+var (
+	// jwksByURL : JWKS shared by all requests, by URL (One per identity provider)
+	jwksByURL      = map[string]*JWKS{}
+	jwksByURLMutex sync.Mutex
+)
+
+// getJWKS : Return shared JWKS of url
+func getJWKS(url string) *JWKS {
+
+	jwksByURLMutex.Lock()
+	defer jwksByURLMutex.Unlock()
+
+	set, ok := jwksByURL[url]
+
+	if !ok {
+		set = &JWKS{
+			Client: &http.Client{Timeout: 10 * time.Second},
+			keys:   map[string]interface{}{},
+		}
+		jwksByURL[url] = set
+	}
+
+	return set
+}
+
+// Key : Return public key identified by kid.
+// Key set is fetched again when refresh interval elapsed or when kid is unknown (Key rotation)
+func (set *JWKS) Key(url string, refreshInterval int, kid string) (interface{}, error) {
+
+	set.mutex.Lock()
+	defer set.mutex.Unlock()
+
+	if refreshInterval <= 0 {
+		refreshInterval = DefaultJWKSRefreshInterval
+	}
+
+	// Configured URL changed : Forget keys of previous set
+	if set.url != url {
+		set.url = url
+		set.keys = map[string]interface{}{}
+		set.fetchedAt = time.Time{}
+	}
+
+	age := time.Since(set.fetchedAt)
+	key, ok := set.keys[kid]
+
+	if age > time.Duration(refreshInterval)*time.Second || (!ok && age > jwksMinRefreshInterval) {
+
+		err := set.fetch()
+
+		if err != nil {
+
+			// Keep serving known keys if the JWKS endpoint is unavailable
+			if ok {
+				return key, nil
+			}
+
+			return nil, err
+		}
+
+		key, ok = set.keys[kid]
+	}
+
+	if !ok {
+		return nil, fmt.Errorf("Unknown JWT key ID %s", kid)
+	}
+
+	return key, nil
+}
+
+// fetch : Download and parse key set, must be called with mutex held
+func (set *JWKS) fetch() error {
+
+	res, err := set.Client.Get(set.url)
+
+	if err != nil {
+		return err
+	}
+
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("error fetching JWKS : status %d", res.StatusCode)
+	}
+
+	body := struct {
+		Keys []jwk `json:"keys"`
+	}{}
+
+	err = json.NewDecoder(res.Body).Decode(&body)
+
+	if err != nil {
+		return err
+	}
+
+	keys := map[string]interface{}{}
+
+	for _, k := range body.Keys {
+
+		// Skip encryption keys and unsupported key types
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+
+		key, err := k.publicKey()
+
+		if err != nil {
+			continue
+		}
+
+		keys[k.Kid] = key
+	}
+
+	set.keys = keys
+	set.fetchedAt = time.Now()
+
+	return nil
+}
+
+// publicKey : Convert JWK into crypto public key
+func (k *jwk) publicKey() (interface{}, error) {
+
+	switch k.Kty {
+
+	case "RSA":
+
+		n, err := decodeJWKInt(k.N)
+
+		if err != nil {
+			return nil, err
+		}
+
+		e, err := decodeJWKInt(k.E)
+
+		if err != nil {
+			return nil, err
+		}
+
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+
+	case "EC":
+
+		var curve elliptic.Curve
+
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("Unsupported JWK curve %s", k.Crv)
+		}
+
+		x, err := decodeJWKInt(k.X)
+
+		if err != nil {
+			return nil, err
+		}
+
+		y, err := decodeJWKInt(k.Y)
+
+		if err != nil {
+			return nil, err
+		}
+
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+	}
+
+	return nil, errors.New("Unsupported JWK key type")
+}
+
+// decodeJWKInt : Decode base64 URL encoded big endian integer
+func decodeJWKInt(s string) (*big.Int, error) {
+
+	data, err := base64.RawURLEncoding.DecodeString(s)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return new(big.Int).SetBytes(data), nil
+}
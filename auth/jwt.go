@@ -0,0 +1,185 @@
+package auth
+
+import (
+	errors "errors"
+	fmt "fmt"
+	ioutil "io/ioutil"
+	strings "strings"
+	sync "sync"
+	models "wave-messaging-management-service/models"
+
+	jwt "github.com/dgrijalva/jwt-go"
+)
+
+const (
+	// MinJWTSecretLength : Minimum length in bytes of HMAC secrets, as long as the SHA-256 output so that secrets can't be brute-forced
+	MinJWTSecretLength = 32
+)
+
+var (
+	// Verification keys loaded from PEM files, by file path
+	jwtKeys      = map[string]interface{}{}
+	jwtKeysMutex sync.Mutex
+)
+
+// JWTVerifier : Validate tokens locally as JWTs
+type JWTVerifier struct{}
+
+// IsLocal : JWTs are validated on every request so that expired tokens are refused even when cached
+func (verifier *JWTVerifier) IsLocal() bool {
+	return true
+}
+
+// VerifyToken : Validate token against configured JWT settings
+func (verifier *JWTVerifier) VerifyToken(env *models.Env, identityProvider *models.IdentityProviderConfig, token string) (*VerifiedUser, error) {
+	return ValidateJWT(&identityProvider.JWT, token)
+}
+
+// ValidateJWT : Validate token locally as a JWT (signature, expiry, audience, issuer).
+// Return original user ID and tenant held by the configured claims
+func ValidateJWT(config *models.JWTConfig, token string) (*VerifiedUser, error) {
+
+	parsedToken, err := jwt.Parse(token, func(parsedToken *jwt.Token) (interface{}, error) {
+
+		// Only accept configured algorithm, prevents algorithm substitution (e.g. none or HS256 with public key)
+		if parsedToken.Method.Alg() != config.Algorithm {
+			return nil, fmt.Errorf("Unexpected signing algorithm %s", parsedToken.Method.Alg())
+		}
+
+		return jwtVerificationKey(config, parsedToken)
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	claims, ok := parsedToken.Claims.(jwt.MapClaims)
+
+	if !ok || !parsedToken.Valid {
+		return nil, errors.New("Invalid JWT")
+	}
+
+	// Expiry is verified by parser when present, but tokens must not live forever
+	if _, ok := claims["exp"]; !ok {
+		return nil, errors.New("JWT has no expiry")
+	}
+
+	if config.Audience != "" && !hasAudience(claims, config.Audience) {
+		return nil, errors.New("Invalid JWT audience")
+	}
+
+	if config.Issuer != "" && !claims.VerifyIssuer(config.Issuer, true) {
+		return nil, errors.New("Invalid JWT issuer")
+	}
+
+	userIDClaim := config.UserIDClaim
+
+	if userIDClaim == "" {
+		userIDClaim = "sub"
+	}
+
+	originalUserID, ok := claims[userIDClaim].(string)
+
+	if !ok || originalUserID == "" {
+		return nil, fmt.Errorf("JWT has no %s claim", userIDClaim)
+	}
+
+	// Tenant claim is optional, users of identity provider tenant otherwise
+	tenantID := ""
+
+	if config.TenantClaim != "" {
+		tenantID, _ = claims[config.TenantClaim].(string)
+	}
+
+	return &VerifiedUser{OriginalUserID: originalUserID, TenantID: tenantID}, nil
+}
+
+// hasAudience : Check aud claim, which may either be a string or an array of strings
+func hasAudience(claims map[string]interface{}, audience string) bool {
+
+	switch aud := claims["aud"].(type) {
+
+	case string:
+		return aud == audience
+
+	case []interface{}:
+		for _, a := range aud {
+			if s, ok := a.(string); ok && s == audience {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// ValidateJWTConfig : Check that HMAC algorithms are given a secret of at least MinJWTSecretLength bytes,
+// as an empty secret would let anyone sign tokens
+func ValidateJWTConfig(config *models.JWTConfig) error {
+
+	if strings.HasPrefix(config.Algorithm, "HS") && len(config.Secret) < MinJWTSecretLength {
+		return fmt.Errorf("JWT secret of %s must be at least %d bytes long", config.Algorithm, MinJWTSecretLength)
+	}
+
+	return nil
+}
+
+// jwtVerificationKey : Return key verifying configured algorithm signatures
+func jwtVerificationKey(config *models.JWTConfig, parsedToken *jwt.Token) (interface{}, error) {
+
+	// HMAC : Shared secret, refused when missing or too short
+	if strings.HasPrefix(config.Algorithm, "HS") {
+
+		err := ValidateJWTConfig(config)
+
+		if err != nil {
+			return nil, err
+		}
+
+		return []byte(config.Secret), nil
+	}
+
+	// JWKS : Key identified by token kid header
+	if config.JWKSURL != "" {
+
+		kid, ok := parsedToken.Header["kid"].(string)
+
+		if !ok {
+			return nil, errors.New("JWT has no key ID")
+		}
+
+		return getJWKS(config.JWKSURL).Key(config.JWKSURL, config.JWKSRefreshInterval, kid)
+	}
+
+	jwtKeysMutex.Lock()
+	defer jwtKeysMutex.Unlock()
+
+	if key, ok := jwtKeys[config.PublicKeyFile]; ok {
+		return key, nil
+	}
+
+	data, err := ioutil.ReadFile(config.PublicKeyFile)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var key interface{}
+
+	switch {
+	case strings.HasPrefix(config.Algorithm, "RS"), strings.HasPrefix(config.Algorithm, "PS"):
+		key, err = jwt.ParseRSAPublicKeyFromPEM(data)
+	case strings.HasPrefix(config.Algorithm, "ES"):
+		key, err = jwt.ParseECPublicKeyFromPEM(data)
+	default:
+		err = fmt.Errorf("Unsupported signing algorithm %s", config.Algorithm)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	jwtKeys[config.PublicKeyFile] = key
+
+	return key, nil
+}
@@ -0,0 +1,152 @@
+package auth
+
+import (
+	context "context"
+	fmt "fmt"
+	strings "strings"
+	models "wave-messaging-management-service/models"
+	memory "wave-messaging-management-service/models/memory"
+)
+
+const (
+	// createMappingScript : Map original user (KEYS[1]) with internal Wave user ID ARGV[2] and token ARGV[1], unless it is already mapped.
+	// Return internal Wave user ID and token of the mapping, the existing ones when a concurrent authentication mapped the user first
+	createMappingScript = `
+local mapping = redis.call('HMGET', KEYS[1], 'internalWaveUserID', 'token')
+if mapping[1] then
+  return {mapping[1], mapping[2] or ''}
+end
+redis.call('HSET', KEYS[1], 'token', ARGV[1], 'internalWaveUserID', ARGV[2])
+return {ARGV[2], ARGV[1]}
+`
+
+	// internalWaveUserIDsScript : Return internal Wave user ID of every mapping key, empty for keys which do not exist.
+	// Existence and internal Wave user ID are read at once, so that a mapping removed meanwhile is never half read
+	internalWaveUserIDsScript = `
+local ids = {}
+for i, key in ipairs(KEYS) do
+  ids[i] = redis.call('HGET', key, 'internalWaveUserID') or ''
+end
+return ids
+`
+)
+
+// createMapping : Map originalUserID with newInternalWaveUserID and token, unless it is already mapped (Check-and-set).
+// Return internal Wave user ID and token of the mapping, and whether it was created
+func createMapping(env *models.Env, originalUserID string, token string, newInternalWaveUserID string) (string, string, bool, error) {
+
+	mapping, err := env.Redis.EvalStrings(env.TraceContext(), createMappingScript, []string{fmt.Sprintf("mapping:%s", originalUserID)}, token, newInternalWaveUserID)
+
+	if err != nil {
+		return "", "", false, err
+	}
+
+	if len(mapping) != 2 {
+		return "", "", false, fmt.Errorf("unexpected mapping of %s : %v", originalUserID, mapping)
+	}
+
+	// Reverse index is written apart from the script, its key living in another hash slot than the mapping on a Redis Cluster.
+	// Mappings created by a concurrent authentication are indexed again, which is harmless
+	err = indexMapping(env, originalUserID, mapping[0])
+
+	if err != nil {
+		return "", "", false, err
+	}
+
+	return mapping[0], mapping[1], mapping[0] == newInternalWaveUserID, nil
+}
+
+// mappingIndexKey : Key of the reverse index of the mapping of internalWaveUserID, holding its original user ID
+func mappingIndexKey(internalWaveUserID string) string {
+	return fmt.Sprintf("user:%s", internalWaveUserID)
+}
+
+// indexMapping : Write reverse index of the mapping of originalUserID with internalWaveUserID (See FindMapping)
+func indexMapping(env *models.Env, originalUserID string, internalWaveUserID string) error {
+	return env.Redis.Set(env.TraceContext(), mappingIndexKey(internalWaveUserID), []byte(originalUserID))
+}
+
+// IndexMappings : Write reverse index of every mapping, the ones created before the index included. Return the number of mappings indexed
+func IndexMappings(env *models.Env) (int, error) {
+
+	mappingKeys, err := env.Redis.GetKeys(env.TraceContext(), "mapping:*")
+
+	if err != nil {
+		return 0, err
+	}
+
+	internalWaveUserIDs, err := env.Redis.BatchHGet(env.TraceContext(), mappingKeys, "internalWaveUserID")
+
+	if err != nil {
+		return 0, err
+	}
+
+	indexed := 0
+
+	for i, mappingKey := range mappingKeys {
+
+		if len(internalWaveUserIDs[i]) == 0 {
+			continue
+		}
+
+		err = indexMapping(env, strings.TrimPrefix(mappingKey, "mapping:"), string(internalWaveUserIDs[i]))
+
+		if err != nil {
+			return indexed, err
+		}
+
+		indexed++
+	}
+
+	return indexed, nil
+}
+
+// GetInternalWaveUserIDs : Return internal Wave user IDs of mapping keys, in order of keys and empty for unknown users.
+// Mappings are read atomically, in a single round trip on a single node (By hash slot on a Redis Cluster, scripts being pipelined by node)
+func GetInternalWaveUserIDs(env *models.Env, mappingKeys []string) ([]string, error) {
+
+	if len(mappingKeys) == 0 {
+		return []string{}, nil
+	}
+
+	return env.Redis.EvalStrings(env.TraceContext(), internalWaveUserIDsScript, mappingKeys)
+}
+
+// HandleMappingScripts : Register Go implementations of the mapping scripts on redis, so that authentication and mappings lookups
+// can be tested with memory.Redis (See Test Doubles)
+func HandleMappingScripts(redis *memory.Redis) {
+
+	redis.HandleStringsScript(createMappingScript, func(redis *memory.Redis, keys []string, args ...interface{}) ([]string, error) {
+
+		internalWaveUserID, err := redis.HGet(context.Background(), keys[0], "internalWaveUserID")
+
+		if err == nil {
+
+			token, _ := redis.HGet(context.Background(), keys[0], "token")
+
+			return []string{string(internalWaveUserID), string(token)}, nil
+		}
+
+		token, newInternalWaveUserID := fmt.Sprint(args[0]), fmt.Sprint(args[1])
+
+		err = redis.HSet(context.Background(), keys[0], "token", []byte(token), "internalWaveUserID", []byte(newInternalWaveUserID))
+
+		if err != nil {
+			return nil, err
+		}
+
+		return []string{newInternalWaveUserID, token}, nil
+	})
+
+	redis.HandleStringsScript(internalWaveUserIDsScript, func(redis *memory.Redis, keys []string, args ...interface{}) ([]string, error) {
+
+		internalWaveUserIDs := make([]string, len(keys))
+
+		for i, key := range keys {
+			internalWaveUserID, _ := redis.HGet(context.Background(), key, "internalWaveUserID")
+			internalWaveUserIDs[i] = string(internalWaveUserID)
+		}
+
+		return internalWaveUserIDs, nil
+	})
+}
@@ -0,0 +1,146 @@
+package auth
+
+import (
+	errors "errors"
+	time "time"
+	models "wave-messaging-management-service/models"
+)
+
+var (
+	// ErrMigrationLeased : Migration is being applied by another instance (Or was applied since migrations were looked up), later migrations wait for it
+	ErrMigrationLeased = errors.New("Migration being applied by another instance")
+
+	// migrations : Schema migrations, by increasing version. Versions are never reused nor reordered once released
+	migrations = []*Migration{
+		{
+			Version: 1,
+			Name:    "dedup-acl-patterns",
+			Up: func(env *models.Env) error {
+
+				deduplicated, err := env.Store.DedupACLPatterns(env.TraceContext())
+
+				if err != nil {
+					return err
+				}
+
+				env.Logger.WithField("acls", deduplicated).Info("ACL patterns deduplicated")
+
+				return nil
+			},
+		},
+		{
+			Version: 2,
+			Name:    "index-mappings",
+			Up: func(env *models.Env) error {
+
+				indexed, err := IndexMappings(env)
+
+				if err != nil {
+					return err
+				}
+
+				env.Logger.WithField("mappings", indexed).Info("Mappings indexed")
+
+				return nil
+			},
+		},
+	}
+)
+
+// Migration : Versioned schema change, applied once by a single instance, after every migration of a lower version.
+// Up must be safe to run again, as migrations of stopped instances are applied again once their lease expired
+type Migration struct {
+	Version int
+	Name    string
+	Up      func(env *models.Env) error
+}
+
+// MigrationReport : Outcome of a migrations run, with names of the migrations it applied and of those left pending
+type MigrationReport struct {
+	Applied []string `json:"applied"`
+	Pending []string `json:"pending"`
+}
+
+// RunMigrations : Apply pending migrations in version order, stopping at the first one failing or leased by another instance (ErrMigrationLeased).
+// The report lists migrations left pending on failure
+func RunMigrations(env *models.Env) (*MigrationReport, error) {
+
+	report := &MigrationReport{Applied: []string{}, Pending: []string{}}
+
+	records, err := env.Store.GetMigrations(env.TraceContext())
+
+	if err != nil {
+
+		for _, migration := range migrations {
+			report.Pending = append(report.Pending, migration.Name)
+		}
+
+		return report, err
+	}
+
+	applied := map[int]bool{}
+
+	for _, record := range records {
+		if record.AppliedAt != nil {
+			applied[record.Version] = true
+		}
+	}
+
+	for index, migration := range migrations {
+
+		if applied[migration.Version] {
+			continue
+		}
+
+		err = runMigration(env, migration)
+
+		if err != nil {
+
+			for _, pending := range migrations[index:] {
+				if !applied[pending.Version] {
+					report.Pending = append(report.Pending, pending.Name)
+				}
+			}
+
+			return report, err
+		}
+
+		report.Applied = append(report.Applied, migration.Name)
+	}
+
+	return report, nil
+}
+
+// runMigration : Claim migration for the configured lease timeout, apply it and record it as applied
+func runMigration(env *models.Env, migration *Migration) error {
+
+	leaseTimeout := env.Config.Migrations.LeaseTimeout
+
+	if leaseTimeout <= 0 {
+		leaseTimeout = models.DefaultMigrationsLeaseTimeout
+	}
+
+	now := time.Now().UTC()
+
+	claimed, err := env.Store.ClaimMigration(env.TraceContext(), migration.Version, migration.Name, now, now.Add(time.Duration(leaseTimeout)*time.Second))
+
+	if err != nil {
+		return err
+	}
+
+	if !claimed {
+		return ErrMigrationLeased
+	}
+
+	log := env.Logger.WithField("version", migration.Version).WithField("migration", migration.Name)
+
+	log.Info("Applying migration")
+
+	err = migration.Up(env)
+
+	if err != nil {
+		return err
+	}
+
+	return env.Store.CompleteMigration(env.TraceContext(), migration.Version, time.Now().UTC())
+}
@@ -0,0 +1,93 @@
+package auth
+
+import (
+	tls "crypto/tls"
+	x509 "crypto/x509"
+	errors "errors"
+	ioutil "io/ioutil"
+	models "wave-messaging-management-service/models"
+
+	logruswrapper "github.com/terryvogelsang/logruswrapper"
+	acme "golang.org/x/crypto/acme"
+	autocert "golang.org/x/crypto/acme/autocert"
+)
+
+// NewServerTLSConfig : Return management API TLS config, with configured minimum version and cipher suites.
+// Server certificates are obtained by certManager when set (See NewCertManager), read from certificate files otherwise.
+// Client certificates are verified against ClientCAFile when set, and only mandatory with RequireClientCert,
+// so that user endpoints keep working with header tokens otherwise
+func NewServerTLSConfig(config *models.TLSConfig, certManager *autocert.Manager) (*tls.Config, error) {
+
+	minVersion, err := parseTLSVersion(config.MinVersion)
+
+	if err != nil {
+		return nil, err
+	}
+
+	cipherSuites, err := parseCipherSuites(config.CipherSuites)
+
+	if err != nil {
+		return nil, err
+	}
+
+	tlsConfig := &tls.Config{
+		MinVersion:   minVersion,
+		CipherSuites: cipherSuites,
+	}
+
+	if certManager != nil {
+		tlsConfig.GetCertificate = certManager.GetCertificate
+		tlsConfig.NextProtos = []string{"h2", "http/1.1", acme.ALPNProto}
+	}
+
+	if config.ClientCAFile == "" {
+		return tlsConfig, nil
+	}
+
+	data, err := ioutil.ReadFile(config.ClientCAFile)
+
+	if err != nil {
+		return nil, err
+	}
+
+	clientCAs := x509.NewCertPool()
+
+	if !clientCAs.AppendCertsFromPEM(data) {
+		return nil, errors.New("No client CA certificate found in " + config.ClientCAFile)
+	}
+
+	tlsConfig.ClientCAs = clientCAs
+	tlsConfig.ClientAuth = tls.VerifyClientCertIfGiven
+
+	if config.RequireClientCert {
+		tlsConfig.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+
+	return tlsConfig, nil
+}
+
+// CheckClientCertificate : Return service identity matching verified client certificate subject if it was granted scope
+func CheckClientCertificate(env *models.Env, state *tls.ConnectionState, scope string) (*models.ServiceIdentity, error) {
+
+	// Only certificates verified against client CAs are considered
+	if state == nil || len(state.VerifiedChains) == 0 || len(state.VerifiedChains[0]) == 0 {
+		return nil, errors.New("No Client Certificate Provided")
+	}
+
+	certificate := state.VerifiedChains[0][0]
+
+	for _, identity := range env.Config.TLS.ServiceIdentities {
+
+		if identity.Subject != certificate.Subject.CommonName && identity.Subject != certificate.Subject.String() {
+			continue
+		}
+
+		if !identity.HasScope(scope) {
+			return nil, ErrMissingScope
+		}
+
+		return identity, nil
+	}
+
+	return nil, errors.New(logruswrapper.CodeInvalidToken)
+}
@@ -0,0 +1,147 @@
+package auth
+
+import (
+	time "time"
+	models "wave-messaging-management-service/models"
+
+	logrus "github.com/sirupsen/logrus"
+)
+
+// LifecycleEvents : Return lifecycle events of audit entries in the environment tenant and request, to be written in the outbox
+// along with the mutation they stem from. Entries are audited once the mutation succeeded (See Audit), actions without events are skipped
+func LifecycleEvents(env *models.Env, entries ...*models.AuditEntry) []*models.LifecycleEvent {
+
+	events := []*models.LifecycleEvent{}
+
+	for _, entry := range entries {
+
+		entry.TenantID = env.TenantID
+
+		if entry.RequestID == "" {
+			entry.RequestID = env.RequestID
+		}
+
+		if event := models.NewLifecycleEvent(entry); event != nil {
+			events = append(events, event)
+		}
+	}
+
+	return events
+}
+
+// StartOutboxRelay : Relay outbox events every configured poll interval, in background until workers are stopped
+func StartOutboxRelay(env *models.Env) {
+
+	interval := func() time.Duration {
+
+		interval := env.Config.Outbox.PollInterval
+
+		if interval <= 0 {
+			interval = models.DefaultOutboxPollInterval
+		}
+
+		return time.Duration(interval) * time.Second
+	}
+
+	env.Workers.Every(interval, func() {
+		env.Guard(func() {
+
+			err := RelayOutbox(env)
+
+			if err != nil {
+				env.Logger.WithError(err).Error("Failed to relay outbox events")
+			}
+		})
+	})
+}
+
+// RelayOutbox : Relay outbox events of all tenants, oldest first, a batch at a time until none are left or workers are stopped.
+// Events are produced to the lifecycle events transport and queued for outbound webhooks, then removed from the outbox.
+// Events whose relay failed are relayed again once their lease expires : events are relayed at least once
+func RelayOutbox(env *models.Env) error {
+
+	batchSize := env.Config.Outbox.BatchSize
+
+	if batchSize <= 0 {
+		batchSize = models.DefaultOutboxBatchSize
+	}
+
+	leaseTimeout := env.Config.Outbox.LeaseTimeout
+
+	if leaseTimeout <= 0 {
+		leaseTimeout = models.DefaultOutboxLeaseTimeout
+	}
+
+	for {
+
+		now := time.Now().UTC()
+
+		outboxEvents, err := env.Store.GetOutboxEvents(env.TraceContext(), now, batchSize)
+
+		if err != nil {
+			return err
+		}
+
+		// Events are relayed in order, so that consumers get events of a conversation in the order of their mutations
+		for _, outboxEvent := range outboxEvents {
+
+			claimed, err := env.Store.ClaimOutboxEvent(env.TraceContext(), outboxEvent.ID, now, now.Add(time.Duration(leaseTimeout)*time.Second))
+
+			if err != nil {
+				return err
+			}
+
+			if !claimed {
+				continue
+			}
+
+			result := "relayed"
+
+			err = relayOutboxEvent(env, outboxEvent)
+
+			if err != nil {
+				result = "failed"
+				env.Logger.WithError(err).WithFields(logrus.Fields{"eventID": outboxEvent.ID, "type": outboxEvent.EventType}).Error("Failed to relay outbox event")
+			}
+
+			models.OutboxEvents.WithLabelValues(outboxEvent.EventType, result).Inc()
+		}
+
+		if len(outboxEvents) < batchSize {
+			return nil
+		}
+
+		select {
+		case <-env.Workers.Stopping():
+			return nil
+		default:
+		}
+	}
+}
+
+// relayOutboxEvent : Produce event of outboxEvent and queue its webhook deliveries, then remove it from the outbox
+func relayOutboxEvent(env *models.Env, outboxEvent *models.OutboxEvent) error {
+
+	event, err := outboxEvent.LifecycleEvent()
+
+	if err != nil {
+		return err
+	}
+
+	if env.Producer != nil {
+
+		err = env.Producer.Produce(event)
+
+		if err != nil {
+			return err
+		}
+	}
+
+	err = DeliverWebhooks(env.ForTenant(event.TenantID), event)
+
+	if err != nil {
+		return err
+	}
+
+	return env.Store.RemoveOutboxEvent(env.TraceContext(), outboxEvent.ID)
+}
@@ -0,0 +1,389 @@
+package auth
+
+import (
+	rand "crypto/rand"
+	sha256 "crypto/sha256"
+	sha512 "crypto/sha512"
+	subtle "crypto/subtle"
+	base64 "encoding/base64"
+	errors "errors"
+	fmt "fmt"
+	hash "hash"
+	strings "strings"
+	models "wave-messaging-management-service/models"
+
+	argon2 "golang.org/x/crypto/argon2"
+	bcrypt "golang.org/x/crypto/bcrypt"
+	pbkdf2 "golang.org/x/crypto/pbkdf2"
+)
+
+const (
+	// PasshashAlgorithmBcrypt : bcrypt passhashes ($2a$...), Default
+	PasshashAlgorithmBcrypt = "bcrypt"
+
+	// PasshashAlgorithmArgon2id : argon2id passhashes, PHC string format ($argon2id$v=19$m=...,t=...,p=...$salt$hash)
+	PasshashAlgorithmArgon2id = "argon2id"
+
+	// PasshashAlgorithmPBKDF2 : PBKDF2 passhashes, PHC string format ($pbkdf2-sha256$i=...$salt$hash)
+	PasshashAlgorithmPBKDF2 = "pbkdf2"
+
+	// DefaultBcryptCost : bcrypt cost, used when none is configured
+	DefaultBcryptCost = 14
+
+	// DefaultArgon2Time : argon2id passes over memory, used when none is configured
+	DefaultArgon2Time = 3
+
+	// DefaultArgon2Memory : argon2id memory in KiB, used when none is configured
+	DefaultArgon2Memory = 64 * 1024
+
+	// DefaultArgon2Threads : argon2id parallelism, used when none is configured
+	DefaultArgon2Threads = 2
+
+	// DefaultPBKDF2Iterations : PBKDF2 iterations, used when none is configured
+	DefaultPBKDF2Iterations = 100000
+
+	// DefaultPBKDF2Hash : PBKDF2 pseudorandom function, used when none is configured
+	DefaultPBKDF2Hash = "sha256"
+
+	// DefaultPasshashSaltLength : argon2id & PBKDF2 salt length in bytes, used when none is configured
+	DefaultPasshashSaltLength = 16
+
+	// DefaultPasshashKeyLength : argon2id & PBKDF2 derived key length in bytes, used when none is configured
+	DefaultPasshashKeyLength = 32
+)
+
+var (
+	// ErrMalformedPasshash : Returned when a passhash can't be parsed
+	ErrMalformedPasshash = errors.New("Malformed passhash")
+
+	// passhashEncoding : Salts and hashes encoding in PHC strings
+	passhashEncoding = base64.RawStdEncoding
+)
+
+// HashPassword : Hash password with configured algorithm (bcrypt by default)
+func HashPassword(config models.PasshashConfig, password string) (string, error) {
+
+	switch config.Algorithm {
+
+	case "", PasshashAlgorithmBcrypt:
+		bytes, err := bcrypt.GenerateFromPassword([]byte(password), intOrDefault(config.Cost, DefaultBcryptCost))
+		return string(bytes), err
+
+	case PasshashAlgorithmArgon2id:
+		return hashArgon2id(config, password)
+
+	case PasshashAlgorithmPBKDF2:
+		return hashPBKDF2(config, password)
+
+	default:
+		return "", fmt.Errorf("Unknown passhash algorithm %s", config.Algorithm)
+	}
+}
+
+// VerifyPassword : Check password against passhash, whatever algorithm it was generated with
+func VerifyPassword(passhash string, password string) (bool, error) {
+
+	switch passhashAlgorithm(passhash) {
+
+	case PasshashAlgorithmBcrypt:
+
+		err := bcrypt.CompareHashAndPassword([]byte(passhash), []byte(password))
+
+		if err == bcrypt.ErrMismatchedHashAndPassword {
+			return false, nil
+		}
+
+		return err == nil, err
+
+	case PasshashAlgorithmArgon2id:
+		return verifyArgon2id(passhash, password)
+
+	case PasshashAlgorithmPBKDF2:
+		return verifyPBKDF2(passhash, password)
+
+	default:
+		return false, ErrMalformedPasshash
+	}
+}
+
+// passhashAlgorithm : Return algorithm passhash was generated with, an empty string if unknown
+func passhashAlgorithm(passhash string) string {
+
+	switch {
+
+	case strings.HasPrefix(passhash, "$2a$"), strings.HasPrefix(passhash, "$2b$"), strings.HasPrefix(passhash, "$2y$"):
+		return PasshashAlgorithmBcrypt
+
+	case strings.HasPrefix(passhash, "$argon2id$"):
+		return PasshashAlgorithmArgon2id
+
+	case strings.HasPrefix(passhash, "$pbkdf2-"):
+		return PasshashAlgorithmPBKDF2
+
+	default:
+		return ""
+	}
+}
+
+// NeedsRehash : Check if passhash was generated with another algorithm or other parameters than configured ones (Legacy passhash)
+func NeedsRehash(config models.PasshashConfig, passhash string) bool {
+
+	algorithm := config.Algorithm
+
+	if algorithm == "" {
+		algorithm = PasshashAlgorithmBcrypt
+	}
+
+	if passhashAlgorithm(passhash) != algorithm {
+		return true
+	}
+
+	switch algorithm {
+
+	case PasshashAlgorithmBcrypt:
+
+		cost, err := bcrypt.Cost([]byte(passhash))
+
+		return err != nil || cost != intOrDefault(config.Cost, DefaultBcryptCost)
+
+	case PasshashAlgorithmArgon2id:
+
+		params, err := parseArgon2id(passhash)
+
+		return err != nil ||
+			params.passes != uint32(intOrDefault(config.Time, DefaultArgon2Time)) ||
+			params.memory != uint32(intOrDefault(config.Memory, DefaultArgon2Memory)) ||
+			params.threads != uint8(intOrDefault(config.Threads, DefaultArgon2Threads)) ||
+			len(params.salt) != intOrDefault(config.SaltLength, DefaultPasshashSaltLength) ||
+			len(params.key) != intOrDefault(config.KeyLength, DefaultPasshashKeyLength)
+
+	case PasshashAlgorithmPBKDF2:
+
+		hashName := config.Hash
+
+		if hashName == "" {
+			hashName = DefaultPBKDF2Hash
+		}
+
+		params, err := parsePBKDF2(passhash)
+
+		return err != nil ||
+			params.hash != hashName ||
+			params.iterations != intOrDefault(config.Iterations, DefaultPBKDF2Iterations) ||
+			len(params.salt) != intOrDefault(config.SaltLength, DefaultPasshashSaltLength) ||
+			len(params.key) != intOrDefault(config.KeyLength, DefaultPasshashKeyLength)
+	}
+
+	return true
+}
+
+// hashArgon2id : Hash password with argon2id, encoded as PHC string
+func hashArgon2id(config models.PasshashConfig, password string) (string, error) {
+
+	salt, err := newSalt(intOrDefault(config.SaltLength, DefaultPasshashSaltLength))
+
+	if err != nil {
+		return "", err
+	}
+
+	passes := uint32(intOrDefault(config.Time, DefaultArgon2Time))
+	memory := uint32(intOrDefault(config.Memory, DefaultArgon2Memory))
+	threads := uint8(intOrDefault(config.Threads, DefaultArgon2Threads))
+	key := argon2.IDKey([]byte(password), salt, passes, memory, threads, uint32(intOrDefault(config.KeyLength, DefaultPasshashKeyLength)))
+
+	return fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s", argon2.Version, memory, passes, threads, passhashEncoding.EncodeToString(salt), passhashEncoding.EncodeToString(key)), nil
+}
+
+// verifyArgon2id : Check password against argon2id PHC string, with the parameters it holds
+func verifyArgon2id(passhash string, password string) (bool, error) {
+
+	params, err := parseArgon2id(passhash)
+
+	if err != nil {
+		return false, err
+	}
+
+	computedKey := argon2.IDKey([]byte(password), params.salt, params.passes, params.memory, params.threads, uint32(len(params.key)))
+
+	return subtle.ConstantTimeCompare(params.key, computedKey) == 1, nil
+}
+
+// argon2idParams : Parameters, salt and derived key held by an argon2id PHC string
+type argon2idParams struct {
+	memory  uint32
+	passes  uint32
+	threads uint8
+	salt    []byte
+	key     []byte
+}
+
+// parseArgon2id : Parse argon2id PHC string
+func parseArgon2id(passhash string) (*argon2idParams, error) {
+
+	// "", "argon2id", "v=19", "m=...,t=...,p=...", salt, hash
+	parts := strings.Split(passhash, "$")
+
+	if len(parts) != 6 {
+		return nil, ErrMalformedPasshash
+	}
+
+	var version int
+	params := &argon2idParams{}
+
+	_, err := fmt.Sscanf(parts[2], "v=%d", &version)
+
+	if err != nil || version != argon2.Version {
+		return nil, ErrMalformedPasshash
+	}
+
+	_, err = fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &params.memory, &params.passes, &params.threads)
+
+	if err != nil {
+		return nil, ErrMalformedPasshash
+	}
+
+	params.salt, params.key, err = decodeSaltAndKey(parts[4], parts[5])
+
+	if err != nil {
+		return nil, err
+	}
+
+	return params, nil
+}
+
+// hashPBKDF2 : Hash password with PBKDF2, encoded as PHC string
+func hashPBKDF2(config models.PasshashConfig, password string) (string, error) {
+
+	hashName := config.Hash
+
+	if hashName == "" {
+		hashName = DefaultPBKDF2Hash
+	}
+
+	hashFunc, err := pbkdf2Hash(hashName)
+
+	if err != nil {
+		return "", err
+	}
+
+	salt, err := newSalt(intOrDefault(config.SaltLength, DefaultPasshashSaltLength))
+
+	if err != nil {
+		return "", err
+	}
+
+	iterations := intOrDefault(config.Iterations, DefaultPBKDF2Iterations)
+	key := pbkdf2.Key([]byte(password), salt, iterations, intOrDefault(config.KeyLength, DefaultPasshashKeyLength), hashFunc)
+
+	return fmt.Sprintf("$pbkdf2-%s$i=%d$%s$%s", hashName, iterations, passhashEncoding.EncodeToString(salt), passhashEncoding.EncodeToString(key)), nil
+}
+
+// verifyPBKDF2 : Check password against PBKDF2 PHC string, with the parameters it holds
+func verifyPBKDF2(passhash string, password string) (bool, error) {
+
+	params, err := parsePBKDF2(passhash)
+
+	if err != nil {
+		return false, err
+	}
+
+	hashFunc, err := pbkdf2Hash(params.hash)
+
+	if err != nil {
+		return false, err
+	}
+
+	computedKey := pbkdf2.Key([]byte(password), params.salt, params.iterations, len(params.key), hashFunc)
+
+	return subtle.ConstantTimeCompare(params.key, computedKey) == 1, nil
+}
+
+// pbkdf2Params : Parameters, salt and derived key held by a PBKDF2 PHC string
+type pbkdf2Params struct {
+	hash       string
+	iterations int
+	salt       []byte
+	key        []byte
+}
+
+// parsePBKDF2 : Parse PBKDF2 PHC string
+func parsePBKDF2(passhash string) (*pbkdf2Params, error) {
+
+	// "", "pbkdf2-{hash}", "i=...", salt, hash
+	parts := strings.Split(passhash, "$")
+
+	if len(parts) != 5 {
+		return nil, ErrMalformedPasshash
+	}
+
+	params := &pbkdf2Params{hash: strings.TrimPrefix(parts[1], "pbkdf2-")}
+
+	_, err := fmt.Sscanf(parts[2], "i=%d", &params.iterations)
+
+	if err != nil || params.iterations <= 0 {
+		return nil, ErrMalformedPasshash
+	}
+
+	params.salt, params.key, err = decodeSaltAndKey(parts[3], parts[4])
+
+	if err != nil {
+		return nil, err
+	}
+
+	return params, nil
+}
+
+// pbkdf2Hash : Return PBKDF2 pseudorandom function hash by name
+func pbkdf2Hash(name string) (func() hash.Hash, error) {
+
+	switch name {
+	case "sha256":
+		return sha256.New, nil
+	case "sha512":
+		return sha512.New, nil
+	default:
+		return nil, fmt.Errorf("Unknown PBKDF2 hash %s", name)
+	}
+}
+
+// decodeSaltAndKey : Decode salt and derived key of a PHC string
+func decodeSaltAndKey(encodedSalt string, encodedKey string) ([]byte, []byte, error) {
+
+	salt, err := passhashEncoding.DecodeString(encodedSalt)
+
+	if err != nil {
+		return nil, nil, ErrMalformedPasshash
+	}
+
+	key, err := passhashEncoding.DecodeString(encodedKey)
+
+	if err != nil || len(key) == 0 {
+		return nil, nil, ErrMalformedPasshash
+	}
+
+	return salt, key, nil
+}
+
+// newSalt : Return length random bytes
+func newSalt(length int) ([]byte, error) {
+
+	salt := make([]byte, length)
+
+	_, err := rand.Read(salt)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return salt, nil
+}
+
+// intOrDefault : Return value, defaultValue if not set
+func intOrDefault(value int, defaultValue int) int {
+
+	if value <= 0 {
+		return defaultValue
+	}
+
+	return value
+}
@@ -0,0 +1,135 @@
+package auth
+
+import (
+	fmt "fmt"
+	strings "strings"
+	models "wave-messaging-management-service/models"
+)
+
+// PasshashMigrationReport : Outcome of a batch passhash migration
+type PasshashMigrationReport struct {
+	Users    int `json:"users"`
+	Upgraded int `json:"upgraded"`
+	Failed   int `json:"failed"`
+}
+
+// UpgradePasshash : Rehash legacy passhash of internalWaveUserID ACLs with configured algorithm.
+// Passhashes are hashes of the user token, so they can only be upgraded with a token still matching them.
+// Return true if passhash was upgraded
+func UpgradePasshash(env *models.Env, internalWaveUserID string, token string) (bool, error) {
+
+	config := env.Config.Passhash
+
+	verneMQACLs, err := env.Store.GetUserACLs(env.TraceContext(), internalWaveUserID)
+
+	if err != nil {
+		return false, err
+	}
+
+	for _, verneMQACL := range verneMQACLs {
+
+		if !NeedsRehash(config, verneMQACL.Passhash) {
+			continue
+		}
+
+		// Rotated or unknown passhashes are left untouched
+		matches, err := VerifyPassword(verneMQACL.Passhash, token)
+
+		if err != nil || !matches {
+			continue
+		}
+
+		passhash, err := HashPassword(config, token)
+
+		if err != nil {
+			return false, err
+		}
+
+		// Devices share profile passhash, so all of them are upgraded at once
+		err = updatePassHash(env, internalWaveUserID, passhash)
+
+		if err != nil {
+			return false, err
+		}
+
+		return true, nil
+	}
+
+	return false, nil
+}
+
+// upgradeLegacyPasshash : Upgrade passhash of an authenticated user, once per passhash config
+func upgradeLegacyPasshash(env *models.Env, internalWaveUserID string, token string) {
+
+	scheme, _ := env.Redis.Get(env.TraceContext(), fmt.Sprintf("passhash:%s", internalWaveUserID))
+
+	if string(scheme) == passhashScheme(env.Config.Passhash) {
+		return
+	}
+
+	_, err := UpgradePasshash(env, internalWaveUserID, token)
+
+	if err != nil {
+		env.Logger.WithError(err).WithField(models.LogFieldUserID, internalWaveUserID).Error("Failed to upgrade passhash")
+		return
+	}
+
+	env.Redis.Set(env.TraceContext(), fmt.Sprintf("passhash:%s", internalWaveUserID), []byte(passhashScheme(env.Config.Passhash)))
+}
+
+// updatePassHash : Update passhash of internalWaveUserID ACLs along with events, remembering it was generated with configured algorithm
+func updatePassHash(env *models.Env, internalWaveUserID string, passhash string, events ...*models.LifecycleEvent) error {
+
+	err := env.Store.UpdatePassHash(env.TraceContext(), internalWaveUserID, passhash, events...)
+
+	if err != nil {
+		return err
+	}
+
+	return env.Redis.Set(env.TraceContext(), fmt.Sprintf("passhash:%s", internalWaveUserID), []byte(passhashScheme(env.Config.Passhash)))
+}
+
+// passhashScheme : Identify passhash config, so that passhashes are checked again when it changes
+func passhashScheme(config models.PasshashConfig) string {
+	return fmt.Sprintf("%+v", config)
+}
+
+// MigratePasshashes : Upgrade legacy passhashes of all mapped users with their current token.
+// Users without mapping are upgraded on their next authentication
+func MigratePasshashes(env *models.Env) (*PasshashMigrationReport, error) {
+
+	mappingKeys, err := env.Redis.GetKeys(env.TraceContext(), "mapping:*")
+
+	if err != nil {
+		return nil, err
+	}
+
+	report := &PasshashMigrationReport{}
+
+	for _, mappingKey := range mappingKeys {
+
+		originalUserID := strings.TrimPrefix(mappingKey, "mapping:")
+
+		internalWaveUserID, token, err := CheckIfUserAlreadyHasToken(env, originalUserID)
+
+		if err != nil || internalWaveUserID == "" || token == "" {
+			continue
+		}
+
+		report.Users++
+
+		upgraded, err := UpgradePasshash(env, internalWaveUserID, token)
+
+		if err != nil {
+			env.Logger.WithError(err).WithField(models.LogFieldUserID, internalWaveUserID).Error("Failed to upgrade passhash")
+			report.Failed++
+			continue
+		}
+
+		if upgraded {
+			report.Upgraded++
+		}
+	}
+
+	return report, nil
+}
@@ -0,0 +1,111 @@
+package auth
+
+import (
+	models "wave-messaging-management-service/models"
+)
+
+// GetQuotas : Return effective quotas of internalWaveUserID (Configured ones overridden by its override, if any)
+func GetQuotas(env *models.Env, internalWaveUserID string) (models.Quotas, *models.QuotaOverride, error) {
+
+	override, err := env.Store.GetQuotaOverride(env.TraceContext(), internalWaveUserID)
+
+	if err != nil {
+		return models.Quotas{}, nil, err
+	}
+
+	return env.Config.Quotas.Apply(override), override, nil
+}
+
+// GetUserQuotas : Return effective quotas of internalWaveUserID with its override and usage.
+// Usage is counted in the user tenant
+func GetUserQuotas(env *models.Env, internalWaveUserID string) (*models.UserQuotas, error) {
+
+	env = env.ForTenant(GetUserTenant(env, internalWaveUserID))
+
+	quotas, override, err := GetQuotas(env, internalWaveUserID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	conversations, err := env.Store.CountCreatedGroupConversations(env.TraceContext(), internalWaveUserID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	groupMemberships, err := env.Store.CountGroupMemberships(env.TraceContext(), internalWaveUserID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.UserQuotas{
+		UserID:   internalWaveUserID,
+		Quotas:   quotas,
+		Override: override,
+		Usage: &models.QuotaUsage{
+			Conversations:    conversations,
+			GroupMemberships: groupMemberships,
+		},
+	}, nil
+}
+
+// SetQuotaOverride : Override configured quotas of internalWaveUserID, in the user tenant
+func SetQuotaOverride(env *models.Env, internalWaveUserID string, override *models.QuotaOverride) error {
+
+	env = env.ForTenant(GetUserTenant(env, internalWaveUserID))
+
+	override.UserID = internalWaveUserID
+
+	return env.Store.SetQuotaOverride(env.TraceContext(), override)
+}
+
+// CheckGroupConversationQuotas : Check that creating a group conversation takes neither its creator over its conversations quota,
+// nor any of its members over their group memberships quota. Returned error is a *models.QuotaExceededError when a quota is exceeded
+func CheckGroupConversationQuotas(env *models.Env, creatorID string, members []string) error {
+
+	quotas, _, err := GetQuotas(env, creatorID)
+
+	if err != nil {
+		return err
+	}
+
+	if quotas.MaxConversations > 0 {
+
+		conversations, err := env.Store.CountCreatedGroupConversations(env.TraceContext(), creatorID)
+
+		if err != nil {
+			return err
+		}
+
+		if conversations >= quotas.MaxConversations {
+			return &models.QuotaExceededError{UserID: creatorID, Quota: models.QuotaConversations, Limit: int64(quotas.MaxConversations)}
+		}
+	}
+
+	for _, member := range members {
+
+		quotas, _, err := GetQuotas(env, member)
+
+		if err != nil {
+			return err
+		}
+
+		if quotas.MaxGroupMemberships <= 0 {
+			continue
+		}
+
+		groupMemberships, err := env.Store.CountGroupMemberships(env.TraceContext(), member)
+
+		if err != nil {
+			return err
+		}
+
+		if groupMemberships >= quotas.MaxGroupMemberships {
+			return &models.QuotaExceededError{UserID: member, Quota: models.QuotaGroupMemberships, Limit: int64(quotas.MaxGroupMemberships)}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,179 @@
+package auth
+
+import (
+	sha256 "crypto/sha256"
+	hex "encoding/hex"
+	errors "errors"
+	fmt "fmt"
+	strings "strings"
+	time "time"
+	models "wave-messaging-management-service/models"
+
+	jwt "github.com/dgrijalva/jwt-go"
+	logruswrapper "github.com/terryvogelsang/logruswrapper"
+)
+
+const (
+	// revokedTokenLeeway : Seconds a revoked JWT stays in denylist past its expiry, covering clocks skew of verifiers
+	revokedTokenLeeway = 60
+)
+
+// TokenFingerprint : Hex encoded SHA-256 hash of token, identifying it where it can't be stored in clear (Denylist, audit log)
+func TokenFingerprint(token string) string {
+
+	hash := sha256.Sum256([]byte(token))
+
+	return hex.EncodeToString(hash[:])
+}
+
+// revokedTokenKey : Denylist key of token, hashed so that revoked tokens are not stored in clear
+func revokedTokenKey(token string) string {
+	return fmt.Sprintf("revoked:token:%s", TokenFingerprint(token))
+}
+
+// revokedUserKey : Denylist key of application user
+func revokedUserKey(originalUserID string) string {
+	return fmt.Sprintf("revoked:user:%s", originalUserID)
+}
+
+// addToDenylist : Set denylist key, expiring after ttl seconds (Never if 0)
+func addToDenylist(env *models.Env, key string, ttl int) error {
+
+	if ttl > 0 {
+		return env.Redis.SetEx(env.TraceContext(), key, []byte("1"), ttl)
+	}
+
+	return env.Redis.Set(env.TraceContext(), key, []byte("1"))
+}
+
+// RevokeToken : Add token to denylist, then revoke MQTT credentials and sessions of its owner if token is cached
+func RevokeToken(env *models.Env, token string, ttl int) error {
+
+	err := addToDenylist(env, revokedTokenKey(token), ttl)
+
+	if err != nil {
+		return err
+	}
+
+	internalWaveUserID, _ := CheckIfTokenIsCached(env, token)
+
+	if internalWaveUserID == "" {
+		return nil
+	}
+
+	return RevokeCredentials(env, internalWaveUserID, token)
+}
+
+// DenyToken : Add token to denylist until it expires, so that it can't be used to authenticate again once logged out.
+// JWTs are kept past their exp claim, other tokens (Whose lifetime is unknown) forever
+func DenyToken(env *models.Env, token string) error {
+	return addToDenylist(env, revokedTokenKey(token), remainingTokenLifetime(token))
+}
+
+// remainingTokenLifetime : Return seconds before token expires, leeway included, 0 when unknown (Not a JWT, or without expiry).
+// Only expiry is read, token being verified beforehand. Identity provider prefix ({name}:{token}) is ignored
+func remainingTokenLifetime(token string) int {
+
+	token = token[strings.LastIndex(token, ":")+1:]
+
+	parsedToken, _, err := new(jwt.Parser).ParseUnverified(token, jwt.MapClaims{})
+
+	if err != nil {
+		return 0
+	}
+
+	claims, ok := parsedToken.Claims.(jwt.MapClaims)
+
+	if !ok {
+		return 0
+	}
+
+	exp, ok := claims["exp"].(float64)
+
+	if !ok {
+		return 0
+	}
+
+	remaining := int(int64(exp)-time.Now().Unix()) + revokedTokenLeeway
+
+	if remaining < 1 {
+		remaining = 1
+	}
+
+	return remaining
+}
+
+// RevokeUser : Add application user to denylist, then revoke its MQTT credentials and sessions if it is mapped
+func RevokeUser(env *models.Env, originalUserID string, ttl int) error {
+
+	err := addToDenylist(env, revokedUserKey(originalUserID), ttl)
+
+	if err != nil {
+		return err
+	}
+
+	internalWaveUserID, cachedToken, _ := CheckIfUserAlreadyHasToken(env, originalUserID)
+
+	if internalWaveUserID == "" {
+		return nil
+	}
+
+	return RevokeCredentials(env, internalWaveUserID, cachedToken)
+}
+
+// RestoreUser : Remove application user from denylist and lift its suspension, its next authentication maps it again
+func RestoreUser(env *models.Env, originalUserID string) error {
+
+	err := env.Redis.Delete(env.TraceContext(), revokedUserKey(originalUserID))
+
+	if err != nil {
+		return err
+	}
+
+	return liftSuspension(env, originalUserID)
+}
+
+// CheckRevocation : Return an error if token or application user (When not empty) is in denylist.
+// Denylist is fail closed, Redis errors are returned as well
+func CheckRevocation(env *models.Env, token string, originalUserID string) error {
+
+	if token != "" {
+
+		revoked, err := IsTokenRevoked(env, token)
+
+		if err != nil {
+			return err
+		}
+
+		if revoked {
+			return errors.New(logruswrapper.CodeInvalidToken)
+		}
+	}
+
+	if originalUserID != "" {
+
+		revoked, err := IsUserRevoked(env, originalUserID)
+
+		if err != nil {
+			return err
+		}
+
+		if revoked {
+			return errors.New(logruswrapper.CodeInvalidToken)
+		}
+	}
+
+	return nil
+}
+
+// IsTokenRevoked : Check if token is in denylist
+func IsTokenRevoked(env *models.Env, token string) (bool, error) {
+
+	return env.Redis.Exists(env.TraceContext(), revokedTokenKey(token))
+}
+
+// IsUserRevoked : Check if application user is in denylist
+func IsUserRevoked(env *models.Env, originalUserID string) (bool, error) {
+
+	return env.Redis.Exists(env.TraceContext(), revokedUserKey(originalUserID))
+}
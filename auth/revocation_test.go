@@ -0,0 +1,48 @@
+package auth
+
+import (
+	context "context"
+	testing "testing"
+	models "wave-messaging-management-service/models"
+	mocks "wave-messaging-management-service/models/mocks"
+
+	gomock "github.com/golang/mock/gomock"
+	logrus "github.com/sirupsen/logrus"
+)
+
+func TestAddToDenylistExpiring(t *testing.T) {
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	redis := mocks.NewMockRedisInterface(ctrl)
+
+	// Key is set along with its expiry, a failure between SET and EXPIRE would revoke for good
+	redis.EXPECT().SetEx(gomock.Any(), "revoked:user:alice", []byte("1"), 60).Return(nil)
+
+	env := &models.Env{Redis: redis, Logger: logrus.NewEntry(logrus.New()), Context: context.Background()}
+
+	err := addToDenylist(env, revokedUserKey("alice"), 60)
+
+	if err != nil {
+		t.Fatalf("Failed to add to denylist : %v", err)
+	}
+}
+
+func TestAddToDenylistPermanent(t *testing.T) {
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	redis := mocks.NewMockRedisInterface(ctrl)
+
+	redis.EXPECT().Set(gomock.Any(), "revoked:user:alice", []byte("1")).Return(nil)
+
+	env := &models.Env{Redis: redis, Logger: logrus.NewEntry(logrus.New()), Context: context.Background()}
+
+	err := addToDenylist(env, revokedUserKey("alice"), 0)
+
+	if err != nil {
+		t.Fatalf("Failed to add to denylist : %v", err)
+	}
+}
@@ -0,0 +1,90 @@
+package auth
+
+import (
+	tls "crypto/tls"
+	fmt "fmt"
+	models "wave-messaging-management-service/models"
+
+	acme "golang.org/x/crypto/acme"
+	autocert "golang.org/x/crypto/acme/autocert"
+)
+
+var (
+	// tlsVersions : TLS versions accepted as minimum version
+	tlsVersions = map[string]uint16{
+		"1.0": tls.VersionTLS10,
+		"1.1": tls.VersionTLS11,
+		"1.2": tls.VersionTLS12,
+		"1.3": tls.VersionTLS13,
+	}
+)
+
+// NewCertManager : Return manager obtaining and renewing server certificates of configured domains from an ACME CA.
+// Terms of service of the CA are accepted, certificates of other domains are refused
+func NewCertManager(config *models.AutocertConfig) *autocert.Manager {
+
+	cacheDir := config.CacheDir
+
+	if cacheDir == "" {
+		cacheDir = models.DefaultAutocertCacheDir
+	}
+
+	manager := &autocert.Manager{
+		Prompt:     autocert.AcceptTOS,
+		HostPolicy: autocert.HostWhitelist(config.Domains...),
+		Cache:      autocert.DirCache(cacheDir),
+		Email:      config.Email,
+	}
+
+	if config.DirectoryURL != "" {
+		manager.Client = &acme.Client{DirectoryURL: config.DirectoryURL}
+	}
+
+	return manager
+}
+
+// parseTLSVersion : Return TLS version of its name (e.g. 1.2), default minimum version if empty
+func parseTLSVersion(name string) (uint16, error) {
+
+	if name == "" {
+		name = models.DefaultTLSMinVersion
+	}
+
+	version, ok := tlsVersions[name]
+
+	if !ok {
+		return 0, fmt.Errorf("Unknown TLS version %s", name)
+	}
+
+	return version, nil
+}
+
+// parseCipherSuites : Return IDs of cipher suites by their IANA name (e.g. TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256), nil for Go defaults.
+// Only suites considered secure are accepted. TLS 1.3 suites are not configurable
+func parseCipherSuites(names []string) ([]uint16, error) {
+
+	if len(names) == 0 {
+		return nil, nil
+	}
+
+	secureSuites := map[string]uint16{}
+
+	for _, suite := range tls.CipherSuites() {
+		secureSuites[suite.Name] = suite.ID
+	}
+
+	ids := make([]uint16, 0, len(names))
+
+	for _, name := range names {
+
+		id, ok := secureSuites[name]
+
+		if !ok {
+			return nil, fmt.Errorf("Unknown or insecure cipher suite %s", name)
+		}
+
+		ids = append(ids, id)
+	}
+
+	return ids, nil
+}
@@ -0,0 +1,252 @@
+package auth
+
+import (
+	errors "errors"
+	strings "strings"
+	models "wave-messaging-management-service/models"
+	utils "wave-messaging-management-service/utils"
+)
+
+// Operations of internal services, shared by the HTTP service endpoints and the gRPC API.
+// Callers check service credentials first, and run them in the environment scoped to the service tenant
+
+var (
+	// ErrOtherTenant : User belongs to another tenant than the one of a tenant scoped service
+	ErrOtherTenant = errors.New("User belongs to another tenant")
+
+	// ErrTooManyConversations : More group conversations than models.MaxBulkGroupConversations were to be created at once
+	ErrTooManyConversations = errors.New("Too many conversations")
+)
+
+// MappingsError : Mappings of some users could not be fetched, other ones were
+type MappingsError struct {
+	FailedUserIDs []string
+}
+
+func (err *MappingsError) Error() string {
+	return "Failed to get mappings of users " + strings.Join(err.FailedUserIDs, ", ")
+}
+
+// UnknownUsersError : Some users of group conversations to create have no mapping
+type UnknownUsersError struct {
+	UserIDs []string
+}
+
+func (err *UnknownUsersError) Error() string {
+	return "Unknown users " + strings.Join(err.UserIDs, ", ")
+}
+
+// TopicError : Topic pattern granted by a service leaves the topic namespace of its tenant
+type TopicError struct {
+	Err error
+}
+
+func (err *TopicError) Error() string {
+	return err.Err.Error()
+}
+
+// CheckTenantUser : Check internal Wave user belongs to the tenant of a tenant scoped environment
+func CheckTenantUser(env *models.Env, internalWaveUserID string) error {
+
+	if env.TenantID != "" && GetUserTenant(env, internalWaveUserID) != env.TenantID {
+		return ErrOtherTenant
+	}
+
+	return nil
+}
+
+// GetMappings : Return mappings of known users among original user IDs of identity provider, fetched pipelined (See models.Redis.Pipeline).
+// Unknown users are left out. A *MappingsError lists users whose mapping failed to be fetched
+func GetMappings(env *models.Env, identityProvider *models.IdentityProviderConfig, userIDs []string) ([]models.Mapping, error) {
+
+	keys := make([]string, len(userIDs))
+
+	for i, userID := range userIDs {
+		keys[i] = "mapping:" + NamespaceUserID(identityProvider, env.TenantID, userID)
+	}
+
+	internalWaveUserIDs, err := env.Redis.BatchHGet(env.TraceContext(), keys, "internalWaveUserID")
+
+	batchErr, partial := err.(*models.BatchError)
+
+	if err != nil && !partial {
+		return nil, err
+	}
+
+	mappings := []models.Mapping{}
+	failedUserIDs := []string{}
+
+	for i, userID := range userIDs {
+
+		if partial && batchErr.Errors[i] != nil {
+			env.Logger.WithError(batchErr.Errors[i]).Error("Failed to get mapping")
+			failedUserIDs = append(failedUserIDs, userID)
+			continue
+		}
+
+		if len(internalWaveUserIDs[i]) != 0 {
+			mappings = append(mappings, models.Mapping{OriginalUserID: userID, InternalWaveUserID: string(internalWaveUserIDs[i])})
+		}
+	}
+
+	if len(failedUserIDs) > 0 {
+		return nil, &MappingsError{FailedUserIDs: failedUserIDs}
+	}
+
+	return mappings, nil
+}
+
+// CreateGroupConversations : Create group conversations between original users of identity provider, on behalf of actor.
+// Creators and members of all conversations are resolved first, so that nothing is written if one of them is unknown (*UnknownUsersError).
+// Conversations are then inserted at once, and ACLs of each member updated once. Quotas are not enforced
+func CreateGroupConversations(env *models.Env, actor models.AuditActor, identityProvider *models.IdentityProviderConfig, conversations []utils.BulkGroupConversation) ([]*models.GroupConversation, error) {
+
+	if len(conversations) > models.MaxBulkGroupConversations {
+		return nil, ErrTooManyConversations
+	}
+
+	userIDs := []string{}
+	known := map[string]bool{}
+
+	for _, conversation := range conversations {
+		for _, userID := range append([]string{conversation.CreatorID}, conversation.Members...) {
+			if !known[userID] {
+				known[userID] = true
+				userIDs = append(userIDs, userID)
+			}
+		}
+	}
+
+	mappings, err := GetMappings(env, identityProvider, userIDs)
+
+	if err != nil {
+		return nil, err
+	}
+
+	internalWaveUserIDs := map[string]string{}
+
+	for _, mapping := range mappings {
+		internalWaveUserIDs[mapping.OriginalUserID] = mapping.InternalWaveUserID
+	}
+
+	if len(mappings) != len(userIDs) {
+
+		unknownUserIDs := []string{}
+
+		for _, userID := range userIDs {
+			if internalWaveUserIDs[userID] == "" {
+				unknownUserIDs = append(unknownUserIDs, userID)
+			}
+		}
+
+		return nil, &UnknownUsersError{UserIDs: unknownUserIDs}
+	}
+
+	groupConversations := make([]*models.GroupConversation, 0, len(conversations))
+
+	for _, conversation := range conversations {
+
+		creatorID := internalWaveUserIDs[conversation.CreatorID]
+		members := []string{}
+		added := map[string]bool{creatorID: true}
+
+		// Creator is a member, listed last as on single creations
+		for _, member := range conversation.Members {
+			if !added[internalWaveUserIDs[member]] {
+				added[internalWaveUserIDs[member]] = true
+				members = append(members, internalWaveUserIDs[member])
+			}
+		}
+
+		groupConversations = append(groupConversations, models.NewGroupConversation(conversation.Name, creatorID, append(members, creatorID)))
+	}
+
+	topicPaths, err := env.TopicPaths()
+
+	if err != nil {
+		return nil, err
+	}
+
+	entries := []*models.AuditEntry{}
+
+	for _, groupConv := range groupConversations {
+
+		entries = append(entries, models.NewAuditEntry(actor, models.AuditGroupCreate, groupConv.GroupConversationID, map[string]string{"name": groupConv.Name, "creatorID": groupConv.CreatorID}))
+
+		for _, member := range groupConv.Members {
+			entries = append(entries, models.NewAuditEntry(actor, models.AuditGroupMemberAdd, member, map[string]string{"conversationID": groupConv.GroupConversationID}))
+		}
+	}
+
+	err = env.Store.CreateGroupConversations(env.TraceContext(), groupConversations, topicPaths.Group, LifecycleEvents(env, entries...)...)
+
+	if err != nil {
+		return nil, err
+	}
+
+	for _, entry := range entries {
+		Audit(env, entry)
+	}
+
+	for _, groupConv := range groupConversations {
+		RecordUsage(env, groupConv.CreatorID, models.UsageConversationsCreated)
+	}
+
+	env.Logger.WithField("conversations", len(groupConversations)).Info("Group conversations created")
+
+	return groupConversations, nil
+}
+
+// GetClientACL : Return VerneMQ ACL of one MQTT client of the environment tenant, without its credentials
+func GetClientACL(env *models.Env, clientID string) (*models.VerneMQACL, error) {
+
+	verneMQACL, err := env.Store.GetClientACL(env.TraceContext(), clientID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	err = CheckTenantUser(env, verneMQACL.Username)
+
+	if err != nil {
+		return nil, err
+	}
+
+	// Never expose credentials
+	verneMQACL.Passhash = ""
+
+	return verneMQACL, nil
+}
+
+// AuthorizePublishing : Grant publishing rights on a MQTT topic to a user of the environment tenant (On all its devices) on behalf of actor.
+// Topic is moved into the topic namespace of the tenant, and must stay inside it (*TopicError). Return the granted pattern
+func AuthorizePublishing(env *models.Env, actor models.AuditActor, internalWaveUserID string, topic string) (string, error) {
+
+	err := CheckTenantUser(env, internalWaveUserID)
+
+	if err != nil {
+		return "", err
+	}
+
+	topicPaths, err := env.TopicPaths()
+
+	if err != nil {
+		return "", err
+	}
+
+	pattern, err := topicPaths.ScopePattern(topic)
+
+	if err != nil {
+		return "", &TopicError{Err: err}
+	}
+
+	err = env.Store.AuthorizePublishing(env.TraceContext(), internalWaveUserID, pattern)
+
+	if err != nil {
+		return "", err
+	}
+
+	Audit(env, models.NewAuditEntry(actor, models.AuditACLGrant, internalWaveUserID, map[string]string{"topic": pattern}))
+
+	return pattern, nil
+}
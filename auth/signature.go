@@ -0,0 +1,57 @@
+package auth
+
+import (
+	hmac "crypto/hmac"
+	sha256 "crypto/sha256"
+	hex "encoding/hex"
+	errors "errors"
+	strconv "strconv"
+	time "time"
+	models "wave-messaging-management-service/models"
+)
+
+// SignRequest : Return hex encoded HMAC-SHA256 of timestamp, method, request URI (Path and query) and body :
+// HMAC(secret, "{timestamp}\n{method}\n{requestURI}\n{body}"), so that a signature is only valid for the request it was computed for.
+// Lines are separated by newlines, which the method and request URI can't hold
+func SignRequest(secret string, timestamp string, method string, requestURI string, body []byte) string {
+
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp + "\n" + method + "\n" + requestURI + "\n"))
+	mac.Write(body)
+
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// VerifyRequestSignature : Check signature of request method, URI and body and that its timestamp (Unix seconds) is recent enough to block replays
+func VerifyRequestSignature(config *models.RequestSigningConfig, timestamp string, signature string, method string, requestURI string, body []byte) error {
+
+	if timestamp == "" || signature == "" {
+		return errors.New("No Signature Provided")
+	}
+
+	seconds, err := strconv.ParseInt(timestamp, 10, 64)
+
+	if err != nil {
+		return errors.New("Invalid signature timestamp")
+	}
+
+	tolerance := config.Tolerance
+
+	if tolerance <= 0 {
+		tolerance = models.DefaultSignatureTolerance
+	}
+
+	age := time.Since(time.Unix(seconds, 0))
+
+	if age > time.Duration(tolerance)*time.Second || age < -time.Duration(tolerance)*time.Second {
+		return errors.New("Signature timestamp out of tolerance")
+	}
+
+	expected := SignRequest(config.Secret, timestamp, method, requestURI, body)
+
+	if !hmac.Equal([]byte(expected), []byte(signature)) {
+		return errors.New("Invalid signature")
+	}
+
+	return nil
+}
@@ -0,0 +1,92 @@
+package auth
+
+import (
+	json "encoding/json"
+	errors "errors"
+	ioutil "io/ioutil"
+	os "os"
+	sync "sync"
+	time "time"
+	models "wave-messaging-management-service/models"
+
+	logruswrapper "github.com/terryvogelsang/logruswrapper"
+)
+
+// StaticFileVerifier : Verify tokens against a static JSON file mapping tokens to user IDs :
+// {"token1": "userID1", "token2": "userID2"}
+// Intended for development, tests and service accounts
+type StaticFileVerifier struct {
+	mutex sync.Mutex
+	files map[string]*staticTokensFile
+}
+
+// staticTokensFile : Parsed tokens file, by path (One per identity provider)
+type staticTokensFile struct {
+	modTime time.Time
+	tokens  map[string]string
+}
+
+// IsLocal : Static tokens are checked on every request so that removing a token from the file revokes it
+func (verifier *StaticFileVerifier) IsLocal() bool {
+	return true
+}
+
+// VerifyToken : Return user ID matching token in static tokens file
+func (verifier *StaticFileVerifier) VerifyToken(env *models.Env, identityProvider *models.IdentityProviderConfig, token string) (*VerifiedUser, error) {
+
+	tokens, err := verifier.load(identityProvider.StaticTokensFile)
+
+	if err != nil {
+		return nil, err
+	}
+
+	originalUserID, ok := tokens[token]
+
+	if !ok || originalUserID == "" {
+		return nil, errors.New(logruswrapper.CodeInvalidToken)
+	}
+
+	return &VerifiedUser{OriginalUserID: originalUserID}, nil
+}
+
+// load : Return tokens of file, file is parsed again only when modified
+func (verifier *StaticFileVerifier) load(path string) (map[string]string, error) {
+
+	verifier.mutex.Lock()
+	defer verifier.mutex.Unlock()
+
+	info, err := os.Stat(path)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if verifier.files == nil {
+		verifier.files = map[string]*staticTokensFile{}
+	}
+
+	if file, ok := verifier.files[path]; ok && info.ModTime().Equal(file.modTime) {
+		return file.tokens, nil
+	}
+
+	data, err := ioutil.ReadFile(path)
+
+	if err != nil {
+		return nil, err
+	}
+
+	tokens := map[string]string{}
+
+	err = json.Unmarshal(data, &tokens)
+
+	if err != nil {
+		return nil, err
+	}
+
+	verifier.files[path] = &staticTokensFile{
+		modTime: info.ModTime(),
+		tokens:  tokens,
+	}
+
+	return tokens, nil
+}
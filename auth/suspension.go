@@ -0,0 +1,125 @@
+package auth
+
+import (
+	fmt "fmt"
+	time "time"
+	models "wave-messaging-management-service/models"
+)
+
+// SuspendUser : Revoke all access of internalWaveUserID at once, for incident response :
+// Application user is added to denylist for good, its mapping marked as suspended, cached token invalidated,
+// VerneMQ ACLs of all its devices removed along with events, and their sessions disconnected. Lifting the user revocation restores it
+func SuspendUser(env *models.Env, internalWaveUserID string, events ...*models.LifecycleEvent) (*models.Mapping, error) {
+
+	mapping, err := FindMapping(env, internalWaveUserID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	// Refuse any further authentication of the user
+	err = addToDenylist(env, revokedUserKey(mapping.OriginalUserID), 0)
+
+	if err != nil {
+		return nil, err
+	}
+
+	mappingKey := fmt.Sprintf("mapping:%s", mapping.OriginalUserID)
+
+	err = env.Redis.HSet(env.TraceContext(), mappingKey, "suspended", []byte("1"), "suspendedAt", []byte(time.Now().UTC().Format(time.RFC3339)))
+
+	if err != nil {
+		return nil, err
+	}
+
+	token, _ := env.Redis.HGet(env.TraceContext(), mappingKey, "token")
+
+	if len(token) > 0 {
+
+		err = InvalidateCachedToken(env, string(token))
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	verneMQACLs, err := env.Store.GetUserACLs(env.TraceContext(), internalWaveUserID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	// ACLs are removed before disconnecting, so that sessions can't reconnect
+	err = env.Store.RemoveUserACLs(env.TraceContext(), internalWaveUserID, events...)
+
+	if err != nil {
+		return nil, err
+	}
+
+	for _, verneMQACL := range verneMQACLs {
+
+		err = env.Broker.DisconnectSession(verneMQACL.ClientID)
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return mapping, nil
+}
+
+// GetSuspension : Return date originalUserID was suspended at, nil if it is not suspended
+func GetSuspension(env *models.Env, originalUserID string) *time.Time {
+
+	suspendedAt, err := env.Redis.HGet(env.TraceContext(), fmt.Sprintf("mapping:%s", originalUserID), "suspendedAt")
+
+	if err != nil || len(suspendedAt) == 0 {
+		return nil
+	}
+
+	date, err := time.Parse(time.RFC3339, string(suspendedAt))
+
+	if err != nil {
+		return nil
+	}
+
+	return &date
+}
+
+// liftSuspension : Remove suspension mark from originalUserID mapping
+func liftSuspension(env *models.Env, originalUserID string) error {
+
+	return env.Redis.HDel(env.TraceContext(), fmt.Sprintf("mapping:%s", originalUserID), "suspended", "suspendedAt")
+}
+
+// FindMapping : Return mapping of internalWaveUserID, looked up in the reverse index of mappings (See IndexMappings).
+// Index is checked against the mapping, so that a stale entry never resolves another user
+func FindMapping(env *models.Env, internalWaveUserID string) (*models.Mapping, error) {
+
+	replies, err := env.Redis.Pipeline(env.TraceContext(), []models.RedisCommand{{Name: "GET", Key: mappingIndexKey(internalWaveUserID)}})
+
+	if err != nil {
+		return nil, err
+	}
+
+	originalUserID, _ := replies[0].([]byte)
+
+	if len(originalUserID) == 0 {
+		return nil, ErrUnknownUser
+	}
+
+	mappedInternalWaveUserIDs, err := env.Redis.BatchHGet(env.TraceContext(), []string{fmt.Sprintf("mapping:%s", originalUserID)}, "internalWaveUserID")
+
+	if err != nil {
+		return nil, err
+	}
+
+	if string(mappedInternalWaveUserIDs[0]) != internalWaveUserID {
+		return nil, ErrUnknownUser
+	}
+
+	return &models.Mapping{
+		OriginalUserID:     string(originalUserID),
+		InternalWaveUserID: internalWaveUserID,
+	}, nil
+}
@@ -0,0 +1,40 @@
+package auth
+
+import (
+	models "wave-messaging-management-service/models"
+
+	uuid "github.com/satori/go.uuid"
+)
+
+// NewSystemPublisher : Provision VerneMQ ACL of the internal system messages publisher, then return the publisher.
+// Publisher password is generated again on every startup, replacing the previous ACL
+func NewSystemPublisher(env *models.Env) (*models.MQTTPublisher, error) {
+
+	clientID := env.Config.SystemPublisher.ClientID
+
+	if clientID == "" {
+		clientID = models.DefaultSystemPublisherClientID
+	}
+
+	password := uuid.NewV4().String()
+
+	passhash, err := HashPassword(env.Config.Passhash, password)
+
+	if err != nil {
+		return nil, err
+	}
+
+	err = env.Store.RemoveUserACLs(env.TraceContext(), clientID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	err = env.Store.AddProfileACL(env.TraceContext(), models.NewSystemPublisherVerneMQACL(clientID, passhash))
+
+	if err != nil {
+		return nil, err
+	}
+
+	return models.NewMQTTPublisher(&env.Config, clientID, password), nil
+}
@@ -0,0 +1,212 @@
+package auth
+
+import (
+	errors "errors"
+	strconv "strconv"
+	time "time"
+	models "wave-messaging-management-service/models"
+)
+
+var (
+	// ErrUnknownGroupConversation : Group conversation does not exist, or is not in the state the operation expects (Removed or not)
+	ErrUnknownGroupConversation = errors.New("Unknown group conversation")
+
+	// ErrNoDeletedACLs : User has no removed VerneMQ ACL left to restore
+	ErrNoDeletedACLs = errors.New("No removed ACL to restore")
+
+	// ErrUserSuspended : User is suspended, its removed VerneMQ ACLs can't be restored until its suspension is lifted
+	ErrUserSuspended = errors.New("User suspended")
+)
+
+// RemoveGroupConversation : Remove group conversation of the environment tenant along with events, revoking access of its members.
+// It is kept as a tombstone until purged (See PurgeTombstones), and sessions of its members are disconnected as the broker only checks ACLs on connection
+func RemoveGroupConversation(env *models.Env, groupConversationID string, events ...*models.LifecycleEvent) (*models.GroupConversation, error) {
+
+	topicPaths, err := env.TopicPaths()
+
+	if err != nil {
+		return nil, err
+	}
+
+	groupConversation, err := env.Store.RemoveGroupConversation(env.TraceContext(), groupConversationID, topicPaths.Group, time.Now().UTC(), events...)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if groupConversation == nil {
+		return nil, ErrUnknownGroupConversation
+	}
+
+	for _, member := range groupConversation.Members {
+
+		verneMQACLs, err := env.Store.GetUserACLs(env.TraceContext(), member)
+
+		if err != nil {
+			env.Logger.WithError(err).WithField(models.LogFieldUserID, member).Warn("Failed to disconnect sessions of group conversation member")
+			continue
+		}
+
+		for _, verneMQACL := range verneMQACLs {
+
+			err = env.Broker.DisconnectSession(verneMQACL.ClientID)
+
+			if err != nil {
+				env.Logger.WithError(err).WithField("clientID", verneMQACL.ClientID).Warn("Failed to disconnect session of group conversation member")
+			}
+		}
+	}
+
+	return groupConversation, nil
+}
+
+// RestoreGroupConversation : Restore removed group conversation of the environment tenant along with events, granting access to its members again
+func RestoreGroupConversation(env *models.Env, groupConversationID string, events ...*models.LifecycleEvent) (*models.GroupConversation, error) {
+
+	topicPaths, err := env.TopicPaths()
+
+	if err != nil {
+		return nil, err
+	}
+
+	groupConversation, err := env.Store.RestoreGroupConversation(env.TraceContext(), groupConversationID, topicPaths.Group, events...)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if groupConversation == nil {
+		return nil, ErrUnknownGroupConversation
+	}
+
+	return groupConversation, nil
+}
+
+// RestoreUserACLs : Restore removed VerneMQ ACLs of internalWaveUserID along with events (Undo of a device removal, expiry or suspension),
+// returning the number restored. ACLs of suspended users are not restored, as the broker would accept their former credentials.
+// Restored ACLs are renewed, so that expired ones are not removed again by the next cleanup
+func RestoreUserACLs(env *models.Env, internalWaveUserID string, events ...*models.LifecycleEvent) (int, error) {
+
+	mapping, err := FindMapping(env, internalWaveUserID)
+
+	// Guests have no mapping
+	if err != nil && err != ErrUnknownUser {
+		return 0, err
+	}
+
+	if mapping != nil && GetSuspension(env, mapping.OriginalUserID) != nil {
+		return 0, ErrUserSuspended
+	}
+
+	deletedACLs, err := env.Store.GetDeletedACLs(env.TraceContext(), internalWaveUserID)
+
+	if err != nil {
+		return 0, err
+	}
+
+	if len(deletedACLs) == 0 {
+		return 0, ErrNoDeletedACLs
+	}
+
+	restored, err := env.Store.RestoreUserACLs(env.TraceContext(), internalWaveUserID, events...)
+
+	if err != nil {
+		return 0, err
+	}
+
+	if restored == 0 {
+		return 0, nil
+	}
+
+	_, err = RenewACLs(env, internalWaveUserID)
+
+	if err != nil {
+		env.Logger.WithError(err).WithField(models.LogFieldUserID, internalWaveUserID).Warn("Failed to renew restored ACLs")
+	}
+
+	return restored, nil
+}
+
+// PurgeTombstones : Remove tombstones of VerneMQ ACLs and group conversations (Of every tenant) for good, once their retention is over.
+// Nothing is purged while tombstones are under legal hold. Stores with TTL indexes remove tombstones on their own (See ExpireTombstones),
+// their expiry only being updated to the current retention and legal hold
+func PurgeTombstones(env *models.Env) error {
+
+	err := ExpireTombstones(env)
+
+	if err != models.ErrNotSupported {
+		return err
+	}
+
+	if env.Config.Tombstones.LegalHold {
+		env.Logger.Debug("Tombstones under legal hold, purge skipped")
+		return nil
+	}
+
+	deletedBefore := env.Config.Tombstones.RetainedSince(time.Now().UTC())
+
+	acls, err := env.Store.PurgeDeletedACLs(env.TraceContext(), deletedBefore)
+
+	if err != nil {
+		return err
+	}
+
+	groupConversations, err := env.Store.PurgeGroupConversations(env.TraceContext(), deletedBefore)
+
+	if err != nil {
+		return err
+	}
+
+	if acls == 0 && groupConversations == 0 {
+		return nil
+	}
+
+	Audit(env, models.NewAuditEntry(models.SystemActor(), models.AuditTombstonesPurge, "tombstones", map[string]string{
+		"acls":               strconv.Itoa(acls),
+		"groupConversations": strconv.Itoa(groupConversations),
+		"deletedBefore":      deletedBefore.Format(time.RFC3339),
+	}))
+
+	env.Logger.WithField("acls", acls).WithField("groupConversations", groupConversations).Info("Tombstones purged")
+
+	return nil
+}
+
+// ExpireTombstones : Have the store remove tombstones once their retention is over, expiry being suspended under legal hold.
+// Return models.ErrNotSupported when the store has no TTL index
+func ExpireTombstones(env *models.Env) error {
+
+	expireAfter := time.Duration(0)
+
+	if !env.Config.Tombstones.LegalHold {
+		expireAfter = env.Config.Tombstones.RetentionPeriod()
+	}
+
+	return env.Store.ExpireTombstones(env.TraceContext(), expireAfter)
+}
+
+// StartTombstonesPurge : Purge tombstones every configured purge interval, in background until workers are stopped
+func StartTombstonesPurge(env *models.Env) {
+
+	interval := func() time.Duration {
+
+		interval := env.Config.Tombstones.PurgeInterval
+
+		if interval <= 0 {
+			interval = models.DefaultTombstonesPurgeInterval
+		}
+
+		return time.Duration(interval) * time.Second
+	}
+
+	env.Workers.Every(interval, func() {
+		env.Guard(func() {
+
+			err := PurgeTombstones(env)
+
+			if err != nil {
+				env.Logger.WithError(err).Error("Failed to purge tombstones")
+			}
+		})
+	})
+}
@@ -0,0 +1,172 @@
+package auth
+
+import (
+	fmt "fmt"
+	sort "sort"
+	strings "strings"
+	time "time"
+	models "wave-messaging-management-service/models"
+
+	logrus "github.com/sirupsen/logrus"
+)
+
+const (
+	// takeCounterScript : Atomically read and remove usage counter, so that increments made meanwhile are flushed next time
+	takeCounterScript = `
+local count = tonumber(redis.call('GET', KEYS[1]) or 0)
+redis.call('DEL', KEYS[1])
+return {count}
+`
+
+	// restoreCounterScript : Add back counter which couldn't be flushed
+	restoreCounterScript = `
+return {redis.call('INCRBY', KEYS[1], ARGV[1])}
+`
+)
+
+// usageKey : Redis counter of userID metric on date, tenant comes last as it may hold separators
+// usage:{date}:{metric}:{userID}:{tenantID}
+func usageKey(date string, metric string, userID string, tenantID string) string {
+	return fmt.Sprintf("usage:%s:%s:%s:%s", date, metric, userID, tenantID)
+}
+
+// RecordUsage : Count one occurrence of metric for userID in the environment tenant.
+// Counters live in Redis until flushed to MongoDB, failures are logged but never fail the request
+func RecordUsage(env *models.Env, userID string, metric string) {
+
+	_, err := env.Redis.Incr(env.TraceContext(), usageKey(time.Now().UTC().Format(models.UsageDateLayout), metric, userID, env.TenantID))
+
+	if err != nil {
+		env.Logger.WithError(err).WithFields(logrus.Fields{"metric": metric, models.LogFieldUserID: userID}).Error("Failed to record usage")
+	}
+}
+
+// FlushUsage : Move Redis usage counters to MongoDB daily usage records
+func FlushUsage(env *models.Env) error {
+
+	keys, err := env.Redis.GetKeys(env.TraceContext(), "usage:*")
+
+	if err != nil {
+		return err
+	}
+
+	for _, key := range keys {
+
+		parts := strings.SplitN(key, ":", 5)
+
+		if len(parts) != 5 {
+			continue
+		}
+
+		counts, err := env.Redis.EvalInts(env.TraceContext(), takeCounterScript, []string{key})
+
+		if err != nil {
+			env.Logger.WithError(err).WithField("key", key).Error("Failed to take usage counter")
+			continue
+		}
+
+		if len(counts) == 0 || counts[0] == 0 {
+			continue
+		}
+
+		err = env.Store.AddUsage(env.TraceContext(), parts[1], parts[4], parts[3], parts[2], counts[0])
+
+		if err != nil {
+
+			env.Logger.WithError(err).WithField("key", key).Error("Failed to flush usage counter")
+
+			_, err = env.Redis.EvalInts(env.TraceContext(), restoreCounterScript, []string{key}, counts[0])
+
+			if err != nil {
+				env.Logger.WithError(err).WithFields(logrus.Fields{"key": key, "count": counts[0]}).Error("Usage counter lost")
+			}
+		}
+	}
+
+	return nil
+}
+
+// StartUsageFlush : Flush usage counters every configured flush interval, in background until workers are stopped
+func StartUsageFlush(env *models.Env) {
+
+	interval := func() time.Duration {
+
+		interval := env.Config.Usage.FlushInterval
+
+		if interval <= 0 {
+			interval = models.DefaultUsageFlushInterval
+		}
+
+		return time.Duration(interval) * time.Second
+	}
+
+	env.Workers.Every(interval, func() {
+		env.Guard(func() {
+
+			err := FlushUsage(env)
+
+			if err != nil {
+				env.Logger.WithError(err).Error("Failed to flush usage")
+			}
+		})
+	})
+}
+
+// GetUsageReport : Aggregate usage records matching filter per tenant or per user (groupBy).
+// Counters are flushed first so that the report is up to date
+func GetUsageReport(env *models.Env, filter *models.UsageFilter, groupBy string) (*models.UsageReport, error) {
+
+	err := FlushUsage(env)
+
+	if err != nil {
+		return nil, err
+	}
+
+	records, err := env.Store.GetUsage(env.TraceContext(), filter)
+
+	if err != nil {
+		return nil, err
+	}
+
+	report := &models.UsageReport{
+		From:    filter.From,
+		To:      filter.To,
+		GroupBy: groupBy,
+		Totals:  &models.UsageRecord{},
+		Entries: []*models.UsageRecord{},
+	}
+
+	entries := map[string]*models.UsageRecord{}
+
+	for _, record := range records {
+
+		entry := &models.UsageRecord{TenantID: record.TenantID}
+
+		if groupBy == models.UsageGroupByUser {
+			entry.UserID = record.UserID
+		}
+
+		key := entry.TenantID + "/" + entry.UserID
+
+		if existing, ok := entries[key]; ok {
+			entry = existing
+		} else {
+			entries[key] = entry
+			report.Entries = append(report.Entries, entry)
+		}
+
+		entry.Add(record)
+		report.Totals.Add(record)
+	}
+
+	sort.Slice(report.Entries, func(i, j int) bool {
+
+		if report.Entries[i].TenantID != report.Entries[j].TenantID {
+			return report.Entries[i].TenantID < report.Entries[j].TenantID
+		}
+
+		return report.Entries[i].UserID < report.Entries[j].UserID
+	})
+
+	return report, nil
+}
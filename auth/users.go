@@ -0,0 +1,334 @@
+package auth
+
+import (
+	errors "errors"
+	sort "sort"
+	strings "strings"
+	models "wave-messaging-management-service/models"
+	utils "wave-messaging-management-service/utils"
+)
+
+var (
+	// ErrUnknownUser : Internal Wave user has neither ACL nor mapping
+	ErrUnknownUser = errors.New("Unknown user")
+)
+
+// ListUsers : List mapped users whose original or internal user ID contains search (Case insensitive), sorted by original user ID.
+// Users of a tenant scoped environment are the only ones listed
+func ListUsers(env *models.Env, search string, pagination *utils.Pagination) (*models.UsersPage, error) {
+
+	mappings, err := searchMappings(env, strings.ToLower(search))
+
+	if err != nil {
+		return nil, err
+	}
+
+	page := &models.UsersPage{
+		Page:  utils.NewPage(pagination, len(mappings)),
+		Users: []*models.User{},
+	}
+
+	if pagination.Offset >= len(mappings) {
+		return page, nil
+	}
+
+	mappings = mappings[pagination.Offset:]
+
+	if len(mappings) > pagination.Limit {
+		mappings = mappings[:pagination.Limit]
+	}
+
+	// Presence is fetched once per page, users are listed without it if the broker can't be reached
+	onlineClients, err := env.Broker.GetOnlineClients()
+
+	if err != nil {
+		env.Logger.WithError(err).Warn("Failed to get presence from broker")
+	}
+
+	for _, mapping := range mappings {
+
+		user, err := GetUser(env, mapping, onlineClients)
+
+		if err != nil {
+			return nil, err
+		}
+
+		page.Users = append(page.Users, user)
+	}
+
+	return page, nil
+}
+
+// GetUser : Join mapping of a user with its VerneMQ ACLs and presence (onlineClients, unknown if nil)
+func GetUser(env *models.Env, mapping *models.Mapping, onlineClients map[string]bool) (*models.User, error) {
+
+	verneMQACLs, err := env.Store.GetUserACLs(env.TraceContext(), mapping.InternalWaveUserID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	user := &models.User{
+		OriginalUserID:     mapping.OriginalUserID,
+		InternalWaveUserID: mapping.InternalWaveUserID,
+		TenantID:           GetUserTenant(env, mapping.InternalWaveUserID),
+		SuspendedAt:        GetSuspension(env, mapping.OriginalUserID),
+		OnlineClientIDs:    []string{},
+		ACLs:               verneMQACLs,
+	}
+
+	user.Suspended = user.SuspendedAt != nil
+
+	for _, verneMQACL := range verneMQACLs {
+
+		// Never expose credentials
+		verneMQACL.Passhash = ""
+
+		if onlineClients[verneMQACL.ClientID] {
+			user.OnlineClientIDs = append(user.OnlineClientIDs, verneMQACL.ClientID)
+		}
+	}
+
+	if onlineClients != nil {
+		online := len(user.OnlineClientIDs) > 0
+		user.Online = &online
+	}
+
+	return user, nil
+}
+
+// searchMappings : Return mappings of the environment tenant users matching lowercased search, sorted by original user ID.
+// Internal Wave user IDs are fetched pipelined (See models.Redis.Pipeline), users whose mapping could not be fetched are skipped
+func searchMappings(env *models.Env, search string) ([]*models.Mapping, error) {
+
+	keys, err := env.Redis.GetKeys(env.TraceContext(), "mapping:*")
+
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Strings(keys)
+
+	mappingKeys := []string{}
+
+	for _, mappingKey := range keys {
+
+		// User IDs of tenants are namespaced with their tenant
+		if env.TenantID == "" || strings.HasPrefix(mappingKey, "mapping:"+env.TenantID+"/") {
+			mappingKeys = append(mappingKeys, mappingKey)
+		}
+	}
+
+	internalWaveUserIDs, err := env.Redis.BatchHGet(env.TraceContext(), mappingKeys, "internalWaveUserID")
+
+	if _, partial := err.(*models.BatchError); err != nil && !partial {
+		return nil, err
+	}
+
+	mappings := []*models.Mapping{}
+
+	for i, mappingKey := range mappingKeys {
+
+		originalUserID := strings.TrimPrefix(mappingKey, "mapping:")
+		internalWaveUserID := string(internalWaveUserIDs[i])
+
+		if internalWaveUserID == "" {
+			continue
+		}
+
+		if search != "" && !strings.Contains(strings.ToLower(originalUserID), search) && !strings.Contains(strings.ToLower(internalWaveUserID), search) {
+			continue
+		}
+
+		mappings = append(mappings, &models.Mapping{
+			OriginalUserID:     originalUserID,
+			InternalWaveUserID: internalWaveUserID,
+		})
+	}
+
+	return mappings, nil
+}
+
+// GetUserACL : Return effective VerneMQ ACLs of internalWaveUserID, with conversations derived from their patterns, and tombstones of its removed ACLs.
+// Group conversations are looked up in the user tenant, to tell whether the user is still listed among their members
+func GetUserACL(env *models.Env, internalWaveUserID string) (*models.UserACL, error) {
+
+	verneMQACLs, err := env.Store.GetUserACLs(env.TraceContext(), internalWaveUserID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	deletedACLs, err := env.Store.GetDeletedACLs(env.TraceContext(), internalWaveUserID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if len(verneMQACLs) == 0 && len(deletedACLs) == 0 {
+		return nil, ErrUnknownUser
+	}
+
+	for _, deletedACL := range deletedACLs {
+		deletedACL.Passhash = ""
+	}
+
+	tenantID := GetUserTenant(env, internalWaveUserID)
+	userEnv := env.ForTenant(tenantID)
+
+	topicPaths, err := userEnv.TopicPaths()
+
+	if err != nil {
+		return nil, err
+	}
+
+	userACL := &models.UserACL{
+		InternalWaveUserID: internalWaveUserID,
+		TenantID:           tenantID,
+		ACLs:               verneMQACLs,
+		DeletedACLs:        deletedACLs,
+		Conversations:      []*models.ConversationMembership{},
+	}
+
+	memberships := map[string]*models.ConversationMembership{}
+
+	for _, verneMQACL := range verneMQACLs {
+
+		// Never expose credentials
+		verneMQACL.Passhash = ""
+
+		for _, acl := range verneMQACL.PublishACL {
+			addMembership(userACL, memberships, topicPaths, verneMQACL.ClientID, acl.Pattern, true)
+		}
+
+		for _, acl := range verneMQACL.SubscribeACL {
+			addMembership(userACL, memberships, topicPaths, verneMQACL.ClientID, acl.Pattern, false)
+		}
+	}
+
+	for _, membership := range userACL.Conversations {
+
+		if membership.ConversationType != models.GroupConversationType {
+			continue
+		}
+
+		groupConversation, err := userEnv.Store.GetGroupConversation(userEnv.TraceContext(), membership.ConversationID)
+
+		// Patterns may outlive their group conversation
+		if err != nil {
+			continue
+		}
+
+		membership.Name = groupConversation.Name
+
+		for _, member := range groupConversation.Members {
+			if member == internalWaveUserID {
+				membership.Member = true
+			}
+		}
+	}
+
+	return userACL, nil
+}
+
+// addMembership : Record conversation access granted to clientID by ACL pattern, patterns outside conversation topics are ignored
+func addMembership(userACL *models.UserACL, memberships map[string]*models.ConversationMembership, topicPaths *models.TopicPaths, clientID string, pattern string, publish bool) {
+
+	topic, err := topicPaths.ParseConversationTopic(pattern)
+
+	if err != nil {
+		return
+	}
+
+	conversationID := topic.ConversationID
+
+	// Private subscribe patterns ({private}+/{clientID}) are identified by their recipient
+	if topic.ConversationType == models.PrivateConversationType && !publish {
+		conversationID = pattern[strings.LastIndex(pattern, "/")+1:]
+	}
+
+	key := topic.ConversationType + "/" + conversationID
+	membership, ok := memberships[key]
+
+	if !ok {
+
+		membership = &models.ConversationMembership{
+			ConversationType: topic.ConversationType,
+			ConversationID:   conversationID,
+			Member:           topic.ConversationType == models.PrivateConversationType,
+			ClientIDs:        []string{},
+		}
+
+		memberships[key] = membership
+		userACL.Conversations = append(userACL.Conversations, membership)
+	}
+
+	if publish {
+		membership.Publish = true
+	} else {
+		membership.Subscribe = true
+	}
+
+	for _, grantedClientID := range membership.ClientIDs {
+		if grantedClientID == clientID {
+			return
+		}
+	}
+
+	membership.ClientIDs = append(membership.ClientIDs, clientID)
+}
+
+// ListUserConversations : List group conversations internalWaveUserID is a member of, sorted by name.
+// Group conversations are looked up in the user tenant
+func ListUserConversations(env *models.Env, internalWaveUserID string, pagination *utils.Pagination) (*models.GroupConversationsPage, error) {
+
+	userEnv := env.ForTenant(GetUserTenant(env, internalWaveUserID))
+
+	groupConversations, total, err := userEnv.Store.GetGroupMemberships(userEnv.TraceContext(), internalWaveUserID, pagination)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if groupConversations == nil {
+		groupConversations = []*models.GroupConversation{}
+	}
+
+	return &models.GroupConversationsPage{
+		Page:          utils.NewPage(pagination, total),
+		Conversations: groupConversations,
+	}, nil
+}
+
+// DisconnectUser : Disconnect sessions of all devices of internalWaveUserID.
+// Unlike SuspendUser, ACLs are kept and devices may reconnect right away
+func DisconnectUser(env *models.Env, internalWaveUserID string) (*models.UserSessions, error) {
+
+	verneMQACLs, err := env.Store.GetUserACLs(env.TraceContext(), internalWaveUserID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if len(verneMQACLs) == 0 {
+		return nil, ErrUnknownUser
+	}
+
+	sessions := &models.UserSessions{
+		InternalWaveUserID: internalWaveUserID,
+		ClientIDs:          []string{},
+	}
+
+	for _, verneMQACL := range verneMQACLs {
+
+		err = env.Broker.DisconnectSession(verneMQACL.ClientID)
+
+		if err != nil {
+			return nil, err
+		}
+
+		sessions.ClientIDs = append(sessions.ClientIDs, verneMQACL.ClientID)
+	}
+
+	return sessions, nil
+}
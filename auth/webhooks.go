@@ -0,0 +1,623 @@
+package auth
+
+import (
+	bytes "bytes"
+	context "context"
+	rand "crypto/rand"
+	base64 "encoding/base64"
+	json "encoding/json"
+	errors "errors"
+	fmt "fmt"
+	io "io"
+	ioutil "io/ioutil"
+	http "net/http"
+	strconv "strconv"
+	sync "sync"
+	time "time"
+	models "wave-messaging-management-service/models"
+	utils "wave-messaging-management-service/utils"
+
+	uuid "github.com/satori/go.uuid"
+	logrus "github.com/sirupsen/logrus"
+)
+
+const (
+	// WebhookEventHeader : HTTP header of webhook deliveries holding the type of the delivered event
+	WebhookEventHeader = "X-Wave-Event"
+
+	// WebhookDeliveryHeader : HTTP header of webhook deliveries holding the delivery ID, identical across its attempts
+	WebhookDeliveryHeader = "X-Wave-Delivery"
+
+	// webhookSecretLength : Random bytes of generated webhook secrets
+	webhookSecretLength = 32
+
+	// webhookErrorMaxLength : Recorded length of failed attempts errors
+	webhookErrorMaxLength = 256
+)
+
+var (
+	// ErrUnknownWebhook : Webhook is not registered in the tenant
+	ErrUnknownWebhook = errors.New("Unknown webhook")
+
+	// ErrUnknownDeadLetter : Dead letter does not exist in the tenant
+	ErrUnknownDeadLetter = errors.New("Unknown webhook dead letter")
+
+	// webhookClient : HTTP client of webhook deliveries, attempts being bound by their context
+	webhookClient = &http.Client{}
+
+	// webhookBreakers : Circuit breakers of webhook URLs, so that failing endpoints don't hold deliveries to others
+	webhookBreakers      = map[string]*CircuitBreaker{}
+	webhookBreakersMutex sync.Mutex
+)
+
+// CreateWebhook : Register webhook of body in the tenant of env, generating its ID and signing secret
+func CreateWebhook(env *models.Env, body *utils.WebhookBody) (*models.Webhook, error) {
+
+	data := make([]byte, webhookSecretLength)
+
+	_, err := rand.Read(data)
+
+	if err != nil {
+		return nil, err
+	}
+
+	webhook := &models.Webhook{
+		ID:        uuid.NewV4().String(),
+		URL:       body.URL,
+		Events:    body.Events,
+		Secret:    base64.RawURLEncoding.EncodeToString(data),
+		CreatedAt: time.Now().UTC(),
+	}
+
+	err = env.Store.AddWebhook(env.TraceContext(), webhook)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return webhook, nil
+}
+
+// ListWebhooks : Return webhooks registered in the tenant of env, without their secret
+func ListWebhooks(env *models.Env) (*models.Webhooks, error) {
+
+	webhooks, err := env.Store.GetWebhooks(env.TraceContext())
+
+	if err != nil {
+		return nil, err
+	}
+
+	for _, webhook := range webhooks {
+		webhook.Secret = ""
+	}
+
+	return &models.Webhooks{Webhooks: webhooks}, nil
+}
+
+// RemoveWebhook : Remove webhook of the tenant of env, its deliveries and dead letters, returning ErrUnknownWebhook if it is not registered
+func RemoveWebhook(env *models.Env, webhookID string) error {
+
+	removed, err := env.Store.RemoveWebhook(env.TraceContext(), webhookID)
+
+	if err != nil {
+		return err
+	}
+
+	if !removed {
+		return ErrUnknownWebhook
+	}
+
+	return nil
+}
+
+// ListWebhookDeliveries : Return page of deliveries of webhook of the tenant of env, most recent first.
+// Returns ErrUnknownWebhook if it is not registered
+func ListWebhookDeliveries(env *models.Env, webhookID string, pagination *utils.Pagination) (*models.WebhookDeliveriesPage, error) {
+
+	webhook, err := env.Store.GetWebhook(env.TraceContext(), webhookID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if webhook == nil {
+		return nil, ErrUnknownWebhook
+	}
+
+	deliveries, total, err := env.Store.GetWebhookDeliveries(env.TraceContext(), webhookID, pagination)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.WebhookDeliveriesPage{
+		Page:       utils.NewPage(pagination, total),
+		Deliveries: deliveries,
+	}, nil
+}
+
+// DeliverWebhooks : Persist deliveries of event to webhooks of the tenant of env subscribed to its type (See RelayOutbox).
+// Deliveries are then attempted in background until acknowledged or dead-lettered (See StartWebhookDeliveries)
+func DeliverWebhooks(env *models.Env, event *models.LifecycleEvent) error {
+
+	webhooks, err := env.Store.GetWebhooks(env.TraceContext())
+
+	if err != nil {
+		return err
+	}
+
+	payload, err := json.Marshal(event)
+
+	if err != nil {
+		return err
+	}
+
+	for _, webhook := range webhooks {
+
+		if !webhook.Subscribes(event.Type) {
+			continue
+		}
+
+		delivery, err := queueWebhookDelivery(env, webhook.ID, event.ID, event.Type, payload)
+
+		if err != nil {
+			return err
+		}
+
+		// First attempts don't wait for the next poll, nor end with the request which emitted the event
+		env.Workers.Go(func() {
+			env.Guard(func() {
+				claimWebhookDelivery(env.WithTraceContext(context.Background()), delivery)
+			})
+		})
+	}
+
+	return nil
+}
+
+// ListWebhookDeadLetters : Return page of dead letters of the tenant of env matching webhookID (All webhooks when empty), most recent first
+func ListWebhookDeadLetters(env *models.Env, webhookID string, pagination *utils.Pagination) (*models.WebhookDeadLettersPage, error) {
+
+	deadLetters, total, err := env.Store.GetWebhookDeadLetters(env.TraceContext(), &models.WebhookDeadLetterFilter{
+		TenantID:  env.TenantID,
+		WebhookID: webhookID,
+		Page:      pagination,
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	for _, deadLetter := range deadLetters {
+
+		deadLetter.Event = &models.LifecycleEvent{}
+
+		err = json.Unmarshal(deadLetter.Payload, deadLetter.Event)
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return &models.WebhookDeadLettersPage{
+		Page:        utils.NewPage(pagination, total),
+		DeadLetters: deadLetters,
+	}, nil
+}
+
+// ReplayWebhookDeadLetter : Queue a new delivery of the event of dead letter to its webhook, then remove the dead letter.
+// Returns ErrUnknownDeadLetter if it does not exist in the tenant of env, ErrUnknownWebhook if its webhook was removed meanwhile
+func ReplayWebhookDeadLetter(env *models.Env, deadLetterID string) (*models.WebhookDelivery, error) {
+
+	deadLetter, err := getWebhookDeadLetter(env, deadLetterID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	webhook, err := env.Store.GetWebhook(env.TraceContext(), deadLetter.WebhookID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if webhook == nil {
+		return nil, ErrUnknownWebhook
+	}
+
+	event := &models.LifecycleEvent{}
+
+	err = json.Unmarshal(deadLetter.Payload, event)
+
+	if err != nil {
+		return nil, err
+	}
+
+	delivery, err := queueWebhookDelivery(env, webhook.ID, event.ID, event.Type, deadLetter.Payload)
+
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = env.Store.RemoveWebhookDeadLetter(env.TraceContext(), deadLetter.ID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return delivery, nil
+}
+
+// DiscardWebhookDeadLetter : Remove dead letter, returning ErrUnknownDeadLetter if it does not exist in the tenant of env
+func DiscardWebhookDeadLetter(env *models.Env, deadLetterID string) error {
+
+	deadLetter, err := getWebhookDeadLetter(env, deadLetterID)
+
+	if err != nil {
+		return err
+	}
+
+	removed, err := env.Store.RemoveWebhookDeadLetter(env.TraceContext(), deadLetter.ID)
+
+	if err != nil {
+		return err
+	}
+
+	if !removed {
+		return ErrUnknownDeadLetter
+	}
+
+	return nil
+}
+
+// StartWebhookDeliveries : Attempt deliveries due every configured poll interval, in background until workers are stopped.
+// Deliveries are leased while attempted, so that instances sharing the database never attempt a delivery twice at a time
+func StartWebhookDeliveries(env *models.Env) {
+
+	interval := func() time.Duration {
+
+		interval := env.Config.Webhooks.PollInterval
+
+		if interval <= 0 {
+			interval = models.DefaultWebhookPollInterval
+		}
+
+		return time.Duration(interval) * time.Second
+	}
+
+	env.Workers.Every(interval, func() {
+		env.Guard(func() {
+
+			err := AttemptWebhookDeliveries(env)
+
+			if err != nil {
+				env.Logger.WithError(err).Error("Failed to attempt webhook deliveries")
+			}
+		})
+	})
+}
+
+// AttemptWebhookDeliveries : Attempt deliveries of all tenants due now, a batch of concurrency deliveries at a time,
+// until none are due or workers are stopped
+func AttemptWebhookDeliveries(env *models.Env) error {
+
+	concurrency := env.Config.Webhooks.Concurrency
+
+	if concurrency <= 0 {
+		concurrency = models.DefaultWebhookConcurrency
+	}
+
+	for {
+
+		deliveries, err := env.Store.GetDueWebhookDeliveries(env.TraceContext(), time.Now().UTC(), concurrency)
+
+		if err != nil {
+			return err
+		}
+
+		running := sync.WaitGroup{}
+
+		for _, delivery := range deliveries {
+
+			running.Add(1)
+
+			go func(delivery *models.WebhookDelivery) {
+				defer running.Done()
+				env.Guard(func() {
+					claimWebhookDelivery(env, delivery)
+				})
+			}(delivery)
+		}
+
+		running.Wait()
+
+		if len(deliveries) < concurrency {
+			return nil
+		}
+
+		select {
+		case <-env.Workers.Stopping():
+			return nil
+		default:
+		}
+	}
+}
+
+// queueWebhookDelivery : Persist pending delivery of event payload to webhookID, due now
+func queueWebhookDelivery(env *models.Env, webhookID string, eventID string, eventType string, payload []byte) (*models.WebhookDelivery, error) {
+
+	now := time.Now().UTC()
+
+	delivery := &models.WebhookDelivery{
+		ID:            uuid.NewV4().String(),
+		WebhookID:     webhookID,
+		TenantID:      env.TenantID,
+		EventID:       eventID,
+		EventType:     eventType,
+		Status:        models.WebhookDeliveryPending,
+		Payload:       payload,
+		CreatedAt:     now,
+		NextAttemptAt: &now,
+	}
+
+	err := env.Store.AddWebhookDelivery(env.TraceContext(), delivery)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return delivery, nil
+}
+
+// claimWebhookDelivery : Lease delivery, then attempt it. Deliveries leased by another instance are left to it
+func claimWebhookDelivery(env *models.Env, delivery *models.WebhookDelivery) {
+
+	now := time.Now().UTC()
+
+	// Leases outlast attempts, so that deliveries of crashed instances are attempted again once their lease expires
+	leaseUntil := now.Add(2 * webhookTimeout(env))
+
+	claimed, err := env.Store.ClaimWebhookDelivery(env.TraceContext(), delivery.ID, now, leaseUntil)
+
+	if err != nil {
+		env.Logger.WithError(err).WithField("deliveryID", delivery.ID).Error("Failed to lease webhook delivery")
+		return
+	}
+
+	if !claimed {
+		return
+	}
+
+	delivery.LeaseUntil = leaseUntil
+
+	attemptWebhookDelivery(env.ForTenant(delivery.TenantID), delivery)
+}
+
+// attemptWebhookDelivery : Attempt leased delivery, then record its outcome : Delivered, scheduled for another attempt with exponential backoff,
+// or dead-lettered once its attempts are exhausted. Attempts to webhook URLs whose circuit is open are postponed without counting
+func attemptWebhookDelivery(env *models.Env, delivery *models.WebhookDelivery) {
+
+	config := env.Config.Webhooks
+
+	logger := env.Logger.WithFields(logrus.Fields{"webhookID": delivery.WebhookID, "deliveryID": delivery.ID, "type": delivery.EventType})
+
+	webhook, err := env.Store.GetWebhook(env.TraceContext(), delivery.WebhookID)
+
+	if err != nil {
+		logger.WithError(err).Error("Failed to get webhook")
+		return
+	}
+
+	// Deliveries of removed webhooks are removed with them
+	if webhook == nil {
+		return
+	}
+
+	breaker := webhookBreaker(env, webhook.URL)
+
+	now := time.Now().UTC()
+
+	if breaker.Allow(&config.CircuitBreaker) != nil {
+
+		nextAttemptAt := now.Add(BreakerOpenTimeout(&config.CircuitBreaker))
+		delivery.NextAttemptAt, delivery.LeaseUntil = &nextAttemptAt, now
+
+		models.WebhookDeliveries.WithLabelValues(delivery.EventType, "postponed").Inc()
+		recordWebhookDelivery(env, logger, delivery)
+
+		return
+	}
+
+	delivery.Attempts++
+
+	delivery.ResponseStatus, err = postWebhook(env, webhook, delivery)
+
+	breaker.Record(&config.CircuitBreaker, err, err != nil)
+
+	now = time.Now().UTC()
+	delivery.LeaseUntil = now
+
+	if err == nil {
+
+		delivery.Status, delivery.Error, delivery.NextAttemptAt, delivery.DeliveredAt = models.WebhookDeliveryDelivered, "", nil, &now
+
+		models.WebhookDeliveries.WithLabelValues(delivery.EventType, "delivered").Inc()
+		recordWebhookDelivery(env, logger, delivery)
+
+		return
+	}
+
+	delivery.Error = err.Error()
+
+	if len(delivery.Error) > webhookErrorMaxLength {
+		delivery.Error = delivery.Error[:webhookErrorMaxLength]
+	}
+
+	maxAttempts := config.MaxAttempts
+
+	if maxAttempts <= 0 {
+		maxAttempts = models.DefaultWebhookMaxAttempts
+	}
+
+	if delivery.Attempts < maxAttempts {
+
+		nextAttemptAt := now.Add(webhookRetryDelay(&config, delivery.Attempts))
+		delivery.NextAttemptAt = &nextAttemptAt
+
+		models.WebhookDeliveries.WithLabelValues(delivery.EventType, "failed").Inc()
+		recordWebhookDelivery(env, logger, delivery)
+
+		return
+	}
+
+	delivery.Status, delivery.NextAttemptAt = models.WebhookDeliveryDeadLettered, nil
+
+	models.WebhookDeliveries.WithLabelValues(delivery.EventType, "deadLettered").Inc()
+	logger.WithField("attempts", delivery.Attempts).WithField("error", delivery.Error).Warn("Webhook delivery dead-lettered")
+
+	// Dead letter is added first, so that a failure leaves the delivery pending rather than lost
+	err = env.Store.AddWebhookDeadLetter(env.TraceContext(), &models.WebhookDeadLetter{
+		ID:             uuid.NewV4().String(),
+		WebhookID:      delivery.WebhookID,
+		TenantID:       delivery.TenantID,
+		DeliveryID:     delivery.ID,
+		URL:            webhook.URL,
+		EventType:      delivery.EventType,
+		Attempts:       delivery.Attempts,
+		ResponseStatus: delivery.ResponseStatus,
+		Error:          delivery.Error,
+		Payload:        delivery.Payload,
+		DeadLetteredAt: now,
+	})
+
+	if err != nil {
+		logger.WithError(err).Error("Failed to dead-letter webhook delivery")
+		return
+	}
+
+	recordWebhookDelivery(env, logger, delivery)
+}
+
+// recordWebhookDelivery : Record current status of delivery, failures being logged as the delivery is attempted again once its lease expires
+func recordWebhookDelivery(env *models.Env, logger *logrus.Entry, delivery *models.WebhookDelivery) {
+
+	err := env.Store.UpdateWebhookDelivery(env.TraceContext(), delivery)
+
+	if err != nil {
+		logger.WithError(err).Error("Failed to record webhook delivery")
+	}
+}
+
+// getWebhookDeadLetter : Return dead letter deadLetterID, ErrUnknownDeadLetter if it does not exist in the tenant of env
+func getWebhookDeadLetter(env *models.Env, deadLetterID string) (*models.WebhookDeadLetter, error) {
+
+	deadLetter, err := env.Store.GetWebhookDeadLetter(env.TraceContext(), deadLetterID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	// Dead letters are shared by tenants, those of other tenants are unknown
+	if deadLetter == nil || deadLetter.TenantID != env.TenantID {
+		return nil, ErrUnknownDeadLetter
+	}
+
+	return deadLetter, nil
+}
+
+// webhookBreaker : Return circuit breaker of webhook URL, created on first use. Breakers are per instance
+func webhookBreaker(env *models.Env, url string) *CircuitBreaker {
+
+	webhookBreakersMutex.Lock()
+	defer webhookBreakersMutex.Unlock()
+
+	breaker, ok := webhookBreakers[url]
+
+	if !ok {
+		breaker = &CircuitBreaker{Logger: env.Logger.WithField("url", url), Name: "Webhook"}
+		webhookBreakers[url] = breaker
+	}
+
+	return breaker
+}
+
+// webhookRetryDelay : Return delay before the attempt following attempts of a delivery, doubled after each attempt up to the maximum retry delay
+func webhookRetryDelay(config *models.WebhooksConfig, attempts int) time.Duration {
+
+	retryDelay := config.RetryDelay
+
+	if retryDelay <= 0 {
+		retryDelay = models.DefaultWebhookRetryDelay
+	}
+
+	maxRetryDelay := config.MaxRetryDelay
+
+	if maxRetryDelay <= 0 {
+		maxRetryDelay = models.DefaultWebhookMaxRetryDelay
+	}
+
+	delay := time.Duration(retryDelay) * time.Second
+
+	for i := 1; i < attempts && delay < time.Duration(maxRetryDelay)*time.Second; i++ {
+		delay *= 2
+	}
+
+	if delay > time.Duration(maxRetryDelay)*time.Second {
+		delay = time.Duration(maxRetryDelay) * time.Second
+	}
+
+	return delay
+}
+
+// webhookTimeout : Return duration webhook URLs have to answer an attempt
+func webhookTimeout(env *models.Env) time.Duration {
+
+	timeout := env.Config.Webhooks.Timeout
+
+	if timeout <= 0 {
+		timeout = models.DefaultWebhookTimeout
+	}
+
+	return time.Duration(timeout) * time.Second
+}
+
+// postWebhook : POST payload of delivery to webhook, signed with its secret, returning the response status. Statuses other than 2xx are errors
+func postWebhook(env *models.Env, webhook *models.Webhook, delivery *models.WebhookDelivery) (int, error) {
+
+	ctx, cancel := context.WithTimeout(context.Background(), webhookTimeout(env))
+	defer cancel()
+
+	body := delivery.Payload
+
+	req, err := http.NewRequest(http.MethodPost, webhook.URL, bytes.NewReader(body))
+
+	if err != nil {
+		return 0, err
+	}
+
+	// Deliveries are signed like requests to the service, so that integrators verify them the same way
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Wave-Timestamp", timestamp)
+	req.Header.Set("X-Wave-Signature", SignRequest(webhook.Secret, timestamp, req.Method, req.URL.RequestURI(), body))
+	req.Header.Set(WebhookEventHeader, delivery.EventType)
+	req.Header.Set(WebhookDeliveryHeader, delivery.ID)
+
+	res, err := webhookClient.Do(req.WithContext(ctx))
+
+	if err != nil {
+		return 0, err
+	}
+
+	defer res.Body.Close()
+
+	// Connections are reused once bodies are drained
+	io.Copy(ioutil.Discard, io.LimitReader(res.Body, 1<<16))
+
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return res.StatusCode, fmt.Errorf("Webhook answered with status %d", res.StatusCode)
+	}
+
+	return res.StatusCode, nil
+}
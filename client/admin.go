@@ -0,0 +1,377 @@
+package client
+
+import (
+	context "context"
+	http "net/http"
+	url "net/url"
+	strconv "strconv"
+	time "time"
+	models "wave-messaging-management-service/models"
+	utils "wave-messaging-management-service/utils"
+)
+
+// Admin endpoints, authenticated with the API key of the client granted admin scopes
+
+// PageQuery : Page of a list endpoint, Cursor being the next or previous cursor of a page. The first page is returned when Cursor is empty
+type PageQuery struct {
+	Cursor string
+	Limit  int
+}
+
+// UsageQuery : Usage report query, dates formatted as models.UsageDateLayout. Server defaults apply to empty fields
+type UsageQuery struct {
+	UserID   string
+	TenantID string
+	From     string
+	To       string
+	GroupBy  string
+}
+
+// AuditQuery : Audit log query, empty fields not filtering entries
+type AuditQuery struct {
+	ActorType string
+	ActorID   string
+	Target    string
+	Action    string
+	TenantID  *string
+	From      *time.Time
+	To        *time.Time
+	Page      PageQuery
+}
+
+// values : Query parameters of page
+func (page PageQuery) values() url.Values {
+
+	query := url.Values{}
+
+	if page.Cursor != "" {
+		query.Set("cursor", page.Cursor)
+	}
+
+	if page.Limit > 0 {
+		query.Set("limit", strconv.Itoa(page.Limit))
+	}
+
+	return query
+}
+
+// setQuery : Set query parameter name, if value is not empty
+func setQuery(query url.Values, name string, value string) {
+
+	if value != "" {
+		query.Set(name, value)
+	}
+}
+
+// ListUsers : List users whose original or internal user ID contains search (Every user when empty)
+func (client *Client) ListUsers(ctx context.Context, search string, page PageQuery) (*models.UsersPage, error) {
+
+	query := page.values()
+	setQuery(query, "search", search)
+
+	usersPage := &models.UsersPage{}
+
+	err := client.do(ctx, &request{method: http.MethodGet, path: "/v1/admin/users", query: query, credentials: credentialsAPIKey}, usersPage)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return usersPage, nil
+}
+
+// GetUserACL : Get effective ACLs of an internal Wave user
+func (client *Client) GetUserACL(ctx context.Context, internalWaveUserID string) (*models.UserACL, error) {
+
+	userACL := &models.UserACL{}
+
+	err := client.do(ctx, &request{method: http.MethodGet, path: "/v1/admin/users/" + url.PathEscape(internalWaveUserID) + "/acl", credentials: credentialsAPIKey}, userACL)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return userACL, nil
+}
+
+// SuspendUser : Revoke all access of an internal Wave user, return its mapping
+func (client *Client) SuspendUser(ctx context.Context, internalWaveUserID string) (*models.Mapping, error) {
+
+	mapping := &models.Mapping{}
+
+	err := client.do(ctx, &request{method: http.MethodPost, path: "/v1/admin/users/" + url.PathEscape(internalWaveUserID) + "/suspend", credentials: credentialsAPIKey, idempotent: true}, mapping)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return mapping, nil
+}
+
+// ListUserConversations : List group conversations an internal Wave user is a member of, sorted by name
+func (client *Client) ListUserConversations(ctx context.Context, internalWaveUserID string, page PageQuery) (*models.GroupConversationsPage, error) {
+
+	conversationsPage := &models.GroupConversationsPage{}
+
+	err := client.do(ctx, &request{method: http.MethodGet, path: "/v1/admin/users/" + url.PathEscape(internalWaveUserID) + "/conversations", query: page.values(), credentials: credentialsAPIKey}, conversationsPage)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return conversationsPage, nil
+}
+
+// DisconnectUser : Disconnect sessions of all devices of an internal Wave user, which may reconnect
+func (client *Client) DisconnectUser(ctx context.Context, internalWaveUserID string) (*models.UserSessions, error) {
+
+	sessions := &models.UserSessions{}
+
+	err := client.do(ctx, &request{method: http.MethodPost, path: "/v1/admin/users/" + url.PathEscape(internalWaveUserID) + "/disconnect", credentials: credentialsAPIKey, retryable: true}, sessions)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return sessions, nil
+}
+
+// RestoreUserACLs : Restore removed VerneMQ ACLs of an internal Wave user, return its effective ACLs
+func (client *Client) RestoreUserACLs(ctx context.Context, internalWaveUserID string) (*models.UserACL, error) {
+
+	userACL := &models.UserACL{}
+
+	err := client.do(ctx, &request{method: http.MethodPost, path: "/v1/admin/users/" + url.PathEscape(internalWaveUserID) + "/restore", credentials: credentialsAPIKey, idempotent: true}, userACL)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return userACL, nil
+}
+
+// RemoveGroupConversation : Remove a group conversation of the tenant, revoking access of its members. It can be restored until purged
+func (client *Client) RemoveGroupConversation(ctx context.Context, groupConversationID string) (*models.GroupConversation, error) {
+
+	groupConversation := &models.GroupConversation{}
+
+	err := client.do(ctx, &request{method: http.MethodDelete, path: "/v1/admin/conversations/group/" + url.PathEscape(groupConversationID), credentials: credentialsAPIKey}, groupConversation)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return groupConversation, nil
+}
+
+// RestoreGroupConversation : Restore a removed group conversation of the tenant
+func (client *Client) RestoreGroupConversation(ctx context.Context, groupConversationID string) (*models.GroupConversation, error) {
+
+	groupConversation := &models.GroupConversation{}
+
+	err := client.do(ctx, &request{method: http.MethodPost, path: "/v1/admin/conversations/group/" + url.PathEscape(groupConversationID) + "/restore", credentials: credentialsAPIKey, idempotent: true}, groupConversation)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return groupConversation, nil
+}
+
+// GetUserQuotas : Get quotas of an internal Wave user, with its usage
+func (client *Client) GetUserQuotas(ctx context.Context, internalWaveUserID string) (*models.UserQuotas, error) {
+
+	userQuotas := &models.UserQuotas{}
+
+	err := client.do(ctx, &request{method: http.MethodGet, path: "/v1/admin/users/" + url.PathEscape(internalWaveUserID) + "/quotas", credentials: credentialsAPIKey}, userQuotas)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return userQuotas, nil
+}
+
+// SetUserQuotas : Override quotas of an internal Wave user, nil quotas falling back to the configured ones
+func (client *Client) SetUserQuotas(ctx context.Context, internalWaveUserID string, override *models.QuotaOverride) error {
+	return client.do(ctx, &request{method: http.MethodPut, path: "/v1/admin/users/" + url.PathEscape(internalWaveUserID) + "/quotas", body: override, credentials: credentialsAPIKey}, nil)
+}
+
+// Broadcast : Publish a system message to users, all users of the tenant when none is listed
+func (client *Client) Broadcast(ctx context.Context, broadcast utils.BroadcastBody) (*models.BroadcastReport, error) {
+
+	report := &models.BroadcastReport{}
+
+	err := client.do(ctx, &request{method: http.MethodPost, path: "/v1/admin/broadcasts", body: broadcast, credentials: credentialsAPIKey}, report)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return report, nil
+}
+
+// GetUsageReport : Get usage aggregated per tenant or per user
+func (client *Client) GetUsageReport(ctx context.Context, usageQuery UsageQuery) (*models.UsageReport, error) {
+
+	query := url.Values{}
+	setQuery(query, "userID", usageQuery.UserID)
+	setQuery(query, "tenantID", usageQuery.TenantID)
+	setQuery(query, "from", usageQuery.From)
+	setQuery(query, "to", usageQuery.To)
+	setQuery(query, "groupBy", usageQuery.GroupBy)
+
+	report := &models.UsageReport{}
+
+	err := client.do(ctx, &request{method: http.MethodGet, path: "/v1/admin/usage", query: query, credentials: credentialsAPIKey}, report)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return report, nil
+}
+
+// GetAuditLog : Query the audit log, most recent entries first
+func (client *Client) GetAuditLog(ctx context.Context, auditQuery AuditQuery) (*models.AuditPage, error) {
+
+	query := auditQuery.Page.values()
+	setQuery(query, "actorType", auditQuery.ActorType)
+	setQuery(query, "actorID", auditQuery.ActorID)
+	setQuery(query, "target", auditQuery.Target)
+	setQuery(query, "action", auditQuery.Action)
+
+	// An empty tenant ID filters entries of actions outside tenants
+	if auditQuery.TenantID != nil {
+		query.Set("tenantID", *auditQuery.TenantID)
+	}
+
+	if auditQuery.From != nil {
+		query.Set("from", auditQuery.From.Format(time.RFC3339))
+	}
+
+	if auditQuery.To != nil {
+		query.Set("to", auditQuery.To.Format(time.RFC3339))
+	}
+
+	auditPage := &models.AuditPage{}
+
+	err := client.do(ctx, &request{method: http.MethodGet, path: "/v1/admin/audit", query: query, credentials: credentialsAPIKey}, auditPage)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return auditPage, nil
+}
+
+// GetLogging : Get logging settings of the instance answering the request
+func (client *Client) GetLogging(ctx context.Context) (*models.LoggingConfig, error) {
+
+	config := &models.LoggingConfig{}
+
+	err := client.do(ctx, &request{method: http.MethodGet, path: "/v1/admin/logging", credentials: credentialsAPIKey}, config)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return config, nil
+}
+
+// SetLogging : Change logging settings of the instance answering the request, return them as applied
+func (client *Client) SetLogging(ctx context.Context, logging models.LoggingConfig) (*models.LoggingConfig, error) {
+
+	config := &models.LoggingConfig{}
+
+	err := client.do(ctx, &request{method: http.MethodPut, path: "/v1/admin/logging", body: logging, credentials: credentialsAPIKey}, config)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return config, nil
+}
+
+// CreateWebhook : Register an outbound webhook receiving lifecycle events of the tenant, returned with its signing secret
+func (client *Client) CreateWebhook(ctx context.Context, webhook utils.WebhookBody) (*models.Webhook, error) {
+
+	created := &models.Webhook{}
+
+	err := client.do(ctx, &request{method: http.MethodPost, path: "/v1/admin/webhooks", body: webhook, credentials: credentialsAPIKey}, created)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return created, nil
+}
+
+// ListWebhooks : List outbound webhooks of the tenant, without their secret
+func (client *Client) ListWebhooks(ctx context.Context) (*models.Webhooks, error) {
+
+	webhooks := &models.Webhooks{}
+
+	err := client.do(ctx, &request{method: http.MethodGet, path: "/v1/admin/webhooks", credentials: credentialsAPIKey}, webhooks)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return webhooks, nil
+}
+
+// RemoveWebhook : Remove an outbound webhook of the tenant, its deliveries and dead letters
+func (client *Client) RemoveWebhook(ctx context.Context, webhookID string) error {
+	return client.do(ctx, &request{method: http.MethodDelete, path: "/v1/admin/webhooks/" + url.PathEscape(webhookID), credentials: credentialsAPIKey}, nil)
+}
+
+// ListWebhookDeliveries : List deliveries of an outbound webhook of the tenant, most recent first
+func (client *Client) ListWebhookDeliveries(ctx context.Context, webhookID string, page PageQuery) (*models.WebhookDeliveriesPage, error) {
+
+	deliveriesPage := &models.WebhookDeliveriesPage{}
+
+	err := client.do(ctx, &request{method: http.MethodGet, path: "/v1/admin/webhooks/" + url.PathEscape(webhookID) + "/deliveries", query: page.values(), credentials: credentialsAPIKey}, deliveriesPage)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return deliveriesPage, nil
+}
+
+// ListWebhookDeadLetters : List deliveries of the tenant dead-lettered after their last attempt, of webhookID (All webhooks when empty), most recent first
+func (client *Client) ListWebhookDeadLetters(ctx context.Context, webhookID string, page PageQuery) (*models.WebhookDeadLettersPage, error) {
+
+	query := page.values()
+	setQuery(query, "webhookID", webhookID)
+
+	deadLettersPage := &models.WebhookDeadLettersPage{}
+
+	err := client.do(ctx, &request{method: http.MethodGet, path: "/v1/admin/webhooks/deadletters", query: query, credentials: credentialsAPIKey}, deadLettersPage)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return deadLettersPage, nil
+}
+
+// ReplayWebhookDeadLetter : Deliver a dead-lettered event again to its webhook, returning the new delivery
+func (client *Client) ReplayWebhookDeadLetter(ctx context.Context, deadLetterID string) (*models.WebhookDelivery, error) {
+
+	delivery := &models.WebhookDelivery{}
+
+	err := client.do(ctx, &request{method: http.MethodPost, path: "/v1/admin/webhooks/deadletters/" + url.PathEscape(deadLetterID) + "/replay", credentials: credentialsAPIKey}, delivery)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return delivery, nil
+}
+
+// DiscardWebhookDeadLetter : Discard a dead-lettered delivery of the tenant
+func (client *Client) DiscardWebhookDeadLetter(ctx context.Context, deadLetterID string) error {
+	return client.do(ctx, &request{method: http.MethodDelete, path: "/v1/admin/webhooks/deadletters/" + url.PathEscape(deadLetterID), credentials: credentialsAPIKey}, nil)
+}
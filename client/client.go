@@ -0,0 +1,307 @@
+package client
+
+import (
+	bytes "bytes"
+	context "context"
+	hmac "crypto/hmac"
+	sha256 "crypto/sha256"
+	hex "encoding/hex"
+	json "encoding/json"
+	fmt "fmt"
+	ioutil "io/ioutil"
+	rand "math/rand"
+	http "net/http"
+	url "net/url"
+	strconv "strconv"
+	strings "strings"
+	time "time"
+
+	uuid "github.com/satori/go.uuid"
+)
+
+const (
+	// DefaultMaxRetries : Retries of failed requests, used when none is configured
+	DefaultMaxRetries = 2
+
+	// DefaultRetryBaseDelay : Delay before the first retry, doubled on each retry, used when none is configured
+	DefaultRetryBaseDelay = 100 * time.Millisecond
+
+	// DefaultTimeout : Timeout of requests of the default HTTP client
+	DefaultTimeout = 30 * time.Second
+
+	// maxRetryAfter : Longest Retry-After delay waited for, longer ones fail the request
+	maxRetryAfter = 10 * time.Second
+)
+
+// Config : Client Config. Token (and IdentityProvider) authenticate user endpoints, APIKey internal services and admin endpoints,
+// whose requests are signed with SigningSecret when set (See Request Signing). MaxRetries is negative to disable retries
+type Config struct {
+	BaseURL          string
+	Token            string
+	IdentityProvider string
+	APIKey           string
+	SigningSecret    string
+	HTTPClient       *http.Client
+	MaxRetries       int
+	RetryBaseDelay   time.Duration
+}
+
+// Client : Client of the management API, wrapping its endpoints with typed requests and responses.
+// Failed requests which can be safely repeated (Reads, PUT, DELETE and idempotent endpoints, sent with an Idempotency-Key) are retried
+// on network errors, 429 and 5xx statuses. Errors answered by the service are returned as *Error
+type Client struct {
+	config     Config
+	httpClient *http.Client
+}
+
+// Error : Error answered by the service (See Error Responses), Status being the HTTP status
+type Error struct {
+	Status    int                    `json:"-"`
+	Code      string                 `json:"code"`
+	Message   string                 `json:"message"`
+	Details   map[string]interface{} `json:"details,omitempty"`
+	RequestID string                 `json:"requestID"`
+}
+
+func (err *Error) Error() string {
+	return fmt.Sprintf("%d %s: %s", err.Status, err.Code, err.Message)
+}
+
+// credentials : Credentials a request is authenticated with
+type credentials int
+
+const (
+	credentialsNone credentials = iota
+	credentialsToken
+	credentialsAPIKey
+)
+
+// request : Request of an endpoint. Idempotent requests hold an Idempotency-Key, repeated by retries
+type request struct {
+	method      string
+	path        string
+	query       url.Values
+	body        interface{}
+	credentials credentials
+	idempotent  bool
+	retryable   bool
+}
+
+// envelope : Standard response envelope of successful requests, holding the response payload
+type envelope struct {
+	Content json.RawMessage `json:"content"`
+}
+
+// New : Return client of the management API at config.BaseURL (e.g. https://messaging.example.com), with the default HTTP client unless one is configured
+func New(config Config) *Client {
+
+	httpClient := config.HTTPClient
+
+	if httpClient == nil {
+		httpClient = &http.Client{Timeout: DefaultTimeout}
+	}
+
+	if config.MaxRetries == 0 {
+		config.MaxRetries = DefaultMaxRetries
+	}
+
+	if config.RetryBaseDelay <= 0 {
+		config.RetryBaseDelay = DefaultRetryBaseDelay
+	}
+
+	config.BaseURL = strings.TrimSuffix(config.BaseURL, "/")
+
+	return &Client{config: config, httpClient: httpClient}
+}
+
+// do : Send request, retrying it when it can be safely repeated, and decode the payload of its response into result when not nil
+func (client *Client) do(ctx context.Context, req *request, result interface{}) error {
+
+	data, err := client.send(ctx, req)
+
+	if err != nil {
+		return err
+	}
+
+	if result == nil {
+		return nil
+	}
+
+	payload := envelope{}
+
+	err = json.Unmarshal(data, &payload)
+
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(payload.Content, result)
+}
+
+// send : Send request, retrying it when it can be safely repeated, and return the body of its successful response
+func (client *Client) send(ctx context.Context, req *request) ([]byte, error) {
+
+	var body []byte
+
+	if req.body != nil {
+
+		var err error
+
+		body, err = json.Marshal(req.body)
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// Retries share the request ID, so that their attempts can be traced, and the idempotency key, so that they are handled once
+	headers := http.Header{}
+	headers.Set("X-Request-ID", uuid.NewV4().String())
+	headers.Set("Accept", "application/json")
+
+	if req.idempotent {
+		headers.Set("Idempotency-Key", uuid.NewV4().String())
+	}
+
+	maxRetries := client.config.MaxRetries
+
+	if !(req.retryable || req.idempotent) || maxRetries < 0 {
+		maxRetries = 0
+	}
+
+	for attempt := 0; ; attempt++ {
+
+		data, retryAfter, err := client.attempt(ctx, req, headers, body)
+
+		if err == nil || attempt >= maxRetries || retryAfter < 0 {
+			return data, err
+		}
+
+		delay := client.config.RetryBaseDelay << uint(attempt)
+		delay += time.Duration(rand.Int63n(int64(delay)/2 + 1))
+
+		if retryAfter > delay {
+			delay = retryAfter
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(delay):
+		}
+	}
+}
+
+// attempt : Send request once, return the body of its successful response.
+// On failure, return the delay the service asked to wait before retrying (Retry-After), negative when the failure is not transient
+func (client *Client) attempt(ctx context.Context, req *request, headers http.Header, body []byte) ([]byte, time.Duration, error) {
+
+	endpoint := client.config.BaseURL + req.path
+
+	if len(req.query) > 0 {
+		endpoint += "?" + req.query.Encode()
+	}
+
+	httpReq, err := http.NewRequest(req.method, endpoint, bytes.NewReader(body))
+
+	if err != nil {
+		return nil, -1, err
+	}
+
+	httpReq = httpReq.WithContext(ctx)
+
+	for name, values := range headers {
+		httpReq.Header[name] = values
+	}
+
+	if body != nil {
+		httpReq.Header.Set("Content-Type", "application/json")
+	}
+
+	client.authenticate(httpReq, req.credentials, body)
+
+	resp, err := client.httpClient.Do(httpReq)
+
+	// Network errors are transient, unless the caller gave up
+	if err != nil {
+
+		if ctx.Err() != nil {
+			return nil, -1, ctx.Err()
+		}
+
+		return nil, 0, err
+	}
+
+	defer resp.Body.Close()
+
+	data, err := ioutil.ReadAll(resp.Body)
+
+	if err != nil {
+		return nil, 0, err
+	}
+
+	if resp.StatusCode < 400 {
+		return data, 0, nil
+	}
+
+	apiErr := &Error{Status: resp.StatusCode}
+
+	// Bodies of proxies or older versions may not be error envelopes
+	if json.Unmarshal(data, apiErr) != nil || apiErr.Code == "" {
+		apiErr.Message = http.StatusText(resp.StatusCode)
+	}
+
+	if apiErr.RequestID == "" {
+		apiErr.RequestID = resp.Header.Get("X-Request-ID")
+	}
+
+	if resp.StatusCode != http.StatusTooManyRequests && resp.StatusCode < 500 {
+		return nil, -1, apiErr
+	}
+
+	retryAfter := time.Duration(0)
+
+	if seconds, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil {
+		retryAfter = time.Duration(seconds) * time.Second
+	}
+
+	if retryAfter > maxRetryAfter {
+		return nil, -1, apiErr
+	}
+
+	return nil, retryAfter, apiErr
+}
+
+// authenticate : Set credentials headers of request, signing its body for internal services and admin endpoints when a signing secret is configured
+func (client *Client) authenticate(httpReq *http.Request, credentials credentials, body []byte) {
+
+	switch credentials {
+
+	case credentialsToken:
+		httpReq.Header.Set("token", client.config.Token)
+
+		if client.config.IdentityProvider != "" {
+			httpReq.Header.Set("identityProvider", client.config.IdentityProvider)
+		}
+
+	case credentialsAPIKey:
+		httpReq.Header.Set("apiKey", client.config.APIKey)
+
+		if client.config.IdentityProvider != "" {
+			httpReq.Header.Set("identityProvider", client.config.IdentityProvider)
+		}
+
+		if client.config.SigningSecret != "" {
+
+			timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+
+			// HMAC(secret, "{timestamp}\n{method}\n{requestURI}\n{body}"), see auth.SignRequest
+			mac := hmac.New(sha256.New, []byte(client.config.SigningSecret))
+			mac.Write([]byte(timestamp + "\n" + httpReq.Method + "\n" + httpReq.URL.RequestURI() + "\n"))
+			mac.Write(body)
+
+			httpReq.Header.Set("X-Wave-Timestamp", timestamp)
+			httpReq.Header.Set("X-Wave-Signature", hex.EncodeToString(mac.Sum(nil)))
+		}
+	}
+}
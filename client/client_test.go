@@ -0,0 +1,352 @@
+package client_test
+
+import (
+	context "context"
+	http "net/http"
+	httptest "net/http/httptest"
+	sync "sync"
+	testing "testing"
+	time "time"
+	auth "wave-messaging-management-service/auth"
+	client "wave-messaging-management-service/client"
+	models "wave-messaging-management-service/models"
+	memory "wave-messaging-management-service/models/memory"
+	router "wave-messaging-management-service/router"
+	utils "wave-messaging-management-service/utils"
+
+	jwt "github.com/dgrijalva/jwt-go"
+	logrus "github.com/sirupsen/logrus"
+)
+
+const (
+	// testJWTSecret : HMAC secret of the tokens of test users
+	testJWTSecret = "0123456789abcdef0123456789abcdef"
+
+	// testSigningSecret : Secret signing requests of internal services
+	testSigningSecret = "test-signing-secret"
+
+	// testIdentityProvider : Additional identity provider, whose users are authenticated with the same JWTs as default ones
+	testIdentityProvider = "partner"
+)
+
+// testBroker : Broker recording disconnected sessions
+type testBroker struct {
+	mutex        sync.Mutex
+	disconnected []string
+}
+
+func (broker *testBroker) DisconnectSession(clientID string) error {
+
+	broker.mutex.Lock()
+	defer broker.mutex.Unlock()
+
+	broker.disconnected = append(broker.disconnected, clientID)
+
+	return nil
+}
+
+func (broker *testBroker) GetOnlineClients() (map[string]bool, error) {
+	return map[string]bool{}, nil
+}
+
+// newTestServer : Start the router over in-memory datastores, authenticating users with HS256 JWTs. To be closed by the caller
+func newTestServer() (*httptest.Server, *models.Env) {
+
+	redis := memory.NewRedis()
+	auth.HandleMappingScripts(redis)
+
+	env := &models.Env{
+		Store:   memory.NewStore(),
+		Redis:   redis,
+		Broker:  &testBroker{},
+		Logger:  logrus.NewEntry(logrus.New()),
+		Context: context.Background(),
+		Workers: models.NewWorkers(),
+		Config: models.Config{
+			AuthenticationMode: models.AuthenticationModeJWT,
+			JWT:                models.JWTConfig{Algorithm: "HS256", Secret: testJWTSecret},
+			IdentityProviders: []*models.IdentityProviderConfig{{
+				Name:               testIdentityProvider,
+				AuthenticationMode: models.AuthenticationModeJWT,
+				JWT:                models.JWTConfig{Algorithm: "HS256", Secret: testJWTSecret},
+			}},
+			// Cheapest bcrypt cost, passhashes are computed on every authentication
+			Passhash:       models.PasshashConfig{Cost: 4},
+			RequestSigning: models.RequestSigningConfig{Secret: testSigningSecret},
+		},
+	}
+
+	env.AuthProvider = auth.NewProvider(env)
+
+	return httptest.NewServer(router.NewServer(env).Handler), env
+}
+
+// newToken : Return a JWT of userID valid for an hour, signed with secret
+func newToken(t *testing.T, userID string, secret string) string {
+
+	t.Helper()
+
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"sub": userID,
+		"exp": time.Now().Add(time.Hour).Unix(),
+	}).SignedString([]byte(secret))
+
+	if err != nil {
+		t.Fatalf("Failed to sign token : %v", err)
+	}
+
+	return token
+}
+
+// newUserClient : Return client of the test server authenticated as userID
+func newUserClient(t *testing.T, server *httptest.Server, userID string) *client.Client {
+	return client.New(client.Config{BaseURL: server.URL, Token: newToken(t, userID, testJWTSecret), MaxRetries: -1})
+}
+
+// newServiceClient : Return client of the test server authenticated with a new API key granted scopes, signing requests with signingSecret
+func newServiceClient(t *testing.T, server *httptest.Server, env *models.Env, signingSecret string, scopes ...string) *client.Client {
+
+	t.Helper()
+
+	return client.New(client.Config{BaseURL: server.URL, APIKey: newAPIKey(t, env, scopes...), SigningSecret: signingSecret, MaxRetries: -1})
+}
+
+// newAPIKey : Return a new API key of the test service granted scopes
+func newAPIKey(t *testing.T, env *models.Env, scopes ...string) string {
+
+	t.Helper()
+
+	apiKey, err := auth.CreateAPIKey(env, "test-service", scopes)
+
+	if err != nil {
+		t.Fatalf("Failed to create API key : %v", err)
+	}
+
+	return apiKey
+}
+
+// provision : Provision credentials of user, failing the test otherwise
+func provision(t *testing.T, user *client.Client) *models.MQTTAuthInfos {
+
+	t.Helper()
+
+	MQTTAuthInfos, err := user.ProvisionCredentials(context.Background())
+
+	if err != nil {
+		t.Fatalf("Failed to provision credentials : %v", err)
+	}
+
+	return MQTTAuthInfos
+}
+
+// expectError : Fail the test unless err was answered by the service with status and code
+func expectError(t *testing.T, err error, status int, code string) {
+
+	t.Helper()
+
+	apiErr, ok := err.(*client.Error)
+
+	if !ok {
+		t.Fatalf("Expected a %d %s error, got %v", status, code, err)
+	}
+
+	if apiErr.Status != status || apiErr.Code != code {
+		t.Fatalf("Expected a %d %s error, got %v", status, code, apiErr)
+	}
+
+	if apiErr.RequestID == "" {
+		t.Fatalf("Error %v has no request ID", apiErr)
+	}
+}
+
+func TestProvisionCredentials(t *testing.T) {
+
+	server, env := newTestServer()
+	defer server.Close()
+
+	user := newUserClient(t, server, "alice")
+
+	MQTTAuthInfos := provision(t, user)
+
+	if MQTTAuthInfos.ClientID == "" || MQTTAuthInfos.Username != MQTTAuthInfos.ClientID {
+		t.Fatalf("Unexpected MQTT credentials %+v", MQTTAuthInfos)
+	}
+
+	verneMQACL, err := env.Store.GetProfileACL(context.Background(), MQTTAuthInfos.ClientID)
+
+	if err != nil {
+		t.Fatalf("Profile ACL of %s was not stored : %v", MQTTAuthInfos.ClientID, err)
+	}
+
+	if verneMQACL.ClientID != MQTTAuthInfos.ClientID {
+		t.Fatalf("Unexpected profile ACL %+v", verneMQACL)
+	}
+
+	// Same token again : Credentials are already provisioned
+	_, err = user.ProvisionCredentials(context.Background())
+
+	expectError(t, err, http.StatusConflict, models.CodeAlreadyExists)
+}
+
+func TestProvisionCredentialsInvalidToken(t *testing.T) {
+
+	server, _ := newTestServer()
+	defer server.Close()
+
+	user := client.New(client.Config{BaseURL: server.URL, Token: newToken(t, "mallory", "another-secret-of-at-least-32-bytes"), MaxRetries: -1})
+
+	_, err := user.ProvisionCredentials(context.Background())
+
+	expectError(t, err, http.StatusUnauthorized, models.CodeInvalidToken)
+}
+
+func TestRegisterDevice(t *testing.T) {
+
+	server, env := newTestServer()
+	defer server.Close()
+
+	token := newToken(t, "alice", testJWTSecret)
+	user := client.New(client.Config{BaseURL: server.URL, Token: token, MaxRetries: -1})
+
+	profileMQTTAuthInfos := provision(t, user)
+
+	deviceMQTTAuthInfos, err := user.RegisterDevice(context.Background(), utils.DeviceBody{DeviceName: "tablet"})
+
+	if err != nil {
+		t.Fatalf("Failed to register device : %v", err)
+	}
+
+	// Devices connect with the username and token of the user, never with its passhash
+	if deviceMQTTAuthInfos.ClientID == "" || deviceMQTTAuthInfos.ClientID == profileMQTTAuthInfos.ClientID {
+		t.Fatalf("Unexpected device client ID %s", deviceMQTTAuthInfos.ClientID)
+	}
+
+	if deviceMQTTAuthInfos.Username != profileMQTTAuthInfos.ClientID || deviceMQTTAuthInfos.Password != token {
+		t.Fatalf("Unexpected device MQTT credentials %+v", deviceMQTTAuthInfos)
+	}
+
+	deviceACL, err := env.Store.GetClientACL(context.Background(), deviceMQTTAuthInfos.ClientID)
+
+	if err != nil {
+		t.Fatalf("Device ACL of %s was not stored : %v", deviceMQTTAuthInfos.ClientID, err)
+	}
+
+	valid, err := auth.VerifyPassword(deviceACL.Passhash, deviceMQTTAuthInfos.Password)
+
+	if err != nil || !valid {
+		t.Fatalf("Device password does not match its passhash : %v", err)
+	}
+}
+
+func TestGetMappings(t *testing.T) {
+
+	server, _ := newTestServer()
+	defer server.Close()
+
+	alice := newUserClient(t, server, "alice")
+	bob := newUserClient(t, server, "bob")
+
+	provision(t, alice)
+	bobMQTTAuthInfos := provision(t, bob)
+
+	// Unknown users are left out
+	mappings, err := alice.GetMappings(context.Background(), []string{"bob", "carol"})
+
+	if err != nil {
+		t.Fatalf("Failed to get mappings : %v", err)
+	}
+
+	if len(mappings) != 1 || mappings[0].OriginalUserID != "bob" || mappings[0].InternalWaveUserID != bobMQTTAuthInfos.ClientID {
+		t.Fatalf("Unexpected mappings %+v", mappings)
+	}
+}
+
+func TestGetServiceMappings(t *testing.T) {
+
+	server, env := newTestServer()
+	defer server.Close()
+
+	aliceMQTTAuthInfos := provision(t, newUserClient(t, server, "alice"))
+
+	service := newServiceClient(t, server, env, testSigningSecret, models.APIKeyScopeMappingsRead)
+
+	mappings, err := service.GetServiceMappings(context.Background(), []string{"alice"})
+
+	if err != nil {
+		t.Fatalf("Failed to get mappings : %v", err)
+	}
+
+	if len(mappings) != 1 || mappings[0].InternalWaveUserID != aliceMQTTAuthInfos.ClientID {
+		t.Fatalf("Unexpected mappings %+v", mappings)
+	}
+}
+
+func TestGetServiceMappingsInvalidSignature(t *testing.T) {
+
+	server, env := newTestServer()
+	defer server.Close()
+
+	service := newServiceClient(t, server, env, "wrong-signing-secret", models.APIKeyScopeMappingsRead)
+
+	_, err := service.GetServiceMappings(context.Background(), []string{"alice"})
+
+	expectError(t, err, http.StatusUnauthorized, models.CodeInvalidCredentials)
+}
+
+func TestGetServiceMappingsMissingScope(t *testing.T) {
+
+	server, env := newTestServer()
+	defer server.Close()
+
+	service := newServiceClient(t, server, env, testSigningSecret, models.APIKeyScopeACLRead)
+
+	_, err := service.GetServiceMappings(context.Background(), []string{"alice"})
+
+	expectError(t, err, http.StatusForbidden, models.CodeForbidden)
+}
+
+func TestRevokeIdentityProviderUser(t *testing.T) {
+
+	server, env := newTestServer()
+	defer server.Close()
+
+	// User ID holding a namespace separator, escaped in the namespaced user ID
+	user := client.New(client.Config{BaseURL: server.URL, Token: newToken(t, "team/alice", testJWTSecret), IdentityProvider: testIdentityProvider, MaxRetries: -1})
+
+	MQTTAuthInfos := provision(t, user)
+
+	service := client.New(client.Config{
+		BaseURL:          server.URL,
+		APIKey:           newAPIKey(t, env, models.APIKeyScopeRevocationsWrite),
+		IdentityProvider: testIdentityProvider,
+		SigningSecret:    testSigningSecret,
+		MaxRetries:       -1,
+	})
+
+	err := service.RevokeCredentials(context.Background(), utils.RevocationBody{UserIDs: []string{"team/alice"}})
+
+	if err != nil {
+		t.Fatalf("Failed to revoke user : %v", err)
+	}
+
+	revoked, err := auth.IsUserRevoked(env, auth.NamespaceUserID(env.Config.IdentityProviders[0], "", "team/alice"))
+
+	if err != nil || !revoked {
+		t.Fatalf("Namespaced user ID is not in denylist : %v", err)
+	}
+
+	broker := env.Broker.(*testBroker)
+
+	broker.mutex.Lock()
+	disconnected := broker.disconnected
+	broker.mutex.Unlock()
+
+	if len(disconnected) != 1 || disconnected[0] != MQTTAuthInfos.ClientID {
+		t.Fatalf("Unexpected disconnected sessions %v", disconnected)
+	}
+
+	// Revoked user is refused instead of being told its credentials already exist
+	_, err = user.ProvisionCredentials(context.Background())
+
+	expectError(t, err, http.StatusUnauthorized, models.CodeInvalidToken)
+}
@@ -0,0 +1,164 @@
+package client
+
+import (
+	context "context"
+	json "encoding/json"
+	http "net/http"
+	url "net/url"
+	models "wave-messaging-management-service/models"
+	utils "wave-messaging-management-service/utils"
+)
+
+// GraphQLResponse : Response of a GraphQL query, Data holding what was resolved despite Errors
+type GraphQLResponse struct {
+	Data   json.RawMessage `json:"data"`
+	Errors []GraphQLError  `json:"errors"`
+}
+
+// GraphQLError : Error of a GraphQL query, Path locating the field which failed
+type GraphQLError struct {
+	Message string        `json:"message"`
+	Path    []interface{} `json:"path"`
+}
+
+// ProvisionCredentials : Provision MQTT credentials of the token owner, or refresh them after a token change.
+// Already provisioned credentials are refused with a 409 ALREADY_EXISTS error
+func (client *Client) ProvisionCredentials(ctx context.Context) (*models.MQTTAuthInfos, error) {
+
+	payload := json.RawMessage{}
+
+	err := client.do(ctx, &request{method: http.MethodPost, path: "/v1/profiles", credentials: credentialsToken, idempotent: true}, &payload)
+
+	if err != nil {
+		return nil, err
+	}
+
+	// Refreshed credentials are returned whole, new ones as their client ID, the token being the MQTT password
+	clientID := ""
+
+	if json.Unmarshal(payload, &clientID) == nil {
+		return models.NewMQTTAuthInfos(clientID, client.config.Token), nil
+	}
+
+	MQTTAuthInfos := &models.MQTTAuthInfos{}
+
+	err = json.Unmarshal(payload, MQTTAuthInfos)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return MQTTAuthInfos, nil
+}
+
+// GetMappings : Get internal Wave user IDs of application users, unknown users are left out
+func (client *Client) GetMappings(ctx context.Context, userIDs []string) ([]models.Mapping, error) {
+
+	mappings := []models.Mapping{}
+
+	err := client.do(ctx, &request{method: http.MethodPost, path: "/v1/profiles/mappings", body: utils.MappingRequestBody{UserIDs: userIDs}, credentials: credentialsToken, retryable: true}, &mappings)
+
+	return mappings, err
+}
+
+// ForceLogout : Revoke MQTT credentials of the token owner and disconnect its sessions
+func (client *Client) ForceLogout(ctx context.Context) error {
+	return client.do(ctx, &request{method: http.MethodPost, path: "/v1/profiles/logout", credentials: credentialsToken}, nil)
+}
+
+// RegisterDevice : Register an additional device of the token owner, return its MQTT credentials
+func (client *Client) RegisterDevice(ctx context.Context, device utils.DeviceBody) (*models.MQTTAuthInfos, error) {
+
+	MQTTAuthInfos := &models.MQTTAuthInfos{}
+
+	err := client.do(ctx, &request{method: http.MethodPost, path: "/v1/profiles/devices", body: device, credentials: credentialsToken, idempotent: true}, MQTTAuthInfos)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return MQTTAuthInfos, nil
+}
+
+// DeregisterDevice : Remove a device of the token owner and disconnect its session
+func (client *Client) DeregisterDevice(ctx context.Context, clientID string) error {
+	return client.do(ctx, &request{method: http.MethodDelete, path: "/v1/profiles/devices/" + url.PathEscape(clientID), credentials: credentialsToken, idempotent: true}, nil)
+}
+
+// RegisterPushToken : Register a push token of the token owner
+func (client *Client) RegisterPushToken(ctx context.Context, pushToken utils.PushTokenBody) error {
+	return client.do(ctx, &request{method: http.MethodPost, path: "/v1/profiles/pushtokens", body: pushToken, credentials: credentialsToken}, nil)
+}
+
+// UnregisterPushToken : Unregister a push token of the token owner. Token is passed as query parameter, which fits Web Push subscription URLs too
+func (client *Client) UnregisterPushToken(ctx context.Context, pushToken string) error {
+	return client.do(ctx, &request{method: http.MethodDelete, path: "/v1/profiles/pushtokens", query: url.Values{"pushToken": {pushToken}}, credentials: credentialsToken}, nil)
+}
+
+// GetNotificationPreferences : Get push notifications settings of the token owner
+func (client *Client) GetNotificationPreferences(ctx context.Context) (*models.NotificationPreferences, error) {
+
+	preferences := &models.NotificationPreferences{}
+
+	err := client.do(ctx, &request{method: http.MethodGet, path: "/v1/profiles/notifications/preferences", credentials: credentialsToken}, preferences)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return preferences, nil
+}
+
+// SetNotificationPreferences : Replace push notifications settings of the token owner, return them as stored
+func (client *Client) SetNotificationPreferences(ctx context.Context, preferences *models.NotificationPreferences) (*models.NotificationPreferences, error) {
+
+	stored := &models.NotificationPreferences{}
+
+	err := client.do(ctx, &request{method: http.MethodPut, path: "/v1/profiles/notifications/preferences", body: preferences, credentials: credentialsToken}, stored)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return stored, nil
+}
+
+// AddGroupConversation : Create a group conversation of the token owner with members (Application user IDs)
+func (client *Client) AddGroupConversation(ctx context.Context, groupConversation utils.GroupConversationBody) error {
+	return client.do(ctx, &request{method: http.MethodPost, path: "/v1/conversations/group", body: groupConversation, credentials: credentialsToken, idempotent: true}, nil)
+}
+
+// QueryGraphQL : Run a GraphQL query for the token owner. Errors of the query are returned in the response, next to the data resolved
+func (client *Client) QueryGraphQL(ctx context.Context, query utils.GraphQLBody) (*GraphQLResponse, error) {
+
+	// GraphQL responses are not wrapped in the standard envelope
+	data, err := client.send(ctx, &request{method: http.MethodPost, path: "/v1/graphql", body: query, credentials: credentialsToken, retryable: true})
+
+	if err != nil {
+		return nil, err
+	}
+
+	response := &GraphQLResponse{}
+
+	err = json.Unmarshal(data, response)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return response, nil
+}
+
+// AddGuest : Get short-lived subscribe-only MQTT credentials, without token
+func (client *Client) AddGuest(ctx context.Context) (*models.GuestMQTTAuthInfos, error) {
+
+	guestAuthInfos := &models.GuestMQTTAuthInfos{}
+
+	err := client.do(ctx, &request{method: http.MethodPost, path: "/v1/guests"}, guestAuthInfos)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return guestAuthInfos, nil
+}
@@ -0,0 +1,65 @@
+package client
+
+import (
+	context "context"
+	http "net/http"
+	url "net/url"
+	models "wave-messaging-management-service/models"
+	utils "wave-messaging-management-service/utils"
+)
+
+// Internal services endpoints, authenticated with the API key of the client (Or its client certificate, see Config.HTTPClient)
+
+// GetServiceMappings : Get internal Wave user IDs of application users of the identity provider of the client, unknown users are left out
+func (client *Client) GetServiceMappings(ctx context.Context, userIDs []string) ([]models.Mapping, error) {
+
+	mappings := []models.Mapping{}
+
+	err := client.do(ctx, &request{method: http.MethodPost, path: "/v1/services/mappings", body: utils.MappingRequestBody{UserIDs: userIDs}, credentials: credentialsAPIKey, retryable: true}, &mappings)
+
+	return mappings, err
+}
+
+// AuthorizePublishing : Grant publishing rights on a MQTT topic to an internal Wave user, on all its devices
+func (client *Client) AuthorizePublishing(ctx context.Context, grant utils.PublishACLBody) error {
+	return client.do(ctx, &request{method: http.MethodPost, path: "/v1/services/acls/publish", body: grant, credentials: credentialsAPIKey, idempotent: true}, nil)
+}
+
+// AddGroupConversations : Create many group conversations between application users, return them with internal Wave user IDs
+func (client *Client) AddGroupConversations(ctx context.Context, groupConversations utils.BulkGroupConversationsBody) ([]models.GroupConversation, error) {
+
+	created := []models.GroupConversation{}
+
+	err := client.do(ctx, &request{method: http.MethodPost, path: "/v1/services/conversations/group/bulk", body: groupConversations, credentials: credentialsAPIKey, idempotent: true}, &created)
+
+	return created, err
+}
+
+// GetClientACL : Get VerneMQ ACL of a MQTT client, without its credentials
+func (client *Client) GetClientACL(ctx context.Context, clientID string) (*models.VerneMQACL, error) {
+
+	verneMQACL := &models.VerneMQACL{}
+
+	err := client.do(ctx, &request{method: http.MethodGet, path: "/v1/services/acls/" + url.PathEscape(clientID), credentials: credentialsAPIKey}, verneMQACL)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return verneMQACL, nil
+}
+
+// InvalidateAuthCache : Remove a token from auth cache, so that it is verified again on its next use
+func (client *Client) InvalidateAuthCache(ctx context.Context, token string) error {
+	return client.do(ctx, &request{method: http.MethodPost, path: "/v1/services/authcache/invalidate", body: utils.TokenBody{Token: token}, credentials: credentialsAPIKey, retryable: true}, nil)
+}
+
+// RevokeCredentials : Revoke tokens and application users
+func (client *Client) RevokeCredentials(ctx context.Context, revocation utils.RevocationBody) error {
+	return client.do(ctx, &request{method: http.MethodPost, path: "/v1/services/revocations", body: revocation, credentials: credentialsAPIKey}, nil)
+}
+
+// RestoreUser : Lift revocation of an application user
+func (client *Client) RestoreUser(ctx context.Context, userID string) error {
+	return client.do(ctx, &request{method: http.MethodDelete, path: "/v1/services/revocations/users/" + url.PathEscape(userID), credentials: credentialsAPIKey}, nil)
+}
@@ -0,0 +1,407 @@
+package graph
+
+import (
+	context "context"
+	errors "errors"
+	sync "sync"
+	auth "wave-messaging-management-service/auth"
+	models "wave-messaging-management-service/models"
+	utils "wave-messaging-management-service/utils"
+
+	graphql "github.com/graph-gophers/graphql-go"
+)
+
+const (
+	// DefaultConversationsPageSize : Conversations listed per page when first is not set
+	DefaultConversationsPageSize = 20
+
+	// MaxConversationsPageSize : Maximum conversations listed per page
+	MaxConversationsPageSize = 100
+
+	// MaxPresenceUsers : Maximum users whose presence is queried at once
+	MaxPresenceUsers = 100
+
+	// MaxDepth : Maximum depth of queries, deep enough for the members presence of listed conversations
+	MaxDepth = 6
+)
+
+var (
+	// errInternal : Error of resolvers failing on a datastore, whose cause is logged but not exposed
+	errInternal = errors.New("Internal error")
+
+	// errTooManyUsers : More users than MaxPresenceUsers were queried
+	errTooManyUsers = errors.New("Too many users")
+
+	// schema : Parsed schema, resolvers being checked at startup
+	schema = graphql.MustParseSchema(Schema, &Resolver{}, graphql.MaxDepth(MaxDepth))
+)
+
+// viewerKey : Context key of the viewer of a query
+type viewerKey struct{}
+
+// viewer : Token owner of a query in its environment. Presence is fetched from the broker once per query,
+// and users presence once per user, as resolvers of a query run concurrently
+type viewer struct {
+	env    *models.Env
+	userID string
+
+	presenceOnce  sync.Once
+	onlineClients map[string]bool
+
+	mutex       sync.Mutex
+	onlineUsers map[string]*bool
+}
+
+// Exec : Run GraphQL query for the token owner userID, in its environment (Scoped to its tenant).
+// Errors are reported in the response, next to the data resolved
+func Exec(env *models.Env, userID string, query string, operationName string, variables map[string]interface{}) *graphql.Response {
+
+	ctx := context.WithValue(env.TraceContext(), viewerKey{}, &viewer{env: env, userID: userID, onlineUsers: map[string]*bool{}})
+
+	return schema.Exec(ctx, query, operationName, variables)
+}
+
+// queryViewer : Return viewer of the query of ctx (See Exec)
+func queryViewer(ctx context.Context) *viewer {
+	return ctx.Value(viewerKey{}).(*viewer)
+}
+
+// getOnlineClients : Return client IDs connected to the broker, nil if it can't be reached
+func (v *viewer) getOnlineClients() map[string]bool {
+
+	v.presenceOnce.Do(func() {
+
+		onlineClients, err := v.env.Broker.GetOnlineClients()
+
+		if err != nil {
+			v.env.Logger.WithError(err).Warn("Failed to get presence from broker")
+		}
+
+		v.onlineClients = onlineClients
+	})
+
+	return v.onlineClients
+}
+
+// userOnline : Return whether one of the devices of internal Wave user is connected, nil if the broker can't be reached
+func (v *viewer) userOnline(userID string) (*bool, error) {
+
+	onlineClients := v.getOnlineClients()
+
+	if onlineClients == nil {
+		return nil, nil
+	}
+
+	v.mutex.Lock()
+	cached, ok := v.onlineUsers[userID]
+	v.mutex.Unlock()
+
+	if ok {
+		return cached, nil
+	}
+
+	verneMQACLs, err := v.env.Store.GetUserACLs(v.env.TraceContext(), userID)
+
+	if err != nil {
+		v.env.Logger.WithError(err).Error("Failed to get user ACLs")
+		return nil, errInternal
+	}
+
+	online := false
+
+	for _, verneMQACL := range verneMQACLs {
+		online = online || onlineClients[verneMQACL.ClientID]
+	}
+
+	v.mutex.Lock()
+	v.onlineUsers[userID] = &online
+	v.mutex.Unlock()
+
+	return &online, nil
+}
+
+// Resolver : Root resolver of queries
+type Resolver struct{}
+
+// Me : Resolve token owner
+func (r *Resolver) Me(ctx context.Context) *userResolver {
+	return &userResolver{viewer: queryViewer(ctx)}
+}
+
+// Conversations : Resolve page of group conversations of the token owner. after is the nextCursor of the previous page
+func (r *Resolver) Conversations(ctx context.Context, args struct {
+	First *int32
+	After *string
+}) (*conversationsPageResolver, error) {
+
+	v := queryViewer(ctx)
+	pagination := &utils.Pagination{Limit: DefaultConversationsPageSize}
+
+	if args.First != nil && *args.First > 0 {
+		pagination.Limit = int(*args.First)
+	}
+
+	if pagination.Limit > MaxConversationsPageSize {
+		pagination.Limit = MaxConversationsPageSize
+	}
+
+	if args.After != nil {
+
+		offset, err := utils.DecodeCursor(*args.After)
+
+		if err != nil {
+			return nil, err
+		}
+
+		pagination.Offset = offset
+	}
+
+	groupConversations, total, err := v.env.Store.GetGroupMemberships(v.env.TraceContext(), v.userID, pagination)
+
+	if err != nil {
+		v.env.Logger.WithError(err).Error("Failed to get group memberships")
+		return nil, errInternal
+	}
+
+	page := &conversationsPageResolver{page: utils.NewPage(pagination, total)}
+
+	for _, groupConversation := range groupConversations {
+		page.conversations = append(page.conversations, &conversationResolver{viewer: v, groupConversation: groupConversation})
+	}
+
+	return page, nil
+}
+
+// Conversation : Resolve group conversation of the token owner, unknown conversations and the ones it is not a member of resolve to null
+func (r *Resolver) Conversation(ctx context.Context, args struct{ ID graphql.ID }) *conversationResolver {
+
+	v := queryViewer(ctx)
+
+	groupConversation, err := v.env.Store.GetGroupConversation(v.env.TraceContext(), string(args.ID))
+
+	if err != nil {
+		return nil
+	}
+
+	for _, member := range groupConversation.Members {
+		if member == v.userID {
+			return &conversationResolver{viewer: v, groupConversation: groupConversation}
+		}
+	}
+
+	return nil
+}
+
+// Presence : Resolve presence of internal Wave users, the ones of other tenants resolve to a null presence
+func (r *Resolver) Presence(ctx context.Context, args struct{ UserIDs []graphql.ID }) ([]*presenceResolver, error) {
+
+	v := queryViewer(ctx)
+
+	if len(args.UserIDs) > MaxPresenceUsers {
+		return nil, errTooManyUsers
+	}
+
+	presences := make([]*presenceResolver, len(args.UserIDs))
+
+	for i, userID := range args.UserIDs {
+
+		presences[i] = &presenceResolver{userID: userID}
+
+		if auth.CheckTenantUser(v.env, string(userID)) != nil {
+			continue
+		}
+
+		online, err := v.userOnline(string(userID))
+
+		if err != nil {
+			return nil, err
+		}
+
+		presences[i].online = online
+	}
+
+	return presences, nil
+}
+
+// userResolver : Resolve token owner
+type userResolver struct {
+	viewer *viewer
+}
+
+// ID : Internal Wave user ID of the token owner
+func (r *userResolver) ID() graphql.ID {
+	return graphql.ID(r.viewer.userID)
+}
+
+// Online : Whether one of the token owner devices is connected
+func (r *userResolver) Online() (*bool, error) {
+	return r.viewer.userOnline(r.viewer.userID)
+}
+
+// Devices : Devices (MQTT clients) of the token owner, main profile included
+func (r *userResolver) Devices() ([]*deviceResolver, error) {
+
+	verneMQACLs, err := r.viewer.env.Store.GetUserACLs(r.viewer.env.TraceContext(), r.viewer.userID)
+
+	if err != nil {
+		r.viewer.env.Logger.WithError(err).Error("Failed to get user ACLs")
+		return nil, errInternal
+	}
+
+	devices := make([]*deviceResolver, len(verneMQACLs))
+
+	for i, verneMQACL := range verneMQACLs {
+		devices[i] = &deviceResolver{viewer: r.viewer, verneMQACL: verneMQACL}
+	}
+
+	return devices, nil
+}
+
+// deviceResolver : Resolve device of the token owner
+type deviceResolver struct {
+	viewer     *viewer
+	verneMQACL *models.VerneMQACL
+}
+
+// ClientID : MQTT client ID of the device
+func (r *deviceResolver) ClientID() graphql.ID {
+	return graphql.ID(r.verneMQACL.ClientID)
+}
+
+// Name : Name of the device, null for the main profile
+func (r *deviceResolver) Name() *string {
+
+	if r.verneMQACL.DeviceName == "" {
+		return nil
+	}
+
+	return &r.verneMQACL.DeviceName
+}
+
+// Online : Whether the device is connected, null if the broker can't be reached
+func (r *deviceResolver) Online() *bool {
+
+	onlineClients := r.viewer.getOnlineClients()
+
+	if onlineClients == nil {
+		return nil
+	}
+
+	online := onlineClients[r.verneMQACL.ClientID]
+
+	return &online
+}
+
+// conversationsPageResolver : Resolve page of group conversations
+type conversationsPageResolver struct {
+	page          utils.Page
+	conversations []*conversationResolver
+}
+
+// Total : Count of all group conversations of the token owner
+func (r *conversationsPageResolver) Total() int32 {
+	return int32(r.page.Total)
+}
+
+// NextCursor : Cursor of the next page, null on the last one
+func (r *conversationsPageResolver) NextCursor() *string {
+
+	if r.page.NextCursor == "" {
+		return nil
+	}
+
+	return &r.page.NextCursor
+}
+
+// Conversations : Group conversations of the page
+func (r *conversationsPageResolver) Conversations() []*conversationResolver {
+
+	if r.conversations == nil {
+		return []*conversationResolver{}
+	}
+
+	return r.conversations
+}
+
+// conversationResolver : Resolve group conversation
+type conversationResolver struct {
+	viewer            *viewer
+	groupConversation *models.GroupConversation
+}
+
+// ID : Group conversation ID
+func (r *conversationResolver) ID() graphql.ID {
+	return graphql.ID(r.groupConversation.GroupConversationID)
+}
+
+// Name : Group conversation name
+func (r *conversationResolver) Name() string {
+	return r.groupConversation.Name
+}
+
+// Topic : MQTT topic pattern receiving messages of the conversation, in the topic namespace of the tenant
+func (r *conversationResolver) Topic() (string, error) {
+
+	topicPaths, err := r.viewer.env.TopicPaths()
+
+	if err != nil {
+		r.viewer.env.Logger.WithError(err).Error("Invalid topic paths")
+		return "", errInternal
+	}
+
+	return topicPaths.Group + r.groupConversation.GroupConversationID + "/+", nil
+}
+
+// Creator : Creator of the conversation, null for conversations created before creators were recorded
+func (r *conversationResolver) Creator() *memberResolver {
+
+	if r.groupConversation.CreatorID == "" {
+		return nil
+	}
+
+	return &memberResolver{viewer: r.viewer, userID: r.groupConversation.CreatorID}
+}
+
+// Members : Members of the conversation, creator included
+func (r *conversationResolver) Members() []*memberResolver {
+
+	members := make([]*memberResolver, len(r.groupConversation.Members))
+
+	for i, member := range r.groupConversation.Members {
+		members[i] = &memberResolver{viewer: r.viewer, userID: member}
+	}
+
+	return members
+}
+
+// memberResolver : Resolve member of a group conversation
+type memberResolver struct {
+	viewer *viewer
+	userID string
+}
+
+// ID : Internal Wave user ID of the member
+func (r *memberResolver) ID() graphql.ID {
+	return graphql.ID(r.userID)
+}
+
+// Online : Whether one of the member devices is connected, null if the broker can't be reached
+func (r *memberResolver) Online() (*bool, error) {
+	return r.viewer.userOnline(r.userID)
+}
+
+// presenceResolver : Resolve presence of a user
+type presenceResolver struct {
+	userID graphql.ID
+	online *bool
+}
+
+// UserID : Internal Wave user ID
+func (r *presenceResolver) UserID() graphql.ID {
+	return r.userID
+}
+
+// Online : Whether one of the user devices is connected, null for users of other tenants or if the broker can't be reached
+func (r *presenceResolver) Online() *bool {
+	return r.online
+}
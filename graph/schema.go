@@ -0,0 +1,57 @@
+package graph
+
+// Schema : GraphQL schema of client-facing reads, covering the inbox view of the token owner.
+// Messages are not part of it, as they are only relayed by the broker and never stored by the service
+const Schema = `
+schema {
+	query: Query
+}
+
+type Query {
+	# Token owner
+	me: User!
+	# Page of the group conversations the token owner is a member of, sorted by name
+	conversations(first: Int, after: String): ConversationsPage!
+	# Group conversation the token owner is a member of, null otherwise
+	conversation(id: ID!): Conversation
+	# Presence of users of the token owner tenant, null for other users or when the broker can't be reached
+	presence(userIDs: [ID!]!): [Presence!]!
+}
+
+type User {
+	id: ID!
+	online: Boolean
+	devices: [Device!]!
+}
+
+type Device {
+	clientID: ID!
+	name: String
+	online: Boolean
+}
+
+type ConversationsPage {
+	total: Int!
+	nextCursor: String
+	conversations: [Conversation!]!
+}
+
+type Conversation {
+	id: ID!
+	name: String!
+	# MQTT topic pattern receiving messages of the conversation
+	topic: String!
+	creator: Member
+	members: [Member!]!
+}
+
+type Member {
+	id: ID!
+	online: Boolean
+}
+
+type Presence {
+	userID: ID!
+	online: Boolean
+}
+`
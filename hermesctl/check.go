@@ -0,0 +1,167 @@
+package main
+
+import (
+	context "context"
+	json "encoding/json"
+	fmt "fmt"
+	client "wave-messaging-management-service/client"
+	models "wave-messaging-management-service/models"
+
+	cobra "github.com/spf13/cobra"
+)
+
+const (
+	// issueMissingACL : Mapped user, not suspended, without any VerneMQ ACL (Expected for idle users when ACLs expire)
+	issueMissingACL = "missingACL"
+
+	// issueStaleConversationACL : ACL pattern granting a group conversation which does not exist anymore or does not list the user among its members
+	issueStaleConversationACL = "staleConversationACL"
+
+	// issueMissingConversationACL : Group conversation listing the user among its members, without ACL pattern granting it
+	issueMissingConversationACL = "missingConversationACL"
+
+	// checkPageLimit : Users and conversations fetched per request
+	checkPageLimit = 500
+)
+
+// issue : Inconsistency between mappings, VerneMQ ACLs and group conversations of a user
+type issue struct {
+	Issue              string `json:"issue"`
+	OriginalUserID     string `json:"originalUserID"`
+	InternalWaveUserID string `json:"internalWaveUserID"`
+	ConversationID     string `json:"conversationID,omitempty"`
+}
+
+// newCheckCommand : Return check command, cross-checking mappings, VerneMQ ACLs and group conversations of all users through the admin API
+func newCheckCommand(opts *options) *cobra.Command {
+
+	return &cobra.Command{
+		Use:   "check",
+		Short: "Check consistency of mappings, ACLs and group conversations of all users",
+		Long: "Check consistency of mappings, ACLs and group conversations of all users visible to the API key, printing each inconsistency as a JSON line.\n" +
+			"Exits with an error when inconsistencies are found. Nothing is repaired.",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+
+			messaging, err := newClient(opts)
+
+			if err != nil {
+				return err
+			}
+
+			encoder := json.NewEncoder(cmd.OutOrStdout())
+			issues := 0
+			users := 0
+
+			page := client.PageQuery{Limit: checkPageLimit}
+
+			for {
+
+				usersPage, err := messaging.ListUsers(cmd.Context(), "", page)
+
+				if err != nil {
+					return err
+				}
+
+				for _, user := range usersPage.Users {
+
+					userIssues, err := checkUser(cmd.Context(), messaging, user)
+
+					if err != nil {
+						return fmt.Errorf("Failed to check user %s: %v", user.InternalWaveUserID, err)
+					}
+
+					for _, userIssue := range userIssues {
+
+						err = encoder.Encode(userIssue)
+
+						if err != nil {
+							return err
+						}
+					}
+
+					issues += len(userIssues)
+					users++
+				}
+
+				if usersPage.NextCursor == "" {
+					break
+				}
+
+				page.Cursor = usersPage.NextCursor
+			}
+
+			fmt.Fprintf(cmd.ErrOrStderr(), "%d users checked, %d inconsistencies found\n", users, issues)
+
+			if issues > 0 {
+				return fmt.Errorf("%d inconsistencies found", issues)
+			}
+
+			return nil
+		},
+	}
+}
+
+// checkUser : Return inconsistencies of user. Group conversations of users without ACL are left unchecked
+func checkUser(ctx context.Context, messaging *client.Client, user *models.User) ([]*issue, error) {
+
+	newIssue := func(kind string, conversationID string) *issue {
+		return &issue{Issue: kind, OriginalUserID: user.OriginalUserID, InternalWaveUserID: user.InternalWaveUserID, ConversationID: conversationID}
+	}
+
+	issues := []*issue{}
+
+	if len(user.ACLs) == 0 {
+
+		if !user.Suspended {
+			issues = append(issues, newIssue(issueMissingACL, ""))
+		}
+
+		return issues, nil
+	}
+
+	userACL, err := messaging.GetUserACL(ctx, user.InternalWaveUserID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	granted := map[string]bool{}
+
+	for _, membership := range userACL.Conversations {
+
+		if membership.ConversationType != models.GroupConversationType {
+			continue
+		}
+
+		granted[membership.ConversationID] = true
+
+		if !membership.Member {
+			issues = append(issues, newIssue(issueStaleConversationACL, membership.ConversationID))
+		}
+	}
+
+	page := client.PageQuery{Limit: checkPageLimit}
+
+	for {
+
+		conversationsPage, err := messaging.ListUserConversations(ctx, user.InternalWaveUserID, page)
+
+		if err != nil {
+			return nil, err
+		}
+
+		for _, groupConversation := range conversationsPage.Conversations {
+
+			if !granted[groupConversation.GroupConversationID] {
+				issues = append(issues, newIssue(issueMissingConversationACL, groupConversation.GroupConversationID))
+			}
+		}
+
+		if conversationsPage.NextCursor == "" {
+			return issues, nil
+		}
+
+		page.Cursor = conversationsPage.NextCursor
+	}
+}
@@ -0,0 +1,171 @@
+package main
+
+import (
+	json "encoding/json"
+	fmt "fmt"
+	io "io"
+	os "os"
+	time "time"
+	client "wave-messaging-management-service/client"
+
+	cobra "github.com/spf13/cobra"
+)
+
+const (
+	// exportPageLimit : Items fetched per request by exports
+	exportPageLimit = 500
+)
+
+// newExportCommand : Return export command, writing users or audit log entries as JSON lines, to stdout or a file
+func newExportCommand(opts *options) *cobra.Command {
+
+	output := ""
+
+	exportCmd := &cobra.Command{
+		Use:   "export",
+		Short: "Export users or audit log entries as JSON lines",
+	}
+
+	exportCmd.PersistentFlags().StringVarP(&output, "output", "o", "", "File to write the export to (stdout when not set)")
+
+	search := ""
+
+	usersCmd := &cobra.Command{
+		Use:   "users",
+		Short: "Export users with their mapping, ACLs (Without passhash) and presence",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+
+			messaging, err := newClient(opts)
+
+			if err != nil {
+				return err
+			}
+
+			return export(cmd, output, func(encoder *json.Encoder) (int, error) {
+
+				exported := 0
+				page := client.PageQuery{Limit: exportPageLimit}
+
+				for {
+
+					usersPage, err := messaging.ListUsers(cmd.Context(), search, page)
+
+					if err != nil {
+						return exported, err
+					}
+
+					for _, user := range usersPage.Users {
+
+						err = encoder.Encode(user)
+
+						if err != nil {
+							return exported, err
+						}
+
+						exported++
+					}
+
+					if usersPage.NextCursor == "" {
+						return exported, nil
+					}
+
+					page.Cursor = usersPage.NextCursor
+				}
+			})
+		},
+	}
+
+	usersCmd.Flags().StringVar(&search, "search", "", "Export users whose application or internal user ID contains search")
+
+	auditQuery := client.AuditQuery{}
+	since := time.Duration(0)
+
+	auditCmd := &cobra.Command{
+		Use:   "audit",
+		Short: "Export audit log entries, most recent first",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+
+			messaging, err := newClient(opts)
+
+			if err != nil {
+				return err
+			}
+
+			if since > 0 {
+				from := time.Now().Add(-since)
+				auditQuery.From = &from
+			}
+
+			return export(cmd, output, func(encoder *json.Encoder) (int, error) {
+
+				exported := 0
+				auditQuery.Page = client.PageQuery{Limit: exportPageLimit}
+
+				for {
+
+					auditPage, err := messaging.GetAuditLog(cmd.Context(), auditQuery)
+
+					if err != nil {
+						return exported, err
+					}
+
+					for _, entry := range auditPage.Entries {
+
+						err = encoder.Encode(entry)
+
+						if err != nil {
+							return exported, err
+						}
+
+						exported++
+					}
+
+					if auditPage.NextCursor == "" {
+						return exported, nil
+					}
+
+					auditQuery.Page.Cursor = auditPage.NextCursor
+				}
+			})
+		},
+	}
+
+	auditCmd.Flags().StringVar(&auditQuery.Action, "action", "", "Export entries of this action only")
+	auditCmd.Flags().StringVar(&auditQuery.Target, "target", "", "Export entries of this target only")
+	auditCmd.Flags().DurationVar(&since, "since", 0, "Export entries recorded within this duration (e.g. 24h)")
+
+	exportCmd.AddCommand(usersCmd, auditCmd)
+
+	return exportCmd
+}
+
+// export : Run write with an encoder of JSON lines to output (Stdout when empty), then report the number of items exported on stderr
+func export(cmd *cobra.Command, output string, write func(encoder *json.Encoder) (int, error)) error {
+
+	var out io.Writer = cmd.OutOrStdout()
+
+	if output != "" {
+
+		file, err := os.Create(output)
+
+		if err != nil {
+			return err
+		}
+
+		defer file.Close()
+
+		out = file
+	}
+
+	exported, err := write(json.NewEncoder(out))
+
+	if err != nil {
+		return fmt.Errorf("Export failed after %d items: %v", exported, err)
+	}
+
+	fmt.Fprintf(cmd.ErrOrStderr(), "%d items exported\n", exported)
+
+	return nil
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	json "encoding/json"
+	errors "errors"
+	io "io"
+	http "net/http"
+	os "os"
+	time "time"
+	client "wave-messaging-management-service/client"
+
+	cobra "github.com/spf13/cobra"
+)
+
+// hermesctl : Operators CLI, talking to the admin API of the management service with an API key granted admin scopes.
+// Connection settings are read from flags, falling back to HERMES_* environment variables (See README hermesctl section)
+
+// options : Connection settings shared by all commands
+type options struct {
+	baseURL          string
+	apiKey           string
+	signingSecret    string
+	identityProvider string
+	timeout          time.Duration
+}
+
+func main() {
+
+	err := newRootCommand().Execute()
+
+	if err != nil {
+		os.Exit(1)
+	}
+}
+
+// newRootCommand : Return hermesctl command, holding connection flags and all subcommands
+func newRootCommand() *cobra.Command {
+
+	opts := &options{}
+
+	rootCmd := &cobra.Command{
+		Use:          "hermesctl",
+		Short:        "Operate the Wave messaging management service through its admin API",
+		SilenceUsage: true,
+	}
+
+	flags := rootCmd.PersistentFlags()
+	flags.StringVar(&opts.baseURL, "url", os.Getenv("HERMES_URL"), "URL of the management API (HERMES_URL)")
+	flags.StringVar(&opts.apiKey, "api-key", os.Getenv("HERMES_API_KEY"), "API key granted admin scopes (HERMES_API_KEY)")
+	flags.StringVar(&opts.signingSecret, "signing-secret", os.Getenv("HERMES_SIGNING_SECRET"), "Secret signing requests, when request signing is enabled (HERMES_SIGNING_SECRET)")
+	flags.StringVar(&opts.identityProvider, "identity-provider", os.Getenv("HERMES_IDENTITY_PROVIDER"), "Identity provider of the API key, when several are configured (HERMES_IDENTITY_PROVIDER)")
+	flags.DurationVar(&opts.timeout, "timeout", client.DefaultTimeout, "Timeout of each request")
+
+	rootCmd.AddCommand(
+		newACLCommand(opts),
+		newConversationsCommand(opts),
+		newSessionsCommand(opts),
+		newCheckCommand(opts),
+		newExportCommand(opts),
+	)
+
+	return rootCmd
+}
+
+// newClient : Return client of the admin API configured by opts, failing if the URL or the API key is missing
+func newClient(opts *options) (*client.Client, error) {
+
+	if opts.baseURL == "" {
+		return nil, errors.New("Missing management API URL (--url or HERMES_URL)")
+	}
+
+	if opts.apiKey == "" {
+		return nil, errors.New("Missing API key (--api-key or HERMES_API_KEY)")
+	}
+
+	return client.New(client.Config{
+		BaseURL:          opts.baseURL,
+		APIKey:           opts.apiKey,
+		SigningSecret:    opts.signingSecret,
+		IdentityProvider: opts.identityProvider,
+		HTTPClient:       &http.Client{Timeout: opts.timeout},
+	}), nil
+}
+
+// printJSON : Write value to out as indented JSON
+func printJSON(out io.Writer, value interface{}) error {
+
+	encoder := json.NewEncoder(out)
+	encoder.SetIndent("", "    ")
+
+	return encoder.Encode(value)
+}
@@ -0,0 +1,201 @@
+package main
+
+import (
+	client "wave-messaging-management-service/client"
+
+	cobra "github.com/spf13/cobra"
+)
+
+// newACLCommand : Return acl command, inspecting, revoking and restoring ACLs of internal Wave users
+func newACLCommand(opts *options) *cobra.Command {
+
+	aclCmd := &cobra.Command{
+		Use:   "acl",
+		Short: "Inspect, revoke and restore ACLs of users",
+	}
+
+	aclCmd.AddCommand(&cobra.Command{
+		Use:   "inspect <internalWaveUserID>",
+		Short: "Print effective ACLs of a user and conversations derived from them",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+
+			messaging, err := newClient(opts)
+
+			if err != nil {
+				return err
+			}
+
+			userACL, err := messaging.GetUserACL(cmd.Context(), args[0])
+
+			if err != nil {
+				return err
+			}
+
+			return printJSON(cmd.OutOrStdout(), userACL)
+		},
+	})
+
+	aclCmd.AddCommand(&cobra.Command{
+		Use:   "revoke <internalWaveUserID>",
+		Short: "Suspend a user : revoke all its access and disconnect its sessions",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+
+			messaging, err := newClient(opts)
+
+			if err != nil {
+				return err
+			}
+
+			mapping, err := messaging.SuspendUser(cmd.Context(), args[0])
+
+			if err != nil {
+				return err
+			}
+
+			return printJSON(cmd.OutOrStdout(), mapping)
+		},
+	})
+
+	aclCmd.AddCommand(&cobra.Command{
+		Use:   "restore <internalWaveUserID>",
+		Short: "Restore removed ACLs of a user, not purged yet, and print its effective ACLs",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+
+			messaging, err := newClient(opts)
+
+			if err != nil {
+				return err
+			}
+
+			userACL, err := messaging.RestoreUserACLs(cmd.Context(), args[0])
+
+			if err != nil {
+				return err
+			}
+
+			return printJSON(cmd.OutOrStdout(), userACL)
+		},
+	})
+
+	return aclCmd
+}
+
+// newConversationsCommand : Return conversations command, listing group conversations of internal Wave users, removing and restoring them
+func newConversationsCommand(opts *options) *cobra.Command {
+
+	conversationsCmd := &cobra.Command{
+		Use:   "conversations",
+		Short: "Inspect, remove and restore group conversations",
+	}
+
+	page := client.PageQuery{}
+
+	listCmd := &cobra.Command{
+		Use:   "list <internalWaveUserID>",
+		Short: "Print a page of the group conversations a user is a member of",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+
+			messaging, err := newClient(opts)
+
+			if err != nil {
+				return err
+			}
+
+			conversationsPage, err := messaging.ListUserConversations(cmd.Context(), args[0], page)
+
+			if err != nil {
+				return err
+			}
+
+			return printJSON(cmd.OutOrStdout(), conversationsPage)
+		},
+	}
+
+	listCmd.Flags().StringVar(&page.Cursor, "cursor", "", "Cursor of the page to print, as returned by the previous page")
+	listCmd.Flags().IntVar(&page.Limit, "limit", 0, "Conversations per page (Server default when not set)")
+
+	conversationsCmd.AddCommand(listCmd)
+
+	conversationsCmd.AddCommand(&cobra.Command{
+		Use:   "remove <groupConversationID>",
+		Short: "Remove a group conversation and revoke access of its members. It can be restored until purged",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+
+			messaging, err := newClient(opts)
+
+			if err != nil {
+				return err
+			}
+
+			groupConversation, err := messaging.RemoveGroupConversation(cmd.Context(), args[0])
+
+			if err != nil {
+				return err
+			}
+
+			return printJSON(cmd.OutOrStdout(), groupConversation)
+		},
+	})
+
+	conversationsCmd.AddCommand(&cobra.Command{
+		Use:   "restore <groupConversationID>",
+		Short: "Restore a removed group conversation, not purged yet",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+
+			messaging, err := newClient(opts)
+
+			if err != nil {
+				return err
+			}
+
+			groupConversation, err := messaging.RestoreGroupConversation(cmd.Context(), args[0])
+
+			if err != nil {
+				return err
+			}
+
+			return printJSON(cmd.OutOrStdout(), groupConversation)
+		},
+	})
+
+	return conversationsCmd
+}
+
+// newSessionsCommand : Return sessions command, disconnecting sessions of internal Wave users
+func newSessionsCommand(opts *options) *cobra.Command {
+
+	sessionsCmd := &cobra.Command{
+		Use:   "sessions",
+		Short: "Manage MQTT sessions of users",
+	}
+
+	sessionsCmd.AddCommand(&cobra.Command{
+		Use:   "kick <internalWaveUserID>",
+		Short: "Disconnect sessions of all devices of a user, which may reconnect",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+
+			messaging, err := newClient(opts)
+
+			if err != nil {
+				return err
+			}
+
+			sessions, err := messaging.DisconnectUser(cmd.Context(), args[0])
+
+			if err != nil {
+				return err
+			}
+
+			return printJSON(cmd.OutOrStdout(), sessions)
+		},
+	})
+
+	return sessionsCmd
+}
@@ -1,11 +1,22 @@
 package main
 
 import (
+	context "context"
+	flag "flag"
 	fmt "fmt"
-	log "log"
+	http "net/http"
 	os "os"
+	signal "os/signal"
+	syscall "syscall"
+	time "time"
+	auth "wave-messaging-management-service/auth"
 	models "wave-messaging-management-service/models"
+	notifications "wave-messaging-management-service/notifications"
 	router "wave-messaging-management-service/router"
+	rpc "wave-messaging-management-service/rpc"
+
+	logrus "github.com/sirupsen/logrus"
+	grpc "google.golang.org/grpc"
 )
 
 var (
@@ -36,44 +47,474 @@ var (
 	// RedisPort : Redis Port
 	RedisPort = 6379
 
-	// RedisPassword : Redis Password
+	// RedisPassword : Redis Password, used when none is configured
 	RedisPassword = "example"
 
 	// RedisURL : Redis Connection URL
 	RedisURL = fmt.Sprintf("redis://%s:%d", RedisHost, RedisPort)
+
+	// migratePasshashes : Upgrade legacy ACL passhashes to configured algorithm and exit
+	migratePasshashes = flag.Bool("migrate-passhashes", false, "Upgrade legacy ACL passhashes to configured algorithm and exit")
+
+	// dedupACLPatterns : Remove duplicate patterns of VerneMQ ACLs and exit
+	dedupACLPatterns = flag.Bool("dedup-acl-patterns", false, "Remove duplicate patterns of VerneMQ ACLs and exit")
+
+	// migrate : Apply pending schema migrations and exit
+	migrate = flag.Bool("migrate", false, "Apply pending schema migrations and exit")
+
+	// pushDispatcher : Only dispatch push notification jobs of the RabbitMQ queue, without serving the API
+	pushDispatcher = flag.Bool("push-dispatcher", false, "Dispatch push notification jobs of the RabbitMQ queue without serving the API")
 )
 
 func main() {
 
+	flag.Parse()
+
+	// Get structured logger, shared by the whole service
+	logger := models.NewLogger()
+
 	if os.Getenv("WAVE_CONFIG_FILE_PATH") == "" {
-		log.Fatalf("WAVE_CONFIG_FILE_PATH Environment variable must be set !")
+		logger.Fatal("WAVE_CONFIG_FILE_PATH Environment variable must be set !")
 	}
 
-	// Get MongoDB communication interface
-	// If an error occurs, program is set to panic
-	mongoDB := models.NewMongoDB(MongoDBURL)
-
-	// Get Redis communication interface
-	// If an error occurs, program is set to panic
-	redis := models.NewRedis(RedisURL, RedisPassword)
+	// Register Prometheus metrics, exposed on /metrics
+	models.RegisterMetrics()
 
-	// Add interfaces & blank config to the environment
+	// Add blank config to the environment
 	env := &models.Env{
-		MongoDB: mongoDB,
-		Redis:   redis,
+		Logger:  logger,
 		Config:  models.Config{},
+		Workers: models.NewWorkers(),
+	}
+
+	// Get authentication provider, verifying tokens according to configured authentication mode
+	env.AuthProvider = auth.NewProvider(env)
+
+	// Get VerneMQ administration interface, reading the current config on every call
+	env.Broker = models.NewVerneMQBroker(env)
+
+	// Get push notifications dispatcher
+	dispatcher := notifications.NewDispatcher(env)
+	env.Notifier = dispatcher
+
+	// Load config, reloaded for requests when the config file is modified or on SIGHUP
+	err := env.LoadConfig()
+
+	if err != nil {
+		logger.WithError(err).Fatal("Failed to load config")
+	}
+
+	// Refuse to start with unsafe identity providers (e.g. HMAC JWTs without secret, tenant IDs which can't name collections)
+	err = auth.ValidateIdentityProviders(&env.Config)
+
+	if err != nil {
+		logger.WithError(err).Fatal("Invalid identity providers config")
+	}
+
+	// Signed endpoints fail closed without signing secret
+	if env.Config.RequestSigning.Disabled {
+		logger.Warn("Request signing is disabled, webhooks, services and admin endpoints accept unsigned requests")
+	} else if env.Config.RequestSigning.Secret == "" {
+		logger.Warn("No request signing secret configured, webhooks, services and admin endpoints refuse every request")
+	}
+
+	// Get Redis communication interface, to the configured cluster, to the master monitored by the configured Sentinels or to the built-in node,
+	// timed for metrics and bound by the operations timeouts of the config loaded at startup. Topology and pools are read at startup. If an error occurs, program is set to panic
+	redisConfig := &env.Config.Datastores.Redis
+
+	var redis *models.Redis
+
+	switch {
+
+	case redisConfig.Cluster.Enabled() && redisConfig.Sentinel.Enabled():
+		logger.Fatal("Redis Cluster and Redis Sentinel can't both be configured")
+
+	case redisConfig.Cluster.Enabled():
+		redis = models.NewRedisCluster(redisConfig, RedisPassword)
+
+	case redisConfig.Sentinel.Enabled():
+		redis = models.NewRedisSentinel(redisConfig, RedisPassword)
+
+	default:
+		redis = models.NewRedis(RedisURL, redisConfig, RedisPassword)
+	}
+
+	// Export stats of the connections pools
+	models.RegisterRedisPoolMetrics(redis)
+
+	env.Redis = models.InstrumentRedis(redis, &redisConfig.DatastoreConfig)
+
+	// Get Store communication interface of the configured backend, timed for metrics and bound by the operations timeouts
+	// of the config loaded at startup. Connection settings are read at startup. If an error occurs, program is set to panic
+	switch env.Config.Datastores.Backend {
+
+	case models.StoreBackendPostgreSQL:
+
+		env.Store = models.InstrumentStore(
+			models.NewPostgreSQL(&env.Config.Datastores.PostgreSQL),
+			models.DatastorePostgreSQL,
+			&env.Config.Datastores.PostgreSQL.DatastoreConfig,
+		)
+
+	case "", models.StoreBackendMongoDB:
+
+		// Built-in URL is used when none is configured
+		mongoDBConfig := env.Config.Datastores.MongoDB
+
+		if mongoDBConfig.URI == "" {
+			mongoDBConfig.URI = MongoDBURL
+		}
+
+		mongoDB := models.NewMongoDB(&mongoDBConfig)
+
+		// Mutations are written along with their lifecycle events in transactions, refused by standalone servers
+		err = mongoDB.CheckTransactions(context.Background())
+
+		if err != nil {
+			logger.WithError(err).Fatal("MongoDB deployment can't run transactions")
+		}
+
+		// MongoDB transient failures are retried, within the operations timeouts
+		env.Store = models.InstrumentStore(
+			models.RetryMongoDB(mongoDB, &env.Config.Datastores.MongoDB),
+			models.DatastoreMongoDB,
+			&env.Config.Datastores.MongoDB.DatastoreConfig,
+		)
+
+	default:
+		logger.WithField("backend", env.Config.Datastores.Backend).Fatal("Unknown store backend")
+	}
+
+	// Apply logging config, again on SIGUSR1
+	err = models.ConfigureLogger(logger, env.Config.Logging)
+
+	if err != nil {
+		logger.WithError(err).Fatal("Invalid logging config")
+	}
+
+	env.ReloadLoggingOnSignal()
+
+	env.ReloadConfig()
+
+	// Stream real-time events of the instance to operators, keeping recent ones for reconnecting streams
+	env.Events = models.NewEventBus(env.Config.Events.HistorySize)
+
+	// Report errors and panics when a DSN is configured
+	stopErrorReporting, err := models.StartErrorReporting(logger, env.Config.ErrorReporting)
+
+	if err != nil {
+		logger.WithError(err).Fatal("Failed to start error reporting")
 	}
 
-	// Dynamically load config
-	err := env.RefreshConfig()
+	defer stopErrorReporting()
+
+	// Trace requests, exporting spans when enabled
+	stopTracing, err := models.StartTracing(env.Config.Tracing)
 
 	if err != nil {
-		log.Fatalf(err.Error())
+		logger.WithError(err).Fatal("Failed to start tracing")
+	}
+
+	defer stopTracing()
+
+	// Batch passhash migration command
+	if *migratePasshashes {
+
+		report, err := auth.MigratePasshashes(env)
+
+		if err != nil {
+			logger.WithError(err).Fatal("Passhash migration failed")
+		}
+
+		logger.WithFields(logrus.Fields{
+			"users":    report.Users,
+			"upgraded": report.Upgraded,
+			"failed":   report.Failed,
+		}).Info("Passhash migration done")
+
+		env.Store.Close()
+		env.Redis.CloseConnection()
+
+		return
+	}
+
+	// ACL patterns deduplication command
+	if *dedupACLPatterns {
+
+		deduplicated, err := env.Store.DedupACLPatterns(env.TraceContext())
+
+		if err != nil {
+			logger.WithError(err).Fatal("ACL patterns deduplication failed")
+		}
+
+		logger.WithField("acls", deduplicated).Info("ACL patterns deduplication done")
+
+		env.Store.Close()
+		env.Redis.CloseConnection()
+
+		return
 	}
 
-	router.Listen(env)
+	// Schema migrations command
+	if *migrate {
+
+		report, err := auth.RunMigrations(env)
+
+		if err != nil {
+			logger.WithError(err).WithField("pending", report.Pending).Fatal("Migrations failed")
+		}
+
+		logger.WithField("applied", report.Applied).Info("Migrations done")
 
-	defer func() {
+		env.Store.Close()
 		env.Redis.CloseConnection()
+
+		return
+	}
+
+	// Queue settings are read at startup
+	queueConfig := env.Config.Notifications.Queue
+
+	// Push dispatcher command, consuming notification jobs queued by API instances
+	if *pushDispatcher {
+
+		if queueConfig.URL == "" {
+			logger.Fatal("notifications.queue.url must be set to dispatch push notification jobs")
+		}
+
+		runPushDispatcher(env, dispatcher, &queueConfig)
+
+		return
+	}
+
+	// Queue push notification jobs for push dispatchers instead of sending them, when a RabbitMQ URL is configured
+	if queueConfig.URL != "" {
+		env.Notifier = notifications.NewQueueNotifier(&queueConfig)
+	}
+
+	// Apply pending schema migrations before serving, unless another instance is applying them
+	if env.Config.Migrations.RunAtStartup {
+
+		report, err := auth.RunMigrations(env)
+
+		if err == auth.ErrMigrationLeased {
+			logger.WithField("pending", report.Pending).Warn("Migrations being applied by another instance")
+		} else if err != nil {
+			logger.WithError(err).WithField("pending", report.Pending).Fatal("Migrations failed")
+		} else if len(report.Applied) > 0 {
+			logger.WithField("applied", report.Applied).Info("Migrations done")
+		}
+	}
+
+	// Create store indexes in background, the instance being ready once they exist
+	auth.StartIndexCreation(env)
+
+	// Remove ACLs which expired while service was down, then periodically
+	err = auth.PurgeExpiredACLs(env)
+
+	if err != nil {
+		logger.WithError(err).Error("Failed to remove expired ACLs")
+	}
+
+	auth.StartACLCleanup(env)
+
+	// Purge tombstones of removed ACLs and group conversations once their retention is over, or have TTL indexes expire them,
+	// at startup so that expiry follows the retention and legal hold configured, then periodically
+	err = auth.PurgeTombstones(env)
+
+	if err != nil {
+		logger.WithError(err).Error("Failed to purge tombstones")
+	}
+
+	auth.StartTombstonesPurge(env)
+
+	// Move usage counters to the store periodically
+	auth.StartUsageFlush(env)
+
+	// Ping the store and Redis periodically, logging and exposing their degradations
+	auth.StartDependencyMonitor(env)
+
+	// Attempt webhook deliveries due, including those left by stopped instances
+	auth.StartWebhookDeliveries(env)
+
+	// Get internal MQTT publisher of system messages, with its own VerneMQ ACL
+	env.Publisher, err = auth.NewSystemPublisher(env)
+
+	if err != nil {
+		logger.WithError(err).Fatal("Failed to provision system publisher")
+	}
+
+	// Produce lifecycle events over the configured transport, if any
+	env.Producer, err = models.NewProducer(&env.Config.LifecycleEvents, logger)
+
+	if err != nil {
+		logger.WithError(err).Fatal("Invalid lifecycle events config")
+	}
+
+	// Relay lifecycle events committed with their mutations to the producer and outbound webhooks
+	auth.StartOutboxRelay(env)
+
+	// Invalidate caches and stream changes committed by every instance, when enabled
+	auth.StartChangeStreams(env)
+
+	// Stop gracefully on SIGTERM (Orchestrators) or SIGINT
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGTERM, syscall.SIGINT)
+
+	server := router.NewServer(env)
+	serverErrors := make(chan error, 2)
+
+	go func() {
+		serverErrors <- router.Serve(env, server)
 	}()
+
+	// Serve gRPC API of internal services on its own address when enabled
+	var grpcServer *grpc.Server
+
+	if env.Config.GRPC.Enabled {
+
+		grpcServer, err = rpc.NewServer(env)
+
+		if err != nil {
+			logger.WithError(err).Fatal("Failed to create gRPC server")
+		}
+
+		go func() {
+			serverErrors <- rpc.Serve(env, grpcServer)
+		}()
+	}
+
+	select {
+	case sig := <-signals:
+		logger.WithField("signal", sig.String()).Info("Shutting down")
+	case err := <-serverErrors:
+		logger.WithError(err).Error("Server stopped")
+	}
+
+	shutdown(env, server, grpcServer)
+}
+
+// shutdown : Stop accepting requests, wait for in-flight ones and background tasks until configured deadline, then close clients.
+// ACLs writes are not interrupted unless the deadline is exceeded
+func shutdown(env *models.Env, server *http.Server, grpcServer *grpc.Server) {
+
+	timeout := env.Config.Shutdown.Timeout
+
+	if timeout <= 0 {
+		timeout = models.DefaultShutdownTimeout
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(timeout)*time.Second)
+	defer cancel()
+
+	err := server.Shutdown(ctx)
+
+	if err != nil {
+		env.Logger.WithError(err).Error("Failed to drain in-flight requests")
+	}
+
+	if grpcServer != nil {
+		stopGRPC(ctx, grpcServer)
+	}
+
+	err = env.Workers.Stop(ctx)
+
+	if err != nil {
+		env.Logger.WithError(err).Error("Failed to wait for background tasks")
+	}
+
+	if env.Publisher != nil {
+		env.Publisher.Close()
+	}
+
+	// Notification jobs were queued by background tasks, done by now
+	if queueNotifier, ok := env.Notifier.(*notifications.QueueNotifier); ok {
+
+		err = queueNotifier.Close()
+
+		if err != nil {
+			env.Logger.WithError(err).Error("Failed to close notification jobs queue connection")
+		}
+	}
+
+	// Queued lifecycle events are produced before closing
+	if env.Producer != nil {
+
+		err = env.Producer.Close()
+
+		if err != nil {
+			env.Logger.WithError(err).Error("Failed to close lifecycle events producer")
+		}
+	}
+
+	err = env.Store.Close()
+
+	if err != nil {
+		env.Logger.WithError(err).Error("Failed to close store client")
+	}
+
+	err = env.Redis.CloseConnection()
+
+	if err != nil {
+		env.Logger.WithError(err).Error("Failed to close Redis connection")
+	}
+
+	env.Logger.Info("Shutdown complete")
+}
+
+// runPushDispatcher : Dispatch push notification jobs of the RabbitMQ queue until SIGTERM or SIGINT.
+// Jobs in flight are dispatched until the shutdown deadline, unacknowledged ones are then redelivered to other dispatchers
+func runPushDispatcher(env *models.Env, dispatcher *notifications.Dispatcher, config *models.NotificationQueueConfig) {
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGTERM, syscall.SIGINT)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	stopped := make(chan struct{})
+
+	go func() {
+		notifications.ConsumeQueue(ctx, dispatcher, config)
+		close(stopped)
+	}()
+
+	env.Logger.Info("Dispatching push notification jobs")
+
+	sig := <-signals
+	env.Logger.WithField("signal", sig.String()).Info("Shutting down")
+
+	cancel()
+
+	timeout := env.Config.Shutdown.Timeout
+
+	if timeout <= 0 {
+		timeout = models.DefaultShutdownTimeout
+	}
+
+	select {
+	case <-stopped:
+	case <-time.After(time.Duration(timeout) * time.Second):
+		env.Logger.Error("Failed to wait for notification jobs in flight")
+	}
+
+	env.Store.Close()
+	env.Redis.CloseConnection()
+
+	env.Logger.Info("Shutdown complete")
+}
+
+// stopGRPC : Stop accepting gRPC calls and wait for in-flight ones until ctx is done, then cancel them
+func stopGRPC(ctx context.Context, server *grpc.Server) {
+
+	stopped := make(chan struct{})
+
+	go func() {
+		server.GracefulStop()
+		close(stopped)
+	}()
+
+	select {
+	case <-stopped:
+	case <-ctx.Done():
+		server.Stop()
+	}
 }
@@ -0,0 +1,16 @@
+package models
+
+// AccessLogConfig : HTTP access logs Config. Every request is logged while Enabled, except successful requests of handlers
+// listed in SampleRates (e.g. OnOfflineMessage), logged at the given rate (0.1 logs one in ten) to keep high-volume endpoints quiet
+type AccessLogConfig struct {
+	Enabled     bool               `json:"enabled"`
+	SampleRates map[string]float64 `json:"sampleRates"`
+}
+
+// IdentifyCaller : Remember caller of the request once authenticated, to be logged with the request (See ForRequest)
+func (env *Env) IdentifyCaller(actor AuditActor) {
+
+	if env.Caller != nil {
+		*env.Caller = actor
+	}
+}
@@ -0,0 +1,90 @@
+package models
+
+import (
+	time "time"
+)
+
+const (
+	// APIKeyScopeMappingsRead : Read external/internal user IDs mappings
+	APIKeyScopeMappingsRead = "mappings:read"
+
+	// APIKeyScopeACLRead : Read VerneMQ ACLs of MQTT clients
+	APIKeyScopeACLRead = "acl:read"
+
+	// APIKeyScopeACLWrite : Grant publishing rights on MQTT topics to users
+	APIKeyScopeACLWrite = "acl:write"
+
+	// APIKeyScopeConversationsWrite : Create group conversations on behalf of users (e.g. Migrations)
+	APIKeyScopeConversationsWrite = "conversations:write"
+
+	// APIKeyScopeAuthCacheWrite : Invalidate cached tokens
+	APIKeyScopeAuthCacheWrite = "authcache:write"
+
+	// APIKeyScopeRevocationsWrite : Revoke tokens and users
+	APIKeyScopeRevocationsWrite = "revocations:write"
+
+	// APIKeyScopeAdminUsersRead : List and inspect users on the admin API
+	APIKeyScopeAdminUsersRead = "admin:users:read"
+
+	// APIKeyScopeAdminUsersWrite : Suspend users on the admin API
+	APIKeyScopeAdminUsersWrite = "admin:users:write"
+
+	// APIKeyScopeAdminConversationsWrite : Remove and restore group conversations on the admin API
+	APIKeyScopeAdminConversationsWrite = "admin:conversations:write"
+
+	// APIKeyScopeAdminBroadcast : Publish system messages on the admin API
+	APIKeyScopeAdminBroadcast = "admin:broadcast"
+
+	// APIKeyScopeAdminUsageRead : Read usage reports on the admin API
+	APIKeyScopeAdminUsageRead = "admin:usage:read"
+
+	// APIKeyScopeAdminAuditRead : Query the audit log on the admin API
+	APIKeyScopeAdminAuditRead = "admin:audit:read"
+
+	// APIKeyScopeAdminEventsRead : Stream real-time events on the admin API
+	APIKeyScopeAdminEventsRead = "admin:events:read"
+
+	// APIKeyScopeAdminWebhooks : Register, list and remove outbound webhooks, and read their deliveries on the admin API
+	APIKeyScopeAdminWebhooks = "admin:webhooks"
+
+	// APIKeyScopeAdminLogging : Read and change logging config on the admin API
+	APIKeyScopeAdminLogging = "admin:logging"
+
+	// APIKeyScopeAdminDebug : Profile the process on the debug server
+	APIKeyScopeAdminDebug = "admin:debug"
+)
+
+// APIKey : API key of an internal backend service
+// Only the SHA-256 hash of the key is stored, the key itself is handed to the service once. Services of a tenant only access its data
+type APIKey struct {
+	HashedKey   string    `json:"-" bson:"hashedKey"`
+	ServiceName string    `json:"serviceName" bson:"serviceName"`
+	TenantID    string    `json:"tenantID,omitempty" bson:"tenantID,omitempty"`
+	Scopes      []string  `json:"scopes" bson:"scopes"`
+	Disabled    bool      `json:"disabled" bson:"disabled"`
+	CreatedAt   time.Time `json:"createdAt" bson:"createdAt"`
+}
+
+// NewAPIKey : Return new APIKey struct pointer
+func NewAPIKey(hashedKey string, serviceName string, tenantID string, scopes []string) *APIKey {
+	return &APIKey{
+		HashedKey:   hashedKey,
+		ServiceName: serviceName,
+		TenantID:    tenantID,
+		Scopes:      scopes,
+		Disabled:    false,
+		CreatedAt:   time.Now().UTC(),
+	}
+}
+
+// HasScope : Check if API key was granted scope
+func (apiKey *APIKey) HasScope(scope string) bool {
+
+	for _, granted := range apiKey.Scopes {
+		if granted == scope {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,164 @@
+package models
+
+import (
+	time "time"
+	utils "wave-messaging-management-service/utils"
+)
+
+const (
+	// DefaultAuditPageLimit : Number of audit entries listed per page when no limit is given
+	DefaultAuditPageLimit = 50
+
+	// MaxAuditPageLimit : Maximum number of audit entries listed per page
+	MaxAuditPageLimit = 500
+
+	// AuditActorUser : Action performed by an authenticated user (Internal Wave user ID)
+	AuditActorUser = "user"
+
+	// AuditActorService : Action performed by an internal service or an operator (Service name)
+	AuditActorService = "service"
+
+	// AuditActorSystem : Action performed by the service itself (Background workers, credentials rotation)
+	AuditActorSystem = "system"
+
+	// AuditACLCreate : VerneMQ ACL of a user created
+	AuditACLCreate = "acl.create"
+
+	// AuditACLGrant : Publishing rights granted to a user
+	AuditACLGrant = "acl.grant"
+
+	// AuditACLExpire : Expired VerneMQ ACL removed
+	AuditACLExpire = "acl.expire"
+
+	// AuditDeviceRegister : Device ACL of a user created
+	AuditDeviceRegister = "device.register"
+
+	// AuditDeviceDeregister : Device ACL of a user removed
+	AuditDeviceDeregister = "device.deregister"
+
+	// AuditGroupCreate : Group conversation created
+	AuditGroupCreate = "group.create"
+
+	// AuditGroupMemberAdd : User added to a group conversation
+	AuditGroupMemberAdd = "group.member.add"
+
+	// AuditCredentialsRotate : MQTT credentials of a user rotated with its new token
+	AuditCredentialsRotate = "credentials.rotate"
+
+	// AuditCredentialsRevoke : MQTT credentials of a user revoked and sessions disconnected
+	AuditCredentialsRevoke = "credentials.revoke"
+
+	// AuditAuthCacheInvalidate : Cached token invalidated
+	AuditAuthCacheInvalidate = "authcache.invalidate"
+
+	// AuditTokenRevoke : Token added to denylist
+	AuditTokenRevoke = "token.revoke"
+
+	// AuditUserRevoke : Application user added to denylist
+	AuditUserRevoke = "user.revoke"
+
+	// AuditUserRestore : Application user removed from denylist
+	AuditUserRestore = "user.restore"
+
+	// AuditAdminUserSuspend : User suspended by an operator
+	AuditAdminUserSuspend = "admin.user.suspend"
+
+	// AuditAdminUserRestore : Removed VerneMQ ACLs of a user restored by an operator
+	AuditAdminUserRestore = "admin.user.restore"
+
+	// AuditAdminGroupRemove : Group conversation removed by an operator
+	AuditAdminGroupRemove = "admin.group.remove"
+
+	// AuditAdminGroupRestore : Removed group conversation restored by an operator
+	AuditAdminGroupRestore = "admin.group.restore"
+
+	// AuditTombstonesPurge : Tombstones of removed VerneMQ ACLs and group conversations purged after their retention
+	AuditTombstonesPurge = "tombstones.purge"
+
+	// AuditAdminUserDisconnect : Sessions of a user disconnected by an operator
+	AuditAdminUserDisconnect = "admin.user.disconnect"
+
+	// AuditAdminQuotasSet : User quotas overridden by an operator
+	AuditAdminQuotasSet = "admin.quotas.set"
+
+	// AuditAdminBroadcast : System message broadcast by an operator
+	AuditAdminBroadcast = "admin.broadcast"
+
+	// AuditAdminLoggingSet : Logging config changed by an operator
+	AuditAdminLoggingSet = "admin.logging.set"
+
+	// AuditAdminWebhookCreate : Outbound webhook registered by an operator
+	AuditAdminWebhookCreate = "admin.webhook.create"
+
+	// AuditAdminWebhookRemove : Outbound webhook removed by an operator
+	AuditAdminWebhookRemove = "admin.webhook.remove"
+
+	// AuditAdminWebhookDeadLetterReplay : Dead-lettered webhook delivery queued again by an operator
+	AuditAdminWebhookDeadLetterReplay = "admin.webhook.deadletter.replay"
+
+	// AuditAdminWebhookDeadLetterDiscard : Dead-lettered webhook delivery discarded by an operator
+	AuditAdminWebhookDeadLetterDiscard = "admin.webhook.deadletter.discard"
+)
+
+// AuditActor : Author of an audited action, ID depends on Type (AuditActor* constants)
+type AuditActor struct {
+	Type string `json:"type" bson:"type"`
+	ID   string `json:"id" bson:"id"`
+}
+
+// AuditEntry : Security relevant action, appended to the audit log.
+// Tokens never appear in entries, only their SHA-256 fingerprint
+type AuditEntry struct {
+	Action    string            `json:"action" bson:"action"`
+	Actor     AuditActor        `json:"actor" bson:"actor"`
+	Target    string            `json:"target" bson:"target"`
+	TenantID  string            `json:"tenantID,omitempty" bson:"tenantID,omitempty"`
+	Details   map[string]string `json:"details,omitempty" bson:"details,omitempty"`
+	RequestID string            `json:"requestID,omitempty" bson:"requestID,omitempty"`
+	Timestamp time.Time         `json:"timestamp" bson:"timestamp"`
+}
+
+// AuditFilter : Criteria of an audit log query, empty criteria match all entries.
+// From and To bound entries timestamp (Both inclusive), Page selects the listed entries
+type AuditFilter struct {
+	ActorType string
+	ActorID   string
+	Target    string
+	Action    string
+	TenantID  *string
+	From      *time.Time
+	To        *time.Time
+	Page      *utils.Pagination
+}
+
+// AuditPage : Page of audit entries matching a query, most recent first. Total counts all matching entries
+type AuditPage struct {
+	utils.Page
+	Entries []*AuditEntry `json:"entries"`
+}
+
+// NewAuditEntry : Return new AuditEntry struct pointer, timestamped now
+func NewAuditEntry(actor AuditActor, action string, target string, details map[string]string) *AuditEntry {
+	return &AuditEntry{
+		Action:    action,
+		Actor:     actor,
+		Target:    target,
+		Details:   details,
+		Timestamp: time.Now().UTC(),
+	}
+}
+
+// UserActor : Return audit actor of an authenticated user
+func UserActor(internalWaveUserID string) AuditActor {
+	return AuditActor{Type: AuditActorUser, ID: internalWaveUserID}
+}
+
+// ServiceActor : Return audit actor of an internal service
+func ServiceActor(serviceName string) AuditActor {
+	return AuditActor{Type: AuditActorService, ID: serviceName}
+}
+
+// SystemActor : Return audit actor of the service itself
+func SystemActor() AuditActor {
+	return AuditActor{Type: AuditActorSystem}
+}
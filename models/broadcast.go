@@ -0,0 +1,41 @@
+package models
+
+import (
+	time "time"
+
+	uuid "github.com/satori/go.uuid"
+)
+
+const (
+	// SystemMessageType : Type of messages published on system topics, so that clients tell them apart from conversation messages
+	SystemMessageType = "system"
+)
+
+// SystemMessage : Message published by operators to all users of a tenant or to a segment of them
+type SystemMessage struct {
+	MessageID string            `json:"messageID"`
+	Type      string            `json:"type"`
+	Message   string            `json:"message"`
+	Data      map[string]string `json:"data,omitempty"`
+	SentAt    time.Time         `json:"sentAt"`
+}
+
+// NewSystemMessage : Return new SystemMessage struct pointer
+func NewSystemMessage(message string, data map[string]string) *SystemMessage {
+	return &SystemMessage{
+		MessageID: uuid.NewV4().String(),
+		Type:      SystemMessageType,
+		Message:   message,
+		Data:      data,
+		SentAt:    time.Now().UTC(),
+	}
+}
+
+// BroadcastReport : Outcome of a system message broadcast
+// Recipients is empty when message was published to all users, Failed lists recipients it couldn't be published to
+type BroadcastReport struct {
+	MessageID  string   `json:"messageID"`
+	Topics     []string `json:"topics"`
+	Recipients []string `json:"recipients"`
+	Failed     []string `json:"failed"`
+}
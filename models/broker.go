@@ -0,0 +1,111 @@
+package models
+
+import (
+	json "encoding/json"
+	fmt "fmt"
+	http "net/http"
+	url "net/url"
+)
+
+// BrokerInterface : MQTT Broker administration interface
+type BrokerInterface interface {
+	DisconnectSession(clientID string) error
+	GetOnlineClients() (map[string]bool, error)
+}
+
+// VerneMQBroker : VerneMQ HTTP API communication interface
+type VerneMQBroker struct {
+	Env    *Env
+	Client *http.Client
+}
+
+// NewVerneMQBroker : Return a new VerneMQ HTTP API abstraction struct
+// Endpoint and API key are read from the current config of env on every call, so that changes are picked up on reload (See RefreshConfig)
+func NewVerneMQBroker(env *Env) *VerneMQBroker {
+	return &VerneMQBroker{
+		Env:    env,
+		Client: &http.Client{},
+	}
+}
+
+// DisconnectSession : Disconnect MQTT client and clean up its session on the broker
+func (broker *VerneMQBroker) DisconnectSession(clientID string) error {
+
+	// VerneMQ maps vmq-admin commands on its HTTP API :
+	// vmq-admin session disconnect client-id={clientID} --cleanup
+	query := url.Values{}
+	query.Set("client-id", clientID)
+	query.Set("--cleanup", "true")
+
+	res, err := broker.get("/session/disconnect?" + query.Encode())
+
+	if err != nil {
+		return err
+	}
+
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("error disconnecting client %s : broker responded with status %d", clientID, res.StatusCode)
+	}
+
+	return nil
+}
+
+// GetOnlineClients : Return client IDs of MQTT clients currently connected to the broker
+func (broker *VerneMQBroker) GetOnlineClients() (map[string]bool, error) {
+
+	// vmq-admin session show --client_id --is_online=true
+	// Flags without value select displayed columns, which url.Values can't encode
+	res, err := broker.get("/session/show?--client_id&--is_online=true")
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("error listing sessions : broker responded with status %d", res.StatusCode)
+	}
+
+	sessions := struct {
+		Table []struct {
+			ClientID string `json:"client_id"`
+			IsOnline bool   `json:"is_online"`
+		} `json:"table"`
+	}{}
+
+	err = json.NewDecoder(res.Body).Decode(&sessions)
+
+	if err != nil {
+		return nil, err
+	}
+
+	onlineClients := map[string]bool{}
+
+	for _, session := range sessions.Table {
+		if session.IsOnline {
+			onlineClients[session.ClientID] = true
+		}
+	}
+
+	return onlineClients, nil
+}
+
+// get : Send vmq-admin command to the VerneMQ HTTP API
+func (broker *VerneMQBroker) get(command string) (*http.Response, error) {
+
+	config := broker.Env.CurrentConfig()
+
+	req, err := http.NewRequest("GET", config.VerneMQAPIEndpoint+command, nil)
+
+	if err != nil {
+		return nil, err
+	}
+
+	// VerneMQ API key is passed as basic auth username
+	req.SetBasicAuth(config.VerneMQAPIKey, "")
+
+	return broker.Client.Do(req)
+}
@@ -0,0 +1,170 @@
+package models
+
+import (
+	errors "errors"
+	regexp "regexp"
+
+	bson "go.mongodb.org/mongo-driver/bson"
+	mongo "go.mongodb.org/mongo-driver/mongo"
+)
+
+const (
+	// DefaultChangeStreamsRetryInterval : Seconds before watching changes again after the change stream failed, used when none is configured
+	DefaultChangeStreamsRetryInterval = 5
+
+	// ChangeEventPrefix : Prefix of the types of real-time events of data changes (e.g. change.acl.update)
+	ChangeEventPrefix = "change."
+
+	// changeStreamHistoryLostCode : Resume token is older than the oplog, the change stream can't be resumed
+	changeStreamHistoryLostCode = 286
+)
+
+// ChangeStreamsConfig : MongoDB change streams Config, read at startup. When Enabled, every instance watches changes of VerneMQ ACLs
+// and group conversations, committed by any instance or outside the service, to invalidate Redis caches and publish them as real-time events.
+// Change streams need a replica set, failed ones are watched again after RetryInterval seconds
+type ChangeStreamsConfig struct {
+	Enabled       bool `json:"enabled"`
+	RetryInterval int  `json:"retryInterval"`
+}
+
+// DataChange : Change of a VerneMQ ACL or group conversation document. Collection is the name of its collection without tenant prefix,
+// ACL or GroupConversation the document after the change (Nil for deletions, whose DocumentID is the only reference)
+type DataChange struct {
+	Operation         string
+	Collection        string
+	TenantID          string
+	DocumentID        string
+	UpdatedFields     []string
+	ACL               *VerneMQACL
+	GroupConversation *GroupConversation
+}
+
+// EventType : Return type of the real-time event of change, e.g. change.acl.update or change.group.insert
+func (change *DataChange) EventType() string {
+
+	if change.Collection == GroupConversationCollection {
+		return ChangeEventPrefix + "group." + change.Operation
+	}
+
+	return ChangeEventPrefix + "acl." + change.Operation
+}
+
+// Target : Return client ID of changed ACL, or ID of changed group conversation. Deleted documents are referred to by their document ID
+func (change *DataChange) Target() string {
+
+	if change.ACL != nil {
+		return change.ACL.ClientID
+	}
+
+	if change.GroupConversation != nil {
+		return change.GroupConversation.GroupConversationID
+	}
+
+	return change.DocumentID
+}
+
+// IsUpdated : Check if field was set by change. Inserted and replaced documents have all their fields set
+func (change *DataChange) IsUpdated(field string) bool {
+
+	if change.Operation == "insert" || change.Operation == "replace" {
+		return true
+	}
+
+	for _, updatedField := range change.UpdatedFields {
+		if updatedField == field {
+			return true
+		}
+	}
+
+	return false
+}
+
+// changeStreamDocument : Change event of the change stream, as sent by MongoDB
+type changeStreamDocument struct {
+	OperationType string `bson:"operationType"`
+	Namespace     struct {
+		Collection string `bson:"coll"`
+	} `bson:"ns"`
+	DocumentKey struct {
+		ID interface{} `bson:"_id"`
+	} `bson:"documentKey"`
+	FullDocument      bson.Raw `bson:"fullDocument"`
+	UpdateDescription struct {
+		UpdatedFields bson.M `bson:"updatedFields"`
+	} `bson:"updateDescription"`
+}
+
+// changeStreamPipeline : Return pipeline of the change stream, keeping document changes of VerneMQ ACLs and group conversations (Of every tenant) named after names
+func changeStreamPipeline(names *CollectionNames) mongo.Pipeline {
+	return mongo.Pipeline{
+		bson.D{bson.E{Key: "$match", Value: bson.M{
+			"operationType": bson.M{"$in": bson.A{"insert", "update", "replace", "delete"}},
+			"$or": bson.A{
+				bson.M{"ns.coll": names.Name("", VerneMQACLCollection)},
+				bson.M{"ns.coll": bson.M{"$regex": "^" + regexp.QuoteMeta(names.Prefix) + "(.+_)?" + regexp.QuoteMeta(names.base(GroupConversationCollection)) + "$"}},
+			},
+		}}},
+	}
+}
+
+// newDataChange : Return data change of change stream document, its collection being named after names
+func newDataChange(document *changeStreamDocument, names *CollectionNames) (*DataChange, error) {
+
+	change := &DataChange{
+		Operation:  document.OperationType,
+		Collection: VerneMQACLCollection,
+		DocumentID: documentID(document.DocumentKey.ID),
+	}
+
+	for field := range document.UpdateDescription.UpdatedFields {
+		change.UpdatedFields = append(change.UpdatedFields, field)
+	}
+
+	if tenantID, ok := names.TenantOf(document.Namespace.Collection, GroupConversationCollection); ok {
+		change.TenantID = tenantID
+		change.Collection = GroupConversationCollection
+	}
+
+	// Updated documents are looked up when the change is sent, they are gone if removed since
+	if len(document.FullDocument) == 0 {
+		return change, nil
+	}
+
+	var err error
+
+	if change.Collection == GroupConversationCollection {
+		change.GroupConversation = &GroupConversation{}
+		err = bson.Unmarshal(document.FullDocument, change.GroupConversation)
+	} else {
+		change.ACL = &VerneMQACL{}
+		err = bson.Unmarshal(document.FullDocument, change.ACL)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return change, nil
+}
+
+// documentID : Return string representation of document ID
+func documentID(id interface{}) string {
+
+	if objectID, ok := id.(interface{ Hex() string }); ok {
+		return objectID.Hex()
+	}
+
+	if stringID, ok := id.(string); ok {
+		return stringID
+	}
+
+	return ""
+}
+
+// IsChangeStreamHistoryLost : Check if err tells a change stream can't be resumed, as changes following its resume token left the oplog
+func IsChangeStreamHistoryLost(err error) bool {
+
+	var serverErr mongo.ServerError
+
+	return errors.As(err, &serverErr) && serverErr.HasErrorCode(changeStreamHistoryLostCode)
+}
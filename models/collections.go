@@ -0,0 +1,133 @@
+package models
+
+import (
+	errors "errors"
+	os "os"
+	strings "strings"
+)
+
+const (
+	// MongoDBDatabaseEnv : Environment variable overriding the configured MongoDB database
+	MongoDBDatabaseEnv = "WAVE_MONGODB_DATABASE"
+
+	// MongoDBCollectionPrefixEnv : Environment variable overriding the configured prefix of MongoDB collections
+	MongoDBCollectionPrefixEnv = "WAVE_MONGODB_COLLECTION_PREFIX"
+)
+
+var (
+	// mongoDBCollections : Collections of the service, by default name
+	mongoDBCollections = []string{
+		PrivateConversationsCollection,
+		VerneMQACLCollection,
+		DeletedACLsCollection,
+		GroupConversationCollection,
+		PushTokensCollection,
+		NotificationPreferencesCollection,
+		APIKeysCollection,
+		QuotaOverridesCollection,
+		UsageCollection,
+		AuditLogCollection,
+		WebhooksCollection,
+		WebhookDeliveriesCollection,
+		WebhookDeadLettersCollection,
+		OutboxCollection,
+		MigrationsCollection,
+	}
+)
+
+// CollectionNames : Names of the collections of the service in its database. Every collection is prefixed by Prefix,
+// then by {tenantID}_ for tenant collections, and renamed when listed in Renamed (By default name)
+type CollectionNames struct {
+	Prefix  string
+	Renamed map[string]string
+}
+
+// DatabaseName : Return name of the database of the service, read from the environment first
+func (config *MongoDBConfig) DatabaseName() string {
+
+	if database := os.Getenv(MongoDBDatabaseEnv); database != "" {
+		return database
+	}
+
+	if config.Database != "" {
+		return config.Database
+	}
+
+	return WaveDatabaseName
+}
+
+// newCollectionNames : Return names of the collections of config, prefix being read from the environment first
+func newCollectionNames(config *MongoDBConfig) (*CollectionNames, error) {
+
+	names := &CollectionNames{Prefix: config.CollectionPrefix, Renamed: config.Collections}
+
+	if prefix, ok := os.LookupEnv(MongoDBCollectionPrefixEnv); ok {
+		names.Prefix = prefix
+	}
+
+	for collection, name := range names.Renamed {
+
+		if !isMongoDBCollection(collection) {
+			return nil, errors.New("Unknown MongoDB collection " + collection)
+		}
+
+		if name == "" || strings.Contains(name, "$") {
+			return nil, errors.New("Invalid name of MongoDB collection " + collection)
+		}
+	}
+
+	return names, nil
+}
+
+// Name : Return name of collection (Default name) of tenantID, the default tenant being empty
+func (names *CollectionNames) Name(tenantID string, collection string) string {
+
+	if tenantID != "" {
+		return names.Prefix + tenantID + "_" + names.base(collection)
+	}
+
+	return names.Prefix + names.base(collection)
+}
+
+// TenantOf : Return tenant of the collection named name if it is a collection (Default name) of the default tenant or of a tenant, false otherwise
+func (names *CollectionNames) TenantOf(name string, collection string) (string, bool) {
+
+	if !strings.HasPrefix(name, names.Prefix) {
+		return "", false
+	}
+
+	name = strings.TrimPrefix(name, names.Prefix)
+	base := names.base(collection)
+
+	if name == base {
+		return "", true
+	}
+
+	if tenantID := strings.TrimSuffix(name, "_"+base); tenantID != name && tenantID != "" {
+		return tenantID, true
+	}
+
+	return "", false
+}
+
+// base : Return name of collection, without prefix
+func (names *CollectionNames) base(collection string) string {
+
+	if name, ok := names.Renamed[collection]; ok {
+		return name
+	}
+
+	return collection
+}
+
+// isMongoDBCollection : Check if collection is the default name of a collection of the service
+func isMongoDBCollection(collection string) bool {
+
+	for _, known := range mongoDBCollections {
+		if known == collection {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,166 @@
+package models
+
+import (
+	errors "errors"
+	strconv "strconv"
+	time "time"
+
+	options "go.mongodb.org/mongo-driver/mongo/options"
+	readpref "go.mongodb.org/mongo-driver/mongo/readpref"
+	writeconcern "go.mongodb.org/mongo-driver/mongo/writeconcern"
+)
+
+const (
+	// WriteClassACL : Writes of VerneMQ ACLs, and transactions (All of them updating ACLs)
+	WriteClassACL = "acl"
+
+	// WriteClassConversations : Writes of private and group conversations outside transactions
+	WriteClassConversations = "conversations"
+
+	// WriteClassUsers : Writes of push tokens, notification preferences, quota overrides and API keys
+	WriteClassUsers = "users"
+
+	// WriteClassAnalytics : Writes of usage counters and audit entries
+	WriteClassAnalytics = "analytics"
+
+	// WriteClassWebhooks : Writes of webhooks, their deliveries and dead letters
+	WriteClassWebhooks = "webhooks"
+
+	// WriteClassOutbox : Claims and removals of relayed outbox events
+	WriteClassOutbox = "outbox"
+)
+
+var (
+	// writeClasses : Operation class of writes to each collection, by collection name (Tenant collections included)
+	writeClasses = map[string]string{
+		VerneMQACLCollection:              WriteClassACL,
+		PrivateConversationsCollection:    WriteClassConversations,
+		GroupConversationCollection:       WriteClassConversations,
+		PushTokensCollection:              WriteClassUsers,
+		NotificationPreferencesCollection: WriteClassUsers,
+		QuotaOverridesCollection:          WriteClassUsers,
+		APIKeysCollection:                 WriteClassUsers,
+		UsageCollection:                   WriteClassAnalytics,
+		AuditLogCollection:                WriteClassAnalytics,
+		WebhooksCollection:                WriteClassWebhooks,
+		WebhookDeliveriesCollection:       WriteClassWebhooks,
+		WebhookDeadLettersCollection:      WriteClassWebhooks,
+		OutboxCollection:                  WriteClassOutbox,
+	}
+)
+
+// WriteConcernConfig : Write concern of an operation class. W is "majority", a number of members or a tag set name,
+// Journal waits for writes to be journaled, and WTimeout (Milliseconds) bounds replication waits
+type WriteConcernConfig struct {
+	W        string `json:"w"`
+	Journal  bool   `json:"journal"`
+	WTimeout int    `json:"wTimeout"`
+}
+
+// newWriteConcern : Return write concern of config
+func newWriteConcern(config *WriteConcernConfig) *writeconcern.WriteConcern {
+
+	concernOptions := []writeconcern.Option{}
+
+	if config.W == "majority" {
+		concernOptions = append(concernOptions, writeconcern.WMajority())
+	} else if w, err := strconv.Atoi(config.W); err == nil {
+		concernOptions = append(concernOptions, writeconcern.W(w))
+	} else if config.W != "" {
+		concernOptions = append(concernOptions, writeconcern.WTagSet(config.W))
+	}
+
+	if config.Journal {
+		concernOptions = append(concernOptions, writeconcern.J(true))
+	}
+
+	if config.WTimeout > 0 {
+		concernOptions = append(concernOptions, writeconcern.WTimeout(time.Duration(config.WTimeout)*time.Millisecond))
+	}
+
+	return writeconcern.New(concernOptions...)
+}
+
+// newReadPreference : Return read preference of mode (primary, primaryPreferred, secondary, secondaryPreferred or nearest)
+func newReadPreference(mode string) (*readpref.ReadPref, error) {
+
+	readMode, err := readpref.ModeFromString(mode)
+
+	if err != nil {
+		return nil, errors.New("Invalid MongoDB read preference " + mode)
+	}
+
+	return readpref.New(readMode)
+}
+
+// newCollectionsOptions : Return options of collections with a configured read preference or write concern, by collection name.
+// Other collections inherit the ones of the URI
+func newCollectionsOptions(config *MongoDBConfig) (map[string]*options.CollectionOptions, error) {
+
+	for collection := range config.ReadPreferences {
+		if _, ok := writeClasses[collection]; !ok {
+			return nil, errors.New("Unknown MongoDB collection " + collection)
+		}
+	}
+
+	for class := range config.WriteConcerns {
+		if !isWriteClass(class) {
+			return nil, errors.New("Unknown MongoDB operation class " + class)
+		}
+	}
+
+	collectionsOptions := map[string]*options.CollectionOptions{}
+
+	for collection, class := range writeClasses {
+
+		collectionOptions := options.Collection()
+		configured := false
+
+		if mode, ok := config.ReadPreferences[collection]; ok {
+
+			readPreference, err := newReadPreference(mode)
+
+			if err != nil {
+				return nil, err
+			}
+
+			collectionOptions.SetReadPreference(readPreference)
+			configured = true
+		}
+
+		if writeConcern, ok := config.WriteConcerns[class]; ok {
+			collectionOptions.SetWriteConcern(newWriteConcern(writeConcern))
+			configured = true
+		}
+
+		if configured {
+			collectionsOptions[collection] = collectionOptions
+		}
+	}
+
+	return collectionsOptions, nil
+}
+
+// isWriteClass : Check if class is the operation class of writes to a collection
+func isWriteClass(class string) bool {
+
+	for _, writeClass := range writeClasses {
+		if writeClass == class {
+			return true
+		}
+	}
+
+	return false
+}
+
+// newTransactionOptions : Return options of transactions, committed with the write concern of the acl class when configured
+func newTransactionOptions(config *MongoDBConfig) *options.TransactionOptions {
+
+	transactionOptions := options.Transaction()
+
+	if writeConcern, ok := config.WriteConcerns[WriteClassACL]; ok {
+		transactionOptions.SetWriteConcern(newWriteConcern(writeConcern))
+	}
+
+	return transactionOptions
+}
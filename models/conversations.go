@@ -1,22 +1,46 @@
 package models
 
 import (
+	time "time"
+	utils "wave-messaging-management-service/utils"
+
 	uuid "github.com/satori/go.uuid"
 )
 
-// GroupConversation : Group conversation struct
+const (
+	// MaxBulkGroupConversations : Maximum number of group conversations created by a bulk request
+	MaxBulkGroupConversations = 500
+
+	// DefaultConversationsPageLimit : Number of group conversations listed per page when no limit is given
+	DefaultConversationsPageLimit = 50
+
+	// MaxConversationsPageLimit : Maximum number of group conversations listed per page
+	MaxConversationsPageLimit = 500
+)
+
+// GroupConversationsPage : Page of the group conversations of a user, sorted by name. Total counts all its group conversations
+type GroupConversationsPage struct {
+	utils.Page
+	Conversations []*GroupConversation `json:"conversations"`
+}
+
+// GroupConversation : Group conversation struct. Removed group conversations are kept with their removal date (DeletedAt) until purged,
+// and left out of queries meanwhile (See TombstonesConfig)
 type GroupConversation struct {
-	GroupConversationID string   `json:"GroupConversationID" bson:"groupConversationID"`
-	Name                string   `json:"name" bson:"name"`
-	Members             []string `json:"members" bson:"members"`
+	GroupConversationID string     `json:"GroupConversationID" bson:"groupConversationID"`
+	Name                string     `json:"name" bson:"name"`
+	CreatorID           string     `json:"creatorID" bson:"creatorID,omitempty"`
+	Members             []string   `json:"members" bson:"members"`
+	DeletedAt           *time.Time `json:"deletedAt,omitempty" bson:"deletedAt,omitempty"`
 	// TODO: Add message backup support
 }
 
 // NewGroupConversation : Return new VerneMQACL struct pointer
-func NewGroupConversation(name string, members []string) *GroupConversation {
+func NewGroupConversation(name string, creatorID string, members []string) *GroupConversation {
 	return &GroupConversation{
 		GroupConversationID: uuid.NewV4().String(),
 		Name:                name,
+		CreatorID:           creatorID,
 		Members:             members,
 	}
 }
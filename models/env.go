@@ -1,42 +1,847 @@
 package models
 
 import (
+	context "context"
 	json "encoding/json"
+	errors "errors"
 	ioutil "io/ioutil"
+	net "net"
 	os "os"
+	signal "os/signal"
+	regexp "regexp"
+	atomic "sync/atomic"
+	syscall "syscall"
+	time "time"
+
+	logrus "github.com/sirupsen/logrus"
 )
 
 var (
 	configFilePath = os.Getenv("WAVE_CONFIG_FILE_PATH")
 )
 
-// Env : Execution environment containing Datastore communication interfaces (Redis, MongoDB) & Config
+const (
+	// AuthenticationModeEndpoint : Tokens are verified by the external authentication endpoint (Default)
+	AuthenticationModeEndpoint = "endpoint"
+
+	// AuthenticationModeJWT : Tokens are validated locally as JWTs
+	AuthenticationModeJWT = "jwt"
+
+	// AuthenticationModeIntrospection : Tokens are OAuth2 access tokens verified by an introspection endpoint (RFC 7662)
+	AuthenticationModeIntrospection = "introspection"
+
+	// AuthenticationModeStaticFile : Tokens are looked up in a static JSON file
+	AuthenticationModeStaticFile = "static"
+
+	// DefaultGuestTTL : Guests ACLs lifetime in seconds, used when none is configured
+	DefaultGuestTTL = 3600
+
+	// DefaultGuestRate : Guests created per second by IP, used when no guest rate limit is configured
+	DefaultGuestRate = 0.1
+
+	// DefaultGuestBurst : Guests created at once by IP, used when no guest rate limit is configured
+	DefaultGuestBurst = 5
+
+	// DefaultACLCleanupInterval : Seconds between expired ACLs removals, used when none is configured
+	DefaultACLCleanupInterval = 60
+
+	// DefaultSignatureTolerance : Maximum age in seconds of signed requests timestamps, used when none is configured
+	DefaultSignatureTolerance = 300
+
+	// DefaultRateLimitIPFactor : Factor of endpoint rules applied to the bucket of each IP, used when none is configured
+	DefaultRateLimitIPFactor = 10
+
+	// DefaultDigestWindow : Push notifications digest window in seconds, used when none is configured
+	DefaultDigestWindow = 60
+
+	// DefaultUsageFlushInterval : Seconds between usage counters flushes, used when none is configured
+	DefaultUsageFlushInterval = 60
+
+	// DefaultSystemPublisherClientID : MQTT client ID of the internal system messages publisher, used when none is configured
+	DefaultSystemPublisherClientID = "wave-system-publisher"
+
+	// DefaultReadinessTimeout : Milliseconds to wait for each dependency of readiness checks, used when none is configured
+	DefaultReadinessTimeout = 1000
+
+	// DefaultDependencyMonitorInterval : Seconds between pings of the dependencies monitor, used when none is configured
+	DefaultDependencyMonitorInterval = 15
+
+	// DefaultIdempotencyTTL : Seconds idempotent responses are replayed for, used when none is configured
+	DefaultIdempotencyTTL = 86400
+
+	// DefaultDebugAddress : Listening address of the debug server, used when none is configured
+	DefaultDebugAddress = "127.0.0.1:6060"
+
+	// DefaultMetricsAddress : Listening address of the metrics server, used when none is configured
+	DefaultMetricsAddress = ":9102"
+
+	// DefaultGRPCAddress : Listening address of the gRPC API of internal services, used when none is configured
+	DefaultGRPCAddress = ":9090"
+
+	// DefaultShutdownTimeout : Seconds given to in-flight requests and background tasks to complete on shutdown, used when none is configured
+	DefaultShutdownTimeout = 30
+
+	// DefaultAutocertCacheDir : Directory caching ACME certificates, used when none is configured
+	DefaultAutocertCacheDir = "/var/lib/wave/autocert"
+
+	// DefaultTLSMinVersion : Minimum TLS version of the management API, used when none is configured
+	DefaultTLSMinVersion = "1.2"
+
+	// DefaultServerReadHeaderTimeout : Milliseconds to read request headers, used when none is configured
+	DefaultServerReadHeaderTimeout = 5000
+
+	// DefaultServerReadTimeout : Milliseconds to read whole requests, used when none is configured
+	DefaultServerReadTimeout = 15000
+
+	// DefaultServerWriteTimeout : Milliseconds from the end of request headers to the end of the response, used when none is configured
+	DefaultServerWriteTimeout = 30000
+
+	// DefaultServerIdleTimeout : Milliseconds keep-alive connections are kept idle, used when none is configured
+	DefaultServerIdleTimeout = 60000
+
+	// DefaultServerMaxHeaderBytes : Maximum size of request headers, used when none is configured
+	DefaultServerMaxHeaderBytes = 1 << 20
+
+	// DefaultHandlerTimeout : Milliseconds handlers are given to handle a request, used when none is configured
+	DefaultHandlerTimeout = 20000
+
+	// DefaultMongoDBOperationTimeout : Milliseconds MongoDB operations are given, used when none is configured
+	DefaultMongoDBOperationTimeout = 5000
+
+	// DefaultPostgreSQLOperationTimeout : Milliseconds PostgreSQL operations are given, used when none is configured
+	DefaultPostgreSQLOperationTimeout = 5000
+
+	// DefaultRedisOperationTimeout : Milliseconds Redis operations are given, used when none is configured
+	DefaultRedisOperationTimeout = 1000
+
+	// DefaultMaxBodyBytes : Maximum size of request bodies, used when none is configured
+	DefaultMaxBodyBytes = 1 << 20
+
+	// DefaultCompressionMinSize : Minimum size in bytes of compressed responses, used when none is configured
+	DefaultCompressionMinSize = 1024
+
+	// ConfigReloadInterval : Time between checks of the config file for modifications, reloaded when modified
+	ConfigReloadInterval = 5 * time.Second
+)
+
+// Env : Execution environment containing Datastore communication interfaces (Redis, Store), AuthProvider, Broker, Notifier, Publisher, Logger & Config
+// TenantID is set on environments scoped to a tenant (See ForTenant), Logger holds fields of the request being handled (See WithLogFields).
+// RequestID is set on environments of a request, and forwarded to remote verifiers. Context holds the current span and request deadline, given to datastores operations (See TraceContext).
+// Caller is shared by all environments of a request, and set once its caller is authenticated (See IdentifyCaller).
+// Workers run background tasks, waited for on shutdown. Events streams real-time events of the instance to operators,
+// Producer produces lifecycle events for downstream services when enabled, Indexes tells whether Store indexes were created.
+// Configs holds the config last loaded, copied in environments of requests (See RefreshConfig)
 type Env struct {
-	MongoDB MongoDBInterface
-	Redis   RedisInterface
-	Config  Config
+	Store        Store
+	Redis        RedisInterface
+	AuthProvider AuthProviderInterface
+	Broker       BrokerInterface
+	Notifier     NotifierInterface
+	Publisher    PublisherInterface
+	Logger       *logrus.Entry
+	Config       Config
+	TenantID     string
+	RequestID    string
+	Context      context.Context
+	Caller       *AuditActor
+	Workers      *Workers
+	Events       *EventBus
+	Producer     ProducerInterface
+	Indexes      *IndexesStatus
+	Configs      *ConfigStore
+}
+
+// AuthProviderInterface : Authentication provider interface
+type AuthProviderInterface interface {
+
+	// CheckAuthentication : Return MQTT Auth Infos if provided auth token is valid for identityProvider (Default one if empty),
+	// an error if present, a boolean flag indicating if user was already cached and a boolean flag indicating wether token was updated.
+	// env is the environment of the request being handled
+	CheckAuthentication(env *Env, token string, identityProvider string) (*MQTTAuthInfos, bool, bool, error)
+
+	// InvalidateToken : Remove token from auth cache, so that it is verified again on next request
+	InvalidateToken(token string) error
 }
 
 // Config : Global Config
 type Config struct {
-	AuthenticationCheckEndpoint string `json:"authenticationCheckEndpoint"`
-	TokenValidationRegex        string `json:"tokenValidationRegex"`
+	AuthenticationMode          string                    `json:"authenticationMode"`
+	AuthenticationCheckEndpoint string                    `json:"authenticationCheckEndpoint"`
+	JWT                         JWTConfig                 `json:"jwt"`
+	Introspection               IntrospectionConfig       `json:"introspection"`
+	StaticTokensFile            string                    `json:"staticTokensFile"`
+	IdentityProviders           []*IdentityProviderConfig `json:"identityProviders"`
+	AuthCache                   AuthCacheConfig           `json:"authCache"`
+	CircuitBreaker              CircuitBreakerConfig      `json:"circuitBreaker"`
+	AuthHTTPClient              HTTPClientConfig          `json:"authHTTPClient"`
+	TokenValidationRegex        string                    `json:"tokenValidationRegex"`
+	TokenValidators             []*TokenValidatorConfig   `json:"tokenValidators"`
+	VerneMQAPIEndpoint          string                    `json:"verneMQAPIEndpoint"`
+	VerneMQAPIKey               string                    `json:"verneMQAPIKey"`
+	Guest                       GuestConfig               `json:"guest"`
+	ACLExpiry                   ACLExpiryConfig           `json:"aclExpiry"`
+	Passhash                    PasshashConfig            `json:"passhash"`
+	Topics                      TopicsConfig              `json:"topics"`
+	SystemPublisher             SystemPublisherConfig     `json:"systemPublisher"`
+	Quotas                      Quotas                    `json:"quotas"`
+	Usage                       UsageConfig               `json:"usage"`
+	Logging                     LoggingConfig             `json:"logging"`
+	Tracing                     TracingConfig             `json:"tracing"`
+	ErrorReporting              ErrorReportingConfig      `json:"errorReporting"`
+	AccessLog                   AccessLogConfig           `json:"accessLog"`
+	TLS                         TLSConfig                 `json:"tls"`
+	RequestSigning              RequestSigningConfig      `json:"requestSigning"`
+	RateLimit                   RateLimitConfig           `json:"rateLimit"`
+	Notifications               NotificationsConfig       `json:"notifications"`
+	Readiness                   ReadinessConfig           `json:"readiness"`
+	DependencyMonitor           DependencyMonitorConfig   `json:"dependencyMonitor"`
+	Debug                       DebugConfig               `json:"debug"`
+	Metrics                     MetricsConfig             `json:"metrics"`
+	Shutdown                    ShutdownConfig            `json:"shutdown"`
+	Server                      ServerConfig              `json:"server"`
+	Datastores                  DatastoresConfig          `json:"datastores"`
+	Indexes                     IndexesConfig             `json:"indexes"`
+	CORS                        CORSConfig                `json:"cors"`
+	Compression                 CompressionConfig         `json:"compression"`
+	API                         APIConfig                 `json:"api"`
+	OpenAPI                     OpenAPIConfig             `json:"openAPI"`
+	Idempotency                 IdempotencyConfig         `json:"idempotency"`
+	GRPC                        GRPCConfig                `json:"grpc"`
+	Events                      EventsConfig              `json:"events"`
+	LifecycleEvents             LifecycleConfig           `json:"lifecycleEvents"`
+	Webhooks                    WebhooksConfig            `json:"webhooks"`
+	Outbox                      OutboxConfig              `json:"outbox"`
+	ChangeStreams               ChangeStreamsConfig       `json:"changeStreams"`
+	Migrations                  MigrationsConfig          `json:"migrations"`
+	Tombstones                  TombstonesConfig          `json:"tombstones"`
+	tokenValidatorChain         []*TokenValidatorConfig
 }
 
-// RefreshConfig : Load current environment values in config
-func (env *Env) RefreshConfig() error {
+// IdentityProviderConfig : Additional identity provider Config, for upstream applications sharing the service
+// Selected by name (identityProvider HTTP header) or by TokenPrefix, which is stripped before verification.
+// Its user IDs are namespaced with its name ({name}:{userID}) so that applications users can't collide, its users belong to Tenant if set
+type IdentityProviderConfig struct {
+	Name                        string              `json:"name"`
+	Tenant                      string              `json:"tenant"`
+	TokenPrefix                 string              `json:"tokenPrefix"`
+	AuthenticationMode          string              `json:"authenticationMode"`
+	AuthenticationCheckEndpoint string              `json:"authenticationCheckEndpoint"`
+	JWT                         JWTConfig           `json:"jwt"`
+	Introspection               IntrospectionConfig `json:"introspection"`
+	StaticTokensFile            string              `json:"staticTokensFile"`
+}
+
+// DefaultIdentityProvider : Return identity provider described by top level authentication settings
+func (config *Config) DefaultIdentityProvider() *IdentityProviderConfig {
+	return &IdentityProviderConfig{
+		AuthenticationMode:          config.AuthenticationMode,
+		AuthenticationCheckEndpoint: config.AuthenticationCheckEndpoint,
+		JWT:                         config.JWT,
+		Introspection:               config.Introspection,
+		StaticTokensFile:            config.StaticTokensFile,
+	}
+}
+
+// TokenValidatorConfig : Token format validator Config, fields used depend on Type :
+// regex (Pattern), length (Min, Max), charset (Charset), expiry (Leeway) and issuer (Issuers)
+type TokenValidatorConfig struct {
+	Type    string   `json:"type"`
+	Pattern string   `json:"pattern,omitempty"`
+	Min     int      `json:"min,omitempty"`
+	Max     int      `json:"max,omitempty"`
+	Charset string   `json:"charset,omitempty"`
+	Leeway  int      `json:"leeway,omitempty"`
+	Issuers []string `json:"issuers,omitempty"`
+	pattern *regexp.Regexp
+}
+
+// JWTConfig : Local JWT validation Config
+// Secret is used by HMAC algorithms, JWKSURL or PublicKeyFile (PEM) by RSA & ECDSA ones. TenantClaim holds the tenant of the user, if any
+type JWTConfig struct {
+	Algorithm           string `json:"algorithm"`
+	Secret              string `json:"secret"`
+	PublicKeyFile       string `json:"publicKeyFile"`
+	JWKSURL             string `json:"jwksURL"`
+	JWKSRefreshInterval int    `json:"jwksRefreshInterval"`
+	Audience            string `json:"audience"`
+	Issuer              string `json:"issuer"`
+	UserIDClaim         string `json:"userIDClaim"`
+	TenantClaim         string `json:"tenantClaim"`
+}
+
+// IntrospectionConfig : OAuth2 token introspection Config
+// Service authenticates to the authorization server with ClientID and ClientSecret (HTTP Basic). TenantClaim holds the tenant of the user, if any
+type IntrospectionConfig struct {
+	Endpoint      string `json:"endpoint"`
+	ClientID      string `json:"clientID"`
+	ClientSecret  string `json:"clientSecret"`
+	RequiredScope string `json:"requiredScope"`
+	Audience      string `json:"audience"`
+	UserIDClaim   string `json:"userIDClaim"`
+	TenantClaim   string `json:"tenantClaim"`
+}
+
+// AuthCacheConfig : Verified tokens cache Config
+// Cached tokens are verified again after TTL seconds, a zero TTL keeps them until revoked
+type AuthCacheConfig struct {
+	TTL int `json:"ttl"`
+}
+
+// CircuitBreakerConfig : Remote verifier (Authentication endpoint, introspection) circuit breaker Config
+// Circuit opens after FailureThreshold consecutive failures and is tried again after OpenTimeout seconds.
+// Meanwhile, tokens verified within the last FallbackTTL seconds are accepted
+type CircuitBreakerConfig struct {
+	FailureThreshold int `json:"failureThreshold"`
+	OpenTimeout      int `json:"openTimeout"`
+	FallbackTTL      int `json:"fallbackTTL"`
+}
+
+// HTTPClientConfig : Remote verifiers (Authentication endpoint, introspection) HTTP client Config, durations in milliseconds
+// Network errors and server errors are retried MaxRetries times
+type HTTPClientConfig struct {
+	ConnectTimeout int `json:"connectTimeout"`
+	ReadTimeout    int `json:"readTimeout"`
+	MaxRetries     int `json:"maxRetries"`
+	RetryBaseDelay int `json:"retryBaseDelay"`
+	MaxIdleConns   int `json:"maxIdleConns"`
+}
+
+// GuestConfig : Guest access Config
+// When enabled, unauthenticated clients get subscribe-only ACLs on Topics, expiring after TTL seconds.
+// Guests creations are limited by IP with RateLimit, whether or not RateLimitConfig is enabled
+type GuestConfig struct {
+	Enabled   bool          `json:"enabled"`
+	Topics    []string      `json:"topics"`
+	TTL       int           `json:"ttl"`
+	RateLimit RateLimitRule `json:"rateLimit"`
+}
+
+// ACLExpiryConfig : Users VerneMQ ACLs expiry Config
+// When TTL (Seconds) is set, ACLs expire unless renewed by a successful authentication. Expired ACLs are removed every CleanupInterval seconds
+type ACLExpiryConfig struct {
+	TTL             int `json:"ttl"`
+	CleanupInterval int `json:"cleanupInterval"`
+}
+
+// PasshashConfig : VerneMQ ACL passhash Config, must match broker plugin configuration.
+// Algorithm is bcrypt (Cost), argon2id (Time, Memory in KiB, Threads) or pbkdf2 (Iterations, Hash), unset parameters use defaults
+type PasshashConfig struct {
+	Algorithm  string `json:"algorithm"`
+	Cost       int    `json:"cost,omitempty"`
+	Time       int    `json:"time,omitempty"`
+	Memory     int    `json:"memory,omitempty"`
+	Threads    int    `json:"threads,omitempty"`
+	Iterations int    `json:"iterations,omitempty"`
+	Hash       string `json:"hash,omitempty"`
+	SaltLength int    `json:"saltLength,omitempty"`
+	KeyLength  int    `json:"keyLength,omitempty"`
+}
+
+// SystemPublisherConfig : Internal MQTT publisher Config, used to publish system messages on system topics.
+// Publishing is disabled while BrokerURL (e.g. tcp://vernemq:1883) is empty. ClientID is read once at startup, when publisher ACL is provisioned
+type SystemPublisherConfig struct {
+	BrokerURL string `json:"brokerURL"`
+	ClientID  string `json:"clientID"`
+	QoS       int    `json:"qos"`
+}
+
+// UsageConfig : Usage reporting Config
+// Usage counters are kept in Redis and flushed to MongoDB every FlushInterval seconds
+type UsageConfig struct {
+	FlushInterval int `json:"flushInterval"`
+}
+
+// ReadinessConfig : Readiness checks Config
+// Each dependency must answer within Timeout milliseconds, external authentication endpoint is only checked if CheckAuthEndpoint.
+// Dependencies answering slower than DegradedLatency milliseconds are degraded, but ready (Never when 0)
+type ReadinessConfig struct {
+	Timeout           int  `json:"timeout"`
+	DegradedLatency   int  `json:"degradedLatency"`
+	CheckAuthEndpoint bool `json:"checkAuthEndpoint"`
+}
+
+// DependencyMonitorConfig : Dependencies monitor Config. The store and Redis are pinged every Interval seconds (Within the readiness timeout),
+// changes of their state being logged and exposed as metrics
+type DependencyMonitorConfig struct {
+	Interval int `json:"interval"`
+}
+
+// TimeoutDuration : Return how long to wait for each dependency
+func (config *ReadinessConfig) TimeoutDuration() time.Duration {
+
+	timeout := config.Timeout
+
+	if timeout <= 0 {
+		timeout = DefaultReadinessTimeout
+	}
+
+	return time.Duration(timeout) * time.Millisecond
+}
+
+// Degraded : Check if a dependency which answered after latency is degraded
+func (config *ReadinessConfig) Degraded(latency time.Duration) bool {
+	return config.DegradedLatency > 0 && latency > time.Duration(config.DegradedLatency)*time.Millisecond
+}
+
+// DebugConfig : Debug server Config (Profiling and runtime stats), read once at startup.
+// Served on Address, apart from the API port, only while Enabled
+type DebugConfig struct {
+	Enabled bool   `json:"enabled"`
+	Address string `json:"address"`
+}
+
+// MetricsConfig : Prometheus metrics server Config, read once at startup.
+// Served on Address, apart from the API port, so that metrics are only reachable from the networks Address is exposed to, only while Enabled
+type MetricsConfig struct {
+	Enabled bool   `json:"enabled"`
+	Address string `json:"address"`
+}
+
+// GRPCConfig : gRPC API of internal services Config, read once at startup.
+// Served on Address, apart from the HTTP API port, only while Enabled. It shares the TLS certificate files and client CA of the HTTP API
+type GRPCConfig struct {
+	Enabled bool   `json:"enabled"`
+	Address string `json:"address"`
+}
+
+// ServerConfig : Management API server timeouts (Milliseconds) and limits, read once at startup except handlers timeouts and body limits.
+// Handlers are given HandlerTimeout to handle a request, or their own timeout in HandlerTimeouts (By handler name).
+// Request bodies are limited to MaxBodyBytes, or the limit of their handler in HandlerMaxBodyBytes
+type ServerConfig struct {
+	ReadHeaderTimeout   int              `json:"readHeaderTimeout"`
+	ReadTimeout         int              `json:"readTimeout"`
+	WriteTimeout        int              `json:"writeTimeout"`
+	IdleTimeout         int              `json:"idleTimeout"`
+	MaxHeaderBytes      int              `json:"maxHeaderBytes"`
+	HandlerTimeout      int              `json:"handlerTimeout"`
+	HandlerTimeouts     map[string]int   `json:"handlerTimeouts"`
+	MaxBodyBytes        int64            `json:"maxBodyBytes"`
+	HandlerMaxBodyBytes map[string]int64 `json:"handlerMaxBodyBytes"`
+}
+
+// HandlerTimeoutOf : Return time given to handler to handle a request
+func (config *ServerConfig) HandlerTimeoutOf(handler string) time.Duration {
+
+	timeout, ok := config.HandlerTimeouts[handler]
+
+	if !ok || timeout <= 0 {
+		timeout = config.HandlerTimeout
+	}
+
+	if timeout <= 0 {
+		timeout = DefaultHandlerTimeout
+	}
+
+	return time.Duration(timeout) * time.Millisecond
+}
+
+// DatastoresConfig : Store backend (mongodb or postgresql) and Redis Cluster topology, read at startup, datastores operations timeouts and MongoDB retries, read on every operation
+type DatastoresConfig struct {
+	Backend    string           `json:"backend"`
+	MongoDB    MongoDBConfig    `json:"mongoDB"`
+	PostgreSQL PostgreSQLConfig `json:"postgreSQL"`
+	Redis      RedisConfig      `json:"redis"`
+}
+
+// StoreBackend : Return backend of the store, MongoDB when none is configured
+func (config *DatastoresConfig) StoreBackend() string {
+
+	if config.Backend == "" {
+		return StoreBackendMongoDB
+	}
+
+	return config.Backend
+}
+
+// DatastoreConfig : Operations of a datastore are given OperationTimeout (Milliseconds), or their own timeout in OperationTimeouts (By operation name).
+// Operations of a request end with it too, whichever comes first
+type DatastoreConfig struct {
+	OperationTimeout  int            `json:"operationTimeout"`
+	OperationTimeouts map[string]int `json:"operationTimeouts"`
+}
+
+// OperationTimeoutOf : Return time given to operation, defaultTimeout milliseconds when none is configured
+func (config *DatastoreConfig) OperationTimeoutOf(operation string, defaultTimeout int) time.Duration {
+
+	timeout, ok := config.OperationTimeouts[operation]
+
+	if !ok || timeout <= 0 {
+		timeout = config.OperationTimeout
+	}
+
+	if timeout <= 0 {
+		timeout = defaultTimeout
+	}
+
+	return time.Duration(timeout) * time.Millisecond
+}
+
+// CORSConfig : Cross-origin requests Config of browser-based clients, read once at startup. Unset fields use defaults (See router).
+// Credentials (Cookies) are not needed as tokens are sent in headers, AllowCredentials is refused with a wildcard origin
+type CORSConfig struct {
+	AllowedOrigins   []string `json:"allowedOrigins"`
+	AllowedHeaders   []string `json:"allowedHeaders"`
+	AllowedMethods   []string `json:"allowedMethods"`
+	ExposedHeaders   []string `json:"exposedHeaders"`
+	AllowCredentials bool     `json:"allowCredentials"`
+	MaxAge           int      `json:"maxAge"`
+}
+
+// CompressionConfig : Responses compression Config, read once at startup.
+// While Enabled, responses of at least MinSize bytes are compressed at Level (1 fastest to 9 smallest, default compression if unset)
+type CompressionConfig struct {
+	Enabled bool `json:"enabled"`
+	MinSize int  `json:"minSize"`
+	Level   int  `json:"level"`
+}
+
+// APIConfig : API versions Config, read once at startup.
+// Unversioned LegacyPaths are aliases of the oldest version, always deprecated as set in Legacy.
+// Versions are deprecated by name (e.g. "v1") in Deprecations
+type APIConfig struct {
+	LegacyPaths  bool                          `json:"legacyPaths"`
+	Legacy       DeprecationConfig             `json:"legacy"`
+	Deprecations map[string]*DeprecationConfig `json:"deprecations"`
+}
+
+// OpenAPIConfig : OpenAPI document Config, read once at startup. While Enabled, the document is served along with a Swagger UI if SwaggerUI is set.
+// ServerURL is the public URL of the API advertised to generated clients
+type OpenAPIConfig struct {
+	Enabled   bool   `json:"enabled"`
+	SwaggerUI bool   `json:"swaggerUI"`
+	ServerURL string `json:"serverURL"`
+}
+
+// IdempotencyConfig : Idempotency-Key Config
+// Responses of requests holding an Idempotency-Key are replayed to retries for TTL seconds
+type IdempotencyConfig struct {
+	TTL int `json:"ttl"`
+}
+
+// DeprecationConfig : Deprecation of API paths, announced to clients with Deprecation (Since), Sunset and Link headers.
+// Since and Sunset are RFC 3339 dates, Link points to migration documentation
+type DeprecationConfig struct {
+	Since  string `json:"since"`
+	Sunset string `json:"sunset"`
+	Link   string `json:"link"`
+}
+
+// MaxBodyBytesOf : Return maximum size of request bodies of handler
+func (config *ServerConfig) MaxBodyBytesOf(handler string) int64 {
+
+	maxBodyBytes, ok := config.HandlerMaxBodyBytes[handler]
+
+	if !ok || maxBodyBytes <= 0 {
+		maxBodyBytes = config.MaxBodyBytes
+	}
+
+	if maxBodyBytes <= 0 {
+		maxBodyBytes = DefaultMaxBodyBytes
+	}
+
+	return maxBodyBytes
+}
+
+// ShutdownConfig : Graceful shutdown Config
+// On SIGTERM, in-flight requests and background tasks are given Timeout seconds to complete before clients are closed
+type ShutdownConfig struct {
+	Timeout int `json:"timeout"`
+}
+
+// TLSConfig : Management API TLS Config, read once at startup.
+// When ClientCAFile is set, client certificates signed by it authenticate internal services (Mutual TLS)
+type TLSConfig struct {
+	CertFile          string             `json:"certFile"`
+	KeyFile           string             `json:"keyFile"`
+	Autocert          AutocertConfig     `json:"autocert"`
+	MinVersion        string             `json:"minVersion"`
+	CipherSuites      []string           `json:"cipherSuites"`
+	ClientCAFile      string             `json:"clientCAFile"`
+	RequireClientCert bool               `json:"requireClientCert"`
+	ServiceIdentities []*ServiceIdentity `json:"serviceIdentities"`
+}
+
+// AutocertConfig : Server certificates obtained from an ACME CA (Let's Encrypt by default) for Domains, when no certificate file is set.
+// Certificates are cached in CacheDir, HTTP-01 challenges are answered on HTTPAddress when set (TLS-ALPN-01 ones on the API port)
+type AutocertConfig struct {
+	Domains      []string `json:"domains"`
+	Email        string   `json:"email"`
+	CacheDir     string   `json:"cacheDir"`
+	DirectoryURL string   `json:"directoryURL"`
+	HTTPAddress  string   `json:"httpAddress"`
+}
+
+// Enabled : Check if the management API is served over TLS, with certificate files or ACME certificates
+func (config *TLSConfig) Enabled() bool {
+	return config.CertFile != "" || len(config.Autocert.Domains) > 0
+}
+
+// ServiceIdentity : Internal service authenticated by a client certificate
+// Subject matches either the certificate common name or its full distinguished name. Services of a tenant only access its data
+type ServiceIdentity struct {
+	Subject     string   `json:"subject"`
+	ServiceName string   `json:"serviceName"`
+	TenantID    string   `json:"tenantID"`
+	Scopes      []string `json:"scopes"`
+}
+
+// HasScope : Check if service identity was granted scope
+func (identity *ServiceIdentity) HasScope(scope string) bool {
+
+	for _, granted := range identity.Scopes {
+		if granted == scope {
+			return true
+		}
+	}
+
+	return false
+}
+
+// RequestSigningConfig : HMAC-SHA256 request signing Config of webhooks and internal services endpoints
+// Requests are refused while Secret is empty, unless signature checks are explicitly Disabled (e.g. behind a trusted network)
+type RequestSigningConfig struct {
+	Secret    string `json:"secret"`
+	Tolerance int    `json:"tolerance"`
+	Disabled  bool   `json:"disabled"`
+}
+
+// RateLimitConfig : Token bucket rate limiting Config, buckets are kept per endpoint and per token
+// Endpoints rules are keyed by method and route template (e.g. "POST /v1/profiles/mappings") and take precedence over Default.
+// Every IP gets a bucket of IPFactor times the rule too, whatever the credentials of its requests, unless DisableIPBuckets is set.
+// Clients of TrustedProxies (IPs or CIDRs, e.g. load balancers) are identified by the X-Forwarded-For or X-Real-IP header
+type RateLimitConfig struct {
+	Enabled          bool                      `json:"enabled"`
+	Default          RateLimitRule             `json:"default"`
+	Endpoints        map[string]*RateLimitRule `json:"endpoints"`
+	IPFactor         float64                   `json:"ipFactor"`
+	DisableIPBuckets bool                      `json:"disableIPBuckets"`
+	TrustedProxies   []string                  `json:"trustedProxies"`
+	trustedProxies   []*net.IPNet
+}
+
+// RateLimitRule : Bucket refilled by Rate requests per second, holding up to Burst requests
+// A zero Rate disables rate limiting
+type RateLimitRule struct {
+	Rate  float64 `json:"rate"`
+	Burst int     `json:"burst"`
+}
+
+// NotificationsConfig : Push notifications Config
+type NotificationsConfig struct {
+	FCM       FCMConfig                   `json:"fcm"`
+	APNs      APNsConfig                  `json:"apns"`
+	WebPush   WebPushConfig               `json:"webPush"`
+	Digest    DigestConfig                `json:"digest"`
+	Templates NotificationTemplatesConfig `json:"templates"`
+	Queue     NotificationQueueConfig     `json:"queue"`
+}
+
+// FCMConfig : Firebase Cloud Messaging Config
+type FCMConfig struct {
+	Endpoint  string `json:"endpoint"`
+	ServerKey string `json:"serverKey"`
+}
+
+// APNsConfig : Apple Push Notification service Config (Token-based authentication)
+type APNsConfig struct {
+	Endpoint   string `json:"endpoint"`
+	KeyFile    string `json:"keyFile"`
+	KeyID      string `json:"keyID"`
+	TeamID     string `json:"teamID"`
+	BundleID   string `json:"bundleID"`
+	SilentPush bool   `json:"silentPush"`
+}
+
+// WebPushConfig : Web Push Config (VAPID private key is base64 URL encoded, public key is derived from it)
+type WebPushConfig struct {
+	Subject    string `json:"subject"`
+	PrivateKey string `json:"privateKey"`
+	TTL        int    `json:"ttl"`
+}
+
+// DigestConfig : Push notifications digest mode Config
+// Offline messages of a conversation received within Window seconds are summarized in a single push
+type DigestConfig struct {
+	Enabled bool `json:"enabled"`
+	Window  int  `json:"window"`
+}
+
+// NotificationQueueConfig : RabbitMQ queue of push notification jobs Config, read at startup.
+// When URL is set, API instances queue jobs for push dispatchers (-push-dispatcher) instead of sending notifications themselves
+type NotificationQueueConfig struct {
+	URL      string `json:"url"`
+	Queue    string `json:"queue"`
+	Prefetch int    `json:"prefetch"`
+}
+
+// NotificationTemplatesConfig : Push notifications payload templates (text/template syntax)
+type NotificationTemplatesConfig struct {
+	PrivateTitle      string `json:"privateTitle"`
+	PrivateBody       string `json:"privateBody"`
+	PrivateDigestBody string `json:"privateDigestBody"`
+	GroupTitle        string `json:"groupTitle"`
+	GroupBody         string `json:"groupBody"`
+	GroupDigestBody   string `json:"groupDigestBody"`
+}
+
+// ConfigStore : Config last loaded from the config file. Published configs are never modified, so that environments of requests
+// copy a consistent config while it is reloaded (See ForRequest)
+type ConfigStore struct {
+	current atomic.Value
+}
+
+// Load : Return config last published, nil before the config file was first loaded
+func (store *ConfigStore) Load() *Config {
+
+	if store == nil {
+		return nil
+	}
+
+	config, _ := store.current.Load().(*Config)
+
+	return config
+}
+
+// CurrentConfig : Return config last loaded, config of env when none was published
+func (env *Env) CurrentConfig() *Config {
+
+	if config := env.Configs.Load(); config != nil {
+		return config
+	}
+
+	return &env.Config
+}
+
+// ReadConfig : Return a fresh config read from the config file
+func ReadConfig() (*Config, error) {
 
 	data, err := ioutil.ReadFile(configFilePath)
 
+	if err != nil {
+		return nil, err
+	}
+
+	config := &Config{}
+
+	err = json.Unmarshal(data, config)
+
+	if err != nil {
+		return nil, err
+	}
+
+	err = config.compile()
+
+	if err != nil {
+		return nil, err
+	}
+
+	return config, nil
+}
+
+// compile : Parse settings read by every request once per loaded config, requests copying the parsed ones along with the config (See ForRequest).
+// Invalid settings fail the load, so that a reload keeps the previous config
+func (config *Config) compile() error {
+
+	err := config.compileTokenValidators()
+
 	if err != nil {
 		return err
 	}
 
-	err = json.Unmarshal(data, &env.Config)
+	return config.RateLimit.compile()
+}
+
+// LoadConfig : Load config file in config of env and publish it (See RefreshConfig).
+// Called at startup only, before config of env is read concurrently
+func (env *Env) LoadConfig() error {
+
+	config, err := ReadConfig()
+
+	if err != nil {
+		return err
+	}
+
+	env.Config = *config
+
+	if env.Configs == nil {
+		env.Configs = &ConfigStore{}
+	}
+
+	env.Configs.current.Store(config)
+
+	return nil
+}
+
+// RefreshConfig : Load config file in a fresh config, published for environments of requests started afterwards (See ForRequest).
+// Config of env is left untouched, as it may be read concurrently
+func (env *Env) RefreshConfig() error {
+
+	config, err := ReadConfig()
 
 	if err != nil {
 		return err
 	}
 
+	if env.Configs == nil {
+		return errors.New("Config store is not initialized")
+	}
+
+	env.Configs.current.Store(config)
+
 	return nil
 }
+
+// ReloadConfig : Refresh config in background on each SIGHUP, and whenever the config file is modified (Checked every ConfigReloadInterval)
+func (env *Env) ReloadConfig() {
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGHUP)
+
+	modified := configModTime()
+
+	env.Workers.Go(func() {
+
+		for {
+
+			select {
+
+			case <-env.Workers.Stopping():
+				signal.Stop(signals)
+				return
+
+			case <-signals:
+
+			case <-time.After(ConfigReloadInterval):
+
+				current := configModTime()
+
+				if current.Equal(modified) {
+					continue
+				}
+
+				modified = current
+			}
+
+			err := env.RefreshConfig()
+
+			if err != nil {
+				env.Logger.WithError(err).Error("Failed to reload config")
+				continue
+			}
+
+			env.Logger.Info("Config reloaded")
+		}
+	})
+}
+
+// configModTime : Return modification time of the config file, zero when it can't be read
+func configModTime() time.Time {
+
+	info, err := os.Stat(configFilePath)
+
+	if err != nil {
+		return time.Time{}
+	}
+
+	return info.ModTime()
+}
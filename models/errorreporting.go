@@ -0,0 +1,144 @@
+package models
+
+import (
+	errors "errors"
+	fmt "fmt"
+	regexp "regexp"
+	debug "runtime/debug"
+	time "time"
+
+	sentry "github.com/getsentry/sentry-go"
+	logrus "github.com/sirupsen/logrus"
+)
+
+const (
+	// errorReportingFlushTimeout : Time given to pending reports to be sent on shutdown
+	errorReportingFlushTimeout = 2 * time.Second
+)
+
+var (
+	// errorReportingTags : Log fields reported as tags, so that reports can be searched by request, handler, tenant or user
+	errorReportingTags = []string{LogFieldRequestID, LogFieldHandler, LogFieldTenantID, LogFieldUserID}
+
+	// reportedSecrets : Bearer tokens held by Redis errors, replaced before reporting : Keys named after tokens (Auth cache and verified users),
+	// and values being set (Mappings hold the token of their user)
+	reportedSecrets = []struct {
+		pattern     *regexp.Regexp
+		replacement string
+	}{
+		{pattern: regexp.MustCompile(`\b(session|verified):[^\s\]]+`), replacement: "$1:[REDACTED]"},
+		{pattern: regexp.MustCompile(`(error setting key \S+ to )\S+`), replacement: "${1}[REDACTED]"},
+	}
+)
+
+// ErrorReportingConfig : Sentry-compatible error reporting Config, read once at startup.
+// Errors are reported to DSN while set, SampleRate of them are kept (All of them when unset)
+type ErrorReportingConfig struct {
+	DSN         string  `json:"dsn"`
+	Environment string  `json:"environment"`
+	SampleRate  float64 `json:"sampleRate"`
+}
+
+// StartErrorReporting : Report entries logged at error level and above by logger, recovered panics included (See LogPanic), to configured DSN.
+// Returned function flushes pending reports
+func StartErrorReporting(logger *logrus.Entry, config ErrorReportingConfig) (func(), error) {
+
+	if config.DSN == "" {
+		return func() {}, nil
+	}
+
+	err := sentry.Init(sentry.ClientOptions{
+		Dsn:              config.DSN,
+		Environment:      config.Environment,
+		SampleRate:       config.SampleRate,
+		AttachStacktrace: true,
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	logger.Logger.AddHook(&errorReportingHook{})
+
+	return func() {
+		sentry.Flush(errorReportingFlushTimeout)
+	}, nil
+}
+
+// errorReportingHook : Logrus hook reporting error entries with their fields
+type errorReportingHook struct{}
+
+// Levels : Levels of reported entries
+func (hook *errorReportingHook) Levels() []logrus.Level {
+	return []logrus.Level{logrus.ErrorLevel, logrus.FatalLevel, logrus.PanicLevel}
+}
+
+// Fire : Report entry, its error being the reported exception when set. Stack trace is the one of the logging call
+func (hook *errorReportingHook) Fire(entry *logrus.Entry) error {
+
+	hub := sentry.CurrentHub().Clone()
+
+	hub.WithScope(func(scope *sentry.Scope) {
+
+		scope.SetLevel(sentry.LevelError)
+
+		if entry.Level != logrus.ErrorLevel {
+			scope.SetLevel(sentry.LevelFatal)
+		}
+
+		for _, tag := range errorReportingTags {
+			if value, ok := entry.Data[tag]; ok {
+				scope.SetTag(tag, scrubSecrets(fmt.Sprint(value)))
+			}
+		}
+
+		for key, value := range entry.Data {
+
+			if key == logrus.ErrorKey {
+				continue
+			}
+
+			if text, ok := value.(string); ok {
+				value = scrubSecrets(text)
+			}
+
+			scope.SetExtra(key, value)
+		}
+
+		if err, ok := entry.Data[logrus.ErrorKey].(error); ok {
+			hub.CaptureException(errors.New(scrubSecrets(fmt.Sprintf("%s : %v", entry.Message, err))))
+			return
+		}
+
+		hub.CaptureMessage(scrubSecrets(entry.Message))
+	})
+
+	return nil
+}
+
+// scrubSecrets : Return text of a report without the bearer tokens it may hold (See reportedSecrets), as reports are sent to a third party
+func scrubSecrets(text string) string {
+
+	for _, secret := range reportedSecrets {
+		text = secret.pattern.ReplaceAllString(text, secret.replacement)
+	}
+
+	return text
+}
+
+// LogPanic : Log panic recovered from a handler or background worker with its stack, so that it is reported
+func (env *Env) LogPanic(recovered interface{}) {
+	env.Logger.WithFields(logrus.Fields{"panic": fmt.Sprint(recovered), "stack": string(debug.Stack())}).Error("Recovered from panic")
+}
+
+// Guard : Run task of a background worker, logging its panic instead of crashing the process, so that the worker keeps running
+func (env *Env) Guard(task func()) {
+
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			env.LogPanic(recovered)
+		}
+	}()
+
+	task()
+}
@@ -0,0 +1,185 @@
+package models
+
+import (
+	sync "sync"
+	time "time"
+)
+
+const (
+	// EventClientConnect : MQTT client registered on the broker (VerneMQ on_register hook)
+	EventClientConnect = "client.connect"
+
+	// EventClientDisconnect : MQTT client gone from the broker (VerneMQ on_client_offline and on_client_gone hooks)
+	EventClientDisconnect = "client.disconnect"
+
+	// DefaultEventsHistorySize : Number of recent events kept for reconnecting subscribers, used when none is configured
+	DefaultEventsHistorySize = 1000
+
+	// DefaultEventsHeartbeatInterval : Seconds between heartbeats of idle event streams, used when none is configured
+	DefaultEventsHeartbeatInterval = 15
+
+	// eventSubscriptionBuffer : Events buffered per subscriber, further events are dropped until it catches up
+	eventSubscriptionBuffer = 256
+)
+
+// EventsConfig : Real-time events Config. HistorySize recent events are kept so that reconnecting streams catch up (See EventBus)
+type EventsConfig struct {
+	HistorySize       int `json:"historySize"`
+	HeartbeatInterval int `json:"heartbeatInterval"`
+}
+
+// ClientRegistration : MQTT client registered on the broker (VerneMQ on_register hook)
+type ClientRegistration struct {
+	PeerAddr   string `json:"peer_addr"`
+	PeerPort   int    `json:"peer_port"`
+	Mountpoint string `json:"mountpoint"`
+	ClientID   string `json:"client_id"`
+	Username   string `json:"username"`
+}
+
+// ClientDeparture : MQTT client gone offline or whose session was removed (VerneMQ on_client_offline and on_client_gone hooks)
+type ClientDeparture struct {
+	Mountpoint string `json:"mountpoint"`
+	ClientID   string `json:"client_id"`
+}
+
+// Event : Real-time event of the service instance, streamed to operators. Audited actions are events whose Type is their action.
+// ID increases with each event of the instance, Actor is left out for broker events
+type Event struct {
+	ID        int64             `json:"id"`
+	Type      string            `json:"type"`
+	Actor     *AuditActor       `json:"actor,omitempty"`
+	Target    string            `json:"target"`
+	TenantID  string            `json:"tenantID,omitempty"`
+	Details   map[string]string `json:"details,omitempty"`
+	Timestamp time.Time         `json:"timestamp"`
+}
+
+// EventBus : In-process publish/subscribe of the events of the instance. Publishing never blocks :
+// events are dropped for subscribers whose buffer is full, which are told so by Overflowed
+type EventBus struct {
+	mutex       sync.Mutex
+	lastID      int64
+	history     []*Event
+	historySize int
+	subscribers map[*EventSubscription]bool
+}
+
+// EventSubscription : Events of a tenant (All tenants when empty) received by a subscriber, until closed
+type EventSubscription struct {
+	Events   chan *Event
+	TenantID string
+	overflow int64
+	bus      *EventBus
+}
+
+// NewEventBus : Return event bus keeping historySize recent events (DefaultEventsHistorySize if not set)
+func NewEventBus(historySize int) *EventBus {
+
+	if historySize <= 0 {
+		historySize = DefaultEventsHistorySize
+	}
+
+	return &EventBus{
+		historySize: historySize,
+		subscribers: map[*EventSubscription]bool{},
+	}
+}
+
+// Publish : Number and timestamp event, then hand it to subscribers of its tenant. Nil buses drop events
+func (bus *EventBus) Publish(event *Event) {
+
+	if bus == nil {
+		return
+	}
+
+	bus.mutex.Lock()
+	defer bus.mutex.Unlock()
+
+	bus.lastID++
+	event.ID = bus.lastID
+
+	if event.Timestamp.IsZero() {
+		event.Timestamp = time.Now().UTC()
+	}
+
+	bus.history = append(bus.history, event)
+
+	if len(bus.history) > bus.historySize {
+		bus.history = bus.history[len(bus.history)-bus.historySize:]
+	}
+
+	for subscription := range bus.subscribers {
+
+		if !subscription.receives(event) {
+			continue
+		}
+
+		select {
+		case subscription.Events <- event:
+		default:
+			if subscription.overflow == 0 {
+				subscription.overflow = event.ID
+			}
+		}
+	}
+}
+
+// Subscribe : Return subscription to events of tenantID (All tenants when empty), and kept events published after lastID.
+// gap is true when events following lastID were already evicted from history, or lost with a restart of the instance
+func (bus *EventBus) Subscribe(tenantID string, lastID int64) (subscription *EventSubscription, missed []*Event, gap bool) {
+
+	subscription = &EventSubscription{
+		Events:   make(chan *Event, eventSubscriptionBuffer),
+		TenantID: tenantID,
+		bus:      bus,
+	}
+
+	bus.mutex.Lock()
+	defer bus.mutex.Unlock()
+
+	bus.subscribers[subscription] = true
+
+	if lastID <= 0 {
+		return subscription, nil, false
+	}
+
+	// IDs restart with the instance, a greater ID was published by a previous run
+	if lastID >= bus.lastID {
+		return subscription, nil, lastID > bus.lastID
+	}
+
+	gap = len(bus.history) == 0 || bus.history[0].ID > lastID+1
+
+	for _, event := range bus.history {
+
+		if event.ID > lastID && subscription.receives(event) {
+			missed = append(missed, event)
+		}
+	}
+
+	return subscription, missed, gap
+}
+
+// Overflowed : Return ID of the first event dropped as the subscriber did not keep up, 0 if none was
+func (subscription *EventSubscription) Overflowed() int64 {
+
+	subscription.bus.mutex.Lock()
+	defer subscription.bus.mutex.Unlock()
+
+	return subscription.overflow
+}
+
+// Close : Stop receiving events
+func (subscription *EventSubscription) Close() {
+
+	subscription.bus.mutex.Lock()
+	defer subscription.bus.mutex.Unlock()
+
+	delete(subscription.bus.subscribers, subscription)
+}
+
+// receives : Check event belongs to the tenant of subscription
+func (subscription *EventSubscription) receives(event *Event) bool {
+	return subscription.TenantID == "" || subscription.TenantID == event.TenantID
+}
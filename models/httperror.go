@@ -0,0 +1,76 @@
+package models
+
+import (
+	fmt "fmt"
+)
+
+const (
+	// CodeInvalidRequest : Request body, parameters or headers are malformed or invalid
+	CodeInvalidRequest = "INVALID_REQUEST"
+
+	// CodeInvalidToken : User token is missing, invalid or revoked
+	CodeInvalidToken = "INVALID_TOKEN"
+
+	// CodeInvalidCredentials : Service API key, client certificate or request signature is missing or invalid
+	CodeInvalidCredentials = "INVALID_CREDENTIALS"
+
+	// CodeForbidden : Caller is authenticated but not allowed to perform the request
+	CodeForbidden = "FORBIDDEN"
+
+	// CodeNotFound : Requested resource doesn't exist
+	CodeNotFound = "NOT_FOUND"
+
+	// CodeAlreadyExists : Resource to create already exists
+	CodeAlreadyExists = "ALREADY_EXISTS"
+
+	// CodeQuotaExceeded : Request would take a user over one of its quotas
+	CodeQuotaExceeded = "QUOTA_EXCEEDED"
+
+	// CodeNotAcceptable : None of the media types of the endpoint responses is accepted by the request Accept header
+	CodeNotAcceptable = "NOT_ACCEPTABLE"
+
+	// CodeUnsupportedMediaType : Content-Type of request body is not supported by the endpoint
+	CodeUnsupportedMediaType = "UNSUPPORTED_MEDIA_TYPE"
+
+	// CodeRequestTooLarge : Request body exceeds the limit of its handler
+	CodeRequestTooLarge = "REQUEST_TOO_LARGE"
+
+	// CodeRateLimited : Request exceeds the rate limit of its endpoint
+	CodeRateLimited = "RATE_LIMITED"
+
+	// CodeIdempotencyKeyReused : Idempotency-Key was already used by a different request
+	CodeIdempotencyKeyReused = "IDEMPOTENCY_KEY_REUSED"
+
+	// CodeIdempotencyInProgress : Request with the same Idempotency-Key is still in progress
+	CodeIdempotencyInProgress = "IDEMPOTENCY_IN_PROGRESS"
+
+	// CodeTimeout : Request was not handled within its handler timeout
+	CodeTimeout = "TIMEOUT"
+
+	// CodeInternalError : Request failed because of the service or one of its dependencies
+	CodeInternalError = "INTERNAL_ERROR"
+)
+
+// HTTPError : Error of a request, answered with Status and the error envelope (Code, Message and Details)
+type HTTPError struct {
+	Status  int
+	Code    string
+	Message string
+	Details map[string]interface{}
+}
+
+// NewHTTPError : Return error answered with status, code and message
+func NewHTTPError(status int, code string, message string) *HTTPError {
+	return &HTTPError{Status: status, Code: code, Message: message}
+}
+
+// WithDetails : Set machine-readable details of error and return it
+func (err *HTTPError) WithDetails(details map[string]interface{}) *HTTPError {
+	err.Details = details
+	return err
+}
+
+// Error : Describe error with its code
+func (err *HTTPError) Error() string {
+	return fmt.Sprintf("%s: %s", err.Code, err.Message)
+}
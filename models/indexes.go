@@ -0,0 +1,135 @@
+package models
+
+import (
+	errors "errors"
+	sync "sync"
+
+	bson "go.mongodb.org/mongo-driver/bson"
+	mongo "go.mongodb.org/mongo-driver/mongo"
+	options "go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	// DefaultIndexesRetryInterval : Seconds between two attempts to create indexes while creation fails, used when none is configured
+	DefaultIndexesRetryInterval = 60
+
+	// maxExpireAfterSeconds : Largest expireAfterSeconds of MongoDB TTL indexes, set to suspend expiry (Legal hold)
+	maxExpireAfterSeconds = 2147483647
+
+	// namespaceNotFoundCode : Collection does not exist
+	namespaceNotFoundCode = 26
+
+	// indexNotFoundCode : Index does not exist
+	indexNotFoundCode = 27
+)
+
+var (
+	// ErrIndexesPending : Indexes creation has not completed yet
+	ErrIndexesPending = errors.New("Indexes creation pending")
+
+	// sharedIndexes : Indexes of collections shared by tenants, by collection name
+	sharedIndexes = map[string][]mongo.IndexModel{
+		VerneMQACLCollection: {
+			{Keys: bson.D{bson.E{Key: "client_id", Value: 1}}, Options: options.Index().SetUnique(true)},
+			{Keys: bson.D{bson.E{Key: "username", Value: 1}}},
+			{Keys: bson.D{bson.E{Key: "expires_at", Value: 1}}, Options: options.Index().SetSparse(true)},
+		},
+		DeletedACLsCollection: {
+			{Keys: bson.D{bson.E{Key: "client_id", Value: 1}}, Options: options.Index().SetUnique(true)},
+			{Keys: bson.D{bson.E{Key: "username", Value: 1}}},
+		},
+		APIKeysCollection: {
+			{Keys: bson.D{bson.E{Key: "hashedKey", Value: 1}}, Options: options.Index().SetUnique(true)},
+		},
+		UsageCollection: {
+			{Keys: bson.D{bson.E{Key: "date", Value: 1}, bson.E{Key: "tenantID", Value: 1}, bson.E{Key: "userID", Value: 1}}, Options: options.Index().SetUnique(true)},
+		},
+		AuditLogCollection: {
+			{Keys: bson.D{bson.E{Key: "timestamp", Value: -1}}},
+			{Keys: bson.D{bson.E{Key: "actor.id", Value: 1}, bson.E{Key: "timestamp", Value: -1}}},
+			{Keys: bson.D{bson.E{Key: "target", Value: 1}, bson.E{Key: "timestamp", Value: -1}}},
+		},
+		WebhookDeliveriesCollection: {
+			{Keys: bson.D{bson.E{Key: "id", Value: 1}}, Options: options.Index().SetUnique(true)},
+			{Keys: bson.D{bson.E{Key: "webhookID", Value: 1}, bson.E{Key: "createdAt", Value: -1}}},
+			{Keys: bson.D{bson.E{Key: "status", Value: 1}, bson.E{Key: "nextAttemptAt", Value: 1}}},
+		},
+		WebhookDeadLettersCollection: {
+			{Keys: bson.D{bson.E{Key: "id", Value: 1}}, Options: options.Index().SetUnique(true)},
+			{Keys: bson.D{bson.E{Key: "tenantID", Value: 1}, bson.E{Key: "deadLetteredAt", Value: -1}}},
+			{Keys: bson.D{bson.E{Key: "webhookID", Value: 1}}},
+		},
+		OutboxCollection: {
+			{Keys: bson.D{bson.E{Key: "id", Value: 1}}, Options: options.Index().SetUnique(true)},
+			{Keys: bson.D{bson.E{Key: "leaseUntil", Value: 1}, bson.E{Key: "createdAt", Value: 1}}},
+		},
+	}
+
+	// ttlIndexes : Fields of the TTL indexes expiring tombstones, by collection name (See ExpireTombstones). They are not created with other indexes,
+	// as expireAfterSeconds follows the tombstones Config
+	ttlIndexes = map[string]string{
+		DeletedACLsCollection:       "deleted_at",
+		GroupConversationCollection: "deletedAt",
+	}
+
+	// tenantIndexes : Indexes of tenant collections, by collection name. They apply to the collections of every tenant ({tenantID}_{collection})
+	tenantIndexes = map[string][]mongo.IndexModel{
+		GroupConversationCollection: {
+			{Keys: bson.D{bson.E{Key: "groupConversationID", Value: 1}}, Options: options.Index().SetUnique(true)},
+			{Keys: bson.D{bson.E{Key: "members", Value: 1}, bson.E{Key: "name", Value: 1}, bson.E{Key: "groupConversationID", Value: 1}}},
+			{Keys: bson.D{bson.E{Key: "creatorID", Value: 1}}, Options: options.Index().SetSparse(true)},
+		},
+		PushTokensCollection: {
+			{Keys: bson.D{bson.E{Key: "token", Value: 1}}, Options: options.Index().SetUnique(true)},
+			{Keys: bson.D{bson.E{Key: "userID", Value: 1}}},
+		},
+		NotificationPreferencesCollection: {
+			{Keys: bson.D{bson.E{Key: "userID", Value: 1}}, Options: options.Index().SetUnique(true)},
+		},
+		QuotaOverridesCollection: {
+			{Keys: bson.D{bson.E{Key: "userID", Value: 1}}, Options: options.Index().SetUnique(true)},
+		},
+		WebhooksCollection: {
+			{Keys: bson.D{bson.E{Key: "id", Value: 1}}, Options: options.Index().SetUnique(true)},
+		},
+	}
+)
+
+// IndexesConfig : MongoDB indexes Config. Indexes are created at startup, then every RetryInterval seconds until created
+type IndexesConfig struct {
+	RetryInterval int `json:"retryInterval"`
+}
+
+// IndexesStatus : Outcome of the last indexes creation of the instance, shared by its environments (See readiness)
+type IndexesStatus struct {
+	mutex   sync.Mutex
+	created bool
+	err     error
+}
+
+// Set : Record outcome of an indexes creation, nil err once indexes are created
+func (status *IndexesStatus) Set(err error) {
+
+	status.mutex.Lock()
+	defer status.mutex.Unlock()
+
+	status.created = err == nil
+	status.err = err
+}
+
+// Err : Return error of the last indexes creation, ErrIndexesPending until one completed. Nil statuses have no indexes to wait for
+func (status *IndexesStatus) Err() error {
+
+	if status == nil {
+		return nil
+	}
+
+	status.mutex.Lock()
+	defer status.mutex.Unlock()
+
+	if !status.created && status.err == nil {
+		return ErrIndexesPending
+	}
+
+	return status.err
+}
@@ -0,0 +1,797 @@
+package models
+
+import (
+	context "context"
+	time "time"
+	utils "wave-messaging-management-service/utils"
+)
+
+// InstrumentedStore : Store wrapper recording operations duration of Datastore and VerneMQ ACLs mutations (See metrics),
+// tracing operations as children of their ctx span and bounding them by the timeouts of Config
+type InstrumentedStore struct {
+	Store     Store
+	Datastore string
+	Config    *DatastoreConfig
+}
+
+// InstrumentedRedis : RedisInterface wrapper recording operations duration (See metrics), tracing operations as children of their ctx span
+// and bounding them by the timeouts of Config
+type InstrumentedRedis struct {
+	Redis  RedisInterface
+	Config *DatastoreConfig
+}
+
+// InstrumentStore : Return instrumented store backed by datastore (DatastoreMongoDB or DatastorePostgreSQL), whose operations timeouts are read from config on every operation
+func InstrumentStore(store Store, datastore string, config *DatastoreConfig) Store {
+	return &InstrumentedStore{Store: store, Datastore: datastore, Config: config}
+}
+
+// InstrumentRedis : Return instrumented redis, whose operations timeouts are read from config on every operation
+func InstrumentRedis(redis RedisInterface, config *DatastoreConfig) RedisInterface {
+	return &InstrumentedRedis{Redis: redis, Config: config}
+}
+
+// startOperation : Start store operation, returning its context and the function ending it (See startDatastoreOperation)
+func (store *InstrumentedStore) startOperation(ctx context.Context, operation string) (context.Context, func()) {
+
+	defaultTimeout := DefaultMongoDBOperationTimeout
+
+	if store.Datastore == DatastorePostgreSQL {
+		defaultTimeout = DefaultPostgreSQLOperationTimeout
+	}
+
+	return startDatastoreOperation(ctx, store.Datastore, operation, store.Config.OperationTimeoutOf(operation, defaultTimeout))
+}
+
+// startOperation : Start Redis operation, returning its context and the function ending it (See startDatastoreOperation)
+func (redis *InstrumentedRedis) startOperation(ctx context.Context, operation string) (context.Context, func()) {
+	return startDatastoreOperation(ctx, DatastoreRedis, operation, redis.Config.OperationTimeoutOf(operation, DefaultRedisOperationTimeout))
+}
+
+// CreateGroupConversations : Timed Store.CreateGroupConversations, counted as ACL mutation
+func (store *InstrumentedStore) CreateGroupConversations(ctx context.Context, groupConversations []*GroupConversation, groupTopicPath string, events ...*LifecycleEvent) error {
+
+	ctx, end := store.startOperation(ctx, "CreateGroupConversations")
+	defer end()
+
+	err := store.Store.CreateGroupConversations(ctx, groupConversations, groupTopicPath, events...)
+
+	countACLMutation("CreateGroupConversations", err)
+
+	return err
+}
+
+// GetGroupConversation : Timed Store.GetGroupConversation
+func (store *InstrumentedStore) GetGroupConversation(ctx context.Context, groupConversationID string) (*GroupConversation, error) {
+
+	ctx, end := store.startOperation(ctx, "GetGroupConversation")
+	defer end()
+
+	return store.Store.GetGroupConversation(ctx, groupConversationID)
+}
+
+// CountCreatedGroupConversations : Timed Store.CountCreatedGroupConversations
+func (store *InstrumentedStore) CountCreatedGroupConversations(ctx context.Context, userID string) (int, error) {
+
+	ctx, end := store.startOperation(ctx, "CountCreatedGroupConversations")
+	defer end()
+
+	return store.Store.CountCreatedGroupConversations(ctx, userID)
+}
+
+// CountGroupMemberships : Timed Store.CountGroupMemberships
+func (store *InstrumentedStore) CountGroupMemberships(ctx context.Context, userID string) (int, error) {
+
+	ctx, end := store.startOperation(ctx, "CountGroupMemberships")
+	defer end()
+
+	return store.Store.CountGroupMemberships(ctx, userID)
+}
+
+// GetGroupMemberships : Timed Store.GetGroupMemberships
+func (store *InstrumentedStore) GetGroupMemberships(ctx context.Context, userID string, pagination *utils.Pagination) ([]*GroupConversation, int, error) {
+
+	ctx, end := store.startOperation(ctx, "GetGroupMemberships")
+	defer end()
+
+	return store.Store.GetGroupMemberships(ctx, userID, pagination)
+}
+
+// RemoveGroupConversation : Timed Store.RemoveGroupConversation, counted as ACL mutation
+func (store *InstrumentedStore) RemoveGroupConversation(ctx context.Context, groupConversationID string, groupTopicPath string, deletedAt time.Time, events ...*LifecycleEvent) (*GroupConversation, error) {
+
+	ctx, end := store.startOperation(ctx, "RemoveGroupConversation")
+	defer end()
+
+	groupConversation, err := store.Store.RemoveGroupConversation(ctx, groupConversationID, groupTopicPath, deletedAt, events...)
+
+	countACLMutation("RemoveGroupConversation", err)
+
+	return groupConversation, err
+}
+
+// RestoreGroupConversation : Timed Store.RestoreGroupConversation, counted as ACL mutation
+func (store *InstrumentedStore) RestoreGroupConversation(ctx context.Context, groupConversationID string, groupTopicPath string, events ...*LifecycleEvent) (*GroupConversation, error) {
+
+	ctx, end := store.startOperation(ctx, "RestoreGroupConversation")
+	defer end()
+
+	groupConversation, err := store.Store.RestoreGroupConversation(ctx, groupConversationID, groupTopicPath, events...)
+
+	countACLMutation("RestoreGroupConversation", err)
+
+	return groupConversation, err
+}
+
+// PurgeGroupConversations : Timed Store.PurgeGroupConversations
+func (store *InstrumentedStore) PurgeGroupConversations(ctx context.Context, deletedBefore time.Time) (int, error) {
+
+	ctx, end := store.startOperation(ctx, "PurgeGroupConversations")
+	defer end()
+
+	return store.Store.PurgeGroupConversations(ctx, deletedBefore)
+}
+
+// AddProfileACL : Timed Store.AddProfileACL, counted as ACL mutation
+func (store *InstrumentedStore) AddProfileACL(ctx context.Context, verneMQACL *VerneMQACL, events ...*LifecycleEvent) error {
+
+	ctx, end := store.startOperation(ctx, "AddProfileACL")
+	defer end()
+
+	err := store.Store.AddProfileACL(ctx, verneMQACL, events...)
+
+	countACLMutation("AddProfileACL", err)
+
+	return err
+}
+
+// GetProfileACL : Timed Store.GetProfileACL
+func (store *InstrumentedStore) GetProfileACL(ctx context.Context, userID string) (*VerneMQACL, error) {
+
+	ctx, end := store.startOperation(ctx, "GetProfileACL")
+	defer end()
+
+	return store.Store.GetProfileACL(ctx, userID)
+}
+
+// GetClientACL : Timed Store.GetClientACL
+func (store *InstrumentedStore) GetClientACL(ctx context.Context, clientID string) (*VerneMQACL, error) {
+
+	ctx, end := store.startOperation(ctx, "GetClientACL")
+	defer end()
+
+	return store.Store.GetClientACL(ctx, clientID)
+}
+
+// GetUserACLs : Timed Store.GetUserACLs
+func (store *InstrumentedStore) GetUserACLs(ctx context.Context, userID string) ([]*VerneMQACL, error) {
+
+	ctx, end := store.startOperation(ctx, "GetUserACLs")
+	defer end()
+
+	return store.Store.GetUserACLs(ctx, userID)
+}
+
+// RemoveDeviceACL : Timed Store.RemoveDeviceACL, counted as ACL mutation
+func (store *InstrumentedStore) RemoveDeviceACL(ctx context.Context, userID string, deviceClientID string, events ...*LifecycleEvent) error {
+
+	ctx, end := store.startOperation(ctx, "RemoveDeviceACL")
+	defer end()
+
+	err := store.Store.RemoveDeviceACL(ctx, userID, deviceClientID, events...)
+
+	countACLMutation("RemoveDeviceACL", err)
+
+	return err
+}
+
+// RemoveUserACLs : Timed Store.RemoveUserACLs, counted as ACL mutation
+func (store *InstrumentedStore) RemoveUserACLs(ctx context.Context, userID string, events ...*LifecycleEvent) error {
+
+	ctx, end := store.startOperation(ctx, "RemoveUserACLs")
+	defer end()
+
+	err := store.Store.RemoveUserACLs(ctx, userID, events...)
+
+	countACLMutation("RemoveUserACLs", err)
+
+	return err
+}
+
+// GetExpiredACLs : Timed Store.GetExpiredACLs
+func (store *InstrumentedStore) GetExpiredACLs(ctx context.Context, now time.Time) ([]*VerneMQACL, error) {
+
+	ctx, end := store.startOperation(ctx, "GetExpiredACLs")
+	defer end()
+
+	return store.Store.GetExpiredACLs(ctx, now)
+}
+
+// RemoveExpiredACLs : Timed Store.RemoveExpiredACLs, counted as ACL mutation
+func (store *InstrumentedStore) RemoveExpiredACLs(ctx context.Context, now time.Time, events ...*LifecycleEvent) error {
+
+	ctx, end := store.startOperation(ctx, "RemoveExpiredACLs")
+	defer end()
+
+	err := store.Store.RemoveExpiredACLs(ctx, now, events...)
+
+	countACLMutation("RemoveExpiredACLs", err)
+
+	return err
+}
+
+// RenewACLs : Timed Store.RenewACLs, counted as ACL mutation
+func (store *InstrumentedStore) RenewACLs(ctx context.Context, userID string, expiresAt time.Time) (bool, error) {
+
+	ctx, end := store.startOperation(ctx, "RenewACLs")
+	defer end()
+
+	exists, err := store.Store.RenewACLs(ctx, userID, expiresAt)
+
+	countACLMutation("RenewACLs", err)
+
+	return exists, err
+}
+
+// AuthorizePublishing : Timed Store.AuthorizePublishing, counted as ACL mutation
+func (store *InstrumentedStore) AuthorizePublishing(ctx context.Context, userID string, topic string) error {
+
+	ctx, end := store.startOperation(ctx, "AuthorizePublishing")
+	defer end()
+
+	err := store.Store.AuthorizePublishing(ctx, userID, topic)
+
+	countACLMutation("AuthorizePublishing", err)
+
+	return err
+}
+
+// UpdatePassHash : Timed Store.UpdatePassHash, counted as ACL mutation
+func (store *InstrumentedStore) UpdatePassHash(ctx context.Context, userID string, newPasshash string, events ...*LifecycleEvent) error {
+
+	ctx, end := store.startOperation(ctx, "UpdatePassHash")
+	defer end()
+
+	err := store.Store.UpdatePassHash(ctx, userID, newPasshash, events...)
+
+	countACLMutation("UpdatePassHash", err)
+
+	return err
+}
+
+// AddPushToken : Timed Store.AddPushToken
+func (store *InstrumentedStore) AddPushToken(ctx context.Context, pushToken *PushToken) error {
+
+	ctx, end := store.startOperation(ctx, "AddPushToken")
+	defer end()
+
+	return store.Store.AddPushToken(ctx, pushToken)
+}
+
+// RemovePushToken : Timed Store.RemovePushToken
+func (store *InstrumentedStore) RemovePushToken(ctx context.Context, userID string, token string) error {
+
+	ctx, end := store.startOperation(ctx, "RemovePushToken")
+	defer end()
+
+	return store.Store.RemovePushToken(ctx, userID, token)
+}
+
+// GetPushTokens : Timed Store.GetPushTokens
+func (store *InstrumentedStore) GetPushTokens(ctx context.Context, userID string) ([]*PushToken, error) {
+
+	ctx, end := store.startOperation(ctx, "GetPushTokens")
+	defer end()
+
+	return store.Store.GetPushTokens(ctx, userID)
+}
+
+// GetNotificationPreferences : Timed Store.GetNotificationPreferences
+func (store *InstrumentedStore) GetNotificationPreferences(ctx context.Context, userID string) (*NotificationPreferences, error) {
+
+	ctx, end := store.startOperation(ctx, "GetNotificationPreferences")
+	defer end()
+
+	return store.Store.GetNotificationPreferences(ctx, userID)
+}
+
+// SetNotificationPreferences : Timed Store.SetNotificationPreferences
+func (store *InstrumentedStore) SetNotificationPreferences(ctx context.Context, preferences *NotificationPreferences) error {
+
+	ctx, end := store.startOperation(ctx, "SetNotificationPreferences")
+	defer end()
+
+	return store.Store.SetNotificationPreferences(ctx, preferences)
+}
+
+// AddAPIKey : Timed Store.AddAPIKey
+func (store *InstrumentedStore) AddAPIKey(ctx context.Context, apiKey *APIKey) error {
+
+	ctx, end := store.startOperation(ctx, "AddAPIKey")
+	defer end()
+
+	return store.Store.AddAPIKey(ctx, apiKey)
+}
+
+// GetAPIKey : Timed Store.GetAPIKey
+func (store *InstrumentedStore) GetAPIKey(ctx context.Context, hashedKey string) (*APIKey, error) {
+
+	ctx, end := store.startOperation(ctx, "GetAPIKey")
+	defer end()
+
+	return store.Store.GetAPIKey(ctx, hashedKey)
+}
+
+// GetQuotaOverride : Timed Store.GetQuotaOverride
+func (store *InstrumentedStore) GetQuotaOverride(ctx context.Context, userID string) (*QuotaOverride, error) {
+
+	ctx, end := store.startOperation(ctx, "GetQuotaOverride")
+	defer end()
+
+	return store.Store.GetQuotaOverride(ctx, userID)
+}
+
+// SetQuotaOverride : Timed Store.SetQuotaOverride
+func (store *InstrumentedStore) SetQuotaOverride(ctx context.Context, override *QuotaOverride) error {
+
+	ctx, end := store.startOperation(ctx, "SetQuotaOverride")
+	defer end()
+
+	return store.Store.SetQuotaOverride(ctx, override)
+}
+
+// AddUsage : Timed Store.AddUsage
+func (store *InstrumentedStore) AddUsage(ctx context.Context, date string, tenantID string, userID string, metric string, count int) error {
+
+	ctx, end := store.startOperation(ctx, "AddUsage")
+	defer end()
+
+	return store.Store.AddUsage(ctx, date, tenantID, userID, metric, count)
+}
+
+// GetUsage : Timed Store.GetUsage
+func (store *InstrumentedStore) GetUsage(ctx context.Context, filter *UsageFilter) ([]*UsageRecord, error) {
+
+	ctx, end := store.startOperation(ctx, "GetUsage")
+	defer end()
+
+	return store.Store.GetUsage(ctx, filter)
+}
+
+// AddAuditEntry : Timed Store.AddAuditEntry
+func (store *InstrumentedStore) AddAuditEntry(ctx context.Context, entry *AuditEntry) error {
+
+	ctx, end := store.startOperation(ctx, "AddAuditEntry")
+	defer end()
+
+	return store.Store.AddAuditEntry(ctx, entry)
+}
+
+// GetAuditEntries : Timed Store.GetAuditEntries
+func (store *InstrumentedStore) GetAuditEntries(ctx context.Context, filter *AuditFilter) ([]*AuditEntry, int, error) {
+
+	ctx, end := store.startOperation(ctx, "GetAuditEntries")
+	defer end()
+
+	return store.Store.GetAuditEntries(ctx, filter)
+}
+
+// DedupACLPatterns : Timed Store.DedupACLPatterns
+func (store *InstrumentedStore) DedupACLPatterns(ctx context.Context) (int, error) {
+
+	ctx, end := store.startOperation(ctx, "DedupACLPatterns")
+	defer end()
+
+	return store.Store.DedupACLPatterns(ctx)
+}
+
+// GetDeletedACLs : Timed Store.GetDeletedACLs
+func (store *InstrumentedStore) GetDeletedACLs(ctx context.Context, userID string) ([]*DeletedACL, error) {
+
+	ctx, end := store.startOperation(ctx, "GetDeletedACLs")
+	defer end()
+
+	return store.Store.GetDeletedACLs(ctx, userID)
+}
+
+// RestoreUserACLs : Timed Store.RestoreUserACLs, counted as ACL mutation
+func (store *InstrumentedStore) RestoreUserACLs(ctx context.Context, userID string, events ...*LifecycleEvent) (int, error) {
+
+	ctx, end := store.startOperation(ctx, "RestoreUserACLs")
+	defer end()
+
+	restored, err := store.Store.RestoreUserACLs(ctx, userID, events...)
+
+	countACLMutation("RestoreUserACLs", err)
+
+	return restored, err
+}
+
+// PurgeDeletedACLs : Timed Store.PurgeDeletedACLs
+func (store *InstrumentedStore) PurgeDeletedACLs(ctx context.Context, deletedBefore time.Time) (int, error) {
+
+	ctx, end := store.startOperation(ctx, "PurgeDeletedACLs")
+	defer end()
+
+	return store.Store.PurgeDeletedACLs(ctx, deletedBefore)
+}
+
+// GetMigrations : Timed Store.GetMigrations
+func (store *InstrumentedStore) GetMigrations(ctx context.Context) ([]*MigrationRecord, error) {
+
+	ctx, end := store.startOperation(ctx, "GetMigrations")
+	defer end()
+
+	return store.Store.GetMigrations(ctx)
+}
+
+// ClaimMigration : Timed Store.ClaimMigration
+func (store *InstrumentedStore) ClaimMigration(ctx context.Context, version int, name string, now time.Time, leaseUntil time.Time) (bool, error) {
+
+	ctx, end := store.startOperation(ctx, "ClaimMigration")
+	defer end()
+
+	return store.Store.ClaimMigration(ctx, version, name, now, leaseUntil)
+}
+
+// CompleteMigration : Timed Store.CompleteMigration
+func (store *InstrumentedStore) CompleteMigration(ctx context.Context, version int, now time.Time) error {
+
+	ctx, end := store.startOperation(ctx, "CompleteMigration")
+	defer end()
+
+	return store.Store.CompleteMigration(ctx, version, now)
+}
+
+// WatchChanges : Store.WatchChanges, neither timed nor bound by an operation timeout as it runs until ctx is done
+func (store *InstrumentedStore) WatchChanges(ctx context.Context, resumeToken []byte, handle func(change *DataChange)) ([]byte, error) {
+	return store.Store.WatchChanges(ctx, resumeToken, handle)
+}
+
+// EnsureIndexes : Timed Store.EnsureIndexes
+func (store *InstrumentedStore) EnsureIndexes(ctx context.Context) error {
+
+	ctx, end := store.startOperation(ctx, "EnsureIndexes")
+	defer end()
+
+	return store.Store.EnsureIndexes(ctx)
+}
+
+// ExpireTombstones : Timed Store.ExpireTombstones
+func (store *InstrumentedStore) ExpireTombstones(ctx context.Context, expireAfter time.Duration) error {
+
+	ctx, end := store.startOperation(ctx, "ExpireTombstones")
+	defer end()
+
+	return store.Store.ExpireTombstones(ctx, expireAfter)
+}
+
+// Ping : Timed Store.Ping
+func (store *InstrumentedStore) Ping(ctx context.Context) error {
+
+	ctx, end := store.startOperation(ctx, "Ping")
+	defer end()
+
+	return store.Store.Ping(ctx)
+}
+
+// Close : Store.Close, not timed
+func (store *InstrumentedStore) Close() error {
+	return store.Store.Close()
+}
+
+// ForTenant : Return instrumented store scoped to tenantID
+func (store *InstrumentedStore) ForTenant(tenantID string) Store {
+	return &InstrumentedStore{Store: store.Store.ForTenant(tenantID), Datastore: store.Datastore, Config: store.Config}
+}
+
+// Ping : Timed RedisInterface.Ping
+func (redis *InstrumentedRedis) Ping(ctx context.Context) error {
+
+	ctx, end := redis.startOperation(ctx, "Ping")
+	defer end()
+
+	return redis.Redis.Ping(ctx)
+}
+
+// CloseConnection : RedisInterface.CloseConnection, not timed
+func (redis *InstrumentedRedis) CloseConnection() error {
+	return redis.Redis.CloseConnection()
+}
+
+// Get : Timed RedisInterface.Get
+func (redis *InstrumentedRedis) Get(ctx context.Context, key string) ([]byte, error) {
+
+	ctx, end := redis.startOperation(ctx, "Get")
+	defer end()
+
+	return redis.Redis.Get(ctx, key)
+}
+
+// HGet : Timed RedisInterface.HGet
+func (redis *InstrumentedRedis) HGet(ctx context.Context, key string, field string) ([]byte, error) {
+
+	ctx, end := redis.startOperation(ctx, "HGet")
+	defer end()
+
+	return redis.Redis.HGet(ctx, key, field)
+}
+
+// BatchHGet : Timed RedisInterface.BatchHGet
+func (redis *InstrumentedRedis) BatchHGet(ctx context.Context, keys []string, field string) ([][]byte, error) {
+
+	ctx, end := redis.startOperation(ctx, "BatchHGet")
+	defer end()
+
+	return redis.Redis.BatchHGet(ctx, keys, field)
+}
+
+// BatchExists : Timed RedisInterface.BatchExists
+func (redis *InstrumentedRedis) BatchExists(ctx context.Context, keys []string) ([]bool, error) {
+
+	ctx, end := redis.startOperation(ctx, "BatchExists")
+	defer end()
+
+	return redis.Redis.BatchExists(ctx, keys)
+}
+
+// Pipeline : Timed RedisInterface.Pipeline
+func (redis *InstrumentedRedis) Pipeline(ctx context.Context, commands []RedisCommand) ([]interface{}, error) {
+
+	ctx, end := redis.startOperation(ctx, "Pipeline")
+	defer end()
+
+	return redis.Redis.Pipeline(ctx, commands)
+}
+
+// HSet : Timed RedisInterface.HSet
+func (redis *InstrumentedRedis) HSet(ctx context.Context, key string, field1 string, value1 []byte, field2 string, value2 []byte) error {
+
+	ctx, end := redis.startOperation(ctx, "HSet")
+	defer end()
+
+	return redis.Redis.HSet(ctx, key, field1, value1, field2, value2)
+}
+
+// HDel : Timed RedisInterface.HDel
+func (redis *InstrumentedRedis) HDel(ctx context.Context, key string, fields ...string) error {
+
+	ctx, end := redis.startOperation(ctx, "HDel")
+	defer end()
+
+	return redis.Redis.HDel(ctx, key, fields...)
+}
+
+// Set : Timed RedisInterface.Set
+func (redis *InstrumentedRedis) Set(ctx context.Context, key string, value []byte) error {
+
+	ctx, end := redis.startOperation(ctx, "Set")
+	defer end()
+
+	return redis.Redis.Set(ctx, key, value)
+}
+
+// SetEx : Timed RedisInterface.SetEx
+func (redis *InstrumentedRedis) SetEx(ctx context.Context, key string, value []byte, seconds int) error {
+
+	ctx, end := redis.startOperation(ctx, "SetEx")
+	defer end()
+
+	return redis.Redis.SetEx(ctx, key, value, seconds)
+}
+
+// Exists : Timed RedisInterface.Exists
+func (redis *InstrumentedRedis) Exists(ctx context.Context, key string) (bool, error) {
+
+	ctx, end := redis.startOperation(ctx, "Exists")
+	defer end()
+
+	return redis.Redis.Exists(ctx, key)
+}
+
+// Delete : Timed RedisInterface.Delete
+func (redis *InstrumentedRedis) Delete(ctx context.Context, key string) error {
+
+	ctx, end := redis.startOperation(ctx, "Delete")
+	defer end()
+
+	return redis.Redis.Delete(ctx, key)
+}
+
+// GetKeys : Timed RedisInterface.GetKeys
+func (redis *InstrumentedRedis) GetKeys(ctx context.Context, pattern string) ([]string, error) {
+
+	ctx, end := redis.startOperation(ctx, "GetKeys")
+	defer end()
+
+	return redis.Redis.GetKeys(ctx, pattern)
+}
+
+// Incr : Timed RedisInterface.Incr
+func (redis *InstrumentedRedis) Incr(ctx context.Context, counterKey string) (int, error) {
+
+	ctx, end := redis.startOperation(ctx, "Incr")
+	defer end()
+
+	return redis.Redis.Incr(ctx, counterKey)
+}
+
+// Rename : Timed RedisInterface.Rename
+func (redis *InstrumentedRedis) Rename(ctx context.Context, oldKey string, newKey string) error {
+
+	ctx, end := redis.startOperation(ctx, "Rename")
+	defer end()
+
+	return redis.Redis.Rename(ctx, oldKey, newKey)
+}
+
+// Expire : Timed RedisInterface.Expire
+func (redis *InstrumentedRedis) Expire(ctx context.Context, key string, seconds int) error {
+
+	ctx, end := redis.startOperation(ctx, "Expire")
+	defer end()
+
+	return redis.Redis.Expire(ctx, key, seconds)
+}
+
+// EvalInts : Timed RedisInterface.EvalInts
+func (redis *InstrumentedRedis) EvalInts(ctx context.Context, script string, keys []string, args ...interface{}) ([]int, error) {
+
+	ctx, end := redis.startOperation(ctx, "EvalInts")
+	defer end()
+
+	return redis.Redis.EvalInts(ctx, script, keys, args...)
+}
+
+// EvalStrings : Timed RedisInterface.EvalStrings
+func (redis *InstrumentedRedis) EvalStrings(ctx context.Context, script string, keys []string, args ...interface{}) ([]string, error) {
+
+	ctx, end := redis.startOperation(ctx, "EvalStrings")
+	defer end()
+
+	return redis.Redis.EvalStrings(ctx, script, keys, args...)
+}
+
+// AddWebhook : Timed Store.AddWebhook
+func (store *InstrumentedStore) AddWebhook(ctx context.Context, webhook *Webhook) error {
+
+	ctx, end := store.startOperation(ctx, "AddWebhook")
+	defer end()
+
+	return store.Store.AddWebhook(ctx, webhook)
+}
+
+// GetWebhooks : Timed Store.GetWebhooks
+func (store *InstrumentedStore) GetWebhooks(ctx context.Context) ([]*Webhook, error) {
+
+	ctx, end := store.startOperation(ctx, "GetWebhooks")
+	defer end()
+
+	return store.Store.GetWebhooks(ctx)
+}
+
+// RemoveWebhook : Timed Store.RemoveWebhook
+func (store *InstrumentedStore) RemoveWebhook(ctx context.Context, webhookID string) (bool, error) {
+
+	ctx, end := store.startOperation(ctx, "RemoveWebhook")
+	defer end()
+
+	return store.Store.RemoveWebhook(ctx, webhookID)
+}
+
+// AddWebhookDelivery : Timed Store.AddWebhookDelivery
+func (store *InstrumentedStore) AddWebhookDelivery(ctx context.Context, delivery *WebhookDelivery) error {
+
+	ctx, end := store.startOperation(ctx, "AddWebhookDelivery")
+	defer end()
+
+	return store.Store.AddWebhookDelivery(ctx, delivery)
+}
+
+// UpdateWebhookDelivery : Timed Store.UpdateWebhookDelivery
+func (store *InstrumentedStore) UpdateWebhookDelivery(ctx context.Context, delivery *WebhookDelivery) error {
+
+	ctx, end := store.startOperation(ctx, "UpdateWebhookDelivery")
+	defer end()
+
+	return store.Store.UpdateWebhookDelivery(ctx, delivery)
+}
+
+// GetWebhookDeliveries : Timed Store.GetWebhookDeliveries
+func (store *InstrumentedStore) GetWebhookDeliveries(ctx context.Context, webhookID string, pagination *utils.Pagination) ([]*WebhookDelivery, int, error) {
+
+	ctx, end := store.startOperation(ctx, "GetWebhookDeliveries")
+	defer end()
+
+	return store.Store.GetWebhookDeliveries(ctx, webhookID, pagination)
+}
+
+// GetWebhook : Timed Store.GetWebhook
+func (store *InstrumentedStore) GetWebhook(ctx context.Context, webhookID string) (*Webhook, error) {
+
+	ctx, end := store.startOperation(ctx, "GetWebhook")
+	defer end()
+
+	return store.Store.GetWebhook(ctx, webhookID)
+}
+
+// GetDueWebhookDeliveries : Timed Store.GetDueWebhookDeliveries
+func (store *InstrumentedStore) GetDueWebhookDeliveries(ctx context.Context, now time.Time, limit int) ([]*WebhookDelivery, error) {
+
+	ctx, end := store.startOperation(ctx, "GetDueWebhookDeliveries")
+	defer end()
+
+	return store.Store.GetDueWebhookDeliveries(ctx, now, limit)
+}
+
+// ClaimWebhookDelivery : Timed Store.ClaimWebhookDelivery
+func (store *InstrumentedStore) ClaimWebhookDelivery(ctx context.Context, deliveryID string, now time.Time, leaseUntil time.Time) (bool, error) {
+
+	ctx, end := store.startOperation(ctx, "ClaimWebhookDelivery")
+	defer end()
+
+	return store.Store.ClaimWebhookDelivery(ctx, deliveryID, now, leaseUntil)
+}
+
+// AddWebhookDeadLetter : Timed Store.AddWebhookDeadLetter
+func (store *InstrumentedStore) AddWebhookDeadLetter(ctx context.Context, deadLetter *WebhookDeadLetter) error {
+
+	ctx, end := store.startOperation(ctx, "AddWebhookDeadLetter")
+	defer end()
+
+	return store.Store.AddWebhookDeadLetter(ctx, deadLetter)
+}
+
+// GetWebhookDeadLetter : Timed Store.GetWebhookDeadLetter
+func (store *InstrumentedStore) GetWebhookDeadLetter(ctx context.Context, deadLetterID string) (*WebhookDeadLetter, error) {
+
+	ctx, end := store.startOperation(ctx, "GetWebhookDeadLetter")
+	defer end()
+
+	return store.Store.GetWebhookDeadLetter(ctx, deadLetterID)
+}
+
+// GetWebhookDeadLetters : Timed Store.GetWebhookDeadLetters
+func (store *InstrumentedStore) GetWebhookDeadLetters(ctx context.Context, filter *WebhookDeadLetterFilter) ([]*WebhookDeadLetter, int, error) {
+
+	ctx, end := store.startOperation(ctx, "GetWebhookDeadLetters")
+	defer end()
+
+	return store.Store.GetWebhookDeadLetters(ctx, filter)
+}
+
+// RemoveWebhookDeadLetter : Timed Store.RemoveWebhookDeadLetter
+func (store *InstrumentedStore) RemoveWebhookDeadLetter(ctx context.Context, deadLetterID string) (bool, error) {
+
+	ctx, end := store.startOperation(ctx, "RemoveWebhookDeadLetter")
+	defer end()
+
+	return store.Store.RemoveWebhookDeadLetter(ctx, deadLetterID)
+}
+
+// GetOutboxEvents : Timed Store.GetOutboxEvents
+func (store *InstrumentedStore) GetOutboxEvents(ctx context.Context, now time.Time, limit int) ([]*OutboxEvent, error) {
+
+	ctx, end := store.startOperation(ctx, "GetOutboxEvents")
+	defer end()
+
+	return store.Store.GetOutboxEvents(ctx, now, limit)
+}
+
+// ClaimOutboxEvent : Timed Store.ClaimOutboxEvent
+func (store *InstrumentedStore) ClaimOutboxEvent(ctx context.Context, eventID string, now time.Time, leaseUntil time.Time) (bool, error) {
+
+	ctx, end := store.startOperation(ctx, "ClaimOutboxEvent")
+	defer end()
+
+	return store.Store.ClaimOutboxEvent(ctx, eventID, now, leaseUntil)
+}
+
+// RemoveOutboxEvent : Timed Store.RemoveOutboxEvent
+func (store *InstrumentedStore) RemoveOutboxEvent(ctx context.Context, eventID string) error {
+
+	ctx, end := store.startOperation(ctx, "RemoveOutboxEvent")
+	defer end()
+
+	return store.Store.RemoveOutboxEvent(ctx, eventID)
+}
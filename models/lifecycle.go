@@ -0,0 +1,247 @@
+package models
+
+import (
+	context "context"
+	tls "crypto/tls"
+	json "encoding/json"
+	errors "errors"
+	time "time"
+
+	uuid "github.com/satori/go.uuid"
+	kafka "github.com/segmentio/kafka-go"
+	plain "github.com/segmentio/kafka-go/sasl/plain"
+	logrus "github.com/sirupsen/logrus"
+)
+
+const (
+	// LifecycleConversationCreated : Group conversation created, Subject being its ID
+	LifecycleConversationCreated = "conversation.created"
+
+	// LifecycleMemberAdded : User added to a group conversation, Subject being the internal Wave user ID
+	LifecycleMemberAdded = "member.added"
+
+	// LifecycleUserProvisioned : VerneMQ ACL of a user created on its first connection, Subject being its internal Wave user ID
+	LifecycleUserProvisioned = "user.provisioned"
+
+	// LifecycleACLRevoked : VerneMQ ACL removed (Logout, device removal, expiry or suspension), Subject being its client ID (Internal Wave user ID when suspended)
+	LifecycleACLRevoked = "acl.revoked"
+
+	// LifecycleACLRestored : Removed VerneMQ ACLs of a user restored, Subject being its internal Wave user ID
+	LifecycleACLRestored = "acl.restored"
+
+	// LifecycleConversationRemoved : Group conversation removed, Subject being its ID
+	LifecycleConversationRemoved = "conversation.removed"
+
+	// LifecycleConversationRestored : Removed group conversation restored, Subject being its ID
+	LifecycleConversationRestored = "conversation.restored"
+
+	// LifecycleTransportKafka : Lifecycle events produced to a Kafka topic
+	LifecycleTransportKafka = "kafka"
+
+	// LifecycleTransportNATS : Lifecycle events published on NATS subjects
+	LifecycleTransportNATS = "nats"
+
+	// DefaultKafkaBatchTimeout : Milliseconds events are batched before being produced, used when none is configured
+	DefaultKafkaBatchTimeout = 100
+
+	// lifecycleTypeHeader : Kafka and NATS header holding the type of an event, so that consumers can filter without decoding values
+	lifecycleTypeHeader = "type"
+)
+
+var (
+	// ErrUnknownLifecycleTransport : Lifecycle events transport is neither kafka nor nats
+	ErrUnknownLifecycleTransport = errors.New("Unknown lifecycle events transport")
+
+	// ErrMissingKafkaTopic : Kafka transport selected without brokers or topic
+	ErrMissingKafkaTopic = errors.New("Kafka brokers and topic must be set")
+
+	// lifecycleEventTypes : Lifecycle event types of audited actions, actions missing from it are not produced
+	lifecycleEventTypes = map[string]string{
+		AuditACLCreate:         LifecycleUserProvisioned,
+		AuditGroupCreate:       LifecycleConversationCreated,
+		AuditGroupMemberAdd:    LifecycleMemberAdded,
+		AuditCredentialsRevoke: LifecycleACLRevoked,
+		AuditDeviceDeregister:  LifecycleACLRevoked,
+		AuditACLExpire:         LifecycleACLRevoked,
+		AuditAdminUserSuspend:  LifecycleACLRevoked,
+		AuditAdminUserRestore:  LifecycleACLRestored,
+		AuditAdminGroupRemove:  LifecycleConversationRemoved,
+		AuditAdminGroupRestore: LifecycleConversationRestored,
+	}
+)
+
+// LifecycleConfig : Lifecycle events Config, read at startup. Transport selects where events go (kafka or nats), none are produced when empty
+type LifecycleConfig struct {
+	Transport string      `json:"transport"`
+	Kafka     KafkaConfig `json:"kafka"`
+	NATS      NATSConfig  `json:"nats"`
+}
+
+// KafkaConfig : Kafka transport Config. Events are produced to Topic on Brokers, over TLS when enabled,
+// authenticated with SASL/PLAIN when Username is set
+type KafkaConfig struct {
+	Brokers      []string `json:"brokers"`
+	Topic        string   `json:"topic"`
+	TLS          bool     `json:"tls"`
+	Username     string   `json:"username"`
+	Password     string   `json:"password"`
+	BatchTimeout int      `json:"batchTimeout"`
+}
+
+// LifecycleEvent : Structured event of the conversations and ACLs lifecycle, produced for analytics and downstream services.
+// Action is the audited action the event stems from (See Audit Log), Details its audit details
+type LifecycleEvent struct {
+	ID        string            `json:"id"`
+	Type      string            `json:"type"`
+	Subject   string            `json:"subject"`
+	TenantID  string            `json:"tenantID,omitempty"`
+	Actor     AuditActor        `json:"actor"`
+	Action    string            `json:"action"`
+	Details   map[string]string `json:"details,omitempty"`
+	RequestID string            `json:"requestID,omitempty"`
+	Timestamp time.Time         `json:"timestamp"`
+}
+
+// NewLifecycleEvent : Return lifecycle event of audit entry, nil if its action has none
+func NewLifecycleEvent(entry *AuditEntry) *LifecycleEvent {
+
+	eventType, ok := lifecycleEventTypes[entry.Action]
+
+	if !ok {
+		return nil
+	}
+
+	return &LifecycleEvent{
+		ID:        uuid.NewV4().String(),
+		Type:      eventType,
+		Subject:   entry.Target,
+		TenantID:  entry.TenantID,
+		Actor:     entry.Actor,
+		Action:    entry.Action,
+		Details:   entry.Details,
+		RequestID: entry.RequestID,
+		Timestamp: entry.Timestamp,
+	}
+}
+
+// Key : Partitioning key of the event, so that events of a conversation (Or of a subject) are consumed in order
+func (event *LifecycleEvent) Key() string {
+
+	if conversationID, ok := event.Details["conversationID"]; ok {
+		return conversationID
+	}
+
+	return event.Subject
+}
+
+// ProducerInterface : Producer of lifecycle events for downstream services, over the transport selected by configuration.
+// Produce never waits for delivery, Close delivers queued events
+type ProducerInterface interface {
+	Produce(event *LifecycleEvent) error
+	Close() error
+}
+
+// NewProducer : Return producer of the configured lifecycle events transport, nil if none is selected
+func NewProducer(config *LifecycleConfig, logger *logrus.Entry) (ProducerInterface, error) {
+
+	switch config.Transport {
+
+	case "":
+		return nil, nil
+
+	case LifecycleTransportKafka:
+		return NewKafkaProducer(&config.Kafka, logger)
+
+	case LifecycleTransportNATS:
+		return NewNATSProducer(&config.NATS, logger)
+	}
+
+	return nil, ErrUnknownLifecycleTransport
+}
+
+// KafkaProducer : Asynchronous Kafka producer of lifecycle events. Events are batched, failures are logged and counted, never returned
+type KafkaProducer struct {
+	writer *kafka.Writer
+}
+
+// NewKafkaProducer : Return Kafka producer of config, logging failed deliveries with logger
+func NewKafkaProducer(config *KafkaConfig, logger *logrus.Entry) (*KafkaProducer, error) {
+
+	if len(config.Brokers) == 0 || config.Topic == "" {
+		return nil, ErrMissingKafkaTopic
+	}
+
+	batchTimeout := config.BatchTimeout
+
+	if batchTimeout <= 0 {
+		batchTimeout = DefaultKafkaBatchTimeout
+	}
+
+	transport := &kafka.Transport{}
+
+	if config.TLS {
+		transport.TLS = &tls.Config{MinVersion: tls.VersionTLS12}
+	}
+
+	if config.Username != "" {
+		transport.SASL = plain.Mechanism{Username: config.Username, Password: config.Password}
+	}
+
+	writer := &kafka.Writer{
+		Addr:         kafka.TCP(config.Brokers...),
+		Topic:        config.Topic,
+		Balancer:     &kafka.Hash{},
+		BatchTimeout: time.Duration(batchTimeout) * time.Millisecond,
+		RequiredAcks: kafka.RequireAll,
+		Transport:    transport,
+		Async:        true,
+		Completion: func(messages []kafka.Message, err error) {
+
+			result := "produced"
+
+			if err != nil {
+				result = "failed"
+				logger.WithError(err).WithField("events", len(messages)).Error("Failed to produce lifecycle events")
+			}
+
+			for _, message := range messages {
+				LifecycleEvents.WithLabelValues(LifecycleTransportKafka, lifecycleMessageType(message), result).Inc()
+			}
+		},
+	}
+
+	return &KafkaProducer{writer: writer}, nil
+}
+
+// Produce : Queue event for production, keyed by its partitioning key
+func (producer *KafkaProducer) Produce(event *LifecycleEvent) error {
+
+	value, err := json.Marshal(event)
+
+	if err != nil {
+		return err
+	}
+
+	return producer.writer.WriteMessages(context.Background(), kafka.Message{
+		Key:     []byte(event.Key()),
+		Value:   value,
+		Headers: []kafka.Header{{Key: lifecycleTypeHeader, Value: []byte(event.Type)}},
+	})
+}
+
+// Close : Produce queued events, then close connections to brokers
+func (producer *KafkaProducer) Close() error {
+	return producer.writer.Close()
+}
+
+// lifecycleMessageType : Return type of the event of message, from its header
+func lifecycleMessageType(message kafka.Message) string {
+
+	for _, header := range message.Headers {
+		if header.Key == lifecycleTypeHeader {
+			return string(header.Value)
+		}
+	}
+
+	return ""
+}
@@ -0,0 +1,159 @@
+package models
+
+import (
+	context "context"
+	fmt "fmt"
+	os "os"
+	signal "os/signal"
+	sync "sync"
+	syscall "syscall"
+
+	logrus "github.com/sirupsen/logrus"
+)
+
+const (
+	// RequestIDHeader : Header holding the ID of a request, generated when the caller did not set one.
+	// Forwarded to remote verifiers and returned in responses
+	RequestIDHeader = "X-Request-ID"
+
+	// LogFormatJSON : Log JSON lines (Default)
+	LogFormatJSON = "json"
+
+	// LogFormatText : Log human readable lines
+	LogFormatText = "text"
+
+	// DefaultLogLevel : Minimum level of logged entries, used when none is configured
+	DefaultLogLevel = "info"
+
+	// LogFieldRequestID : Log field holding the ID of the request being handled
+	LogFieldRequestID = "requestID"
+
+	// LogFieldUserID : Log field holding the internal Wave user ID of the request maker
+	LogFieldUserID = "userID"
+
+	// LogFieldHandler : Log field holding the name of the handler of the request
+	LogFieldHandler = "handler"
+
+	// LogFieldTenantID : Log field holding the tenant of a tenant scoped environment
+	LogFieldTenantID = "tenantID"
+)
+
+var (
+	loggingMutex  sync.Mutex
+	loggingConfig = LoggingConfig{Level: DefaultLogLevel, Format: LogFormatJSON}
+)
+
+// LoggingConfig : Service logger Config, Level is debug, info, warn or error and Format json or text.
+// Applied at startup and on SIGUSR1, unset fields use defaults (info, json)
+type LoggingConfig struct {
+	Level  string `json:"level" validate:"omitempty,oneof=debug info warn error"`
+	Format string `json:"format" validate:"omitempty,oneof=json text"`
+}
+
+// NewLogger : Return structured logger of the service, writing JSON lines until configured (See ConfigureLogger).
+// Standard logrus logger is used, so that code running before environment exists logs alike
+func NewLogger() *logrus.Entry {
+
+	logger := logrus.StandardLogger()
+	logger.Formatter = &logrus.JSONFormatter{}
+	logger.SetLevel(logrus.InfoLevel)
+
+	return logrus.NewEntry(logger).WithField("service", "MessagingService")
+}
+
+// ConfigureLogger : Apply level and format of config to the service logger, unset fields use defaults
+func ConfigureLogger(logger *logrus.Entry, config LoggingConfig) error {
+
+	if config.Level == "" {
+		config.Level = DefaultLogLevel
+	}
+
+	if config.Format == "" {
+		config.Format = LogFormatJSON
+	}
+
+	level, err := logrus.ParseLevel(config.Level)
+
+	if err != nil {
+		return err
+	}
+
+	var formatter logrus.Formatter
+
+	switch config.Format {
+	case LogFormatJSON:
+		formatter = &logrus.JSONFormatter{}
+	case LogFormatText:
+		formatter = &logrus.TextFormatter{DisableColors: true, FullTimestamp: true}
+	default:
+		return fmt.Errorf("Unknown log format %s", config.Format)
+	}
+
+	loggingMutex.Lock()
+	defer loggingMutex.Unlock()
+
+	logger.Logger.Formatter = formatter
+	logger.Logger.SetLevel(level)
+
+	loggingConfig = config
+
+	return nil
+}
+
+// CurrentLoggingConfig : Return logging config currently applied to the service logger
+func CurrentLoggingConfig() LoggingConfig {
+
+	loggingMutex.Lock()
+	defer loggingMutex.Unlock()
+
+	return loggingConfig
+}
+
+// ReloadLoggingOnSignal : Apply logging config of the config file again on each SIGUSR1, in background.
+// Config file is only watched for logging changes on signal, so that runtime changes of the admin API are kept until then
+func (env *Env) ReloadLoggingOnSignal() {
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGUSR1)
+
+	go func() {
+
+		for range signals {
+
+			config, err := ReadConfig()
+
+			if err == nil {
+				err = ConfigureLogger(env.Logger, config.Logging)
+			}
+
+			if err != nil {
+				env.Logger.WithError(err).Error("Failed to reload logging config")
+				continue
+			}
+
+			env.Logger.WithField("logging", config.Logging).Info("Logging config reloaded")
+		}
+	}()
+}
+
+// ForRequest : Return copy of execution environment for a request : Its ID is kept to be forwarded, and logged with the handler name.
+// Its spans are children of ctx span, its caller is unknown until identified, its config is the one last loaded (See RefreshConfig)
+func (env *Env) ForRequest(ctx context.Context, requestID string, handler string) *Env {
+
+	scoped := env.WithLogFields(logrus.Fields{LogFieldRequestID: requestID, LogFieldHandler: handler}).WithTraceContext(ctx)
+	scoped.RequestID = requestID
+	scoped.Caller = &AuditActor{}
+
+	scoped.Config = *env.CurrentConfig()
+
+	return scoped
+}
+
+// WithLogFields : Return copy of execution environment whose logger holds fields (e.g. request ID, user ID)
+func (env *Env) WithLogFields(fields logrus.Fields) *Env {
+
+	scoped := *env
+	scoped.Logger = env.Logger.WithFields(fields)
+
+	return &scoped
+}
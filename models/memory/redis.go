@@ -0,0 +1,455 @@
+package memory
+
+import (
+	context "context"
+	errors "errors"
+	fmt "fmt"
+	regexp "regexp"
+	strconv "strconv"
+	strings "strings"
+	sync "sync"
+	time "time"
+	models "wave-messaging-management-service/models"
+)
+
+var (
+	// ErrNil : Key or field does not exist, as reported by Redis
+	ErrNil = errors.New("nil returned")
+)
+
+// ScriptHandler : Go implementation of a Lua script run by EvalInts, given the keys and arguments of the call
+type ScriptHandler func(redis *Redis, keys []string, args ...interface{}) ([]int, error)
+
+// StringsScriptHandler : Go implementation of a Lua script run by EvalStrings, given the keys and arguments of the call
+type StringsScriptHandler func(redis *Redis, keys []string, args ...interface{}) ([]string, error)
+
+// Redis : In-memory models.RedisInterface, for unit tests. Keys expire lazily, when read after their expiry.
+// Lua scripts can't be run : EvalInts and EvalStrings call the handler registered for the script (See HandleScript and HandleStringsScript),
+// and fail for other scripts
+type Redis struct {
+	mutex          sync.Mutex
+	values         map[string][]byte
+	hashes         map[string]map[string][]byte
+	expiries       map[string]time.Time
+	scripts        map[string]ScriptHandler
+	stringsScripts map[string]StringsScriptHandler
+}
+
+// NewRedis : Return a new empty in-memory Redis
+func NewRedis() *Redis {
+	return &Redis{
+		values:         map[string][]byte{},
+		hashes:         map[string]map[string][]byte{},
+		expiries:       map[string]time.Time{},
+		scripts:        map[string]ScriptHandler{},
+		stringsScripts: map[string]StringsScriptHandler{},
+	}
+}
+
+// HandleScript : Run handler when script is evaluated by EvalInts. Handlers are not run atomically, they may call methods of redis
+func (redis *Redis) HandleScript(script string, handler ScriptHandler) {
+
+	redis.mutex.Lock()
+	defer redis.mutex.Unlock()
+
+	redis.scripts[script] = handler
+}
+
+// HandleStringsScript : Run handler when script is evaluated by EvalStrings. Handlers are not run atomically, they may call methods of redis
+func (redis *Redis) HandleStringsScript(script string, handler StringsScriptHandler) {
+
+	redis.mutex.Lock()
+	defer redis.mutex.Unlock()
+
+	redis.stringsScripts[script] = handler
+}
+
+// CloseConnection : Nothing to close, keys are kept
+func (redis *Redis) CloseConnection() error {
+	return nil
+}
+
+// Ping : In-memory Redis is always up
+func (redis *Redis) Ping(ctx context.Context) error {
+	return nil
+}
+
+// Get : Get value of key
+func (redis *Redis) Get(ctx context.Context, key string) ([]byte, error) {
+
+	defer redis.lock()()
+
+	value, ok := redis.values[key]
+
+	if !ok {
+		return nil, fmt.Errorf("error getting key %s : %v", key, ErrNil)
+	}
+
+	return append([]byte{}, value...), nil
+}
+
+// HGet : Get field of hash key
+func (redis *Redis) HGet(ctx context.Context, key string, field string) ([]byte, error) {
+
+	defer redis.lock()()
+
+	value, ok := redis.hashes[key][field]
+
+	if !ok {
+		return nil, fmt.Errorf("error getting key %s : %v", key, ErrNil)
+	}
+
+	return append([]byte{}, value...), nil
+}
+
+// BatchHGet : Get field of keys, values being ordered as keys and nil for missing keys or fields
+func (redis *Redis) BatchHGet(ctx context.Context, keys []string, field string) ([][]byte, error) {
+
+	defer redis.lock()()
+
+	values := make([][]byte, len(keys))
+
+	for i, key := range keys {
+		if value, ok := redis.hashes[key][field]; ok {
+			values[i] = append([]byte{}, value...)
+		}
+	}
+
+	return values, nil
+}
+
+// BatchExists : Check if keys exist, results being ordered as keys
+func (redis *Redis) BatchExists(ctx context.Context, keys []string) ([]bool, error) {
+
+	defer redis.lock()()
+
+	exist := make([]bool, len(keys))
+
+	for i, key := range keys {
+		exist[i] = redis.exists(key)
+	}
+
+	return exist, nil
+}
+
+// Pipeline : Run GET, HGET and EXISTS commands, replies being ordered as commands and typed as with Redis.
+// Other commands fail, as part of a *models.BatchError
+func (redis *Redis) Pipeline(ctx context.Context, commands []models.RedisCommand) ([]interface{}, error) {
+
+	defer redis.lock()()
+
+	replies := make([]interface{}, len(commands))
+	batchErr := &models.BatchError{Errors: map[int]error{}}
+
+	for i, command := range commands {
+
+		switch {
+
+		case strings.EqualFold(command.Name, "GET") && len(command.Args) == 0:
+			if value, ok := redis.values[command.Key]; ok {
+				replies[i] = append([]byte{}, value...)
+			}
+
+		case strings.EqualFold(command.Name, "HGET") && len(command.Args) == 1:
+			if value, ok := redis.hashes[command.Key][fmt.Sprintf("%s", command.Args[0])]; ok {
+				replies[i] = append([]byte{}, value...)
+			}
+
+		case strings.EqualFold(command.Name, "EXISTS") && len(command.Args) == 0:
+			replies[i] = int64(0)
+
+			if redis.exists(command.Key) {
+				replies[i] = int64(1)
+			}
+
+		default:
+			batchErr.Errors[i] = fmt.Errorf("error running %s on key %s : command not supported in memory", command.Name, command.Key)
+		}
+	}
+
+	if len(batchErr.Errors) > 0 {
+		return replies, batchErr
+	}
+
+	return replies, nil
+}
+
+// HSet : Set two fields of hash key
+func (redis *Redis) HSet(ctx context.Context, key string, field1 string, value1 []byte, field2 string, value2 []byte) error {
+
+	defer redis.lock()()
+
+	if _, ok := redis.values[key]; ok {
+		return fmt.Errorf("error setting key %s to %s : WRONGTYPE", key, value1)
+	}
+
+	if _, ok := redis.hashes[key]; !ok {
+		redis.hashes[key] = map[string][]byte{}
+	}
+
+	redis.hashes[key][field1] = append([]byte{}, value1...)
+	redis.hashes[key][field2] = append([]byte{}, value2...)
+
+	return nil
+}
+
+// HDel : Remove fields of hash key, the key being removed with its last field
+func (redis *Redis) HDel(ctx context.Context, key string, fields ...string) error {
+
+	defer redis.lock()()
+
+	for _, field := range fields {
+		delete(redis.hashes[key], field)
+	}
+
+	if len(redis.hashes[key]) == 0 {
+		redis.remove(key)
+	}
+
+	return nil
+}
+
+// Set : Set value of key, removing its expiry
+func (redis *Redis) Set(ctx context.Context, key string, value []byte) error {
+
+	defer redis.lock()()
+
+	redis.remove(key)
+	redis.values[key] = append([]byte{}, value...)
+
+	return nil
+}
+
+// SetEx : Set value of key, removed in seconds
+func (redis *Redis) SetEx(ctx context.Context, key string, value []byte, seconds int) error {
+
+	defer redis.lock()()
+
+	redis.remove(key)
+	redis.values[key] = append([]byte{}, value...)
+	redis.expiries[key] = time.Now().Add(time.Duration(seconds) * time.Second)
+
+	return nil
+}
+
+// Rename : Rename oldKey to newKey, replacing newKey
+func (redis *Redis) Rename(ctx context.Context, oldKey string, newKey string) error {
+
+	defer redis.lock()()
+
+	if !redis.exists(oldKey) {
+		return fmt.Errorf("error renaming key %s to %s : no such key", oldKey, newKey)
+	}
+
+	value, isValue := redis.values[oldKey]
+	hash := redis.hashes[oldKey]
+	expiry, expires := redis.expiries[oldKey]
+
+	redis.remove(oldKey)
+	redis.remove(newKey)
+
+	if isValue {
+		redis.values[newKey] = value
+	} else {
+		redis.hashes[newKey] = hash
+	}
+
+	if expires {
+		redis.expiries[newKey] = expiry
+	}
+
+	return nil
+}
+
+// Exists : Check if key exists
+func (redis *Redis) Exists(ctx context.Context, key string) (bool, error) {
+
+	defer redis.lock()()
+
+	return redis.exists(key), nil
+}
+
+// Delete : Remove key
+func (redis *Redis) Delete(ctx context.Context, key string) error {
+
+	defer redis.lock()()
+
+	redis.remove(key)
+
+	return nil
+}
+
+// GetKeys : Get keys matching glob-style pattern (*, ? and character classes, as with SCAN)
+func (redis *Redis) GetKeys(ctx context.Context, pattern string) ([]string, error) {
+
+	expression, err := regexp.Compile(globExpression(pattern))
+
+	if err != nil {
+		return nil, fmt.Errorf("error retrieving '%s' keys", pattern)
+	}
+
+	defer redis.lock()()
+
+	keys := []string{}
+
+	for _, key := range redis.keys() {
+		if expression.MatchString(key) {
+			keys = append(keys, key)
+		}
+	}
+
+	return keys, nil
+}
+
+// Incr : Increment integer value of key, created at 0, returning its new value
+func (redis *Redis) Incr(ctx context.Context, counterKey string) (int, error) {
+
+	defer redis.lock()()
+
+	count := 0
+
+	if value, ok := redis.values[counterKey]; ok {
+
+		parsed, err := strconv.Atoi(string(value))
+
+		if err != nil {
+			return 0, fmt.Errorf("error incrementing key %s : value is not an integer", counterKey)
+		}
+
+		count = parsed
+	} else if redis.exists(counterKey) {
+		return 0, fmt.Errorf("error incrementing key %s : WRONGTYPE", counterKey)
+	}
+
+	count++
+	redis.values[counterKey] = []byte(strconv.Itoa(count))
+
+	return count, nil
+}
+
+// Expire : Remove key in seconds, keys which do not exist being left out
+func (redis *Redis) Expire(ctx context.Context, key string, seconds int) error {
+
+	defer redis.lock()()
+
+	if redis.exists(key) {
+		redis.expiries[key] = time.Now().Add(time.Duration(seconds) * time.Second)
+	}
+
+	return nil
+}
+
+// EvalInts : Run the handler of script on keys (See HandleScript)
+func (redis *Redis) EvalInts(ctx context.Context, script string, keys []string, args ...interface{}) ([]int, error) {
+
+	redis.mutex.Lock()
+	handler, ok := redis.scripts[script]
+	redis.mutex.Unlock()
+
+	if !ok {
+		return nil, fmt.Errorf("error evaluating script on keys %v : no handler registered for script", keys)
+	}
+
+	return handler(redis, keys, args...)
+}
+
+// EvalStrings : Run the handler of script on keys (See HandleStringsScript)
+func (redis *Redis) EvalStrings(ctx context.Context, script string, keys []string, args ...interface{}) ([]string, error) {
+
+	redis.mutex.Lock()
+	handler, ok := redis.stringsScripts[script]
+	redis.mutex.Unlock()
+
+	if !ok {
+		return nil, fmt.Errorf("error evaluating script on keys %v : no handler registered for script", keys)
+	}
+
+	return handler(redis, keys, args...)
+}
+
+// lock : Lock keys, removing expired ones, returning the function unlocking them
+func (redis *Redis) lock() func() {
+
+	redis.mutex.Lock()
+
+	now := time.Now()
+
+	for key, expiry := range redis.expiries {
+		if !expiry.After(now) {
+			redis.remove(key)
+		}
+	}
+
+	return redis.mutex.Unlock
+}
+
+// exists : Check if key exists. Keys must be locked
+func (redis *Redis) exists(key string) bool {
+
+	if _, ok := redis.values[key]; ok {
+		return true
+	}
+
+	_, ok := redis.hashes[key]
+
+	return ok
+}
+
+// remove : Remove key and its expiry. Keys must be locked
+func (redis *Redis) remove(key string) {
+	delete(redis.values, key)
+	delete(redis.hashes, key)
+	delete(redis.expiries, key)
+}
+
+// keys : Return all keys. Keys must be locked
+func (redis *Redis) keys() []string {
+
+	keys := make([]string, 0, len(redis.values)+len(redis.hashes))
+
+	for key := range redis.values {
+		keys = append(keys, key)
+	}
+
+	for key := range redis.hashes {
+		keys = append(keys, key)
+	}
+
+	return keys
+}
+
+// globExpression : Return regular expression of glob-style pattern
+func globExpression(pattern string) string {
+
+	var expression strings.Builder
+
+	expression.WriteString("^")
+
+	for i := 0; i < len(pattern); i++ {
+
+		switch pattern[i] {
+
+		case '*':
+			expression.WriteString(".*")
+
+		case '?':
+			expression.WriteString(".")
+
+		case '[', ']':
+			expression.WriteByte(pattern[i])
+
+		case '\\':
+			if i+1 < len(pattern) {
+				i++
+				expression.WriteString(regexp.QuoteMeta(string(pattern[i])))
+			}
+
+		default:
+			expression.WriteString(regexp.QuoteMeta(string(pattern[i])))
+		}
+	}
+
+	expression.WriteString("$")
+
+	return expression.String()
+}
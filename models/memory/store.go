@@ -0,0 +1,1479 @@
+package memory
+
+import (
+	context "context"
+	errors "errors"
+	fmt "fmt"
+	sort "sort"
+	sync "sync"
+	time "time"
+	models "wave-messaging-management-service/models"
+	utils "wave-messaging-management-service/utils"
+)
+
+var (
+	// ErrNotFound : No record matches the lookup, returned where MongoDB returns mongo.ErrNoDocuments
+	ErrNotFound = errors.New("Record not found")
+
+	// Store and Redis are checked against the interfaces they stand in for
+	_ models.Store          = (*Store)(nil)
+	_ models.RedisInterface = (*Redis)(nil)
+)
+
+// Store : In-memory models.Store, for unit tests of handlers and of services embedding the management service.
+// Records are copied in and out, so that callers can't change them behind the store. Every operation holds a single lock,
+// mutations and their outbox events being written atomically like in a transaction. Change streams are not supported
+type Store struct {
+	data     *storeData
+	TenantID string
+}
+
+// storeData : Records of a Store, shared by its tenant views (See ForTenant)
+type storeData struct {
+	mutex                   sync.Mutex
+	acls                    map[string]*models.VerneMQACL
+	deletedACLs             map[string]*models.DeletedACL
+	groupConversations      map[string]map[string]*models.GroupConversation
+	pushTokens              map[string]map[string]*models.PushToken
+	notificationPreferences map[string]map[string]*models.NotificationPreferences
+	quotaOverrides          map[string]map[string]*models.QuotaOverride
+	apiKeys                 map[string]*models.APIKey
+	usage                   map[string]*models.UsageRecord
+	auditLog                []*models.AuditEntry
+	webhooks                map[string]map[string]*models.Webhook
+	webhookDeliveries       map[string]*models.WebhookDelivery
+	webhookDeadLetters      map[string]*models.WebhookDeadLetter
+	outbox                  map[string]*models.OutboxEvent
+	migrations              map[int]*models.MigrationRecord
+}
+
+// NewStore : Return a new empty in-memory Store, scoped to the default tenant
+func NewStore() *Store {
+	return &Store{
+		data: &storeData{
+			acls:                    map[string]*models.VerneMQACL{},
+			deletedACLs:             map[string]*models.DeletedACL{},
+			groupConversations:      map[string]map[string]*models.GroupConversation{},
+			pushTokens:              map[string]map[string]*models.PushToken{},
+			notificationPreferences: map[string]map[string]*models.NotificationPreferences{},
+			quotaOverrides:          map[string]map[string]*models.QuotaOverride{},
+			apiKeys:                 map[string]*models.APIKey{},
+			usage:                   map[string]*models.UsageRecord{},
+			webhooks:                map[string]map[string]*models.Webhook{},
+			webhookDeliveries:       map[string]*models.WebhookDelivery{},
+			webhookDeadLetters:      map[string]*models.WebhookDeadLetter{},
+			outbox:                  map[string]*models.OutboxEvent{},
+			migrations:              map[int]*models.MigrationRecord{},
+		},
+	}
+}
+
+// ForTenant : Return view of the store scoped to tenantID. Records shared by tenants with MongoDB (VerneMQ ACLs and their tombstones, API keys, usage,
+// audit log, webhook deliveries, dead letters and outbox) are shared by the views too
+func (store *Store) ForTenant(tenantID string) models.Store {
+	return &Store{data: store.data, TenantID: tenantID}
+}
+
+// Ping : In-memory store is always up
+func (store *Store) Ping(ctx context.Context) error {
+	return nil
+}
+
+// Close : Nothing to close, records are kept
+func (store *Store) Close() error {
+	return nil
+}
+
+// EnsureIndexes : In-memory store has no index
+func (store *Store) EnsureIndexes(ctx context.Context) error {
+	return nil
+}
+
+// ExpireTombstones : In-memory store has no TTL index, models.ErrNotSupported is returned
+func (store *Store) ExpireTombstones(ctx context.Context, expireAfter time.Duration) error {
+	return models.ErrNotSupported
+}
+
+// WatchChanges : Change streams are not supported by the in-memory store, models.ErrNotSupported is returned
+func (store *Store) WatchChanges(ctx context.Context, resumeToken []byte, handle func(change *models.DataChange)) ([]byte, error) {
+	return resumeToken, models.ErrNotSupported
+}
+
+// lock : Lock records of the store, returning the function unlocking them
+func (store *Store) lock() func() {
+	store.data.mutex.Lock()
+	return store.data.mutex.Unlock
+}
+
+// CreateGroupConversations : Add group conversations, and grant access to their members under groupTopicPath, along with events
+func (store *Store) CreateGroupConversations(ctx context.Context, groupConversations []*models.GroupConversation, groupTopicPath string, events ...*models.LifecycleEvent) error {
+
+	defer store.lock()()
+
+	conversations := store.tenantGroupConversations()
+
+	for _, groupConversation := range groupConversations {
+		if _, ok := conversations[groupConversation.GroupConversationID]; ok {
+			return fmt.Errorf("error creating group conversation %s : duplicate ID", groupConversation.GroupConversationID)
+		}
+	}
+
+	outboxEvents, err := newOutboxEvents(events)
+
+	if err != nil {
+		return err
+	}
+
+	for _, groupConversation := range groupConversations {
+
+		conversations[groupConversation.GroupConversationID] = copyGroupConversation(groupConversation)
+
+		for _, userID := range groupConversation.Members {
+			for _, acl := range store.data.acls {
+
+				if acl.Username != userID {
+					continue
+				}
+
+				acl.PublishACL = addPatterns(acl.PublishACL, &models.ACL{Pattern: groupTopicPath + groupConversation.GroupConversationID + "/" + userID})
+				acl.SubscribeACL = addPatterns(acl.SubscribeACL, &models.ACL{Pattern: groupTopicPath + groupConversation.GroupConversationID + "/+"})
+			}
+		}
+	}
+
+	store.addOutboxEvents(outboxEvents)
+
+	return nil
+}
+
+// GetGroupConversation : Get group conversation, ErrNotFound if it does not exist or was removed
+func (store *Store) GetGroupConversation(ctx context.Context, groupConversationID string) (*models.GroupConversation, error) {
+
+	defer store.lock()()
+
+	groupConversation, ok := store.tenantGroupConversations()[groupConversationID]
+
+	if !ok || groupConversation.DeletedAt != nil {
+		return nil, ErrNotFound
+	}
+
+	return copyGroupConversation(groupConversation), nil
+}
+
+// CountCreatedGroupConversations : Count group conversations created by userID
+func (store *Store) CountCreatedGroupConversations(ctx context.Context, userID string) (int, error) {
+
+	defer store.lock()()
+
+	count := 0
+
+	for _, groupConversation := range store.tenantGroupConversations() {
+		if groupConversation.CreatorID == userID && groupConversation.DeletedAt == nil {
+			count++
+		}
+	}
+
+	return count, nil
+}
+
+// CountGroupMemberships : Count group conversations userID is a member of
+func (store *Store) CountGroupMemberships(ctx context.Context, userID string) (int, error) {
+
+	defer store.lock()()
+
+	return len(store.groupMemberships(userID)), nil
+}
+
+// GetGroupMemberships : Get page of group conversations userID is a member of sorted by name, and their total count
+func (store *Store) GetGroupMemberships(ctx context.Context, userID string, pagination *utils.Pagination) ([]*models.GroupConversation, int, error) {
+
+	defer store.lock()()
+
+	memberships := store.groupMemberships(userID)
+
+	sort.Slice(memberships, func(i, j int) bool {
+
+		if memberships[i].Name != memberships[j].Name {
+			return memberships[i].Name < memberships[j].Name
+		}
+
+		return memberships[i].GroupConversationID < memberships[j].GroupConversationID
+	})
+
+	start, end := pageBounds(len(memberships), pagination)
+	groupConversations := []*models.GroupConversation{}
+
+	for _, groupConversation := range memberships[start:end] {
+		groupConversations = append(groupConversations, copyGroupConversation(groupConversation))
+	}
+
+	return groupConversations, len(memberships), nil
+}
+
+// RemoveGroupConversation : Mark group conversation removed at deletedAt and revoke access of its members under groupTopicPath along with events.
+// Return the removed group conversation, nil if it does not exist or was already removed
+func (store *Store) RemoveGroupConversation(ctx context.Context, groupConversationID string, groupTopicPath string, deletedAt time.Time, events ...*models.LifecycleEvent) (*models.GroupConversation, error) {
+
+	defer store.lock()()
+
+	groupConversation, ok := store.tenantGroupConversations()[groupConversationID]
+
+	if !ok || groupConversation.DeletedAt != nil {
+		return nil, nil
+	}
+
+	outboxEvents, err := newOutboxEvents(events)
+
+	if err != nil {
+		return nil, err
+	}
+
+	removedAt := deletedAt
+	groupConversation.DeletedAt = &removedAt
+
+	subscribePattern := groupTopicPath + groupConversationID + "/+"
+
+	for _, userID := range groupConversation.Members {
+
+		publishPattern := groupTopicPath + groupConversationID + "/" + userID
+
+		// Tombstones are updated too, so that restoring them doesn't grant access to the removed group conversation again
+		for _, acl := range store.data.acls {
+			if acl.Username == userID {
+				acl.PublishACL = removePatterns(acl.PublishACL, publishPattern)
+				acl.SubscribeACL = removePatterns(acl.SubscribeACL, subscribePattern)
+			}
+		}
+
+		for _, deletedACL := range store.data.deletedACLs {
+			if deletedACL.Username == userID {
+				deletedACL.PublishACL = removePatterns(deletedACL.PublishACL, publishPattern)
+				deletedACL.SubscribeACL = removePatterns(deletedACL.SubscribeACL, subscribePattern)
+			}
+		}
+	}
+
+	store.addOutboxEvents(outboxEvents)
+
+	return copyGroupConversation(groupConversation), nil
+}
+
+// RestoreGroupConversation : Unmark removed group conversation and grant access to its members under groupTopicPath again along with events.
+// Return the restored group conversation, nil if it does not exist or was not removed
+func (store *Store) RestoreGroupConversation(ctx context.Context, groupConversationID string, groupTopicPath string, events ...*models.LifecycleEvent) (*models.GroupConversation, error) {
+
+	defer store.lock()()
+
+	groupConversation, ok := store.tenantGroupConversations()[groupConversationID]
+
+	if !ok || groupConversation.DeletedAt == nil {
+		return nil, nil
+	}
+
+	outboxEvents, err := newOutboxEvents(events)
+
+	if err != nil {
+		return nil, err
+	}
+
+	groupConversation.DeletedAt = nil
+
+	for _, userID := range groupConversation.Members {
+		for _, acl := range store.data.acls {
+			if acl.Username == userID {
+				acl.PublishACL = addPatterns(acl.PublishACL, &models.ACL{Pattern: groupTopicPath + groupConversationID + "/" + userID})
+				acl.SubscribeACL = addPatterns(acl.SubscribeACL, &models.ACL{Pattern: groupTopicPath + groupConversationID + "/+"})
+			}
+		}
+	}
+
+	store.addOutboxEvents(outboxEvents)
+
+	return copyGroupConversation(groupConversation), nil
+}
+
+// PurgeGroupConversations : Remove group conversations of every tenant for good, when removed before deletedBefore. Return the number purged
+func (store *Store) PurgeGroupConversations(ctx context.Context, deletedBefore time.Time) (int, error) {
+
+	defer store.lock()()
+
+	purged := 0
+
+	for _, conversations := range store.data.groupConversations {
+		for groupConversationID, groupConversation := range conversations {
+			if groupConversation.DeletedAt != nil && groupConversation.DeletedAt.Before(deletedBefore) {
+				delete(conversations, groupConversationID)
+				purged++
+			}
+		}
+	}
+
+	return purged, nil
+}
+
+// AddProfileACL : Upsert VerneMQ ACL by client ID along with events, merging its patterns with the ones of an existing ACL
+func (store *Store) AddProfileACL(ctx context.Context, verneMQACL *models.VerneMQACL, events ...*models.LifecycleEvent) error {
+
+	defer store.lock()()
+
+	outboxEvents, err := newOutboxEvents(events)
+
+	if err != nil {
+		return err
+	}
+
+	acl := copyACL(verneMQACL)
+
+	if existing, ok := store.data.acls[acl.ClientID]; ok {
+		acl.PublishACL = addPatterns(existing.PublishACL, acl.PublishACL...)
+		acl.SubscribeACL = addPatterns(existing.SubscribeACL, acl.SubscribeACL...)
+	}
+
+	store.data.acls[acl.ClientID] = acl
+	store.addOutboxEvents(outboxEvents)
+
+	return nil
+}
+
+// GetProfileACL : Get main VerneMQ ACL of userID, ErrNotFound if it does not exist
+func (store *Store) GetProfileACL(ctx context.Context, userID string) (*models.VerneMQACL, error) {
+
+	defer store.lock()()
+
+	acl, ok := store.data.acls[userID]
+
+	if !ok || acl.Username != userID {
+		return nil, ErrNotFound
+	}
+
+	return copyACL(acl), nil
+}
+
+// GetClientACL : Get VerneMQ ACL of MQTT client ID, ErrNotFound if it does not exist
+func (store *Store) GetClientACL(ctx context.Context, clientID string) (*models.VerneMQACL, error) {
+
+	defer store.lock()()
+
+	acl, ok := store.data.acls[clientID]
+
+	if !ok {
+		return nil, ErrNotFound
+	}
+
+	return copyACL(acl), nil
+}
+
+// GetUserACLs : Get all VerneMQ ACLs of userID (Main profile and devices)
+func (store *Store) GetUserACLs(ctx context.Context, userID string) ([]*models.VerneMQACL, error) {
+
+	defer store.lock()()
+
+	return store.findACLs(func(acl *models.VerneMQACL) bool { return acl.Username == userID }), nil
+}
+
+// RemoveDeviceACL : Move VerneMQ ACL of one of userID devices to tombstones along with events. Main profile ACL can't be removed this way
+func (store *Store) RemoveDeviceACL(ctx context.Context, userID string, deviceClientID string, events ...*models.LifecycleEvent) error {
+
+	if deviceClientID == userID {
+		return fmt.Errorf("error removing device %s : main profile can't be removed", deviceClientID)
+	}
+
+	defer store.lock()()
+
+	acl, ok := store.data.acls[deviceClientID]
+
+	if !ok || acl.Username != userID {
+		return fmt.Errorf("error removing device %s : no such device for user %s", deviceClientID, userID)
+	}
+
+	outboxEvents, err := newOutboxEvents(events)
+
+	if err != nil {
+		return err
+	}
+
+	store.moveToTombstone(acl, time.Now().UTC())
+	store.addOutboxEvents(outboxEvents)
+
+	return nil
+}
+
+// RemoveUserACLs : Move all VerneMQ ACLs of userID to tombstones along with events
+func (store *Store) RemoveUserACLs(ctx context.Context, userID string, events ...*models.LifecycleEvent) error {
+
+	return store.removeACLs(events, time.Now().UTC(), func(acl *models.VerneMQACL) bool { return acl.Username == userID })
+}
+
+// GetExpiredACLs : Get VerneMQ ACLs expired at now
+func (store *Store) GetExpiredACLs(ctx context.Context, now time.Time) ([]*models.VerneMQACL, error) {
+
+	defer store.lock()()
+
+	return store.findACLs(func(acl *models.VerneMQACL) bool { return isExpired(acl, now) }), nil
+}
+
+// RenewACLs : Push back expiry date of all VerneMQ ACLs of userID, returning false if user has no ACL left
+func (store *Store) RenewACLs(ctx context.Context, userID string, expiresAt time.Time) (bool, error) {
+
+	defer store.lock()()
+
+	renewed := false
+
+	for _, acl := range store.data.acls {
+
+		if acl.Username != userID {
+			continue
+		}
+
+		renewedAt := expiresAt
+		acl.ExpiresAt = &renewedAt
+		renewed = true
+	}
+
+	return renewed, nil
+}
+
+// RemoveExpiredACLs : Move VerneMQ ACLs expired at now to tombstones along with events, ACLs without expiry date being kept
+func (store *Store) RemoveExpiredACLs(ctx context.Context, now time.Time, events ...*models.LifecycleEvent) error {
+
+	return store.removeACLs(events, now, func(acl *models.VerneMQACL) bool { return isExpired(acl, now) })
+}
+
+// AuthorizePublishing : Authorize publishing on MQTT topic for userID (On all its devices), unless already authorized
+func (store *Store) AuthorizePublishing(ctx context.Context, userID string, topic string) error {
+
+	defer store.lock()()
+
+	for _, acl := range store.data.acls {
+		if acl.Username == userID {
+			acl.PublishACL = addPatterns(acl.PublishACL, &models.ACL{Pattern: topic})
+		}
+	}
+
+	return nil
+}
+
+// UpdatePassHash : Update passhash of all VerneMQ ACLs of userID along with events
+func (store *Store) UpdatePassHash(ctx context.Context, userID string, newPasshash string, events ...*models.LifecycleEvent) error {
+
+	defer store.lock()()
+
+	outboxEvents, err := newOutboxEvents(events)
+
+	if err != nil {
+		return err
+	}
+
+	for _, acl := range store.data.acls {
+		if acl.Username == userID {
+			acl.Passhash = newPasshash
+		}
+	}
+
+	store.addOutboxEvents(outboxEvents)
+
+	return nil
+}
+
+// DedupACLPatterns : Remove duplicate patterns of VerneMQ ACLs, returning the number of ACLs deduplicated
+func (store *Store) DedupACLPatterns(ctx context.Context) (int, error) {
+
+	defer store.lock()()
+
+	deduplicated := 0
+
+	for _, acl := range store.data.acls {
+
+		publishACL := addPatterns(nil, acl.PublishACL...)
+		subscribeACL := addPatterns(nil, acl.SubscribeACL...)
+
+		if len(publishACL) == len(acl.PublishACL) && len(subscribeACL) == len(acl.SubscribeACL) {
+			continue
+		}
+
+		acl.PublishACL = publishACL
+		acl.SubscribeACL = subscribeACL
+		deduplicated++
+	}
+
+	return deduplicated, nil
+}
+
+// GetDeletedACLs : Get tombstones of the removed VerneMQ ACLs of userID
+func (store *Store) GetDeletedACLs(ctx context.Context, userID string) ([]*models.DeletedACL, error) {
+
+	defer store.lock()()
+
+	deletedACLs := []*models.DeletedACL{}
+
+	for _, deletedACL := range store.data.deletedACLs {
+		if deletedACL.Username == userID {
+			deletedACLs = append(deletedACLs, &models.DeletedACL{VerneMQACL: *copyACL(&deletedACL.VerneMQACL), DeletedAt: deletedACL.DeletedAt})
+		}
+	}
+
+	return deletedACLs, nil
+}
+
+// RestoreUserACLs : Move tombstones of the removed VerneMQ ACLs of userID back to VerneMQ ACLs along with events,
+// tombstones of client IDs having an ACL again being left as they are. Return the number of ACLs restored
+func (store *Store) RestoreUserACLs(ctx context.Context, userID string, events ...*models.LifecycleEvent) (int, error) {
+
+	defer store.lock()()
+
+	outboxEvents, err := newOutboxEvents(events)
+
+	if err != nil {
+		return 0, err
+	}
+
+	restored := 0
+
+	for clientID, deletedACL := range store.data.deletedACLs {
+
+		if deletedACL.Username != userID {
+			continue
+		}
+
+		if _, ok := store.data.acls[clientID]; ok {
+			continue
+		}
+
+		store.data.acls[clientID] = copyACL(&deletedACL.VerneMQACL)
+		delete(store.data.deletedACLs, clientID)
+		restored++
+	}
+
+	store.addOutboxEvents(outboxEvents)
+
+	return restored, nil
+}
+
+// PurgeDeletedACLs : Remove tombstones of VerneMQ ACLs removed before deletedBefore for good. Return the number purged
+func (store *Store) PurgeDeletedACLs(ctx context.Context, deletedBefore time.Time) (int, error) {
+
+	defer store.lock()()
+
+	purged := 0
+
+	for clientID, deletedACL := range store.data.deletedACLs {
+		if deletedACL.DeletedAt.Before(deletedBefore) {
+			delete(store.data.deletedACLs, clientID)
+			purged++
+		}
+	}
+
+	return purged, nil
+}
+
+// AddPushToken : Add device push token, replacing an existing entry of the same user with the same token.
+// models.ErrPushTokenTaken if another user registered it
+func (store *Store) AddPushToken(ctx context.Context, pushToken *models.PushToken) error {
+
+	defer store.lock()()
+
+	pushTokens := store.tenantPushTokens()
+
+	if existing, ok := pushTokens[pushToken.Token]; ok && existing.UserID != pushToken.UserID {
+		return models.ErrPushTokenTaken
+	}
+
+	copied := *pushToken
+	pushTokens[pushToken.Token] = &copied
+
+	return nil
+}
+
+// RemovePushToken : Remove device push token of userID
+func (store *Store) RemovePushToken(ctx context.Context, userID string, token string) error {
+
+	defer store.lock()()
+
+	pushTokens := store.tenantPushTokens()
+	pushToken, ok := pushTokens[token]
+
+	if !ok || pushToken.UserID != userID {
+		return fmt.Errorf("error removing push token : no such token for user %s", userID)
+	}
+
+	delete(pushTokens, token)
+
+	return nil
+}
+
+// GetPushTokens : Get all devices push tokens of userID
+func (store *Store) GetPushTokens(ctx context.Context, userID string) ([]*models.PushToken, error) {
+
+	defer store.lock()()
+
+	pushTokens := []*models.PushToken{}
+
+	for _, pushToken := range store.tenantPushTokens() {
+		if pushToken.UserID == userID {
+			copied := *pushToken
+			pushTokens = append(pushTokens, &copied)
+		}
+	}
+
+	return pushTokens, nil
+}
+
+// GetNotificationPreferences : Get push notifications settings of userID, defaults are returned if none were set
+func (store *Store) GetNotificationPreferences(ctx context.Context, userID string) (*models.NotificationPreferences, error) {
+
+	defer store.lock()()
+
+	preferences, ok := store.tenantNotificationPreferences()[userID]
+
+	if !ok {
+		return models.NewNotificationPreferences(userID), nil
+	}
+
+	return copyNotificationPreferences(preferences), nil
+}
+
+// SetNotificationPreferences : Replace push notifications settings of user
+func (store *Store) SetNotificationPreferences(ctx context.Context, preferences *models.NotificationPreferences) error {
+
+	defer store.lock()()
+
+	store.tenantNotificationPreferences()[preferences.UserID] = copyNotificationPreferences(preferences)
+
+	return nil
+}
+
+// GetQuotaOverride : Get quotas of userID overriding configured ones, nil if there is none
+func (store *Store) GetQuotaOverride(ctx context.Context, userID string) (*models.QuotaOverride, error) {
+
+	defer store.lock()()
+
+	override, ok := store.tenantQuotaOverrides()[userID]
+
+	if !ok {
+		return nil, nil
+	}
+
+	copied := *override
+
+	return &copied, nil
+}
+
+// SetQuotaOverride : Replace quotas of user overriding configured ones
+func (store *Store) SetQuotaOverride(ctx context.Context, override *models.QuotaOverride) error {
+
+	defer store.lock()()
+
+	copied := *override
+	store.tenantQuotaOverrides()[override.UserID] = &copied
+
+	return nil
+}
+
+// AddAPIKey : Add hashed API key of an internal backend service
+func (store *Store) AddAPIKey(ctx context.Context, apiKey *models.APIKey) error {
+
+	defer store.lock()()
+
+	if _, ok := store.data.apiKeys[apiKey.HashedKey]; ok {
+		return errors.New("error adding API key : duplicate key")
+	}
+
+	copied := *apiKey
+	copied.Scopes = append([]string{}, apiKey.Scopes...)
+	store.data.apiKeys[apiKey.HashedKey] = &copied
+
+	return nil
+}
+
+// GetAPIKey : Get API key matching hashedKey, ErrNotFound if there is none
+func (store *Store) GetAPIKey(ctx context.Context, hashedKey string) (*models.APIKey, error) {
+
+	defer store.lock()()
+
+	apiKey, ok := store.data.apiKeys[hashedKey]
+
+	if !ok {
+		return nil, ErrNotFound
+	}
+
+	copied := *apiKey
+	copied.Scopes = append([]string{}, apiKey.Scopes...)
+
+	return &copied, nil
+}
+
+// AddUsage : Add count to metric counter of userID on date, creating its usage record if needed
+func (store *Store) AddUsage(ctx context.Context, date string, tenantID string, userID string, metric string, count int) error {
+
+	defer store.lock()()
+
+	key := date + ":" + tenantID + ":" + userID
+	record, ok := store.data.usage[key]
+
+	if !ok {
+		record = &models.UsageRecord{Date: date, TenantID: tenantID, UserID: userID}
+	}
+
+	switch metric {
+
+	case models.UsageACLs:
+		record.ACLs += int64(count)
+
+	case models.UsageConversationsCreated:
+		record.ConversationsCreated += int64(count)
+
+	case models.UsageMessagesStored:
+		record.MessagesStored += int64(count)
+
+	case models.UsagePushesSent:
+		record.PushesSent += int64(count)
+
+	default:
+		return errors.New("Unknown usage metric " + metric)
+	}
+
+	store.data.usage[key] = record
+
+	return nil
+}
+
+// GetUsage : Get usage records matching filter
+func (store *Store) GetUsage(ctx context.Context, filter *models.UsageFilter) ([]*models.UsageRecord, error) {
+
+	defer store.lock()()
+
+	records := []*models.UsageRecord{}
+
+	for _, record := range store.data.usage {
+
+		if record.Date < filter.From || record.Date > filter.To {
+			continue
+		}
+
+		if filter.TenantID != nil && record.TenantID != *filter.TenantID {
+			continue
+		}
+
+		if filter.UserID != "" && record.UserID != filter.UserID {
+			continue
+		}
+
+		copied := *record
+		records = append(records, &copied)
+	}
+
+	return records, nil
+}
+
+// AddAuditEntry : Append entry to the audit log
+func (store *Store) AddAuditEntry(ctx context.Context, entry *models.AuditEntry) error {
+
+	defer store.lock()()
+
+	store.data.auditLog = append(store.data.auditLog, copyAuditEntry(entry))
+
+	return nil
+}
+
+// GetAuditEntries : Return page of audit entries matching filter, most recent first, and number of matching entries
+func (store *Store) GetAuditEntries(ctx context.Context, filter *models.AuditFilter) ([]*models.AuditEntry, int, error) {
+
+	defer store.lock()()
+
+	matching := []*models.AuditEntry{}
+
+	for _, entry := range store.data.auditLog {
+		if matchesAuditFilter(entry, filter) {
+			matching = append(matching, entry)
+		}
+	}
+
+	sort.SliceStable(matching, func(i, j int) bool { return matching[i].Timestamp.After(matching[j].Timestamp) })
+
+	start, end := pageBounds(len(matching), filter.Page)
+	entries := []*models.AuditEntry{}
+
+	for _, entry := range matching[start:end] {
+		entries = append(entries, copyAuditEntry(entry))
+	}
+
+	return entries, len(matching), nil
+}
+
+// AddWebhook : Add outbound webhook
+func (store *Store) AddWebhook(ctx context.Context, webhook *models.Webhook) error {
+
+	defer store.lock()()
+
+	webhooks := store.tenantWebhooks()
+
+	if _, ok := webhooks[webhook.ID]; ok {
+		return fmt.Errorf("error adding webhook %s : duplicate ID", webhook.ID)
+	}
+
+	webhooks[webhook.ID] = copyWebhook(webhook)
+
+	return nil
+}
+
+// GetWebhooks : Get outbound webhooks, oldest first
+func (store *Store) GetWebhooks(ctx context.Context) ([]*models.Webhook, error) {
+
+	defer store.lock()()
+
+	webhooks := []*models.Webhook{}
+
+	for _, webhook := range store.tenantWebhooks() {
+		webhooks = append(webhooks, copyWebhook(webhook))
+	}
+
+	sort.Slice(webhooks, func(i, j int) bool { return webhooks[i].CreatedAt.Before(webhooks[j].CreatedAt) })
+
+	return webhooks, nil
+}
+
+// GetWebhook : Get outbound webhook webhookID, nil if it does not exist
+func (store *Store) GetWebhook(ctx context.Context, webhookID string) (*models.Webhook, error) {
+
+	defer store.lock()()
+
+	webhook, ok := store.tenantWebhooks()[webhookID]
+
+	if !ok {
+		return nil, nil
+	}
+
+	return copyWebhook(webhook), nil
+}
+
+// RemoveWebhook : Remove outbound webhook webhookID, its deliveries and dead letters, returning false if it does not exist
+func (store *Store) RemoveWebhook(ctx context.Context, webhookID string) (bool, error) {
+
+	defer store.lock()()
+
+	webhooks := store.tenantWebhooks()
+
+	if _, ok := webhooks[webhookID]; !ok {
+		return false, nil
+	}
+
+	delete(webhooks, webhookID)
+
+	for id, delivery := range store.data.webhookDeliveries {
+		if delivery.WebhookID == webhookID {
+			delete(store.data.webhookDeliveries, id)
+		}
+	}
+
+	for id, deadLetter := range store.data.webhookDeadLetters {
+		if deadLetter.WebhookID == webhookID {
+			delete(store.data.webhookDeadLetters, id)
+		}
+	}
+
+	return true, nil
+}
+
+// AddWebhookDelivery : Add delivery of a lifecycle event to an outbound webhook
+func (store *Store) AddWebhookDelivery(ctx context.Context, delivery *models.WebhookDelivery) error {
+
+	defer store.lock()()
+
+	if _, ok := store.data.webhookDeliveries[delivery.ID]; ok {
+		return fmt.Errorf("error adding webhook delivery %s : duplicate ID", delivery.ID)
+	}
+
+	store.data.webhookDeliveries[delivery.ID] = copyWebhookDelivery(delivery)
+
+	return nil
+}
+
+// UpdateWebhookDelivery : Replace delivery of a lifecycle event with its current status
+func (store *Store) UpdateWebhookDelivery(ctx context.Context, delivery *models.WebhookDelivery) error {
+
+	defer store.lock()()
+
+	if _, ok := store.data.webhookDeliveries[delivery.ID]; ok {
+		store.data.webhookDeliveries[delivery.ID] = copyWebhookDelivery(delivery)
+	}
+
+	return nil
+}
+
+// GetWebhookDeliveries : Get page of deliveries of webhookID, most recent first, and their total count
+func (store *Store) GetWebhookDeliveries(ctx context.Context, webhookID string, pagination *utils.Pagination) ([]*models.WebhookDelivery, int, error) {
+
+	defer store.lock()()
+
+	matching := store.findWebhookDeliveries(func(delivery *models.WebhookDelivery) bool { return delivery.WebhookID == webhookID })
+
+	sort.Slice(matching, func(i, j int) bool { return matching[i].CreatedAt.After(matching[j].CreatedAt) })
+
+	start, end := pageBounds(len(matching), pagination)
+
+	return matching[start:end], len(matching), nil
+}
+
+// GetDueWebhookDeliveries : Get up to limit pending webhook deliveries due for an attempt at now and not leased, most overdue first
+func (store *Store) GetDueWebhookDeliveries(ctx context.Context, now time.Time, limit int) ([]*models.WebhookDelivery, error) {
+
+	defer store.lock()()
+
+	due := store.findWebhookDeliveries(func(delivery *models.WebhookDelivery) bool {
+		return delivery.Status == models.WebhookDeliveryPending && delivery.NextAttemptAt != nil &&
+			!delivery.NextAttemptAt.After(now) && !delivery.LeaseUntil.After(now)
+	})
+
+	sort.Slice(due, func(i, j int) bool { return due[i].NextAttemptAt.Before(*due[j].NextAttemptAt) })
+
+	if len(due) > limit {
+		due = due[:limit]
+	}
+
+	return due, nil
+}
+
+// ClaimWebhookDelivery : Lease pending webhook delivery until leaseUntil, unless it is leased beyond now or not pending anymore
+func (store *Store) ClaimWebhookDelivery(ctx context.Context, deliveryID string, now time.Time, leaseUntil time.Time) (bool, error) {
+
+	defer store.lock()()
+
+	delivery, ok := store.data.webhookDeliveries[deliveryID]
+
+	if !ok || delivery.Status != models.WebhookDeliveryPending || delivery.LeaseUntil.After(now) {
+		return false, nil
+	}
+
+	delivery.LeaseUntil = leaseUntil
+
+	return true, nil
+}
+
+// AddWebhookDeadLetter : Add delivery abandoned after its last attempt
+func (store *Store) AddWebhookDeadLetter(ctx context.Context, deadLetter *models.WebhookDeadLetter) error {
+
+	defer store.lock()()
+
+	store.data.webhookDeadLetters[deadLetter.ID] = copyWebhookDeadLetter(deadLetter)
+
+	return nil
+}
+
+// GetWebhookDeadLetter : Get dead letter deadLetterID, nil if it does not exist
+func (store *Store) GetWebhookDeadLetter(ctx context.Context, deadLetterID string) (*models.WebhookDeadLetter, error) {
+
+	defer store.lock()()
+
+	deadLetter, ok := store.data.webhookDeadLetters[deadLetterID]
+
+	if !ok {
+		return nil, nil
+	}
+
+	return copyWebhookDeadLetter(deadLetter), nil
+}
+
+// GetWebhookDeadLetters : Return page of dead letters matching filter, most recent first, and number of matching dead letters
+func (store *Store) GetWebhookDeadLetters(ctx context.Context, filter *models.WebhookDeadLetterFilter) ([]*models.WebhookDeadLetter, int, error) {
+
+	defer store.lock()()
+
+	matching := []*models.WebhookDeadLetter{}
+
+	for _, deadLetter := range store.data.webhookDeadLetters {
+
+		if deadLetter.TenantID != filter.TenantID || (filter.WebhookID != "" && deadLetter.WebhookID != filter.WebhookID) {
+			continue
+		}
+
+		matching = append(matching, copyWebhookDeadLetter(deadLetter))
+	}
+
+	sort.Slice(matching, func(i, j int) bool { return matching[i].DeadLetteredAt.After(matching[j].DeadLetteredAt) })
+
+	start, end := pageBounds(len(matching), filter.Page)
+
+	return matching[start:end], len(matching), nil
+}
+
+// RemoveWebhookDeadLetter : Remove dead letter deadLetterID, returning false if it does not exist
+func (store *Store) RemoveWebhookDeadLetter(ctx context.Context, deadLetterID string) (bool, error) {
+
+	defer store.lock()()
+
+	if _, ok := store.data.webhookDeadLetters[deadLetterID]; !ok {
+		return false, nil
+	}
+
+	delete(store.data.webhookDeadLetters, deadLetterID)
+
+	return true, nil
+}
+
+// GetOutboxEvents : Get up to limit outbox events of all tenants not leased at now, oldest first
+func (store *Store) GetOutboxEvents(ctx context.Context, now time.Time, limit int) ([]*models.OutboxEvent, error) {
+
+	defer store.lock()()
+
+	outboxEvents := []*models.OutboxEvent{}
+
+	for _, outboxEvent := range store.data.outbox {
+		if !outboxEvent.LeaseUntil.After(now) {
+			copied := *outboxEvent
+			outboxEvents = append(outboxEvents, &copied)
+		}
+	}
+
+	sort.Slice(outboxEvents, func(i, j int) bool { return outboxEvents[i].CreatedAt.Before(outboxEvents[j].CreatedAt) })
+
+	if len(outboxEvents) > limit {
+		outboxEvents = outboxEvents[:limit]
+	}
+
+	return outboxEvents, nil
+}
+
+// ClaimOutboxEvent : Lease outbox event until leaseUntil, unless it is leased beyond now or relayed already
+func (store *Store) ClaimOutboxEvent(ctx context.Context, eventID string, now time.Time, leaseUntil time.Time) (bool, error) {
+
+	defer store.lock()()
+
+	outboxEvent, ok := store.data.outbox[eventID]
+
+	if !ok || outboxEvent.LeaseUntil.After(now) {
+		return false, nil
+	}
+
+	outboxEvent.LeaseUntil = leaseUntil
+
+	return true, nil
+}
+
+// RemoveOutboxEvent : Remove relayed outbox event
+func (store *Store) RemoveOutboxEvent(ctx context.Context, eventID string) error {
+
+	defer store.lock()()
+
+	delete(store.data.outbox, eventID)
+
+	return nil
+}
+
+// OutboxEvents : Return outbox events not relayed yet, leased or not, oldest first, so that tests check events written along with mutations
+func (store *Store) OutboxEvents() []*models.OutboxEvent {
+
+	defer store.lock()()
+
+	outboxEvents := []*models.OutboxEvent{}
+
+	for _, outboxEvent := range store.data.outbox {
+		copied := *outboxEvent
+		outboxEvents = append(outboxEvents, &copied)
+	}
+
+	sort.Slice(outboxEvents, func(i, j int) bool { return outboxEvents[i].CreatedAt.Before(outboxEvents[j].CreatedAt) })
+
+	return outboxEvents
+}
+
+// GetMigrations : Get schema migrations claimed or applied, by increasing version
+func (store *Store) GetMigrations(ctx context.Context) ([]*models.MigrationRecord, error) {
+
+	defer store.lock()()
+
+	migrations := []*models.MigrationRecord{}
+
+	for _, migration := range store.data.migrations {
+		copied := *migration
+		migrations = append(migrations, &copied)
+	}
+
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+
+	return migrations, nil
+}
+
+// ClaimMigration : Lease migration of version until leaseUntil, unless it was applied or is leased beyond now
+func (store *Store) ClaimMigration(ctx context.Context, version int, name string, now time.Time, leaseUntil time.Time) (bool, error) {
+
+	defer store.lock()()
+
+	if migration, ok := store.data.migrations[version]; ok {
+		if migration.AppliedAt != nil || migration.LeaseUntil == nil || migration.LeaseUntil.After(now) {
+			return false, nil
+		}
+	}
+
+	store.data.migrations[version] = &models.MigrationRecord{Version: version, Name: name, StartedAt: now, LeaseUntil: &leaseUntil}
+
+	return true, nil
+}
+
+// CompleteMigration : Record migration of version as applied at now, releasing its lease
+func (store *Store) CompleteMigration(ctx context.Context, version int, now time.Time) error {
+
+	defer store.lock()()
+
+	if migration, ok := store.data.migrations[version]; ok {
+		migration.AppliedAt = &now
+		migration.LeaseUntil = nil
+	}
+
+	return nil
+}
+
+// tenantGroupConversations : Return group conversations of the tenant of store, by ID. Records must be locked
+func (store *Store) tenantGroupConversations() map[string]*models.GroupConversation {
+
+	if _, ok := store.data.groupConversations[store.TenantID]; !ok {
+		store.data.groupConversations[store.TenantID] = map[string]*models.GroupConversation{}
+	}
+
+	return store.data.groupConversations[store.TenantID]
+}
+
+// tenantPushTokens : Return push tokens of the tenant of store, by token. Records must be locked
+func (store *Store) tenantPushTokens() map[string]*models.PushToken {
+
+	if _, ok := store.data.pushTokens[store.TenantID]; !ok {
+		store.data.pushTokens[store.TenantID] = map[string]*models.PushToken{}
+	}
+
+	return store.data.pushTokens[store.TenantID]
+}
+
+// tenantNotificationPreferences : Return notification preferences of the tenant of store, by user ID. Records must be locked
+func (store *Store) tenantNotificationPreferences() map[string]*models.NotificationPreferences {
+
+	if _, ok := store.data.notificationPreferences[store.TenantID]; !ok {
+		store.data.notificationPreferences[store.TenantID] = map[string]*models.NotificationPreferences{}
+	}
+
+	return store.data.notificationPreferences[store.TenantID]
+}
+
+// tenantQuotaOverrides : Return quota overrides of the tenant of store, by user ID. Records must be locked
+func (store *Store) tenantQuotaOverrides() map[string]*models.QuotaOverride {
+
+	if _, ok := store.data.quotaOverrides[store.TenantID]; !ok {
+		store.data.quotaOverrides[store.TenantID] = map[string]*models.QuotaOverride{}
+	}
+
+	return store.data.quotaOverrides[store.TenantID]
+}
+
+// tenantWebhooks : Return outbound webhooks of the tenant of store, by ID. Records must be locked
+func (store *Store) tenantWebhooks() map[string]*models.Webhook {
+
+	if _, ok := store.data.webhooks[store.TenantID]; !ok {
+		store.data.webhooks[store.TenantID] = map[string]*models.Webhook{}
+	}
+
+	return store.data.webhooks[store.TenantID]
+}
+
+// groupMemberships : Return group conversations userID is a member of, not copied. Removed ones are left out. Records must be locked
+func (store *Store) groupMemberships(userID string) []*models.GroupConversation {
+
+	memberships := []*models.GroupConversation{}
+
+	for _, groupConversation := range store.tenantGroupConversations() {
+
+		if groupConversation.DeletedAt != nil {
+			continue
+		}
+
+		for _, member := range groupConversation.Members {
+			if member == userID {
+				memberships = append(memberships, groupConversation)
+				break
+			}
+		}
+	}
+
+	return memberships
+}
+
+// findACLs : Return copies of VerneMQ ACLs matching match. Records must be locked
+func (store *Store) findACLs(match func(acl *models.VerneMQACL) bool) []*models.VerneMQACL {
+
+	acls := []*models.VerneMQACL{}
+
+	for _, acl := range store.data.acls {
+		if match(acl) {
+			acls = append(acls, copyACL(acl))
+		}
+	}
+
+	return acls
+}
+
+// removeACLs : Move VerneMQ ACLs matching match to tombstones removed at deletedAt, along with events
+func (store *Store) removeACLs(events []*models.LifecycleEvent, deletedAt time.Time, match func(acl *models.VerneMQACL) bool) error {
+
+	defer store.lock()()
+
+	outboxEvents, err := newOutboxEvents(events)
+
+	if err != nil {
+		return err
+	}
+
+	for _, acl := range store.data.acls {
+		if match(acl) {
+			store.moveToTombstone(acl, deletedAt)
+		}
+	}
+
+	store.addOutboxEvents(outboxEvents)
+
+	return nil
+}
+
+// moveToTombstone : Move VerneMQ ACL to tombstones removed at deletedAt, replacing the one of a previous removal of its client ID. Records must be locked
+func (store *Store) moveToTombstone(acl *models.VerneMQACL, deletedAt time.Time) {
+	store.data.deletedACLs[acl.ClientID] = &models.DeletedACL{VerneMQACL: *acl, DeletedAt: deletedAt}
+	delete(store.data.acls, acl.ClientID)
+}
+
+// findWebhookDeliveries : Return copies of webhook deliveries matching match. Records must be locked
+func (store *Store) findWebhookDeliveries(match func(delivery *models.WebhookDelivery) bool) []*models.WebhookDelivery {
+
+	deliveries := []*models.WebhookDelivery{}
+
+	for _, delivery := range store.data.webhookDeliveries {
+		if match(delivery) {
+			deliveries = append(deliveries, copyWebhookDelivery(delivery))
+		}
+	}
+
+	return deliveries
+}
+
+// addOutboxEvents : Add outbox events, written along with a mutation. Records must be locked
+func (store *Store) addOutboxEvents(outboxEvents []*models.OutboxEvent) {
+	for _, outboxEvent := range outboxEvents {
+		store.data.outbox[outboxEvent.ID] = outboxEvent
+	}
+}
+
+// newOutboxEvents : Return outbox events of lifecycle events
+func newOutboxEvents(events []*models.LifecycleEvent) ([]*models.OutboxEvent, error) {
+
+	outboxEvents := make([]*models.OutboxEvent, 0, len(events))
+
+	for _, event := range events {
+
+		outboxEvent, err := models.NewOutboxEvent(event)
+
+		if err != nil {
+			return nil, err
+		}
+
+		outboxEvents = append(outboxEvents, outboxEvent)
+	}
+
+	return outboxEvents, nil
+}
+
+// addPatterns : Return patterns with added ones, those already in patterns being left out (As with $addToSet)
+func addPatterns(patterns []*models.ACL, added ...*models.ACL) []*models.ACL {
+
+	for _, pattern := range added {
+
+		found := false
+
+		for _, existing := range patterns {
+			if existing.Pattern == pattern.Pattern {
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			patterns = append(patterns, &models.ACL{Pattern: pattern.Pattern})
+		}
+	}
+
+	return patterns
+}
+
+// removePatterns : Return patterns without the removed ones (As with $pull)
+func removePatterns(patterns []*models.ACL, removed ...string) []*models.ACL {
+
+	kept := []*models.ACL{}
+
+	for _, pattern := range patterns {
+
+		found := false
+
+		for _, removedPattern := range removed {
+			if pattern.Pattern == removedPattern {
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			kept = append(kept, pattern)
+		}
+	}
+
+	return kept
+}
+
+// isExpired : Check if acl has an expiry date, reached at now
+func isExpired(acl *models.VerneMQACL, now time.Time) bool {
+	return acl.ExpiresAt != nil && !acl.ExpiresAt.After(now)
+}
+
+// matchesAuditFilter : Check if entry matches filter
+func matchesAuditFilter(entry *models.AuditEntry, filter *models.AuditFilter) bool {
+
+	if filter.ActorType != "" && entry.Actor.Type != filter.ActorType {
+		return false
+	}
+
+	if filter.ActorID != "" && entry.Actor.ID != filter.ActorID {
+		return false
+	}
+
+	if filter.Target != "" && entry.Target != filter.Target {
+		return false
+	}
+
+	if filter.Action != "" && entry.Action != filter.Action {
+		return false
+	}
+
+	if filter.TenantID != nil && entry.TenantID != *filter.TenantID {
+		return false
+	}
+
+	if filter.From != nil && entry.Timestamp.Before(*filter.From) {
+		return false
+	}
+
+	return filter.To == nil || !entry.Timestamp.After(*filter.To)
+}
+
+// pageBounds : Return bounds of the page of pagination among total records
+func pageBounds(total int, pagination *utils.Pagination) (int, int) {
+
+	start := pagination.Offset
+
+	if start > total {
+		start = total
+	}
+
+	end := start + pagination.Limit
+
+	if end > total {
+		end = total
+	}
+
+	return start, end
+}
+
+// copyACL : Return copy of acl, patterns included
+func copyACL(acl *models.VerneMQACL) *models.VerneMQACL {
+
+	copied := *acl
+	copied.PublishACL = addPatterns(nil, acl.PublishACL...)
+	copied.SubscribeACL = addPatterns(nil, acl.SubscribeACL...)
+
+	if acl.ExpiresAt != nil {
+		expiresAt := *acl.ExpiresAt
+		copied.ExpiresAt = &expiresAt
+	}
+
+	return &copied
+}
+
+// copyGroupConversation : Return copy of groupConversation, members and removal date included
+func copyGroupConversation(groupConversation *models.GroupConversation) *models.GroupConversation {
+
+	copied := *groupConversation
+	copied.Members = append([]string{}, groupConversation.Members...)
+
+	if groupConversation.DeletedAt != nil {
+		deletedAt := *groupConversation.DeletedAt
+		copied.DeletedAt = &deletedAt
+	}
+
+	return &copied
+}
+
+// copyNotificationPreferences : Return copy of preferences, quiet hours and conversation overrides included
+func copyNotificationPreferences(preferences *models.NotificationPreferences) *models.NotificationPreferences {
+
+	copied := *preferences
+	copied.ConversationOverrides = []*models.ConversationOverride{}
+
+	if preferences.QuietHours != nil {
+		quietHours := *preferences.QuietHours
+		copied.QuietHours = &quietHours
+	}
+
+	for _, override := range preferences.ConversationOverrides {
+		copiedOverride := *override
+		copied.ConversationOverrides = append(copied.ConversationOverrides, &copiedOverride)
+	}
+
+	return &copied
+}
+
+// copyAuditEntry : Return copy of entry, details included
+func copyAuditEntry(entry *models.AuditEntry) *models.AuditEntry {
+
+	copied := *entry
+
+	if entry.Details != nil {
+
+		copied.Details = map[string]string{}
+
+		for key, value := range entry.Details {
+			copied.Details[key] = value
+		}
+	}
+
+	return &copied
+}
+
+// copyWebhook : Return copy of webhook, events included
+func copyWebhook(webhook *models.Webhook) *models.Webhook {
+
+	copied := *webhook
+	copied.Events = append([]string{}, webhook.Events...)
+
+	return &copied
+}
+
+// copyWebhookDelivery : Return copy of delivery, payload and dates included
+func copyWebhookDelivery(delivery *models.WebhookDelivery) *models.WebhookDelivery {
+
+	copied := *delivery
+	copied.Payload = append([]byte{}, delivery.Payload...)
+
+	if delivery.NextAttemptAt != nil {
+		nextAttemptAt := *delivery.NextAttemptAt
+		copied.NextAttemptAt = &nextAttemptAt
+	}
+
+	if delivery.DeliveredAt != nil {
+		deliveredAt := *delivery.DeliveredAt
+		copied.DeliveredAt = &deliveredAt
+	}
+
+	return &copied
+}
+
+// copyWebhookDeadLetter : Return copy of deadLetter, payload included. Its event is decoded from its payload by readers, as with MongoDB
+func copyWebhookDeadLetter(deadLetter *models.WebhookDeadLetter) *models.WebhookDeadLetter {
+
+	copied := *deadLetter
+	copied.Event = nil
+	copied.Payload = append([]byte{}, deadLetter.Payload...)
+
+	return &copied
+}
@@ -0,0 +1,134 @@
+package models
+
+import (
+	time "time"
+
+	prometheus "github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	// MetricsNamespace : Prefix of the service Prometheus metrics
+	MetricsNamespace = "wave"
+
+	// DatastoreMongoDB : Datastore label of MongoDB operations
+	DatastoreMongoDB = "mongodb"
+
+	// DatastorePostgreSQL : Datastore label of PostgreSQL operations
+	DatastorePostgreSQL = "postgresql"
+
+	// DatastoreRedis : Datastore label of Redis operations
+	DatastoreRedis = "redis"
+)
+
+var (
+	// HTTPRequests : Handled requests, per handler, method and status code
+	HTTPRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: MetricsNamespace,
+		Name:      "http_requests_total",
+		Help:      "Handled HTTP requests, per handler, method and status code",
+	}, []string{"handler", "method", "code"})
+
+	// HTTPRequestDuration : Requests handling duration, per handler
+	HTTPRequestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: MetricsNamespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "HTTP requests handling duration, per handler",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"handler"})
+
+	// AuthCacheLookups : Auth cache lookups of authenticated tokens, per result (hit or miss)
+	AuthCacheLookups = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: MetricsNamespace,
+		Name:      "auth_cache_lookups_total",
+		Help:      "Auth cache lookups, per result (hit or miss)",
+	}, []string{"result"})
+
+	// DatastoreOperationDuration : MongoDB and Redis operations duration, per datastore and operation
+	DatastoreOperationDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: MetricsNamespace,
+		Name:      "datastore_operation_duration_seconds",
+		Help:      "MongoDB and Redis operations duration, per datastore and operation",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"datastore", "operation"})
+
+	// DatastoreRetries : Retries of datastore operations failing with transient errors, per datastore, operation and result
+	// (retried for each retry, recovered or exhausted once the operation succeeded or was given up after retries)
+	DatastoreRetries = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: MetricsNamespace,
+		Name:      "datastore_retries_total",
+		Help:      "Retries of datastore operations failing with transient errors, per datastore, operation and result",
+	}, []string{"datastore", "operation", "result"})
+
+	// ACLMutations : Successful VerneMQ ACLs mutations, per operation
+	ACLMutations = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: MetricsNamespace,
+		Name:      "acl_mutations_total",
+		Help:      "Successful VerneMQ ACLs mutations, per operation",
+	}, []string{"operation"})
+
+	// LifecycleEvents : Lifecycle events delivered, per transport, type and result (produced or failed)
+	LifecycleEvents = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: MetricsNamespace,
+		Name:      "lifecycle_events_total",
+		Help:      "Lifecycle events delivered, per transport, type and result",
+	}, []string{"transport", "type", "result"})
+
+	// NotificationJobs : Push notification jobs of the RabbitMQ queue, per type and result (queued, unqueued, dispatched or rejected)
+	NotificationJobs = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: MetricsNamespace,
+		Name:      "notification_jobs_total",
+		Help:      "Push notification jobs of the RabbitMQ queue, per type and result",
+	}, []string{"type", "result"})
+
+	// WebhookDeliveries : Delivery attempts of lifecycle events to outbound webhooks, per event type and result (delivered, failed, deadLettered or postponed)
+	WebhookDeliveries = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: MetricsNamespace,
+		Name:      "webhook_deliveries_total",
+		Help:      "Delivery attempts of lifecycle events to outbound webhooks, per event type and result",
+	}, []string{"type", "result"})
+
+	// OutboxEvents : Lifecycle events taken out of the transactional outbox, per event type and result (relayed or failed)
+	OutboxEvents = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: MetricsNamespace,
+		Name:      "outbox_events_total",
+		Help:      "Lifecycle events taken out of the transactional outbox, per event type and result",
+	}, []string{"type", "result"})
+
+	// DataChanges : Changes of VerneMQ ACLs and group conversations received from the MongoDB change stream, per collection and operation
+	DataChanges = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: MetricsNamespace,
+		Name:      "data_changes_total",
+		Help:      "Changes of VerneMQ ACLs and group conversations received from the MongoDB change stream, per collection and operation",
+	}, []string{"collection", "operation"})
+
+	// DependencyUp : Whether a dependency answered the last ping of the dependencies monitor (1) or not (0), per dependency
+	DependencyUp = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: MetricsNamespace,
+		Name:      "dependency_up",
+		Help:      "Whether a dependency answered the last ping of the dependencies monitor, per dependency",
+	}, []string{"dependency"})
+
+	// DependencyDegraded : Whether a dependency was down or slow on the last ping of the dependencies monitor (1) or not (0), per dependency
+	DependencyDegraded = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: MetricsNamespace,
+		Name:      "dependency_degraded",
+		Help:      "Whether a dependency was down or slow on the last ping of the dependencies monitor, per dependency",
+	}, []string{"dependency"})
+)
+
+// RegisterMetrics : Register the service metrics to the default Prometheus registry, exposed on /metrics
+func RegisterMetrics() {
+	prometheus.MustRegister(HTTPRequests, HTTPRequestDuration, AuthCacheLookups, DatastoreOperationDuration, DatastoreRetries, ACLMutations, LifecycleEvents, NotificationJobs, WebhookDeliveries, OutboxEvents, DataChanges, DependencyUp, DependencyDegraded)
+}
+
+// observeDatastore : Record duration of a datastore operation started at start
+func observeDatastore(datastore string, operation string, start time.Time) {
+	DatastoreOperationDuration.WithLabelValues(datastore, operation).Observe(time.Since(start).Seconds())
+}
+
+// countACLMutation : Count successful VerneMQ ACLs mutation
+func countACLMutation(operation string, err error) {
+	if err == nil {
+		ACLMutations.WithLabelValues(operation).Inc()
+	}
+}
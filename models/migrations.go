@@ -0,0 +1,26 @@
+package models
+
+import (
+	time "time"
+)
+
+const (
+	// DefaultMigrationsLeaseTimeout : Seconds an instance has to apply the migration it claimed, used when none is configured
+	DefaultMigrationsLeaseTimeout = 3600
+)
+
+// MigrationsConfig : Schema migrations Config. Pending migrations are applied at startup when RunAtStartup is set,
+// each one being leased for LeaseTimeout seconds by the instance applying it, so that a migration of a stopped instance is applied by another one
+type MigrationsConfig struct {
+	RunAtStartup bool `json:"runAtStartup"`
+	LeaseTimeout int  `json:"leaseTimeout"`
+}
+
+// MigrationRecord : Schema migration claimed or applied, identified by its version. AppliedAt is nil until the migration completed
+type MigrationRecord struct {
+	Version    int        `json:"version" bson:"_id"`
+	Name       string     `json:"name" bson:"name"`
+	StartedAt  time.Time  `json:"startedAt" bson:"startedAt"`
+	LeaseUntil *time.Time `json:"-" bson:"leaseUntil,omitempty"`
+	AppliedAt  *time.Time `json:"appliedAt,omitempty" bson:"appliedAt,omitempty"`
+}
@@ -2,16 +2,24 @@ package models
 
 import (
 	context "context"
+	tls "crypto/tls"
+	x509 "crypto/x509"
+	errors "errors"
+	fmt "fmt"
+	ioutil "io/ioutil"
+	strings "strings"
+	time "time"
 	utils "wave-messaging-management-service/utils"
 
-	mongoBSON "github.com/mongodb/mongo-go-driver/bson"
-	mongo "github.com/mongodb/mongo-go-driver/mongo"
-	bson "gopkg.in/mgo.v2/bson"
+	bson "go.mongodb.org/mongo-driver/bson"
+	mongo "go.mongodb.org/mongo-driver/mongo"
+	options "go.mongodb.org/mongo-driver/mongo/options"
+	readpref "go.mongodb.org/mongo-driver/mongo/readpref"
 )
 
 const (
 
-	// WaveDatabaseName : Database name of the Wave project as defined in MongoDB
+	// WaveDatabaseName : Database name of the Wave project as defined in MongoDB, used when none is configured
 	WaveDatabaseName = "waveDB"
 
 	// PrivateConversationsCollection : MongoDB Collection containing private conversations backups
@@ -20,74 +28,1545 @@ const (
 	// VerneMQACLCollection : MongoDB Collection containing VerneMQ ACLs
 	VerneMQACLCollection = "vmq_acl_auth"
 
+	// DeletedACLsCollection : MongoDB Collection containing tombstones of removed VerneMQ ACLs, out of reach of the broker
+	DeletedACLsCollection = "deletedACLs"
+
 	// GroupConversationCollection : MongoDB Collection containing group private conversations backups
 	GroupConversationCollection = "groupConversations"
+
+	// PushTokensCollection : MongoDB Collection containing users devices push notification tokens
+	PushTokensCollection = "pushTokens"
+
+	// NotificationPreferencesCollection : MongoDB Collection containing users push notifications settings
+	NotificationPreferencesCollection = "notificationPreferences"
+
+	// APIKeysCollection : MongoDB Collection containing hashed API keys of internal backend services
+	APIKeysCollection = "apiKeys"
+
+	// QuotaOverridesCollection : MongoDB Collection containing users quotas overriding configured ones
+	QuotaOverridesCollection = "quotaOverrides"
+
+	// UsageCollection : MongoDB Collection containing daily usage counters of users
+	UsageCollection = "usage"
+
+	// AuditLogCollection : MongoDB Collection containing security relevant actions (Append-only)
+	AuditLogCollection = "auditLog"
+
+	// WebhooksCollection : MongoDB Collection containing outbound webhooks registered by integrators
+	WebhooksCollection = "webhooks"
+
+	// WebhookDeliveriesCollection : MongoDB Collection containing deliveries of lifecycle events to outbound webhooks
+	WebhookDeliveriesCollection = "webhookDeliveries"
+
+	// WebhookDeadLettersCollection : MongoDB Collection containing deliveries to outbound webhooks abandoned after their last attempt
+	WebhookDeadLettersCollection = "webhookDeadLetters"
+
+	// OutboxCollection : MongoDB Collection containing lifecycle events written along with their mutation, until relayed
+	OutboxCollection = "outbox"
+
+	// MigrationsCollection : MongoDB Collection containing schema migrations claimed or applied, by version
+	MigrationsCollection = "migrations"
 )
 
-// MongoDBInterface : MongoDB Communication interface
-type MongoDBInterface interface {
-	AddGroupConversation(groupConversation *GroupConversation) error
-	AddProfileACL(verneMQACL *VerneMQACL) error
-	AuthorizePublishing(userID string, topic string) error
-	UpdateProfilesWithGroupACL(groupConversation *GroupConversation) error
-	UpdatePassHash(userID string, newPasshash string) error
+// MongoDBConfig : MongoDB client Config, read at startup except operations timeouts (See DatastoreConfig) and retries.
+// URI holds hosts and URI options, connection settings (Milliseconds), Auth and TLS overriding the options of URI when set.
+// Database, CollectionPrefix and Collections name the database and collections of the service (See CollectionNames), so that environments share a cluster.
+// ReadPreferences (By collection name) and WriteConcerns (By operation class, see WriteClassACL) override the ones of URI too.
+// Operations failing with transient errors are retried up to MaxRetries times, after RetryBaseDelay milliseconds doubled after each retry up to RetryMaxDelay
+type MongoDBConfig struct {
+	DatastoreConfig
+	URI                    string                         `json:"uri"`
+	Database               string                         `json:"database"`
+	CollectionPrefix       string                         `json:"collectionPrefix"`
+	Collections            map[string]string              `json:"collections"`
+	ReplicaSet             string                         `json:"replicaSet"`
+	MaxPoolSize            uint64                         `json:"maxPoolSize"`
+	MinPoolSize            uint64                         `json:"minPoolSize"`
+	MaxConnIdleTime        int                            `json:"maxConnIdleTime"`
+	ConnectTimeout         int                            `json:"connectTimeout"`
+	SocketTimeout          int                            `json:"socketTimeout"`
+	ServerSelectionTimeout int                            `json:"serverSelectionTimeout"`
+	Auth                   MongoDBAuthConfig              `json:"auth"`
+	TLS                    DatastoreTLSConfig             `json:"tls"`
+	ReadPreferences        map[string]string              `json:"readPreferences"`
+	WriteConcerns          map[string]*WriteConcernConfig `json:"writeConcerns"`
+	MaxRetries             int                            `json:"maxRetries"`
+	RetryBaseDelay         int                            `json:"retryBaseDelay"`
+	RetryMaxDelay          int                            `json:"retryMaxDelay"`
+}
+
+// MongoDBAuthConfig : MongoDB authentication Config, used when a username is set (Or Mechanism is MONGODB-X509).
+// Source is the database holding the user (admin by default), Mechanism is negotiated with the server when empty (SCRAM).
+// Credentials are read from UsernameFile and PasswordFile when set, e.g. Kubernetes Secrets or secret stores mounted as files
+type MongoDBAuthConfig struct {
+	Mechanism    string `json:"mechanism"`
+	Source       string `json:"source"`
+	Username     string `json:"username"`
+	Password     string `json:"password"`
+	UsernameFile string `json:"usernameFile"`
+	PasswordFile string `json:"passwordFile"`
+}
+
+// DatastoreTLSConfig : MongoDB or Redis TLS Config, used when Enabled or when a certificate file is set.
+// Servers are verified against CAFile (System roots by default), CertFile and KeyFile hold the client certificate (Both in CertFile when KeyFile is empty)
+type DatastoreTLSConfig struct {
+	Enabled            bool   `json:"enabled"`
+	CAFile             string `json:"caFile"`
+	CertFile           string `json:"certFile"`
+	KeyFile            string `json:"keyFile"`
+	ServerName         string `json:"serverName"`
+	InsecureSkipVerify bool   `json:"insecureSkipVerify"`
+}
+
+// MongoDB : Store backed by MongoDB
+type MongoDB struct {
+	Client                            *mongo.Client
+	WaveDB                            *mongo.Database
+	PrivateConversationsCollection    *mongo.Collection
+	VerneMQACLCollection              *mongo.Collection
+	DeletedACLsCollection             *mongo.Collection
+	GroupConversationCollection       *mongo.Collection
+	PushTokensCollection              *mongo.Collection
+	NotificationPreferencesCollection *mongo.Collection
+	APIKeysCollection                 *mongo.Collection
+	QuotaOverridesCollection          *mongo.Collection
+	UsageCollection                   *mongo.Collection
+	AuditLogCollection                *mongo.Collection
+	WebhooksCollection                *mongo.Collection
+	WebhookDeliveriesCollection       *mongo.Collection
+	WebhookDeadLettersCollection      *mongo.Collection
+	OutboxCollection                  *mongo.Collection
+	MigrationsCollection              *mongo.Collection
+	collectionNames                   *CollectionNames
+	collectionsOptions                map[string]*options.CollectionOptions
+	transactionOptions                *options.TransactionOptions
+}
+
+// NewMongoDB : Return a new MongoDB abstraction struct, connected according to config
+func NewMongoDB(config *MongoDBConfig) *MongoDB {
+
+	// Get connection to DB, established in background by the driver
+	clientOptions, err := newClientOptions(config)
+
+	if err != nil {
+		utils.PanicOnError(err, "Failed to configure MongoDB client")
+	}
+
+	client, err := mongo.Connect(context.Background(), clientOptions)
+
+	if err != nil {
+		utils.PanicOnError(err, "Failed to connect to MongoDB")
+	}
+
+	// Read preferences and write concerns of collections
+	collectionsOptions, err := newCollectionsOptions(config)
+
+	if err != nil {
+		utils.PanicOnError(err, "Failed to configure MongoDB collections")
+	}
+
+	// Names of the database and collections, configured or from the environment
+	collectionNames, err := newCollectionNames(config)
+
+	if err != nil {
+		utils.PanicOnError(err, "Failed to configure MongoDB collections names")
+	}
+
+	// Get database reference
+	mongoDB := &MongoDB{
+		Client:             client,
+		WaveDB:             client.Database(config.DatabaseName()),
+		collectionNames:    collectionNames,
+		collectionsOptions: collectionsOptions,
+		transactionOptions: newTransactionOptions(config),
+	}
+
+	// Get collections references
+	mongoDB.PrivateConversationsCollection = mongoDB.collection("", PrivateConversationsCollection)
+	mongoDB.VerneMQACLCollection = mongoDB.collection("", VerneMQACLCollection)
+	mongoDB.DeletedACLsCollection = mongoDB.collection("", DeletedACLsCollection)
+	mongoDB.GroupConversationCollection = mongoDB.collection("", GroupConversationCollection)
+	mongoDB.PushTokensCollection = mongoDB.collection("", PushTokensCollection)
+	mongoDB.NotificationPreferencesCollection = mongoDB.collection("", NotificationPreferencesCollection)
+	mongoDB.APIKeysCollection = mongoDB.collection("", APIKeysCollection)
+	mongoDB.QuotaOverridesCollection = mongoDB.collection("", QuotaOverridesCollection)
+	mongoDB.UsageCollection = mongoDB.collection("", UsageCollection)
+	mongoDB.AuditLogCollection = mongoDB.collection("", AuditLogCollection)
+	mongoDB.WebhooksCollection = mongoDB.collection("", WebhooksCollection)
+	mongoDB.WebhookDeliveriesCollection = mongoDB.collection("", WebhookDeliveriesCollection)
+	mongoDB.WebhookDeadLettersCollection = mongoDB.collection("", WebhookDeadLettersCollection)
+	mongoDB.OutboxCollection = mongoDB.collection("", OutboxCollection)
+	mongoDB.MigrationsCollection = mongoDB.collection("", MigrationsCollection)
+
+	// Return new MongoDB abstraction struct
+	return mongoDB
+}
+
+// collection : Return reference to collection of name (Default name) of tenantID (Empty for shared collections and the default tenant),
+// with the read preference and write concern configured for name
+func (mongoDB *MongoDB) collection(tenantID string, name string) *mongo.Collection {
+
+	if collectionOptions, ok := mongoDB.collectionsOptions[name]; ok {
+		return mongoDB.WaveDB.Collection(mongoDB.collectionNames.Name(tenantID, name), collectionOptions)
+	}
+
+	return mongoDB.WaveDB.Collection(mongoDB.collectionNames.Name(tenantID, name))
+}
+
+// newClientOptions : Return options of the MongoDB client of config, settings of config overriding the options of its URI
+func newClientOptions(config *MongoDBConfig) (*options.ClientOptions, error) {
+
+	clientOptions := options.Client().ApplyURI(config.URI)
+
+	if config.ReplicaSet != "" {
+		clientOptions.SetReplicaSet(config.ReplicaSet)
+	}
+
+	if config.MaxPoolSize > 0 {
+		clientOptions.SetMaxPoolSize(config.MaxPoolSize)
+	}
+
+	if config.MinPoolSize > 0 {
+		clientOptions.SetMinPoolSize(config.MinPoolSize)
+	}
+
+	if config.MaxConnIdleTime > 0 {
+		clientOptions.SetMaxConnIdleTime(time.Duration(config.MaxConnIdleTime) * time.Millisecond)
+	}
+
+	if config.ConnectTimeout > 0 {
+		clientOptions.SetConnectTimeout(time.Duration(config.ConnectTimeout) * time.Millisecond)
+	}
+
+	if config.SocketTimeout > 0 {
+		clientOptions.SetSocketTimeout(time.Duration(config.SocketTimeout) * time.Millisecond)
+	}
+
+	if config.ServerSelectionTimeout > 0 {
+		clientOptions.SetServerSelectionTimeout(time.Duration(config.ServerSelectionTimeout) * time.Millisecond)
+	}
+
+	credential, err := newCredential(&config.Auth)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if credential != nil {
+		clientOptions.SetAuth(*credential)
+	}
+
+	tlsConfig, err := newDatastoreTLSConfig(&config.TLS)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if tlsConfig != nil {
+		clientOptions.SetTLSConfig(tlsConfig)
+	}
+
+	return clientOptions, nil
+}
+
+// newCredential : Return MongoDB credential of config, nil when authentication is left to the URI
+func newCredential(config *MongoDBAuthConfig) (*options.Credential, error) {
+
+	username, err := readSecret(config.Username, config.UsernameFile)
+
+	if err != nil {
+		return nil, err
+	}
+
+	password, err := readSecret(config.Password, config.PasswordFile)
+
+	if err != nil {
+		return nil, err
+	}
+
+	// X.509 users are authenticated by their client certificate, without password
+	if username == "" && config.Mechanism != "MONGODB-X509" {
+		return nil, nil
+	}
+
+	return &options.Credential{
+		AuthMechanism: config.Mechanism,
+		AuthSource:    config.Source,
+		Username:      username,
+		Password:      password,
+		PasswordSet:   password != "",
+	}, nil
+}
+
+// newDatastoreTLSConfig : Return TLS config of MongoDB or Redis connections, nil when TLS is left to the URI
+func newDatastoreTLSConfig(config *DatastoreTLSConfig) (*tls.Config, error) {
+
+	if !config.Enabled && config.CAFile == "" && config.CertFile == "" {
+		return nil, nil
+	}
+
+	tlsConfig := &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		ServerName:         config.ServerName,
+		InsecureSkipVerify: config.InsecureSkipVerify,
+	}
+
+	if config.CAFile != "" {
+
+		data, err := ioutil.ReadFile(config.CAFile)
+
+		if err != nil {
+			return nil, err
+		}
+
+		rootCAs := x509.NewCertPool()
+
+		if !rootCAs.AppendCertsFromPEM(data) {
+			return nil, errors.New("No CA certificate found in " + config.CAFile)
+		}
+
+		tlsConfig.RootCAs = rootCAs
+	}
+
+	if config.CertFile != "" {
+
+		keyFile := config.KeyFile
+
+		if keyFile == "" {
+			keyFile = config.CertFile
+		}
+
+		certificate, err := tls.LoadX509KeyPair(config.CertFile, keyFile)
+
+		if err != nil {
+			return nil, err
+		}
+
+		tlsConfig.Certificates = []tls.Certificate{certificate}
+	}
+
+	return tlsConfig, nil
+}
+
+// readSecret : Return content of file, without surrounding whitespaces, when set. Return value otherwise
+func readSecret(value string, file string) (string, error) {
+
+	if file == "" {
+		return value, nil
+	}
+
+	data, err := ioutil.ReadFile(file)
+
+	if err != nil {
+		return "", err
+	}
+
+	return strings.TrimSpace(string(data)), nil
+}
+
+// Ping : Check MongoDB answers before ctx is done
+func (mongoDB *MongoDB) Ping(ctx context.Context) error {
+	return mongoDB.Client.Ping(ctx, readpref.Primary())
+}
+
+// CheckTransactions : Return an error unless the deployment runs transactions, which write mutations along with their lifecycle events
+// (See withOutbox) : a replica set of MongoDB 4.0 or later, or a sharded cluster of MongoDB 4.2 or later. Standalone servers can't run them
+func (mongoDB *MongoDB) CheckTransactions(ctx context.Context) error {
+
+	hello := struct {
+		SetName        string `bson:"setName"`
+		Msg            string `bson:"msg"`
+		MaxWireVersion int    `bson:"maxWireVersion"`
+	}{}
+
+	// isMaster is understood by servers older than 4.4.2, which don't know hello
+	err := mongoDB.Client.Database("admin").RunCommand(ctx, bson.D{bson.E{Key: "hello", Value: 1}}).Decode(&hello)
+
+	if commandErr, ok := err.(mongo.CommandError); ok && commandErr.Name == "CommandNotFound" {
+		err = mongoDB.Client.Database("admin").RunCommand(ctx, bson.D{bson.E{Key: "isMaster", Value: 1}}).Decode(&hello)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	switch {
+
+	// Wire versions 7 and 8 are the ones of MongoDB 4.0 and 4.2
+	case hello.Msg == "isdbgrid" && hello.MaxWireVersion >= 8, hello.SetName != "" && hello.MaxWireVersion >= 7:
+		return nil
+
+	case hello.Msg == "isdbgrid" || hello.SetName != "":
+		return errors.New("MongoDB deployment is too old to run transactions : MongoDB 4.0 or later (4.2 for sharded clusters) is required")
+	}
+
+	return errors.New("MongoDB deployment is a standalone server, which can't run transactions : deploy it as a replica set (A single node one in development)")
+}
+
+// Close : Disconnect MongoDB client, once operations are completed
+func (mongoDB *MongoDB) Close() error {
+	return mongoDB.Client.Disconnect(context.Background())
+}
+
+// ForTenant : Return MongoDB abstraction struct scoped to tenantID.
+// Tenant data lives in {tenantID}_{collection} collections (See CollectionNames), VerneMQ ACLs and API keys are shared as the broker and API keys lookups are tenant agnostic.
+// Usage, audit log, webhook deliveries and outbox are shared too, their records hold their tenant so that they can be aggregated (Or relayed) across tenants
+func (mongoDB *MongoDB) ForTenant(tenantID string) Store {
+
+	tenantMongoDB := *mongoDB
+
+	tenantMongoDB.PrivateConversationsCollection = mongoDB.collection(tenantID, PrivateConversationsCollection)
+	tenantMongoDB.GroupConversationCollection = mongoDB.collection(tenantID, GroupConversationCollection)
+	tenantMongoDB.PushTokensCollection = mongoDB.collection(tenantID, PushTokensCollection)
+	tenantMongoDB.NotificationPreferencesCollection = mongoDB.collection(tenantID, NotificationPreferencesCollection)
+	tenantMongoDB.QuotaOverridesCollection = mongoDB.collection(tenantID, QuotaOverridesCollection)
+	tenantMongoDB.WebhooksCollection = mongoDB.collection(tenantID, WebhooksCollection)
+
+	return &tenantMongoDB
+}
+
+// CreateGroupConversations : Add group conversations entries in database with a single insert, and grant access to their members
+// under groupTopicPath, along with events in a single transaction (See updateProfilesWithGroupACLs)
+func (mongoDB *MongoDB) CreateGroupConversations(ctx context.Context, groupConversations []*GroupConversation, groupTopicPath string, events ...*LifecycleEvent) error {
+
+	if len(groupConversations) == 0 {
+		return nil
+	}
+
+	docs := make([]interface{}, 0, len(groupConversations))
+
+	for _, groupConversation := range groupConversations {
+		docs = append(docs, groupConversation)
+	}
+
+	return mongoDB.withOutbox(ctx, events, func(ctx context.Context) error {
+
+		_, err := mongoDB.GroupConversationCollection.InsertMany(ctx, docs)
+
+		if err != nil {
+			return err
+		}
+
+		return mongoDB.updateProfilesWithGroupACLs(ctx, groupConversations, groupTopicPath)
+	})
+}
+
+// GetGroupConversation : Get group conversation entry from database, unless removed
+func (mongoDB *MongoDB) GetGroupConversation(ctx context.Context, groupConversationID string) (*GroupConversation, error) {
+
+	groupConversation := &GroupConversation{}
+
+	err := mongoDB.GroupConversationCollection.FindOne(
+		ctx,
+		bson.M{"groupConversationID": groupConversationID, "deletedAt": bson.M{"$exists": false}},
+	).Decode(groupConversation)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return groupConversation, nil
+}
+
+// CountCreatedGroupConversations : Count group conversations created by userID
+func (mongoDB *MongoDB) CountCreatedGroupConversations(ctx context.Context, userID string) (int, error) {
+
+	count, err := mongoDB.GroupConversationCollection.CountDocuments(
+		ctx,
+		bson.M{"creatorID": userID, "deletedAt": bson.M{"$exists": false}},
+	)
+
+	if err != nil {
+		return 0, err
+	}
+
+	return int(count), nil
+}
+
+// CountGroupMemberships : Count group conversations userID is a member of
+func (mongoDB *MongoDB) CountGroupMemberships(ctx context.Context, userID string) (int, error) {
+
+	count, err := mongoDB.GroupConversationCollection.CountDocuments(
+		ctx,
+		bson.M{"members": userID, "deletedAt": bson.M{"$exists": false}},
+	)
+
+	if err != nil {
+		return 0, err
+	}
+
+	return int(count), nil
+}
+
+// GetGroupMemberships : Get page of group conversations userID is a member of sorted by name, and their total count
+func (mongoDB *MongoDB) GetGroupMemberships(ctx context.Context, userID string, pagination *utils.Pagination) ([]*GroupConversation, int, error) {
+
+	query := bson.M{"members": userID, "deletedAt": bson.M{"$exists": false}}
+
+	total, err := mongoDB.GroupConversationCollection.CountDocuments(ctx, query)
+
+	if err != nil {
+		return nil, 0, err
+	}
+
+	cursor, err := mongoDB.GroupConversationCollection.Find(ctx, query,
+		pageOptions(pagination, bson.D{bson.E{Key: "name", Value: 1}, bson.E{Key: "groupConversationID", Value: 1}}),
+	)
+
+	if err != nil {
+		return nil, 0, err
+	}
+
+	groupConversations := []*GroupConversation{}
+
+	err = cursor.All(ctx, &groupConversations)
+
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return groupConversations, int(total), nil
+}
+
+// RemoveGroupConversation : Mark group conversation removed at deletedAt and revoke access of its members under groupTopicPath,
+// along with events in a single transaction. Return the removed group conversation, nil if it does not exist or was already removed
+func (mongoDB *MongoDB) RemoveGroupConversation(ctx context.Context, groupConversationID string, groupTopicPath string, deletedAt time.Time, events ...*LifecycleEvent) (*GroupConversation, error) {
+
+	groupConversation := &GroupConversation{}
+
+	err := mongoDB.withOutbox(ctx, events, func(ctx context.Context) error {
+
+		err := mongoDB.GroupConversationCollection.FindOneAndUpdate(
+			ctx,
+			bson.M{"groupConversationID": groupConversationID, "deletedAt": bson.M{"$exists": false}},
+			bson.M{"$set": bson.M{"deletedAt": deletedAt}},
+			options.FindOneAndUpdate().SetReturnDocument(options.After),
+		).Decode(groupConversation)
+
+		if err != nil {
+			return err
+		}
+
+		return mongoDB.revokeGroupACLs(ctx, groupConversation, groupTopicPath)
+	})
+
+	// Transaction is aborted, so that no event is relayed for a group conversation which was not changed
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return groupConversation, nil
+}
+
+// RestoreGroupConversation : Unmark removed group conversation and grant access to its members under groupTopicPath again,
+// along with events in a single transaction. Return the restored group conversation, nil if it does not exist or was not removed
+func (mongoDB *MongoDB) RestoreGroupConversation(ctx context.Context, groupConversationID string, groupTopicPath string, events ...*LifecycleEvent) (*GroupConversation, error) {
+
+	groupConversation := &GroupConversation{}
+
+	err := mongoDB.withOutbox(ctx, events, func(ctx context.Context) error {
+
+		err := mongoDB.GroupConversationCollection.FindOneAndUpdate(
+			ctx,
+			bson.M{"groupConversationID": groupConversationID, "deletedAt": bson.M{"$exists": true}},
+			bson.M{"$unset": bson.M{"deletedAt": ""}},
+			options.FindOneAndUpdate().SetReturnDocument(options.After),
+		).Decode(groupConversation)
+
+		if err != nil {
+			return err
+		}
+
+		return mongoDB.updateProfilesWithGroupACLs(ctx, []*GroupConversation{groupConversation}, groupTopicPath)
+	})
+
+	// Transaction is aborted, so that no event is relayed for a group conversation which was not changed
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return groupConversation, nil
+}
+
+// PurgeGroupConversations : Remove group conversations of every tenant for good, when removed before deletedBefore. Return the number purged
+func (mongoDB *MongoDB) PurgeGroupConversations(ctx context.Context, deletedBefore time.Time) (int, error) {
+
+	names, err := mongoDB.WaveDB.ListCollectionNames(ctx, bson.M{})
+
+	if err != nil {
+		return 0, err
+	}
+
+	purged := 0
+
+	for _, name := range mongoDB.tenantsCollections(names, GroupConversationCollection) {
+
+		res, err := mongoDB.WaveDB.Collection(name).DeleteMany(
+			ctx,
+			bson.M{"deletedAt": bson.M{"$lt": deletedBefore}},
+		)
+
+		if err != nil {
+			return purged, err
+		}
+
+		purged += int(res.DeletedCount)
+	}
+
+	return purged, nil
+}
+
+// AddProfileACL : Add VerneMQ ACL for user in database, along with events in a single transaction
+// Should be trigerred when a user connect for the first time. ACL is upserted by client ID, so that concurrent additions don't duplicate it :
+// credentials, device name and expiry of an existing ACL are replaced, and its patterns merged with the ones of verneMQACL (Group ACLs are kept)
+func (mongoDB *MongoDB) AddProfileACL(ctx context.Context, verneMQACL *VerneMQACL, events ...*LifecycleEvent) error {
+
+	set := bson.M{
+		"mountpoint": verneMQACL.Mountpoint,
+		"username":   verneMQACL.Username,
+		"passhash":   verneMQACL.Passhash,
+	}
+
+	unset := bson.M{}
+
+	if verneMQACL.DeviceName != "" {
+		set["device_name"] = verneMQACL.DeviceName
+	} else {
+		unset["device_name"] = ""
+	}
+
+	if verneMQACL.ExpiresAt != nil {
+		set["expires_at"] = verneMQACL.ExpiresAt
+	} else {
+		unset["expires_at"] = ""
+	}
+
+	update := bson.M{
+		"$set": set,
+		"$addToSet": bson.M{
+			"publish_acl":   bson.M{"$each": verneMQACL.PublishACL},
+			"subscribe_acl": bson.M{"$each": verneMQACL.SubscribeACL},
+		},
+	}
+
+	if len(unset) > 0 {
+		update["$unset"] = unset
+	}
+
+	// Upsert ACL into VerneMQ ACL Collection
+	return mongoDB.withOutbox(ctx, events, func(ctx context.Context) error {
+
+		_, err := mongoDB.VerneMQACLCollection.UpdateOne(
+			ctx,
+			bson.M{"client_id": verneMQACL.ClientID},
+			update,
+			options.Update().SetUpsert(true),
+		)
+
+		return err
+	})
+}
+
+// GetProfileACL : Get main VerneMQ ACL of userID (MQTT client ID matching internal user ID)
+func (mongoDB *MongoDB) GetProfileACL(ctx context.Context, userID string) (*VerneMQACL, error) {
+
+	verneMQACL := &VerneMQACL{}
+
+	err := mongoDB.VerneMQACLCollection.FindOne(
+		ctx,
+		bson.M{"client_id": userID, "username": userID},
+	).Decode(verneMQACL)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return verneMQACL, nil
+}
+
+// GetClientACL : Get VerneMQ ACL of MQTT client ID (Main profile or device)
+func (mongoDB *MongoDB) GetClientACL(ctx context.Context, clientID string) (*VerneMQACL, error) {
+
+	verneMQACL := &VerneMQACL{}
+
+	err := mongoDB.VerneMQACLCollection.FindOne(
+		ctx,
+		bson.M{"client_id": clientID},
+	).Decode(verneMQACL)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return verneMQACL, nil
+}
+
+// GetUserACLs : Get all VerneMQ ACLs of userID (Main profile and devices)
+func (mongoDB *MongoDB) GetUserACLs(ctx context.Context, userID string) ([]*VerneMQACL, error) {
+
+	cursor, err := mongoDB.VerneMQACLCollection.Find(
+		ctx,
+		bson.M{"username": userID},
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	verneMQACLs := []*VerneMQACL{}
+
+	err = cursor.All(ctx, &verneMQACLs)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return verneMQACLs, nil
+}
+
+// RemoveDeviceACL : Move VerneMQ ACL of one of userID devices to tombstones, along with events in a single transaction
+// Main profile ACL (client ID matching user ID) can't be removed this way
+func (mongoDB *MongoDB) RemoveDeviceACL(ctx context.Context, userID string, deviceClientID string, events ...*LifecycleEvent) error {
+
+	if deviceClientID == userID {
+		return fmt.Errorf("error removing device %s : main profile can't be removed", deviceClientID)
+	}
+
+	return mongoDB.withOutbox(ctx, events, func(ctx context.Context) error {
+
+		removed, err := mongoDB.moveACLsToTombstones(
+			ctx,
+			bson.M{"client_id": deviceClientID, "username": userID},
+			time.Now().UTC(),
+		)
+
+		if err != nil {
+			return err
+		}
+
+		// Transaction is aborted, so that no event is relayed for a device which did not exist
+		if removed == 0 {
+			return fmt.Errorf("error removing device %s : no such device for user %s", deviceClientID, userID)
+		}
+
+		return nil
+	})
+}
+
+// RemoveUserACLs : Move all VerneMQ ACLs of userID (Main profile and devices) to tombstones, along with events in a single transaction
+func (mongoDB *MongoDB) RemoveUserACLs(ctx context.Context, userID string, events ...*LifecycleEvent) error {
+
+	return mongoDB.withOutbox(ctx, events, func(ctx context.Context) error {
+
+		_, err := mongoDB.moveACLsToTombstones(ctx, bson.M{"username": userID}, time.Now().UTC())
+
+		return err
+	})
+}
+
+// GetExpiredACLs : Get VerneMQ ACLs expired at now
+func (mongoDB *MongoDB) GetExpiredACLs(ctx context.Context, now time.Time) ([]*VerneMQACL, error) {
+
+	cursor, err := mongoDB.VerneMQACLCollection.Find(
+		ctx,
+		bson.M{"expires_at": bson.M{"$lte": now}},
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	verneMQACLs := []*VerneMQACL{}
+
+	err = cursor.All(ctx, &verneMQACLs)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return verneMQACLs, nil
+}
+
+// RenewACLs : Push back expiry date of all VerneMQ ACLs of userID (Main profile and devices)
+// Return false if user has no ACL left
+func (mongoDB *MongoDB) RenewACLs(ctx context.Context, userID string, expiresAt time.Time) (bool, error) {
+
+	res, err := mongoDB.VerneMQACLCollection.UpdateMany(
+		ctx,
+		bson.M{"username": userID},
+		bson.M{"$set": bson.M{"expires_at": expiresAt}},
+	)
+
+	if err != nil {
+		return false, err
+	}
+
+	return res.MatchedCount > 0, nil
+}
+
+// RemoveExpiredACLs : Move VerneMQ ACLs expired at now (Guests and stale users) to tombstones, along with events in a single transaction
+// ACLs without expiry date are kept
+func (mongoDB *MongoDB) RemoveExpiredACLs(ctx context.Context, now time.Time, events ...*LifecycleEvent) error {
+
+	return mongoDB.withOutbox(ctx, events, func(ctx context.Context) error {
+
+		_, err := mongoDB.moveACLsToTombstones(ctx, bson.M{"expires_at": bson.M{"$lte": now}}, now)
+
+		return err
+	})
+}
+
+// AuthorizePublishing : Authorize publishing on MQTT topic for userID (On all its devices). Topics already authorized are not added again
+func (mongoDB *MongoDB) AuthorizePublishing(ctx context.Context, userID string, topic string) error {
+
+	_, err := mongoDB.VerneMQACLCollection.UpdateMany(
+		ctx,
+		bson.M{"username": userID},
+		bson.M{"$addToSet": bson.M{"publish_acl": bson.M{"pattern": topic}}},
+	)
+
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// updateProfilesWithGroupACLs : Update VerneMQ ACLs within ctx (A transaction) to grant publish and read access to all members of group conversations.
+// ACLs are granted on every device of each member, under groupTopicPath. ACLs of each member are updated once, with the patterns of all its conversations,
+// in a single bulk write (Unordered, as updates of members are independent). Patterns already granted are not added again
+func (mongoDB *MongoDB) updateProfilesWithGroupACLs(ctx context.Context, groupConversations []*GroupConversation, groupTopicPath string) error {
+
+	publishPatterns := map[string]bson.A{}
+	subscribePatterns := map[string]bson.A{}
+	userIDs := []string{}
+
+	for _, groupConversation := range groupConversations {
+		for _, userID := range groupConversation.Members {
+
+			if _, ok := publishPatterns[userID]; !ok {
+				userIDs = append(userIDs, userID)
+			}
+
+			publishPatterns[userID] = append(publishPatterns[userID], bson.M{"pattern": groupTopicPath + groupConversation.GroupConversationID + "/" + userID})
+			subscribePatterns[userID] = append(subscribePatterns[userID], bson.M{"pattern": groupTopicPath + groupConversation.GroupConversationID + "/+"})
+		}
+	}
+
+	if len(userIDs) == 0 {
+		return nil
+	}
+
+	// Publish patterns hold the member ID, so members can't share an update : updates are sent in a single bulk write instead of a round trip by member
+	updates := make([]mongo.WriteModel, 0, len(userIDs))
+
+	for _, userID := range userIDs {
+
+		update := mongo.NewUpdateManyModel().
+			SetFilter(bson.M{"username": userID}).
+			SetUpdate(bson.M{
+				"$addToSet": bson.M{
+					"publish_acl":   bson.M{"$each": publishPatterns[userID]},
+					"subscribe_acl": bson.M{"$each": subscribePatterns[userID]},
+				},
+			})
+
+		updates = append(updates, update)
+	}
+
+	_, err := mongoDB.VerneMQACLCollection.BulkWrite(ctx, updates, options.BulkWrite().SetOrdered(false))
+
+	return err
+}
+
+// revokeGroupACLs : Update VerneMQ ACLs within ctx (A transaction) to revoke access of the members of groupConversation under groupTopicPath.
+// Tombstones of their ACLs are updated too, so that restoring them doesn't grant access to the removed group conversation again
+func (mongoDB *MongoDB) revokeGroupACLs(ctx context.Context, groupConversation *GroupConversation, groupTopicPath string) error {
+
+	if len(groupConversation.Members) == 0 {
+		return nil
+	}
+
+	publishPatterns := make(bson.A, 0, len(groupConversation.Members))
+
+	for _, userID := range groupConversation.Members {
+		publishPatterns = append(publishPatterns, groupTopicPath+groupConversation.GroupConversationID+"/"+userID)
+	}
+
+	update := bson.M{
+		"$pull": bson.M{
+			"publish_acl":   bson.M{"pattern": bson.M{"$in": publishPatterns}},
+			"subscribe_acl": bson.M{"pattern": groupTopicPath + groupConversation.GroupConversationID + "/+"},
+		},
+	}
+
+	for _, collection := range []*mongo.Collection{mongoDB.VerneMQACLCollection, mongoDB.DeletedACLsCollection} {
+
+		_, err := collection.UpdateMany(ctx, bson.M{"username": bson.M{"$in": groupConversation.Members}}, update)
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// UpdatePassHash : Update passhash field in VerneMQ ACLs Collection Acls, along with events in a single transaction
+// Devices share the user token, so passhash is updated on all of them
+func (mongoDB *MongoDB) UpdatePassHash(ctx context.Context, userID string, newPasshash string, events ...*LifecycleEvent) error {
+
+	return mongoDB.withOutbox(ctx, events, func(ctx context.Context) error {
+
+		_, err := mongoDB.VerneMQACLCollection.UpdateMany(
+			ctx,
+			bson.M{"username": userID},
+			bson.M{"$set": bson.M{"passhash": newPasshash}},
+		)
+
+		return err
+	})
+}
+
+// GetDeletedACLs : Get tombstones of the removed VerneMQ ACLs of userID (Main profile and devices)
+func (mongoDB *MongoDB) GetDeletedACLs(ctx context.Context, userID string) ([]*DeletedACL, error) {
+
+	cursor, err := mongoDB.DeletedACLsCollection.Find(
+		ctx,
+		bson.M{"username": userID},
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	deletedACLs := []*DeletedACL{}
+
+	err = cursor.All(ctx, &deletedACLs)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return deletedACLs, nil
+}
+
+// RestoreUserACLs : Move tombstones of the removed VerneMQ ACLs of userID back to VerneMQ ACLs, along with events in a single transaction.
+// Tombstones of client IDs having an ACL again are left as they are. Return the number of ACLs restored
+func (mongoDB *MongoDB) RestoreUserACLs(ctx context.Context, userID string, events ...*LifecycleEvent) (int, error) {
+
+	restored := 0
+
+	err := mongoDB.withOutbox(ctx, events, func(ctx context.Context) error {
+
+		// Transaction may be retried
+		restored = 0
+
+		deletedACLs, err := mongoDB.GetDeletedACLs(ctx, userID)
+
+		if err != nil {
+			return err
+		}
+
+		for _, deletedACL := range deletedACLs {
+
+			res, err := mongoDB.VerneMQACLCollection.UpdateOne(
+				ctx,
+				bson.M{"client_id": deletedACL.ClientID},
+				bson.M{"$setOnInsert": deletedACL.VerneMQACL},
+				options.Update().SetUpsert(true),
+			)
+
+			if err != nil {
+				return err
+			}
+
+			if res.UpsertedCount == 0 {
+				continue
+			}
+
+			_, err = mongoDB.DeletedACLsCollection.DeleteOne(ctx, bson.M{"client_id": deletedACL.ClientID})
+
+			if err != nil {
+				return err
+			}
+
+			restored++
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return 0, err
+	}
+
+	return restored, nil
+}
+
+// PurgeDeletedACLs : Remove tombstones of VerneMQ ACLs removed before deletedBefore for good. Return the number purged
+func (mongoDB *MongoDB) PurgeDeletedACLs(ctx context.Context, deletedBefore time.Time) (int, error) {
+
+	res, err := mongoDB.DeletedACLsCollection.DeleteMany(
+		ctx,
+		bson.M{"deleted_at": bson.M{"$lt": deletedBefore}},
+	)
+
+	if err != nil {
+		return 0, err
+	}
+
+	return int(res.DeletedCount), nil
+}
+
+// moveACLsToTombstones : Move VerneMQ ACLs matching query to tombstones within ctx (A transaction), removed at deletedAt.
+// Tombstones replace the ones of previous removals of their client IDs. Return the number of ACLs moved
+func (mongoDB *MongoDB) moveACLsToTombstones(ctx context.Context, query bson.M, deletedAt time.Time) (int, error) {
+
+	cursor, err := mongoDB.VerneMQACLCollection.Find(ctx, query)
+
+	if err != nil {
+		return 0, err
+	}
+
+	verneMQACLs := []*VerneMQACL{}
+
+	err = cursor.All(ctx, &verneMQACLs)
+
+	if err != nil {
+		return 0, err
+	}
+
+	if len(verneMQACLs) == 0 {
+		return 0, nil
+	}
+
+	tombstones := make([]mongo.WriteModel, 0, len(verneMQACLs))
+
+	for _, verneMQACL := range verneMQACLs {
+
+		tombstone := mongo.NewReplaceOneModel().
+			SetFilter(bson.M{"client_id": verneMQACL.ClientID}).
+			SetReplacement(&DeletedACL{VerneMQACL: *verneMQACL, DeletedAt: deletedAt}).
+			SetUpsert(true)
+
+		tombstones = append(tombstones, tombstone)
+	}
+
+	_, err = mongoDB.DeletedACLsCollection.BulkWrite(ctx, tombstones, options.BulkWrite().SetOrdered(false))
+
+	if err != nil {
+		return 0, err
+	}
+
+	res, err := mongoDB.VerneMQACLCollection.DeleteMany(ctx, query)
+
+	if err != nil {
+		return 0, err
+	}
+
+	return int(res.DeletedCount), nil
+}
+
+// AddPushToken : Add device push token in database, replacing an existing entry of the same user with the same token.
+// A push token belongs to a single device : ErrPushTokenTaken if another user registered it (Refused by the unique token index)
+func (mongoDB *MongoDB) AddPushToken(ctx context.Context, pushToken *PushToken) error {
+
+	_, err := mongoDB.PushTokensCollection.ReplaceOne(
+		ctx,
+		bson.M{"token": pushToken.Token, "userID": pushToken.UserID},
+		pushToken,
+		options.Replace().SetUpsert(true),
+	)
+
+	if mongo.IsDuplicateKeyError(err) {
+		return ErrPushTokenTaken
+	}
+
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// RemovePushToken : Remove device push token of userID from database
+func (mongoDB *MongoDB) RemovePushToken(ctx context.Context, userID string, token string) error {
+
+	res, err := mongoDB.PushTokensCollection.DeleteOne(
+		ctx,
+		bson.M{"token": token, "userID": userID},
+	)
+
+	if err != nil {
+		return err
+	}
+
+	if res.DeletedCount == 0 {
+		return fmt.Errorf("error removing push token : no such token for user %s", userID)
+	}
+
+	return nil
+}
+
+// GetPushTokens : Get all devices push tokens of userID
+func (mongoDB *MongoDB) GetPushTokens(ctx context.Context, userID string) ([]*PushToken, error) {
+
+	cursor, err := mongoDB.PushTokensCollection.Find(
+		ctx,
+		bson.M{"userID": userID},
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	pushTokens := []*PushToken{}
+
+	err = cursor.All(ctx, &pushTokens)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return pushTokens, nil
+}
+
+// GetNotificationPreferences : Get push notifications settings of userID, defaults are returned if none were set
+func (mongoDB *MongoDB) GetNotificationPreferences(ctx context.Context, userID string) (*NotificationPreferences, error) {
+
+	preferences := NewNotificationPreferences(userID)
+
+	err := mongoDB.NotificationPreferencesCollection.FindOne(
+		ctx,
+		bson.M{"userID": userID},
+	).Decode(preferences)
+
+	if err == mongo.ErrNoDocuments {
+		return preferences, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return preferences, nil
+}
+
+// SetNotificationPreferences : Replace push notifications settings of user
+func (mongoDB *MongoDB) SetNotificationPreferences(ctx context.Context, preferences *NotificationPreferences) error {
+
+	_, err := mongoDB.NotificationPreferencesCollection.ReplaceOne(
+		ctx,
+		bson.M{"userID": preferences.UserID},
+		preferences,
+		options.Replace().SetUpsert(true),
+	)
+
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// AddAPIKey : Add hashed API key of an internal backend service in database
+func (mongoDB *MongoDB) AddAPIKey(ctx context.Context, apiKey *APIKey) error {
+
+	_, err := mongoDB.APIKeysCollection.InsertOne(ctx, apiKey)
+
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// GetAPIKey : Get API key matching hashedKey
+func (mongoDB *MongoDB) GetAPIKey(ctx context.Context, hashedKey string) (*APIKey, error) {
+
+	apiKey := &APIKey{}
+
+	err := mongoDB.APIKeysCollection.FindOne(
+		ctx,
+		bson.M{"hashedKey": hashedKey},
+	).Decode(apiKey)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return apiKey, nil
+}
+
+// GetQuotaOverride : Get quotas of userID overriding configured ones, nil if there is none
+func (mongoDB *MongoDB) GetQuotaOverride(ctx context.Context, userID string) (*QuotaOverride, error) {
+
+	override := &QuotaOverride{}
+
+	err := mongoDB.QuotaOverridesCollection.FindOne(
+		ctx,
+		bson.M{"userID": userID},
+	).Decode(override)
+
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return override, nil
+}
+
+// SetQuotaOverride : Replace quotas of user overriding configured ones
+func (mongoDB *MongoDB) SetQuotaOverride(ctx context.Context, override *QuotaOverride) error {
+
+	_, err := mongoDB.QuotaOverridesCollection.ReplaceOne(
+		ctx,
+		bson.M{"userID": override.UserID},
+		override,
+		options.Replace().SetUpsert(true),
+	)
+
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// AddUsage : Add count to metric counter of userID on date, creating its usage record if needed
+func (mongoDB *MongoDB) AddUsage(ctx context.Context, date string, tenantID string, userID string, metric string, count int) error {
+
+	_, err := mongoDB.UsageCollection.UpdateOne(
+		ctx,
+		bson.M{"date": date, "tenantID": tenantID, "userID": userID},
+		bson.M{"$inc": bson.M{metric: int64(count)}},
+		options.Update().SetUpsert(true),
+	)
+
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// GetUsage : Get usage records matching filter
+func (mongoDB *MongoDB) GetUsage(ctx context.Context, filter *UsageFilter) ([]*UsageRecord, error) {
+
+	query := bson.M{"date": bson.M{"$gte": filter.From, "$lte": filter.To}}
+
+	if filter.TenantID != nil {
+		query["tenantID"] = *filter.TenantID
+	}
+
+	if filter.UserID != "" {
+		query["userID"] = filter.UserID
+	}
+
+	cursor, err := mongoDB.UsageCollection.Find(ctx, query)
+
+	if err != nil {
+		return nil, err
+	}
+
+	records := []*UsageRecord{}
+
+	err = cursor.All(ctx, &records)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return records, nil
+}
+
+// AddAuditEntry : Append entry to the audit log, entries are never updated nor removed
+func (mongoDB *MongoDB) AddAuditEntry(ctx context.Context, entry *AuditEntry) error {
+
+	_, err := mongoDB.AuditLogCollection.InsertOne(ctx, entry)
+
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// GetAuditEntries : Return page of audit entries matching filter, most recent first, and number of matching entries
+func (mongoDB *MongoDB) GetAuditEntries(ctx context.Context, filter *AuditFilter) ([]*AuditEntry, int, error) {
+
+	query := bson.M{}
+
+	if filter.ActorType != "" {
+		query["actor.type"] = filter.ActorType
+	}
+
+	if filter.ActorID != "" {
+		query["actor.id"] = filter.ActorID
+	}
+
+	if filter.Target != "" {
+		query["target"] = filter.Target
+	}
+
+	if filter.Action != "" {
+		query["action"] = filter.Action
+	}
+
+	if filter.TenantID != nil {
+		query["tenantID"] = tenantQuery(*filter.TenantID)
+	}
+
+	if filter.From != nil || filter.To != nil {
+
+		timestamp := bson.M{}
+
+		if filter.From != nil {
+			timestamp["$gte"] = *filter.From
+		}
+
+		if filter.To != nil {
+			timestamp["$lte"] = *filter.To
+		}
+
+		query["timestamp"] = timestamp
+	}
+
+	total, err := mongoDB.AuditLogCollection.CountDocuments(ctx, query)
+
+	if err != nil {
+		return nil, 0, err
+	}
+
+	cursor, err := mongoDB.AuditLogCollection.Find(ctx, query,
+		pageOptions(filter.Page, bson.D{bson.E{Key: "timestamp", Value: -1}}),
+	)
+
+	if err != nil {
+		return nil, 0, err
+	}
+
+	entries := []*AuditEntry{}
+
+	err = cursor.All(ctx, &entries)
+
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return entries, int(total), nil
+}
+
+// AddWebhook : Add outbound webhook in database
+func (mongoDB *MongoDB) AddWebhook(ctx context.Context, webhook *Webhook) error {
+
+	_, err := mongoDB.WebhooksCollection.InsertOne(ctx, webhook)
+
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// GetWebhooks : Get outbound webhooks, oldest first
+func (mongoDB *MongoDB) GetWebhooks(ctx context.Context) ([]*Webhook, error) {
+
+	cursor, err := mongoDB.WebhooksCollection.Find(ctx, bson.M{},
+		options.Find().SetSort(bson.D{bson.E{Key: "createdAt", Value: 1}}),
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	webhooks := []*Webhook{}
+
+	err = cursor.All(ctx, &webhooks)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return webhooks, nil
+}
+
+// GetWebhook : Get outbound webhook webhookID, nil if it does not exist
+func (mongoDB *MongoDB) GetWebhook(ctx context.Context, webhookID string) (*Webhook, error) {
+
+	webhook := &Webhook{}
+
+	err := mongoDB.WebhooksCollection.FindOne(
+		ctx,
+		bson.M{"id": webhookID},
+	).Decode(webhook)
+
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return webhook, nil
+}
+
+// RemoveWebhook : Remove outbound webhook webhookID, its deliveries and dead letters, returning false if it does not exist
+func (mongoDB *MongoDB) RemoveWebhook(ctx context.Context, webhookID string) (bool, error) {
+
+	res, err := mongoDB.WebhooksCollection.DeleteOne(
+		ctx,
+		bson.M{"id": webhookID},
+	)
+
+	if err != nil {
+		return false, err
+	}
+
+	if res.DeletedCount == 0 {
+		return false, nil
+	}
+
+	// Deliveries and dead letters of the webhook are shared by tenants, webhook IDs being unique across them
+	for _, collection := range []*mongo.Collection{mongoDB.WebhookDeliveriesCollection, mongoDB.WebhookDeadLettersCollection} {
+
+		_, err = collection.DeleteMany(
+			ctx,
+			bson.M{"webhookID": webhookID},
+		)
+
+		if err != nil {
+			return true, err
+		}
+	}
+
+	return true, nil
 }
 
-// MongoDB : MongoDB communication interface
-type MongoDB struct {
-	Client                         *mongo.Client
-	WaveDB                         *mongo.Database
-	PrivateConversationsCollection *mongo.Collection
-	VerneMQACLCollection           *mongo.Collection
-	GroupConversationCollection    *mongo.Collection
+// AddWebhookDelivery : Add delivery of a lifecycle event to an outbound webhook in database
+func (mongoDB *MongoDB) AddWebhookDelivery(ctx context.Context, delivery *WebhookDelivery) error {
+
+	_, err := mongoDB.WebhookDeliveriesCollection.InsertOne(ctx, delivery)
+
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// UpdateWebhookDelivery : Replace delivery of a lifecycle event with its current status
+func (mongoDB *MongoDB) UpdateWebhookDelivery(ctx context.Context, delivery *WebhookDelivery) error {
+
+	_, err := mongoDB.WebhookDeliveriesCollection.ReplaceOne(
+		ctx,
+		bson.M{"id": delivery.ID},
+		delivery,
+	)
+
+	if err != nil {
+		return err
+	}
+
+	return nil
 }
 
-// NewMongoDB : Return a new MongoDB abstraction struct
-func NewMongoDB(connectionURL string) *MongoDB {
+// GetWebhookDeliveries : Get page of deliveries of webhookID, most recent first, and their total count
+func (mongoDB *MongoDB) GetWebhookDeliveries(ctx context.Context, webhookID string, pagination *utils.Pagination) ([]*WebhookDelivery, int, error) {
+
+	query := bson.M{"webhookID": webhookID}
 
-	// Get connection to DB
-	client, err := mongo.NewClient(connectionURL)
+	total, err := mongoDB.WebhookDeliveriesCollection.CountDocuments(ctx, query)
 
 	if err != nil {
-		utils.PanicOnError(err, "Failed to connect to MongoDB")
+		return nil, 0, err
 	}
 
-	err = client.Connect(context.TODO())
+	cursor, err := mongoDB.WebhookDeliveriesCollection.Find(ctx, query,
+		pageOptions(pagination, bson.D{bson.E{Key: "createdAt", Value: -1}}),
+	)
 
 	if err != nil {
-		utils.PanicOnError(err, "Failed to connect to context")
+		return nil, 0, err
 	}
 
-	// Get database reference
-	waveDB := client.Database(WaveDatabaseName)
+	deliveries := []*WebhookDelivery{}
 
-	// Get collections references
-	privateConversationsCollection := waveDB.Collection(PrivateConversationsCollection)
-	vmqACLCollection := waveDB.Collection(VerneMQACLCollection)
-	groupConversationCollection := waveDB.Collection(GroupConversationCollection)
+	err = cursor.All(ctx, &deliveries)
 
-	// Return new MongoDB abstraction struct
-	return &MongoDB{
-		Client:                         client,
-		WaveDB:                         waveDB,
-		PrivateConversationsCollection: privateConversationsCollection,
-		VerneMQACLCollection:           vmqACLCollection,
-		GroupConversationCollection:    groupConversationCollection,
+	if err != nil {
+		return nil, 0, err
 	}
+
+	return deliveries, int(total), nil
 }
 
-// AddGroupConversation : Add group conversation entry in database
-func (mongoDB *MongoDB) AddGroupConversation(groupConversation *GroupConversation) error {
+// GetDueWebhookDeliveries : Get up to limit pending webhook deliveries of all tenants due for an attempt at now and not leased, most overdue first
+func (mongoDB *MongoDB) GetDueWebhookDeliveries(ctx context.Context, now time.Time, limit int) ([]*WebhookDelivery, error) {
 
-	// Marshal struct into bson object
-	doc, err := bson.Marshal(*groupConversation)
+	cursor, err := mongoDB.WebhookDeliveriesCollection.Find(
+		ctx,
+		bson.M{
+			"status":        WebhookDeliveryPending,
+			"nextAttemptAt": bson.M{"$lte": now},
+			"leaseUntil":    bson.M{"$lte": now},
+		},
+		options.Find().SetSort(bson.D{bson.E{Key: "nextAttemptAt", Value: 1}}).SetLimit(int64(limit)),
+	)
 
 	if err != nil {
-		return err
+		return nil, err
+	}
+
+	deliveries := []*WebhookDelivery{}
+
+	err = cursor.All(ctx, &deliveries)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return deliveries, nil
+}
+
+// ClaimWebhookDelivery : Lease pending webhook delivery until leaseUntil, unless another instance leased it beyond now.
+// Returns false when the delivery was leased by another instance, or is not pending anymore
+func (mongoDB *MongoDB) ClaimWebhookDelivery(ctx context.Context, deliveryID string, now time.Time, leaseUntil time.Time) (bool, error) {
+
+	res, err := mongoDB.WebhookDeliveriesCollection.UpdateOne(
+		ctx,
+		bson.M{
+			"id":         deliveryID,
+			"status":     WebhookDeliveryPending,
+			"leaseUntil": bson.M{"$lte": now},
+		},
+		bson.M{"$set": bson.M{"leaseUntil": leaseUntil}},
+	)
+
+	if err != nil {
+		return false, err
 	}
 
-	// Insert group conversation into DB
-	_, err = mongoDB.GroupConversationCollection.InsertOne(nil, doc)
+	return res.ModifiedCount == 1, nil
+}
+
+// AddWebhookDeadLetter : Add delivery abandoned after its last attempt in database
+func (mongoDB *MongoDB) AddWebhookDeadLetter(ctx context.Context, deadLetter *WebhookDeadLetter) error {
+
+	_, err := mongoDB.WebhookDeadLettersCollection.InsertOne(ctx, deadLetter)
 
 	if err != nil {
 		return err
@@ -96,19 +1575,172 @@ func (mongoDB *MongoDB) AddGroupConversation(groupConversation *GroupConversatio
 	return nil
 }
 
-// AddProfileACL : Add VerneMQ ACL for user in database
-// Should be trigerred when a user connect for the first time
-func (mongoDB *MongoDB) AddProfileACL(verneMQACL *VerneMQACL) error {
+// GetWebhookDeadLetter : Get dead letter deadLetterID, nil if it does not exist
+func (mongoDB *MongoDB) GetWebhookDeadLetter(ctx context.Context, deadLetterID string) (*WebhookDeadLetter, error) {
+
+	deadLetter := &WebhookDeadLetter{}
+
+	err := mongoDB.WebhookDeadLettersCollection.FindOne(
+		ctx,
+		bson.M{"id": deadLetterID},
+	).Decode(deadLetter)
+
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return deadLetter, nil
+}
+
+// GetWebhookDeadLetters : Return page of dead letters matching filter, most recent first, and number of matching dead letters
+func (mongoDB *MongoDB) GetWebhookDeadLetters(ctx context.Context, filter *WebhookDeadLetterFilter) ([]*WebhookDeadLetter, int, error) {
+
+	query := bson.M{"tenantID": tenantQuery(filter.TenantID)}
+
+	if filter.WebhookID != "" {
+		query["webhookID"] = filter.WebhookID
+	}
+
+	total, err := mongoDB.WebhookDeadLettersCollection.CountDocuments(ctx, query)
+
+	if err != nil {
+		return nil, 0, err
+	}
+
+	cursor, err := mongoDB.WebhookDeadLettersCollection.Find(ctx, query,
+		pageOptions(filter.Page, bson.D{bson.E{Key: "deadLetteredAt", Value: -1}}),
+	)
+
+	if err != nil {
+		return nil, 0, err
+	}
+
+	deadLetters := []*WebhookDeadLetter{}
+
+	err = cursor.All(ctx, &deadLetters)
+
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return deadLetters, int(total), nil
+}
+
+// RemoveWebhookDeadLetter : Remove dead letter deadLetterID, returning false if it does not exist
+func (mongoDB *MongoDB) RemoveWebhookDeadLetter(ctx context.Context, deadLetterID string) (bool, error) {
+
+	res, err := mongoDB.WebhookDeadLettersCollection.DeleteOne(
+		ctx,
+		bson.M{"id": deadLetterID},
+	)
+
+	if err != nil {
+		return false, err
+	}
+
+	return res.DeletedCount == 1, nil
+}
+
+// withOutbox : Run write in a transaction, which also adds events to the outbox. Events are thus relayed if and only if
+// their mutation is committed, even if the instance stops right after. write must pass its ctx to its operations, so that they
+// belong to the transaction, and may be run again when the transaction is retried (Transient errors).
+// Writes without events are run as is. Transactions need MongoDB 4.0 or later, deployed as a replica set (See CheckTransactions)
+func (mongoDB *MongoDB) withOutbox(ctx context.Context, events []*LifecycleEvent, write func(ctx context.Context) error) error {
+
+	if len(events) == 0 {
+		return write(ctx)
+	}
+
+	docs := make([]interface{}, 0, len(events))
+
+	for _, event := range events {
+
+		outboxEvent, err := NewOutboxEvent(event)
+
+		if err != nil {
+			return err
+		}
+
+		docs = append(docs, outboxEvent)
+	}
 
-	// Marshal struct into bson object
-	doc, err := bson.Marshal(*verneMQACL)
+	session, err := mongoDB.Client.StartSession()
 
 	if err != nil {
 		return err
 	}
 
-	// Insert ACL into VerneMQ ACL Collection
-	_, err = mongoDB.VerneMQACLCollection.InsertOne(nil, doc)
+	// Session is ended even if ctx is done, so that it is not left open on the server
+	defer session.EndSession(context.Background())
+
+	_, err = session.WithTransaction(ctx, func(ctx mongo.SessionContext) (interface{}, error) {
+
+		err := write(ctx)
+
+		if err != nil {
+			return nil, err
+		}
+
+		_, err = mongoDB.OutboxCollection.InsertMany(ctx, docs)
+
+		return nil, err
+	}, mongoDB.transactionOptions)
+
+	return err
+}
+
+// GetOutboxEvents : Get up to limit outbox events of all tenants not leased at now, oldest first
+func (mongoDB *MongoDB) GetOutboxEvents(ctx context.Context, now time.Time, limit int) ([]*OutboxEvent, error) {
+
+	cursor, err := mongoDB.OutboxCollection.Find(
+		ctx,
+		bson.M{"leaseUntil": bson.M{"$lte": now}},
+		options.Find().SetSort(bson.D{bson.E{Key: "createdAt", Value: 1}}).SetLimit(int64(limit)),
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	outboxEvents := []*OutboxEvent{}
+
+	err = cursor.All(ctx, &outboxEvents)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return outboxEvents, nil
+}
+
+// ClaimOutboxEvent : Lease outbox event until leaseUntil, unless another instance leased it beyond now.
+// Returns false when the event was leased by another instance, or relayed already
+func (mongoDB *MongoDB) ClaimOutboxEvent(ctx context.Context, eventID string, now time.Time, leaseUntil time.Time) (bool, error) {
+
+	res, err := mongoDB.OutboxCollection.UpdateOne(
+		ctx,
+		bson.M{"id": eventID, "leaseUntil": bson.M{"$lte": now}},
+		bson.M{"$set": bson.M{"leaseUntil": leaseUntil}},
+	)
+
+	if err != nil {
+		return false, err
+	}
+
+	return res.ModifiedCount == 1, nil
+}
+
+// RemoveOutboxEvent : Remove relayed outbox event
+func (mongoDB *MongoDB) RemoveOutboxEvent(ctx context.Context, eventID string) error {
+
+	_, err := mongoDB.OutboxCollection.DeleteOne(
+		ctx,
+		bson.M{"id": eventID},
+	)
 
 	if err != nil {
 		return err
@@ -117,71 +1749,285 @@ func (mongoDB *MongoDB) AddProfileACL(verneMQACL *VerneMQACL) error {
 	return nil
 }
 
-// AuthorizePublishing : Authorize publishing on MQTT topic for userID
-func (mongoDB *MongoDB) AuthorizePublishing(userID string, topic string) error {
+// DedupACLPatterns : Remove duplicate patterns of VerneMQ ACLs, pushed before patterns were added to sets, with a single update run by the server.
+// Order of patterns is not kept, as VerneMQ matches all of them. Return the number of ACLs deduplicated
+func (mongoDB *MongoDB) DedupACLPatterns(ctx context.Context) (int, error) {
+
+	uniquePublishPatterns := bson.M{"$setUnion": bson.A{bson.M{"$ifNull": bson.A{"$publish_acl", bson.A{}}}, bson.A{}}}
+	uniqueSubscribePatterns := bson.M{"$setUnion": bson.A{bson.M{"$ifNull": bson.A{"$subscribe_acl", bson.A{}}}, bson.A{}}}
+
+	// Only ACLs holding duplicates are rewritten
+	res, err := mongoDB.VerneMQACLCollection.UpdateMany(
+		ctx,
+		bson.M{"$expr": bson.M{"$or": bson.A{
+			bson.M{"$lt": bson.A{bson.M{"$size": uniquePublishPatterns}, bson.M{"$size": bson.M{"$ifNull": bson.A{"$publish_acl", bson.A{}}}}}},
+			bson.M{"$lt": bson.A{bson.M{"$size": uniqueSubscribePatterns}, bson.M{"$size": bson.M{"$ifNull": bson.A{"$subscribe_acl", bson.A{}}}}}},
+		}}},
+		bson.A{bson.M{"$set": bson.M{
+			"publish_acl":   uniquePublishPatterns,
+			"subscribe_acl": uniqueSubscribePatterns,
+		}}},
+	)
+
+	if err != nil {
+		return 0, err
+	}
+
+	return int(res.ModifiedCount), nil
+}
+
+// GetMigrations : Get schema migrations claimed or applied, by increasing version
+func (mongoDB *MongoDB) GetMigrations(ctx context.Context) ([]*MigrationRecord, error) {
+
+	cursor, err := mongoDB.MigrationsCollection.Find(
+		ctx,
+		bson.M{},
+		options.Find().SetSort(bson.D{bson.E{Key: "_id", Value: 1}}),
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	migrations := []*MigrationRecord{}
+
+	err = cursor.All(ctx, &migrations)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return migrations, nil
+}
+
+// ClaimMigration : Lease migration of version until leaseUntil, unless it was applied or another instance leased it beyond now.
+// Returns false when the migration was leased by another instance, or applied already
+func (mongoDB *MongoDB) ClaimMigration(ctx context.Context, version int, name string, now time.Time, leaseUntil time.Time) (bool, error) {
+
+	// Migrations are keyed by version, a migration applied or leased by another instance is not matched and can't be inserted again
+	_, err := mongoDB.MigrationsCollection.UpdateOne(
+		ctx,
+		bson.M{
+			"_id":        version,
+			"appliedAt":  bson.M{"$exists": false},
+			"leaseUntil": bson.M{"$lte": now},
+		},
+		bson.M{"$set": bson.M{"name": name, "startedAt": now, "leaseUntil": leaseUntil}},
+		options.Update().SetUpsert(true),
+	)
+
+	if mongo.IsDuplicateKeyError(err) {
+		return false, nil
+	}
+
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// CompleteMigration : Record migration of version as applied at now, releasing its lease
+func (mongoDB *MongoDB) CompleteMigration(ctx context.Context, version int, now time.Time) error {
 
-	_, err := mongoDB.VerneMQACLCollection.UpdateOne(
-		nil,
-		mongoBSON.NewDocument(
-			mongoBSON.EC.String("client_id", userID),
-		),
-		mongoBSON.NewDocument(
-			mongoBSON.EC.SubDocumentFromElements("$push",
-				mongoBSON.EC.String("publish_acl", topic),
-			),
-		),
+	_, err := mongoDB.MigrationsCollection.UpdateOne(
+		ctx,
+		bson.M{"_id": version},
+		bson.M{
+			"$set":   bson.M{"appliedAt": now},
+			"$unset": bson.M{"leaseUntil": ""},
+		},
 	)
+
 	if err != nil {
 		return err
 	}
+
 	return nil
 }
 
-// UpdateProfilesWithGroupACL : Update VerneMQ Acls in database to grant publish and read access to all members of the group
-func (mongoDB *MongoDB) UpdateProfilesWithGroupACL(groupConversation *GroupConversation) error {
+// WatchChanges : Watch changes of VerneMQ ACLs and group conversations of every tenant, after resumeToken when set, until ctx is done
+// or the change stream fails. Changes are handled in order, updated documents being looked up. Return the resume token of the last handled change
+func (mongoDB *MongoDB) WatchChanges(ctx context.Context, resumeToken []byte, handle func(change *DataChange)) ([]byte, error) {
 
-	for _, userID := range groupConversation.Members {
+	streamOptions := options.ChangeStream().SetFullDocument(options.UpdateLookup)
+
+	if resumeToken != nil {
+		streamOptions.SetResumeAfter(bson.Raw(resumeToken))
+	}
+
+	stream, err := mongoDB.WaveDB.Watch(ctx, changeStreamPipeline(mongoDB.collectionNames), streamOptions)
+
+	if err != nil {
+		return resumeToken, err
+	}
+
+	// Stream is closed even if ctx is done, so that its cursor is not left open on the server
+	defer stream.Close(context.Background())
+
+	for stream.Next(ctx) {
+
+		document := &changeStreamDocument{}
+
+		err = stream.Decode(document)
+
+		if err != nil {
+			return resumeToken, err
+		}
+
+		change, err := newDataChange(document, mongoDB.collectionNames)
+
+		if err != nil {
+			return resumeToken, err
+		}
+
+		handle(change)
+
+		resumeToken = stream.ResumeToken()
+	}
+
+	return resumeToken, stream.Err()
+}
+
+// EnsureIndexes : Create indexes of shared collections, and of the collections of every tenant (Those existing), unless they exist.
+// Indexes of a tenant created afterwards are created on the next startup, until then its queries scan its collections
+func (mongoDB *MongoDB) EnsureIndexes(ctx context.Context) error {
+
+	names, err := mongoDB.WaveDB.ListCollectionNames(ctx, bson.M{})
+
+	if err != nil {
+		return err
+	}
+
+	for collection, indexes := range sharedIndexes {
+
+		err = mongoDB.createIndexes(ctx, mongoDB.collectionNames.Name("", collection), indexes)
 
-		_, err := mongoDB.VerneMQACLCollection.UpdateOne(
-			nil,
-			mongoBSON.NewDocument(
-				mongoBSON.EC.String("client_id", userID),
-			),
-			mongoBSON.NewDocument(
-				mongoBSON.EC.SubDocumentFromElements("$push",
-					mongoBSON.EC.SubDocumentFromElements("publish_acl",
-						mongoBSON.EC.String("pattern", GroupConversationTopicPath+groupConversation.GroupConversationID+"/"+userID)),
-				),
-				mongoBSON.EC.SubDocumentFromElements("$push",
-					mongoBSON.EC.SubDocumentFromElements("subscribe_acl",
-						mongoBSON.EC.String("pattern", GroupConversationTopicPath+groupConversation.GroupConversationID+"/+")),
-				),
-			),
-		)
 		if err != nil {
 			return err
 		}
 	}
+
+	for collection, indexes := range tenantIndexes {
+
+		for _, name := range mongoDB.tenantsCollections(names, collection) {
+
+			err = mongoDB.createIndexes(ctx, name, indexes)
+
+			if err != nil {
+				return err
+			}
+		}
+	}
+
 	return nil
 }
 
-// UpdatePassHash : Update passhash field in VerneMQ ACLs Collection Acls
-func (mongoDB *MongoDB) UpdatePassHash(userID string, newPasshash string) error {
+// tenantsCollections : Return names of collection (Default name) of the default tenant, and of the tenants having it among names
+func (mongoDB *MongoDB) tenantsCollections(names []string, collection string) []string {
+
+	tenantsNames := []string{mongoDB.collectionNames.Name("", collection)}
+
+	for _, name := range names {
+
+		if tenantID, ok := mongoDB.collectionNames.TenantOf(name, collection); ok && tenantID != "" {
+			tenantsNames = append(tenantsNames, name)
+		}
+	}
+
+	return tenantsNames
+}
+
+// createIndexes : Create indexes of collection, existing ones being left as they are
+func (mongoDB *MongoDB) createIndexes(ctx context.Context, collection string, indexes []mongo.IndexModel) error {
+
+	_, err := mongoDB.WaveDB.Collection(collection).Indexes().CreateMany(ctx, indexes)
+
+	if err != nil {
+		return fmt.Errorf("error creating indexes of collection %s : %v", collection, err)
+	}
+
+	return nil
+}
+
+// ExpireTombstones : Have MongoDB remove tombstones of VerneMQ ACLs and group conversations (Of every existing tenant) expireAfter after their removal,
+// through TTL indexes created unless they exist. Expiry is suspended when expireAfter is 0. Removals by the TTL monitor run about every minute
+func (mongoDB *MongoDB) ExpireTombstones(ctx context.Context, expireAfter time.Duration) error {
+
+	expireAfterSeconds := int32(maxExpireAfterSeconds)
+
+	if expireAfter > 0 && expireAfter < maxExpireAfterSeconds*time.Second {
+		expireAfterSeconds = int32(expireAfter / time.Second)
+	}
+
+	names, err := mongoDB.WaveDB.ListCollectionNames(ctx, bson.M{})
 
-	_, err := mongoDB.VerneMQACLCollection.UpdateOne(
-		nil,
-		mongoBSON.NewDocument(
-			mongoBSON.EC.String("client_id", userID),
-		),
-		mongoBSON.NewDocument(
-			mongoBSON.EC.SubDocumentFromElements("$set",
-				mongoBSON.EC.String("passhash", newPasshash),
-			),
-		),
-	)
 	if err != nil {
 		return err
 	}
 
+	for collection, field := range ttlIndexes {
+
+		for _, name := range mongoDB.tenantsCollections(names, collection) {
+
+			err = mongoDB.ensureTTLIndex(ctx, name, field, expireAfterSeconds)
+
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// ensureTTLIndex : Set expireAfterSeconds of the TTL index of field on collection, creating the index (Sparse) when missing
+func (mongoDB *MongoDB) ensureTTLIndex(ctx context.Context, collection string, field string, expireAfterSeconds int32) error {
+
+	keys := bson.D{bson.E{Key: field, Value: 1}}
+
+	err := mongoDB.WaveDB.RunCommand(ctx, bson.D{
+		bson.E{Key: "collMod", Value: collection},
+		bson.E{Key: "index", Value: bson.D{
+			bson.E{Key: "keyPattern", Value: keys},
+			bson.E{Key: "expireAfterSeconds", Value: expireAfterSeconds},
+		}},
+	}).Err()
+
+	if err == nil {
+		return nil
+	}
+
+	var serverErr mongo.ServerError
+
+	if !errors.As(err, &serverErr) || !(serverErr.HasErrorCode(indexNotFoundCode) || serverErr.HasErrorCode(namespaceNotFoundCode)) {
+		return fmt.Errorf("error setting TTL index of collection %s : %v", collection, err)
+	}
+
+	// Index or collection missing
+	_, err = mongoDB.WaveDB.Collection(collection).Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    keys,
+		Options: options.Index().SetSparse(true).SetExpireAfterSeconds(expireAfterSeconds),
+	})
+
+	if err != nil {
+		return fmt.Errorf("error creating TTL index of collection %s : %v", collection, err)
+	}
+
 	return nil
 }
+
+// pageOptions : Return find options of the page of pagination, in sort order
+func pageOptions(pagination *utils.Pagination, sort bson.D) *options.FindOptions {
+	return options.Find().SetSort(sort).SetSkip(int64(pagination.Offset)).SetLimit(int64(pagination.Limit))
+}
+
+// tenantQuery : Return query of records of shared collections belonging to tenantID. Records of the default tenant have no tenantID field
+func tenantQuery(tenantID string) interface{} {
+
+	if tenantID == "" {
+		return bson.M{"$exists": false}
+	}
+
+	return tenantID
+}
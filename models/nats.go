@@ -0,0 +1,121 @@
+package models
+
+import (
+	json "encoding/json"
+	errors "errors"
+	time "time"
+
+	nats "github.com/nats-io/nats.go"
+	logrus "github.com/sirupsen/logrus"
+)
+
+const (
+	// natsClientName : Name of the service NATS connection, shown by NATS monitoring
+	natsClientName = "wave-messaging-management-service"
+
+	// natsMsgIDHeader : NATS header deduplicating messages stored by JetStream
+	natsMsgIDHeader = "Nats-Msg-Id"
+
+	// natsFlushTimeout : Maximum duration of the flush of buffered publications on close
+	natsFlushTimeout = 5 * time.Second
+)
+
+var (
+	// ErrMissingNATSSubject : NATS transport selected without URL or subject
+	ErrMissingNATSSubject = errors.New("NATS URL and subject must be set")
+)
+
+// NATSConfig : NATS transport Config. Events are published on {Subject}.{type} (e.g. wave.messaging.lifecycle.member.added)
+// to the servers of URL (Comma separated, tls:// scheme for TLS), authenticated with Token or a CredentialsFile (JWT and NKey) when set
+type NATSConfig struct {
+	URL             string `json:"url"`
+	Subject         string `json:"subject"`
+	Token           string `json:"token"`
+	CredentialsFile string `json:"credentialsFile"`
+}
+
+// NATSProducer : NATS publisher of lifecycle events. Publications are buffered by the connection, including while it reconnects,
+// failures are logged and counted, never returned
+type NATSProducer struct {
+	conn    *nats.Conn
+	subject string
+}
+
+// NewNATSProducer : Return NATS producer of config, logging connection failures with logger.
+// Startup is not blocked by unreachable servers, the connection keeps retrying in background
+func NewNATSProducer(config *NATSConfig, logger *logrus.Entry) (*NATSProducer, error) {
+
+	if config.URL == "" || config.Subject == "" {
+		return nil, ErrMissingNATSSubject
+	}
+
+	options := []nats.Option{
+		nats.Name(natsClientName),
+		nats.MaxReconnects(-1),
+		nats.RetryOnFailedConnect(true),
+		nats.DisconnectErrHandler(func(conn *nats.Conn, err error) {
+			if err != nil {
+				logger.WithError(err).Warn("Disconnected from NATS")
+			}
+		}),
+		nats.ReconnectHandler(func(conn *nats.Conn) {
+			logger.WithField("url", conn.ConnectedUrl()).Info("Reconnected to NATS")
+		}),
+		nats.ErrorHandler(func(conn *nats.Conn, subscription *nats.Subscription, err error) {
+			logger.WithError(err).Error("NATS error")
+		}),
+	}
+
+	if config.Token != "" {
+		options = append(options, nats.Token(config.Token))
+	}
+
+	if config.CredentialsFile != "" {
+		options = append(options, nats.UserCredentials(config.CredentialsFile))
+	}
+
+	conn, err := nats.Connect(config.URL, options...)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &NATSProducer{conn: conn, subject: config.Subject}, nil
+}
+
+// Produce : Publish event on the subject of its type
+func (producer *NATSProducer) Produce(event *LifecycleEvent) error {
+
+	value, err := json.Marshal(event)
+
+	if err != nil {
+		return err
+	}
+
+	msg := nats.NewMsg(producer.subject + "." + event.Type)
+	msg.Data = value
+	msg.Header.Set(lifecycleTypeHeader, event.Type)
+	msg.Header.Set(natsMsgIDHeader, event.ID)
+
+	err = producer.conn.PublishMsg(msg)
+
+	result := "produced"
+
+	if err != nil {
+		result = "failed"
+	}
+
+	LifecycleEvents.WithLabelValues(LifecycleTransportNATS, event.Type, result).Inc()
+
+	return err
+}
+
+// Close : Flush buffered publications, then close the connection
+func (producer *NATSProducer) Close() error {
+
+	err := producer.conn.FlushTimeout(natsFlushTimeout)
+
+	producer.conn.Close()
+
+	return err
+}
@@ -0,0 +1,128 @@
+package models
+
+import (
+	errors "errors"
+	time "time"
+)
+
+const (
+	// QuietHoursLayout : Quiet hours bounds format (24h clock)
+	QuietHoursLayout = "15:04"
+)
+
+// NotificationPreferences : Push notifications settings of a user
+type NotificationPreferences struct {
+	UserID                string                  `json:"-" bson:"userID"`
+	Muted                 bool                    `json:"muted" bson:"muted"`
+	QuietHours            *QuietHours             `json:"quietHours,omitempty" bson:"quietHours,omitempty"`
+	ConversationOverrides []*ConversationOverride `json:"conversationOverrides" bson:"conversationOverrides"`
+}
+
+// QuietHours : Daily time range during which no push notification is sent
+type QuietHours struct {
+	Start    string `json:"start" bson:"start"`
+	End      string `json:"end" bson:"end"`
+	TimeZone string `json:"timeZone" bson:"timeZone"`
+}
+
+// ConversationOverride : Conversation specific setting, taking precedence over global Muted flag
+// Private conversations are identified by the other user internal ID, group conversations by their group ID
+type ConversationOverride struct {
+	ConversationID string `json:"conversationID" bson:"conversationID"`
+	Muted          bool   `json:"muted" bson:"muted"`
+}
+
+// NewNotificationPreferences : Return default NotificationPreferences struct pointer (Everything notified)
+func NewNotificationPreferences(userID string) *NotificationPreferences {
+	return &NotificationPreferences{
+		UserID:                userID,
+		Muted:                 false,
+		ConversationOverrides: []*ConversationOverride{},
+	}
+}
+
+// Validate : Check quiet hours bounds and time zone
+func (preferences *NotificationPreferences) Validate() error {
+
+	if preferences.QuietHours == nil {
+		return nil
+	}
+
+	_, err := time.Parse(QuietHoursLayout, preferences.QuietHours.Start)
+
+	if err != nil {
+		return errors.New("Invalid quiet hours start")
+	}
+
+	_, err = time.Parse(QuietHoursLayout, preferences.QuietHours.End)
+
+	if err != nil {
+		return errors.New("Invalid quiet hours end")
+	}
+
+	_, err = time.LoadLocation(preferences.QuietHours.TimeZone)
+
+	if err != nil {
+		return errors.New("Invalid quiet hours time zone")
+	}
+
+	return nil
+}
+
+// AllowsNotification : Check if a notification of conversationID may be sent at instant now
+func (preferences *NotificationPreferences) AllowsNotification(conversationID string, now time.Time) bool {
+
+	muted := preferences.Muted
+
+	for _, override := range preferences.ConversationOverrides {
+		if override.ConversationID == conversationID {
+			muted = override.Muted
+			break
+		}
+	}
+
+	if muted {
+		return false
+	}
+
+	return !preferences.QuietHours.Contains(now)
+}
+
+// Contains : Check if instant now is within quiet hours
+func (quietHours *QuietHours) Contains(now time.Time) bool {
+
+	if quietHours == nil {
+		return false
+	}
+
+	location, err := time.LoadLocation(quietHours.TimeZone)
+
+	if err != nil {
+		return false
+	}
+
+	start, err := time.Parse(QuietHoursLayout, quietHours.Start)
+
+	if err != nil {
+		return false
+	}
+
+	end, err := time.Parse(QuietHoursLayout, quietHours.End)
+
+	if err != nil {
+		return false
+	}
+
+	now = now.In(location)
+
+	minutes := now.Hour()*60 + now.Minute()
+	startMinutes := start.Hour()*60 + start.Minute()
+	endMinutes := end.Hour()*60 + end.Minute()
+
+	// Quiet hours may span over midnight (e.g. 22:00 - 07:00)
+	if startMinutes <= endMinutes {
+		return minutes >= startMinutes && minutes < endMinutes
+	}
+
+	return minutes >= startMinutes || minutes < endMinutes
+}
@@ -0,0 +1,46 @@
+package models
+
+const (
+	// PrivateConversationType : Conversation type of private conversations topics
+	PrivateConversationType = "private"
+
+	// GroupConversationType : Conversation type of group conversations topics
+	GroupConversationType = "group"
+
+	// NotificationJobOfflineMessage : Notification job of a message queued for an offline client
+	NotificationJobOfflineMessage = "offlineMessage"
+
+	// DefaultNotificationQueue : RabbitMQ queue of push notification jobs, used when none is configured
+	DefaultNotificationQueue = "wave.notifications"
+
+	// DefaultNotificationQueuePrefetch : Notification jobs dispatched concurrently by each push dispatcher, used when none is configured
+	DefaultNotificationQueuePrefetch = 10
+)
+
+// NotifierInterface : Push notifications dispatching interface
+type NotifierInterface interface {
+	NotifyOfflineMessage(offlineMessage *OfflineMessage) error
+}
+
+// OfflineMessage : Message queued by the broker for an offline client (VerneMQ on_offline_message hook)
+type OfflineMessage struct {
+	Mountpoint string `json:"mountpoint"`
+	ClientID   string `json:"client_id"`
+	QoS        int    `json:"qos"`
+	Topic      string `json:"topic"`
+	Payload    string `json:"payload"`
+	Retain     bool   `json:"retain"`
+}
+
+// NotificationJob : Push notification job queued for push dispatchers, Type telling which of its fields is set
+type NotificationJob struct {
+	Type           string          `json:"type"`
+	OfflineMessage *OfflineMessage `json:"offlineMessage,omitempty"`
+}
+
+// ConversationTopic : Informations carried by a conversation topic path
+type ConversationTopic struct {
+	ConversationType string
+	ConversationID   string
+	SenderID         string
+}
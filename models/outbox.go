@@ -0,0 +1,68 @@
+package models
+
+import (
+	json "encoding/json"
+	time "time"
+)
+
+const (
+	// DefaultOutboxPollInterval : Seconds between two lookups of outbox events to relay, used when none is configured
+	DefaultOutboxPollInterval = 1
+
+	// DefaultOutboxBatchSize : Outbox events relayed per lookup, used when none is configured
+	DefaultOutboxBatchSize = 100
+
+	// DefaultOutboxLeaseTimeout : Seconds an instance has to relay the outbox events it claimed, used when none is configured
+	DefaultOutboxLeaseTimeout = 30
+)
+
+// OutboxConfig : Transactional outbox Config. Relays look up events every PollInterval seconds, BatchSize events at a time,
+// and lease them for LeaseTimeout seconds, so that events claimed by a stopped instance are relayed by another one
+type OutboxConfig struct {
+	PollInterval int `json:"pollInterval"`
+	BatchSize    int `json:"batchSize"`
+	LeaseTimeout int `json:"leaseTimeout"`
+}
+
+// OutboxEvent : Lifecycle event (Payload) written in the same transaction as the conversation or ACL mutation it stems from,
+// until relayed to the lifecycle events transport and outbound webhooks. ID is the event ID
+type OutboxEvent struct {
+	ID         string    `bson:"id"`
+	TenantID   string    `bson:"tenantID,omitempty"`
+	EventType  string    `bson:"eventType"`
+	Payload    []byte    `bson:"payload"`
+	CreatedAt  time.Time `bson:"createdAt"`
+	LeaseUntil time.Time `bson:"leaseUntil"`
+}
+
+// NewOutboxEvent : Return outbox event of lifecycle event, ready to be relayed
+func NewOutboxEvent(event *LifecycleEvent) (*OutboxEvent, error) {
+
+	payload, err := json.Marshal(event)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &OutboxEvent{
+		ID:        event.ID,
+		TenantID:  event.TenantID,
+		EventType: event.Type,
+		Payload:   payload,
+		CreatedAt: time.Now().UTC(),
+	}, nil
+}
+
+// LifecycleEvent : Return lifecycle event of outbox event
+func (outboxEvent *OutboxEvent) LifecycleEvent() (*LifecycleEvent, error) {
+
+	event := &LifecycleEvent{}
+
+	err := json.Unmarshal(outboxEvent.Payload, event)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return event, nil
+}
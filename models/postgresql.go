@@ -0,0 +1,1885 @@
+package models
+
+import (
+	context "context"
+	sql "database/sql"
+	json "encoding/json"
+	errors "errors"
+	fmt "fmt"
+	strconv "strconv"
+	strings "strings"
+	time "time"
+	utils "wave-messaging-management-service/utils"
+
+	pq "github.com/lib/pq"
+)
+
+const (
+	// aclColumns : Columns of VerneMQ ACLs, in the order they are scanned (See scanACL)
+	aclColumns = "mountpoint, client_id, username, password, publish_acl, subscribe_acl, device_name, expires_at"
+
+	// deletedACLColumns : Columns of VerneMQ ACLs tombstones, in the order they are scanned (See GetDeletedACLs)
+	deletedACLColumns = aclColumns + ", deleted_at"
+
+	// groupConversationColumns : Columns of group conversations, in the order they are scanned (See scanGroupConversation)
+	groupConversationColumns = "group_conversation_id, name, creator_id, members, deleted_at"
+
+	// webhookColumns : Columns of outbound webhooks, in the order they are scanned (See scanWebhook)
+	webhookColumns = "id, url, events, secret, created_at"
+
+	// webhookDeliveryColumns : Columns of webhook deliveries, in the order they are scanned (See queryWebhookDeliveries)
+	webhookDeliveryColumns = "id, webhook_id, tenant_id, event_id, event_type, status, attempts, response_status, error, payload, created_at, next_attempt_at, delivered_at, lease_until"
+
+	// webhookDeadLetterColumns : Columns of webhook dead letters, in the order they are scanned (See scanWebhookDeadLetter)
+	webhookDeadLetterColumns = "id, webhook_id, tenant_id, delivery_id, url, event_type, attempts, response_status, error, payload, dead_lettered_at"
+)
+
+var (
+	// postgreSQLSchema : Statements creating tables and indexes of the service unless they exist, run in order.
+	// VerneMQ ACLs follow the layout read by the vmq_diversity PostgreSQL plugin, tenant data is told apart by tenant_id ('' for the default tenant)
+	postgreSQLSchema = []string{
+		`CREATE TABLE IF NOT EXISTS vmq_auth_acl (
+			mountpoint VARCHAR(10) NOT NULL,
+			client_id VARCHAR(128) NOT NULL,
+			username VARCHAR(128) NOT NULL,
+			password TEXT,
+			publish_acl JSONB NOT NULL DEFAULT '[]',
+			subscribe_acl JSONB NOT NULL DEFAULT '[]',
+			device_name TEXT NOT NULL DEFAULT '',
+			expires_at TIMESTAMPTZ,
+			PRIMARY KEY (mountpoint, client_id)
+		)`,
+		`CREATE UNIQUE INDEX IF NOT EXISTS vmq_auth_acl_client_id ON vmq_auth_acl (client_id)`,
+		`CREATE INDEX IF NOT EXISTS vmq_auth_acl_username ON vmq_auth_acl (username)`,
+		`CREATE INDEX IF NOT EXISTS vmq_auth_acl_expires_at ON vmq_auth_acl (expires_at) WHERE expires_at IS NOT NULL`,
+		`CREATE TABLE IF NOT EXISTS deleted_acls (
+			mountpoint VARCHAR(10) NOT NULL,
+			client_id VARCHAR(128) PRIMARY KEY,
+			username VARCHAR(128) NOT NULL,
+			password TEXT,
+			publish_acl JSONB NOT NULL DEFAULT '[]',
+			subscribe_acl JSONB NOT NULL DEFAULT '[]',
+			device_name TEXT NOT NULL DEFAULT '',
+			expires_at TIMESTAMPTZ,
+			deleted_at TIMESTAMPTZ NOT NULL
+		)`,
+		`CREATE INDEX IF NOT EXISTS deleted_acls_username ON deleted_acls (username)`,
+		`CREATE INDEX IF NOT EXISTS deleted_acls_deleted_at ON deleted_acls (deleted_at)`,
+		`CREATE TABLE IF NOT EXISTS group_conversations (
+			tenant_id TEXT NOT NULL DEFAULT '',
+			group_conversation_id TEXT NOT NULL,
+			name TEXT NOT NULL,
+			creator_id TEXT NOT NULL DEFAULT '',
+			members TEXT[] NOT NULL DEFAULT '{}',
+			PRIMARY KEY (tenant_id, group_conversation_id)
+		)`,
+		`CREATE INDEX IF NOT EXISTS group_conversations_members ON group_conversations USING GIN (members)`,
+		`CREATE INDEX IF NOT EXISTS group_conversations_creator_id ON group_conversations (tenant_id, creator_id)`,
+		`ALTER TABLE group_conversations ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMPTZ`,
+		`CREATE INDEX IF NOT EXISTS group_conversations_deleted_at ON group_conversations (deleted_at) WHERE deleted_at IS NOT NULL`,
+		`CREATE TABLE IF NOT EXISTS push_tokens (
+			tenant_id TEXT NOT NULL DEFAULT '',
+			token TEXT NOT NULL,
+			user_id TEXT NOT NULL,
+			platform TEXT NOT NULL,
+			device_client_id TEXT NOT NULL DEFAULT '',
+			app_version TEXT NOT NULL DEFAULT '',
+			locale TEXT NOT NULL DEFAULT '',
+			p256dh TEXT NOT NULL DEFAULT '',
+			auth TEXT NOT NULL DEFAULT '',
+			updated_at TIMESTAMPTZ NOT NULL,
+			PRIMARY KEY (tenant_id, token)
+		)`,
+		`CREATE INDEX IF NOT EXISTS push_tokens_user_id ON push_tokens (tenant_id, user_id)`,
+		`CREATE TABLE IF NOT EXISTS notification_preferences (
+			tenant_id TEXT NOT NULL DEFAULT '',
+			user_id TEXT NOT NULL,
+			muted BOOLEAN NOT NULL DEFAULT FALSE,
+			quiet_hours JSONB,
+			conversation_overrides JSONB NOT NULL DEFAULT '[]',
+			PRIMARY KEY (tenant_id, user_id)
+		)`,
+		`CREATE TABLE IF NOT EXISTS quota_overrides (
+			tenant_id TEXT NOT NULL DEFAULT '',
+			user_id TEXT NOT NULL,
+			max_conversations INTEGER,
+			max_group_memberships INTEGER,
+			max_stored_messages INTEGER,
+			max_attachments_bytes BIGINT,
+			PRIMARY KEY (tenant_id, user_id)
+		)`,
+		`CREATE TABLE IF NOT EXISTS api_keys (
+			hashed_key TEXT PRIMARY KEY,
+			service_name TEXT NOT NULL,
+			tenant_id TEXT NOT NULL DEFAULT '',
+			scopes TEXT[] NOT NULL DEFAULT '{}',
+			disabled BOOLEAN NOT NULL DEFAULT FALSE,
+			created_at TIMESTAMPTZ NOT NULL
+		)`,
+		`CREATE TABLE IF NOT EXISTS usage (
+			date TEXT NOT NULL,
+			tenant_id TEXT NOT NULL DEFAULT '',
+			user_id TEXT NOT NULL,
+			acls BIGINT NOT NULL DEFAULT 0,
+			conversations_created BIGINT NOT NULL DEFAULT 0,
+			messages_stored BIGINT NOT NULL DEFAULT 0,
+			pushes_sent BIGINT NOT NULL DEFAULT 0,
+			PRIMARY KEY (date, tenant_id, user_id)
+		)`,
+		`CREATE TABLE IF NOT EXISTS audit_log (
+			id BIGSERIAL PRIMARY KEY,
+			action TEXT NOT NULL,
+			actor_type TEXT NOT NULL,
+			actor_id TEXT NOT NULL,
+			target TEXT NOT NULL,
+			tenant_id TEXT NOT NULL DEFAULT '',
+			details JSONB,
+			request_id TEXT NOT NULL DEFAULT '',
+			timestamp TIMESTAMPTZ NOT NULL
+		)`,
+		`CREATE INDEX IF NOT EXISTS audit_log_timestamp ON audit_log (timestamp DESC)`,
+		`CREATE INDEX IF NOT EXISTS audit_log_actor_id ON audit_log (actor_id, timestamp DESC)`,
+		`CREATE INDEX IF NOT EXISTS audit_log_target ON audit_log (target, timestamp DESC)`,
+		`CREATE TABLE IF NOT EXISTS webhooks (
+			tenant_id TEXT NOT NULL DEFAULT '',
+			id TEXT NOT NULL,
+			url TEXT NOT NULL,
+			events TEXT[] NOT NULL DEFAULT '{}',
+			secret TEXT NOT NULL,
+			created_at TIMESTAMPTZ NOT NULL,
+			PRIMARY KEY (tenant_id, id)
+		)`,
+		`CREATE TABLE IF NOT EXISTS webhook_deliveries (
+			id TEXT PRIMARY KEY,
+			webhook_id TEXT NOT NULL,
+			tenant_id TEXT NOT NULL DEFAULT '',
+			event_id TEXT NOT NULL,
+			event_type TEXT NOT NULL,
+			status TEXT NOT NULL,
+			attempts INTEGER NOT NULL DEFAULT 0,
+			response_status INTEGER NOT NULL DEFAULT 0,
+			error TEXT NOT NULL DEFAULT '',
+			payload BYTEA,
+			created_at TIMESTAMPTZ NOT NULL,
+			next_attempt_at TIMESTAMPTZ,
+			delivered_at TIMESTAMPTZ,
+			lease_until TIMESTAMPTZ NOT NULL
+		)`,
+		`CREATE INDEX IF NOT EXISTS webhook_deliveries_webhook_id ON webhook_deliveries (webhook_id, created_at DESC)`,
+		`CREATE INDEX IF NOT EXISTS webhook_deliveries_status ON webhook_deliveries (status, next_attempt_at)`,
+		`CREATE TABLE IF NOT EXISTS webhook_dead_letters (
+			id TEXT PRIMARY KEY,
+			webhook_id TEXT NOT NULL,
+			tenant_id TEXT NOT NULL DEFAULT '',
+			delivery_id TEXT NOT NULL,
+			url TEXT NOT NULL,
+			event_type TEXT NOT NULL,
+			attempts INTEGER NOT NULL DEFAULT 0,
+			response_status INTEGER NOT NULL DEFAULT 0,
+			error TEXT NOT NULL DEFAULT '',
+			payload BYTEA,
+			dead_lettered_at TIMESTAMPTZ NOT NULL
+		)`,
+		`CREATE INDEX IF NOT EXISTS webhook_dead_letters_tenant_id ON webhook_dead_letters (tenant_id, dead_lettered_at DESC)`,
+		`CREATE INDEX IF NOT EXISTS webhook_dead_letters_webhook_id ON webhook_dead_letters (webhook_id)`,
+		`CREATE TABLE IF NOT EXISTS outbox (
+			id TEXT PRIMARY KEY,
+			tenant_id TEXT NOT NULL DEFAULT '',
+			event_type TEXT NOT NULL,
+			payload BYTEA,
+			created_at TIMESTAMPTZ NOT NULL,
+			lease_until TIMESTAMPTZ NOT NULL
+		)`,
+		`CREATE INDEX IF NOT EXISTS outbox_lease_until ON outbox (lease_until, created_at)`,
+		`CREATE TABLE IF NOT EXISTS migrations (
+			version INTEGER PRIMARY KEY,
+			name TEXT NOT NULL,
+			started_at TIMESTAMPTZ NOT NULL,
+			lease_until TIMESTAMPTZ,
+			applied_at TIMESTAMPTZ
+		)`,
+	}
+
+	// usageColumns : Columns of usage counters, by metric name
+	usageColumns = map[string]string{
+		UsageACLs:                 "acls",
+		UsageConversationsCreated: "conversations_created",
+		UsageMessagesStored:       "messages_stored",
+		UsagePushesSent:           "pushes_sent",
+	}
+)
+
+// PostgreSQLConfig : PostgreSQL client Config, read at startup except operations timeouts (See DatastoreConfig).
+// URL is a connection URL or DSN (e.g. postgres://wave@localhost/wave?sslmode=verify-full), read from URLFile when set.
+// Connections are pooled, up to MaxOpenConns connections (Unlimited by default) of which MaxIdleConns are kept idle, for ConnMaxLifetime milliseconds at most
+type PostgreSQLConfig struct {
+	DatastoreConfig
+	URL             string `json:"url"`
+	URLFile         string `json:"urlFile"`
+	MaxOpenConns    int    `json:"maxOpenConns"`
+	MaxIdleConns    int    `json:"maxIdleConns"`
+	ConnMaxLifetime int    `json:"connMaxLifetime"`
+}
+
+// PostgreSQL : Store backed by PostgreSQL, for deployments that can't run MongoDB. Tenant data shares the tables of the default tenant,
+// rows of TenantID being told apart by their tenant_id column. Change streams are not supported
+type PostgreSQL struct {
+	DB       *sql.DB
+	TenantID string
+}
+
+// rowScanner : Row of a query result, single (sql.Row) or iterated (sql.Rows)
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// sqlExecutor : Executor of statements, the database or one of its transactions
+type sqlExecutor interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+}
+
+// sqlQuery : Conditions of the WHERE clause of a query built from a filter, along with their arguments
+type sqlQuery struct {
+	conditions []string
+	args       []interface{}
+}
+
+// NewPostgreSQL : Return a new PostgreSQL abstraction struct, connected according to config. Connections are established when first used
+func NewPostgreSQL(config *PostgreSQLConfig) *PostgreSQL {
+
+	url, err := readSecret(config.URL, config.URLFile)
+
+	if err != nil {
+		utils.PanicOnError(err, "Failed to read PostgreSQL URL")
+	}
+
+	db, err := sql.Open("postgres", url)
+
+	if err != nil {
+		utils.PanicOnError(err, "Failed to configure PostgreSQL client")
+	}
+
+	db.SetMaxOpenConns(config.MaxOpenConns)
+	db.SetMaxIdleConns(config.MaxIdleConns)
+	db.SetConnMaxLifetime(time.Duration(config.ConnMaxLifetime) * time.Millisecond)
+
+	return &PostgreSQL{DB: db}
+}
+
+// Ping : Check PostgreSQL answers before ctx is done
+func (postgreSQL *PostgreSQL) Ping(ctx context.Context) error {
+	return postgreSQL.DB.PingContext(ctx)
+}
+
+// Close : Close connections of the pool, once operations are completed
+func (postgreSQL *PostgreSQL) Close() error {
+	return postgreSQL.DB.Close()
+}
+
+// ForTenant : Return PostgreSQL abstraction struct scoped to tenantID. As with MongoDB, VerneMQ ACLs, API keys, usage, audit log,
+// webhook deliveries, dead letters and outbox are shared by tenants, their rows holding their tenant
+func (postgreSQL *PostgreSQL) ForTenant(tenantID string) Store {
+	return &PostgreSQL{DB: postgreSQL.DB, TenantID: tenantID}
+}
+
+// CreateGroupConversations : Add group conversations in database, and grant access to their members under groupTopicPath,
+// along with events in a single transaction (See updateProfilesWithGroupACLs)
+func (postgreSQL *PostgreSQL) CreateGroupConversations(ctx context.Context, groupConversations []*GroupConversation, groupTopicPath string, events ...*LifecycleEvent) error {
+
+	if len(groupConversations) == 0 {
+		return nil
+	}
+
+	return postgreSQL.withOutbox(ctx, events, func(tx *sql.Tx) error {
+
+		for _, groupConversation := range groupConversations {
+
+			_, err := tx.ExecContext(
+				ctx,
+				`INSERT INTO group_conversations (tenant_id, group_conversation_id, name, creator_id, members) VALUES ($1, $2, $3, $4, $5)`,
+				postgreSQL.TenantID, groupConversation.GroupConversationID, groupConversation.Name, groupConversation.CreatorID, pq.Array(groupConversation.Members),
+			)
+
+			if err != nil {
+				return err
+			}
+		}
+
+		return updateProfilesWithGroupACLs(ctx, tx, groupConversations, groupTopicPath)
+	})
+}
+
+// GetGroupConversation : Get group conversation entry from database, unless removed
+func (postgreSQL *PostgreSQL) GetGroupConversation(ctx context.Context, groupConversationID string) (*GroupConversation, error) {
+
+	row := postgreSQL.DB.QueryRowContext(
+		ctx,
+		`SELECT `+groupConversationColumns+` FROM group_conversations WHERE tenant_id = $1 AND group_conversation_id = $2 AND deleted_at IS NULL`,
+		postgreSQL.TenantID, groupConversationID,
+	)
+
+	return scanGroupConversation(row)
+}
+
+// CountCreatedGroupConversations : Count group conversations created by userID
+func (postgreSQL *PostgreSQL) CountCreatedGroupConversations(ctx context.Context, userID string) (int, error) {
+	return postgreSQL.count(ctx, `SELECT COUNT(*) FROM group_conversations WHERE tenant_id = $1 AND creator_id = $2 AND deleted_at IS NULL`, postgreSQL.TenantID, userID)
+}
+
+// CountGroupMemberships : Count group conversations userID is a member of
+func (postgreSQL *PostgreSQL) CountGroupMemberships(ctx context.Context, userID string) (int, error) {
+	return postgreSQL.count(ctx, `SELECT COUNT(*) FROM group_conversations WHERE tenant_id = $1 AND members @> ARRAY[$2]::TEXT[] AND deleted_at IS NULL`, postgreSQL.TenantID, userID)
+}
+
+// GetGroupMemberships : Get page of group conversations userID is a member of sorted by name, and their total count
+func (postgreSQL *PostgreSQL) GetGroupMemberships(ctx context.Context, userID string, pagination *utils.Pagination) ([]*GroupConversation, int, error) {
+
+	total, err := postgreSQL.CountGroupMemberships(ctx, userID)
+
+	if err != nil {
+		return nil, 0, err
+	}
+
+	rows, err := postgreSQL.DB.QueryContext(
+		ctx,
+		`SELECT `+groupConversationColumns+` FROM group_conversations WHERE tenant_id = $1 AND members @> ARRAY[$2]::TEXT[] AND deleted_at IS NULL
+		ORDER BY name, group_conversation_id LIMIT $3 OFFSET $4`,
+		postgreSQL.TenantID, userID, pagination.Limit, pagination.Offset,
+	)
+
+	if err != nil {
+		return nil, 0, err
+	}
+
+	defer rows.Close()
+
+	groupConversations := []*GroupConversation{}
+
+	for rows.Next() {
+
+		groupConversation, err := scanGroupConversation(rows)
+
+		if err != nil {
+			return nil, 0, err
+		}
+
+		groupConversations = append(groupConversations, groupConversation)
+	}
+
+	return groupConversations, total, rows.Err()
+}
+
+// RemoveGroupConversation : Mark group conversation removed at deletedAt and revoke access of its members under groupTopicPath,
+// along with events in a single transaction. Return the removed group conversation, nil if it does not exist or was already removed
+func (postgreSQL *PostgreSQL) RemoveGroupConversation(ctx context.Context, groupConversationID string, groupTopicPath string, deletedAt time.Time, events ...*LifecycleEvent) (*GroupConversation, error) {
+
+	var groupConversation *GroupConversation
+
+	err := postgreSQL.withOutbox(ctx, events, func(tx *sql.Tx) error {
+
+		var err error
+
+		groupConversation, err = scanGroupConversation(tx.QueryRowContext(
+			ctx,
+			`UPDATE group_conversations SET deleted_at = $3 WHERE tenant_id = $1 AND group_conversation_id = $2 AND deleted_at IS NULL
+			RETURNING `+groupConversationColumns,
+			postgreSQL.TenantID, groupConversationID, deletedAt,
+		))
+
+		if err != nil {
+			return err
+		}
+
+		return revokeGroupACLs(ctx, tx, groupConversation, groupTopicPath)
+	})
+
+	// Transaction is rolled back, so that no event is relayed for a group conversation which was not changed
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return groupConversation, nil
+}
+
+// RestoreGroupConversation : Unmark removed group conversation and grant access to its members under groupTopicPath again,
+// along with events in a single transaction. Return the restored group conversation, nil if it does not exist or was not removed
+func (postgreSQL *PostgreSQL) RestoreGroupConversation(ctx context.Context, groupConversationID string, groupTopicPath string, events ...*LifecycleEvent) (*GroupConversation, error) {
+
+	var groupConversation *GroupConversation
+
+	err := postgreSQL.withOutbox(ctx, events, func(tx *sql.Tx) error {
+
+		var err error
+
+		groupConversation, err = scanGroupConversation(tx.QueryRowContext(
+			ctx,
+			`UPDATE group_conversations SET deleted_at = NULL WHERE tenant_id = $1 AND group_conversation_id = $2 AND deleted_at IS NOT NULL
+			RETURNING `+groupConversationColumns,
+			postgreSQL.TenantID, groupConversationID,
+		))
+
+		if err != nil {
+			return err
+		}
+
+		return updateProfilesWithGroupACLs(ctx, tx, []*GroupConversation{groupConversation}, groupTopicPath)
+	})
+
+	// Transaction is rolled back, so that no event is relayed for a group conversation which was not changed
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return groupConversation, nil
+}
+
+// PurgeGroupConversations : Remove group conversations of every tenant for good, when removed before deletedBefore. Return the number purged
+func (postgreSQL *PostgreSQL) PurgeGroupConversations(ctx context.Context, deletedBefore time.Time) (int, error) {
+	return postgreSQL.purge(ctx, `DELETE FROM group_conversations WHERE deleted_at < $1`, deletedBefore)
+}
+
+// AddProfileACL : Add VerneMQ ACL for user in database, along with events in a single transaction
+// ACL is upserted by client ID as with MongoDB : credentials, device name and expiry of an existing ACL are replaced, and its patterns merged with the ones of verneMQACL
+func (postgreSQL *PostgreSQL) AddProfileACL(ctx context.Context, verneMQACL *VerneMQACL, events ...*LifecycleEvent) error {
+
+	publishACL, err := marshalPatterns(verneMQACL.PublishACL)
+
+	if err != nil {
+		return err
+	}
+
+	subscribeACL, err := marshalPatterns(verneMQACL.SubscribeACL)
+
+	if err != nil {
+		return err
+	}
+
+	return postgreSQL.withOutbox(ctx, events, func(tx *sql.Tx) error {
+
+		_, err := tx.ExecContext(
+			ctx,
+			`INSERT INTO vmq_auth_acl (`+aclColumns+`) VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+			ON CONFLICT (client_id) DO UPDATE SET
+				mountpoint = EXCLUDED.mountpoint,
+				username = EXCLUDED.username,
+				password = EXCLUDED.password,
+				publish_acl = `+patternsUnion("vmq_auth_acl.publish_acl || EXCLUDED.publish_acl")+`,
+				subscribe_acl = `+patternsUnion("vmq_auth_acl.subscribe_acl || EXCLUDED.subscribe_acl")+`,
+				device_name = EXCLUDED.device_name,
+				expires_at = EXCLUDED.expires_at`,
+			verneMQACL.Mountpoint, verneMQACL.ClientID, verneMQACL.Username, verneMQACL.Passhash,
+			publishACL, subscribeACL, verneMQACL.DeviceName, verneMQACL.ExpiresAt,
+		)
+
+		return err
+	})
+}
+
+// GetProfileACL : Get main VerneMQ ACL of userID (MQTT client ID matching internal user ID)
+func (postgreSQL *PostgreSQL) GetProfileACL(ctx context.Context, userID string) (*VerneMQACL, error) {
+	return scanACL(postgreSQL.DB.QueryRowContext(ctx, `SELECT `+aclColumns+` FROM vmq_auth_acl WHERE client_id = $1 AND username = $1`, userID))
+}
+
+// GetClientACL : Get VerneMQ ACL of MQTT client ID (Main profile or device)
+func (postgreSQL *PostgreSQL) GetClientACL(ctx context.Context, clientID string) (*VerneMQACL, error) {
+	return scanACL(postgreSQL.DB.QueryRowContext(ctx, `SELECT `+aclColumns+` FROM vmq_auth_acl WHERE client_id = $1`, clientID))
+}
+
+// GetUserACLs : Get all VerneMQ ACLs of userID (Main profile and devices)
+func (postgreSQL *PostgreSQL) GetUserACLs(ctx context.Context, userID string) ([]*VerneMQACL, error) {
+	return postgreSQL.queryACLs(ctx, `SELECT `+aclColumns+` FROM vmq_auth_acl WHERE username = $1`, userID)
+}
+
+// RemoveDeviceACL : Move VerneMQ ACL of one of userID devices to tombstones, along with events in a single transaction
+// Main profile ACL (client ID matching user ID) can't be removed this way
+func (postgreSQL *PostgreSQL) RemoveDeviceACL(ctx context.Context, userID string, deviceClientID string, events ...*LifecycleEvent) error {
+
+	if deviceClientID == userID {
+		return fmt.Errorf("error removing device %s : main profile can't be removed", deviceClientID)
+	}
+
+	return postgreSQL.withOutbox(ctx, events, func(tx *sql.Tx) error {
+
+		removed, err := moveACLsToTombstones(ctx, tx, `client_id = $2 AND username = $3`, time.Now().UTC(), deviceClientID, userID)
+
+		if err != nil {
+			return err
+		}
+
+		// Transaction is rolled back, so that no event is relayed for a device which did not exist
+		if removed == 0 {
+			return fmt.Errorf("error removing device %s : no such device for user %s", deviceClientID, userID)
+		}
+
+		return nil
+	})
+}
+
+// RemoveUserACLs : Move all VerneMQ ACLs of userID (Main profile and devices) to tombstones, along with events in a single transaction
+func (postgreSQL *PostgreSQL) RemoveUserACLs(ctx context.Context, userID string, events ...*LifecycleEvent) error {
+
+	return postgreSQL.withOutbox(ctx, events, func(tx *sql.Tx) error {
+
+		_, err := moveACLsToTombstones(ctx, tx, `username = $2`, time.Now().UTC(), userID)
+
+		return err
+	})
+}
+
+// GetExpiredACLs : Get VerneMQ ACLs expired at now
+func (postgreSQL *PostgreSQL) GetExpiredACLs(ctx context.Context, now time.Time) ([]*VerneMQACL, error) {
+	return postgreSQL.queryACLs(ctx, `SELECT `+aclColumns+` FROM vmq_auth_acl WHERE expires_at <= $1`, now)
+}
+
+// RenewACLs : Push back expiry date of all VerneMQ ACLs of userID (Main profile and devices)
+// Return false if user has no ACL left
+func (postgreSQL *PostgreSQL) RenewACLs(ctx context.Context, userID string, expiresAt time.Time) (bool, error) {
+
+	res, err := postgreSQL.DB.ExecContext(ctx, `UPDATE vmq_auth_acl SET expires_at = $2 WHERE username = $1`, userID, expiresAt)
+
+	if err != nil {
+		return false, err
+	}
+
+	updated, err := res.RowsAffected()
+
+	if err != nil {
+		return false, err
+	}
+
+	return updated > 0, nil
+}
+
+// RemoveExpiredACLs : Move VerneMQ ACLs expired at now (Guests and stale users) to tombstones, along with events in a single transaction
+// ACLs without expiry date are kept
+func (postgreSQL *PostgreSQL) RemoveExpiredACLs(ctx context.Context, now time.Time, events ...*LifecycleEvent) error {
+
+	return postgreSQL.withOutbox(ctx, events, func(tx *sql.Tx) error {
+
+		_, err := moveACLsToTombstones(ctx, tx, `expires_at <= $1`, now)
+
+		return err
+	})
+}
+
+// AuthorizePublishing : Authorize publishing on MQTT topic for userID (On all its devices). Topics already authorized are not added again
+func (postgreSQL *PostgreSQL) AuthorizePublishing(ctx context.Context, userID string, topic string) error {
+
+	publishACL, err := marshalPatterns([]*ACL{{Pattern: topic}})
+
+	if err != nil {
+		return err
+	}
+
+	_, err = postgreSQL.DB.ExecContext(
+		ctx,
+		`UPDATE vmq_auth_acl SET publish_acl = `+patternsUnion("publish_acl || $2::JSONB")+` WHERE username = $1`,
+		userID, publishACL,
+	)
+
+	return err
+}
+
+// updateProfilesWithGroupACLs : Update VerneMQ ACLs within tx to grant publish and read access to all members of group conversations.
+// ACLs are granted on every device of each member under groupTopicPath, with the patterns of all its conversations. Patterns already granted are not added again
+func updateProfilesWithGroupACLs(ctx context.Context, tx sqlExecutor, groupConversations []*GroupConversation, groupTopicPath string) error {
+
+	publishPatterns := map[string][]*ACL{}
+	subscribePatterns := map[string][]*ACL{}
+	userIDs := []string{}
+
+	for _, groupConversation := range groupConversations {
+		for _, userID := range groupConversation.Members {
+
+			if _, ok := publishPatterns[userID]; !ok {
+				userIDs = append(userIDs, userID)
+			}
+
+			publishPatterns[userID] = append(publishPatterns[userID], &ACL{Pattern: groupTopicPath + groupConversation.GroupConversationID + "/" + userID})
+			subscribePatterns[userID] = append(subscribePatterns[userID], &ACL{Pattern: groupTopicPath + groupConversation.GroupConversationID + "/+"})
+		}
+	}
+
+	for _, userID := range userIDs {
+
+		publishACL, err := marshalPatterns(publishPatterns[userID])
+
+		if err != nil {
+			return err
+		}
+
+		subscribeACL, err := marshalPatterns(subscribePatterns[userID])
+
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.ExecContext(
+			ctx,
+			`UPDATE vmq_auth_acl SET
+				publish_acl = `+patternsUnion("publish_acl || $2::JSONB")+`,
+				subscribe_acl = `+patternsUnion("subscribe_acl || $3::JSONB")+`
+			WHERE username = $1`,
+			userID, publishACL, subscribeACL,
+		)
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// revokeGroupACLs : Update VerneMQ ACLs within tx to revoke access of the members of groupConversation under groupTopicPath.
+// Tombstones of their ACLs are updated too, so that restoring them doesn't grant access to the removed group conversation again
+func revokeGroupACLs(ctx context.Context, tx sqlExecutor, groupConversation *GroupConversation, groupTopicPath string) error {
+
+	publishPatterns := make([]*ACL, 0, len(groupConversation.Members))
+
+	for _, userID := range groupConversation.Members {
+		publishPatterns = append(publishPatterns, &ACL{Pattern: groupTopicPath + groupConversation.GroupConversationID + "/" + userID})
+	}
+
+	publishACL, err := marshalPatterns(publishPatterns)
+
+	if err != nil {
+		return err
+	}
+
+	subscribeACL, err := marshalPatterns([]*ACL{{Pattern: groupTopicPath + groupConversation.GroupConversationID + "/+"}})
+
+	if err != nil {
+		return err
+	}
+
+	for _, table := range []string{"vmq_auth_acl", "deleted_acls"} {
+
+		_, err = tx.ExecContext(
+			ctx,
+			`UPDATE `+table+` SET
+				publish_acl = `+patternsDifference("publish_acl", "$2::JSONB")+`,
+				subscribe_acl = `+patternsDifference("subscribe_acl", "$3::JSONB")+`
+			WHERE username = ANY($1)`,
+			pq.Array(groupConversation.Members), publishACL, subscribeACL,
+		)
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// UpdatePassHash : Update passhash of VerneMQ ACLs of userID, along with events in a single transaction
+// Devices share the user token, so passhash is updated on all of them
+func (postgreSQL *PostgreSQL) UpdatePassHash(ctx context.Context, userID string, newPasshash string, events ...*LifecycleEvent) error {
+
+	return postgreSQL.withOutbox(ctx, events, func(tx *sql.Tx) error {
+
+		_, err := tx.ExecContext(ctx, `UPDATE vmq_auth_acl SET password = $2 WHERE username = $1`, userID, newPasshash)
+
+		return err
+	})
+}
+
+// DedupACLPatterns : Remove duplicate patterns of VerneMQ ACLs with a single update run by the server.
+// Order of patterns is not kept, as VerneMQ matches all of them. Return the number of ACLs deduplicated
+func (postgreSQL *PostgreSQL) DedupACLPatterns(ctx context.Context) (int, error) {
+
+	// Only ACLs holding duplicates are rewritten
+	res, err := postgreSQL.DB.ExecContext(
+		ctx,
+		`UPDATE vmq_auth_acl SET
+			publish_acl = `+patternsUnion("publish_acl")+`,
+			subscribe_acl = `+patternsUnion("subscribe_acl")+`
+		WHERE jsonb_array_length(`+patternsUnion("publish_acl")+`) < jsonb_array_length(publish_acl)
+			OR jsonb_array_length(`+patternsUnion("subscribe_acl")+`) < jsonb_array_length(subscribe_acl)`,
+	)
+
+	if err != nil {
+		return 0, err
+	}
+
+	deduplicated, err := res.RowsAffected()
+
+	if err != nil {
+		return 0, err
+	}
+
+	return int(deduplicated), nil
+}
+
+// GetDeletedACLs : Get tombstones of the removed VerneMQ ACLs of userID (Main profile and devices)
+func (postgreSQL *PostgreSQL) GetDeletedACLs(ctx context.Context, userID string) ([]*DeletedACL, error) {
+
+	rows, err := postgreSQL.DB.QueryContext(ctx, `SELECT `+deletedACLColumns+` FROM deleted_acls WHERE username = $1`, userID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	deletedACLs := []*DeletedACL{}
+
+	for rows.Next() {
+
+		var deletedAt time.Time
+
+		verneMQACL, err := scanACL(rows, &deletedAt)
+
+		if err != nil {
+			return nil, err
+		}
+
+		deletedACLs = append(deletedACLs, &DeletedACL{VerneMQACL: *verneMQACL, DeletedAt: deletedAt})
+	}
+
+	return deletedACLs, rows.Err()
+}
+
+// RestoreUserACLs : Move tombstones of the removed VerneMQ ACLs of userID back to VerneMQ ACLs, along with events in a single transaction.
+// Tombstones of client IDs having an ACL again are left as they are. Return the number of ACLs restored
+func (postgreSQL *PostgreSQL) RestoreUserACLs(ctx context.Context, userID string, events ...*LifecycleEvent) (int, error) {
+
+	restored := 0
+
+	err := postgreSQL.withOutbox(ctx, events, func(tx *sql.Tx) error {
+
+		res, err := tx.ExecContext(
+			ctx,
+			`WITH restored AS (
+				DELETE FROM deleted_acls WHERE username = $1 AND client_id NOT IN (SELECT client_id FROM vmq_auth_acl)
+				RETURNING `+aclColumns+`
+			)
+			INSERT INTO vmq_auth_acl (`+aclColumns+`) SELECT `+aclColumns+` FROM restored`,
+			userID,
+		)
+
+		if err != nil {
+			return err
+		}
+
+		inserted, err := res.RowsAffected()
+
+		if err != nil {
+			return err
+		}
+
+		restored = int(inserted)
+
+		return nil
+	})
+
+	if err != nil {
+		return 0, err
+	}
+
+	return restored, nil
+}
+
+// PurgeDeletedACLs : Remove tombstones of VerneMQ ACLs removed before deletedBefore for good. Return the number purged
+func (postgreSQL *PostgreSQL) PurgeDeletedACLs(ctx context.Context, deletedBefore time.Time) (int, error) {
+	return postgreSQL.purge(ctx, `DELETE FROM deleted_acls WHERE deleted_at < $1`, deletedBefore)
+}
+
+// moveACLsToTombstones : Move VerneMQ ACLs matching condition to tombstones within tx, removed at deletedAt ($1, condition arguments following it).
+// Tombstones replace the ones of previous removals of their client IDs. Return the number of ACLs moved
+func moveACLsToTombstones(ctx context.Context, tx sqlExecutor, condition string, deletedAt time.Time, args ...interface{}) (int, error) {
+
+	res, err := tx.ExecContext(
+		ctx,
+		`WITH removed AS (
+			DELETE FROM vmq_auth_acl WHERE `+condition+` RETURNING `+aclColumns+`
+		)
+		INSERT INTO deleted_acls (`+deletedACLColumns+`) SELECT `+aclColumns+`, $1::TIMESTAMPTZ FROM removed
+		ON CONFLICT (client_id) DO UPDATE SET
+			mountpoint = EXCLUDED.mountpoint,
+			username = EXCLUDED.username,
+			password = EXCLUDED.password,
+			publish_acl = EXCLUDED.publish_acl,
+			subscribe_acl = EXCLUDED.subscribe_acl,
+			device_name = EXCLUDED.device_name,
+			expires_at = EXCLUDED.expires_at,
+			deleted_at = EXCLUDED.deleted_at`,
+		append([]interface{}{deletedAt}, args...)...,
+	)
+
+	if err != nil {
+		return 0, err
+	}
+
+	moved, err := res.RowsAffected()
+
+	if err != nil {
+		return 0, err
+	}
+
+	return int(moved), nil
+}
+
+// AddPushToken : Add device push token in database, replacing an existing entry of the same user with the same token.
+// A push token belongs to a single device : ErrPushTokenTaken if another user registered it
+func (postgreSQL *PostgreSQL) AddPushToken(ctx context.Context, pushToken *PushToken) error {
+
+	res, err := postgreSQL.DB.ExecContext(
+		ctx,
+		`INSERT INTO push_tokens (tenant_id, token, user_id, platform, device_client_id, app_version, locale, p256dh, auth, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		ON CONFLICT (tenant_id, token) DO UPDATE SET
+			user_id = EXCLUDED.user_id,
+			platform = EXCLUDED.platform,
+			device_client_id = EXCLUDED.device_client_id,
+			app_version = EXCLUDED.app_version,
+			locale = EXCLUDED.locale,
+			p256dh = EXCLUDED.p256dh,
+			auth = EXCLUDED.auth,
+			updated_at = EXCLUDED.updated_at
+		WHERE push_tokens.user_id = EXCLUDED.user_id`,
+		postgreSQL.TenantID, pushToken.Token, pushToken.UserID, pushToken.Platform, pushToken.DeviceClientID,
+		pushToken.AppVersion, pushToken.Locale, pushToken.P256dh, pushToken.Auth, pushToken.UpdatedAt,
+	)
+
+	if err != nil {
+		return err
+	}
+
+	upserted, err := res.RowsAffected()
+
+	if err != nil {
+		return err
+	}
+
+	// Conflicting entry of another user is left untouched
+	if upserted == 0 {
+		return ErrPushTokenTaken
+	}
+
+	return nil
+}
+
+// RemovePushToken : Remove device push token of userID from database
+func (postgreSQL *PostgreSQL) RemovePushToken(ctx context.Context, userID string, token string) error {
+
+	res, err := postgreSQL.DB.ExecContext(ctx, `DELETE FROM push_tokens WHERE tenant_id = $1 AND token = $2 AND user_id = $3`, postgreSQL.TenantID, token, userID)
+
+	if err != nil {
+		return err
+	}
+
+	deleted, err := res.RowsAffected()
+
+	if err != nil {
+		return err
+	}
+
+	if deleted == 0 {
+		return fmt.Errorf("error removing push token : no such token for user %s", userID)
+	}
+
+	return nil
+}
+
+// GetPushTokens : Get all devices push tokens of userID
+func (postgreSQL *PostgreSQL) GetPushTokens(ctx context.Context, userID string) ([]*PushToken, error) {
+
+	rows, err := postgreSQL.DB.QueryContext(
+		ctx,
+		`SELECT token, user_id, platform, device_client_id, app_version, locale, p256dh, auth, updated_at FROM push_tokens WHERE tenant_id = $1 AND user_id = $2`,
+		postgreSQL.TenantID, userID,
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	pushTokens := []*PushToken{}
+
+	for rows.Next() {
+
+		pushToken := &PushToken{}
+
+		err = rows.Scan(&pushToken.Token, &pushToken.UserID, &pushToken.Platform, &pushToken.DeviceClientID,
+			&pushToken.AppVersion, &pushToken.Locale, &pushToken.P256dh, &pushToken.Auth, &pushToken.UpdatedAt)
+
+		if err != nil {
+			return nil, err
+		}
+
+		pushTokens = append(pushTokens, pushToken)
+	}
+
+	return pushTokens, rows.Err()
+}
+
+// GetNotificationPreferences : Get push notifications settings of userID, defaults are returned if none were set
+func (postgreSQL *PostgreSQL) GetNotificationPreferences(ctx context.Context, userID string) (*NotificationPreferences, error) {
+
+	preferences := NewNotificationPreferences(userID)
+
+	var quietHours, conversationOverrides []byte
+
+	err := postgreSQL.DB.QueryRowContext(
+		ctx,
+		`SELECT muted, quiet_hours, conversation_overrides FROM notification_preferences WHERE tenant_id = $1 AND user_id = $2`,
+		postgreSQL.TenantID, userID,
+	).Scan(&preferences.Muted, &quietHours, &conversationOverrides)
+
+	if err == sql.ErrNoRows {
+		return preferences, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	err = unmarshalColumns(map[*[]byte]interface{}{
+		&quietHours:            &preferences.QuietHours,
+		&conversationOverrides: &preferences.ConversationOverrides,
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return preferences, nil
+}
+
+// SetNotificationPreferences : Replace push notifications settings of user
+func (postgreSQL *PostgreSQL) SetNotificationPreferences(ctx context.Context, preferences *NotificationPreferences) error {
+
+	quietHours, err := json.Marshal(preferences.QuietHours)
+
+	if err != nil {
+		return err
+	}
+
+	conversationOverrides, err := json.Marshal(preferences.ConversationOverrides)
+
+	if err != nil {
+		return err
+	}
+
+	_, err = postgreSQL.DB.ExecContext(
+		ctx,
+		`INSERT INTO notification_preferences (tenant_id, user_id, muted, quiet_hours, conversation_overrides) VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (tenant_id, user_id) DO UPDATE SET
+			muted = EXCLUDED.muted,
+			quiet_hours = EXCLUDED.quiet_hours,
+			conversation_overrides = EXCLUDED.conversation_overrides`,
+		postgreSQL.TenantID, preferences.UserID, preferences.Muted, quietHours, conversationOverrides,
+	)
+
+	return err
+}
+
+// AddAPIKey : Add hashed API key of an internal backend service in database
+func (postgreSQL *PostgreSQL) AddAPIKey(ctx context.Context, apiKey *APIKey) error {
+
+	_, err := postgreSQL.DB.ExecContext(
+		ctx,
+		`INSERT INTO api_keys (hashed_key, service_name, tenant_id, scopes, disabled, created_at) VALUES ($1, $2, $3, $4, $5, $6)`,
+		apiKey.HashedKey, apiKey.ServiceName, apiKey.TenantID, pq.Array(apiKey.Scopes), apiKey.Disabled, apiKey.CreatedAt,
+	)
+
+	return err
+}
+
+// GetAPIKey : Get API key matching hashedKey
+func (postgreSQL *PostgreSQL) GetAPIKey(ctx context.Context, hashedKey string) (*APIKey, error) {
+
+	apiKey := &APIKey{}
+
+	err := postgreSQL.DB.QueryRowContext(
+		ctx,
+		`SELECT hashed_key, service_name, tenant_id, scopes, disabled, created_at FROM api_keys WHERE hashed_key = $1`,
+		hashedKey,
+	).Scan(&apiKey.HashedKey, &apiKey.ServiceName, &apiKey.TenantID, pq.Array(&apiKey.Scopes), &apiKey.Disabled, &apiKey.CreatedAt)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return apiKey, nil
+}
+
+// GetQuotaOverride : Get quotas of userID overriding configured ones, nil if there is none
+func (postgreSQL *PostgreSQL) GetQuotaOverride(ctx context.Context, userID string) (*QuotaOverride, error) {
+
+	override := &QuotaOverride{UserID: userID}
+
+	err := postgreSQL.DB.QueryRowContext(
+		ctx,
+		`SELECT max_conversations, max_group_memberships, max_stored_messages, max_attachments_bytes FROM quota_overrides WHERE tenant_id = $1 AND user_id = $2`,
+		postgreSQL.TenantID, userID,
+	).Scan(&override.MaxConversations, &override.MaxGroupMemberships, &override.MaxStoredMessages, &override.MaxAttachmentsBytes)
+
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return override, nil
+}
+
+// SetQuotaOverride : Replace quotas of user overriding configured ones
+func (postgreSQL *PostgreSQL) SetQuotaOverride(ctx context.Context, override *QuotaOverride) error {
+
+	_, err := postgreSQL.DB.ExecContext(
+		ctx,
+		`INSERT INTO quota_overrides (tenant_id, user_id, max_conversations, max_group_memberships, max_stored_messages, max_attachments_bytes)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (tenant_id, user_id) DO UPDATE SET
+			max_conversations = EXCLUDED.max_conversations,
+			max_group_memberships = EXCLUDED.max_group_memberships,
+			max_stored_messages = EXCLUDED.max_stored_messages,
+			max_attachments_bytes = EXCLUDED.max_attachments_bytes`,
+		postgreSQL.TenantID, override.UserID, override.MaxConversations, override.MaxGroupMemberships, override.MaxStoredMessages, override.MaxAttachmentsBytes,
+	)
+
+	return err
+}
+
+// AddUsage : Add count to metric counter of userID on date, creating its usage record if needed
+func (postgreSQL *PostgreSQL) AddUsage(ctx context.Context, date string, tenantID string, userID string, metric string, count int) error {
+
+	// Columns can't be bound as arguments, only known ones are written in the statement
+	column, ok := usageColumns[metric]
+
+	if !ok {
+		return errors.New("Unknown usage metric " + metric)
+	}
+
+	_, err := postgreSQL.DB.ExecContext(
+		ctx,
+		`INSERT INTO usage (date, tenant_id, user_id, `+column+`) VALUES ($1, $2, $3, $4)
+		ON CONFLICT (date, tenant_id, user_id) DO UPDATE SET `+column+` = usage.`+column+` + EXCLUDED.`+column,
+		date, tenantID, userID, int64(count),
+	)
+
+	return err
+}
+
+// GetUsage : Get usage records matching filter
+func (postgreSQL *PostgreSQL) GetUsage(ctx context.Context, filter *UsageFilter) ([]*UsageRecord, error) {
+
+	query := &sqlQuery{}
+	query.where("date >= %s", filter.From)
+	query.where("date <= %s", filter.To)
+
+	if filter.TenantID != nil {
+		query.where("tenant_id = %s", *filter.TenantID)
+	}
+
+	if filter.UserID != "" {
+		query.where("user_id = %s", filter.UserID)
+	}
+
+	rows, err := postgreSQL.DB.QueryContext(
+		ctx,
+		`SELECT date, tenant_id, user_id, acls, conversations_created, messages_stored, pushes_sent FROM usage`+query.clause(),
+		query.args...,
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	records := []*UsageRecord{}
+
+	for rows.Next() {
+
+		record := &UsageRecord{}
+
+		err = rows.Scan(&record.Date, &record.TenantID, &record.UserID, &record.ACLs, &record.ConversationsCreated, &record.MessagesStored, &record.PushesSent)
+
+		if err != nil {
+			return nil, err
+		}
+
+		records = append(records, record)
+	}
+
+	return records, rows.Err()
+}
+
+// AddAuditEntry : Append entry to the audit log, entries are never updated nor removed
+func (postgreSQL *PostgreSQL) AddAuditEntry(ctx context.Context, entry *AuditEntry) error {
+
+	details, err := json.Marshal(entry.Details)
+
+	if err != nil {
+		return err
+	}
+
+	_, err = postgreSQL.DB.ExecContext(
+		ctx,
+		`INSERT INTO audit_log (action, actor_type, actor_id, target, tenant_id, details, request_id, timestamp) VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`,
+		entry.Action, entry.Actor.Type, entry.Actor.ID, entry.Target, entry.TenantID, details, entry.RequestID, entry.Timestamp,
+	)
+
+	return err
+}
+
+// GetAuditEntries : Return page of audit entries matching filter, most recent first, and number of matching entries
+func (postgreSQL *PostgreSQL) GetAuditEntries(ctx context.Context, filter *AuditFilter) ([]*AuditEntry, int, error) {
+
+	query := &sqlQuery{}
+
+	if filter.ActorType != "" {
+		query.where("actor_type = %s", filter.ActorType)
+	}
+
+	if filter.ActorID != "" {
+		query.where("actor_id = %s", filter.ActorID)
+	}
+
+	if filter.Target != "" {
+		query.where("target = %s", filter.Target)
+	}
+
+	if filter.Action != "" {
+		query.where("action = %s", filter.Action)
+	}
+
+	if filter.TenantID != nil {
+		query.where("tenant_id = %s", *filter.TenantID)
+	}
+
+	if filter.From != nil {
+		query.where("timestamp >= %s", *filter.From)
+	}
+
+	if filter.To != nil {
+		query.where("timestamp <= %s", *filter.To)
+	}
+
+	total, err := postgreSQL.count(ctx, `SELECT COUNT(*) FROM audit_log`+query.clause(), query.args...)
+
+	if err != nil {
+		return nil, 0, err
+	}
+
+	rows, err := postgreSQL.DB.QueryContext(
+		ctx,
+		`SELECT action, actor_type, actor_id, target, tenant_id, details, request_id, timestamp FROM audit_log`+query.clause()+
+			` ORDER BY timestamp DESC`+pageClause(filter.Page),
+		query.args...,
+	)
+
+	if err != nil {
+		return nil, 0, err
+	}
+
+	defer rows.Close()
+
+	entries := []*AuditEntry{}
+
+	for rows.Next() {
+
+		entry := &AuditEntry{}
+
+		var details []byte
+
+		err = rows.Scan(&entry.Action, &entry.Actor.Type, &entry.Actor.ID, &entry.Target, &entry.TenantID, &details, &entry.RequestID, &entry.Timestamp)
+
+		if err != nil {
+			return nil, 0, err
+		}
+
+		err = unmarshalColumns(map[*[]byte]interface{}{&details: &entry.Details})
+
+		if err != nil {
+			return nil, 0, err
+		}
+
+		entries = append(entries, entry)
+	}
+
+	return entries, total, rows.Err()
+}
+
+// AddWebhook : Add outbound webhook in database
+func (postgreSQL *PostgreSQL) AddWebhook(ctx context.Context, webhook *Webhook) error {
+
+	_, err := postgreSQL.DB.ExecContext(
+		ctx,
+		`INSERT INTO webhooks (tenant_id, `+webhookColumns+`) VALUES ($1, $2, $3, $4, $5, $6)`,
+		postgreSQL.TenantID, webhook.ID, webhook.URL, pq.Array(webhook.Events), webhook.Secret, webhook.CreatedAt,
+	)
+
+	return err
+}
+
+// GetWebhooks : Get outbound webhooks, oldest first
+func (postgreSQL *PostgreSQL) GetWebhooks(ctx context.Context) ([]*Webhook, error) {
+
+	rows, err := postgreSQL.DB.QueryContext(ctx, `SELECT `+webhookColumns+` FROM webhooks WHERE tenant_id = $1 ORDER BY created_at`, postgreSQL.TenantID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	webhooks := []*Webhook{}
+
+	for rows.Next() {
+
+		webhook, err := scanWebhook(rows)
+
+		if err != nil {
+			return nil, err
+		}
+
+		webhooks = append(webhooks, webhook)
+	}
+
+	return webhooks, rows.Err()
+}
+
+// GetWebhook : Get outbound webhook webhookID, nil if it does not exist
+func (postgreSQL *PostgreSQL) GetWebhook(ctx context.Context, webhookID string) (*Webhook, error) {
+
+	webhook, err := scanWebhook(postgreSQL.DB.QueryRowContext(
+		ctx,
+		`SELECT `+webhookColumns+` FROM webhooks WHERE tenant_id = $1 AND id = $2`,
+		postgreSQL.TenantID, webhookID,
+	))
+
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return webhook, nil
+}
+
+// RemoveWebhook : Remove outbound webhook webhookID, its deliveries and dead letters in a single transaction, returning false if it does not exist
+func (postgreSQL *PostgreSQL) RemoveWebhook(ctx context.Context, webhookID string) (bool, error) {
+
+	removed := false
+
+	err := postgreSQL.withOutbox(ctx, nil, func(tx *sql.Tx) error {
+
+		res, err := tx.ExecContext(ctx, `DELETE FROM webhooks WHERE tenant_id = $1 AND id = $2`, postgreSQL.TenantID, webhookID)
+
+		if err != nil {
+			return err
+		}
+
+		deleted, err := res.RowsAffected()
+
+		if err != nil || deleted == 0 {
+			return err
+		}
+
+		removed = true
+
+		// Deliveries and dead letters of the webhook are shared by tenants, webhook IDs being unique across them
+		for _, table := range []string{"webhook_deliveries", "webhook_dead_letters"} {
+
+			_, err = tx.ExecContext(ctx, `DELETE FROM `+table+` WHERE webhook_id = $1`, webhookID)
+
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return false, err
+	}
+
+	return removed, nil
+}
+
+// AddWebhookDelivery : Add delivery of a lifecycle event to an outbound webhook in database
+func (postgreSQL *PostgreSQL) AddWebhookDelivery(ctx context.Context, delivery *WebhookDelivery) error {
+
+	_, err := postgreSQL.DB.ExecContext(
+		ctx,
+		`INSERT INTO webhook_deliveries (`+webhookDeliveryColumns+`) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)`,
+		delivery.ID, delivery.WebhookID, delivery.TenantID, delivery.EventID, delivery.EventType, delivery.Status, delivery.Attempts,
+		delivery.ResponseStatus, delivery.Error, delivery.Payload, delivery.CreatedAt, delivery.NextAttemptAt, delivery.DeliveredAt, delivery.LeaseUntil,
+	)
+
+	return err
+}
+
+// UpdateWebhookDelivery : Replace delivery of a lifecycle event with its current status
+func (postgreSQL *PostgreSQL) UpdateWebhookDelivery(ctx context.Context, delivery *WebhookDelivery) error {
+
+	_, err := postgreSQL.DB.ExecContext(
+		ctx,
+		`UPDATE webhook_deliveries SET
+			status = $2, attempts = $3, response_status = $4, error = $5,
+			next_attempt_at = $6, delivered_at = $7, lease_until = $8
+		WHERE id = $1`,
+		delivery.ID, delivery.Status, delivery.Attempts, delivery.ResponseStatus, delivery.Error,
+		delivery.NextAttemptAt, delivery.DeliveredAt, delivery.LeaseUntil,
+	)
+
+	return err
+}
+
+// GetWebhookDeliveries : Get page of deliveries of webhookID, most recent first, and their total count
+func (postgreSQL *PostgreSQL) GetWebhookDeliveries(ctx context.Context, webhookID string, pagination *utils.Pagination) ([]*WebhookDelivery, int, error) {
+
+	query := &sqlQuery{}
+	query.where("webhook_id = %s", webhookID)
+
+	total, err := postgreSQL.count(ctx, `SELECT COUNT(*) FROM webhook_deliveries`+query.clause(), query.args...)
+
+	if err != nil {
+		return nil, 0, err
+	}
+
+	deliveries, err := postgreSQL.queryWebhookDeliveries(
+		ctx,
+		`SELECT `+webhookDeliveryColumns+` FROM webhook_deliveries`+query.clause()+` ORDER BY created_at DESC`+pageClause(pagination),
+		query.args...,
+	)
+
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return deliveries, total, nil
+}
+
+// GetDueWebhookDeliveries : Get up to limit pending webhook deliveries of all tenants due for an attempt at now and not leased, most overdue first
+func (postgreSQL *PostgreSQL) GetDueWebhookDeliveries(ctx context.Context, now time.Time, limit int) ([]*WebhookDelivery, error) {
+	return postgreSQL.queryWebhookDeliveries(
+		ctx,
+		`SELECT `+webhookDeliveryColumns+` FROM webhook_deliveries WHERE status = $1 AND next_attempt_at <= $2 AND lease_until <= $2
+		ORDER BY next_attempt_at LIMIT $3`,
+		WebhookDeliveryPending, now, limit,
+	)
+}
+
+// ClaimWebhookDelivery : Lease pending webhook delivery until leaseUntil, unless another instance leased it beyond now.
+// Returns false when the delivery was leased by another instance, or is not pending anymore
+func (postgreSQL *PostgreSQL) ClaimWebhookDelivery(ctx context.Context, deliveryID string, now time.Time, leaseUntil time.Time) (bool, error) {
+	return postgreSQL.claim(
+		ctx,
+		`UPDATE webhook_deliveries SET lease_until = $4 WHERE id = $1 AND status = $2 AND lease_until <= $3`,
+		deliveryID, WebhookDeliveryPending, now, leaseUntil,
+	)
+}
+
+// AddWebhookDeadLetter : Add delivery abandoned after its last attempt in database
+func (postgreSQL *PostgreSQL) AddWebhookDeadLetter(ctx context.Context, deadLetter *WebhookDeadLetter) error {
+
+	_, err := postgreSQL.DB.ExecContext(
+		ctx,
+		`INSERT INTO webhook_dead_letters (`+webhookDeadLetterColumns+`) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)`,
+		deadLetter.ID, deadLetter.WebhookID, deadLetter.TenantID, deadLetter.DeliveryID, deadLetter.URL, deadLetter.EventType,
+		deadLetter.Attempts, deadLetter.ResponseStatus, deadLetter.Error, deadLetter.Payload, deadLetter.DeadLetteredAt,
+	)
+
+	return err
+}
+
+// GetWebhookDeadLetter : Get dead letter deadLetterID, nil if it does not exist
+func (postgreSQL *PostgreSQL) GetWebhookDeadLetter(ctx context.Context, deadLetterID string) (*WebhookDeadLetter, error) {
+
+	deadLetter, err := scanWebhookDeadLetter(postgreSQL.DB.QueryRowContext(
+		ctx,
+		`SELECT `+webhookDeadLetterColumns+` FROM webhook_dead_letters WHERE id = $1`,
+		deadLetterID,
+	))
+
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return deadLetter, nil
+}
+
+// GetWebhookDeadLetters : Return page of dead letters matching filter, most recent first, and number of matching dead letters
+func (postgreSQL *PostgreSQL) GetWebhookDeadLetters(ctx context.Context, filter *WebhookDeadLetterFilter) ([]*WebhookDeadLetter, int, error) {
+
+	query := &sqlQuery{}
+	query.where("tenant_id = %s", filter.TenantID)
+
+	if filter.WebhookID != "" {
+		query.where("webhook_id = %s", filter.WebhookID)
+	}
+
+	total, err := postgreSQL.count(ctx, `SELECT COUNT(*) FROM webhook_dead_letters`+query.clause(), query.args...)
+
+	if err != nil {
+		return nil, 0, err
+	}
+
+	rows, err := postgreSQL.DB.QueryContext(
+		ctx,
+		`SELECT `+webhookDeadLetterColumns+` FROM webhook_dead_letters`+query.clause()+` ORDER BY dead_lettered_at DESC`+pageClause(filter.Page),
+		query.args...,
+	)
+
+	if err != nil {
+		return nil, 0, err
+	}
+
+	defer rows.Close()
+
+	deadLetters := []*WebhookDeadLetter{}
+
+	for rows.Next() {
+
+		deadLetter, err := scanWebhookDeadLetter(rows)
+
+		if err != nil {
+			return nil, 0, err
+		}
+
+		deadLetters = append(deadLetters, deadLetter)
+	}
+
+	return deadLetters, total, rows.Err()
+}
+
+// RemoveWebhookDeadLetter : Remove dead letter deadLetterID, returning false if it does not exist
+func (postgreSQL *PostgreSQL) RemoveWebhookDeadLetter(ctx context.Context, deadLetterID string) (bool, error) {
+	return postgreSQL.claim(ctx, `DELETE FROM webhook_dead_letters WHERE id = $1`, deadLetterID)
+}
+
+// withOutbox : Run write in a transaction, which also adds events to the outbox. Events are thus relayed if and only if
+// their mutation is committed, even if the instance stops right after. Transaction is rolled back when write fails
+func (postgreSQL *PostgreSQL) withOutbox(ctx context.Context, events []*LifecycleEvent, write func(tx *sql.Tx) error) error {
+
+	outboxEvents := make([]*OutboxEvent, 0, len(events))
+
+	for _, event := range events {
+
+		outboxEvent, err := NewOutboxEvent(event)
+
+		if err != nil {
+			return err
+		}
+
+		outboxEvents = append(outboxEvents, outboxEvent)
+	}
+
+	tx, err := postgreSQL.DB.BeginTx(ctx, nil)
+
+	if err != nil {
+		return err
+	}
+
+	// Rollback is a no-op once the transaction is committed
+	defer tx.Rollback()
+
+	err = write(tx)
+
+	if err != nil {
+		return err
+	}
+
+	for _, outboxEvent := range outboxEvents {
+
+		_, err = tx.ExecContext(
+			ctx,
+			`INSERT INTO outbox (id, tenant_id, event_type, payload, created_at, lease_until) VALUES ($1, $2, $3, $4, $5, $6)`,
+			outboxEvent.ID, outboxEvent.TenantID, outboxEvent.EventType, outboxEvent.Payload, outboxEvent.CreatedAt, outboxEvent.LeaseUntil,
+		)
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// GetOutboxEvents : Get up to limit outbox events of all tenants not leased at now, oldest first
+func (postgreSQL *PostgreSQL) GetOutboxEvents(ctx context.Context, now time.Time, limit int) ([]*OutboxEvent, error) {
+
+	rows, err := postgreSQL.DB.QueryContext(
+		ctx,
+		`SELECT id, tenant_id, event_type, payload, created_at, lease_until FROM outbox WHERE lease_until <= $1 ORDER BY created_at LIMIT $2`,
+		now, limit,
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	outboxEvents := []*OutboxEvent{}
+
+	for rows.Next() {
+
+		outboxEvent := &OutboxEvent{}
+
+		err = rows.Scan(&outboxEvent.ID, &outboxEvent.TenantID, &outboxEvent.EventType, &outboxEvent.Payload, &outboxEvent.CreatedAt, &outboxEvent.LeaseUntil)
+
+		if err != nil {
+			return nil, err
+		}
+
+		outboxEvents = append(outboxEvents, outboxEvent)
+	}
+
+	return outboxEvents, rows.Err()
+}
+
+// ClaimOutboxEvent : Lease outbox event until leaseUntil, unless another instance leased it beyond now.
+// Returns false when the event was leased by another instance, or relayed already
+func (postgreSQL *PostgreSQL) ClaimOutboxEvent(ctx context.Context, eventID string, now time.Time, leaseUntil time.Time) (bool, error) {
+	return postgreSQL.claim(ctx, `UPDATE outbox SET lease_until = $3 WHERE id = $1 AND lease_until <= $2`, eventID, now, leaseUntil)
+}
+
+// RemoveOutboxEvent : Remove relayed outbox event
+func (postgreSQL *PostgreSQL) RemoveOutboxEvent(ctx context.Context, eventID string) error {
+
+	_, err := postgreSQL.DB.ExecContext(ctx, `DELETE FROM outbox WHERE id = $1`, eventID)
+
+	return err
+}
+
+// GetMigrations : Get schema migrations claimed or applied, by increasing version
+func (postgreSQL *PostgreSQL) GetMigrations(ctx context.Context) ([]*MigrationRecord, error) {
+
+	rows, err := postgreSQL.DB.QueryContext(ctx, `SELECT version, name, started_at, lease_until, applied_at FROM migrations ORDER BY version`)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	migrations := []*MigrationRecord{}
+
+	for rows.Next() {
+
+		migration := &MigrationRecord{}
+
+		err = rows.Scan(&migration.Version, &migration.Name, &migration.StartedAt, &migration.LeaseUntil, &migration.AppliedAt)
+
+		if err != nil {
+			return nil, err
+		}
+
+		migrations = append(migrations, migration)
+	}
+
+	return migrations, rows.Err()
+}
+
+// ClaimMigration : Lease migration of version until leaseUntil, unless it was applied or another instance leased it beyond now.
+// Returns false when the migration was leased by another instance, or applied already
+func (postgreSQL *PostgreSQL) ClaimMigration(ctx context.Context, version int, name string, now time.Time, leaseUntil time.Time) (bool, error) {
+	return postgreSQL.claim(
+		ctx,
+		`INSERT INTO migrations (version, name, started_at, lease_until) VALUES ($1, $2, $3, $4)
+		ON CONFLICT (version) DO UPDATE SET name = EXCLUDED.name, started_at = EXCLUDED.started_at, lease_until = EXCLUDED.lease_until
+		WHERE migrations.applied_at IS NULL AND migrations.lease_until <= $3`,
+		version, name, now, leaseUntil,
+	)
+}
+
+// CompleteMigration : Record migration of version as applied at now, releasing its lease
+func (postgreSQL *PostgreSQL) CompleteMigration(ctx context.Context, version int, now time.Time) error {
+
+	_, err := postgreSQL.DB.ExecContext(ctx, `UPDATE migrations SET applied_at = $2, lease_until = NULL WHERE version = $1`, version, now)
+
+	return err
+}
+
+// WatchChanges : Change streams are not supported by PostgreSQL, ErrNotSupported is returned
+func (postgreSQL *PostgreSQL) WatchChanges(ctx context.Context, resumeToken []byte, handle func(change *DataChange)) ([]byte, error) {
+	return resumeToken, ErrNotSupported
+}
+
+// ExpireTombstones : PostgreSQL has no TTL index, ErrNotSupported is returned
+func (postgreSQL *PostgreSQL) ExpireTombstones(ctx context.Context, expireAfter time.Duration) error {
+	return ErrNotSupported
+}
+
+// EnsureIndexes : Create tables and indexes of the service unless they exist (See postgreSQLSchema). Tables are shared by tenants
+func (postgreSQL *PostgreSQL) EnsureIndexes(ctx context.Context) error {
+
+	for _, statement := range postgreSQLSchema {
+
+		_, err := postgreSQL.DB.ExecContext(ctx, statement)
+
+		if err != nil {
+			return fmt.Errorf("error creating PostgreSQL schema : %v", err)
+		}
+	}
+
+	return nil
+}
+
+// count : Return count of the COUNT query
+func (postgreSQL *PostgreSQL) count(ctx context.Context, query string, args ...interface{}) (int, error) {
+
+	var count int
+
+	err := postgreSQL.DB.QueryRowContext(ctx, query, args...).Scan(&count)
+
+	if err != nil {
+		return 0, err
+	}
+
+	return count, nil
+}
+
+// purge : Execute statement removing rows, returning the number removed
+func (postgreSQL *PostgreSQL) purge(ctx context.Context, statement string, args ...interface{}) (int, error) {
+
+	res, err := postgreSQL.DB.ExecContext(ctx, statement, args...)
+
+	if err != nil {
+		return 0, err
+	}
+
+	purged, err := res.RowsAffected()
+
+	if err != nil {
+		return 0, err
+	}
+
+	return int(purged), nil
+}
+
+// claim : Execute statement updating (Or removing) a single row, returning false when no row matched
+func (postgreSQL *PostgreSQL) claim(ctx context.Context, statement string, args ...interface{}) (bool, error) {
+
+	res, err := postgreSQL.DB.ExecContext(ctx, statement, args...)
+
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := res.RowsAffected()
+
+	if err != nil {
+		return false, err
+	}
+
+	return affected == 1, nil
+}
+
+// queryACLs : Return VerneMQ ACLs of query
+func (postgreSQL *PostgreSQL) queryACLs(ctx context.Context, query string, args ...interface{}) ([]*VerneMQACL, error) {
+
+	rows, err := postgreSQL.DB.QueryContext(ctx, query, args...)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	verneMQACLs := []*VerneMQACL{}
+
+	for rows.Next() {
+
+		verneMQACL, err := scanACL(rows)
+
+		if err != nil {
+			return nil, err
+		}
+
+		verneMQACLs = append(verneMQACLs, verneMQACL)
+	}
+
+	return verneMQACLs, rows.Err()
+}
+
+// queryWebhookDeliveries : Return webhook deliveries of query
+func (postgreSQL *PostgreSQL) queryWebhookDeliveries(ctx context.Context, query string, args ...interface{}) ([]*WebhookDelivery, error) {
+
+	rows, err := postgreSQL.DB.QueryContext(ctx, query, args...)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	deliveries := []*WebhookDelivery{}
+
+	for rows.Next() {
+
+		delivery := &WebhookDelivery{}
+
+		err = rows.Scan(&delivery.ID, &delivery.WebhookID, &delivery.TenantID, &delivery.EventID, &delivery.EventType, &delivery.Status,
+			&delivery.Attempts, &delivery.ResponseStatus, &delivery.Error, &delivery.Payload, &delivery.CreatedAt,
+			&delivery.NextAttemptAt, &delivery.DeliveredAt, &delivery.LeaseUntil)
+
+		if err != nil {
+			return nil, err
+		}
+
+		deliveries = append(deliveries, delivery)
+	}
+
+	return deliveries, rows.Err()
+}
+
+// scanACL : Return VerneMQ ACL of row, selected with aclColumns. Columns selected after them are scanned into columns
+func scanACL(row rowScanner, columns ...interface{}) (*VerneMQACL, error) {
+
+	verneMQACL := &VerneMQACL{}
+
+	var password sql.NullString
+	var publishACL, subscribeACL []byte
+
+	dest := []interface{}{&verneMQACL.Mountpoint, &verneMQACL.ClientID, &verneMQACL.Username, &password,
+		&publishACL, &subscribeACL, &verneMQACL.DeviceName, &verneMQACL.ExpiresAt}
+
+	err := row.Scan(append(dest, columns...)...)
+
+	if err != nil {
+		return nil, err
+	}
+
+	verneMQACL.Passhash = password.String
+
+	err = unmarshalColumns(map[*[]byte]interface{}{
+		&publishACL:   &verneMQACL.PublishACL,
+		&subscribeACL: &verneMQACL.SubscribeACL,
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return verneMQACL, nil
+}
+
+// scanGroupConversation : Return group conversation of row, selected with groupConversationColumns
+func scanGroupConversation(row rowScanner) (*GroupConversation, error) {
+
+	groupConversation := &GroupConversation{}
+
+	err := row.Scan(&groupConversation.GroupConversationID, &groupConversation.Name, &groupConversation.CreatorID, pq.Array(&groupConversation.Members),
+		&groupConversation.DeletedAt)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return groupConversation, nil
+}
+
+// scanWebhook : Return outbound webhook of row, selected with webhookColumns
+func scanWebhook(row rowScanner) (*Webhook, error) {
+
+	webhook := &Webhook{}
+
+	err := row.Scan(&webhook.ID, &webhook.URL, pq.Array(&webhook.Events), &webhook.Secret, &webhook.CreatedAt)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return webhook, nil
+}
+
+// scanWebhookDeadLetter : Return webhook dead letter of row, selected with webhookDeadLetterColumns
+func scanWebhookDeadLetter(row rowScanner) (*WebhookDeadLetter, error) {
+
+	deadLetter := &WebhookDeadLetter{}
+
+	err := row.Scan(&deadLetter.ID, &deadLetter.WebhookID, &deadLetter.TenantID, &deadLetter.DeliveryID, &deadLetter.URL, &deadLetter.EventType,
+		&deadLetter.Attempts, &deadLetter.ResponseStatus, &deadLetter.Error, &deadLetter.Payload, &deadLetter.DeadLetteredAt)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return deadLetter, nil
+}
+
+// marshalPatterns : Return JSON array of patterns, as stored in the publish_acl and subscribe_acl columns
+func marshalPatterns(patterns []*ACL) ([]byte, error) {
+
+	if patterns == nil {
+		patterns = []*ACL{}
+	}
+
+	return json.Marshal(patterns)
+}
+
+// patternsUnion : Return SQL expression of the patterns of the JSONB array expression, without duplicates (Order is not kept)
+func patternsUnion(patterns string) string {
+	return "COALESCE((SELECT jsonb_agg(DISTINCT pattern) FROM jsonb_array_elements(" + patterns + ") AS pattern), '[]'::JSONB)"
+}
+
+// patternsDifference : Return SQL expression of the JSONB array of ACL patterns without the ones of removed (A JSONB array too)
+func patternsDifference(patterns string, removed string) string {
+	return "COALESCE((SELECT jsonb_agg(pattern) FROM jsonb_array_elements(" + patterns + ") AS pattern WHERE NOT " + removed + " @> jsonb_build_array(pattern)), '[]'::JSONB)"
+}
+
+// unmarshalColumns : Unmarshal JSON columns into their values, by column. NULL columns leave their values as they are
+func unmarshalColumns(columns map[*[]byte]interface{}) error {
+
+	for column, value := range columns {
+
+		if len(*column) == 0 {
+			continue
+		}
+
+		err := json.Unmarshal(*column, value)
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// where : Add condition on an argument to query, formatted with the placeholder of arg (e.g. "user_id = %s")
+func (query *sqlQuery) where(condition string, arg interface{}) {
+	query.args = append(query.args, arg)
+	query.conditions = append(query.conditions, fmt.Sprintf(condition, "$"+strconv.Itoa(len(query.args))))
+}
+
+// clause : Return WHERE clause of query, empty without conditions
+func (query *sqlQuery) clause() string {
+
+	if len(query.conditions) == 0 {
+		return ""
+	}
+
+	return " WHERE " + strings.Join(query.conditions, " AND ")
+}
+
+// pageClause : Return LIMIT and OFFSET clauses of the page of pagination, written in the statement as they are integers
+func pageClause(pagination *utils.Pagination) string {
+	return " LIMIT " + strconv.Itoa(pagination.Limit) + " OFFSET " + strconv.Itoa(pagination.Offset)
+}
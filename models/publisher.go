@@ -0,0 +1,108 @@
+package models
+
+import (
+	errors "errors"
+	fmt "fmt"
+	sync "sync"
+	time "time"
+
+	mqtt "github.com/eclipse/paho.mqtt.golang"
+)
+
+const (
+	// publisherTimeout : Maximum duration of publisher connections and publications
+	publisherTimeout = 10 * time.Second
+)
+
+// PublisherInterface : Internal MQTT publisher interface
+type PublisherInterface interface {
+	Publish(topic string, payload []byte) error
+	Close()
+}
+
+// MQTTPublisher : Internal MQTT client, connected to the broker on first publication with its own VerneMQ ACL
+type MQTTPublisher struct {
+	Config   *Config
+	ClientID string
+	Password string
+	client   mqtt.Client
+	mutex    sync.Mutex
+}
+
+// NewMQTTPublisher : Return a new internal MQTT publisher authenticating with clientID and password
+// Config is referenced so that broker URL and QoS changes are picked up on refresh
+func NewMQTTPublisher(config *Config, clientID string, password string) *MQTTPublisher {
+	return &MQTTPublisher{
+		Config:   config,
+		ClientID: clientID,
+		Password: password,
+	}
+}
+
+// Publish : Publish payload on topic with configured QoS
+func (publisher *MQTTPublisher) Publish(topic string, payload []byte) error {
+
+	client, err := publisher.connect()
+
+	if err != nil {
+		return err
+	}
+
+	token := client.Publish(topic, byte(publisher.Config.SystemPublisher.QoS), false, payload)
+
+	if !token.WaitTimeout(publisherTimeout) {
+		return fmt.Errorf("error publishing on %s : timeout", topic)
+	}
+
+	return token.Error()
+}
+
+// Close : Disconnect from the broker, letting pending publications complete
+func (publisher *MQTTPublisher) Close() {
+
+	publisher.mutex.Lock()
+	defer publisher.mutex.Unlock()
+
+	if publisher.client != nil && publisher.client.IsConnected() {
+		publisher.client.Disconnect(uint(publisherTimeout / time.Millisecond))
+	}
+}
+
+// connect : Return connected MQTT client, connecting it if needed
+func (publisher *MQTTPublisher) connect() (mqtt.Client, error) {
+
+	publisher.mutex.Lock()
+	defer publisher.mutex.Unlock()
+
+	if publisher.client != nil && publisher.client.IsConnected() {
+		return publisher.client, nil
+	}
+
+	brokerURL := publisher.Config.SystemPublisher.BrokerURL
+
+	if brokerURL == "" {
+		return nil, errors.New("System publisher disabled")
+	}
+
+	options := mqtt.NewClientOptions().
+		AddBroker(brokerURL).
+		SetClientID(publisher.ClientID).
+		SetUsername(publisher.ClientID).
+		SetPassword(publisher.Password).
+		SetAutoReconnect(true)
+
+	client := mqtt.NewClient(options)
+	token := client.Connect()
+
+	if !token.WaitTimeout(publisherTimeout) {
+		return nil, fmt.Errorf("error connecting to %s : timeout", brokerURL)
+	}
+
+	if token.Error() != nil {
+		return nil, token.Error()
+	}
+
+	publisher.client = client
+
+	return client, nil
+}
@@ -0,0 +1,48 @@
+package models
+
+import (
+	errors "errors"
+	time "time"
+)
+
+const (
+	// PushPlatformFCM : Firebase Cloud Messaging (Android & Web)
+	PushPlatformFCM = "fcm"
+
+	// PushPlatformAPNs : Apple Push Notification service (iOS & macOS)
+	PushPlatformAPNs = "apns"
+
+	// PushPlatformWebPush : Web Push (Browsers), token is the subscription endpoint
+	PushPlatformWebPush = "webpush"
+)
+
+var (
+	// ErrPushTokenTaken : Push token is registered by another user, who must unregister it first
+	ErrPushTokenTaken = errors.New("Push token registered by another user")
+)
+
+// PushToken : Push notification token of one user device
+type PushToken struct {
+	Token          string    `json:"token" bson:"token"`
+	UserID         string    `json:"userID" bson:"userID"`
+	Platform       string    `json:"platform" bson:"platform"`
+	DeviceClientID string    `json:"deviceClientID,omitempty" bson:"deviceClientID,omitempty"`
+	AppVersion     string    `json:"appVersion,omitempty" bson:"appVersion,omitempty"`
+	Locale         string    `json:"locale,omitempty" bson:"locale,omitempty"`
+	P256dh         string    `json:"p256dh,omitempty" bson:"p256dh,omitempty"`
+	Auth           string    `json:"auth,omitempty" bson:"auth,omitempty"`
+	UpdatedAt      time.Time `json:"updatedAt" bson:"updatedAt"`
+}
+
+// NewPushToken : Return new PushToken struct pointer
+func NewPushToken(userID string, token string, platform string, deviceClientID string, appVersion string, locale string) *PushToken {
+	return &PushToken{
+		Token:          token,
+		UserID:         userID,
+		Platform:       platform,
+		DeviceClientID: deviceClientID,
+		AppVersion:     appVersion,
+		Locale:         locale,
+		UpdatedAt:      time.Now().UTC(),
+	}
+}
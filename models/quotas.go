@@ -0,0 +1,88 @@
+package models
+
+import (
+	fmt "fmt"
+)
+
+const (
+	// QuotaConversations : Group conversations created by the user
+	QuotaConversations = "maxConversations"
+
+	// QuotaGroupMemberships : Group conversations the user is a member of
+	QuotaGroupMemberships = "maxGroupMemberships"
+
+	// QuotaStoredMessages : Messages stored for the user
+	QuotaStoredMessages = "maxStoredMessages"
+
+	// QuotaAttachmentsBytes : Size of attachments stored for the user
+	QuotaAttachmentsBytes = "maxAttachmentsBytes"
+)
+
+// Quotas : Per user limits, zero means unlimited
+type Quotas struct {
+	MaxConversations    int   `json:"maxConversations"`
+	MaxGroupMemberships int   `json:"maxGroupMemberships"`
+	MaxStoredMessages   int   `json:"maxStoredMessages"`
+	MaxAttachmentsBytes int64 `json:"maxAttachmentsBytes"`
+}
+
+// QuotaOverride : Quotas of a user overriding configured ones, unset fields keep configured quotas
+type QuotaOverride struct {
+	UserID              string `json:"-" bson:"userID"`
+	MaxConversations    *int   `json:"maxConversations" bson:"maxConversations,omitempty" validate:"omitempty,min=0"`
+	MaxGroupMemberships *int   `json:"maxGroupMemberships" bson:"maxGroupMemberships,omitempty" validate:"omitempty,min=0"`
+	MaxStoredMessages   *int   `json:"maxStoredMessages" bson:"maxStoredMessages,omitempty" validate:"omitempty,min=0"`
+	MaxAttachmentsBytes *int64 `json:"maxAttachmentsBytes" bson:"maxAttachmentsBytes,omitempty" validate:"omitempty,min=0"`
+}
+
+// QuotaUsage : Current usage of a user quotas
+type QuotaUsage struct {
+	Conversations    int `json:"conversations"`
+	GroupMemberships int `json:"groupMemberships"`
+}
+
+// UserQuotas : Effective quotas of a user, with its override and usage
+type UserQuotas struct {
+	UserID   string         `json:"userID"`
+	Quotas   Quotas         `json:"quotas"`
+	Override *QuotaOverride `json:"override"`
+	Usage    *QuotaUsage    `json:"usage"`
+}
+
+// QuotaExceededError : Returned when an action would take a user over one of its quotas
+type QuotaExceededError struct {
+	UserID string
+	Quota  string
+	Limit  int64
+}
+
+// Error : Describe exceeded quota
+func (err *QuotaExceededError) Error() string {
+	return fmt.Sprintf("User %s exceeded quota %s (%d)", err.UserID, err.Quota, err.Limit)
+}
+
+// Apply : Return quotas overridden by override fields which are set
+func (quotas Quotas) Apply(override *QuotaOverride) Quotas {
+
+	if override == nil {
+		return quotas
+	}
+
+	if override.MaxConversations != nil {
+		quotas.MaxConversations = *override.MaxConversations
+	}
+
+	if override.MaxGroupMemberships != nil {
+		quotas.MaxGroupMemberships = *override.MaxGroupMemberships
+	}
+
+	if override.MaxStoredMessages != nil {
+		quotas.MaxStoredMessages = *override.MaxStoredMessages
+	}
+
+	if override.MaxAttachmentsBytes != nil {
+		quotas.MaxAttachmentsBytes = *override.MaxAttachmentsBytes
+	}
+
+	return quotas
+}
@@ -0,0 +1,77 @@
+package models
+
+import (
+	fmt "fmt"
+	net "net"
+	strings "strings"
+)
+
+// compile : Parse trusted proxies of config
+func (config *RateLimitConfig) compile() error {
+
+	trustedProxies, err := parseNetworks(config.TrustedProxies)
+
+	if err != nil {
+		return err
+	}
+
+	config.trustedProxies = trustedProxies
+
+	return nil
+}
+
+// IsTrustedProxy : Check if ip is one of the trusted proxies of config.
+// Configs which were not read from the config file (e.g. built by tests) have their trusted proxies parsed on each call
+func (config *RateLimitConfig) IsTrustedProxy(ip net.IP) bool {
+
+	trustedProxies := config.trustedProxies
+
+	if trustedProxies == nil {
+		trustedProxies, _ = parseNetworks(config.TrustedProxies)
+	}
+
+	for _, network := range trustedProxies {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// parseNetworks : Parse IPs (e.g. 10.0.0.1) and CIDRs (e.g. 10.0.0.0/8), IPs being networks of a single address. Never nil
+func parseNetworks(addresses []string) ([]*net.IPNet, error) {
+
+	networks := make([]*net.IPNet, 0, len(addresses))
+
+	for _, address := range addresses {
+
+		if strings.Contains(address, "/") {
+
+			_, network, err := net.ParseCIDR(address)
+
+			if err != nil {
+				return nil, err
+			}
+
+			networks = append(networks, network)
+			continue
+		}
+
+		ip := net.ParseIP(address)
+
+		if ip == nil {
+			return nil, fmt.Errorf("Invalid trusted proxy %s", address)
+		}
+
+		bits := 8 * net.IPv6len
+
+		if ip.To4() != nil {
+			ip, bits = ip.To4(), 8*net.IPv4len
+		}
+
+		networks = append(networks, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+	}
+
+	return networks, nil
+}
@@ -1,58 +1,444 @@
 package models
 
 import (
+	context "context"
+	errors "errors"
 	fmt "fmt"
+	strconv "strconv"
+	sync "sync"
+	time "time"
 
+	sentinel "github.com/FZambia/sentinel"
 	redisgo "github.com/gomodule/redigo/redis"
+	redisc "github.com/mna/redisc"
+)
+
+const (
+	// DefaultRedisMaxRedirects : MOVED and ASK redirections followed by a Redis Cluster command, used when none is configured
+	DefaultRedisMaxRedirects = 3
+
+	// DefaultRedisSentinelRoleCheckInterval : Milliseconds idle connections to the master monitored by Sentinel are used without
+	// checking its role again, used when none is configured
+	DefaultRedisSentinelRoleCheckInterval = 1000
+
+	// redisTryAgainDelay : Time before a Redis Cluster command failing with TRYAGAIN (Slot being migrated) is sent again
+	redisTryAgainDelay = 50 * time.Millisecond
 )
 
 // RedisInterface : Redis Communication interface
 type RedisInterface interface {
 	CloseConnection() error
-	Get(key string) ([]byte, error)
-	HGet(key string, field string) ([]byte, error)
-	HSet(key string, field1 string, value1 []byte, field2 string, value2 []byte) error
-	Set(key string, value []byte) error
-	Exists(key string) (bool, error)
-	Delete(key string) error
-	GetKeys(pattern string) ([]string, error)
-	Incr(counterKey string) (int, error)
-	Rename(oldKey string, newKey string) error
-}
-
-// Redis : Redis communication interface
+	Get(ctx context.Context, key string) ([]byte, error)
+	HGet(ctx context.Context, key string, field string) ([]byte, error)
+	BatchHGet(ctx context.Context, keys []string, field string) ([][]byte, error)
+	BatchExists(ctx context.Context, keys []string) ([]bool, error)
+	Pipeline(ctx context.Context, commands []RedisCommand) ([]interface{}, error)
+	HSet(ctx context.Context, key string, field1 string, value1 []byte, field2 string, value2 []byte) error
+	HDel(ctx context.Context, key string, fields ...string) error
+	Set(ctx context.Context, key string, value []byte) error
+	SetEx(ctx context.Context, key string, value []byte, seconds int) error
+	Exists(ctx context.Context, key string) (bool, error)
+	Delete(ctx context.Context, key string) error
+	GetKeys(ctx context.Context, pattern string) ([]string, error)
+	Incr(ctx context.Context, counterKey string) (int, error)
+	Rename(ctx context.Context, oldKey string, newKey string) error
+	Expire(ctx context.Context, key string, seconds int) error
+	EvalInts(ctx context.Context, script string, keys []string, args ...interface{}) ([]int, error)
+	EvalStrings(ctx context.Context, script string, keys []string, args ...interface{}) ([]string, error)
+	Ping(ctx context.Context) error
+}
+
+// RedisCommand : Command of a pipeline (See RedisInterface.Pipeline) on Key, followed by Args. Key decides of the node
+// the command is sent to on a cluster
+type RedisCommand struct {
+	Name string
+	Key  string
+	Args []interface{}
+}
+
+// BatchError : Failures of some keys of a batch operation, by index of the key.
+// Values of the other keys are still returned along with it
+type BatchError struct {
+	Errors map[int]error
+}
+
+// Error : Describe number of failed keys
+func (err *BatchError) Error() string {
+	return fmt.Sprintf("error on %d keys of batch", len(err.Errors))
+}
+
+// RedisConfig : Redis Config. Operations timeouts are read on every operation, Redis Cluster and Sentinel topologies,
+// credentials, TLS (Of nodes and Sentinels) and connections pools at startup
+type RedisConfig struct {
+	DatastoreConfig
+	Cluster  RedisClusterConfig  `json:"cluster"`
+	Sentinel RedisSentinelConfig `json:"sentinel"`
+	Auth     RedisAuthConfig     `json:"auth"`
+	TLS      DatastoreTLSConfig  `json:"tls"`
+	Pool     RedisPoolConfig     `json:"pool"`
+}
+
+// RedisClusterConfig : Redis Cluster Config. Slots of the cluster are discovered from SeedNodes (host:port), Redis
+// being a single node when none is configured. Commands follow up to MaxRedirects MOVED and ASK redirections
+type RedisClusterConfig struct {
+	SeedNodes    []string `json:"seedNodes"`
+	MaxRedirects int      `json:"maxRedirects"`
+}
+
+// Enabled : Check if Redis is a cluster
+func (config *RedisClusterConfig) Enabled() bool {
+	return len(config.SeedNodes) > 0
+}
+
+// RedisSentinelConfig : Redis Sentinel Config. Connections go to the current master of MasterName, as told by the Sentinels
+// of Addresses (host:port), Redis being a single node when none is configured. The role of the node of idle connections
+// is checked again when they were idle for more than RoleCheckInterval milliseconds
+type RedisSentinelConfig struct {
+	MasterName        string   `json:"masterName"`
+	Addresses         []string `json:"addresses"`
+	RoleCheckInterval int      `json:"roleCheckInterval"`
+}
+
+// Enabled : Check if Redis is monitored by Sentinel
+func (config *RedisSentinelConfig) Enabled() bool {
+	return len(config.Addresses) > 0
+}
+
+// Redis : Redis communication interface, to a single node or to the master monitored by Sentinel (Pool),
+// or to a Redis Cluster (Cluster)
 type Redis struct {
-	Connection redisgo.Conn
+	Pool         *redisgo.Pool
+	Sentinel     *sentinel.Sentinel
+	Cluster      *redisc.Cluster
+	MaxRedirects int
+	slotNodes    *redisSlotNodes
+}
+
+// NewRedis : Return a new Redis abstraction struct, authenticated with the credentials of config (password when none is configured)
+func NewRedis(connectionURL string, config *RedisConfig, password string) *Redis {
+
+	options, err := config.dialOptions(password)
+	if err != nil {
+		panic(err)
+	}
+
+	// Initialize the redis connections pool to a redis instance running on your local machine, connections being authenticated when dialed
+	pool, err := newRedisPool(&config.Pool, func() (redisgo.Conn, error) {
+		return redisgo.DialURL(config.tlsURL(connectionURL), options...)
+	})
+	if err != nil {
+		panic(err)
+	}
+
+	// Return new Redis abstraction struct
+	return &Redis{
+		Pool: pool,
+	}
 }
 
-// NewRedis : Return a new Redis abstraction struct
-func NewRedis(connectionURL string, password string) *Redis {
+// NewRedisCluster : Return a new Redis abstraction struct of the Redis Cluster of config, whose slots are mapped to nodes before returning.
+// If an error occurs, program is set to panic
+func NewRedisCluster(redisConfig *RedisConfig, password string) *Redis {
+
+	config := &redisConfig.Cluster
+
+	options, err := redisConfig.dialOptions(password)
 
-	// Initialize the redis connection to a redis instance running on your local machine
-	conn, err := redisgo.DialURL(connectionURL)
 	if err != nil {
 		panic(err)
 	}
 
-	// Authenticate to Redis
-	conn.Do("AUTH", password)
+	// Every node has a pool of its own
+	cluster := &redisc.Cluster{
+		StartupNodes: config.SeedNodes,
+		DialOptions:  options,
+		CreatePool: func(address string, options ...redisgo.DialOption) (*redisgo.Pool, error) {
+			return newRedisPool(&redisConfig.Pool, func() (redisgo.Conn, error) {
+				return redisgo.Dial("tcp", address, options...)
+			})
+		},
+	}
+
+	// Map hash slots to nodes, mapping being refreshed on MOVED redirections
+	err = cluster.Refresh()
+
+	if err != nil {
+		panic(err)
+	}
+
+	maxRedirects := config.MaxRedirects
+
+	if maxRedirects <= 0 {
+		maxRedirects = DefaultRedisMaxRedirects
+	}
 
-	// Return new MongoDB abstraction struct
 	return &Redis{
-		Connection: conn,
+		Cluster:      cluster,
+		MaxRedirects: maxRedirects,
+		slotNodes:    &redisSlotNodes{},
 	}
 }
 
-// CloseConnection : Close Redis Connection
+// NewRedisSentinel : Return a new Redis abstraction struct of the master monitored by the Sentinels of config.
+// Connections are dialed to the master the Sentinels currently know, so that a failover only fails commands of broken connections.
+// If an error occurs, program is set to panic
+func NewRedisSentinel(redisConfig *RedisConfig, password string) *Redis {
+
+	config := &redisConfig.Sentinel
+
+	if config.MasterName == "" {
+		panic(errors.New("Redis Sentinel master name must be configured"))
+	}
+
+	options, err := redisConfig.dialOptions(password)
+
+	if err != nil {
+		panic(err)
+	}
+
+	// Sentinels are reached over TLS too, without the credentials of the nodes
+	sentinelOptions, err := redisConfig.tlsDialOptions()
+
+	if err != nil {
+		panic(err)
+	}
+
+	sentinelOptions = append(sentinelOptions, redisgo.DialConnectTimeout(redisConfig.Pool.dialTimeout()))
+
+	roleCheckInterval := config.RoleCheckInterval
+
+	if roleCheckInterval <= 0 {
+		roleCheckInterval = DefaultRedisSentinelRoleCheckInterval
+	}
+
+	sentinels := &sentinel.Sentinel{
+		Addrs:      config.Addresses,
+		MasterName: config.MasterName,
+		Dial: func(address string) (redisgo.Conn, error) {
+			return redisgo.Dial("tcp", address, sentinelOptions...)
+		},
+	}
+
+	pool, err := newRedisPool(&redisConfig.Pool, func() (redisgo.Conn, error) {
+
+		address, err := sentinels.MasterAddr()
+
+		if err != nil {
+			return nil, err
+		}
+
+		conn, err := redisgo.Dial("tcp", address, options...)
+
+		if err != nil {
+			return nil, err
+		}
+
+		// Sentinels may not have noticed a failover yet
+		if !sentinel.TestRole(conn, "master") {
+			conn.Close()
+			return nil, fmt.Errorf("redis node %s is not the master of %s", address, config.MasterName)
+		}
+
+		return conn, nil
+	})
+
+	if err != nil {
+		panic(err)
+	}
+
+	// Master demoted by a failover is told apart by its role, connections kept busy being closed by Sentinel when it reconfigures the node
+	pool.TestOnBorrow = func(conn redisgo.Conn, lastUsed time.Time) error {
+
+		if time.Since(lastUsed) < time.Duration(roleCheckInterval)*time.Millisecond {
+			return nil
+		}
+
+		if !sentinel.TestRole(conn, "master") {
+			return fmt.Errorf("redis node is not the master of %s anymore", config.MasterName)
+		}
+
+		return nil
+	}
+
+	return &Redis{
+		Pool:     pool,
+		Sentinel: sentinels,
+	}
+}
+
+// CloseConnection : Close Redis connections, to the single node, to the master monitored by Sentinel or to every node of the cluster
 func (redis *Redis) CloseConnection() error {
 
-	return redis.Connection.Close()
+	if redis.Cluster != nil {
+		return redis.Cluster.Close()
+	}
+
+	if redis.Sentinel != nil {
+		redis.Sentinel.Close()
+	}
+
+	return redis.Pool.Close()
 }
 
-func (redis *Redis) Get(key string) ([]byte, error) {
+// nodeConn : Return connection of the pool of the single node, or of the master monitored by Sentinel, and the function releasing it.
+// Waiting for a connection (At maxActive) ends with ctx
+func (redis *Redis) nodeConn(ctx context.Context) (redisgo.Conn, func(), error) {
+
+	conn, err := redis.Pool.GetContext(ctx)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return conn, func() { conn.Close() }, nil
+}
+
+// conn : Return connection a command is sent on and the function releasing it. Cluster connections send the command
+// to the node of the slot of its first key, following MOVED and ASK redirections
+func (redis *Redis) conn(ctx context.Context) (redisgo.Conn, func(), error) {
+
+	if redis.Cluster == nil {
+		return redis.nodeConn(ctx)
+	}
+
+	conn := redis.Cluster.Get()
+
+	// Redirections are followed by the node answering them, plus the initial attempt
+	retryConn, err := redisc.RetryConn(conn, redis.MaxRedirects+1, redisTryAgainDelay)
+
+	if err != nil {
+		conn.Close()
+		return nil, nil, err
+	}
+
+	return retryConn, func() { retryConn.Close() }, nil
+}
+
+// pipelineConn : Return connection commands on keys of the node serving key are pipelined on and the function releasing it.
+// Cluster connections are bound to the node of the slot of key, redirections are not followed
+func (redis *Redis) pipelineConn(ctx context.Context, key string) (redisgo.Conn, func(), error) {
+
+	if redis.Cluster == nil {
+		return redis.nodeConn(ctx)
+	}
+
+	conn := redis.Cluster.Get()
+
+	err := redisc.BindConn(conn, key)
+
+	if err != nil {
+		conn.Close()
+		return nil, nil, err
+	}
+
+	return conn, func() { conn.Close() }, nil
+}
+
+// do : Send command and return its reply
+func (redis *Redis) do(ctx context.Context, command string, args ...interface{}) (interface{}, error) {
+
+	conn, release, err := redis.conn(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer release()
+
+	return redisgo.DoContext(conn, ctx, command, args...)
+}
+
+// eachNode : Call fn with a connection to the node (The master monitored by Sentinel), or to every master of the cluster
+func (redis *Redis) eachNode(ctx context.Context, fn func(conn redisgo.Conn) error) error {
+
+	if redis.Cluster == nil {
+
+		conn, release, err := redis.nodeConn(ctx)
+
+		if err != nil {
+			return err
+		}
+
+		defer release()
+
+		return fn(conn)
+	}
+
+	return redis.Cluster.EachNode(false, func(address string, conn redisgo.Conn) error {
+
+		err := fn(conn)
+
+		if err != nil {
+			return fmt.Errorf("node %s : %v", address, err)
+		}
+
+		return nil
+	})
+}
+
+// slotGroups : Return indexes of keys grouped by hash slot (In order of their first key), all keys being in one group on a single node
+func (redis *Redis) slotGroups(keys []string) [][]int {
+
+	if redis.Cluster == nil {
+		return groupKeys(keys, func(key string) string { return "" })
+	}
+
+	return groupKeys(keys, func(key string) string { return strconv.Itoa(redisc.Slot(key)) })
+}
+
+// nodeGroups : Return indexes of keys grouped by the master node serving them (In order of their first key), all keys being in one group
+// on a single node. Keys are grouped by hash slot when nodes of slots can't be read
+func (redis *Redis) nodeGroups(ctx context.Context, keys []string) [][]int {
+
+	if redis.Cluster == nil {
+		return redis.slotGroups(keys)
+	}
+
+	nodes, err := redis.slotNodes.get(ctx, redis)
+
+	if err != nil {
+		return redis.slotGroups(keys)
+	}
+
+	return groupKeys(keys, func(key string) string {
+
+		slot := redisc.Slot(key)
+
+		if nodes[slot] == "" {
+			return "slot " + strconv.Itoa(slot)
+		}
+
+		return nodes[slot]
+	})
+}
+
+// groupKeys : Return indexes of keys grouped by groupOf their key, in order of their first key
+func groupKeys(keys []string, groupOf func(key string) string) [][]int {
+
+	indexOfGroup := map[string]int{}
+	groups := [][]int{}
+
+	for i, key := range keys {
+
+		name := groupOf(key)
+		group, ok := indexOfGroup[name]
+
+		if !ok {
+			group = len(groups)
+			indexOfGroup[name] = group
+			groups = append(groups, nil)
+		}
+
+		groups[group] = append(groups[group], i)
+	}
+
+	return groups
+}
+
+func (redis *Redis) Get(ctx context.Context, key string) ([]byte, error) {
 
 	var data []byte
-	data, err := redisgo.Bytes(redis.Connection.Do("GET", key))
+	data, err := redisgo.Bytes(redis.do(ctx, "GET", key))
 
 	if err != nil {
 		return nil, fmt.Errorf("error getting key %s : %v", key, err)
@@ -60,10 +446,10 @@ func (redis *Redis) Get(key string) ([]byte, error) {
 	return data, nil
 }
 
-func (redis *Redis) HGet(key string, field string) ([]byte, error) {
+func (redis *Redis) HGet(ctx context.Context, key string, field string) ([]byte, error) {
 
 	var data []byte
-	data, err := redisgo.Bytes(redis.Connection.Do("HGET", key, field))
+	data, err := redisgo.Bytes(redis.do(ctx, "HGET", key, field))
 
 	if err != nil {
 		return nil, fmt.Errorf("error getting key %s : %v", key, err)
@@ -71,18 +457,279 @@ func (redis *Redis) HGet(key string, field string) ([]byte, error) {
 	return data, nil
 }
 
-func (redis *Redis) HSet(key string, field1 string, value1 []byte, field2 string, value2 []byte) error {
+// Pipeline : Send commands pipelined, in a single round trip on a single node. On a cluster, commands are pipelined by master node
+// serving their key, pipelines of the nodes being sent concurrently (See pipelineByNode). Replies are ordered as commands and as returned
+// by Redis ([]byte for bulk strings, int64 for integers, string for status replies, nil for missing values, []interface{} for arrays).
+// Failures of some commands are returned as a *BatchError (Their reply being nil), failure of the whole pipeline as any other error
+func (redis *Redis) Pipeline(ctx context.Context, commands []RedisCommand) ([]interface{}, error) {
+
+	if len(commands) == 0 {
+		return []interface{}{}, nil
+	}
+
+	pipelined := make([]pipelinedCommand, len(commands))
+
+	for i, command := range commands {
+		pipelined[i] = pipelinedCommand{key: command.Key, name: command.Name, args: append([]interface{}{command.Key}, command.Args...)}
+	}
+
+	replies, err := redis.pipelineByNode(ctx, pipelined)
 
-	_, err := redis.Connection.Do("HSET", key, field1, value1, field2, value2)
+	if err != nil {
+		return nil, fmt.Errorf("error sending %d commands : %v", len(commands), err)
+	}
+
+	batchErr := &BatchError{Errors: map[int]error{}}
+
+	for i, reply := range replies {
+		if err, ok := reply.(redisgo.Error); ok {
+			batchErr.Errors[i] = fmt.Errorf("error running %s on key %s : %v", commands[i].Name, commands[i].Key, err)
+			replies[i] = nil
+		}
+	}
+
+	if len(batchErr.Errors) > 0 {
+		return replies, batchErr
+	}
+
+	return replies, nil
+}
+
+// pipelinedCommand : Command sent with args to the node serving key, key being held by args
+type pipelinedCommand struct {
+	key  string
+	name string
+	args []interface{}
+}
+
+// pipelineByNode : Send commands in one pipeline by node serving their key (See nodeGroups), pipelines of the nodes of a cluster being sent
+// concurrently so that a batch takes about a round trip whatever its nodes. Replies are ordered as commands, error replies being returned as replies.
+// Commands redirected meanwhile (Slot migrated to another node) are sent again following redirections, and nodes of slots read again
+func (redis *Redis) pipelineByNode(ctx context.Context, commands []pipelinedCommand) ([]interface{}, error) {
+
+	keys := make([]string, len(commands))
+
+	for i, command := range commands {
+		keys[i] = command.key
+	}
+
+	groups := redis.nodeGroups(ctx, keys)
+
+	replies := make([]interface{}, len(commands))
+	errs := make([]error, len(groups))
+
+	var wait sync.WaitGroup
+
+	for g, group := range groups {
+
+		wait.Add(1)
+
+		go func(g int, group []int) {
+
+			defer wait.Done()
+
+			groupCommands := make([]pipelinedCommand, len(group))
+
+			for j, i := range group {
+				groupCommands[j] = commands[i]
+			}
+
+			groupReplies, err := redis.pipeline(ctx, groupCommands)
+
+			if err != nil {
+				errs[g] = err
+				return
+			}
+
+			for j, i := range group {
+				replies[i] = groupReplies[j]
+			}
+		}(g, group)
+	}
+
+	wait.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	for i, reply := range replies {
+
+		if err, ok := reply.(redisgo.Error); !ok || redisc.ParseRedir(err) == nil {
+			continue
+		}
+
+		redis.slotNodes.invalidate()
+
+		reply, err := redis.do(ctx, commands[i].name, commands[i].args...)
+
+		if _, ok := err.(redisgo.Error); ok {
+			reply = err
+		} else if err != nil {
+			return nil, err
+		}
+
+		replies[i] = reply
+	}
+
+	return replies, nil
+}
+
+// pipeline : Send commands (Whose keys are served by the same node) in a single round trip, replies being ordered as commands
+func (redis *Redis) pipeline(ctx context.Context, commands []pipelinedCommand) ([]interface{}, error) {
+
+	conn, release, err := redis.pipelineConn(ctx, commands[0].key)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer release()
+
+	for _, command := range commands {
+		err := conn.Send(command.name, command.args...)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// Empty command flushes the pipeline and receives all pending replies
+	replies, err := redisgo.Values(redisgo.DoContext(conn, ctx, ""))
+
+	if err != nil {
+		return nil, err
+	}
+
+	if len(replies) != len(commands) {
+		return nil, fmt.Errorf("%d replies received", len(replies))
+	}
+
+	return replies, nil
+}
+
+// BatchHGet : Get field of keys pipelined (See Pipeline), values being ordered as keys and nil for missing keys or fields.
+// Failures of some keys are returned as a *BatchError, failure of the whole batch as any other error
+func (redis *Redis) BatchHGet(ctx context.Context, keys []string, field string) ([][]byte, error) {
+
+	commands := make([]RedisCommand, len(keys))
+
+	for i, key := range keys {
+		commands[i] = RedisCommand{Name: "HGET", Key: key, Args: []interface{}{field}}
+	}
+
+	replies, batchErr, err := redis.batch(ctx, commands)
+
+	if err != nil {
+		return nil, fmt.Errorf("error getting %d keys : %v", len(keys), err)
+	}
+
+	values := make([][]byte, len(keys))
+
+	for i, reply := range replies {
+
+		if reply == nil || batchErr.Errors[i] != nil {
+			continue
+		}
+
+		value, err := redisgo.Bytes(reply, nil)
+
+		if err != nil {
+			batchErr.Errors[i] = fmt.Errorf("error getting key %s : %v", keys[i], err)
+			continue
+		}
+
+		values[i] = value
+	}
+
+	if len(batchErr.Errors) > 0 {
+		return values, batchErr
+	}
+
+	return values, nil
+}
+
+// BatchExists : Check if keys exist pipelined (See Pipeline), results being ordered as keys.
+// Failures of some keys are returned as a *BatchError, failure of the whole batch as any other error
+func (redis *Redis) BatchExists(ctx context.Context, keys []string) ([]bool, error) {
+
+	commands := make([]RedisCommand, len(keys))
+
+	for i, key := range keys {
+		commands[i] = RedisCommand{Name: "EXISTS", Key: key}
+	}
+
+	replies, batchErr, err := redis.batch(ctx, commands)
+
+	if err != nil {
+		return nil, fmt.Errorf("error checking if %d keys exist : %v", len(keys), err)
+	}
+
+	exist := make([]bool, len(keys))
+
+	for i, reply := range replies {
+
+		if batchErr.Errors[i] != nil {
+			continue
+		}
+
+		ok, err := redisgo.Bool(reply, nil)
+
+		if err != nil {
+			batchErr.Errors[i] = fmt.Errorf("error checking if key %s exists : %v", keys[i], err)
+			continue
+		}
+
+		exist[i] = ok
+	}
+
+	if len(batchErr.Errors) > 0 {
+		return exist, batchErr
+	}
+
+	return exist, nil
+}
+
+// batch : Pipeline commands, returning replies and failures of some commands (Empty when none failed), or failure of the whole pipeline
+func (redis *Redis) batch(ctx context.Context, commands []RedisCommand) ([]interface{}, *BatchError, error) {
+
+	replies, err := redis.Pipeline(ctx, commands)
+
+	if batchErr, partial := err.(*BatchError); partial {
+		return replies, batchErr, nil
+	}
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return replies, &BatchError{Errors: map[int]error{}}, nil
+}
+
+func (redis *Redis) HSet(ctx context.Context, key string, field1 string, value1 []byte, field2 string, value2 []byte) error {
+
+	_, err := redis.do(ctx, "HSET", key, field1, value1, field2, value2)
 	if err != nil {
 		return fmt.Errorf("error setting key %s to %s : %v", key, value1, err)
 	}
 	return nil
 }
 
-func (redis *Redis) Set(key string, value []byte) error {
+func (redis *Redis) HDel(ctx context.Context, key string, fields ...string) error {
+
+	args := redisgo.Args{}.Add(key).AddFlat(fields)
 
-	_, err := redis.Connection.Do("SET", key, value)
+	_, err := redis.do(ctx, "HDEL", args...)
+	if err != nil {
+		return fmt.Errorf("error deleting fields of key %s : %v", key, err)
+	}
+	return nil
+}
+
+func (redis *Redis) Set(ctx context.Context, key string, value []byte) error {
+
+	_, err := redis.do(ctx, "SET", key, value)
 	if err != nil {
 		v := string(value)
 		if len(v) > 15 {
@@ -93,27 +740,38 @@ func (redis *Redis) Set(key string, value []byte) error {
 	return nil
 }
 
-func (redis *Redis) Rename(oldKey string, newKey string) error {
+// SetEx : Set value of key expiring in seconds, at once so that key can't be left without expiry
+func (redis *Redis) SetEx(ctx context.Context, key string, value []byte, seconds int) error {
 
-	_, err := redis.Connection.Do("RENAME", oldKey, newKey)
+	_, err := redis.do(ctx, "SET", key, value, "EX", seconds)
+	if err != nil {
+		return fmt.Errorf("error setting key %s with expiration : %v", key, err)
+	}
+	return nil
+}
+
+// Rename : Rename oldKey to newKey, which must be of the same slot on a cluster (e.g. sharing a {hash tag})
+func (redis *Redis) Rename(ctx context.Context, oldKey string, newKey string) error {
+
+	_, err := redis.do(ctx, "RENAME", oldKey, newKey)
 	if err != nil {
 		return fmt.Errorf("error renaming key %s to %s : %v", oldKey, newKey, err)
 	}
 	return nil
 }
 
-func (redis *Redis) Exists(key string) (bool, error) {
+func (redis *Redis) Exists(ctx context.Context, key string) (bool, error) {
 
-	ok, err := redisgo.Bool(redis.Connection.Do("EXISTS", key))
+	ok, err := redisgo.Bool(redis.do(ctx, "EXISTS", key))
 	if err != nil {
 		return ok, fmt.Errorf("error checking if key %s exists : %v", key, err)
 	}
 	return ok, nil
 }
 
-func (redis *Redis) Delete(key string) error {
+func (redis *Redis) Delete(ctx context.Context, key string) error {
 
-	_, err := redis.Connection.Do("DEL", key)
+	_, err := redis.do(ctx, "DEL", key)
 
 	if err != nil {
 		return err
@@ -122,29 +780,174 @@ func (redis *Redis) Delete(key string) error {
 	return nil
 }
 
-func (redis *Redis) GetKeys(pattern string) ([]string, error) {
+func (redis *Redis) GetKeys(ctx context.Context, pattern string) ([]string, error) {
 
-	iter := 0
 	keys := []string{}
-	for {
-		arr, err := redisgo.Values(redis.Connection.Do("SCAN", iter, "MATCH", pattern))
+
+	// Keys of a cluster are spread over its masters, which are all scanned
+	err := redis.eachNode(ctx, func(conn redisgo.Conn) error {
+
+		iter := 0
+		for {
+			arr, err := redisgo.Values(redisgo.DoContext(conn, ctx, "SCAN", iter, "MATCH", pattern))
+			if err != nil {
+				return err
+			}
+
+			iter, _ = redisgo.Int(arr[0], nil)
+			k, _ := redisgo.Strings(arr[1], nil)
+			keys = append(keys, k...)
+
+			if iter == 0 {
+				return nil
+			}
+		}
+	})
+
+	if err != nil {
+		return keys, fmt.Errorf("error retrieving '%s' keys : %v", pattern, err)
+	}
+
+	return keys, nil
+}
+
+func (redis *Redis) Incr(ctx context.Context, counterKey string) (int, error) {
+
+	return redisgo.Int(redis.do(ctx, "INCR", counterKey))
+}
+
+func (redis *Redis) Expire(ctx context.Context, key string, seconds int) error {
+
+	_, err := redis.do(ctx, "EXPIRE", key, seconds)
+	if err != nil {
+		return fmt.Errorf("error setting expiration of key %s : %v", key, err)
+	}
+	return nil
+}
+
+// Ping : Check Redis, every master of the cluster, answers before ctx is done
+func (redis *Redis) Ping(ctx context.Context) error {
+
+	err := redis.eachNode(ctx, func(conn redisgo.Conn) error {
+
+		_, err := redisgo.String(redisgo.DoContext(conn, ctx, "PING"))
+
+		return err
+	})
+
+	if err != nil {
+		return fmt.Errorf("error pinging redis : %v", err)
+	}
+	return nil
+}
+
+// EvalInts : Atomically run Lua script on keys, script must return an array of integers. Keys must be of the same slot on a cluster
+func (redis *Redis) EvalInts(ctx context.Context, script string, keys []string, args ...interface{}) ([]int, error) {
+
+	conn, release, err := redis.conn(ctx)
+
+	if err != nil {
+		return nil, fmt.Errorf("error evaluating script on keys %v : %v", keys, err)
+	}
+
+	defer release()
+
+	keysAndArgs := scriptArgs(keys, args)
+
+	// Script is sent once, then called by its SHA1
+	data, err := redisgo.Ints(redisgo.NewScript(len(keys), script).DoContext(ctx, conn, keysAndArgs...))
+
+	if err != nil {
+		return nil, fmt.Errorf("error evaluating script on keys %v : %v", keys, err)
+	}
+	return data, nil
+}
+
+// EvalStrings : Atomically run Lua script on keys, script must return an array of strings. On a cluster, keys are grouped by hash slot
+// and the script is run once by group (Atomically within it) with the same args, scripts of the groups being pipelined by node (See pipelineByNode) :
+// scripts on keys of several slots must return one string by key, in order of their keys, values being returned in order of keys
+func (redis *Redis) EvalStrings(ctx context.Context, script string, keys []string, args ...interface{}) ([]string, error) {
+
+	groups := redis.slotGroups(keys)
+
+	if len(groups) <= 1 {
+		return redis.evalStrings(ctx, script, keys, args...)
+	}
+
+	commands := make([]pipelinedCommand, len(groups))
+	keysOfGroups := make([][]string, len(groups))
+
+	for g, group := range groups {
+
+		groupKeys := make([]string, len(group))
+
+		for j, i := range group {
+			groupKeys[j] = keys[i]
+		}
+
+		// Pipelined scripts are sent whole, as a missing SHA1 could only be loaded once its reply is read
+		commands[g] = pipelinedCommand{key: groupKeys[0], name: "EVAL", args: append([]interface{}{script, len(groupKeys)}, scriptArgs(groupKeys, args)...)}
+		keysOfGroups[g] = groupKeys
+	}
+
+	replies, err := redis.pipelineByNode(ctx, commands)
+
+	if err != nil {
+		return nil, fmt.Errorf("error evaluating script on keys %v : %v", keys, err)
+	}
+
+	values := make([]string, len(keys))
+
+	for g, group := range groups {
+
+		groupValues, err := redisgo.Strings(replies[g], nil)
+
 		if err != nil {
-			return keys, fmt.Errorf("error retrieving '%s' keys", pattern)
+			return nil, fmt.Errorf("error evaluating script on keys %v : %v", keysOfGroups[g], err)
 		}
 
-		iter, _ = redisgo.Int(arr[0], nil)
-		k, _ := redisgo.Strings(arr[1], nil)
-		keys = append(keys, k...)
+		if len(groupValues) != len(group) {
+			return nil, fmt.Errorf("error evaluating script on keys %v : %d values returned", keysOfGroups[g], len(groupValues))
+		}
 
-		if iter == 0 {
-			break
+		for j, i := range group {
+			values[i] = groupValues[j]
 		}
 	}
 
-	return keys, nil
+	return values, nil
+}
+
+// evalStrings : Atomically run Lua script on keys (Of the same slot on a cluster), script must return an array of strings
+func (redis *Redis) evalStrings(ctx context.Context, script string, keys []string, args ...interface{}) ([]string, error) {
+
+	conn, release, err := redis.conn(ctx)
+
+	if err != nil {
+		return nil, fmt.Errorf("error evaluating script on keys %v : %v", keys, err)
+	}
+
+	defer release()
+
+	keysAndArgs := scriptArgs(keys, args)
+
+	// Script is sent once, then called by its SHA1
+	data, err := redisgo.Strings(redisgo.NewScript(len(keys), script).DoContext(ctx, conn, keysAndArgs...))
+
+	if err != nil {
+		return nil, fmt.Errorf("error evaluating script on keys %v : %v", keys, err)
+	}
+	return data, nil
 }
 
-func (redis *Redis) Incr(counterKey string) (int, error) {
+// scriptArgs : Return keys followed by args, as sent to scripts
+func scriptArgs(keys []string, args []interface{}) []interface{} {
+
+	keysAndArgs := make([]interface{}, 0, len(keys)+len(args))
+
+	for _, key := range keys {
+		keysAndArgs = append(keysAndArgs, key)
+	}
 
-	return redisgo.Int(redis.Connection.Do("INCR", counterKey))
+	return append(keysAndArgs, args...)
 }
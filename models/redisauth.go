@@ -0,0 +1,114 @@
+package models
+
+import (
+	os "os"
+	strings "strings"
+
+	redisgo "github.com/gomodule/redigo/redis"
+)
+
+const (
+	// RedisUsernameEnv : Environment variable overriding the configured Redis ACL user
+	RedisUsernameEnv = "WAVE_REDIS_USERNAME"
+
+	// RedisPasswordEnv : Environment variable overriding the configured Redis password
+	RedisPasswordEnv = "WAVE_REDIS_PASSWORD"
+)
+
+// RedisAuthConfig : Redis authentication Config. Username is a Redis 6 ACL user, the default user being authenticated by password alone when empty.
+// Credentials are read from the environment first, then from UsernameFile and PasswordFile when set, e.g. Kubernetes Secrets or secret stores mounted as files
+type RedisAuthConfig struct {
+	Username     string `json:"username"`
+	Password     string `json:"password"`
+	UsernameFile string `json:"usernameFile"`
+	PasswordFile string `json:"passwordFile"`
+}
+
+// credentials : Return username and password of config, defaultPassword being used when no password is configured
+func (config *RedisAuthConfig) credentials(defaultPassword string) (string, string, error) {
+
+	username, err := readSecret(config.Username, config.UsernameFile)
+
+	if err != nil {
+		return "", "", err
+	}
+
+	password, err := readSecret(config.Password, config.PasswordFile)
+
+	if err != nil {
+		return "", "", err
+	}
+
+	if value, ok := os.LookupEnv(RedisUsernameEnv); ok {
+		username = value
+	}
+
+	if value, ok := os.LookupEnv(RedisPasswordEnv); ok {
+		password = value
+	}
+
+	if password == "" {
+		password = defaultPassword
+	}
+
+	return username, password, nil
+}
+
+// tlsDialOptions : Return options dialing Redis nodes and Sentinels over the TLS of config, none when TLS is not configured
+func (config *RedisConfig) tlsDialOptions() ([]redisgo.DialOption, error) {
+
+	tlsConfig, err := newDatastoreTLSConfig(&config.TLS)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if tlsConfig == nil {
+		return nil, nil
+	}
+
+	return []redisgo.DialOption{
+		redisgo.DialUseTLS(true),
+		redisgo.DialTLSConfig(tlsConfig),
+		redisgo.DialTLSSkipVerify(tlsConfig.InsecureSkipVerify),
+	}, nil
+}
+
+// dialOptions : Return options dialing Redis nodes with the credentials, over the TLS and within the pool timeouts of config,
+// defaultPassword being used when no password is configured
+func (config *RedisConfig) dialOptions(defaultPassword string) ([]redisgo.DialOption, error) {
+
+	username, password, err := config.Auth.credentials(defaultPassword)
+
+	if err != nil {
+		return nil, err
+	}
+
+	options, err := config.tlsDialOptions()
+
+	if err != nil {
+		return nil, err
+	}
+
+	options = append(options, config.Pool.dialOptions()...)
+
+	if username != "" {
+		options = append(options, redisgo.DialUsername(username))
+	}
+
+	if password != "" {
+		options = append(options, redisgo.DialPassword(password))
+	}
+
+	return options, nil
+}
+
+// tlsURL : Return connectionURL, with the rediss scheme when TLS is configured (The scheme deciding of TLS when dialing an URL)
+func (config *RedisConfig) tlsURL(connectionURL string) string {
+
+	if config.TLS.Enabled || config.TLS.CAFile != "" || config.TLS.CertFile != "" {
+		return strings.Replace(connectionURL, "redis://", "rediss://", 1)
+	}
+
+	return connectionURL
+}
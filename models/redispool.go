@@ -0,0 +1,207 @@
+package models
+
+import (
+	time "time"
+
+	redisgo "github.com/gomodule/redigo/redis"
+	prometheus "github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	// DefaultRedisMaxIdleConns : Idle connections kept by Redis connections pool, used when none is configured
+	DefaultRedisMaxIdleConns = 10
+
+	// DefaultRedisDialTimeout : Milliseconds given to connect to a Redis node or Sentinel, used when none is configured
+	DefaultRedisDialTimeout = 1000
+
+	// RedisPoolDefaultNode : Node label of the pool of the single node, or of the master monitored by Sentinel
+	RedisPoolDefaultNode = "default"
+
+	// redisDialRetryDelay : Time before a failed connection to a Redis node is dialed again
+	redisDialRetryDelay = 50 * time.Millisecond
+)
+
+var (
+	// redisPoolActiveConnections : Open connections of Redis pools, idle ones included, per node
+	redisPoolActiveConnections = prometheus.NewDesc(
+		prometheus.BuildFQName(MetricsNamespace, "redis_pool", "active_connections"),
+		"Open connections of Redis pools, idle ones included, per node",
+		[]string{"node"}, nil,
+	)
+
+	// redisPoolIdleConnections : Idle connections of Redis pools, per node
+	redisPoolIdleConnections = prometheus.NewDesc(
+		prometheus.BuildFQName(MetricsNamespace, "redis_pool", "idle_connections"),
+		"Idle connections of Redis pools, per node",
+		[]string{"node"}, nil,
+	)
+
+	// redisPoolWaits : Operations which waited for a connection of Redis pools (At maxActive), per node
+	redisPoolWaits = prometheus.NewDesc(
+		prometheus.BuildFQName(MetricsNamespace, "redis_pool", "waits_total"),
+		"Operations which waited for a connection of Redis pools, per node",
+		[]string{"node"}, nil,
+	)
+
+	// redisPoolWaitDuration : Time operations waited for a connection of Redis pools, per node
+	redisPoolWaitDuration = prometheus.NewDesc(
+		prometheus.BuildFQName(MetricsNamespace, "redis_pool", "wait_duration_seconds_total"),
+		"Time operations waited for a connection of Redis pools, per node",
+		[]string{"node"}, nil,
+	)
+)
+
+// RedisPoolConfig : Redis connections pool Config, of the single node, of the master monitored by Sentinel or of every node of a cluster.
+// At most MaxActive connections are open (Unlimited when 0), operations waiting for one within their timeout. MaxIdle connections are kept idle,
+// MinIdle of them being dialed at startup, until they were idle for IdleTimeout seconds (Forever when 0).
+// Connections are given DialTimeout, ReadTimeout and WriteTimeout milliseconds (Read and writes being bounded by operations timeouts when 0),
+// and failing dials are attempted again up to MaxRetries times
+type RedisPoolConfig struct {
+	MaxActive    int `json:"maxActive"`
+	MaxIdle      int `json:"maxIdle"`
+	MinIdle      int `json:"minIdle"`
+	IdleTimeout  int `json:"idleTimeout"`
+	DialTimeout  int `json:"dialTimeout"`
+	ReadTimeout  int `json:"readTimeout"`
+	WriteTimeout int `json:"writeTimeout"`
+	MaxRetries   int `json:"maxRetries"`
+}
+
+// dialTimeout : Return time given to connect to a Redis node or Sentinel
+func (config *RedisPoolConfig) dialTimeout() time.Duration {
+
+	if config.DialTimeout <= 0 {
+		return DefaultRedisDialTimeout * time.Millisecond
+	}
+
+	return time.Duration(config.DialTimeout) * time.Millisecond
+}
+
+// dialOptions : Return timeouts options of connections to Redis nodes
+func (config *RedisPoolConfig) dialOptions() []redisgo.DialOption {
+
+	return []redisgo.DialOption{
+		redisgo.DialConnectTimeout(config.dialTimeout()),
+		redisgo.DialReadTimeout(time.Duration(config.ReadTimeout) * time.Millisecond),
+		redisgo.DialWriteTimeout(time.Duration(config.WriteTimeout) * time.Millisecond),
+	}
+}
+
+// newRedisPool : Return a new connections pool of config dialing connections with dial, MinIdle connections being dialed before returning.
+// Only dials are retried : commands are not sent again once sent, as counters increments and scripts would be applied twice
+func newRedisPool(config *RedisPoolConfig, dial func() (redisgo.Conn, error)) (*redisgo.Pool, error) {
+
+	maxIdle := config.MaxIdle
+
+	if maxIdle <= 0 {
+		maxIdle = DefaultRedisMaxIdleConns
+	}
+
+	pool := &redisgo.Pool{
+		MaxActive:   config.MaxActive,
+		MaxIdle:     maxIdle,
+		IdleTimeout: time.Duration(config.IdleTimeout) * time.Second,
+		Wait:        config.MaxActive > 0,
+		Dial: func() (redisgo.Conn, error) {
+
+			for attempt := 0; ; attempt++ {
+
+				conn, err := dial()
+
+				if err == nil {
+
+					if attempt > 0 {
+						DatastoreRetries.WithLabelValues(DatastoreRedis, "Dial", "recovered").Inc()
+					}
+
+					return conn, nil
+				}
+
+				if attempt >= config.MaxRetries {
+
+					if attempt > 0 {
+						DatastoreRetries.WithLabelValues(DatastoreRedis, "Dial", "exhausted").Inc()
+					}
+
+					return nil, err
+				}
+
+				DatastoreRetries.WithLabelValues(DatastoreRedis, "Dial", "retried").Inc()
+
+				time.Sleep(redisDialRetryDelay)
+			}
+		},
+	}
+
+	// At least one connection is dialed, so that an unreachable node fails startup
+	minIdle := config.MinIdle
+
+	if minIdle > maxIdle {
+		minIdle = maxIdle
+	}
+
+	if minIdle < 1 {
+		minIdle = 1
+	}
+
+	conns := make([]redisgo.Conn, 0, minIdle)
+
+	defer func() {
+		for _, conn := range conns {
+			conn.Close()
+		}
+	}()
+
+	for len(conns) < minIdle {
+
+		conn := pool.Get()
+		conns = append(conns, conn)
+
+		_, err := conn.Do("PING")
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return pool, nil
+}
+
+// PoolStats : Return stats of the connections pools of Redis, by node (RedisPoolDefaultNode for the single node or the master monitored by Sentinel)
+func (redis *Redis) PoolStats() map[string]redisgo.PoolStats {
+
+	if redis.Cluster != nil {
+		return redis.Cluster.Stats()
+	}
+
+	return map[string]redisgo.PoolStats{RedisPoolDefaultNode: redis.Pool.Stats()}
+}
+
+// RedisPoolCollector : Prometheus collector of the stats of the connections pools of Redis, read when metrics are scraped
+type RedisPoolCollector struct {
+	Redis *Redis
+}
+
+// RegisterRedisPoolMetrics : Register Prometheus collector of the stats of the connections pools of redis, exposed on /metrics
+func RegisterRedisPoolMetrics(redis *Redis) {
+	prometheus.MustRegister(&RedisPoolCollector{Redis: redis})
+}
+
+// Describe : Send descriptions of the metrics of the collector
+func (collector *RedisPoolCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- redisPoolActiveConnections
+	ch <- redisPoolIdleConnections
+	ch <- redisPoolWaits
+	ch <- redisPoolWaitDuration
+}
+
+// Collect : Send current stats of the connections pools, by node
+func (collector *RedisPoolCollector) Collect(ch chan<- prometheus.Metric) {
+
+	for node, stats := range collector.Redis.PoolStats() {
+		ch <- prometheus.MustNewConstMetric(redisPoolActiveConnections, prometheus.GaugeValue, float64(stats.ActiveCount), node)
+		ch <- prometheus.MustNewConstMetric(redisPoolIdleConnections, prometheus.GaugeValue, float64(stats.IdleCount), node)
+		ch <- prometheus.MustNewConstMetric(redisPoolWaits, prometheus.CounterValue, float64(stats.WaitCount), node)
+		ch <- prometheus.MustNewConstMetric(redisPoolWaitDuration, prometheus.CounterValue, stats.WaitDuration.Seconds(), node)
+	}
+}
@@ -0,0 +1,86 @@
+package models
+
+import (
+	context "context"
+	fmt "fmt"
+	net "net"
+	strconv "strconv"
+	sync "sync"
+
+	redisgo "github.com/gomodule/redigo/redis"
+)
+
+const (
+	// redisClusterSlots : Hash slots of a Redis Cluster
+	redisClusterSlots = 16384
+)
+
+// redisSlotNodes : Address of the master node serving each hash slot of a Redis Cluster, so that commands on keys of the same node
+// are pipelined together. Addresses are read with CLUSTER SLOTS when first needed, and again once a pipelined command was redirected
+type redisSlotNodes struct {
+	mutex sync.Mutex
+	nodes []string
+}
+
+// get : Return addresses of master nodes by slot, reading them on redis when unknown
+func (slotNodes *redisSlotNodes) get(ctx context.Context, redis *Redis) ([]string, error) {
+
+	slotNodes.mutex.Lock()
+	defer slotNodes.mutex.Unlock()
+
+	if slotNodes.nodes != nil {
+		return slotNodes.nodes, nil
+	}
+
+	slotRanges, err := redisgo.Values(redis.do(ctx, "CLUSTER", "SLOTS"))
+
+	if err != nil {
+		return nil, fmt.Errorf("error reading cluster slots : %v", err)
+	}
+
+	nodes := make([]string, redisClusterSlots)
+
+	for _, slotRange := range slotRanges {
+
+		// Each range is [start, end, [host, port, ...] of master, replicas...]
+		values, err := redisgo.Values(slotRange, nil)
+
+		if err != nil || len(values) < 3 {
+			return nil, fmt.Errorf("error reading cluster slots : unexpected range %v", slotRange)
+		}
+
+		start, startErr := redisgo.Int(values[0], nil)
+		end, endErr := redisgo.Int(values[1], nil)
+		master, masterErr := redisgo.Values(values[2], nil)
+
+		if startErr != nil || endErr != nil || masterErr != nil || len(master) < 2 {
+			return nil, fmt.Errorf("error reading cluster slots : unexpected range %v", slotRange)
+		}
+
+		host, hostErr := redisgo.String(master[0], nil)
+		port, portErr := redisgo.Int(master[1], nil)
+
+		if hostErr != nil || portErr != nil {
+			return nil, fmt.Errorf("error reading cluster slots : unexpected master %v", master)
+		}
+
+		address := net.JoinHostPort(host, strconv.Itoa(port))
+
+		for slot := start; slot <= end && slot < redisClusterSlots; slot++ {
+			nodes[slot] = address
+		}
+	}
+
+	slotNodes.nodes = nodes
+
+	return nodes, nil
+}
+
+// invalidate : Forget addresses of master nodes, read again by the next batch
+func (slotNodes *redisSlotNodes) invalidate() {
+
+	slotNodes.mutex.Lock()
+	defer slotNodes.mutex.Unlock()
+
+	slotNodes.nodes = nil
+}
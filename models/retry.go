@@ -0,0 +1,680 @@
+package models
+
+import (
+	context "context"
+	errors "errors"
+	rand "math/rand"
+	time "time"
+	utils "wave-messaging-management-service/utils"
+
+	mongo "go.mongodb.org/mongo-driver/mongo"
+)
+
+const (
+	// DefaultMongoDBRetryBaseDelay : Milliseconds before the first retry of a MongoDB operation, doubled after each retry, used when none is configured
+	DefaultMongoDBRetryBaseDelay = 50
+
+	// DefaultMongoDBRetryMaxDelay : Maximum milliseconds between two retries of a MongoDB operation, used when none is configured
+	DefaultMongoDBRetryMaxDelay = 1000
+)
+
+var (
+	// transientMongoErrorCodes : MongoDB server error codes of failures expected to pass (Network errors, primary step down, shutdown)
+	transientMongoErrorCodes = []int{
+		6,     // HostUnreachable
+		7,     // HostNotFound
+		89,    // NetworkTimeout
+		91,    // ShutdownInProgress
+		189,   // PrimarySteppedDown
+		262,   // ExceededTimeLimit
+		9001,  // SocketException
+		10107, // NotWritablePrimary
+		11600, // InterruptedAtShutdown
+		11602, // InterruptedDueToReplStateChange
+		13435, // NotPrimaryNoSecondaryOk
+		13436, // NotPrimaryOrSecondary
+	}
+)
+
+// RetryingMongoDB : Store wrapper of MongoDB retrying operations failing with transient errors (See IsTransientMongoError), with exponential backoff and jitter.
+// Only reads and idempotent writes are retried, retries being counted (See metrics)
+type RetryingMongoDB struct {
+	MongoDB Store
+	Config  *MongoDBConfig
+}
+
+// RetryMongoDB : Return mongoDB retrying operations, whose retry settings are read from config on every operation
+func RetryMongoDB(mongoDB Store, config *MongoDBConfig) Store {
+	return &RetryingMongoDB{MongoDB: mongoDB, Config: config}
+}
+
+// IsTransientMongoError : Check err is a network error, or a server error expected to pass (e.g. primary stepping down during an election).
+// Context errors are not transient, the operation being past its deadline or canceled
+func IsTransientMongoError(err error) bool {
+
+	if err == nil || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+
+	if mongo.IsNetworkError(err) {
+		return true
+	}
+
+	var serverErr mongo.ServerError
+
+	if !errors.As(err, &serverErr) {
+		return false
+	}
+
+	if serverErr.HasErrorLabel("RetryableWriteError") || serverErr.HasErrorLabel("TransientTransactionError") {
+		return true
+	}
+
+	for _, code := range transientMongoErrorCodes {
+		if serverErr.HasErrorCode(code) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// retry : Run operation, again while it fails with a transient error, up to MaxRetries times and until ctx is done.
+// Retries wait between half and whole of the backoff delay (Full jitter), so that instances don't retry in sync
+func (mongoDB *RetryingMongoDB) retry(ctx context.Context, name string, operation func() error) error {
+
+	delay := time.Duration(mongoDB.Config.RetryBaseDelay) * time.Millisecond
+
+	if delay <= 0 {
+		delay = DefaultMongoDBRetryBaseDelay * time.Millisecond
+	}
+
+	maxDelay := time.Duration(mongoDB.Config.RetryMaxDelay) * time.Millisecond
+
+	if maxDelay <= 0 {
+		maxDelay = DefaultMongoDBRetryMaxDelay * time.Millisecond
+	}
+
+	for attempt := 0; ; attempt++ {
+
+		err := operation()
+
+		if !IsTransientMongoError(err) {
+
+			if attempt > 0 && err == nil {
+				DatastoreRetries.WithLabelValues(DatastoreMongoDB, name, "recovered").Inc()
+			}
+
+			return err
+		}
+
+		if attempt >= mongoDB.Config.MaxRetries {
+
+			if attempt > 0 {
+				DatastoreRetries.WithLabelValues(DatastoreMongoDB, name, "exhausted").Inc()
+			}
+
+			return err
+		}
+
+		DatastoreRetries.WithLabelValues(DatastoreMongoDB, name, "retried").Inc()
+
+		select {
+		case <-time.After(delay/2 + time.Duration(rand.Int63n(int64(delay/2)+1))):
+		case <-ctx.Done():
+			DatastoreRetries.WithLabelValues(DatastoreMongoDB, name, "exhausted").Inc()
+			return err
+		}
+
+		delay *= 2
+
+		if delay > maxDelay {
+			delay = maxDelay
+		}
+	}
+}
+
+// Close : Store.Close, not retried
+func (mongoDB *RetryingMongoDB) Close() error {
+	return mongoDB.MongoDB.Close()
+}
+
+// ForTenant : Return retrying MongoDB scoped to tenantID
+func (mongoDB *RetryingMongoDB) ForTenant(tenantID string) Store {
+	return &RetryingMongoDB{MongoDB: mongoDB.MongoDB.ForTenant(tenantID), Config: mongoDB.Config}
+}
+
+// CreateGroupConversations : Store.CreateGroupConversations, not retried as its transaction is retried by the driver on transient errors
+func (mongoDB *RetryingMongoDB) CreateGroupConversations(ctx context.Context, groupConversations []*GroupConversation, groupTopicPath string, events ...*LifecycleEvent) error {
+	return mongoDB.MongoDB.CreateGroupConversations(ctx, groupConversations, groupTopicPath, events...)
+}
+
+// GetGroupConversation : Store.GetGroupConversation, retried on transient errors
+func (mongoDB *RetryingMongoDB) GetGroupConversation(ctx context.Context, groupConversationID string) (*GroupConversation, error) {
+
+	var result *GroupConversation
+
+	err := mongoDB.retry(ctx, "GetGroupConversation", func() (err error) {
+		result, err = mongoDB.MongoDB.GetGroupConversation(ctx, groupConversationID)
+		return err
+	})
+
+	return result, err
+}
+
+// CountCreatedGroupConversations : Store.CountCreatedGroupConversations, retried on transient errors
+func (mongoDB *RetryingMongoDB) CountCreatedGroupConversations(ctx context.Context, userID string) (int, error) {
+
+	var result int
+
+	err := mongoDB.retry(ctx, "CountCreatedGroupConversations", func() (err error) {
+		result, err = mongoDB.MongoDB.CountCreatedGroupConversations(ctx, userID)
+		return err
+	})
+
+	return result, err
+}
+
+// CountGroupMemberships : Store.CountGroupMemberships, retried on transient errors
+func (mongoDB *RetryingMongoDB) CountGroupMemberships(ctx context.Context, userID string) (int, error) {
+
+	var result int
+
+	err := mongoDB.retry(ctx, "CountGroupMemberships", func() (err error) {
+		result, err = mongoDB.MongoDB.CountGroupMemberships(ctx, userID)
+		return err
+	})
+
+	return result, err
+}
+
+// GetGroupMemberships : Store.GetGroupMemberships, retried on transient errors
+func (mongoDB *RetryingMongoDB) GetGroupMemberships(ctx context.Context, userID string, pagination *utils.Pagination) ([]*GroupConversation, int, error) {
+
+	var result []*GroupConversation
+	var total int
+
+	err := mongoDB.retry(ctx, "GetGroupMemberships", func() (err error) {
+		result, total, err = mongoDB.MongoDB.GetGroupMemberships(ctx, userID, pagination)
+		return err
+	})
+
+	return result, total, err
+}
+
+// RemoveGroupConversation : Store.RemoveGroupConversation, not retried as its transaction is retried by the driver on transient errors
+func (mongoDB *RetryingMongoDB) RemoveGroupConversation(ctx context.Context, groupConversationID string, groupTopicPath string, deletedAt time.Time, events ...*LifecycleEvent) (*GroupConversation, error) {
+	return mongoDB.MongoDB.RemoveGroupConversation(ctx, groupConversationID, groupTopicPath, deletedAt, events...)
+}
+
+// RestoreGroupConversation : Store.RestoreGroupConversation, not retried as its transaction is retried by the driver on transient errors
+func (mongoDB *RetryingMongoDB) RestoreGroupConversation(ctx context.Context, groupConversationID string, groupTopicPath string, events ...*LifecycleEvent) (*GroupConversation, error) {
+	return mongoDB.MongoDB.RestoreGroupConversation(ctx, groupConversationID, groupTopicPath, events...)
+}
+
+// PurgeGroupConversations : Store.PurgeGroupConversations, retried on transient errors
+func (mongoDB *RetryingMongoDB) PurgeGroupConversations(ctx context.Context, deletedBefore time.Time) (int, error) {
+
+	var result int
+
+	err := mongoDB.retry(ctx, "PurgeGroupConversations", func() (err error) {
+		result, err = mongoDB.MongoDB.PurgeGroupConversations(ctx, deletedBefore)
+		return err
+	})
+
+	return result, err
+}
+
+// AddProfileACL : Store.AddProfileACL, not retried as its transaction is retried by the driver on transient errors
+func (mongoDB *RetryingMongoDB) AddProfileACL(ctx context.Context, verneMQACL *VerneMQACL, events ...*LifecycleEvent) error {
+	return mongoDB.MongoDB.AddProfileACL(ctx, verneMQACL, events...)
+}
+
+// GetProfileACL : Store.GetProfileACL, retried on transient errors
+func (mongoDB *RetryingMongoDB) GetProfileACL(ctx context.Context, userID string) (*VerneMQACL, error) {
+
+	var result *VerneMQACL
+
+	err := mongoDB.retry(ctx, "GetProfileACL", func() (err error) {
+		result, err = mongoDB.MongoDB.GetProfileACL(ctx, userID)
+		return err
+	})
+
+	return result, err
+}
+
+// GetClientACL : Store.GetClientACL, retried on transient errors
+func (mongoDB *RetryingMongoDB) GetClientACL(ctx context.Context, clientID string) (*VerneMQACL, error) {
+
+	var result *VerneMQACL
+
+	err := mongoDB.retry(ctx, "GetClientACL", func() (err error) {
+		result, err = mongoDB.MongoDB.GetClientACL(ctx, clientID)
+		return err
+	})
+
+	return result, err
+}
+
+// GetUserACLs : Store.GetUserACLs, retried on transient errors
+func (mongoDB *RetryingMongoDB) GetUserACLs(ctx context.Context, userID string) ([]*VerneMQACL, error) {
+
+	var result []*VerneMQACL
+
+	err := mongoDB.retry(ctx, "GetUserACLs", func() (err error) {
+		result, err = mongoDB.MongoDB.GetUserACLs(ctx, userID)
+		return err
+	})
+
+	return result, err
+}
+
+// RemoveDeviceACL : Store.RemoveDeviceACL, not retried as its transaction is retried by the driver on transient errors
+func (mongoDB *RetryingMongoDB) RemoveDeviceACL(ctx context.Context, userID string, deviceClientID string, events ...*LifecycleEvent) error {
+	return mongoDB.MongoDB.RemoveDeviceACL(ctx, userID, deviceClientID, events...)
+}
+
+// RemoveUserACLs : Store.RemoveUserACLs, not retried as its transaction is retried by the driver on transient errors
+func (mongoDB *RetryingMongoDB) RemoveUserACLs(ctx context.Context, userID string, events ...*LifecycleEvent) error {
+	return mongoDB.MongoDB.RemoveUserACLs(ctx, userID, events...)
+}
+
+// GetExpiredACLs : Store.GetExpiredACLs, retried on transient errors
+func (mongoDB *RetryingMongoDB) GetExpiredACLs(ctx context.Context, now time.Time) ([]*VerneMQACL, error) {
+
+	var result []*VerneMQACL
+
+	err := mongoDB.retry(ctx, "GetExpiredACLs", func() (err error) {
+		result, err = mongoDB.MongoDB.GetExpiredACLs(ctx, now)
+		return err
+	})
+
+	return result, err
+}
+
+// RemoveExpiredACLs : Store.RemoveExpiredACLs, not retried as its transaction is retried by the driver on transient errors
+func (mongoDB *RetryingMongoDB) RemoveExpiredACLs(ctx context.Context, now time.Time, events ...*LifecycleEvent) error {
+	return mongoDB.MongoDB.RemoveExpiredACLs(ctx, now, events...)
+}
+
+// RenewACLs : Store.RenewACLs, retried on transient errors
+func (mongoDB *RetryingMongoDB) RenewACLs(ctx context.Context, userID string, expiresAt time.Time) (bool, error) {
+
+	var result bool
+
+	err := mongoDB.retry(ctx, "RenewACLs", func() (err error) {
+		result, err = mongoDB.MongoDB.RenewACLs(ctx, userID, expiresAt)
+		return err
+	})
+
+	return result, err
+}
+
+// AuthorizePublishing : Store.AuthorizePublishing, retried on transient errors
+func (mongoDB *RetryingMongoDB) AuthorizePublishing(ctx context.Context, userID string, topic string) error {
+	return mongoDB.retry(ctx, "AuthorizePublishing", func() error {
+		return mongoDB.MongoDB.AuthorizePublishing(ctx, userID, topic)
+	})
+}
+
+// UpdatePassHash : Store.UpdatePassHash, not retried as its transaction is retried by the driver on transient errors
+func (mongoDB *RetryingMongoDB) UpdatePassHash(ctx context.Context, userID string, newPasshash string, events ...*LifecycleEvent) error {
+	return mongoDB.MongoDB.UpdatePassHash(ctx, userID, newPasshash, events...)
+}
+
+// AddPushToken : Store.AddPushToken, retried on transient errors
+func (mongoDB *RetryingMongoDB) AddPushToken(ctx context.Context, pushToken *PushToken) error {
+	return mongoDB.retry(ctx, "AddPushToken", func() error {
+		return mongoDB.MongoDB.AddPushToken(ctx, pushToken)
+	})
+}
+
+// RemovePushToken : Store.RemovePushToken, not retried as an attempt failing once applied would be reported as not applied (The driver retries it once, safely)
+func (mongoDB *RetryingMongoDB) RemovePushToken(ctx context.Context, userID string, token string) error {
+	return mongoDB.MongoDB.RemovePushToken(ctx, userID, token)
+}
+
+// GetPushTokens : Store.GetPushTokens, retried on transient errors
+func (mongoDB *RetryingMongoDB) GetPushTokens(ctx context.Context, userID string) ([]*PushToken, error) {
+
+	var result []*PushToken
+
+	err := mongoDB.retry(ctx, "GetPushTokens", func() (err error) {
+		result, err = mongoDB.MongoDB.GetPushTokens(ctx, userID)
+		return err
+	})
+
+	return result, err
+}
+
+// GetNotificationPreferences : Store.GetNotificationPreferences, retried on transient errors
+func (mongoDB *RetryingMongoDB) GetNotificationPreferences(ctx context.Context, userID string) (*NotificationPreferences, error) {
+
+	var result *NotificationPreferences
+
+	err := mongoDB.retry(ctx, "GetNotificationPreferences", func() (err error) {
+		result, err = mongoDB.MongoDB.GetNotificationPreferences(ctx, userID)
+		return err
+	})
+
+	return result, err
+}
+
+// SetNotificationPreferences : Store.SetNotificationPreferences, retried on transient errors
+func (mongoDB *RetryingMongoDB) SetNotificationPreferences(ctx context.Context, preferences *NotificationPreferences) error {
+	return mongoDB.retry(ctx, "SetNotificationPreferences", func() error {
+		return mongoDB.MongoDB.SetNotificationPreferences(ctx, preferences)
+	})
+}
+
+// AddAPIKey : Store.AddAPIKey, not retried as the entry would be inserted twice (The driver retries it once, safely)
+func (mongoDB *RetryingMongoDB) AddAPIKey(ctx context.Context, apiKey *APIKey) error {
+	return mongoDB.MongoDB.AddAPIKey(ctx, apiKey)
+}
+
+// GetAPIKey : Store.GetAPIKey, retried on transient errors
+func (mongoDB *RetryingMongoDB) GetAPIKey(ctx context.Context, hashedKey string) (*APIKey, error) {
+
+	var result *APIKey
+
+	err := mongoDB.retry(ctx, "GetAPIKey", func() (err error) {
+		result, err = mongoDB.MongoDB.GetAPIKey(ctx, hashedKey)
+		return err
+	})
+
+	return result, err
+}
+
+// GetQuotaOverride : Store.GetQuotaOverride, retried on transient errors
+func (mongoDB *RetryingMongoDB) GetQuotaOverride(ctx context.Context, userID string) (*QuotaOverride, error) {
+
+	var result *QuotaOverride
+
+	err := mongoDB.retry(ctx, "GetQuotaOverride", func() (err error) {
+		result, err = mongoDB.MongoDB.GetQuotaOverride(ctx, userID)
+		return err
+	})
+
+	return result, err
+}
+
+// SetQuotaOverride : Store.SetQuotaOverride, retried on transient errors
+func (mongoDB *RetryingMongoDB) SetQuotaOverride(ctx context.Context, override *QuotaOverride) error {
+	return mongoDB.retry(ctx, "SetQuotaOverride", func() error {
+		return mongoDB.MongoDB.SetQuotaOverride(ctx, override)
+	})
+}
+
+// AddUsage : Store.AddUsage, not retried as counters would be incremented twice
+func (mongoDB *RetryingMongoDB) AddUsage(ctx context.Context, date string, tenantID string, userID string, metric string, count int) error {
+	return mongoDB.MongoDB.AddUsage(ctx, date, tenantID, userID, metric, count)
+}
+
+// GetUsage : Store.GetUsage, retried on transient errors
+func (mongoDB *RetryingMongoDB) GetUsage(ctx context.Context, filter *UsageFilter) ([]*UsageRecord, error) {
+
+	var result []*UsageRecord
+
+	err := mongoDB.retry(ctx, "GetUsage", func() (err error) {
+		result, err = mongoDB.MongoDB.GetUsage(ctx, filter)
+		return err
+	})
+
+	return result, err
+}
+
+// AddAuditEntry : Store.AddAuditEntry, not retried as the entry would be inserted twice (The driver retries it once, safely)
+func (mongoDB *RetryingMongoDB) AddAuditEntry(ctx context.Context, entry *AuditEntry) error {
+	return mongoDB.MongoDB.AddAuditEntry(ctx, entry)
+}
+
+// GetAuditEntries : Store.GetAuditEntries, retried on transient errors
+func (mongoDB *RetryingMongoDB) GetAuditEntries(ctx context.Context, filter *AuditFilter) ([]*AuditEntry, int, error) {
+
+	var result []*AuditEntry
+	var total int
+
+	err := mongoDB.retry(ctx, "GetAuditEntries", func() (err error) {
+		result, total, err = mongoDB.MongoDB.GetAuditEntries(ctx, filter)
+		return err
+	})
+
+	return result, total, err
+}
+
+// AddWebhook : Store.AddWebhook, not retried as the entry would be inserted twice (The driver retries it once, safely)
+func (mongoDB *RetryingMongoDB) AddWebhook(ctx context.Context, webhook *Webhook) error {
+	return mongoDB.MongoDB.AddWebhook(ctx, webhook)
+}
+
+// GetWebhooks : Store.GetWebhooks, retried on transient errors
+func (mongoDB *RetryingMongoDB) GetWebhooks(ctx context.Context) ([]*Webhook, error) {
+
+	var result []*Webhook
+
+	err := mongoDB.retry(ctx, "GetWebhooks", func() (err error) {
+		result, err = mongoDB.MongoDB.GetWebhooks(ctx)
+		return err
+	})
+
+	return result, err
+}
+
+// GetWebhook : Store.GetWebhook, retried on transient errors
+func (mongoDB *RetryingMongoDB) GetWebhook(ctx context.Context, webhookID string) (*Webhook, error) {
+
+	var result *Webhook
+
+	err := mongoDB.retry(ctx, "GetWebhook", func() (err error) {
+		result, err = mongoDB.MongoDB.GetWebhook(ctx, webhookID)
+		return err
+	})
+
+	return result, err
+}
+
+// RemoveWebhook : Store.RemoveWebhook, not retried as an attempt failing once applied would be reported as not applied (The driver retries it once, safely)
+func (mongoDB *RetryingMongoDB) RemoveWebhook(ctx context.Context, webhookID string) (bool, error) {
+	return mongoDB.MongoDB.RemoveWebhook(ctx, webhookID)
+}
+
+// AddWebhookDelivery : Store.AddWebhookDelivery, not retried as the entry would be inserted twice (The driver retries it once, safely)
+func (mongoDB *RetryingMongoDB) AddWebhookDelivery(ctx context.Context, delivery *WebhookDelivery) error {
+	return mongoDB.MongoDB.AddWebhookDelivery(ctx, delivery)
+}
+
+// UpdateWebhookDelivery : Store.UpdateWebhookDelivery, retried on transient errors
+func (mongoDB *RetryingMongoDB) UpdateWebhookDelivery(ctx context.Context, delivery *WebhookDelivery) error {
+	return mongoDB.retry(ctx, "UpdateWebhookDelivery", func() error {
+		return mongoDB.MongoDB.UpdateWebhookDelivery(ctx, delivery)
+	})
+}
+
+// GetWebhookDeliveries : Store.GetWebhookDeliveries, retried on transient errors
+func (mongoDB *RetryingMongoDB) GetWebhookDeliveries(ctx context.Context, webhookID string, pagination *utils.Pagination) ([]*WebhookDelivery, int, error) {
+
+	var result []*WebhookDelivery
+	var total int
+
+	err := mongoDB.retry(ctx, "GetWebhookDeliveries", func() (err error) {
+		result, total, err = mongoDB.MongoDB.GetWebhookDeliveries(ctx, webhookID, pagination)
+		return err
+	})
+
+	return result, total, err
+}
+
+// GetDueWebhookDeliveries : Store.GetDueWebhookDeliveries, retried on transient errors
+func (mongoDB *RetryingMongoDB) GetDueWebhookDeliveries(ctx context.Context, now time.Time, limit int) ([]*WebhookDelivery, error) {
+
+	var result []*WebhookDelivery
+
+	err := mongoDB.retry(ctx, "GetDueWebhookDeliveries", func() (err error) {
+		result, err = mongoDB.MongoDB.GetDueWebhookDeliveries(ctx, now, limit)
+		return err
+	})
+
+	return result, err
+}
+
+// ClaimWebhookDelivery : Store.ClaimWebhookDelivery, not retried as an attempt failing once applied would be reported as not applied (The driver retries it once, safely)
+func (mongoDB *RetryingMongoDB) ClaimWebhookDelivery(ctx context.Context, deliveryID string, now time.Time, leaseUntil time.Time) (bool, error) {
+	return mongoDB.MongoDB.ClaimWebhookDelivery(ctx, deliveryID, now, leaseUntil)
+}
+
+// AddWebhookDeadLetter : Store.AddWebhookDeadLetter, not retried as the entry would be inserted twice (The driver retries it once, safely)
+func (mongoDB *RetryingMongoDB) AddWebhookDeadLetter(ctx context.Context, deadLetter *WebhookDeadLetter) error {
+	return mongoDB.MongoDB.AddWebhookDeadLetter(ctx, deadLetter)
+}
+
+// GetWebhookDeadLetter : Store.GetWebhookDeadLetter, retried on transient errors
+func (mongoDB *RetryingMongoDB) GetWebhookDeadLetter(ctx context.Context, deadLetterID string) (*WebhookDeadLetter, error) {
+
+	var result *WebhookDeadLetter
+
+	err := mongoDB.retry(ctx, "GetWebhookDeadLetter", func() (err error) {
+		result, err = mongoDB.MongoDB.GetWebhookDeadLetter(ctx, deadLetterID)
+		return err
+	})
+
+	return result, err
+}
+
+// GetWebhookDeadLetters : Store.GetWebhookDeadLetters, retried on transient errors
+func (mongoDB *RetryingMongoDB) GetWebhookDeadLetters(ctx context.Context, filter *WebhookDeadLetterFilter) ([]*WebhookDeadLetter, int, error) {
+
+	var result []*WebhookDeadLetter
+	var total int
+
+	err := mongoDB.retry(ctx, "GetWebhookDeadLetters", func() (err error) {
+		result, total, err = mongoDB.MongoDB.GetWebhookDeadLetters(ctx, filter)
+		return err
+	})
+
+	return result, total, err
+}
+
+// RemoveWebhookDeadLetter : Store.RemoveWebhookDeadLetter, not retried as an attempt failing once applied would be reported as not applied (The driver retries it once, safely)
+func (mongoDB *RetryingMongoDB) RemoveWebhookDeadLetter(ctx context.Context, deadLetterID string) (bool, error) {
+	return mongoDB.MongoDB.RemoveWebhookDeadLetter(ctx, deadLetterID)
+}
+
+// GetOutboxEvents : Store.GetOutboxEvents, retried on transient errors
+func (mongoDB *RetryingMongoDB) GetOutboxEvents(ctx context.Context, now time.Time, limit int) ([]*OutboxEvent, error) {
+
+	var result []*OutboxEvent
+
+	err := mongoDB.retry(ctx, "GetOutboxEvents", func() (err error) {
+		result, err = mongoDB.MongoDB.GetOutboxEvents(ctx, now, limit)
+		return err
+	})
+
+	return result, err
+}
+
+// ClaimOutboxEvent : Store.ClaimOutboxEvent, not retried as an attempt failing once applied would be reported as not applied (The driver retries it once, safely)
+func (mongoDB *RetryingMongoDB) ClaimOutboxEvent(ctx context.Context, eventID string, now time.Time, leaseUntil time.Time) (bool, error) {
+	return mongoDB.MongoDB.ClaimOutboxEvent(ctx, eventID, now, leaseUntil)
+}
+
+// RemoveOutboxEvent : Store.RemoveOutboxEvent, retried on transient errors
+func (mongoDB *RetryingMongoDB) RemoveOutboxEvent(ctx context.Context, eventID string) error {
+	return mongoDB.retry(ctx, "RemoveOutboxEvent", func() error {
+		return mongoDB.MongoDB.RemoveOutboxEvent(ctx, eventID)
+	})
+}
+
+// DedupACLPatterns : Store.DedupACLPatterns, retried on transient errors
+func (mongoDB *RetryingMongoDB) DedupACLPatterns(ctx context.Context) (int, error) {
+
+	var result int
+
+	err := mongoDB.retry(ctx, "DedupACLPatterns", func() (err error) {
+		result, err = mongoDB.MongoDB.DedupACLPatterns(ctx)
+		return err
+	})
+
+	return result, err
+}
+
+// GetDeletedACLs : Store.GetDeletedACLs, retried on transient errors
+func (mongoDB *RetryingMongoDB) GetDeletedACLs(ctx context.Context, userID string) ([]*DeletedACL, error) {
+
+	var result []*DeletedACL
+
+	err := mongoDB.retry(ctx, "GetDeletedACLs", func() (err error) {
+		result, err = mongoDB.MongoDB.GetDeletedACLs(ctx, userID)
+		return err
+	})
+
+	return result, err
+}
+
+// RestoreUserACLs : Store.RestoreUserACLs, not retried as its transaction is retried by the driver on transient errors
+func (mongoDB *RetryingMongoDB) RestoreUserACLs(ctx context.Context, userID string, events ...*LifecycleEvent) (int, error) {
+	return mongoDB.MongoDB.RestoreUserACLs(ctx, userID, events...)
+}
+
+// PurgeDeletedACLs : Store.PurgeDeletedACLs, retried on transient errors
+func (mongoDB *RetryingMongoDB) PurgeDeletedACLs(ctx context.Context, deletedBefore time.Time) (int, error) {
+
+	var result int
+
+	err := mongoDB.retry(ctx, "PurgeDeletedACLs", func() (err error) {
+		result, err = mongoDB.MongoDB.PurgeDeletedACLs(ctx, deletedBefore)
+		return err
+	})
+
+	return result, err
+}
+
+// GetMigrations : Store.GetMigrations, retried on transient errors
+func (mongoDB *RetryingMongoDB) GetMigrations(ctx context.Context) ([]*MigrationRecord, error) {
+
+	var result []*MigrationRecord
+
+	err := mongoDB.retry(ctx, "GetMigrations", func() (err error) {
+		result, err = mongoDB.MongoDB.GetMigrations(ctx)
+		return err
+	})
+
+	return result, err
+}
+
+// ClaimMigration : Store.ClaimMigration, not retried as a retry would not tell its own claim from another one
+func (mongoDB *RetryingMongoDB) ClaimMigration(ctx context.Context, version int, name string, now time.Time, leaseUntil time.Time) (bool, error) {
+	return mongoDB.MongoDB.ClaimMigration(ctx, version, name, now, leaseUntil)
+}
+
+// CompleteMigration : Store.CompleteMigration, retried on transient errors
+func (mongoDB *RetryingMongoDB) CompleteMigration(ctx context.Context, version int, now time.Time) error {
+	return mongoDB.retry(ctx, "CompleteMigration", func() error {
+		return mongoDB.MongoDB.CompleteMigration(ctx, version, now)
+	})
+}
+
+// WatchChanges : Store.WatchChanges, not retried as its watcher resumes it (See StartChangeStreams)
+func (mongoDB *RetryingMongoDB) WatchChanges(ctx context.Context, resumeToken []byte, handle func(change *DataChange)) ([]byte, error) {
+	return mongoDB.MongoDB.WatchChanges(ctx, resumeToken, handle)
+}
+
+// EnsureIndexes : Store.EnsureIndexes, retried on transient errors
+func (mongoDB *RetryingMongoDB) EnsureIndexes(ctx context.Context) error {
+	return mongoDB.retry(ctx, "EnsureIndexes", func() error {
+		return mongoDB.MongoDB.EnsureIndexes(ctx)
+	})
+}
+
+// ExpireTombstones : Store.ExpireTombstones, retried on transient errors
+func (mongoDB *RetryingMongoDB) ExpireTombstones(ctx context.Context, expireAfter time.Duration) error {
+	return mongoDB.retry(ctx, "ExpireTombstones", func() error {
+		return mongoDB.MongoDB.ExpireTombstones(ctx, expireAfter)
+	})
+}
+
+// Ping : Store.Ping, retried on transient errors
+func (mongoDB *RetryingMongoDB) Ping(ctx context.Context) error {
+	return mongoDB.retry(ctx, "Ping", func() error {
+		return mongoDB.MongoDB.Ping(ctx)
+	})
+}
@@ -0,0 +1,122 @@
+package models
+
+//go:generate mockgen -destination=mocks/mocks.go -package=mocks wave-messaging-management-service/models Store,RedisInterface
+
+import (
+	context "context"
+	errors "errors"
+	time "time"
+	utils "wave-messaging-management-service/utils"
+)
+
+const (
+	// StoreBackendMongoDB : Store backed by MongoDB (Default)
+	StoreBackendMongoDB = "mongodb"
+
+	// StoreBackendPostgreSQL : Store backed by PostgreSQL, for deployments that can't run MongoDB
+	StoreBackendPostgreSQL = "postgresql"
+)
+
+var (
+	// ErrNotSupported : Operation not supported by the store backend
+	ErrNotSupported = errors.New("Operation not supported by the store backend")
+)
+
+// ConversationStore : Storage of group conversations. Group creation grants access to their members, removal revokes it (See ACLStore).
+// Removed group conversations are left out of queries until restored or purged (See TombstonesConfig), purges covering every tenant
+type ConversationStore interface {
+	CreateGroupConversations(ctx context.Context, groupConversations []*GroupConversation, groupTopicPath string, events ...*LifecycleEvent) error
+	GetGroupConversation(ctx context.Context, groupConversationID string) (*GroupConversation, error)
+	CountCreatedGroupConversations(ctx context.Context, userID string) (int, error)
+	CountGroupMemberships(ctx context.Context, userID string) (int, error)
+	GetGroupMemberships(ctx context.Context, userID string, pagination *utils.Pagination) ([]*GroupConversation, int, error)
+	RemoveGroupConversation(ctx context.Context, groupConversationID string, groupTopicPath string, deletedAt time.Time, events ...*LifecycleEvent) (*GroupConversation, error)
+	RestoreGroupConversation(ctx context.Context, groupConversationID string, groupTopicPath string, events ...*LifecycleEvent) (*GroupConversation, error)
+	PurgeGroupConversations(ctx context.Context, deletedBefore time.Time) (int, error)
+}
+
+// ACLStore : Storage of VerneMQ ACLs, read by the broker authentication plugin. Mutations taking events write them to the outbox in the same transaction.
+// Removed ACLs are moved to tombstones (See DeletedACL), until restored or purged
+type ACLStore interface {
+	AddProfileACL(ctx context.Context, verneMQACL *VerneMQACL, events ...*LifecycleEvent) error
+	GetProfileACL(ctx context.Context, userID string) (*VerneMQACL, error)
+	GetClientACL(ctx context.Context, clientID string) (*VerneMQACL, error)
+	GetUserACLs(ctx context.Context, userID string) ([]*VerneMQACL, error)
+	RemoveDeviceACL(ctx context.Context, userID string, deviceClientID string, events ...*LifecycleEvent) error
+	RemoveUserACLs(ctx context.Context, userID string, events ...*LifecycleEvent) error
+	GetExpiredACLs(ctx context.Context, now time.Time) ([]*VerneMQACL, error)
+	RemoveExpiredACLs(ctx context.Context, now time.Time, events ...*LifecycleEvent) error
+	RenewACLs(ctx context.Context, userID string, expiresAt time.Time) (bool, error)
+	AuthorizePublishing(ctx context.Context, userID string, topic string) error
+	UpdatePassHash(ctx context.Context, userID string, newPasshash string, events ...*LifecycleEvent) error
+	DedupACLPatterns(ctx context.Context) (int, error)
+	GetDeletedACLs(ctx context.Context, userID string) ([]*DeletedACL, error)
+	RestoreUserACLs(ctx context.Context, userID string, events ...*LifecycleEvent) (int, error)
+	PurgeDeletedACLs(ctx context.Context, deletedBefore time.Time) (int, error)
+}
+
+// UserSettingsStore : Storage of push tokens, notification preferences and quota overrides of users
+type UserSettingsStore interface {
+	AddPushToken(ctx context.Context, pushToken *PushToken) error
+	RemovePushToken(ctx context.Context, userID string, token string) error
+	GetPushTokens(ctx context.Context, userID string) ([]*PushToken, error)
+	GetNotificationPreferences(ctx context.Context, userID string) (*NotificationPreferences, error)
+	SetNotificationPreferences(ctx context.Context, preferences *NotificationPreferences) error
+	GetQuotaOverride(ctx context.Context, userID string) (*QuotaOverride, error)
+	SetQuotaOverride(ctx context.Context, override *QuotaOverride) error
+}
+
+// ServiceStore : Storage of API keys of internal services, usage counters and audit log
+type ServiceStore interface {
+	AddAPIKey(ctx context.Context, apiKey *APIKey) error
+	GetAPIKey(ctx context.Context, hashedKey string) (*APIKey, error)
+	AddUsage(ctx context.Context, date string, tenantID string, userID string, metric string, count int) error
+	GetUsage(ctx context.Context, filter *UsageFilter) ([]*UsageRecord, error)
+	AddAuditEntry(ctx context.Context, entry *AuditEntry) error
+	GetAuditEntries(ctx context.Context, filter *AuditFilter) ([]*AuditEntry, int, error)
+}
+
+// WebhookStore : Storage of outbound webhooks, their deliveries and dead letters
+type WebhookStore interface {
+	AddWebhook(ctx context.Context, webhook *Webhook) error
+	GetWebhooks(ctx context.Context) ([]*Webhook, error)
+	GetWebhook(ctx context.Context, webhookID string) (*Webhook, error)
+	RemoveWebhook(ctx context.Context, webhookID string) (bool, error)
+	AddWebhookDelivery(ctx context.Context, delivery *WebhookDelivery) error
+	UpdateWebhookDelivery(ctx context.Context, delivery *WebhookDelivery) error
+	GetWebhookDeliveries(ctx context.Context, webhookID string, pagination *utils.Pagination) ([]*WebhookDelivery, int, error)
+	GetDueWebhookDeliveries(ctx context.Context, now time.Time, limit int) ([]*WebhookDelivery, error)
+	ClaimWebhookDelivery(ctx context.Context, deliveryID string, now time.Time, leaseUntil time.Time) (bool, error)
+	AddWebhookDeadLetter(ctx context.Context, deadLetter *WebhookDeadLetter) error
+	GetWebhookDeadLetter(ctx context.Context, deadLetterID string) (*WebhookDeadLetter, error)
+	GetWebhookDeadLetters(ctx context.Context, filter *WebhookDeadLetterFilter) ([]*WebhookDeadLetter, int, error)
+	RemoveWebhookDeadLetter(ctx context.Context, deadLetterID string) (bool, error)
+}
+
+// OutboxStore : Storage of the transactional outbox, and of schema migrations
+type OutboxStore interface {
+	GetOutboxEvents(ctx context.Context, now time.Time, limit int) ([]*OutboxEvent, error)
+	ClaimOutboxEvent(ctx context.Context, eventID string, now time.Time, leaseUntil time.Time) (bool, error)
+	RemoveOutboxEvent(ctx context.Context, eventID string) error
+	GetMigrations(ctx context.Context) ([]*MigrationRecord, error)
+	ClaimMigration(ctx context.Context, version int, name string, now time.Time, leaseUntil time.Time) (bool, error)
+	CompleteMigration(ctx context.Context, version int, now time.Time) error
+}
+
+// Store : Storage of the service, backed by MongoDB or PostgreSQL (See DatastoresConfig). Tenant data is reached through ForTenant.
+// WatchChanges returns ErrNotSupported on backends without change streams, EnsureIndexes creates the schema the backend needs.
+// ExpireTombstones returns ErrNotSupported on backends without TTL indexes, tombstones being purged by the service instead (See PurgeDeletedACLs)
+type Store interface {
+	ConversationStore
+	ACLStore
+	UserSettingsStore
+	ServiceStore
+	WebhookStore
+	OutboxStore
+	WatchChanges(ctx context.Context, resumeToken []byte, handle func(change *DataChange)) ([]byte, error)
+	EnsureIndexes(ctx context.Context) error
+	ExpireTombstones(ctx context.Context, expireAfter time.Duration) error
+	Ping(ctx context.Context) error
+	Close() error
+	ForTenant(tenantID string) Store
+}
@@ -0,0 +1,39 @@
+package models
+
+import (
+	fmt "fmt"
+	regexp "regexp"
+)
+
+var (
+	// namespacePattern : Characters allowed in tenant IDs and identity providers names, which name collections and prefix user IDs
+	namespacePattern = regexp.MustCompile(`^[A-Za-z0-9_-]{1,64}$`)
+)
+
+// ValidateNamespace : Check that name of kind (e.g. tenant ID, identity provider name), when not empty, only holds letters, digits, '_' and '-' (64 at most).
+// Separators of namespaced user IDs ('/', ':') and characters MongoDB refuses in collection names ('$', '.') are refused
+func ValidateNamespace(kind string, name string) error {
+
+	if name != "" && !namespacePattern.MatchString(name) {
+		return fmt.Errorf("Invalid %s %q : only letters, digits, '_' and '-' are allowed (64 at most)", kind, name)
+	}
+
+	return nil
+}
+
+// ForTenant : Return execution environment scoped to tenantID : Tenant collections and topic paths (See TopicPaths).
+// Default tenant (Empty tenant ID) environment is env itself
+func (env *Env) ForTenant(tenantID string) *Env {
+
+	if tenantID == "" || tenantID == env.TenantID {
+		return env
+	}
+
+	// Fields added to Env are carried over too
+	scoped := *env
+	scoped.Store = env.Store.ForTenant(tenantID)
+	scoped.Logger = env.Logger.WithField(LogFieldTenantID, tenantID)
+	scoped.TenantID = tenantID
+
+	return &scoped
+}
@@ -0,0 +1,45 @@
+package models
+
+import (
+	time "time"
+)
+
+const (
+	// DefaultTombstonesRetention : Seconds removed VerneMQ ACLs and group conversations are kept before being purged, used when none is configured
+	DefaultTombstonesRetention = 2592000
+
+	// DefaultTombstonesPurgeInterval : Seconds between tombstones purges, used when none is configured
+	DefaultTombstonesPurgeInterval = 3600
+)
+
+// TombstonesConfig : Soft deletion Config. Removed VerneMQ ACLs and group conversations are kept as tombstones for Retention seconds,
+// so that they can be restored or audited, and purged every PurgeInterval seconds. Nothing is purged while LegalHold is set
+type TombstonesConfig struct {
+	Retention     int  `json:"retention"`
+	PurgeInterval int  `json:"purgeInterval"`
+	LegalHold     bool `json:"legalHold"`
+}
+
+// DeletedACL : Tombstone of a removed VerneMQ ACL. Tombstones are moved out of the VerneMQ ACLs read by the broker, so that they can't authenticate.
+// A client ID has a single tombstone, the one of its last removal
+type DeletedACL struct {
+	VerneMQACL `bson:",inline"`
+	DeletedAt  time.Time `json:"deletedAt" bson:"deleted_at"`
+}
+
+// RetentionPeriod : Return how long tombstones are kept after their removal
+func (config *TombstonesConfig) RetentionPeriod() time.Duration {
+
+	retention := config.Retention
+
+	if retention <= 0 {
+		retention = DefaultTombstonesRetention
+	}
+
+	return time.Duration(retention) * time.Second
+}
+
+// RetainedSince : Return removal date of the oldest tombstones kept at now, older ones being purged
+func (config *TombstonesConfig) RetainedSince(now time.Time) time.Time {
+	return now.Add(-config.RetentionPeriod())
+}
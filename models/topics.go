@@ -0,0 +1,253 @@
+package models
+
+import (
+	bytes "bytes"
+	errors "errors"
+	fmt "fmt"
+	strings "strings"
+	template "text/template"
+)
+
+const (
+	// DefaultTopicNamespace : Topic namespace template used when none is configured, tenants get their own namespace
+	DefaultTopicNamespace = "{{if .TenantID}}tenants/{{.TenantID}}/{{end}}"
+
+	// tenantSentinel : Tenant ID rendering namespace template into the root of all tenants namespaces
+	tenantSentinel = "\x00"
+)
+
+// TopicsConfig : Conversation topics Config, fields are text/template templates rendered with TopicTemplateData.
+// Conversation paths live inside Namespace, which must isolate tenants from each other
+type TopicsConfig struct {
+	Environment string `json:"environment"`
+	Namespace   string `json:"namespace"`
+	Private     string `json:"private"`
+	Group       string `json:"group"`
+	System      string `json:"system"`
+}
+
+// TopicTemplateData : Data topic templates are rendered with
+type TopicTemplateData struct {
+	TenantID    string
+	Environment string
+}
+
+// TopicPaths : Conversation and system topic paths of a tenant, Private, Group and System paths include Namespace
+type TopicPaths struct {
+	Namespace string
+	Private   string
+	Group     string
+	System    string
+
+	// tenantsRoot : Common prefix of tenants namespaces, default tenant patterns can't reach into it
+	tenantsRoot string
+	tenantID    string
+}
+
+// TopicPaths : Return conversation topic paths of the environment tenant, rendered from configured templates
+func (env *Env) TopicPaths() (*TopicPaths, error) {
+	return NewTopicPaths(&env.Config.Topics, env.TenantID)
+}
+
+// NewTopicPaths : Render conversation topic paths of tenantID (Empty for the default tenant).
+// Tenants namespaces must hold the tenant ID as a whole level and no wildcard
+func NewTopicPaths(config *TopicsConfig, tenantID string) (*TopicPaths, error) {
+
+	if strings.ContainsAny(tenantID, "/+#"+tenantSentinel) {
+		return nil, fmt.Errorf("Invalid tenant ID %s", tenantID)
+	}
+
+	namespaceTemplate := config.Namespace
+
+	if namespaceTemplate == "" {
+		namespaceTemplate = DefaultTopicNamespace
+	}
+
+	namespace, err := renderTopicPath(namespaceTemplate, tenantID, config.Environment)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if tenantID != "" && !strings.Contains("/"+namespace, "/"+tenantID+"/") {
+		return nil, fmt.Errorf("Topic namespace %s doesn't isolate tenant %s", namespace, tenantID)
+	}
+
+	private, err := renderTopicPath(orDefault(config.Private, PrivateConversationTopicPath), tenantID, config.Environment)
+
+	if err != nil {
+		return nil, err
+	}
+
+	group, err := renderTopicPath(orDefault(config.Group, GroupConversationTopicPath), tenantID, config.Environment)
+
+	if err != nil {
+		return nil, err
+	}
+
+	system, err := renderTopicPath(orDefault(config.System, SystemTopicPath), tenantID, config.Environment)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if private == "" || group == "" || system == "" || private == group || system == private || system == group {
+		return nil, errors.New("Private, group and system topic paths must be distinct and not empty")
+	}
+
+	// Tenants root is what namespaces of all tenants start with (e.g. tenants/)
+	tenantsRoot, err := renderTemplate(namespaceTemplate, tenantSentinel, config.Environment)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if index := strings.Index(tenantsRoot, tenantSentinel); index >= 0 {
+		tenantsRoot = tenantsRoot[:strings.LastIndex(tenantsRoot[:index], "/")+1]
+	} else {
+		tenantsRoot = ""
+	}
+
+	return &TopicPaths{
+		Namespace:   namespace,
+		Private:     namespace + private,
+		Group:       namespace + group,
+		System:      namespace + system,
+		tenantsRoot: tenantsRoot,
+		tenantID:    tenantID,
+	}, nil
+}
+
+// CheckPattern : Check that ACL pattern stays inside the topic namespace.
+// Tenants namespaces may be nested in the default tenant one : Its patterns can neither use a wildcard right after its namespace nor reach into tenants root
+func (paths *TopicPaths) CheckPattern(pattern string) error {
+
+	if !strings.HasPrefix(pattern, paths.Namespace) || pattern == paths.Namespace {
+		return fmt.Errorf("ACL pattern %s is outside of topic namespace %s", pattern, paths.Namespace)
+	}
+
+	if paths.tenantID != "" {
+		return nil
+	}
+
+	firstLevel := strings.SplitN(strings.TrimPrefix(pattern, paths.Namespace), "/", 2)[0]
+
+	if firstLevel == "+" || firstLevel == "#" || (paths.tenantsRoot != "" && strings.HasPrefix(pattern, paths.tenantsRoot)) {
+		return fmt.Errorf("ACL pattern %s reaches into tenants namespaces", pattern)
+	}
+
+	return nil
+}
+
+// ScopePattern : Move ACL pattern into the topic namespace, then check it stays there
+func (paths *TopicPaths) ScopePattern(pattern string) (string, error) {
+
+	if paths.Namespace != "" && !strings.HasPrefix(pattern, paths.Namespace) {
+		pattern = paths.Namespace + pattern
+	}
+
+	return pattern, paths.CheckPattern(pattern)
+}
+
+// BroadcastTopic : Return topic system messages to all users of the tenant are published on
+func (paths *TopicPaths) BroadcastTopic() string {
+	return paths.System + "broadcast"
+}
+
+// UserSystemTopic : Return topic system messages to internalWaveUserID (On all its devices) are published on
+func (paths *TopicPaths) UserSystemTopic(internalWaveUserID string) string {
+	return paths.System + "users/" + internalWaveUserID
+}
+
+// ParseConversationTopic : Extract conversation type, conversation ID and sender from topic :
+// {private}{senderID}/{recipientID} or {group}{groupID}/{senderID}
+func (paths *TopicPaths) ParseConversationTopic(topic string) (*ConversationTopic, error) {
+
+	switch {
+
+	case strings.HasPrefix(topic, paths.Private):
+
+		parts := strings.Split(strings.TrimPrefix(topic, paths.Private), "/")
+
+		if len(parts) != 2 {
+			return nil, errors.New("Invalid private conversation topic")
+		}
+
+		// Private conversations are identified by their sender from the recipient point of view
+		return &ConversationTopic{
+			ConversationType: PrivateConversationType,
+			ConversationID:   parts[0],
+			SenderID:         parts[0],
+		}, nil
+
+	case strings.HasPrefix(topic, paths.Group):
+
+		parts := strings.Split(strings.TrimPrefix(topic, paths.Group), "/")
+
+		if len(parts) != 2 {
+			return nil, errors.New("Invalid group conversation topic")
+		}
+
+		return &ConversationTopic{
+			ConversationType: GroupConversationType,
+			ConversationID:   parts[0],
+			SenderID:         parts[1],
+		}, nil
+	}
+
+	return nil, errors.New("Not a conversation topic")
+}
+
+// renderTopicPath : Render topic path template, rendered path must hold neither wildcards nor empty levels and ends with a separator
+func renderTopicPath(text string, tenantID string, environment string) (string, error) {
+
+	path, err := renderTemplate(text, tenantID, environment)
+
+	if err != nil {
+		return "", err
+	}
+
+	if path == "" {
+		return "", nil
+	}
+
+	if !strings.HasSuffix(path, "/") {
+		path += "/"
+	}
+
+	if strings.ContainsAny(path, "+#") || strings.HasPrefix(path, "/") || strings.Contains(path, "//") {
+		return "", fmt.Errorf("Invalid topic path %s", path)
+	}
+
+	return path, nil
+}
+
+// renderTemplate : Execute topic template against tenant and environment
+func renderTemplate(text string, tenantID string, environment string) (string, error) {
+
+	tmpl, err := template.New("topic").Parse(text)
+
+	if err != nil {
+		return "", err
+	}
+
+	buffer := &bytes.Buffer{}
+
+	err = tmpl.Execute(buffer, TopicTemplateData{TenantID: tenantID, Environment: environment})
+
+	if err != nil {
+		return "", err
+	}
+
+	return buffer.String(), nil
+}
+
+// orDefault : Return value, defaultValue if empty
+func orDefault(value string, defaultValue string) string {
+
+	if value == "" {
+		return defaultValue
+	}
+
+	return value
+}
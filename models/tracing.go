@@ -0,0 +1,127 @@
+package models
+
+import (
+	context "context"
+	time "time"
+
+	otel "go.opentelemetry.io/otel"
+	otlptracehttp "go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	propagation "go.opentelemetry.io/otel/propagation"
+	resource "go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.12.0"
+	trace "go.opentelemetry.io/otel/trace"
+)
+
+const (
+	// DefaultTracingServiceName : Service name of exported spans, used when none is configured
+	DefaultTracingServiceName = "wave-messaging-management-service"
+)
+
+var (
+	// Tracer : Tracer of the service spans, exported by the tracer provider set by StartTracing (Discarded until then)
+	Tracer = otel.Tracer("wave-messaging-management-service")
+)
+
+// TracingConfig : OpenTelemetry tracing Config, read once at startup.
+// While Enabled, spans are exported to the OTLP/HTTP collector at Endpoint (host:port, plain HTTP if Insecure).
+// SampleRatio of new traces are kept (All of them when unset), traces started by callers keep their sampling decision
+type TracingConfig struct {
+	Enabled     bool    `json:"enabled"`
+	Endpoint    string  `json:"endpoint"`
+	Insecure    bool    `json:"insecure"`
+	ServiceName string  `json:"serviceName"`
+	SampleRatio float64 `json:"sampleRatio"`
+}
+
+// StartTracing : Propagate trace context (W3C Trace Context and Baggage headers) and, when enabled, export spans.
+// Returned function flushes remaining spans and stops exporting
+func StartTracing(config TracingConfig) (func(), error) {
+
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+
+	if !config.Enabled {
+		return func() {}, nil
+	}
+
+	options := []otlptracehttp.Option{otlptracehttp.WithEndpoint(config.Endpoint)}
+
+	if config.Insecure {
+		options = append(options, otlptracehttp.WithInsecure())
+	}
+
+	exporter, err := otlptracehttp.New(context.Background(), options...)
+
+	if err != nil {
+		return nil, err
+	}
+
+	serviceName := config.ServiceName
+
+	if serviceName == "" {
+		serviceName = DefaultTracingServiceName
+	}
+
+	sampleRatio := config.SampleRatio
+
+	if sampleRatio <= 0 {
+		sampleRatio = 1
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(resource.NewWithAttributes(semconv.SchemaURL, semconv.ServiceNameKey.String(serviceName))),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(sampleRatio))),
+	)
+
+	otel.SetTracerProvider(provider)
+
+	return func() {
+		provider.Shutdown(context.Background())
+	}, nil
+}
+
+// TraceContext : Return context of the environment, given to datastores operations. It holds the current span and the deadline of the request
+// being handled, background context outside of requests
+func (env *Env) TraceContext() context.Context {
+
+	if env.Context == nil {
+		return context.Background()
+	}
+
+	return env.Context
+}
+
+// WithTraceContext : Return copy of execution environment whose spans, datastores operations included, are children of ctx span.
+// Datastores operations and remote verifiers retries are bound by ctx deadline too, as they are given TraceContext
+func (env *Env) WithTraceContext(ctx context.Context) *Env {
+
+	scoped := *env
+	scoped.Context = ctx
+
+	return &scoped
+}
+
+// startDatastoreOperation : Start span of a datastore operation, child of ctx span, bounding the operation by timeout.
+// Returned context is the one of the operation, returned function ends it and records operation duration
+func startDatastoreOperation(ctx context.Context, datastore string, operation string, timeout time.Duration) (context.Context, func()) {
+
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	start := time.Now()
+
+	ctx, span := Tracer.Start(ctx, datastore+"."+operation,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(semconv.DBSystemKey.String(datastore), semconv.DBOperationKey.String(operation)),
+	)
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+
+	return ctx, func() {
+		cancel()
+		span.End()
+		observeDatastore(datastore, operation, start)
+	}
+}
@@ -0,0 +1,61 @@
+package models
+
+const (
+	// UsageDateLayout : Usage records date format (Days, UTC)
+	UsageDateLayout = "2006-01-02"
+
+	// UsageACLs : VerneMQ ACLs created (Profiles and devices)
+	UsageACLs = "acls"
+
+	// UsageConversationsCreated : Group conversations created
+	UsageConversationsCreated = "conversationsCreated"
+
+	// UsageMessagesStored : Messages stored by the broker for offline clients
+	UsageMessagesStored = "messagesStored"
+
+	// UsagePushesSent : Push notifications sent
+	UsagePushesSent = "pushesSent"
+
+	// UsageGroupByTenant : Usage report entries aggregated per tenant
+	UsageGroupByTenant = "tenant"
+
+	// UsageGroupByUser : Usage report entries aggregated per user
+	UsageGroupByUser = "user"
+)
+
+// UsageRecord : Usage counters of a user on a day, metrics fields are named after Usage* constants
+type UsageRecord struct {
+	Date                 string `json:"date,omitempty" bson:"date"`
+	TenantID             string `json:"tenantID" bson:"tenantID"`
+	UserID               string `json:"userID,omitempty" bson:"userID"`
+	ACLs                 int64  `json:"acls" bson:"acls"`
+	ConversationsCreated int64  `json:"conversationsCreated" bson:"conversationsCreated"`
+	MessagesStored       int64  `json:"messagesStored" bson:"messagesStored"`
+	PushesSent           int64  `json:"pushesSent" bson:"pushesSent"`
+}
+
+// UsageFilter : Usage records selection, From and To are inclusive dates (UsageDateLayout)
+// Records of all tenants are selected when TenantID is nil, of all users when UserID is empty
+type UsageFilter struct {
+	From     string
+	To       string
+	TenantID *string
+	UserID   string
+}
+
+// UsageReport : Usage aggregated over a date range, per tenant or per user
+type UsageReport struct {
+	From    string         `json:"from"`
+	To      string         `json:"to"`
+	GroupBy string         `json:"groupBy"`
+	Totals  *UsageRecord   `json:"totals"`
+	Entries []*UsageRecord `json:"entries"`
+}
+
+// Add : Add counters of other record
+func (record *UsageRecord) Add(other *UsageRecord) {
+	record.ACLs += other.ACLs
+	record.ConversationsCreated += other.ConversationsCreated
+	record.MessagesStored += other.MessagesStored
+	record.PushesSent += other.PushesSent
+}
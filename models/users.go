@@ -0,0 +1,62 @@
+package models
+
+import (
+	time "time"
+	utils "wave-messaging-management-service/utils"
+)
+
+const (
+	// DefaultUsersPageLimit : Number of users listed per page when no limit is given
+	DefaultUsersPageLimit = 50
+
+	// MaxUsersPageLimit : Maximum number of users listed per page
+	MaxUsersPageLimit = 500
+)
+
+// User : Application user as seen by operators, joining its mapping, VerneMQ ACLs (Without passhash) and presence.
+// Online is left out when the broker can't be reached
+type User struct {
+	OriginalUserID     string        `json:"originalUserID"`
+	InternalWaveUserID string        `json:"internalWaveUserID"`
+	TenantID           string        `json:"tenantID,omitempty"`
+	Suspended          bool          `json:"suspended"`
+	SuspendedAt        *time.Time    `json:"suspendedAt,omitempty"`
+	Online             *bool         `json:"online,omitempty"`
+	OnlineClientIDs    []string      `json:"onlineClientIDs"`
+	ACLs               []*VerneMQACL `json:"acls"`
+}
+
+// UsersPage : Page of users matching an admin search, Total counts all matching users
+type UsersPage struct {
+	utils.Page
+	Users []*User `json:"users"`
+}
+
+// ConversationMembership : Conversation access of a user, derived from its ACL patterns.
+// Private conversations are identified by the client whose private topics are granted.
+// Member tells whether group conversation lists the user among its members (Always true for private conversations)
+type ConversationMembership struct {
+	ConversationType string   `json:"conversationType"`
+	ConversationID   string   `json:"conversationID"`
+	Name             string   `json:"name,omitempty"`
+	Publish          bool     `json:"publish"`
+	Subscribe        bool     `json:"subscribe"`
+	Member           bool     `json:"member"`
+	ClientIDs        []string `json:"clientIDs"`
+}
+
+// UserACL : Effective VerneMQ ACLs (Without passhash) of a user and conversations derived from them.
+// DeletedACLs are the tombstones of its removed ACLs (Without passhash), which can be restored until purged
+type UserACL struct {
+	InternalWaveUserID string                    `json:"internalWaveUserID"`
+	TenantID           string                    `json:"tenantID,omitempty"`
+	ACLs               []*VerneMQACL             `json:"acls"`
+	DeletedACLs        []*DeletedACL             `json:"deletedACLs"`
+	Conversations      []*ConversationMembership `json:"conversations"`
+}
+
+// UserSessions : Sessions of a user disconnected by an operator, identified by their client IDs
+type UserSessions struct {
+	InternalWaveUserID string   `json:"internalWaveUserID"`
+	ClientIDs          []string `json:"clientIDs"`
+}
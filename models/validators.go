@@ -0,0 +1,77 @@
+package models
+
+import (
+	fmt "fmt"
+	regexp "regexp"
+)
+
+const (
+	// TokenValidatorTypeRegex : Type of validators checking tokens against Pattern
+	TokenValidatorTypeRegex = "regex"
+)
+
+// compileTokenValidators : Compile patterns of the token validator chain of config, a regex validator of TokenValidationRegex when none is configured
+func (config *Config) compileTokenValidators() error {
+
+	chain := config.TokenValidators
+
+	if len(chain) == 0 {
+		chain = []*TokenValidatorConfig{{Type: TokenValidatorTypeRegex, Pattern: config.TokenValidationRegex}}
+	}
+
+	for _, validator := range chain {
+
+		err := validator.compile()
+
+		if err != nil {
+			return err
+		}
+	}
+
+	config.tokenValidatorChain = chain
+
+	return nil
+}
+
+// TokenValidatorChain : Return token validator chain of config, a regex validator of TokenValidationRegex when none is configured.
+// Configs which were not read from the config file (e.g. built by tests) get a chain whose patterns are compiled on each call
+func (config *Config) TokenValidatorChain() []*TokenValidatorConfig {
+
+	if config.tokenValidatorChain != nil {
+		return config.tokenValidatorChain
+	}
+
+	if len(config.TokenValidators) > 0 {
+		return config.TokenValidators
+	}
+
+	return []*TokenValidatorConfig{{Type: TokenValidatorTypeRegex, Pattern: config.TokenValidationRegex}}
+}
+
+// compile : Compile Pattern of regex validators
+func (config *TokenValidatorConfig) compile() error {
+
+	if config.Type != TokenValidatorTypeRegex {
+		return nil
+	}
+
+	pattern, err := regexp.Compile(config.Pattern)
+
+	if err != nil {
+		return fmt.Errorf("Invalid token validator pattern %s : %v", config.Pattern, err)
+	}
+
+	config.pattern = pattern
+
+	return nil
+}
+
+// Regexp : Return compiled Pattern of regex validator, compiled on each call for configs which were not read from the config file
+func (config *TokenValidatorConfig) Regexp() (*regexp.Regexp, error) {
+
+	if config.pattern != nil {
+		return config.pattern, nil
+	}
+
+	return regexp.Compile(config.Pattern)
+}
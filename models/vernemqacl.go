@@ -1,25 +1,39 @@
 package models
 
+import (
+	time "time"
+)
+
 const (
+	// PrivateConversationTopicPath : Private conversations topic path, used when no template is configured
 	PrivateConversationTopicPath = "conversations/private/"
-	GroupConversationTopicPath   = "conversations/group/"
+
+	// GroupConversationTopicPath : Group conversations topic path, used when no template is configured
+	GroupConversationTopicPath = "conversations/group/"
+
+	// SystemTopicPath : System messages topic path, used when no template is configured
+	SystemTopicPath = "system/"
 )
 
 // VerneMQACL : VerneMQ ACL
 type VerneMQACL struct {
-	Mountpoint   string `json:"mountpoint" bson:"mountpoint"`
-	ClientID     string `json:"clientID" bson:"client_id"`
-	Username     string `json:"username" bson:"username"`
-	Passhash     string `json:"passhash" bson:"passhash"`
-	PublishACL   []*ACL `json:"publish_acl" bson:"publish_acl"`
-	SubscribeACL []*ACL `json:"subscribe_acl" bson:"subscribe_acl"`
+	Mountpoint   string     `json:"mountpoint" bson:"mountpoint"`
+	ClientID     string     `json:"clientID" bson:"client_id"`
+	Username     string     `json:"username" bson:"username"`
+	Passhash     string     `json:"passhash" bson:"passhash"`
+	PublishACL   []*ACL     `json:"publish_acl" bson:"publish_acl"`
+	SubscribeACL []*ACL     `json:"subscribe_acl" bson:"subscribe_acl"`
+	DeviceName   string     `json:"deviceName,omitempty" bson:"device_name,omitempty"`
+	ExpiresAt    *time.Time `json:"expiresAt,omitempty" bson:"expires_at,omitempty"`
 }
 
 // MQTTAuthInfos : MQTT auth informations
+// TenantID is only used internally to scope the execution environment
 type MQTTAuthInfos struct {
 	ClientID string `json:"clientID"`
 	Username string `json:"username"`
 	Password string `json:"password"`
+	TenantID string `json:"-"`
 }
 
 // ACL : ACL entry
@@ -28,12 +42,15 @@ type ACL struct {
 }
 
 // NewVerneMQACL : Return new VerneMQACL struct pointer
-func NewVerneMQACL(clientID string, username string, password string) *VerneMQACL {
+// Private conversation ACLs are granted on the private topic path of the user tenant, system messages can be received on its broadcast and user topics
+func NewVerneMQACL(topicPaths *TopicPaths, clientID string, username string, password string) *VerneMQACL {
 
-	pubPrivateACL := ACL{Pattern: PrivateConversationTopicPath + clientID + "/+"}
-	subPrivateACL := ACL{Pattern: PrivateConversationTopicPath + "+/" + clientID}
+	pubPrivateACL := ACL{Pattern: topicPaths.Private + clientID + "/+"}
+	subPrivateACL := ACL{Pattern: topicPaths.Private + "+/" + clientID}
+	subBroadcastACL := ACL{Pattern: topicPaths.BroadcastTopic()}
+	subSystemACL := ACL{Pattern: topicPaths.UserSystemTopic(username)}
 
-	subACLs := []*ACL{&subPrivateACL}
+	subACLs := []*ACL{&subPrivateACL, &subBroadcastACL, &subSystemACL}
 	pubACLs := []*ACL{&pubPrivateACL}
 
 	return &VerneMQACL{
@@ -46,6 +63,62 @@ func NewVerneMQACL(clientID string, username string, password string) *VerneMQAC
 	}
 }
 
+// NewDeviceVerneMQACL : Return new VerneMQACL struct pointer for an additional device of profile owner
+// Device shares profile username, passhash, conversation ACLs and expiry date but gets its own MQTT client ID
+func NewDeviceVerneMQACL(profile *VerneMQACL, deviceClientID string, deviceName string) *VerneMQACL {
+
+	pubACLs := make([]*ACL, len(profile.PublishACL))
+	copy(pubACLs, profile.PublishACL)
+
+	subACLs := make([]*ACL, len(profile.SubscribeACL))
+	copy(subACLs, profile.SubscribeACL)
+
+	return &VerneMQACL{
+		Mountpoint:   profile.Mountpoint,
+		ClientID:     deviceClientID,
+		Username:     profile.Username,
+		Passhash:     profile.Passhash,
+		SubscribeACL: subACLs,
+		PublishACL:   pubACLs,
+		DeviceName:   deviceName,
+		ExpiresAt:    profile.ExpiresAt,
+	}
+}
+
+// NewGuestVerneMQACL : Return new VerneMQACL struct pointer for an unauthenticated client
+// Guests can only subscribe to the provided topics, until expiresAt
+func NewGuestVerneMQACL(clientID string, passhash string, topics []string, expiresAt time.Time) *VerneMQACL {
+
+	subACLs := []*ACL{}
+
+	for _, topic := range topics {
+		subACLs = append(subACLs, &ACL{Pattern: topic})
+	}
+
+	return &VerneMQACL{
+		Mountpoint:   "",
+		ClientID:     clientID,
+		Username:     clientID,
+		Passhash:     passhash,
+		SubscribeACL: subACLs,
+		PublishACL:   []*ACL{},
+		ExpiresAt:    &expiresAt,
+	}
+}
+
+// NewSystemPublisherVerneMQACL : Return new VerneMQACL struct pointer for the internal system messages publisher
+// Publisher may publish in the system topics of every tenant, whose namespaces can't be enumerated : It is granted all topics but can't subscribe
+func NewSystemPublisherVerneMQACL(clientID string, passhash string) *VerneMQACL {
+	return &VerneMQACL{
+		Mountpoint:   "",
+		ClientID:     clientID,
+		Username:     clientID,
+		Passhash:     passhash,
+		SubscribeACL: []*ACL{},
+		PublishACL:   []*ACL{{Pattern: "#"}},
+	}
+}
+
 // NewMQTTAuthInfos : Return new NewMQTTAuthInfos struct pointer
 func NewMQTTAuthInfos(clientID string, token string) *MQTTAuthInfos {
 
@@ -55,3 +128,19 @@ func NewMQTTAuthInfos(clientID string, token string) *MQTTAuthInfos {
 		Password: token,
 	}
 }
+
+// NewDeviceMQTTAuthInfos : Return new MQTTAuthInfos struct pointer for an additional device of user
+func NewDeviceMQTTAuthInfos(deviceClientID string, username string, token string) *MQTTAuthInfos {
+
+	return &MQTTAuthInfos{
+		ClientID: deviceClientID,
+		Username: username,
+		Password: token,
+	}
+}
+
+// GuestMQTTAuthInfos : MQTT auth informations of a guest, valid until ExpiresAt
+type GuestMQTTAuthInfos struct {
+	MQTTAuthInfos
+	ExpiresAt time.Time `json:"expiresAt"`
+}
@@ -0,0 +1,134 @@
+package models
+
+import (
+	time "time"
+	utils "wave-messaging-management-service/utils"
+)
+
+const (
+	// WebhookDeliveryPending : Delivery not acknowledged yet, attempted again at NextAttemptAt
+	WebhookDeliveryPending = "pending"
+
+	// WebhookDeliveryDelivered : Delivery acknowledged by the webhook URL with a 2xx status
+	WebhookDeliveryDelivered = "delivered"
+
+	// WebhookDeliveryDeadLettered : Delivery abandoned after its last attempt, moved to the dead letters of its webhook
+	WebhookDeliveryDeadLettered = "deadLettered"
+
+	// DefaultWebhookTimeout : Seconds a webhook URL has to answer a delivery attempt, used when none is configured
+	DefaultWebhookTimeout = 10
+
+	// DefaultWebhookMaxAttempts : Delivery attempts of an event before it is dead-lettered, used when none is configured
+	DefaultWebhookMaxAttempts = 5
+
+	// DefaultWebhookRetryDelay : Seconds before the second attempt of a delivery, doubled after each attempt, used when none is configured
+	DefaultWebhookRetryDelay = 2
+
+	// DefaultWebhookMaxRetryDelay : Maximum seconds between two attempts of a delivery, used when none is configured
+	DefaultWebhookMaxRetryDelay = 3600
+
+	// DefaultWebhookPollInterval : Seconds between two lookups of deliveries due for an attempt, used when none is configured
+	DefaultWebhookPollInterval = 5
+
+	// DefaultWebhookConcurrency : Deliveries attempted concurrently by each instance, used when none is configured
+	DefaultWebhookConcurrency = 10
+
+	// DefaultWebhookDeliveriesPageLimit : Number of webhook deliveries (Or dead letters) listed per page when no limit is given
+	DefaultWebhookDeliveriesPageLimit = 50
+
+	// MaxWebhookDeliveriesPageLimit : Maximum number of webhook deliveries (Or dead letters) listed per page
+	MaxWebhookDeliveriesPageLimit = 500
+)
+
+// WebhooksConfig : Outbound webhooks Config. Delivery attempts are retried after RetryDelay seconds, doubled after each attempt up to MaxRetryDelay.
+// Deliveries due are looked up every PollInterval seconds, CircuitBreaker postpones deliveries to webhook URLs failing consecutively
+type WebhooksConfig struct {
+	Timeout        int                  `json:"timeout"`
+	MaxAttempts    int                  `json:"maxAttempts"`
+	RetryDelay     int                  `json:"retryDelay"`
+	MaxRetryDelay  int                  `json:"maxRetryDelay"`
+	PollInterval   int                  `json:"pollInterval"`
+	Concurrency    int                  `json:"concurrency"`
+	CircuitBreaker CircuitBreakerConfig `json:"circuitBreaker"`
+}
+
+// Webhook : URL of an integrator receiving signed POSTs of lifecycle events of Events types (See LifecycleEvent) in its tenant.
+// Secret signs deliveries, it is only returned when the webhook is registered
+type Webhook struct {
+	ID        string    `json:"id" bson:"id"`
+	URL       string    `json:"url" bson:"url"`
+	Events    []string  `json:"events" bson:"events"`
+	Secret    string    `json:"secret,omitempty" bson:"secret"`
+	CreatedAt time.Time `json:"createdAt" bson:"createdAt"`
+}
+
+// Webhooks : Webhooks registered in a tenant, without their secret
+type Webhooks struct {
+	Webhooks []*Webhook `json:"webhooks"`
+}
+
+// WebhookDelivery : Delivery of a lifecycle event (Payload) to a webhook, tracking its attempts. ResponseStatus and Error describe the last attempt.
+// Deliveries of all tenants share a collection, so that any instance attempts them. Instances lease deliveries until LeaseUntil while attempting them
+type WebhookDelivery struct {
+	ID             string     `json:"id" bson:"id"`
+	WebhookID      string     `json:"webhookID" bson:"webhookID"`
+	TenantID       string     `json:"tenantID,omitempty" bson:"tenantID,omitempty"`
+	EventID        string     `json:"eventID" bson:"eventID"`
+	EventType      string     `json:"eventType" bson:"eventType"`
+	Status         string     `json:"status" bson:"status"`
+	Attempts       int        `json:"attempts" bson:"attempts"`
+	ResponseStatus int        `json:"responseStatus,omitempty" bson:"responseStatus,omitempty"`
+	Error          string     `json:"error,omitempty" bson:"error,omitempty"`
+	Payload        []byte     `json:"-" bson:"payload"`
+	CreatedAt      time.Time  `json:"createdAt" bson:"createdAt"`
+	NextAttemptAt  *time.Time `json:"nextAttemptAt,omitempty" bson:"nextAttemptAt,omitempty"`
+	DeliveredAt    *time.Time `json:"deliveredAt,omitempty" bson:"deliveredAt,omitempty"`
+	LeaseUntil     time.Time  `json:"-" bson:"leaseUntil"`
+}
+
+// WebhookDeliveriesPage : Page of deliveries of a webhook, most recent first
+type WebhookDeliveriesPage struct {
+	utils.Page
+	Deliveries []*WebhookDelivery `json:"deliveries"`
+}
+
+// WebhookDeadLetter : Delivery abandoned after its last attempt, kept with its event until replayed or discarded by an operator
+type WebhookDeadLetter struct {
+	ID             string          `json:"id" bson:"id"`
+	WebhookID      string          `json:"webhookID" bson:"webhookID"`
+	TenantID       string          `json:"tenantID,omitempty" bson:"tenantID,omitempty"`
+	DeliveryID     string          `json:"deliveryID" bson:"deliveryID"`
+	URL            string          `json:"url" bson:"url"`
+	EventType      string          `json:"eventType" bson:"eventType"`
+	Event          *LifecycleEvent `json:"event" bson:"-"`
+	Attempts       int             `json:"attempts" bson:"attempts"`
+	ResponseStatus int             `json:"responseStatus,omitempty" bson:"responseStatus,omitempty"`
+	Error          string          `json:"error,omitempty" bson:"error,omitempty"`
+	Payload        []byte          `json:"-" bson:"payload"`
+	DeadLetteredAt time.Time       `json:"deadLetteredAt" bson:"deadLetteredAt"`
+}
+
+// WebhookDeadLetterFilter : Criteria of a dead letters query, WebhookID matching all webhooks of the tenant when empty
+type WebhookDeadLetterFilter struct {
+	TenantID  string
+	WebhookID string
+	Page      *utils.Pagination
+}
+
+// WebhookDeadLettersPage : Page of dead letters, most recent first
+type WebhookDeadLettersPage struct {
+	utils.Page
+	DeadLetters []*WebhookDeadLetter `json:"deadLetters"`
+}
+
+// Subscribes : Check webhook receives events of eventType
+func (webhook *Webhook) Subscribes(eventType string) bool {
+
+	for _, subscribed := range webhook.Events {
+		if subscribed == eventType {
+			return true
+		}
+	}
+
+	return false
+}
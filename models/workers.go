@@ -0,0 +1,90 @@
+package models
+
+import (
+	context "context"
+	sync "sync"
+	time "time"
+)
+
+// Workers : Background workers of the service, stopped together on shutdown
+type Workers struct {
+	stop    chan struct{}
+	stopped bool
+	mutex   sync.Mutex
+	running sync.WaitGroup
+}
+
+// NewWorkers : Return new background workers group
+func NewWorkers() *Workers {
+	return &Workers{
+		stop: make(chan struct{}),
+	}
+}
+
+// Go : Run task in background, waited for on shutdown. Tasks started once workers are stopped are dropped
+func (workers *Workers) Go(task func()) {
+
+	workers.mutex.Lock()
+
+	if workers.stopped {
+		workers.mutex.Unlock()
+		return
+	}
+
+	workers.running.Add(1)
+	workers.mutex.Unlock()
+
+	go func() {
+		defer workers.running.Done()
+		task()
+	}()
+}
+
+// Every : Run task in background after each interval (Read again before each run), until workers are stopped.
+// Running task is completed before stopping
+func (workers *Workers) Every(interval func() time.Duration, task func()) {
+	workers.Go(func() {
+
+		for {
+
+			select {
+			case <-workers.stop:
+				return
+			case <-time.After(interval()):
+				task()
+			}
+		}
+	})
+}
+
+// Stopping : Return channel closed once workers are being stopped, so that long tasks give up waiting
+func (workers *Workers) Stopping() <-chan struct{} {
+	return workers.stop
+}
+
+// Stop : Stop periodic tasks and wait for running ones, until ctx is done
+func (workers *Workers) Stop(ctx context.Context) error {
+
+	workers.mutex.Lock()
+
+	if !workers.stopped {
+		workers.stopped = true
+		close(workers.stop)
+	}
+
+	workers.mutex.Unlock()
+
+	done := make(chan struct{})
+
+	go func() {
+		workers.running.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,271 @@
+package notifications
+
+import (
+	bytes "bytes"
+	ecdsa "crypto/ecdsa"
+	rand "crypto/rand"
+	sha256 "crypto/sha256"
+	x509 "crypto/x509"
+	base64 "encoding/base64"
+	json "encoding/json"
+	pem "encoding/pem"
+	errors "errors"
+	fmt "fmt"
+	ioutil "io/ioutil"
+	big "math/big"
+	http "net/http"
+	sync "sync"
+	time "time"
+	models "wave-messaging-management-service/models"
+)
+
+const (
+	// APNsDefaultEndpoint : Apple Push Notification service production endpoint
+	APNsDefaultEndpoint = "https://api.push.apple.com"
+
+	// APNsTokenLifetime : Provider authentication tokens must be refreshed between 20 and 60 minutes
+	APNsTokenLifetime = 45 * time.Minute
+
+	// apnsCollapseIDMaxLength : apns-collapse-id header can't exceed 64 bytes
+	apnsCollapseIDMaxLength = 64
+)
+
+// APNsProvider : Apple Push Notification service provider (iOS & macOS devices).
+// Uses token-based authentication over HTTP/2
+type APNsProvider struct {
+	Client *http.Client
+
+	mutex    sync.Mutex
+	keyFile  string
+	key      *ecdsa.PrivateKey
+	token    string
+	issuedAt time.Time
+	tokenKey string
+}
+
+// apnsPayload : APNs request body
+type apnsPayload struct {
+	APS              apnsAPS `json:"aps"`
+	ConversationType string  `json:"conversationType"`
+	ConversationID   string  `json:"conversationID"`
+	SenderID         string  `json:"senderID"`
+}
+
+type apnsAPS struct {
+	Alert            *apnsAlert `json:"alert,omitempty"`
+	Sound            string     `json:"sound,omitempty"`
+	ThreadID         string     `json:"thread-id,omitempty"`
+	ContentAvailable int        `json:"content-available,omitempty"`
+}
+
+type apnsAlert struct {
+	Title string `json:"title"`
+	Body  string `json:"body"`
+}
+
+// apnsErrorResponse : APNs error response body
+type apnsErrorResponse struct {
+	Reason string `json:"reason"`
+}
+
+// NewAPNsProvider : Return a new APNsProvider.
+// Default transport negotiates HTTP/2 with APNs over TLS
+func NewAPNsProvider() *APNsProvider {
+	return &APNsProvider{
+		Client: &http.Client{},
+	}
+}
+
+// Send : Send notification to APNs device token
+func (provider *APNsProvider) Send(config *models.NotificationsConfig, pushToken *models.PushToken, notification *Notification) error {
+
+	endpoint := config.APNs.Endpoint
+
+	if endpoint == "" {
+		endpoint = APNsDefaultEndpoint
+	}
+
+	authToken, err := provider.authenticationToken(&config.APNs)
+
+	if err != nil {
+		return err
+	}
+
+	payload := apnsPayload{
+		ConversationType: notification.ConversationType,
+		ConversationID:   notification.ConversationID,
+		SenderID:         notification.SenderID,
+	}
+
+	pushType, priority := "alert", "10"
+
+	if config.APNs.SilentPush {
+
+		// Silent push : wake the app up for background sync without alerting the user
+		payload.APS.ContentAvailable = 1
+		pushType, priority = "background", "5"
+
+	} else {
+
+		payload.APS.Alert = &apnsAlert{
+			Title: notification.Title,
+			Body:  notification.Body,
+		}
+		payload.APS.Sound = "default"
+		payload.APS.ThreadID = notification.ConversationID
+	}
+
+	body, err := json.Marshal(payload)
+
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest("POST", endpoint+"/3/device/"+pushToken.Token, bytes.NewReader(body))
+
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("authorization", "bearer "+authToken)
+	req.Header.Set("apns-topic", config.APNs.BundleID)
+	req.Header.Set("apns-push-type", pushType)
+	req.Header.Set("apns-priority", priority)
+
+	// Only the latest notification of a conversation is displayed
+	req.Header.Set("apns-collapse-id", collapseID(notification))
+
+	res, err := provider.Client.Do(req)
+
+	if err != nil {
+		return err
+	}
+
+	defer res.Body.Close()
+
+	if res.StatusCode == http.StatusOK {
+		return nil
+	}
+
+	apnsErr := apnsErrorResponse{}
+	json.NewDecoder(res.Body).Decode(&apnsErr)
+
+	if res.StatusCode == http.StatusGone || apnsErr.Reason == "BadDeviceToken" || apnsErr.Reason == "Unregistered" {
+		return ErrInvalidPushToken
+	}
+
+	return fmt.Errorf("error sending APNs notification : status %d %s", res.StatusCode, apnsErr.Reason)
+}
+
+// collapseID : Build apns-collapse-id of notification conversation
+func collapseID(notification *Notification) string {
+
+	id := notification.ConversationType + ":" + notification.ConversationID
+
+	if len(id) > apnsCollapseIDMaxLength {
+		id = id[:apnsCollapseIDMaxLength]
+	}
+
+	return id
+}
+
+// authenticationToken : Return cached provider authentication token (ES256 JWT), signing a new one when expired
+func (provider *APNsProvider) authenticationToken(config *models.APNsConfig) (string, error) {
+
+	provider.mutex.Lock()
+	defer provider.mutex.Unlock()
+
+	// Signing key is loaded once per key file
+	if provider.key == nil || provider.keyFile != config.KeyFile {
+
+		key, err := loadAPNsKey(config.KeyFile)
+
+		if err != nil {
+			return "", err
+		}
+
+		provider.key = key
+		provider.keyFile = config.KeyFile
+		provider.token = ""
+	}
+
+	tokenKey := config.KeyID + ":" + config.TeamID
+
+	if provider.token != "" && provider.tokenKey == tokenKey && time.Since(provider.issuedAt) < APNsTokenLifetime {
+		return provider.token, nil
+	}
+
+	issuedAt := time.Now()
+
+	header, err := json.Marshal(map[string]string{"alg": "ES256", "kid": config.KeyID})
+
+	if err != nil {
+		return "", err
+	}
+
+	claims, err := json.Marshal(map[string]interface{}{"iss": config.TeamID, "iat": issuedAt.Unix()})
+
+	if err != nil {
+		return "", err
+	}
+
+	signingInput := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(claims)
+
+	digest := sha256.Sum256([]byte(signingInput))
+
+	r, s, err := ecdsa.Sign(rand.Reader, provider.key, digest[:])
+
+	if err != nil {
+		return "", err
+	}
+
+	provider.token = signingInput + "." + base64.RawURLEncoding.EncodeToString(joinSignature(r, s))
+	provider.tokenKey = tokenKey
+	provider.issuedAt = issuedAt
+
+	return provider.token, nil
+}
+
+// joinSignature : JWS ES256 signature is the 64 bytes concatenation of R and S
+func joinSignature(r *big.Int, s *big.Int) []byte {
+
+	signature := make([]byte, 64)
+
+	rBytes, sBytes := r.Bytes(), s.Bytes()
+
+	copy(signature[32-len(rBytes):32], rBytes)
+	copy(signature[64-len(sBytes):], sBytes)
+
+	return signature
+}
+
+// loadAPNsKey : Load PKCS#8 .p8 signing key provided by Apple
+func loadAPNsKey(keyFile string) (*ecdsa.PrivateKey, error) {
+
+	data, err := ioutil.ReadFile(keyFile)
+
+	if err != nil {
+		return nil, err
+	}
+
+	block, _ := pem.Decode(data)
+
+	if block == nil {
+		return nil, errors.New("Invalid APNs key file")
+	}
+
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+
+	if err != nil {
+		return nil, err
+	}
+
+	ecdsaKey, ok := key.(*ecdsa.PrivateKey)
+
+	if !ok {
+		return nil, errors.New("APNs key must be an ECDSA key")
+	}
+
+	return ecdsaKey, nil
+}
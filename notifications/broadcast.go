@@ -0,0 +1,66 @@
+package notifications
+
+import (
+	json "encoding/json"
+	models "wave-messaging-management-service/models"
+
+	logrus "github.com/sirupsen/logrus"
+)
+
+// Broadcast : Publish system message through the internal publisher, on the broadcast topic of the environment tenant
+// or, when userIDs (Internal Wave user IDs) are given, on the system topic of each of them
+func Broadcast(env *models.Env, message *models.SystemMessage, userIDs []string) (*models.BroadcastReport, error) {
+
+	topicPaths, err := env.TopicPaths()
+
+	if err != nil {
+		return nil, err
+	}
+
+	payload, err := json.Marshal(message)
+
+	if err != nil {
+		return nil, err
+	}
+
+	report := &models.BroadcastReport{
+		MessageID:  message.MessageID,
+		Topics:     []string{},
+		Recipients: userIDs,
+		Failed:     []string{},
+	}
+
+	if len(userIDs) == 0 {
+
+		topic := topicPaths.BroadcastTopic()
+
+		err = env.Publisher.Publish(topic, payload)
+
+		if err != nil {
+			return nil, err
+		}
+
+		report.Topics = append(report.Topics, topic)
+		report.Recipients = []string{}
+
+		return report, nil
+	}
+
+	// One failing recipient doesn't prevent others from receiving the message
+	for _, userID := range userIDs {
+
+		topic := topicPaths.UserSystemTopic(userID)
+
+		err = env.Publisher.Publish(topic, payload)
+
+		if err != nil {
+			env.Logger.WithError(err).WithFields(logrus.Fields{"messageID": message.MessageID, models.LogFieldUserID: userID}).Error("Failed to publish system message")
+			report.Failed = append(report.Failed, userID)
+			continue
+		}
+
+		report.Topics = append(report.Topics, topic)
+	}
+
+	return report, nil
+}
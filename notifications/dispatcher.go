@@ -0,0 +1,213 @@
+package notifications
+
+import (
+	fmt "fmt"
+	strconv "strconv"
+	time "time"
+	auth "wave-messaging-management-service/auth"
+	models "wave-messaging-management-service/models"
+
+	uuid "github.com/satori/go.uuid"
+	logrus "github.com/sirupsen/logrus"
+)
+
+// Dispatcher : Send push notifications to users devices through registered providers
+type Dispatcher struct {
+	Env       *models.Env
+	Providers map[string]Provider
+}
+
+// NewDispatcher : Return a new Dispatcher with all supported providers
+func NewDispatcher(env *models.Env) *Dispatcher {
+	return &Dispatcher{
+		Env: env,
+		Providers: map[string]Provider{
+			models.PushPlatformFCM:     NewFCMProvider(),
+			models.PushPlatformAPNs:    NewAPNsProvider(),
+			models.PushPlatformWebPush: NewWebPushProvider(),
+		},
+	}
+}
+
+// NotifyOfflineMessage : Notify recipient devices of a message queued while its MQTT client was offline
+func (dispatcher *Dispatcher) NotifyOfflineMessage(offlineMessage *models.OfflineMessage) error {
+
+	// Offline client may be a device of the recipient, resolve recipient user ID
+	recipientACL, err := dispatcher.Env.Store.GetClientACL(dispatcher.Env.TraceContext(), offlineMessage.ClientID)
+
+	if err != nil {
+		return err
+	}
+
+	// Recipient data and topic paths depend on its tenant
+	env := dispatcher.Env.ForTenant(auth.GetUserTenant(dispatcher.Env, recipientACL.Username))
+
+	// Broker stored the message until the client reconnects
+	auth.RecordUsage(env, recipientACL.Username, models.UsageMessagesStored)
+
+	topicPaths, err := env.TopicPaths()
+
+	if err != nil {
+		return err
+	}
+
+	// Only conversation messages are notified
+	conversationTopic, err := topicPaths.ParseConversationTopic(offlineMessage.Topic)
+
+	if err != nil {
+		return err
+	}
+
+	// Don't notify users of their own messages
+	if recipientACL.Username == conversationTopic.SenderID {
+		return nil
+	}
+
+	digest := env.Config.Notifications.Digest
+
+	if !digest.Enabled {
+		return dispatcher.send(env, recipientACL.Username, offlineMessage.ClientID, conversationTopic, 1)
+	}
+
+	return dispatcher.coalesce(env, recipientACL.Username, offlineMessage.ClientID, conversationTopic, digest.Window)
+}
+
+// coalesce : Count offline message in current digest window of client conversation.
+// First message of a window schedules a single summarized push at the end of the window
+func (dispatcher *Dispatcher) coalesce(env *models.Env, userID string, clientID string, conversationTopic *models.ConversationTopic, window int) error {
+
+	if window <= 0 {
+		window = models.DefaultDigestWindow
+	}
+
+	// Hash tag keeps the flushing key in the slot of the counter on a Redis Cluster, as renames can't move keys across slots
+	key := fmt.Sprintf("digest:{%s:%s:%s}", clientID, conversationTopic.ConversationType, conversationTopic.ConversationID)
+
+	count, err := env.Redis.Incr(env.TraceContext(), key)
+
+	if err != nil {
+		return err
+	}
+
+	if count > 1 {
+		return nil
+	}
+
+	// Counter outlives the window so that a lost flush (e.g. restart) doesn't mute the conversation forever
+	err = env.Redis.Expire(env.TraceContext(), key, 2*window)
+
+	if err != nil {
+		return err
+	}
+
+	time.AfterFunc(time.Duration(window)*time.Second, func() {
+
+		err := dispatcher.flush(env, key, userID, clientID, conversationTopic)
+
+		if err != nil {
+			env.Logger.WithError(err).WithField(models.LogFieldUserID, userID).Error("Failed to flush push notifications digest")
+		}
+	})
+
+	return nil
+}
+
+// flush : Send summarized push of a digest window.
+// Counter is renamed first so that messages received meanwhile open a new window
+func (dispatcher *Dispatcher) flush(env *models.Env, key string, userID string, clientID string, conversationTopic *models.ConversationTopic) error {
+
+	flushingKey := key + ":flushing:" + uuid.NewV4().String()
+
+	err := env.Redis.Rename(env.TraceContext(), key, flushingKey)
+
+	if err != nil {
+		return err
+	}
+
+	data, err := env.Redis.Get(env.TraceContext(), flushingKey)
+
+	if err != nil {
+		return err
+	}
+
+	err = env.Redis.Delete(env.TraceContext(), flushingKey)
+
+	if err != nil {
+		return err
+	}
+
+	count, err := strconv.Atoi(string(data))
+
+	if err != nil {
+		return err
+	}
+
+	return dispatcher.send(env, userID, clientID, conversationTopic, count)
+}
+
+// send : Push notification of count messages in conversation to the devices of userID matching clientID
+func (dispatcher *Dispatcher) send(env *models.Env, userID string, clientID string, conversationTopic *models.ConversationTopic, count int) error {
+
+	// Respect recipient settings before sending anything
+	preferences, err := env.Store.GetNotificationPreferences(env.TraceContext(), userID)
+
+	if err != nil {
+		return err
+	}
+
+	if !preferences.AllowsNotification(conversationTopic.ConversationID, time.Now()) {
+		return nil
+	}
+
+	pushTokens, err := env.Store.GetPushTokens(env.TraceContext(), userID)
+
+	if err != nil {
+		return err
+	}
+
+	config := env.Config.Notifications
+
+	notification, err := NewNotification(&config.Templates, conversationTopic, count)
+
+	if err != nil {
+		return err
+	}
+
+	for _, pushToken := range pushTokens {
+
+		// Broker queues one message per offline client :
+		// Only push to the device matching that client (Tokens without device belong to main client)
+		targetClientID := pushToken.DeviceClientID
+
+		if targetClientID == "" {
+			targetClientID = userID
+		}
+
+		if targetClientID != clientID {
+			continue
+		}
+
+		provider, ok := dispatcher.Providers[pushToken.Platform]
+
+		if !ok {
+			continue
+		}
+
+		err = provider.Send(&config, pushToken, notification)
+
+		if err == nil {
+			auth.RecordUsage(env, userID, models.UsagePushesSent)
+		}
+
+		// Forget push tokens the provider doesn't know anymore
+		if err == ErrInvalidPushToken {
+			err = env.Store.RemovePushToken(env.TraceContext(), pushToken.UserID, pushToken.Token)
+		}
+
+		if err != nil {
+			env.Logger.WithError(err).WithFields(logrus.Fields{models.LogFieldUserID: userID, "platform": pushToken.Platform}).Error("Failed to send push notification")
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,116 @@
+package notifications
+
+import (
+	bytes "bytes"
+	json "encoding/json"
+	fmt "fmt"
+	http "net/http"
+	models "wave-messaging-management-service/models"
+)
+
+const (
+	// FCMDefaultEndpoint : Firebase Cloud Messaging HTTP endpoint
+	FCMDefaultEndpoint = "https://fcm.googleapis.com/fcm/send"
+)
+
+// FCMProvider : Firebase Cloud Messaging provider (Android & Web devices)
+type FCMProvider struct {
+	Client *http.Client
+}
+
+// fcmMessage : FCM HTTP API request body
+type fcmMessage struct {
+	To           string            `json:"to"`
+	Notification fcmNotification   `json:"notification"`
+	Data         map[string]string `json:"data"`
+}
+
+type fcmNotification struct {
+	Title string `json:"title"`
+	Body  string `json:"body"`
+}
+
+// fcmResponse : FCM HTTP API response body
+type fcmResponse struct {
+	Success int `json:"success"`
+	Failure int `json:"failure"`
+	Results []struct {
+		MessageID string `json:"message_id"`
+		Error     string `json:"error"`
+	} `json:"results"`
+}
+
+// NewFCMProvider : Return a new FCMProvider
+func NewFCMProvider() *FCMProvider {
+	return &FCMProvider{
+		Client: &http.Client{},
+	}
+}
+
+// Send : Send notification to FCM push token
+func (provider *FCMProvider) Send(config *models.NotificationsConfig, pushToken *models.PushToken, notification *Notification) error {
+
+	endpoint := config.FCM.Endpoint
+
+	if endpoint == "" {
+		endpoint = FCMDefaultEndpoint
+	}
+
+	body, err := json.Marshal(fcmMessage{
+		To: pushToken.Token,
+		Notification: fcmNotification{
+			Title: notification.Title,
+			Body:  notification.Body,
+		},
+		Data: map[string]string{
+			"conversationType": notification.ConversationType,
+			"conversationID":   notification.ConversationID,
+			"senderID":         notification.SenderID,
+		},
+	})
+
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest("POST", endpoint, bytes.NewReader(body))
+
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "key="+config.FCM.ServerKey)
+
+	res, err := provider.Client.Do(req)
+
+	if err != nil {
+		return err
+	}
+
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("error sending FCM notification : status %d", res.StatusCode)
+	}
+
+	fcmRes := fcmResponse{}
+
+	err = json.NewDecoder(res.Body).Decode(&fcmRes)
+
+	if err != nil {
+		return err
+	}
+
+	if fcmRes.Failure > 0 && len(fcmRes.Results) > 0 {
+
+		switch fcmRes.Results[0].Error {
+		case "NotRegistered", "InvalidRegistration":
+			return ErrInvalidPushToken
+		default:
+			return fmt.Errorf("error sending FCM notification : %s", fcmRes.Results[0].Error)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,104 @@
+package notifications
+
+import (
+	bytes "bytes"
+	errors "errors"
+	template "text/template"
+	models "wave-messaging-management-service/models"
+)
+
+const (
+	// DefaultTitle : Notification title used when no template is configured
+	DefaultTitle = "New message"
+
+	// DefaultBody : Notification body used when no template is configured
+	DefaultBody = "You received a new message"
+
+	// DefaultDigestBody : Summarized notification body used when no digest template is configured
+	DefaultDigestBody = "You received {{.Count}} new messages"
+)
+
+var (
+	// ErrInvalidPushToken : Returned by providers when push token is not registered anymore
+	ErrInvalidPushToken = errors.New("Invalid push token")
+)
+
+// Provider : Push notification provider interface (FCM, APNs, ...)
+type Provider interface {
+	Send(config *models.NotificationsConfig, pushToken *models.PushToken, notification *Notification) error
+}
+
+// Notification : Rendered push notification
+type Notification struct {
+	Title            string
+	Body             string
+	ConversationType string
+	ConversationID   string
+	SenderID         string
+	Count            int
+}
+
+// NewNotification : Return new Notification struct pointer rendered from configured templates.
+// Digest templates are used when notification summarizes more than one message
+func NewNotification(templates *models.NotificationTemplatesConfig, conversationTopic *models.ConversationTopic, count int) (*Notification, error) {
+
+	notification := &Notification{
+		ConversationType: conversationTopic.ConversationType,
+		ConversationID:   conversationTopic.ConversationID,
+		SenderID:         conversationTopic.SenderID,
+		Count:            count,
+	}
+
+	titleTemplate, bodyTemplate, digestBodyTemplate := templates.PrivateTitle, templates.PrivateBody, templates.PrivateDigestBody
+
+	if conversationTopic.ConversationType == models.GroupConversationType {
+		titleTemplate, bodyTemplate, digestBodyTemplate = templates.GroupTitle, templates.GroupBody, templates.GroupDigestBody
+	}
+
+	defaultBody := DefaultBody
+
+	if count > 1 {
+		bodyTemplate, defaultBody = digestBodyTemplate, DefaultDigestBody
+	}
+
+	title, err := render(titleTemplate, DefaultTitle, notification)
+
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := render(bodyTemplate, defaultBody, notification)
+
+	if err != nil {
+		return nil, err
+	}
+
+	notification.Title = title
+	notification.Body = body
+
+	return notification, nil
+}
+
+// render : Execute text template against notification, fallback template is used when template is empty
+func render(text string, fallback string, notification *Notification) (string, error) {
+
+	if text == "" {
+		text = fallback
+	}
+
+	tmpl, err := template.New("notification").Parse(text)
+
+	if err != nil {
+		return "", err
+	}
+
+	buffer := &bytes.Buffer{}
+
+	err = tmpl.Execute(buffer, notification)
+
+	if err != nil {
+		return "", err
+	}
+
+	return buffer.String(), nil
+}
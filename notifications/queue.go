@@ -0,0 +1,314 @@
+package notifications
+
+import (
+	context "context"
+	json "encoding/json"
+	errors "errors"
+	sync "sync"
+	time "time"
+	models "wave-messaging-management-service/models"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+	uuid "github.com/satori/go.uuid"
+	logrus "github.com/sirupsen/logrus"
+)
+
+const (
+	// queuePublishTimeout : Maximum duration of notification jobs publications, until confirmed by RabbitMQ
+	queuePublishTimeout = 10 * time.Second
+
+	// queueReconnectDelay : Delay before push dispatchers reconnect to RabbitMQ once their connection is lost
+	queueReconnectDelay = 5 * time.Second
+)
+
+var (
+	// ErrJobNotConfirmed : RabbitMQ refused to take charge of a notification job
+	ErrJobNotConfirmed = errors.New("Notification job not confirmed by RabbitMQ")
+
+	// ErrUnknownJob : Notification job of an unknown type, or missing the field of its type
+	ErrUnknownJob = errors.New("Unknown notification job")
+
+	// ErrConsumerClosed : RabbitMQ closed the channel of notification jobs deliveries
+	ErrConsumerClosed = errors.New("Notification jobs consumer closed")
+)
+
+// QueueNotifier : Notifier queuing offline messages as jobs on a RabbitMQ queue, so that push dispatchers scale apart from the API.
+// Jobs are persistent and confirmed by RabbitMQ, the connection is opened on first publication and again once lost
+type QueueNotifier struct {
+	config     models.NotificationQueueConfig
+	mutex      sync.Mutex
+	connection *amqp.Connection
+	channel    *amqp.Channel
+}
+
+// NewQueueNotifier : Return notifier queuing jobs according to config, copied as queue settings are read at startup
+func NewQueueNotifier(config *models.NotificationQueueConfig) *QueueNotifier {
+	return &QueueNotifier{config: *config}
+}
+
+// NotifyOfflineMessage : Queue notification job of a message queued while its MQTT client was offline
+func (notifier *QueueNotifier) NotifyOfflineMessage(offlineMessage *models.OfflineMessage) error {
+	return notifier.publish(&models.NotificationJob{Type: models.NotificationJobOfflineMessage, OfflineMessage: offlineMessage})
+}
+
+// Close : Close connection to RabbitMQ, if open
+func (notifier *QueueNotifier) Close() error {
+
+	notifier.mutex.Lock()
+	defer notifier.mutex.Unlock()
+
+	if notifier.connection == nil {
+		return nil
+	}
+
+	err := notifier.connection.Close()
+	notifier.connection, notifier.channel = nil, nil
+
+	return err
+}
+
+// publish : Publish job to the queue, then wait for RabbitMQ to confirm it
+func (notifier *QueueNotifier) publish(job *models.NotificationJob) (err error) {
+
+	defer func() {
+
+		result := "queued"
+
+		if err != nil {
+			result = "unqueued"
+		}
+
+		models.NotificationJobs.WithLabelValues(job.Type, result).Inc()
+	}()
+
+	body, err := json.Marshal(job)
+
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), queuePublishTimeout)
+	defer cancel()
+
+	notifier.mutex.Lock()
+
+	channel, err := notifier.open()
+
+	if err != nil {
+		notifier.mutex.Unlock()
+		return err
+	}
+
+	// Confirmations are waited for outside of the lock, so that concurrent jobs are published meanwhile
+	confirmation, err := channel.PublishWithDeferredConfirmWithContext(ctx, "", queueName(&notifier.config), false, false, amqp.Publishing{
+		ContentType:  "application/json",
+		DeliveryMode: amqp.Persistent,
+		MessageId:    uuid.NewV4().String(),
+		Timestamp:    time.Now().UTC(),
+		Type:         job.Type,
+		Body:         body,
+	})
+
+	notifier.mutex.Unlock()
+
+	if err != nil {
+		return err
+	}
+
+	confirmed, err := confirmation.WaitContext(ctx)
+
+	if err != nil {
+		return err
+	}
+
+	if !confirmed {
+		return ErrJobNotConfirmed
+	}
+
+	return nil
+}
+
+// open : Return channel in confirm mode of the open connection, reconnecting when it was lost. Callers hold the lock
+func (notifier *QueueNotifier) open() (*amqp.Channel, error) {
+
+	if notifier.channel != nil && !notifier.channel.IsClosed() {
+		return notifier.channel, nil
+	}
+
+	if notifier.connection != nil {
+		notifier.connection.Close()
+		notifier.connection, notifier.channel = nil, nil
+	}
+
+	connection, channel, err := dialQueue(&notifier.config)
+
+	if err != nil {
+		return nil, err
+	}
+
+	err = channel.Confirm(false)
+
+	if err != nil {
+		connection.Close()
+		return nil, err
+	}
+
+	notifier.connection, notifier.channel = connection, channel
+
+	return channel, nil
+}
+
+// ConsumeQueue : Dispatch notification jobs of the RabbitMQ queue until ctx is done, reconnecting when the connection is lost.
+// Jobs are acknowledged once dispatched, failed jobs are rejected without being requeued (Dead-lettered when the queue has a dead letter exchange)
+func ConsumeQueue(ctx context.Context, dispatcher *Dispatcher, config *models.NotificationQueueConfig) {
+
+	for {
+
+		err := consume(ctx, dispatcher, config)
+
+		if ctx.Err() != nil {
+			return
+		}
+
+		dispatcher.Env.Logger.WithError(err).WithField("queue", queueName(config)).Error("Notification jobs consumer stopped, reconnecting")
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(queueReconnectDelay):
+		}
+	}
+}
+
+// consume : Dispatch jobs delivered on a new connection until ctx is done or the connection is lost.
+// Jobs in flight are waited for before closing the connection, so that they are acknowledged
+func consume(ctx context.Context, dispatcher *Dispatcher, config *models.NotificationQueueConfig) error {
+
+	connection, channel, err := dialQueue(config)
+
+	if err != nil {
+		return err
+	}
+
+	defer connection.Close()
+
+	prefetch := config.Prefetch
+
+	if prefetch <= 0 {
+		prefetch = models.DefaultNotificationQueuePrefetch
+	}
+
+	// RabbitMQ delivers at most prefetch unacknowledged jobs, which bounds concurrent dispatches
+	err = channel.Qos(prefetch, 0, false)
+
+	if err != nil {
+		return err
+	}
+
+	deliveries, err := channel.Consume(queueName(config), "", false, false, false, false, nil)
+
+	if err != nil {
+		return err
+	}
+
+	running := sync.WaitGroup{}
+	defer running.Wait()
+
+	for {
+
+		select {
+
+		case <-ctx.Done():
+			return nil
+
+		case delivery, ok := <-deliveries:
+
+			if !ok {
+				return ErrConsumerClosed
+			}
+
+			running.Add(1)
+
+			go func(delivery amqp.Delivery) {
+				defer running.Done()
+				dispatcher.Env.Guard(func() {
+					dispatcher.dispatchJob(delivery)
+				})
+			}(delivery)
+		}
+	}
+}
+
+// dispatchJob : Dispatch job of delivery, then acknowledge it, or reject it when it failed
+func (dispatcher *Dispatcher) dispatchJob(delivery amqp.Delivery) {
+
+	job := &models.NotificationJob{}
+
+	err := json.Unmarshal(delivery.Body, job)
+
+	if err == nil {
+
+		switch {
+
+		case job.Type == models.NotificationJobOfflineMessage && job.OfflineMessage != nil:
+			err = dispatcher.NotifyOfflineMessage(job.OfflineMessage)
+
+		default:
+			err = ErrUnknownJob
+		}
+	}
+
+	if err != nil {
+
+		dispatcher.Env.Logger.WithError(err).WithFields(logrus.Fields{"jobID": delivery.MessageId, "type": job.Type}).Error("Failed to dispatch notification job")
+		models.NotificationJobs.WithLabelValues(job.Type, "rejected").Inc()
+
+		err = delivery.Reject(false)
+
+	} else {
+
+		models.NotificationJobs.WithLabelValues(job.Type, "dispatched").Inc()
+
+		err = delivery.Ack(false)
+	}
+
+	if err != nil {
+		dispatcher.Env.Logger.WithError(err).WithField("jobID", delivery.MessageId).Error("Failed to settle notification job")
+	}
+}
+
+// dialQueue : Open connection and channel to RabbitMQ (amqps:// URLs for TLS), declaring the durable queue of jobs
+func dialQueue(config *models.NotificationQueueConfig) (*amqp.Connection, *amqp.Channel, error) {
+
+	connection, err := amqp.Dial(config.URL)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	channel, err := connection.Channel()
+
+	if err != nil {
+		connection.Close()
+		return nil, nil, err
+	}
+
+	_, err = channel.QueueDeclare(queueName(config), true, false, false, false, nil)
+
+	if err != nil {
+		connection.Close()
+		return nil, nil, err
+	}
+
+	return connection, channel, nil
+}
+
+// queueName : Return queue of config, DefaultNotificationQueue if not set
+func queueName(config *models.NotificationQueueConfig) string {
+
+	if config.Queue == "" {
+		return models.DefaultNotificationQueue
+	}
+
+	return config.Queue
+}
@@ -0,0 +1,348 @@
+package notifications
+
+import (
+	bytes "bytes"
+	aes "crypto/aes"
+	cipher "crypto/cipher"
+	ecdsa "crypto/ecdsa"
+	elliptic "crypto/elliptic"
+	hmac "crypto/hmac"
+	rand "crypto/rand"
+	sha256 "crypto/sha256"
+	base64 "encoding/base64"
+	binary "encoding/binary"
+	json "encoding/json"
+	errors "errors"
+	fmt "fmt"
+	big "math/big"
+	net "net"
+	http "net/http"
+	url "net/url"
+	strconv "strconv"
+	strings "strings"
+	syscall "syscall"
+	time "time"
+	models "wave-messaging-management-service/models"
+)
+
+const (
+	// WebPushDefaultTTL : Time in seconds push services keep undelivered notifications
+	WebPushDefaultTTL = 86400
+
+	// webPushRecordSize : aes128gcm record size, payload is sent as a single record
+	webPushRecordSize = 4096
+)
+
+var (
+	// ErrInvalidWebPushEndpoint : Web Push subscription endpoint is not an https URL of a public host
+	ErrInvalidWebPushEndpoint = errors.New("Web Push endpoint must be an https URL of a public host")
+)
+
+// WebPushProvider : Web Push provider (Browsers), authenticated with VAPID (RFC 8292).
+// Payloads are encrypted with aes128gcm content encoding (RFC 8291)
+type WebPushProvider struct {
+	Client *http.Client
+}
+
+// webPushPayload : Notification payload decrypted by the browser service worker
+type webPushPayload struct {
+	Title            string `json:"title"`
+	Body             string `json:"body"`
+	ConversationType string `json:"conversationType"`
+	ConversationID   string `json:"conversationID"`
+	SenderID         string `json:"senderID"`
+}
+
+// NewWebPushProvider : Return a new WebPushProvider, whose client only connects to public addresses and doesn't follow redirects,
+// as subscription endpoints are given by clients
+func NewWebPushProvider() *WebPushProvider {
+
+	dialer := &net.Dialer{
+		Timeout: 30 * time.Second,
+		Control: func(network string, address string, conn syscall.RawConn) error {
+
+			host, _, err := net.SplitHostPort(address)
+
+			if err != nil {
+				return err
+			}
+
+			if !isPublicIP(net.ParseIP(host)) {
+				return ErrInvalidWebPushEndpoint
+			}
+
+			return nil
+		},
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+
+	return &WebPushProvider{
+		Client: &http.Client{
+			Transport: transport,
+			CheckRedirect: func(req *http.Request, via []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+	}
+}
+
+// ValidateWebPushEndpoint : Check that endpoint is an https URL whose host only resolves to public addresses
+// (Loopback, private, link-local and unspecified addresses are refused), so that subscriptions can't target internal services
+func ValidateWebPushEndpoint(endpoint string) error {
+
+	endpointURL, err := url.Parse(endpoint)
+
+	if err != nil || endpointURL.Scheme != "https" || endpointURL.Hostname() == "" || endpointURL.User != nil {
+		return ErrInvalidWebPushEndpoint
+	}
+
+	ips, err := net.LookupIP(endpointURL.Hostname())
+
+	if err != nil || len(ips) == 0 {
+		return ErrInvalidWebPushEndpoint
+	}
+
+	for _, ip := range ips {
+		if !isPublicIP(ip) {
+			return ErrInvalidWebPushEndpoint
+		}
+	}
+
+	return nil
+}
+
+// isPublicIP : Check that ip is a public unicast address
+func isPublicIP(ip net.IP) bool {
+	return ip != nil && ip.IsGlobalUnicast() && !ip.IsPrivate() && !ip.IsLoopback() && !ip.IsLinkLocalUnicast()
+}
+
+// Send : Send notification to Web Push subscription (Token is the subscription endpoint).
+// Endpoint is checked again, as its host may resolve differently than when it was registered (Connections are only made to public addresses)
+func (provider *WebPushProvider) Send(config *models.NotificationsConfig, pushToken *models.PushToken, notification *Notification) error {
+
+	err := ValidateWebPushEndpoint(pushToken.Token)
+
+	if err != nil {
+		return err
+	}
+
+	payload, err := json.Marshal(webPushPayload{
+		Title:            notification.Title,
+		Body:             notification.Body,
+		ConversationType: notification.ConversationType,
+		ConversationID:   notification.ConversationID,
+		SenderID:         notification.SenderID,
+	})
+
+	if err != nil {
+		return err
+	}
+
+	body, err := encryptWebPushPayload(pushToken.P256dh, pushToken.Auth, payload)
+
+	if err != nil {
+		return err
+	}
+
+	authorization, err := vapidAuthorization(&config.WebPush, pushToken.Token)
+
+	if err != nil {
+		return err
+	}
+
+	ttl := config.WebPush.TTL
+
+	if ttl == 0 {
+		ttl = WebPushDefaultTTL
+	}
+
+	req, err := http.NewRequest("POST", pushToken.Token, bytes.NewReader(body))
+
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Content-Type", "application/octet-stream")
+	req.Header.Set("Content-Encoding", "aes128gcm")
+	req.Header.Set("TTL", strconv.Itoa(ttl))
+	req.Header.Set("Authorization", authorization)
+
+	// Only the latest notification of a conversation is kept by push services
+	req.Header.Set("Topic", webPushTopic(notification))
+
+	res, err := provider.Client.Do(req)
+
+	if err != nil {
+		return err
+	}
+
+	defer res.Body.Close()
+
+	switch {
+	case res.StatusCode == http.StatusNotFound || res.StatusCode == http.StatusGone:
+		return ErrInvalidPushToken
+	case res.StatusCode >= 300:
+		return fmt.Errorf("error sending Web Push notification : status %d", res.StatusCode)
+	}
+
+	return nil
+}
+
+// webPushTopic : Topic header only allows 32 URL-safe base64 characters
+func webPushTopic(notification *Notification) string {
+
+	digest := sha256.Sum256([]byte(notification.ConversationType + ":" + notification.ConversationID))
+
+	return base64.RawURLEncoding.EncodeToString(digest[:])[:32]
+}
+
+// vapidAuthorization : Build VAPID Authorization header for subscription endpoint
+func vapidAuthorization(config *models.WebPushConfig, endpoint string) (string, error) {
+
+	endpointURL, err := url.Parse(endpoint)
+
+	if err != nil {
+		return "", err
+	}
+
+	privateKey, err := decodeBase64URL(config.PrivateKey)
+
+	if err != nil {
+		return "", err
+	}
+
+	key := &ecdsa.PrivateKey{D: new(big.Int).SetBytes(privateKey)}
+	key.PublicKey.Curve = elliptic.P256()
+	key.PublicKey.X, key.PublicKey.Y = key.PublicKey.Curve.ScalarBaseMult(privateKey)
+
+	header, err := json.Marshal(map[string]string{"typ": "JWT", "alg": "ES256"})
+
+	if err != nil {
+		return "", err
+	}
+
+	claims, err := json.Marshal(map[string]interface{}{
+		"aud": endpointURL.Scheme + "://" + endpointURL.Host,
+		"exp": time.Now().Add(12 * time.Hour).Unix(),
+		"sub": config.Subject,
+	})
+
+	if err != nil {
+		return "", err
+	}
+
+	signingInput := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(claims)
+
+	digest := sha256.Sum256([]byte(signingInput))
+
+	r, s, err := ecdsa.Sign(rand.Reader, key, digest[:])
+
+	if err != nil {
+		return "", err
+	}
+
+	token := signingInput + "." + base64.RawURLEncoding.EncodeToString(joinSignature(r, s))
+	publicKey := base64.RawURLEncoding.EncodeToString(elliptic.Marshal(elliptic.P256(), key.PublicKey.X, key.PublicKey.Y))
+
+	return "vapid t=" + token + ", k=" + publicKey, nil
+}
+
+// encryptWebPushPayload : Encrypt payload for subscription keys (RFC 8291)
+func encryptWebPushPayload(p256dh string, auth string, payload []byte) ([]byte, error) {
+
+	userAgentPublicKey, err := decodeBase64URL(p256dh)
+
+	if err != nil {
+		return nil, err
+	}
+
+	authSecret, err := decodeBase64URL(auth)
+
+	if err != nil {
+		return nil, err
+	}
+
+	curve := elliptic.P256()
+
+	userAgentX, userAgentY := elliptic.Unmarshal(curve, userAgentPublicKey)
+
+	if userAgentX == nil {
+		return nil, errors.New("Invalid Web Push subscription key")
+	}
+
+	// Ephemeral application server key pair
+	serverPrivateKey, serverX, serverY, err := elliptic.GenerateKey(curve, rand.Reader)
+
+	if err != nil {
+		return nil, err
+	}
+
+	serverPublicKey := elliptic.Marshal(curve, serverX, serverY)
+
+	sharedX, _ := curve.ScalarMult(userAgentX, userAgentY, serverPrivateKey)
+	ecdhSecret := make([]byte, 32)
+	sharedX.FillBytes(ecdhSecret)
+
+	keyInfo := append([]byte("WebPush: info\x00"), userAgentPublicKey...)
+	keyInfo = append(keyInfo, serverPublicKey...)
+
+	ikm := hkdf(authSecret, ecdhSecret, keyInfo, 32)
+
+	salt := make([]byte, 16)
+
+	_, err = rand.Read(salt)
+
+	if err != nil {
+		return nil, err
+	}
+
+	contentEncryptionKey := hkdf(salt, ikm, []byte("Content-Encoding: aes128gcm\x00"), 16)
+	nonce := hkdf(salt, ikm, []byte("Content-Encoding: nonce\x00"), 12)
+
+	block, err := aes.NewCipher(contentEncryptionKey)
+
+	if err != nil {
+		return nil, err
+	}
+
+	gcm, err := cipher.NewGCM(block)
+
+	if err != nil {
+		return nil, err
+	}
+
+	// Single record : payload followed by last record delimiter
+	ciphertext := gcm.Seal(nil, nonce, append(payload, 0x02), nil)
+
+	// Header : salt | record size | key id length | key id (server public key)
+	header := make([]byte, 21)
+	copy(header, salt)
+	binary.BigEndian.PutUint32(header[16:], webPushRecordSize)
+	header[20] = byte(len(serverPublicKey))
+
+	body := append(header, serverPublicKey...)
+
+	return append(body, ciphertext...), nil
+}
+
+// hkdf : HMAC-SHA256 based key derivation (RFC 5869), limited to one output block
+func hkdf(salt []byte, secret []byte, info []byte, length int) []byte {
+
+	extract := hmac.New(sha256.New, salt)
+	extract.Write(secret)
+	prk := extract.Sum(nil)
+
+	expand := hmac.New(sha256.New, prk)
+	expand.Write(info)
+	expand.Write([]byte{0x01})
+
+	return expand.Sum(nil)[:length]
+}
+
+// decodeBase64URL : Decode base64 URL encoded keys, with or without padding
+func decodeBase64URL(s string) ([]byte, error) {
+	return base64.RawURLEncoding.DecodeString(strings.TrimRight(s, "="))
+}
@@ -0,0 +1,28 @@
+package router
+
+import (
+	http "net/http"
+	models "wave-messaging-management-service/models"
+
+	autocert "golang.org/x/crypto/acme/autocert"
+)
+
+// listenACMEChallenges : Answer ACME HTTP-01 challenges of certManager on configured address, redirecting other requests to HTTPS.
+// TLS-ALPN-01 challenges are answered on the API port, so this listener is optional
+func listenACMEChallenges(env *models.Env, certManager *autocert.Manager) {
+
+	address := env.Config.TLS.Autocert.HTTPAddress
+
+	if address == "" {
+		return
+	}
+
+	server := &http.Server{
+		Addr:    address,
+		Handler: certManager.HTTPHandler(nil),
+	}
+
+	go func() {
+		env.Logger.WithError(server.ListenAndServe()).Error("ACME challenges server stopped")
+	}()
+}
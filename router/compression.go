@@ -0,0 +1,235 @@
+package router
+
+import (
+	flate "compress/flate"
+	gzip "compress/gzip"
+	io "io"
+	http "net/http"
+	strconv "strconv"
+	strings "strings"
+	models "wave-messaging-management-service/models"
+)
+
+const (
+	// encodingGzip : gzip content coding
+	encodingGzip = "gzip"
+
+	// encodingDeflate : deflate content coding
+	encodingDeflate = "deflate"
+)
+
+// compressHandler : Compress responses of handler with the coding accepted by the client (gzip preferred over deflate),
+// once they reach configured minimum size. Smaller responses, HEAD requests and responses already encoded are sent as is
+func compressHandler(config *models.CompressionConfig, handler http.Handler) http.Handler {
+
+	if !config.Enabled {
+		return handler
+	}
+
+	minSize := config.MinSize
+
+	if minSize <= 0 {
+		minSize = models.DefaultCompressionMinSize
+	}
+
+	level := config.Level
+
+	if level < flate.BestSpeed || level > flate.BestCompression {
+		level = flate.DefaultCompression
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+
+		w.Header().Add("Vary", "Accept-Encoding")
+
+		encoding := negotiateEncoding(r.Header.Get("Accept-Encoding"))
+
+		if encoding == "" || r.Method == "HEAD" {
+			handler.ServeHTTP(w, r)
+			return
+		}
+
+		writer := &compressedResponseWriter{ResponseWriter: w, encoding: encoding, minSize: minSize, level: level}
+		defer writer.Close()
+
+		handler.ServeHTTP(writer, r)
+	})
+}
+
+// negotiateEncoding : Return coding to compress response with according to Accept-Encoding header, empty if none is accepted
+func negotiateEncoding(acceptEncoding string) string {
+
+	accepted := map[string]bool{}
+
+	for _, part := range strings.Split(acceptEncoding, ",") {
+
+		fields := strings.Split(part, ";")
+		coding := strings.ToLower(strings.TrimSpace(fields[0]))
+		quality := 1.0
+
+		for _, param := range fields[1:] {
+
+			param = strings.TrimSpace(param)
+
+			if strings.HasPrefix(param, "q=") {
+				quality, _ = strconv.ParseFloat(strings.TrimPrefix(param, "q="), 64)
+			}
+		}
+
+		accepted[coding] = quality > 0
+	}
+
+	for _, coding := range []string{encodingGzip, encodingDeflate} {
+
+		if enabled, ok := accepted[coding]; ok {
+
+			if enabled {
+				return coding
+			}
+
+			continue
+		}
+
+		if accepted["*"] {
+			return coding
+		}
+	}
+
+	return ""
+}
+
+// compressedResponseWriter : ResponseWriter buffering response until it reaches minSize, compressing it from then on.
+// Status is only written once compression is decided, as headers depend on it
+type compressedResponseWriter struct {
+	http.ResponseWriter
+	encoding string
+	minSize  int
+	level    int
+	status   int
+	buffer   []byte
+	started  bool
+	encoder  io.WriteCloser
+}
+
+// WriteHeader : Remember status, written once compression is decided
+func (writer *compressedResponseWriter) WriteHeader(status int) {
+
+	if writer.status == 0 {
+		writer.status = status
+	}
+}
+
+// Write : Buffer data until minimum size is reached, then compress it
+func (writer *compressedResponseWriter) Write(data []byte) (int, error) {
+
+	if writer.started {
+
+		if writer.encoder != nil {
+			return writer.encoder.Write(data)
+		}
+
+		return writer.ResponseWriter.Write(data)
+	}
+
+	writer.buffer = append(writer.buffer, data...)
+
+	if len(writer.buffer) >= writer.minSize {
+
+		err := writer.start(true)
+
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	return len(data), nil
+}
+
+// Close : Send buffered response as is if it never reached minimum size, flush compressed data otherwise
+func (writer *compressedResponseWriter) Close() error {
+
+	if !writer.started {
+		return writer.start(false)
+	}
+
+	if writer.encoder != nil {
+		return writer.encoder.Close()
+	}
+
+	return nil
+}
+
+// Flush : Send response so far to the client. Responses flushed before reaching minimum size (Streams) are sent as is
+func (writer *compressedResponseWriter) Flush() {
+
+	if !writer.started {
+		writer.start(false)
+	}
+
+	if flusher, ok := writer.encoder.(interface{ Flush() error }); ok {
+		flusher.Flush()
+	}
+
+	if flusher, ok := writer.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// start : Write headers and buffered data, compressed if compress and response is not encoded yet
+func (writer *compressedResponseWriter) start(compress bool) error {
+
+	writer.started = true
+
+	header := writer.Header()
+
+	if compress && header.Get("Content-Encoding") == "" {
+
+		// Content type must be sniffed on uncompressed data
+		if header.Get("Content-Type") == "" {
+			header.Set("Content-Type", http.DetectContentType(writer.buffer))
+		}
+
+		header.Set("Content-Encoding", writer.encoding)
+		header.Del("Content-Length")
+
+		encoder, err := newEncoder(writer.ResponseWriter, writer.encoding, writer.level)
+
+		if err != nil {
+			return err
+		}
+
+		writer.encoder = encoder
+	}
+
+	if writer.status == 0 {
+		writer.status = http.StatusOK
+	}
+
+	writer.ResponseWriter.WriteHeader(writer.status)
+
+	if len(writer.buffer) == 0 {
+		return nil
+	}
+
+	var err error
+
+	if writer.encoder != nil {
+		_, err = writer.encoder.Write(writer.buffer)
+	} else {
+		_, err = writer.ResponseWriter.Write(writer.buffer)
+	}
+
+	writer.buffer = nil
+
+	return err
+}
+
+// newEncoder : Return writer compressing to w with encoding at level
+func newEncoder(w io.Writer, encoding string, level int) (io.WriteCloser, error) {
+
+	if encoding == encodingGzip {
+		return gzip.NewWriterLevel(w, level)
+	}
+
+	return flate.NewWriter(w, level)
+}
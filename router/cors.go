@@ -0,0 +1,54 @@
+package router
+
+import (
+	errors "errors"
+	models "wave-messaging-management-service/models"
+
+	cors "github.com/rs/cors"
+)
+
+var (
+	// defaultCORSOrigins : Origins allowed when none is configured
+	defaultCORSOrigins = []string{"*"}
+
+	// defaultCORSHeaders : Request headers allowed when none is configured, the ones read by the API
+	defaultCORSHeaders = []string{"X-Requested-With", "Content-Type", "token", "identityProvider", models.RequestIDHeader, "Idempotency-Key", "If-None-Match"}
+
+	// defaultCORSMethods : Methods allowed when none is configured
+	defaultCORSMethods = []string{"GET", "HEAD", "POST", "PUT", "DELETE", "OPTIONS"}
+
+	// defaultCORSExposedHeaders : Response headers readable by browser-based clients when none is configured
+	defaultCORSExposedHeaders = []string{models.RequestIDHeader, "Retry-After", "Deprecation", "Sunset", "Link", "Idempotent-Replayed", "ETag"}
+)
+
+// newCORSHandler : Return cross-origin requests handler of config, unset fields using defaults
+func newCORSHandler(config *models.CORSConfig) (*cors.Cors, error) {
+
+	origins := stringsOrDefault(config.AllowedOrigins, defaultCORSOrigins)
+
+	// Any origin could otherwise act with credentials of the user
+	for _, origin := range origins {
+		if origin == "*" && config.AllowCredentials {
+			return nil, errors.New("Credentials can't be allowed with a wildcard origin")
+		}
+	}
+
+	return cors.New(cors.Options{
+		AllowedOrigins:   origins,
+		AllowedHeaders:   stringsOrDefault(config.AllowedHeaders, defaultCORSHeaders),
+		AllowedMethods:   stringsOrDefault(config.AllowedMethods, defaultCORSMethods),
+		ExposedHeaders:   stringsOrDefault(config.ExposedHeaders, defaultCORSExposedHeaders),
+		AllowCredentials: config.AllowCredentials,
+		MaxAge:           config.MaxAge,
+	}), nil
+}
+
+// stringsOrDefault : Return values, defaultValues if empty
+func stringsOrDefault(values []string, defaultValues []string) []string {
+
+	if len(values) == 0 {
+		return defaultValues
+	}
+
+	return values
+}
@@ -0,0 +1,34 @@
+package router
+
+import (
+	http "net/http"
+	models "wave-messaging-management-service/models"
+	handlers "wave-messaging-management-service/router/handlers"
+)
+
+// listenDebug : Serve debug endpoints on their own address when enabled, so that they are never reachable through the API port
+func listenDebug(env *models.Env) {
+
+	config := env.Config.Debug
+
+	if !config.Enabled {
+		return
+	}
+
+	address := config.Address
+
+	if address == "" {
+		address = models.DefaultDebugAddress
+	}
+
+	server := &http.Server{
+		Addr:    address,
+		Handler: handlers.DebugHandler(env),
+	}
+
+	go func() {
+		env.Logger.WithError(server.ListenAndServe()).Error("Debug server stopped")
+	}()
+
+	env.Logger.WithField("address", address).Warn("Debug server enabled")
+}
@@ -0,0 +1,46 @@
+package router
+
+import (
+	rand "math/rand"
+	http "net/http"
+	time "time"
+	models "wave-messaging-management-service/models"
+
+	logrus "github.com/sirupsen/logrus"
+)
+
+// logAccess : Log request handled by handler, with its caller once identified and the code of the error it failed with.
+// Successful requests of sampled handlers are only logged at their configured rate
+func logAccess(env *models.Env, handler string, r *http.Request, recorder *statusRecorder, failure error, start time.Time) {
+
+	config := env.Config.AccessLog
+
+	if !config.Enabled {
+		return
+	}
+
+	failed := failure != nil || recorder.status >= http.StatusBadRequest
+
+	if rate, ok := config.SampleRates[handler]; ok && !failed && rand.Float64() >= rate {
+		return
+	}
+
+	fields := logrus.Fields{
+		"method":     r.Method,
+		"path":       r.URL.Path,
+		"status":     recorder.status,
+		"latency":    float64(time.Since(start)) / float64(time.Millisecond),
+		"remoteAddr": r.RemoteAddr,
+	}
+
+	if env.Caller != nil && env.Caller.Type != "" {
+		fields["callerType"] = env.Caller.Type
+		fields["callerID"] = env.Caller.ID
+	}
+
+	if failure != nil {
+		fields["code"] = failure.Error()
+	}
+
+	env.Logger.WithFields(fields).Info("Request handled")
+}
@@ -0,0 +1,959 @@
+package router
+
+import (
+	json "encoding/json"
+	http "net/http"
+	strconv "strconv"
+	time "time"
+	auth "wave-messaging-management-service/auth"
+	models "wave-messaging-management-service/models"
+	notifications "wave-messaging-management-service/notifications"
+	utils "wave-messaging-management-service/utils"
+	validation "wave-messaging-management-service/validation"
+
+	mux "github.com/gorilla/mux"
+	gocustomhttpresponse "github.com/terryvogelsang/gocustomhttpresponse"
+	logruswrapper "github.com/terryvogelsang/logruswrapper"
+)
+
+// ListUsers : List and search users on the admin API, joining their mapping, VerneMQ ACLs and presence.
+// Query parameters : search (Substring of original or internal user ID), cursor or offset, and limit (See utils.ParsePagination)
+func ListUsers(env *models.Env, w http.ResponseWriter, r *http.Request) error {
+
+	// Operators authenticate as services, with admin scopes
+	env, _, err := checkService(env, r, models.APIKeyScopeAdminUsersRead)
+
+	if err != nil {
+		return err
+	}
+
+	query := r.URL.Query()
+
+	pagination, err := utils.ParsePagination(query, models.DefaultUsersPageLimit, models.MaxUsersPageLimit)
+
+	if err != nil {
+		return invalidRequest(err.Error())
+	}
+
+	usersPage, err := auth.ListUsers(env, query.Get("search"), pagination)
+
+	if err != nil {
+		return err
+	}
+
+	utils.WritePageLinks(w, r, usersPage.Page)
+
+	if notModified(w, r, usersPage) {
+		return nil
+	}
+
+	log := logruswrapper.NewEntry("MessagingService", "/admin/users", logruswrapper.CodeSuccess)
+
+	gocustomhttpresponse.WriteResponse(usersPage, log, w)
+
+	return nil
+}
+
+// GetUserACL : Get effective VerneMQ ACLs of an internal Wave user and conversations derived from them on the admin API
+func GetUserACL(env *models.Env, w http.ResponseWriter, r *http.Request) error {
+
+	env, _, err := checkService(env, r, models.APIKeyScopeAdminUsersRead)
+
+	if err != nil {
+		return err
+	}
+
+	internalWaveUserID := mux.Vars(r)["id"]
+
+	err = checkTenantUser(env, internalWaveUserID)
+
+	if err != nil {
+		return err
+	}
+
+	userACL, err := auth.GetUserACL(env, internalWaveUserID)
+
+	if err == auth.ErrUnknownUser {
+		return notFound("Unknown user")
+	}
+
+	if err != nil {
+		env.Logger.WithError(err).Error("Failed to get user ACL")
+		return internalError("Failed to get user ACL")
+	}
+
+	if notModified(w, r, userACL) {
+		return nil
+	}
+
+	log := logruswrapper.NewEntry("MessagingService", "/admin/users/acl", logruswrapper.CodeSuccess)
+
+	gocustomhttpresponse.WriteResponse(userACL, log, w)
+
+	return nil
+}
+
+// SuspendUser : Revoke all access of an internal Wave user on the admin API (Incident response)
+func SuspendUser(env *models.Env, w http.ResponseWriter, r *http.Request) error {
+
+	env, actor, err := checkService(env, r, models.APIKeyScopeAdminUsersWrite)
+
+	if err != nil {
+		return err
+	}
+
+	internalWaveUserID := mux.Vars(r)["id"]
+
+	err = checkTenantUser(env, internalWaveUserID)
+
+	if err != nil {
+		return err
+	}
+
+	entry := models.NewAuditEntry(actor, models.AuditAdminUserSuspend, internalWaveUserID, nil)
+
+	mapping, err := auth.SuspendUser(env, internalWaveUserID, auth.LifecycleEvents(env, entry)...)
+
+	if err == auth.ErrUnknownUser {
+		return notFound("Unknown user")
+	}
+
+	if err != nil {
+		env.Logger.WithError(err).Error("Failed to suspend user")
+		return internalError("Failed to suspend user")
+	}
+
+	env.Logger.WithField("target", internalWaveUserID).Info("User suspended")
+
+	auth.Audit(env, entry)
+
+	log := logruswrapper.NewEntry("MessagingService", "/admin/users/suspend", logruswrapper.CodeSuccess)
+
+	gocustomhttpresponse.WriteResponse(mapping, log, w)
+
+	return nil
+}
+
+// ListUserConversations : List group conversations an internal Wave user is a member of on the admin API.
+// Query parameters : cursor or offset, and limit (See utils.ParsePagination)
+func ListUserConversations(env *models.Env, w http.ResponseWriter, r *http.Request) error {
+
+	env, _, err := checkService(env, r, models.APIKeyScopeAdminUsersRead)
+
+	if err != nil {
+		return err
+	}
+
+	internalWaveUserID := mux.Vars(r)["id"]
+
+	err = checkTenantUser(env, internalWaveUserID)
+
+	if err != nil {
+		return err
+	}
+
+	pagination, err := utils.ParsePagination(r.URL.Query(), models.DefaultConversationsPageLimit, models.MaxConversationsPageLimit)
+
+	if err != nil {
+		return invalidRequest(err.Error())
+	}
+
+	conversationsPage, err := auth.ListUserConversations(env, internalWaveUserID, pagination)
+
+	if err != nil {
+		env.Logger.WithError(err).Error("Failed to list user conversations")
+		return internalError("Failed to list user conversations")
+	}
+
+	utils.WritePageLinks(w, r, conversationsPage.Page)
+
+	if notModified(w, r, conversationsPage) {
+		return nil
+	}
+
+	log := logruswrapper.NewEntry("MessagingService", "/admin/users/conversations", logruswrapper.CodeSuccess)
+
+	gocustomhttpresponse.WriteResponse(conversationsPage, log, w)
+
+	return nil
+}
+
+// DisconnectUser : Disconnect sessions of all devices of an internal Wave user on the admin API, keeping its access
+func DisconnectUser(env *models.Env, w http.ResponseWriter, r *http.Request) error {
+
+	env, actor, err := checkService(env, r, models.APIKeyScopeAdminUsersWrite)
+
+	if err != nil {
+		return err
+	}
+
+	internalWaveUserID := mux.Vars(r)["id"]
+
+	err = checkTenantUser(env, internalWaveUserID)
+
+	if err != nil {
+		return err
+	}
+
+	sessions, err := auth.DisconnectUser(env, internalWaveUserID)
+
+	if err == auth.ErrUnknownUser {
+		return notFound("Unknown user")
+	}
+
+	if err != nil {
+		env.Logger.WithError(err).Error("Failed to disconnect user")
+		return internalError("Failed to disconnect user")
+	}
+
+	env.Logger.WithField("target", internalWaveUserID).Info("User disconnected")
+
+	audit(env, actor, models.AuditAdminUserDisconnect, internalWaveUserID, nil)
+
+	log := logruswrapper.NewEntry("MessagingService", "/admin/users/disconnect", logruswrapper.CodeSuccess)
+
+	gocustomhttpresponse.WriteResponse(sessions, log, w)
+
+	return nil
+}
+
+// RestoreUserACLs : Restore removed VerneMQ ACLs of an internal Wave user on the admin API (Undo of a device removal, expiry or suspension),
+// answering its effective ACLs
+func RestoreUserACLs(env *models.Env, w http.ResponseWriter, r *http.Request) error {
+
+	env, actor, err := checkService(env, r, models.APIKeyScopeAdminUsersWrite)
+
+	if err != nil {
+		return err
+	}
+
+	internalWaveUserID := mux.Vars(r)["id"]
+
+	err = checkTenantUser(env, internalWaveUserID)
+
+	if err != nil {
+		return err
+	}
+
+	entry := models.NewAuditEntry(actor, models.AuditAdminUserRestore, internalWaveUserID, nil)
+
+	restored, err := auth.RestoreUserACLs(env, internalWaveUserID, auth.LifecycleEvents(env, entry)...)
+
+	if err == auth.ErrNoDeletedACLs {
+		return notFound(err.Error())
+	}
+
+	if err == auth.ErrUserSuspended {
+		return forbidden("User suspended, lift its suspension first")
+	}
+
+	if err != nil {
+		env.Logger.WithError(err).Error("Failed to restore user ACLs")
+		return internalError("Failed to restore user ACLs")
+	}
+
+	env.Logger.WithField("target", internalWaveUserID).WithField("restored", restored).Info("User ACLs restored")
+
+	entry.Details = map[string]string{"restored": strconv.Itoa(restored)}
+
+	auth.Audit(env, entry)
+
+	userACL, err := auth.GetUserACL(env, internalWaveUserID)
+
+	if err != nil {
+		env.Logger.WithError(err).Error("Failed to get user ACL")
+		return internalError("Failed to get user ACL")
+	}
+
+	log := logruswrapper.NewEntry("MessagingService", "/admin/users/restore", logruswrapper.CodeUpdated)
+
+	gocustomhttpresponse.WriteResponse(userACL, log, w)
+
+	return nil
+}
+
+// RemoveGroupConversation : Remove a group conversation of the tenant on the admin API, revoking access of its members.
+// It can be restored until its tombstone is purged
+func RemoveGroupConversation(env *models.Env, w http.ResponseWriter, r *http.Request) error {
+
+	env, actor, err := checkService(env, r, models.APIKeyScopeAdminConversationsWrite)
+
+	if err != nil {
+		return err
+	}
+
+	groupConversationID := mux.Vars(r)["id"]
+
+	entry := models.NewAuditEntry(actor, models.AuditAdminGroupRemove, groupConversationID, nil)
+
+	groupConversation, err := auth.RemoveGroupConversation(env, groupConversationID, auth.LifecycleEvents(env, entry)...)
+
+	if err == auth.ErrUnknownGroupConversation {
+		return notFound(err.Error())
+	}
+
+	if err != nil {
+		env.Logger.WithError(err).Error("Failed to remove group conversation")
+		return internalError("Failed to remove group conversation")
+	}
+
+	env.Logger.WithField("target", groupConversationID).Info("Group conversation removed")
+
+	auth.Audit(env, entry)
+
+	log := logruswrapper.NewEntry("MessagingService", "/admin/conversations/group", logruswrapper.CodeUpdated)
+
+	gocustomhttpresponse.WriteResponse(groupConversation, log, w)
+
+	return nil
+}
+
+// RestoreGroupConversation : Restore a removed group conversation of the tenant on the admin API, granting access to its members again
+func RestoreGroupConversation(env *models.Env, w http.ResponseWriter, r *http.Request) error {
+
+	env, actor, err := checkService(env, r, models.APIKeyScopeAdminConversationsWrite)
+
+	if err != nil {
+		return err
+	}
+
+	groupConversationID := mux.Vars(r)["id"]
+
+	entry := models.NewAuditEntry(actor, models.AuditAdminGroupRestore, groupConversationID, nil)
+
+	groupConversation, err := auth.RestoreGroupConversation(env, groupConversationID, auth.LifecycleEvents(env, entry)...)
+
+	if err == auth.ErrUnknownGroupConversation {
+		return notFound(err.Error())
+	}
+
+	if err != nil {
+		env.Logger.WithError(err).Error("Failed to restore group conversation")
+		return internalError("Failed to restore group conversation")
+	}
+
+	env.Logger.WithField("target", groupConversationID).Info("Group conversation restored")
+
+	auth.Audit(env, entry)
+
+	log := logruswrapper.NewEntry("MessagingService", "/admin/conversations/group/restore", logruswrapper.CodeUpdated)
+
+	gocustomhttpresponse.WriteResponse(groupConversation, log, w)
+
+	return nil
+}
+
+// Broadcast : Publish a system message to all users of the operator tenant, or to a segment of them, on the admin API
+func Broadcast(env *models.Env, w http.ResponseWriter, r *http.Request) error {
+
+	env, actor, err := checkService(env, r, models.APIKeyScopeAdminBroadcast)
+
+	if err != nil {
+		return err
+	}
+
+	reqBody := utils.BroadcastBody{}
+
+	err = decodeBody(r, &reqBody)
+
+	if err != nil {
+		return invalidRequest(err.Error())
+	}
+
+	// Check required fields
+	_, err = validation.ValidateStruct(reqBody)
+
+	if err != nil {
+		return invalidRequest(err.Error())
+	}
+
+	// Segment can't reach users of other tenants
+	for _, userID := range reqBody.UserIDs {
+
+		err = checkTenantUser(env, userID)
+
+		if err != nil {
+			return err
+		}
+	}
+
+	report, err := notifications.Broadcast(env, models.NewSystemMessage(reqBody.Message, reqBody.Data), reqBody.UserIDs)
+
+	if err != nil {
+		env.Logger.WithError(err).Error("Failed to broadcast system message")
+		return internalError("Failed to broadcast system message")
+	}
+
+	audit(env, actor, models.AuditAdminBroadcast, report.MessageID, map[string]string{
+		"recipients": strconv.Itoa(len(report.Recipients)),
+		"failed":     strconv.Itoa(len(report.Failed)),
+	})
+
+	log := logruswrapper.NewEntry("MessagingService", "/admin/broadcasts", logruswrapper.CodeSuccess)
+
+	gocustomhttpresponse.WriteResponse(report, log, w)
+
+	return nil
+}
+
+// GetUserQuotas : Get effective quotas of an internal Wave user, with its override and usage, on the admin API
+func GetUserQuotas(env *models.Env, w http.ResponseWriter, r *http.Request) error {
+
+	env, _, err := checkService(env, r, models.APIKeyScopeAdminUsersRead)
+
+	if err != nil {
+		return err
+	}
+
+	internalWaveUserID := mux.Vars(r)["id"]
+
+	err = checkTenantUser(env, internalWaveUserID)
+
+	if err != nil {
+		return err
+	}
+
+	userQuotas, err := auth.GetUserQuotas(env, internalWaveUserID)
+
+	if err != nil {
+		env.Logger.WithError(err).Error("Failed to get user quotas")
+		return internalError("Failed to get user quotas")
+	}
+
+	if notModified(w, r, userQuotas) {
+		return nil
+	}
+
+	log := logruswrapper.NewEntry("MessagingService", "/admin/users/quotas", logruswrapper.CodeSuccess)
+
+	gocustomhttpresponse.WriteResponse(userQuotas, log, w)
+
+	return nil
+}
+
+// SetUserQuotas : Override configured quotas of an internal Wave user on the admin API, null fields keep configured quotas
+func SetUserQuotas(env *models.Env, w http.ResponseWriter, r *http.Request) error {
+
+	env, actor, err := checkService(env, r, models.APIKeyScopeAdminUsersWrite)
+
+	if err != nil {
+		return err
+	}
+
+	internalWaveUserID := mux.Vars(r)["id"]
+
+	err = checkTenantUser(env, internalWaveUserID)
+
+	if err != nil {
+		return err
+	}
+
+	override := &models.QuotaOverride{}
+
+	err = decodeBody(r, override)
+
+	if err != nil {
+		return invalidRequest(err.Error())
+	}
+
+	// Quotas can't be negative
+	_, err = validation.ValidateStruct(*override)
+
+	if err != nil {
+		return invalidRequest(err.Error())
+	}
+
+	err = auth.SetQuotaOverride(env, internalWaveUserID, override)
+
+	if err != nil {
+		env.Logger.WithError(err).Error("Failed to set user quotas")
+		return internalError("Failed to set user quotas")
+	}
+
+	overrideJSON, _ := json.Marshal(override)
+
+	audit(env, actor, models.AuditAdminQuotasSet, internalWaveUserID, map[string]string{"override": string(overrideJSON)})
+
+	log := logruswrapper.NewEntry("MessagingService", "/admin/users/quotas", logruswrapper.CodeUpdated)
+
+	gocustomhttpresponse.WriteResponse(nil, log, w)
+
+	return nil
+}
+
+// GetUsageReport : Get usage aggregated per tenant or per user over a date range on the admin API.
+// Query parameters : from and to (Inclusive dates, last 30 days by default), groupBy (tenant or user), tenantID and userID filters
+func GetUsageReport(env *models.Env, w http.ResponseWriter, r *http.Request) error {
+
+	env, _, err := checkService(env, r, models.APIKeyScopeAdminUsageRead)
+
+	if err != nil {
+		return err
+	}
+
+	query := r.URL.Query()
+
+	now := time.Now().UTC()
+
+	filter := &models.UsageFilter{
+		From:   now.AddDate(0, 0, -29).Format(models.UsageDateLayout),
+		To:     now.Format(models.UsageDateLayout),
+		UserID: query.Get("userID"),
+	}
+
+	if from := query.Get("from"); from != "" {
+		filter.From = from
+	}
+
+	if to := query.Get("to"); to != "" {
+		filter.To = to
+	}
+
+	_, fromErr := time.Parse(models.UsageDateLayout, filter.From)
+	_, toErr := time.Parse(models.UsageDateLayout, filter.To)
+
+	if fromErr != nil || toErr != nil || filter.From > filter.To {
+		return invalidRequest("Invalid from or to date")
+	}
+
+	// Operators of a tenant only see its usage
+	if env.TenantID != "" {
+		filter.TenantID = &env.TenantID
+	} else if tenantID := query.Get("tenantID"); tenantID != "" {
+		filter.TenantID = &tenantID
+	}
+
+	groupBy := query.Get("groupBy")
+
+	if groupBy == "" {
+		groupBy = models.UsageGroupByTenant
+	}
+
+	if groupBy != models.UsageGroupByTenant && groupBy != models.UsageGroupByUser {
+		return invalidRequest("Invalid groupBy")
+	}
+
+	report, err := auth.GetUsageReport(env, filter, groupBy)
+
+	if err != nil {
+		env.Logger.WithError(err).Error("Failed to get usage report")
+		return internalError("Failed to get usage report")
+	}
+
+	if notModified(w, r, report) {
+		return nil
+	}
+
+	log := logruswrapper.NewEntry("MessagingService", "/admin/usage", logruswrapper.CodeSuccess)
+
+	gocustomhttpresponse.WriteResponse(report, log, w)
+
+	return nil
+}
+
+// GetAuditLog : Query the audit log on the admin API, most recent entries first.
+// Query parameters : actorType, actorID, target, action, from and to (RFC 3339 timestamps), tenantID, cursor or offset, and limit (See utils.ParsePagination)
+func GetAuditLog(env *models.Env, w http.ResponseWriter, r *http.Request) error {
+
+	env, _, err := checkService(env, r, models.APIKeyScopeAdminAuditRead)
+
+	if err != nil {
+		return err
+	}
+
+	query := r.URL.Query()
+
+	filter := &models.AuditFilter{
+		ActorType: query.Get("actorType"),
+		ActorID:   query.Get("actorID"),
+		Target:    query.Get("target"),
+		Action:    query.Get("action"),
+	}
+
+	filter.Page, err = utils.ParsePagination(query, models.DefaultAuditPageLimit, models.MaxAuditPageLimit)
+
+	if err != nil {
+		return invalidRequest(err.Error())
+	}
+
+	filter.From, err = queryTime(query.Get("from"))
+
+	if err != nil {
+		return invalidRequest("Invalid from date")
+	}
+
+	filter.To, err = queryTime(query.Get("to"))
+
+	if err != nil {
+		return invalidRequest("Invalid to date")
+	}
+
+	// Operators of a tenant only see its entries
+	if env.TenantID != "" {
+		filter.TenantID = &env.TenantID
+	} else if _, ok := query["tenantID"]; ok {
+		tenantID := query.Get("tenantID")
+		filter.TenantID = &tenantID
+	}
+
+	auditPage, err := auth.GetAuditLog(env, filter)
+
+	if err != nil {
+		env.Logger.WithError(err).Error("Failed to get audit log")
+		return internalError("Failed to get audit log")
+	}
+
+	utils.WritePageLinks(w, r, auditPage.Page)
+
+	if notModified(w, r, auditPage) {
+		return nil
+	}
+
+	log := logruswrapper.NewEntry("MessagingService", "/admin/audit", logruswrapper.CodeSuccess)
+
+	gocustomhttpresponse.WriteResponse(auditPage, log, w)
+
+	return nil
+}
+
+// CreateWebhook : Register an outbound webhook receiving lifecycle events of the tenant on the admin API.
+// Its signing secret is only returned in the response
+func CreateWebhook(env *models.Env, w http.ResponseWriter, r *http.Request) error {
+
+	env, actor, err := checkService(env, r, models.APIKeyScopeAdminWebhooks)
+
+	if err != nil {
+		return err
+	}
+
+	reqBody := utils.WebhookBody{}
+
+	err = decodeBody(r, &reqBody)
+
+	if err != nil {
+		return invalidRequest(err.Error())
+	}
+
+	// Check URL and event types
+	_, err = validation.ValidateStruct(reqBody)
+
+	if err != nil {
+		return invalidRequest(err.Error())
+	}
+
+	webhook, err := auth.CreateWebhook(env, &reqBody)
+
+	if err != nil {
+		env.Logger.WithError(err).Error("Failed to create webhook")
+		return internalError("Failed to create webhook")
+	}
+
+	audit(env, actor, models.AuditAdminWebhookCreate, webhook.ID, map[string]string{"url": webhook.URL})
+
+	log := logruswrapper.NewEntry("MessagingService", "/admin/webhooks", logruswrapper.CodeSuccess)
+
+	gocustomhttpresponse.WriteResponse(webhook, log, w)
+
+	return nil
+}
+
+// ListWebhooks : List outbound webhooks of the tenant, without their secret, on the admin API
+func ListWebhooks(env *models.Env, w http.ResponseWriter, r *http.Request) error {
+
+	env, _, err := checkService(env, r, models.APIKeyScopeAdminWebhooks)
+
+	if err != nil {
+		return err
+	}
+
+	webhooks, err := auth.ListWebhooks(env)
+
+	if err != nil {
+		env.Logger.WithError(err).Error("Failed to list webhooks")
+		return internalError("Failed to list webhooks")
+	}
+
+	if notModified(w, r, webhooks) {
+		return nil
+	}
+
+	log := logruswrapper.NewEntry("MessagingService", "/admin/webhooks", logruswrapper.CodeSuccess)
+
+	gocustomhttpresponse.WriteResponse(webhooks, log, w)
+
+	return nil
+}
+
+// RemoveWebhook : Remove an outbound webhook of the tenant and its deliveries on the admin API
+func RemoveWebhook(env *models.Env, w http.ResponseWriter, r *http.Request) error {
+
+	env, actor, err := checkService(env, r, models.APIKeyScopeAdminWebhooks)
+
+	if err != nil {
+		return err
+	}
+
+	webhookID := mux.Vars(r)["id"]
+
+	err = auth.RemoveWebhook(env, webhookID)
+
+	if err == auth.ErrUnknownWebhook {
+		return notFound("Unknown webhook")
+	}
+
+	if err != nil {
+		env.Logger.WithError(err).Error("Failed to remove webhook")
+		return internalError("Failed to remove webhook")
+	}
+
+	audit(env, actor, models.AuditAdminWebhookRemove, webhookID, nil)
+
+	log := logruswrapper.NewEntry("MessagingService", "/admin/webhooks", logruswrapper.CodeUpdated)
+
+	gocustomhttpresponse.WriteResponse(nil, log, w)
+
+	return nil
+}
+
+// ListWebhookDeliveries : List deliveries of an outbound webhook of the tenant, most recent first, on the admin API.
+// Query parameters : cursor or offset, and limit (See utils.ParsePagination)
+func ListWebhookDeliveries(env *models.Env, w http.ResponseWriter, r *http.Request) error {
+
+	env, _, err := checkService(env, r, models.APIKeyScopeAdminWebhooks)
+
+	if err != nil {
+		return err
+	}
+
+	pagination, err := utils.ParsePagination(r.URL.Query(), models.DefaultWebhookDeliveriesPageLimit, models.MaxWebhookDeliveriesPageLimit)
+
+	if err != nil {
+		return invalidRequest(err.Error())
+	}
+
+	deliveriesPage, err := auth.ListWebhookDeliveries(env, mux.Vars(r)["id"], pagination)
+
+	if err == auth.ErrUnknownWebhook {
+		return notFound("Unknown webhook")
+	}
+
+	if err != nil {
+		env.Logger.WithError(err).Error("Failed to list webhook deliveries")
+		return internalError("Failed to list webhook deliveries")
+	}
+
+	utils.WritePageLinks(w, r, deliveriesPage.Page)
+
+	if notModified(w, r, deliveriesPage) {
+		return nil
+	}
+
+	log := logruswrapper.NewEntry("MessagingService", "/admin/webhooks/deliveries", logruswrapper.CodeSuccess)
+
+	gocustomhttpresponse.WriteResponse(deliveriesPage, log, w)
+
+	return nil
+}
+
+// ListWebhookDeadLetters : List deliveries of the tenant dead-lettered after their last attempt, most recent first, on the admin API.
+// Query parameters : webhookID (All webhooks when empty), cursor or offset, and limit (See utils.ParsePagination)
+func ListWebhookDeadLetters(env *models.Env, w http.ResponseWriter, r *http.Request) error {
+
+	env, _, err := checkService(env, r, models.APIKeyScopeAdminWebhooks)
+
+	if err != nil {
+		return err
+	}
+
+	pagination, err := utils.ParsePagination(r.URL.Query(), models.DefaultWebhookDeliveriesPageLimit, models.MaxWebhookDeliveriesPageLimit)
+
+	if err != nil {
+		return invalidRequest(err.Error())
+	}
+
+	deadLettersPage, err := auth.ListWebhookDeadLetters(env, r.URL.Query().Get("webhookID"), pagination)
+
+	if err != nil {
+		env.Logger.WithError(err).Error("Failed to list webhook dead letters")
+		return internalError("Failed to list webhook dead letters")
+	}
+
+	utils.WritePageLinks(w, r, deadLettersPage.Page)
+
+	if notModified(w, r, deadLettersPage) {
+		return nil
+	}
+
+	log := logruswrapper.NewEntry("MessagingService", "/admin/webhooks/deadletters", logruswrapper.CodeSuccess)
+
+	gocustomhttpresponse.WriteResponse(deadLettersPage, log, w)
+
+	return nil
+}
+
+// ReplayWebhookDeadLetter : Queue a new delivery of a dead-lettered event to its webhook on the admin API, returning the delivery
+func ReplayWebhookDeadLetter(env *models.Env, w http.ResponseWriter, r *http.Request) error {
+
+	env, actor, err := checkService(env, r, models.APIKeyScopeAdminWebhooks)
+
+	if err != nil {
+		return err
+	}
+
+	deadLetterID := mux.Vars(r)["id"]
+
+	delivery, err := auth.ReplayWebhookDeadLetter(env, deadLetterID)
+
+	if err == auth.ErrUnknownDeadLetter {
+		return notFound("Unknown webhook dead letter")
+	}
+
+	if err == auth.ErrUnknownWebhook {
+		return notFound("Unknown webhook")
+	}
+
+	if err != nil {
+		env.Logger.WithError(err).Error("Failed to replay webhook dead letter")
+		return internalError("Failed to replay webhook dead letter")
+	}
+
+	audit(env, actor, models.AuditAdminWebhookDeadLetterReplay, deadLetterID, map[string]string{"webhookID": delivery.WebhookID, "deliveryID": delivery.ID})
+
+	log := logruswrapper.NewEntry("MessagingService", "/admin/webhooks/deadletters/replay", logruswrapper.CodeUpdated)
+
+	gocustomhttpresponse.WriteResponse(delivery, log, w)
+
+	return nil
+}
+
+// DiscardWebhookDeadLetter : Remove a dead-lettered delivery of the tenant without delivering it on the admin API
+func DiscardWebhookDeadLetter(env *models.Env, w http.ResponseWriter, r *http.Request) error {
+
+	env, actor, err := checkService(env, r, models.APIKeyScopeAdminWebhooks)
+
+	if err != nil {
+		return err
+	}
+
+	deadLetterID := mux.Vars(r)["id"]
+
+	err = auth.DiscardWebhookDeadLetter(env, deadLetterID)
+
+	if err == auth.ErrUnknownDeadLetter {
+		return notFound("Unknown webhook dead letter")
+	}
+
+	if err != nil {
+		env.Logger.WithError(err).Error("Failed to discard webhook dead letter")
+		return internalError("Failed to discard webhook dead letter")
+	}
+
+	audit(env, actor, models.AuditAdminWebhookDeadLetterDiscard, deadLetterID, nil)
+
+	log := logruswrapper.NewEntry("MessagingService", "/admin/webhooks/deadletters", logruswrapper.CodeUpdated)
+
+	gocustomhttpresponse.WriteResponse(nil, log, w)
+
+	return nil
+}
+
+// GetLogging : Get logging config currently applied to this instance on the admin API
+func GetLogging(env *models.Env, w http.ResponseWriter, r *http.Request) error {
+
+	_, _, err := checkAdminLogging(env, r)
+
+	if err != nil {
+		return err
+	}
+
+	log := logruswrapper.NewEntry("MessagingService", "/admin/logging", logruswrapper.CodeSuccess)
+
+	gocustomhttpresponse.WriteResponse(models.CurrentLoggingConfig(), log, w)
+
+	return nil
+}
+
+// SetLogging : Change logging level and/or format of this instance on the admin API, until next SIGUSR1 or restart.
+// Empty fields keep current values
+func SetLogging(env *models.Env, w http.ResponseWriter, r *http.Request) error {
+
+	env, actor, err := checkAdminLogging(env, r)
+
+	if err != nil {
+		return err
+	}
+
+	reqBody := models.LoggingConfig{}
+
+	err = decodeBody(r, &reqBody)
+
+	if err != nil {
+		return invalidRequest(err.Error())
+	}
+
+	// Check level and format
+	_, err = validation.ValidateStruct(reqBody)
+
+	if err != nil {
+		return invalidRequest(err.Error())
+	}
+
+	config := models.CurrentLoggingConfig()
+
+	if reqBody.Level != "" {
+		config.Level = reqBody.Level
+	}
+
+	if reqBody.Format != "" {
+		config.Format = reqBody.Format
+	}
+
+	err = models.ConfigureLogger(env.Logger, config)
+
+	if err != nil {
+		env.Logger.WithError(err).Error("Failed to configure logger")
+		return internalError("Failed to configure logger")
+	}
+
+	env.Logger.WithField("logging", config).Info("Logging config changed")
+
+	audit(env, actor, models.AuditAdminLoggingSet, "logging", map[string]string{"level": config.Level, "format": config.Format})
+
+	log := logruswrapper.NewEntry("MessagingService", "/admin/logging", logruswrapper.CodeUpdated)
+
+	gocustomhttpresponse.WriteResponse(config, log, w)
+
+	return nil
+}
+
+// checkAdminLogging : Check operator was granted logging scope. Logger is shared by all tenants, so operators of a tenant can't use it
+func checkAdminLogging(env *models.Env, r *http.Request) (*models.Env, models.AuditActor, error) {
+
+	env, actor, err := checkService(env, r, models.APIKeyScopeAdminLogging)
+
+	if err != nil {
+		return nil, actor, err
+	}
+
+	if env.TenantID != "" {
+		return nil, actor, forbidden("Logging is shared by all tenants")
+	}
+
+	return env, actor, nil
+}
+
+// queryTime : Parse optional RFC 3339 timestamp query parameter, nil if missing
+func queryTime(value string) (*time.Time, error) {
+
+	if value == "" {
+		return nil, nil
+	}
+
+	t, err := time.Parse(time.RFC3339, value)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &t, nil
+}
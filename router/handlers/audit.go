@@ -0,0 +1,11 @@
+package router
+
+import (
+	auth "wave-messaging-management-service/auth"
+	models "wave-messaging-management-service/models"
+)
+
+// audit : Append action of actor on target to the audit log, with the request ID
+func audit(env *models.Env, actor models.AuditActor, action string, target string, details map[string]string) {
+	auth.Audit(env, models.NewAuditEntry(actor, action, target, details))
+}
@@ -0,0 +1,91 @@
+package router
+
+import (
+	json "encoding/json"
+	errors "errors"
+	http "net/http"
+	pprof "net/http/pprof"
+	runtime "runtime"
+	time "time"
+	auth "wave-messaging-management-service/auth"
+	models "wave-messaging-management-service/models"
+
+	logrus "github.com/sirupsen/logrus"
+	logruswrapper "github.com/terryvogelsang/logruswrapper"
+)
+
+// RuntimeStatsResponse : Go runtime stats of the process, sizes in bytes
+type RuntimeStatsResponse struct {
+	Goroutines   int       `json:"goroutines"`
+	CPUs         int       `json:"cpus"`
+	HeapAlloc    uint64    `json:"heapAlloc"`
+	HeapInuse    uint64    `json:"heapInuse"`
+	HeapObjects  uint64    `json:"heapObjects"`
+	TotalAlloc   uint64    `json:"totalAlloc"`
+	Sys          uint64    `json:"sys"`
+	NumGC        uint32    `json:"numGC"`
+	PauseTotalNs uint64    `json:"pauseTotalNs"`
+	LastGC       time.Time `json:"lastGC"`
+}
+
+// DebugHandler : Profiling (net/http/pprof) and runtime stats endpoints, restricted to operators with an API key granted debug scope.
+// Meant to be served on its own address only (See Debug config)
+func DebugHandler(env *models.Env) http.Handler {
+
+	mux := http.NewServeMux()
+
+	// Index also serves named profiles (heap, goroutine, allocs, block, mutex, threadcreate)
+	mux.HandleFunc("/debug/pprof/", pprof.Index)
+	mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+	mux.HandleFunc("/debug/runtime", RuntimeStats)
+
+	return checkDebugAccess(env, mux)
+}
+
+// checkDebugAccess : Only let requests with an API key granted debug scope reach handler.
+// Profiles expose the whole process, so operators of a tenant can't use them
+func checkDebugAccess(env *models.Env, handler http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+
+		apiKey, err := auth.CheckAPIKey(env, r.Header.Get("apiKey"), models.APIKeyScopeAdminDebug)
+
+		if err == nil && apiKey.TenantID != "" {
+			err = errors.New(logruswrapper.CodeInvalidToken)
+		}
+
+		if err != nil {
+			env.Logger.WithError(err).WithField("path", r.URL.Path).Info("Debug access refused")
+			http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+			return
+		}
+
+		env.Logger.WithFields(logrus.Fields{"service": apiKey.ServiceName, "path": r.URL.Path}).Info("Debug endpoint accessed")
+
+		handler.ServeHTTP(w, r)
+	})
+}
+
+// RuntimeStats : Write Go runtime stats, goroutines and memory usage mostly
+func RuntimeStats(w http.ResponseWriter, r *http.Request) {
+
+	var memStats runtime.MemStats
+	runtime.ReadMemStats(&memStats)
+
+	w.Header().Set("Content-Type", "application/json")
+
+	json.NewEncoder(w).Encode(RuntimeStatsResponse{
+		Goroutines:   runtime.NumGoroutine(),
+		CPUs:         runtime.NumCPU(),
+		HeapAlloc:    memStats.HeapAlloc,
+		HeapInuse:    memStats.HeapInuse,
+		HeapObjects:  memStats.HeapObjects,
+		TotalAlloc:   memStats.TotalAlloc,
+		Sys:          memStats.Sys,
+		NumGC:        memStats.NumGC,
+		PauseTotalNs: memStats.PauseTotalNs,
+		LastGC:       time.Unix(0, int64(memStats.LastGC)).UTC(),
+	})
+}
@@ -0,0 +1,77 @@
+package router
+
+import (
+	json "encoding/json"
+	http "net/http"
+	models "wave-messaging-management-service/models"
+	checkers "wave-messaging-management-service/validation/checkers"
+)
+
+// ErrorResponse : Body of every error response, Code being stable and meant for machines, Message for humans
+type ErrorResponse struct {
+	Code      string                 `json:"code"`
+	Message   string                 `json:"message"`
+	Details   map[string]interface{} `json:"details,omitempty"`
+	RequestID string                 `json:"requestID,omitempty"`
+}
+
+// toHTTPError : Return err as *models.HTTPError. Unexpected errors are internal errors, their message being kept out of responses
+func toHTTPError(err error) *models.HTTPError {
+
+	switch err := err.(type) {
+	case *models.HTTPError:
+		return err
+	case *checkers.ValidationError:
+		return models.NewHTTPError(http.StatusUnauthorized, err.Code, "Token refused").WithDetails(map[string]interface{}{"reason": err.Reason})
+	case *models.QuotaExceededError:
+		return models.NewHTTPError(http.StatusForbidden, models.CodeQuotaExceeded, "Quota exceeded").WithDetails(map[string]interface{}{"userID": err.UserID, "quota": err.Quota, "limit": err.Limit})
+	}
+
+	return internalError("Internal error")
+}
+
+// writeErrorResponse : Answer request with the status of err and the error envelope, holding the request ID
+func writeErrorResponse(w http.ResponseWriter, err error) {
+
+	httpErr := toHTTPError(err)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(httpErr.Status)
+
+	json.NewEncoder(w).Encode(ErrorResponse{
+		Code:      httpErr.Code,
+		Message:   httpErr.Message,
+		Details:   httpErr.Details,
+		RequestID: w.Header().Get(models.RequestIDHeader),
+	})
+}
+
+// invalidRequest : Return error of a malformed or invalid request
+func invalidRequest(message string) *models.HTTPError {
+	return models.NewHTTPError(http.StatusBadRequest, models.CodeInvalidRequest, message)
+}
+
+// invalidToken : Return error of a missing, invalid or revoked user token
+func invalidToken() *models.HTTPError {
+	return models.NewHTTPError(http.StatusUnauthorized, models.CodeInvalidToken, "Invalid token")
+}
+
+// invalidCredentials : Return error of missing or invalid service credentials
+func invalidCredentials(message string) *models.HTTPError {
+	return models.NewHTTPError(http.StatusUnauthorized, models.CodeInvalidCredentials, message)
+}
+
+// forbidden : Return error of a request the authenticated caller is not allowed to perform
+func forbidden(message string) *models.HTTPError {
+	return models.NewHTTPError(http.StatusForbidden, models.CodeForbidden, message)
+}
+
+// notFound : Return error of a request on a missing resource
+func notFound(message string) *models.HTTPError {
+	return models.NewHTTPError(http.StatusNotFound, models.CodeNotFound, message)
+}
+
+// internalError : Return error of a request failed because of the service or one of its dependencies
+func internalError(message string) *models.HTTPError {
+	return models.NewHTTPError(http.StatusInternalServerError, models.CodeInternalError, message)
+}
@@ -0,0 +1,52 @@
+package router
+
+import (
+	sha256 "crypto/sha256"
+	hex "encoding/hex"
+	json "encoding/json"
+	http "net/http"
+	strings "strings"
+)
+
+// notModified : Tag response with a weak ETag of payload, return true if the request If-None-Match header holds it.
+// Request is then answered with a 304 status without body, so that clients polling for changes don't download unchanged payloads.
+// ETags are derived from payload only, which doesn't depend on the instance or the response encoding
+func notModified(w http.ResponseWriter, r *http.Request, payload interface{}) bool {
+
+	data, err := json.Marshal(payload)
+
+	if err != nil {
+		return false
+	}
+
+	hash := sha256.Sum256(data)
+	etag := `W/"` + hex.EncodeToString(hash[:16]) + `"`
+
+	// Responses are specific to the caller, and must be revalidated before being reused
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Cache-Control", "private, no-cache")
+
+	if !matchesETag(r.Header.Get("If-None-Match"), etag) {
+		return false
+	}
+
+	w.WriteHeader(http.StatusNotModified)
+
+	return true
+}
+
+// matchesETag : Check If-None-Match header value lists etag, or is "*". Tags are weakly compared (RFC 7232)
+func matchesETag(ifNoneMatch string, etag string) bool {
+
+	if strings.TrimSpace(ifNoneMatch) == "*" {
+		return true
+	}
+
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		if strings.TrimPrefix(strings.TrimSpace(candidate), "W/") == strings.TrimPrefix(etag, "W/") {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,223 @@
+package router
+
+import (
+	json "encoding/json"
+	fmt "fmt"
+	http "net/http"
+	strconv "strconv"
+	strings "strings"
+	time "time"
+	models "wave-messaging-management-service/models"
+)
+
+const (
+	// LastEventIDHeader : Header of reconnecting server-sent events clients, holding the ID of the last event they received
+	LastEventIDHeader = "Last-Event-ID"
+
+	// eventsRetry : Milliseconds server-sent events clients wait before reconnecting once a stream ends
+	eventsRetry = 1000
+
+	// eventGap : Event telling a stream missed events, lost from history, dashboards should reload their state
+	eventGap = "gap"
+)
+
+// StreamEvents : Stream real-time events of the instance (Audited actions and MQTT client connections) as server-sent events on the admin API.
+// Query parameters : types (Comma separated event types or prefixes, e.g. acl,group.create, all events when empty).
+// Streams end at the handler deadline or when the client falls behind, clients reconnect with the Last-Event-ID header and resume from history
+func StreamEvents(env *models.Env, w http.ResponseWriter, r *http.Request) error {
+
+	env, _, err := checkService(env, r, models.APIKeyScopeAdminEventsRead)
+
+	if err != nil {
+		return err
+	}
+
+	flusher, ok := w.(http.Flusher)
+
+	if !ok || env.Events == nil {
+		return internalError("Event streams are not supported")
+	}
+
+	lastEventID := int64(0)
+
+	if header := r.Header.Get(LastEventIDHeader); header != "" {
+
+		lastEventID, err = strconv.ParseInt(header, 10, 64)
+
+		if err != nil {
+			return invalidRequest("Invalid Last-Event-ID")
+		}
+	}
+
+	types := []string{}
+
+	for _, eventType := range strings.Split(r.URL.Query().Get("types"), ",") {
+		if eventType = strings.TrimSpace(eventType); eventType != "" {
+			types = append(types, eventType)
+		}
+	}
+
+	// Operators of a tenant only see events of their tenant
+	subscription, missed, gap := env.Events.Subscribe(env.TenantID, lastEventID)
+	defer subscription.Close()
+
+	header := w.Header()
+	header.Set("Content-Type", MediaTypeEventStream)
+	header.Set("Cache-Control", "no-cache")
+	// Reverse proxies must not buffer the stream
+	header.Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	stream := &eventStream{w: w, flusher: flusher, types: types}
+
+	// Once the response is started, failures can't be answered : the stream ends, and the client reconnects
+	err = stream.start(gap, lastEventID, missed)
+
+	if err != nil {
+		env.Logger.WithError(err).Debug("Event stream closed")
+		return nil
+	}
+
+	heartbeatInterval := env.Config.Events.HeartbeatInterval
+
+	if heartbeatInterval <= 0 {
+		heartbeatInterval = models.DefaultEventsHeartbeatInterval
+	}
+
+	heartbeat := time.NewTicker(time.Duration(heartbeatInterval) * time.Second)
+	defer heartbeat.Stop()
+
+	done := env.TraceContext().Done()
+
+	for {
+
+		select {
+
+		case <-done:
+			return nil
+
+		case event := <-subscription.Events:
+
+			// Events following a dropped one are left for the reconnected stream, replaying them in order from history
+			if overflow := subscription.Overflowed(); overflow > 0 && event.ID >= overflow {
+				return nil
+			}
+
+			err = stream.send(event)
+
+		case <-heartbeat.C:
+
+			if subscription.Overflowed() > 0 {
+				return nil
+			}
+
+			err = stream.comment("heartbeat")
+		}
+
+		if err != nil {
+			env.Logger.WithError(err).Debug("Event stream closed")
+			return nil
+		}
+	}
+}
+
+// eventStream : Server-sent events stream of events of types (All types when empty)
+type eventStream struct {
+	w       http.ResponseWriter
+	flusher http.Flusher
+	types   []string
+}
+
+// start : Send reconnection delay, gap notice when events following lastEventID were lost, and missed events
+func (stream *eventStream) start(gap bool, lastEventID int64, missed []*models.Event) error {
+
+	_, err := fmt.Fprintf(stream.w, "retry: %d\n\n", eventsRetry)
+
+	if err != nil {
+		return err
+	}
+
+	if gap {
+
+		_, err = fmt.Fprintf(stream.w, "event: %s\ndata: {\"lastEventID\":%d}\n\n", eventGap, lastEventID)
+
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, event := range missed {
+
+		err = stream.write(event)
+
+		if err != nil {
+			return err
+		}
+	}
+
+	stream.flusher.Flush()
+
+	return nil
+}
+
+// send : Send event, if it is one of the stream types
+func (stream *eventStream) send(event *models.Event) error {
+
+	err := stream.write(event)
+
+	if err != nil {
+		return err
+	}
+
+	stream.flusher.Flush()
+
+	return nil
+}
+
+// write : Write event, if it is one of the stream types, without flushing it
+func (stream *eventStream) write(event *models.Event) error {
+
+	if !stream.accepts(event.Type) {
+		return nil
+	}
+
+	data, err := json.Marshal(event)
+
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintf(stream.w, "id: %d\nevent: %s\ndata: %s\n\n", event.ID, event.Type, data)
+
+	return err
+}
+
+// comment : Send comment, ignored by clients but keeping idle connections open through proxies
+func (stream *eventStream) comment(text string) error {
+
+	_, err := fmt.Fprintf(stream.w, ": %s\n\n", text)
+
+	if err != nil {
+		return err
+	}
+
+	stream.flusher.Flush()
+
+	return nil
+}
+
+// accepts : Check eventType is one of the stream types, or starts with one of them followed by a dot
+func (stream *eventStream) accepts(eventType string) bool {
+
+	if len(stream.types) == 0 {
+		return true
+	}
+
+	for _, accepted := range stream.types {
+		if eventType == accepted || strings.HasPrefix(eventType, accepted+".") {
+			return true
+		}
+	}
+
+	return false
+}
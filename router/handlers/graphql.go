@@ -0,0 +1,62 @@
+package router
+
+import (
+	json "encoding/json"
+	http "net/http"
+	graph "wave-messaging-management-service/graph"
+	models "wave-messaging-management-service/models"
+	utils "wave-messaging-management-service/utils"
+	validation "wave-messaging-management-service/validation"
+	checkers "wave-messaging-management-service/validation/checkers"
+)
+
+// QueryGraphQL : Run a GraphQL query for the token owner (See graph.Schema), so that clients fetch their inbox view in one request.
+// Query errors are reported next to the data resolved with a 200 status, as GraphQL clients expect, instead of the standard error envelope
+func QueryGraphQL(env *models.Env, w http.ResponseWriter, r *http.Request) error {
+
+	// Retrieve token from request header
+	token := r.Header.Get("token")
+
+	// Check token with configured validator chain (Error code of failing validator is returned)
+	err := checkers.ValidateToken(env, token)
+
+	if err != nil {
+		return err
+	}
+
+	// Check authentication with provided endpoint
+	MQTTAuthInfos, _, _, err := env.AuthProvider.CheckAuthentication(env, token, r.Header.Get("identityProvider"))
+
+	// If an error occurs, token is invalid
+	if err != nil {
+		return invalidToken()
+	}
+
+	// Data of the token owner lives in its tenant, logs hold its user ID
+	env = userEnv(env, MQTTAuthInfos)
+
+	reqBody := utils.GraphQLBody{}
+
+	err = decodeBody(r, &reqBody)
+
+	if err != nil {
+		return invalidRequest(err.Error())
+	}
+
+	// Check required fields
+	_, err = validation.ValidateStruct(reqBody)
+
+	if err != nil {
+		return invalidRequest(err.Error())
+	}
+
+	response := graph.Exec(env, MQTTAuthInfos.ClientID, reqBody.Query, reqBody.OperationName, reqBody.Variables)
+
+	if len(response.Errors) > 0 {
+		env.Logger.WithField("errors", len(response.Errors)).Info("GraphQL query failed")
+	}
+
+	w.Header().Set("Content-Type", MediaTypeJSON)
+
+	return json.NewEncoder(w).Encode(response)
+}
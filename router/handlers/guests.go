@@ -0,0 +1,38 @@
+package router
+
+import (
+	http "net/http"
+	auth "wave-messaging-management-service/auth"
+	models "wave-messaging-management-service/models"
+
+	gocustomhttpresponse "github.com/terryvogelsang/gocustomhttpresponse"
+	logruswrapper "github.com/terryvogelsang/logruswrapper"
+)
+
+// AddGuest : Provision short-lived, subscribe-only MQTT credentials for an unauthenticated client
+// Only available when guest access is enabled, at the guest rate limit of the client IP
+func AddGuest(env *models.Env, w http.ResponseWriter, r *http.Request) error {
+
+	if retryAfter := checkGuestRateLimit(env, r); retryAfter > 0 {
+		writeRateLimitResponse(w, retryAfter)
+		return nil
+	}
+
+	guestAuthInfos, err := auth.CreateGuest(env)
+
+	if err == auth.ErrGuestAccessDisabled {
+		env.Logger.WithError(err).Info("Guest access refused")
+		return forbidden("Guest access disabled")
+	}
+
+	if err != nil {
+		env.Logger.WithError(err).Error("Failed to create guest")
+		return internalError("Failed to create guest")
+	}
+
+	log := logruswrapper.NewEntry("MessagingService", "/guests", logruswrapper.CodeSuccess)
+
+	gocustomhttpresponse.WriteResponse(guestAuthInfos, log, w)
+
+	return nil
+}
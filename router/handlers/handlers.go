@@ -1,16 +1,22 @@
 package router
 
 import (
-	json "encoding/json"
-	errors "errors"
-	fmt "fmt"
-	log "log"
+	context "context"
 	http "net/http"
+	reflect "reflect"
+	runtime "runtime"
+	strings "strings"
+	time "time"
 	auth "wave-messaging-management-service/auth"
 	models "wave-messaging-management-service/models"
+	notifications "wave-messaging-management-service/notifications"
 	utils "wave-messaging-management-service/utils"
+	validation "wave-messaging-management-service/validation"
 	checkers "wave-messaging-management-service/validation/checkers"
 
+	mux "github.com/gorilla/mux"
+	uuid "github.com/satori/go.uuid"
+	logrus "github.com/sirupsen/logrus"
 	gocustomhttpresponse "github.com/terryvogelsang/gocustomhttpresponse"
 	logruswrapper "github.com/terryvogelsang/logruswrapper"
 )
@@ -26,48 +32,83 @@ func AddVerneMQACL(env *models.Env, w http.ResponseWriter, r *http.Request) erro
 	// Retrieve token from request header
 	token := r.Header.Get("token")
 
-	// Check if token has valid format (According to regex provided by environment variable)
-	tokenHasValidFormat, err := checkers.IsTokenValid(env, token)
+	// Check token with configured validator chain (Error code of failing validator is returned)
+	err := checkers.ValidateToken(env, token)
 
 	if err != nil {
 		return err
 	}
 
-	// If token is not formatted correctly, return an error response
-	if !tokenHasValidFormat {
-		log.Println("Invalid token format")
-		return errors.New(logruswrapper.CodeInvalidToken)
-	}
-
 	// Check authentication with provided endpoint
-	MQTTAuthInfos, wasCached, wasTokenUpdated, err := auth.CheckAuthentication(env, token)
+	MQTTAuthInfos, wasCached, wasTokenUpdated, err := env.AuthProvider.CheckAuthentication(env, token, r.Header.Get("identityProvider"))
 
 	// If an error occurs, token is invalid
 	if err != nil {
-		log.Println(err)
-		return errors.New(logruswrapper.CodeInvalidToken)
+		env.Logger.WithError(err).Info("Authentication failed")
+		return invalidToken()
+	}
+
+	// Data of the token owner lives in its tenant, logs hold its user ID
+	env = userEnv(env, MQTTAuthInfos)
+
+	if wasTokenUpdated || wasCached {
+
+		// Successful authentication renews ACLs expiry date, expired ones are created again
+		exists, err := auth.RenewACLs(env, MQTTAuthInfos.ClientID)
+
+		if err != nil {
+			env.Logger.WithError(err).Error("Failed to renew ACLs")
+			return internalError("Failed to renew ACLs")
+		}
+
+		// Passhash of user ACLs was rotated with the new token : Return refreshed MQTT credentials
+		if exists && wasTokenUpdated {
+
+			log := logruswrapper.NewEntry("MessagingService", "/profiles", logruswrapper.CodeUpdated)
+
+			gocustomhttpresponse.WriteResponse(models.NewMQTTAuthInfos(MQTTAuthInfos.ClientID, token), log, w)
+			return nil
+		}
+
+		if exists {
+			env.Logger.Info("Already cached")
+			return models.NewHTTPError(http.StatusConflict, models.CodeAlreadyExists, "Credentials already provisioned")
+		}
 	}
 
-	if wasTokenUpdated {
-		log.Println("Token Updated")
-		return errors.New(logruswrapper.CodeUpdated)
+	// Private topic path of the user tenant
+	topicPaths, err := env.TopicPaths()
+
+	if err != nil {
+		env.Logger.WithError(err).Error("Invalid topic paths")
+		return internalError("Invalid topic paths")
 	}
 
-	if wasCached {
-		log.Println("Already cached")
-		return errors.New(logruswrapper.CodeAlreadyExists)
+	// Token is only hashed when a new profile ACL stores its passhash, not on every authentication
+	passhash, err := auth.HashPassword(env.Config.Passhash, token)
+
+	if err != nil {
+		env.Logger.WithError(err).Error("Failed to hash token")
+		return internalError("Failed to hash token")
 	}
 
 	// Construct MQTT User ACL with MQTT Auth Infos + default ACLs
-	verneMQACL := models.NewVerneMQACL(MQTTAuthInfos.ClientID, MQTTAuthInfos.Username, MQTTAuthInfos.Password)
+	verneMQACL := models.NewVerneMQACL(topicPaths, MQTTAuthInfos.ClientID, MQTTAuthInfos.Username, passhash)
+	verneMQACL.ExpiresAt = auth.ACLExpiresAt(env)
+
+	entry := models.NewAuditEntry(models.UserActor(MQTTAuthInfos.ClientID), models.AuditACLCreate, MQTTAuthInfos.ClientID, nil)
 
-	err = env.MongoDB.AddProfileACL(verneMQACL)
+	err = env.Store.AddProfileACL(env.TraceContext(), verneMQACL, auth.LifecycleEvents(env, entry)...)
 
 	if err != nil {
-		log.Println(err)
-		return errors.New(logruswrapper.CodeInvalidToken)
+		env.Logger.WithError(err).Error("Failed to add profile ACL")
+		return internalError("Failed to add profile ACL")
 	}
 
+	auth.Audit(env, entry)
+
+	auth.RecordUsage(env, MQTTAuthInfos.ClientID, models.UsageACLs)
+
 	log := logruswrapper.NewEntry("MessagingService", "/helloworld", logruswrapper.CodeSuccess)
 
 	gocustomhttpresponse.WriteResponse(MQTTAuthInfos.ClientID, log, w)
@@ -80,81 +121,114 @@ func AddGroupConversation(env *models.Env, w http.ResponseWriter, r *http.Reques
 	// Retrieve token from request header
 	token := r.Header.Get("token")
 
-	// Check if token has valid format (According to regex provided by environment variable)
-	tokenHasValidFormat, err := checkers.IsTokenValid(env, token)
+	// Check token with configured validator chain (Error code of failing validator is returned)
+	err := checkers.ValidateToken(env, token)
 
 	if err != nil {
 		return err
 	}
 
-	// If token is not formatted correctly, return an error response
-	if !tokenHasValidFormat {
-		return errors.New(logruswrapper.CodeInvalidToken)
-	}
-
 	// Check authentication with provided endpoint
-	MQTTAuthInfos, _, _, err := auth.CheckAuthentication(env, token)
+	MQTTAuthInfos, _, _, err := env.AuthProvider.CheckAuthentication(env, token, r.Header.Get("identityProvider"))
 
 	// If an error occurs, token is invalid
 	if err != nil {
-		return errors.New(logruswrapper.CodeInvalidToken)
+		return invalidToken()
 	}
 
+	// Data of the token owner lives in its tenant, logs hold its user ID
+	env = userEnv(env, MQTTAuthInfos)
+
 	reqBody := utils.GroupConversationBody{}
-	err = json.NewDecoder(r.Body).Decode(&reqBody)
+	err = decodeBody(r, &reqBody)
 
 	if err != nil {
-		return errors.New(logruswrapper.CodeInvalidJSON)
+		return invalidRequest(err.Error())
+	}
+
+	// Members are users of the same identity provider as the request maker
+	identityProvider, _, err := auth.SelectIdentityProvider(&env.Config, r.Header.Get("identityProvider"), token)
+
+	if err != nil {
+		return invalidRequest(err.Error())
 	}
 
 	// create a zero-length slice with the same underlying array
 	tmp := reqBody.Members[:0]
 
-	// Check if provided users exist, if not do not store it in DB
-	for _, member := range reqBody.Members {
-
-		doesExist, err := env.Redis.Exists("mapping:" + member)
+	mappingKeys := make([]string, len(reqBody.Members))
 
-		if err != nil {
-			// TODO: Add code an error occured
-			fmt.Println(err)
-			return errors.New(logruswrapper.CodeInvalidJSON)
-		}
+	for i, member := range reqBody.Members {
+		mappingKeys[i] = "mapping:" + auth.NamespaceUserID(identityProvider, env.TenantID, member)
+	}
 
-		// If user does not exists, remove from mapping
-		if doesExist {
+	// Check if provided users exist and get their internal Wave user ID at once (Atomically, see auth.GetInternalWaveUserIDs), if not do not store it in DB
+	internalWaveUserIDs, err := auth.GetInternalWaveUserIDs(env, mappingKeys)
 
-			internalWaveUserID, err := env.Redis.HGet("mapping:"+member, "internalWaveUserID")
+	if err != nil {
+		env.Logger.WithError(err).Error("Failed to get member mappings")
+		return internalError("Failed to get member mapping")
+	}
 
-			if err != nil {
-				// TODO: Add code an error occured
-				fmt.Println(err)
-				return errors.New(logruswrapper.CodeInvalidJSON)
-			}
+	for _, internalWaveUserID := range internalWaveUserIDs {
 
-			// Remove potential duplicate of emitter user ID
-			if string(internalWaveUserID) != MQTTAuthInfos.ClientID {
-				tmp = append(tmp, string(internalWaveUserID))
-			}
+		// Remove unknown members, and potential duplicate of emitter user ID
+		if internalWaveUserID != "" && internalWaveUserID != MQTTAuthInfos.ClientID {
+			tmp = append(tmp, internalWaveUserID)
 		}
 	}
 
 	// Set group conversation with existing members
 	reqBody.Members = tmp
 
+	// Group topic path of the request maker tenant
+	topicPaths, err := env.TopicPaths()
+
+	if err != nil {
+		env.Logger.WithError(err).Error("Invalid topic paths")
+		return internalError("Invalid topic paths")
+	}
+
+	members := append(reqBody.Members, MQTTAuthInfos.ClientID)
+
+	// Request maker can't go over its conversations quota, nor members over their group memberships quota
+	err = auth.CheckGroupConversationQuotas(env, MQTTAuthInfos.ClientID, members)
+
+	if _, ok := err.(*models.QuotaExceededError); ok {
+		return err
+	}
+
+	if err != nil {
+		env.Logger.WithError(err).Error("Failed to check quotas")
+		return internalError("Failed to check quotas")
+	}
+
 	// Create new group conversation struct
-	groupConv := models.NewGroupConversation(reqBody.Name, append(reqBody.Members, MQTTAuthInfos.ClientID))
+	groupConv := models.NewGroupConversation(reqBody.Name, MQTTAuthInfos.ClientID, members)
+
+	actor := models.UserActor(MQTTAuthInfos.ClientID)
 
-	// Store conversation infos in DB
-	err = env.MongoDB.AddGroupConversation(groupConv)
+	entries := []*models.AuditEntry{models.NewAuditEntry(actor, models.AuditGroupCreate, groupConv.GroupConversationID, map[string]string{"name": groupConv.Name})}
 
-	// Update ACL in DB (Request maker get publish rights on recipient private topic)
-	err = env.MongoDB.UpdateProfilesWithGroupACL(groupConv)
+	for _, member := range groupConv.Members {
+		entries = append(entries, models.NewAuditEntry(actor, models.AuditGroupMemberAdd, member, map[string]string{"conversationID": groupConv.GroupConversationID}))
+	}
+
+	// Store conversation infos and update ACLs in DB (Members get publish rights on the group topic) along with lifecycle events.
+	// Writes are transactional : nothing is left behind when creation fails
+	err = env.Store.CreateGroupConversations(env.TraceContext(), []*models.GroupConversation{groupConv}, topicPaths.Group, auth.LifecycleEvents(env, entries...)...)
 
 	if err != nil {
-		return errors.New(logruswrapper.CodeInvalidToken)
+		env.Logger.WithError(err).Error("Failed to create group conversation")
+		return internalError("Failed to create group conversation")
+	}
+
+	for _, entry := range entries {
+		auth.Audit(env, entry)
 	}
 
+	auth.RecordUsage(env, MQTTAuthInfos.ClientID, models.UsageConversationsCreated)
+
 	log := logruswrapper.NewEntry("MessagingService", "/helloworld", logruswrapper.CodeSuccess)
 
 	gocustomhttpresponse.WriteResponse(nil, log, w)
@@ -162,63 +236,166 @@ func AddGroupConversation(env *models.Env, w http.ResponseWriter, r *http.Reques
 }
 
 // CustomHandle : Custom Handlers Wrapper for API
-func CustomHandle(env *models.Env, handlers ...Handler) http.Handler {
+// Requests are rate limited per endpoint and per token, and revoked tokens refused, before any handler runs.
+// Handlers get an environment of the request (See ForRequest), whose ID is returned in the X-Request-ID response header.
+// Handlers errors are answered with their HTTP status (See errorStatus) and the standard error envelope.
+// Handlers panics are logged with their stack (Reported, see Error Reporting) and answered with a 500 status and the standard error envelope.
+// Requests to idempotent handlers holding an Idempotency-Key are handled once, their response being replayed to retries (See startIdempotentRequest).
+// Request bodies are limited to the configured size of the handler and must be of a media type it consumes, responses of a media type the caller accepts (See negotiateMediaTypes).
+// Handlers are given the configured handler timeout, their environment context being done past it (See Server config).
+// Requests are logged (See logAccess), counted and timed per handler, and traced with a span per request, continuing trace of the caller (See Tracing)
+func CustomHandle(sharedEnv *models.Env, handlers ...Handler) http.Handler {
+	name := handlerName(handlers[len(handlers)-1])
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		recorder := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		w = recorder
+		defer observeRequest(name, r, recorder, start)
+		requestID := ensureRequestID(r)
+		w.Header().Set(models.RequestIDHeader, requestID)
+		ctx, span := startRequestSpan(name, r, requestID)
+		defer endRequestSpan(span, recorder)
+		ctx, cancel := context.WithTimeout(ctx, sharedEnv.CurrentConfig().Server.HandlerTimeoutOf(name))
+		defer cancel()
+		env := sharedEnv.ForRequest(ctx, requestID, name)
+		var failure error
+		defer func() { logAccess(env, name, r, recorder, failure, start) }()
+		defer func() {
+			if recovered := recover(); recovered != nil {
+				failure = recoverHandlerPanic(env, recorder, r, recovered)
+				failRequestSpan(ctx, failure)
+			}
+		}()
+		if retryAfter := checkRateLimit(env, r); retryAfter > 0 {
+			writeRateLimitResponse(w, retryAfter)
+			return
+		}
+		// Oversized bodies are refused upfront when announced, cut while read otherwise
+		maxBodyBytes := env.Config.Server.MaxBodyBytesOf(name)
+		if r.ContentLength > maxBodyBytes {
+			writeBodyTooLargeResponse(w, maxBodyBytes)
+			return
+		}
+		r.Body = http.MaxBytesReader(w, r.Body, maxBodyBytes)
+		if err := negotiateMediaTypes(name, r); err != nil {
+			failure = err
+			writeErrorResponse(w, err)
+			return
+		}
+		if token := r.Header.Get("token"); token != "" {
+			if err := auth.CheckRevocation(env, token, ""); err != nil {
+				env.Logger.WithError(err).Info("Revoked token refused")
+				failRequestSpan(ctx, err)
+				failure = err
+				writeErrorResponse(w, invalidToken())
+				return
+			}
+		}
+		// Retries holding the Idempotency-Key of a completed request get its response replayed
+		idempotent, replayed, err := startIdempotentRequest(env, name, w, r)
+		if err != nil {
+			failRequestSpan(ctx, err)
+			failure = err
+			writeErrorResponse(w, err)
+			return
+		}
+		if replayed {
+			return
+		}
+		if idempotent != nil {
+			w = idempotent.recorder
+			defer idempotent.finish(env)
+		}
 		for _, h := range handlers {
-			err := h(env, w, r)
+			// Chained handlers are not run past the deadline, and handlers failing past it most likely failed because of it
+			err := ctx.Err()
+			if err == nil {
+				err = h(env, w, r)
+			}
 			if err != nil {
-				errorLog := logruswrapper.NewEntry("MessagingService", "/helloworld", err.Error())
-				gocustomhttpresponse.WriteResponse(nil, errorLog, w)
+				failRequestSpan(ctx, err)
+				failure = err
+				if ctx.Err() != nil {
+					writeTimeoutResponse(w)
+					return
+				}
+				writeErrorResponse(w, err)
 				return
 			}
 		}
 	})
 }
 
+// ensureRequestID : Return ID of the request, set from a generated one when the caller did not set one
+func ensureRequestID(r *http.Request) string {
+
+	requestID := r.Header.Get(models.RequestIDHeader)
+
+	if requestID == "" {
+		requestID = uuid.NewV4().String()
+		r.Header.Set(models.RequestIDHeader, requestID)
+	}
+
+	return requestID
+}
+
+// handlerName : Return name of handler function, without its package
+func handlerName(handler Handler) string {
+
+	name := runtime.FuncForPC(reflect.ValueOf(handler).Pointer()).Name()
+
+	return name[strings.LastIndex(name, ".")+1:]
+}
+
+// userEnv : Return environment of an authenticated request : Scoped to the token owner tenant, its logger holding the token owner user ID
+func userEnv(env *models.Env, MQTTAuthInfos *models.MQTTAuthInfos) *models.Env {
+	env.IdentifyCaller(models.UserActor(MQTTAuthInfos.ClientID))
+	return env.ForTenant(MQTTAuthInfos.TenantID).WithLogFields(logrus.Fields{models.LogFieldUserID: MQTTAuthInfos.ClientID})
+}
+
 // GetMappingForUsers : Get internal wave user IDs
 func GetMappingForUsers(env *models.Env, w http.ResponseWriter, r *http.Request) error {
 
 	// Retrieve token from request header
 	token := r.Header.Get("token")
 
-	// Check if token has valid format (According to regex provided by environment variable)
-	tokenHasValidFormat, err := checkers.IsTokenValid(env, token)
+	// Check token with configured validator chain (Error code of failing validator is returned)
+	err := checkers.ValidateToken(env, token)
 
 	if err != nil {
 		return err
 	}
 
-	// If token is not formatted correctly, return an error response
-	if !tokenHasValidFormat {
-		return errors.New(logruswrapper.CodeInvalidToken)
-	}
-
 	// Check authentication with provided endpoint
-	_, _, _, err = auth.CheckAuthentication(env, token)
+	MQTTAuthInfos, _, _, err := env.AuthProvider.CheckAuthentication(env, token, r.Header.Get("identityProvider"))
 
 	// If an error occurs, token is invalid
 	if err != nil {
-		return errors.New(logruswrapper.CodeInvalidToken)
+		return invalidToken()
 	}
 
+	// Data of the token owner lives in its tenant, logs hold its user ID
+	env = userEnv(env, MQTTAuthInfos)
+
 	reqBody := utils.MappingRequestBody{}
 
-	err = json.NewDecoder(r.Body).Decode(&reqBody)
+	err = decodeBody(r, &reqBody)
 
 	if err != nil {
-		return errors.New(logruswrapper.CodeInvalidJSON)
+		return invalidRequest(err.Error())
 	}
 
-	mappings := []models.Mapping{}
+	// Users are looked up within the identity provider of the request maker
+	identityProvider, _, err := auth.SelectIdentityProvider(&env.Config, r.Header.Get("identityProvider"), token)
 
-	for _, userID := range reqBody.UserIDs {
+	if err != nil {
+		return invalidRequest(err.Error())
+	}
 
-		internalWaveUserID, _ := env.Redis.HGet("mapping:"+userID, "internalWaveUserID")
+	mappings, err := getMappings(env, identityProvider, reqBody.UserIDs)
 
-		fmt.Println(string(internalWaveUserID))
-		if string(internalWaveUserID) != "" {
-			mappings = append(mappings, models.Mapping{OriginalUserID: userID, InternalWaveUserID: string(internalWaveUserID)})
-		}
+	if err != nil {
+		return err
 	}
 
 	log := logruswrapper.NewEntry("MessagingService", "/profiles/mappings", logruswrapper.CodeSuccess)
@@ -227,3 +404,423 @@ func GetMappingForUsers(env *models.Env, w http.ResponseWriter, r *http.Request)
 
 	return nil
 }
+
+// getMappings : Get internal wave user IDs of mapped application users of identity provider within tenant of env, unknown users are skipped.
+// Mappings are fetched pipelined (See models.Redis.Pipeline). Users whose mapping could not be fetched are listed in the details of the returned error
+func getMappings(env *models.Env, identityProvider *models.IdentityProviderConfig, userIDs []string) ([]models.Mapping, error) {
+
+	mappings, err := auth.GetMappings(env, identityProvider, userIDs)
+
+	if mappingsErr, ok := err.(*auth.MappingsError); ok {
+		return nil, internalError("Failed to get mappings of some users").WithDetails(map[string]interface{}{"failedUserIDs": mappingsErr.FailedUserIDs})
+	}
+
+	if err != nil {
+		env.Logger.WithError(err).Error("Failed to get mappings")
+		return nil, internalError("Failed to get mappings")
+	}
+
+	return mappings, nil
+}
+
+// ForceLogout : Invalidate MQTT credentials of the token owner and disconnect its active sessions
+// Should be used when a token is compromised
+func ForceLogout(env *models.Env, w http.ResponseWriter, r *http.Request) error {
+
+	// Retrieve token from request header
+	token := r.Header.Get("token")
+
+	// Check token with configured validator chain (Error code of failing validator is returned)
+	err := checkers.ValidateToken(env, token)
+
+	if err != nil {
+		return err
+	}
+
+	// Check authentication with provided endpoint
+	MQTTAuthInfos, _, _, err := env.AuthProvider.CheckAuthentication(env, token, r.Header.Get("identityProvider"))
+
+	// If an error occurs, token is invalid
+	if err != nil {
+		return invalidToken()
+	}
+
+	// Data of the token owner lives in its tenant, logs hold its user ID
+	env = userEnv(env, MQTTAuthInfos)
+
+	entry := models.NewAuditEntry(models.UserActor(MQTTAuthInfos.ClientID), models.AuditCredentialsRevoke, MQTTAuthInfos.ClientID, nil)
+
+	// Deny token until it expires, so that authenticating with it again doesn't restore credentials
+	err = auth.DenyToken(env, token)
+
+	if err != nil {
+		env.Logger.WithError(err).Error("Failed to deny token")
+		return internalError("Failed to revoke credentials")
+	}
+
+	// Rotate passhash, revoke cached token and disconnect sessions
+	err = auth.RevokeCredentials(env, MQTTAuthInfos.ClientID, token, auth.LifecycleEvents(env, entry)...)
+
+	if err != nil {
+		env.Logger.WithError(err).Error("Failed to revoke credentials")
+		return internalError("Failed to revoke credentials")
+	}
+
+	auth.Audit(env, entry)
+
+	log := logruswrapper.NewEntry("MessagingService", "/profiles/logout", logruswrapper.CodeSuccess)
+
+	gocustomhttpresponse.WriteResponse(nil, log, w)
+
+	return nil
+}
+
+// RegisterDevice : Register an additional device for the token owner.
+// Device gets its own MQTT client ID while sharing credentials and conversation ACLs of the user
+func RegisterDevice(env *models.Env, w http.ResponseWriter, r *http.Request) error {
+
+	// Retrieve token from request header
+	token := r.Header.Get("token")
+
+	// Check token with configured validator chain (Error code of failing validator is returned)
+	err := checkers.ValidateToken(env, token)
+
+	if err != nil {
+		return err
+	}
+
+	// Check authentication with provided endpoint
+	MQTTAuthInfos, _, _, err := env.AuthProvider.CheckAuthentication(env, token, r.Header.Get("identityProvider"))
+
+	// If an error occurs, token is invalid
+	if err != nil {
+		return invalidToken()
+	}
+
+	// Data of the token owner lives in its tenant, logs hold its user ID
+	env = userEnv(env, MQTTAuthInfos)
+
+	reqBody := utils.DeviceBody{}
+	err = decodeBody(r, &reqBody)
+
+	if err != nil {
+		return invalidRequest(err.Error())
+	}
+
+	// Device ACL is derived from main profile ACL
+	profileACL, err := env.Store.GetProfileACL(env.TraceContext(), MQTTAuthInfos.ClientID)
+
+	if err != nil {
+		env.Logger.WithError(err).Error("Failed to get profile ACL")
+		return internalError("Failed to get profile ACL")
+	}
+
+	deviceACL := models.NewDeviceVerneMQACL(profileACL, uuid.NewV4().String(), reqBody.DeviceName)
+
+	err = env.Store.AddProfileACL(env.TraceContext(), deviceACL)
+
+	if err != nil {
+		env.Logger.WithError(err).Error("Failed to add device ACL")
+		return internalError("Failed to add device ACL")
+	}
+
+	audit(env, models.UserActor(MQTTAuthInfos.ClientID), models.AuditDeviceRegister, deviceACL.ClientID, map[string]string{"deviceName": reqBody.DeviceName})
+
+	auth.RecordUsage(env, MQTTAuthInfos.ClientID, models.UsageACLs)
+
+	log := logruswrapper.NewEntry("MessagingService", "/profiles/devices", logruswrapper.CodeSuccess)
+
+	// Devices connect with the token of the user, matching the passhash shared with the profile ACL (Never answer the passhash itself)
+	gocustomhttpresponse.WriteResponse(models.NewDeviceMQTTAuthInfos(deviceACL.ClientID, MQTTAuthInfos.ClientID, token), log, w)
+
+	return nil
+}
+
+// DeregisterDevice : Remove one device of the token owner and disconnect its active session
+func DeregisterDevice(env *models.Env, w http.ResponseWriter, r *http.Request) error {
+
+	// Retrieve token from request header
+	token := r.Header.Get("token")
+
+	// Check token with configured validator chain (Error code of failing validator is returned)
+	err := checkers.ValidateToken(env, token)
+
+	if err != nil {
+		return err
+	}
+
+	// Check authentication with provided endpoint
+	MQTTAuthInfos, _, _, err := env.AuthProvider.CheckAuthentication(env, token, r.Header.Get("identityProvider"))
+
+	// If an error occurs, token is invalid
+	if err != nil {
+		return invalidToken()
+	}
+
+	// Data of the token owner lives in its tenant, logs hold its user ID
+	env = userEnv(env, MQTTAuthInfos)
+
+	deviceClientID := mux.Vars(r)["clientID"]
+
+	entry := models.NewAuditEntry(models.UserActor(MQTTAuthInfos.ClientID), models.AuditDeviceDeregister, deviceClientID, nil)
+
+	// Only devices owned by the token owner can be removed
+	err = env.Store.RemoveDeviceACL(env.TraceContext(), MQTTAuthInfos.ClientID, deviceClientID, auth.LifecycleEvents(env, entry)...)
+
+	if err != nil {
+		env.Logger.WithError(err).WithField("clientID", deviceClientID).Error("Failed to remove device ACL")
+		return internalError("Failed to remove device ACL")
+	}
+
+	auth.Audit(env, entry)
+
+	// Revoke device access immediately
+	err = env.Broker.DisconnectSession(deviceClientID)
+
+	if err != nil {
+		env.Logger.WithError(err).WithField("clientID", deviceClientID).Warn("Failed to disconnect device session")
+	}
+
+	log := logruswrapper.NewEntry("MessagingService", "/profiles/devices", logruswrapper.CodeSuccess)
+
+	gocustomhttpresponse.WriteResponse(nil, log, w)
+
+	return nil
+}
+
+// RegisterPushToken : Register FCM/APNs push token of one of the token owner devices
+func RegisterPushToken(env *models.Env, w http.ResponseWriter, r *http.Request) error {
+
+	// Retrieve token from request header
+	token := r.Header.Get("token")
+
+	// Check token with configured validator chain (Error code of failing validator is returned)
+	err := checkers.ValidateToken(env, token)
+
+	if err != nil {
+		return err
+	}
+
+	// Check authentication with provided endpoint
+	MQTTAuthInfos, _, _, err := env.AuthProvider.CheckAuthentication(env, token, r.Header.Get("identityProvider"))
+
+	// If an error occurs, token is invalid
+	if err != nil {
+		return invalidToken()
+	}
+
+	// Data of the token owner lives in its tenant, logs hold its user ID
+	env = userEnv(env, MQTTAuthInfos)
+
+	reqBody := utils.PushTokenBody{}
+	err = decodeBody(r, &reqBody)
+
+	if err != nil {
+		return invalidRequest(err.Error())
+	}
+
+	// Check required fields and supported platforms
+	_, err = validation.ValidateStruct(reqBody)
+
+	if err != nil {
+		return invalidRequest(err.Error())
+	}
+
+	// Web Push subscriptions can't be used without their encryption keys
+	if reqBody.Platform == models.PushPlatformWebPush && (reqBody.Keys.P256dh == "" || reqBody.Keys.Auth == "") {
+		return invalidRequest("Web Push subscriptions require p256dh and auth keys")
+	}
+
+	// Web Push subscription endpoints are called by the service, they can't target internal hosts
+	if reqBody.Platform == models.PushPlatformWebPush {
+
+		err = notifications.ValidateWebPushEndpoint(reqBody.Token)
+
+		if err != nil {
+			return invalidRequest(err.Error())
+		}
+	}
+
+	// Push token can only be bound to one of the devices of the token owner
+	if reqBody.DeviceClientID != "" {
+
+		verneMQACLs, err := env.Store.GetUserACLs(env.TraceContext(), MQTTAuthInfos.ClientID)
+
+		if err != nil {
+			env.Logger.WithError(err).Error("Failed to get user ACLs")
+			return internalError("Failed to get user ACLs")
+		}
+
+		if !hasClientACL(verneMQACLs, reqBody.DeviceClientID) {
+			return forbidden("Device does not belong to the token owner")
+		}
+	}
+
+	pushToken := models.NewPushToken(MQTTAuthInfos.ClientID, reqBody.Token, reqBody.Platform, reqBody.DeviceClientID, reqBody.AppVersion, reqBody.Locale)
+	pushToken.P256dh = reqBody.Keys.P256dh
+	pushToken.Auth = reqBody.Keys.Auth
+
+	err = env.Store.AddPushToken(env.TraceContext(), pushToken)
+
+	// Token of a device of another user, which must unregister it first
+	if err == models.ErrPushTokenTaken {
+		return models.NewHTTPError(http.StatusConflict, models.CodeAlreadyExists, err.Error())
+	}
+
+	if err != nil {
+		env.Logger.WithError(err).Error("Failed to add push token")
+		return internalError("Failed to add push token")
+	}
+
+	log := logruswrapper.NewEntry("MessagingService", "/profiles/pushtokens", logruswrapper.CodeSuccess)
+
+	gocustomhttpresponse.WriteResponse(nil, log, w)
+
+	return nil
+}
+
+// hasClientACL : Return whether verneMQACLs hold the ACL of clientID
+func hasClientACL(verneMQACLs []*models.VerneMQACL, clientID string) bool {
+
+	for _, verneMQACL := range verneMQACLs {
+		if verneMQACL.ClientID == clientID {
+			return true
+		}
+	}
+
+	return false
+}
+
+// UnregisterPushToken : Remove FCM/APNs push token of one of the token owner devices
+func UnregisterPushToken(env *models.Env, w http.ResponseWriter, r *http.Request) error {
+
+	// Retrieve token from request header
+	token := r.Header.Get("token")
+
+	// Check token with configured validator chain (Error code of failing validator is returned)
+	err := checkers.ValidateToken(env, token)
+
+	if err != nil {
+		return err
+	}
+
+	// Check authentication with provided endpoint
+	MQTTAuthInfos, _, _, err := env.AuthProvider.CheckAuthentication(env, token, r.Header.Get("identityProvider"))
+
+	// If an error occurs, token is invalid
+	if err != nil {
+		return invalidToken()
+	}
+
+	// Data of the token owner lives in its tenant, logs hold its user ID
+	env = userEnv(env, MQTTAuthInfos)
+
+	// Only push tokens owned by the token owner can be removed
+	err = env.Store.RemovePushToken(env.TraceContext(), MQTTAuthInfos.ClientID, mux.Vars(r)["pushToken"])
+
+	if err != nil {
+		env.Logger.WithError(err).Error("Failed to remove push token")
+		return internalError("Failed to remove push token")
+	}
+
+	log := logruswrapper.NewEntry("MessagingService", "/profiles/pushtokens", logruswrapper.CodeSuccess)
+
+	gocustomhttpresponse.WriteResponse(nil, log, w)
+
+	return nil
+}
+
+// GetNotificationPreferences : Get push notifications settings of the token owner
+func GetNotificationPreferences(env *models.Env, w http.ResponseWriter, r *http.Request) error {
+
+	// Retrieve token from request header
+	token := r.Header.Get("token")
+
+	// Check token with configured validator chain (Error code of failing validator is returned)
+	err := checkers.ValidateToken(env, token)
+
+	if err != nil {
+		return err
+	}
+
+	// Check authentication with provided endpoint
+	MQTTAuthInfos, _, _, err := env.AuthProvider.CheckAuthentication(env, token, r.Header.Get("identityProvider"))
+
+	// If an error occurs, token is invalid
+	if err != nil {
+		return invalidToken()
+	}
+
+	// Data of the token owner lives in its tenant, logs hold its user ID
+	env = userEnv(env, MQTTAuthInfos)
+
+	preferences, err := env.Store.GetNotificationPreferences(env.TraceContext(), MQTTAuthInfos.ClientID)
+
+	if err != nil {
+		env.Logger.WithError(err).Error("Failed to get notification preferences")
+		return internalError("Failed to get notification preferences")
+	}
+
+	if notModified(w, r, preferences) {
+		return nil
+	}
+
+	log := logruswrapper.NewEntry("MessagingService", "/profiles/notifications/preferences", logruswrapper.CodeSuccess)
+
+	gocustomhttpresponse.WriteResponse(preferences, log, w)
+
+	return nil
+}
+
+// SetNotificationPreferences : Replace push notifications settings of the token owner
+func SetNotificationPreferences(env *models.Env, w http.ResponseWriter, r *http.Request) error {
+
+	// Retrieve token from request header
+	token := r.Header.Get("token")
+
+	// Check token with configured validator chain (Error code of failing validator is returned)
+	err := checkers.ValidateToken(env, token)
+
+	if err != nil {
+		return err
+	}
+
+	// Check authentication with provided endpoint
+	MQTTAuthInfos, _, _, err := env.AuthProvider.CheckAuthentication(env, token, r.Header.Get("identityProvider"))
+
+	// If an error occurs, token is invalid
+	if err != nil {
+		return invalidToken()
+	}
+
+	// Data of the token owner lives in its tenant, logs hold its user ID
+	env = userEnv(env, MQTTAuthInfos)
+
+	preferences := models.NewNotificationPreferences(MQTTAuthInfos.ClientID)
+
+	err = decodeBody(r, preferences)
+
+	if err != nil {
+		return invalidRequest(err.Error())
+	}
+
+	// Check quiet hours format
+	err = preferences.Validate()
+
+	if err != nil {
+		return invalidRequest(err.Error())
+	}
+
+	err = env.Store.SetNotificationPreferences(env.TraceContext(), preferences)
+
+	if err != nil {
+		env.Logger.WithError(err).Error("Failed to set notification preferences")
+		return internalError("Failed to set notification preferences")
+	}
+
+	log := logruswrapper.NewEntry("MessagingService", "/profiles/notifications/preferences", logruswrapper.CodeSuccess)
+
+	gocustomhttpresponse.WriteResponse(preferences, log, w)
+
+	return nil
+}
@@ -0,0 +1,138 @@
+package router
+
+import (
+	context "context"
+	json "encoding/json"
+	http "net/http"
+	sync "sync"
+	time "time"
+	auth "wave-messaging-management-service/auth"
+	models "wave-messaging-management-service/models"
+)
+
+const (
+	// HealthStatusOK : Status of a healthy process
+	HealthStatusOK = "ok"
+
+	// HealthStatusUnavailable : Status of a process which can't serve requests, as one of its dependencies is down
+	HealthStatusUnavailable = "unavailable"
+
+	// DependencyStatusUp : Status of a dependency which answered in time
+	DependencyStatusUp = "up"
+
+	// DependencyStatusDegraded : Status of a dependency which answered in time, but slower than the degraded latency
+	DependencyStatusDegraded = "degraded"
+
+	// DependencyStatusDown : Status of a dependency which failed or did not answer in time
+	DependencyStatusDown = "down"
+)
+
+var (
+	// startedAt : Time the process started serving, reported as uptime
+	startedAt = time.Now()
+)
+
+// HealthResponse : Body of liveness endpoint
+type HealthResponse struct {
+	Status string `json:"status"`
+	Uptime int64  `json:"uptime"`
+}
+
+// ReadinessResponse : Body of readiness endpoint, with status of each checked dependency
+type ReadinessResponse struct {
+	Status       string                       `json:"status"`
+	Dependencies map[string]*DependencyStatus `json:"dependencies"`
+}
+
+// DependencyStatus : Status of a dependency and milliseconds it took to answer
+type DependencyStatus struct {
+	Status  string `json:"status"`
+	Latency int64  `json:"latency"`
+}
+
+// Healthz : Liveness probe, process is alive as long as it answers.
+// Dependencies are not checked, so that their outage does not get the container restarted
+func Healthz(w http.ResponseWriter, r *http.Request) {
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(http.StatusOK)
+
+	json.NewEncoder(w).Encode(HealthResponse{Status: HealthStatusOK, Uptime: int64(time.Since(startedAt).Seconds())})
+}
+
+// Readyz : Readiness probe, checking the store (Named after its backend), Redis, store indexes creation and, if configured, the external authentication endpoint concurrently.
+// Answers 503 when any of them is down, so that orchestrators stop routing traffic to the instance, degraded ones being ready. Failures are logged, not returned
+func Readyz(env *models.Env) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		timeout := env.Config.Readiness.TimeoutDuration()
+
+		ctx, cancel := context.WithTimeout(r.Context(), timeout)
+		defer cancel()
+
+		// Store is named after its backend
+		store := env.Config.Datastores.StoreBackend()
+
+		checks := map[string]func() error{
+			store:     func() error { return env.Store.Ping(ctx) },
+			"redis":   func() error { return env.Redis.Ping(ctx) },
+			"indexes": func() error { return env.Indexes.Err() },
+		}
+
+		if env.Config.Readiness.CheckAuthEndpoint && env.Config.AuthenticationCheckEndpoint != "" {
+			checks["authEndpoint"] = func() error { return auth.PingAuthenticationEndpoint(env, timeout) }
+		}
+
+		response := &ReadinessResponse{Status: HealthStatusOK, Dependencies: map[string]*DependencyStatus{}}
+
+		var mutex sync.Mutex
+		var wg sync.WaitGroup
+
+		for name, check := range checks {
+
+			wg.Add(1)
+
+			go func(name string, check func() error) {
+
+				defer wg.Done()
+
+				start := time.Now()
+				err := check()
+				latency := time.Since(start)
+
+				dependency := &DependencyStatus{Status: DependencyStatusUp, Latency: int64(latency / time.Millisecond)}
+
+				if err != nil {
+					env.Logger.WithError(err).WithField("dependency", name).Warn("Readiness check failed")
+					dependency.Status = DependencyStatusDown
+				} else if env.Config.Readiness.Degraded(latency) {
+					dependency.Status = DependencyStatusDegraded
+				}
+
+				mutex.Lock()
+				defer mutex.Unlock()
+
+				response.Dependencies[name] = dependency
+
+				if err != nil {
+					response.Status = HealthStatusUnavailable
+				}
+			}(name, check)
+		}
+
+		wg.Wait()
+
+		status := http.StatusOK
+
+		if response.Status != HealthStatusOK {
+			status = http.StatusServiceUnavailable
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "no-store")
+		w.WriteHeader(status)
+
+		json.NewEncoder(w).Encode(response)
+	}
+}
@@ -0,0 +1,208 @@
+package router
+
+import (
+	bytes "bytes"
+	sha256 "crypto/sha256"
+	hex "encoding/hex"
+	json "encoding/json"
+	ioutil "io/ioutil"
+	http "net/http"
+	time "time"
+	models "wave-messaging-management-service/models"
+)
+
+const (
+	// IdempotencyKeyHeader : HTTP header holding the client generated key of a request, identical across its retries
+	IdempotencyKeyHeader = "Idempotency-Key"
+
+	// IdempotentReplayedHeader : HTTP header set on responses replayed for a retried request
+	IdempotentReplayedHeader = "Idempotent-Replayed"
+
+	// maxIdempotencyKeyLength : Maximum length of idempotency keys
+	maxIdempotencyKeyLength = 255
+)
+
+// idempotencyReserveScript : Reserve key for a request in progress, unless already reserved or completed.
+// Return {1} if key was reserved, {0} otherwise
+const idempotencyReserveScript = `
+if redis.call("SET", KEYS[1], ARGV[1], "NX", "EX", ARGV[2]) then
+	return {1}
+end
+
+return {0}
+`
+
+// IdempotentHandlers : Handlers accepting the Idempotency-Key header, the ones creating or changing ACLs and conversations
+var IdempotentHandlers = map[string]bool{
+	"AddVerneMQACL":                true,
+	"AddGroupConversation":         true,
+	"AddServiceGroupConversations": true,
+	"RegisterDevice":               true,
+	"DeregisterDevice":             true,
+	"AuthorizeServicePublishing":   true,
+	"SuspendUser":                  true,
+}
+
+// IdempotentResponse : Request stored under its idempotency key, with its response once completed
+type IdempotentResponse struct {
+	Fingerprint string `json:"fingerprint"`
+	Completed   bool   `json:"completed"`
+	Status      int    `json:"status,omitempty"`
+	ContentType string `json:"contentType,omitempty"`
+	Body        []byte `json:"body,omitempty"`
+}
+
+// idempotentRequest : Request holding an idempotency key, its response being recorded to be stored
+type idempotentRequest struct {
+	key         string
+	fingerprint string
+	recorder    *responseRecorder
+}
+
+// responseRecorder : ResponseWriter keeping a copy of the response
+type responseRecorder struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+// WriteHeader : Remember status code and write it
+func (recorder *responseRecorder) WriteHeader(status int) {
+	if recorder.status == 0 {
+		recorder.status = status
+	}
+	recorder.ResponseWriter.WriteHeader(status)
+}
+
+// Write : Keep a copy of body and write it
+func (recorder *responseRecorder) Write(data []byte) (int, error) {
+	if recorder.status == 0 {
+		recorder.status = http.StatusOK
+	}
+	recorder.body.Write(data)
+	return recorder.ResponseWriter.Write(data)
+}
+
+// startIdempotentRequest : Reserve idempotency key of a request to an idempotent handler.
+// Return the request to be finished once handled, nil without key. Return true if the stored response of a completed request was replayed instead.
+// Keys are scoped by handler and caller, and refused if in progress or used by a different request (Method, path and body).
+// Redis failures must not block the API, request is then handled without idempotency
+func startIdempotentRequest(env *models.Env, handler string, w http.ResponseWriter, r *http.Request) (*idempotentRequest, bool, error) {
+
+	idempotencyKey := r.Header.Get(IdempotencyKeyHeader)
+
+	if idempotencyKey == "" || !IdempotentHandlers[handler] {
+		return nil, false, nil
+	}
+
+	if len(idempotencyKey) > maxIdempotencyKeyLength {
+		return nil, false, invalidRequest("Idempotency-Key too long")
+	}
+
+	body, err := ioutil.ReadAll(r.Body)
+
+	if err != nil {
+		return nil, false, invalidRequest(err.Error())
+	}
+
+	// Restore body for handlers
+	r.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+	keyHash := sha256.Sum256([]byte(idempotencyKey))
+	fingerprint := sha256.Sum256(append([]byte(r.Method+" "+r.URL.RequestURI()+"\n"), body...))
+
+	request := &idempotentRequest{
+		key:         "idempotency:" + handler + ":" + rateLimitCaller(env, r) + ":" + hex.EncodeToString(keyHash[:]),
+		fingerprint: hex.EncodeToString(fingerprint[:]),
+		recorder:    &responseRecorder{ResponseWriter: w},
+	}
+
+	inProgress, _ := json.Marshal(IdempotentResponse{Fingerprint: request.fingerprint})
+
+	// Reservation outlives the request, so that a crashed instance doesn't hold the key for good
+	reservationTTL := int(env.Config.Server.HandlerTimeoutOf(handler)/time.Second) + 1
+
+	result, err := env.Redis.EvalInts(env.TraceContext(), idempotencyReserveScript, []string{request.key}, inProgress, reservationTTL)
+
+	if err != nil || len(result) != 1 {
+		env.Logger.WithError(err).Error("Failed to reserve idempotency key")
+		return nil, false, nil
+	}
+
+	if result[0] == 1 {
+		return request, false, nil
+	}
+
+	data, err := env.Redis.Get(env.TraceContext(), request.key)
+
+	stored := &IdempotentResponse{}
+
+	if err == nil {
+		err = json.Unmarshal(data, stored)
+	}
+
+	// Reservation expired in between
+	if err != nil {
+		env.Logger.WithError(err).Error("Failed to get idempotent response")
+		return nil, false, nil
+	}
+
+	if stored.Fingerprint != request.fingerprint {
+		return nil, false, models.NewHTTPError(http.StatusUnprocessableEntity, models.CodeIdempotencyKeyReused, "Idempotency-Key used by a different request")
+	}
+
+	if !stored.Completed {
+		return nil, false, models.NewHTTPError(http.StatusConflict, models.CodeIdempotencyInProgress, "Request with the same Idempotency-Key in progress")
+	}
+
+	env.Logger.WithField("status", stored.Status).Info("Idempotent response replayed")
+
+	w.Header().Set(IdempotentReplayedHeader, "true")
+
+	if stored.ContentType != "" {
+		w.Header().Set("Content-Type", stored.ContentType)
+	}
+
+	w.WriteHeader(stored.Status)
+	w.Write(stored.Body)
+
+	return nil, true, nil
+}
+
+// finish : Store response of request to be replayed on retries for the configured TTL.
+// Failed requests (5xx statuses, panics) release the key instead, so that they can be retried
+func (request *idempotentRequest) finish(env *models.Env) {
+
+	status := request.recorder.status
+
+	if status == 0 || status >= http.StatusInternalServerError {
+
+		err := env.Redis.Delete(env.TraceContext(), request.key)
+
+		if err != nil {
+			env.Logger.WithError(err).Error("Failed to release idempotency key")
+		}
+
+		return
+	}
+
+	data, _ := json.Marshal(IdempotentResponse{
+		Fingerprint: request.fingerprint,
+		Completed:   true,
+		Status:      status,
+		ContentType: request.recorder.Header().Get("Content-Type"),
+		Body:        request.recorder.body.Bytes(),
+	})
+
+	ttl := env.Config.Idempotency.TTL
+
+	if ttl <= 0 {
+		ttl = models.DefaultIdempotencyTTL
+	}
+
+	err := env.Redis.SetEx(env.TraceContext(), request.key, data, ttl)
+
+	if err != nil {
+		env.Logger.WithError(err).Error("Failed to store idempotent response")
+	}
+}
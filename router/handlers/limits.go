@@ -0,0 +1,19 @@
+package router
+
+import (
+	http "net/http"
+	models "wave-messaging-management-service/models"
+)
+
+// writeTimeoutResponse : Answer request past its handler timeout with a 503 status
+func writeTimeoutResponse(w http.ResponseWriter) {
+	writeErrorResponse(w, models.NewHTTPError(http.StatusServiceUnavailable, models.CodeTimeout, "Request timeout"))
+}
+
+// writeBodyTooLargeResponse : Refuse request whose body exceeds maxBodyBytes with a 413 status
+func writeBodyTooLargeResponse(w http.ResponseWriter, maxBodyBytes int64) {
+
+	w.Header().Set("Connection", "close")
+
+	writeErrorResponse(w, models.NewHTTPError(http.StatusRequestEntityTooLarge, models.CodeRequestTooLarge, "Request body too large").WithDetails(map[string]interface{}{"maxBodyBytes": maxBodyBytes}))
+}
@@ -0,0 +1,46 @@
+package router
+
+import (
+	http "net/http"
+	strconv "strconv"
+	time "time"
+	models "wave-messaging-management-service/models"
+)
+
+// statusRecorder : ResponseWriter remembering status code of the response, 200 unless set, and whether it was started
+type statusRecorder struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+}
+
+// WriteHeader : Remember status code and write it
+func (recorder *statusRecorder) WriteHeader(status int) {
+	if !recorder.wroteHeader {
+		recorder.status = status
+		recorder.wroteHeader = true
+	}
+	recorder.ResponseWriter.WriteHeader(status)
+}
+
+// Write : Remember response was started and write body
+func (recorder *statusRecorder) Write(data []byte) (int, error) {
+	recorder.wroteHeader = true
+	return recorder.ResponseWriter.Write(data)
+}
+
+// Flush : Send buffered data to the client, when the underlying ResponseWriter supports it (Streamed responses)
+func (recorder *statusRecorder) Flush() {
+
+	recorder.wroteHeader = true
+
+	if flusher, ok := recorder.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// observeRequest : Count request handled by handler and record its duration since start
+func observeRequest(handler string, r *http.Request, recorder *statusRecorder, start time.Time) {
+	models.HTTPRequests.WithLabelValues(handler, r.Method, strconv.Itoa(recorder.status)).Inc()
+	models.HTTPRequestDuration.WithLabelValues(handler).Observe(time.Since(start).Seconds())
+}
@@ -0,0 +1,186 @@
+package router
+
+import (
+	errors "errors"
+	io "io"
+	mime "mime"
+	http "net/http"
+	strconv "strconv"
+	strings "strings"
+	models "wave-messaging-management-service/models"
+	validation "wave-messaging-management-service/validation"
+)
+
+const (
+	// MediaTypeJSON : Media type of JSON bodies
+	MediaTypeJSON = "application/json"
+
+	// MediaTypeEventStream : Media type of server-sent events streams (See StreamEvents)
+	MediaTypeEventStream = "text/event-stream"
+)
+
+// mediaTypes : Media types of request bodies an endpoint consumes and of responses it produces, by order of preference
+type mediaTypes struct {
+	consumes []string
+	produces []string
+}
+
+// decoder : Decode request body into v
+type decoder func(body io.Reader, v interface{}) error
+
+var (
+	// errUnsupportedMediaType : Media type of request body has no decoder
+	errUnsupportedMediaType = errors.New("Unsupported Content-Type")
+
+	// defaultMediaTypes : Media types of endpoints missing from handlerMediaTypes
+	defaultMediaTypes = mediaTypes{consumes: []string{MediaTypeJSON}, produces: []string{MediaTypeJSON}}
+
+	// handlerMediaTypes : Media types of endpoints, by handler name, supporting other formats than JSON (e.g. MessagePack).
+	// Formats consumed need a decoder in decoders
+	handlerMediaTypes = map[string]mediaTypes{
+		"StreamEvents": {consumes: []string{MediaTypeJSON}, produces: []string{MediaTypeEventStream}},
+	}
+
+	// decoders : Request body decoders by media type
+	decoders = map[string]decoder{
+		MediaTypeJSON: validation.DecodeJSON,
+	}
+)
+
+// negotiateMediaTypes : Check Content-Type of request body is consumed by handler, and Accept header allows one of the media types it produces.
+// Requests without body are not checked for their Content-Type, requests without Accept header accept any media type
+func negotiateMediaTypes(handler string, r *http.Request) error {
+
+	handlerTypes, ok := handlerMediaTypes[handler]
+
+	if !ok {
+		handlerTypes = defaultMediaTypes
+	}
+
+	if r.ContentLength != 0 {
+
+		mediaType, err := requestMediaType(r)
+
+		if err != nil || !containsMediaType(handlerTypes.consumes, mediaType) {
+			return models.NewHTTPError(http.StatusUnsupportedMediaType, models.CodeUnsupportedMediaType, "Unsupported Content-Type").WithDetails(map[string]interface{}{"supported": handlerTypes.consumes})
+		}
+	}
+
+	if accept := r.Header.Get("Accept"); accept != "" && negotiateResponseMediaType(accept, handlerTypes.produces) == "" {
+		return models.NewHTTPError(http.StatusNotAcceptable, models.CodeNotAcceptable, "No acceptable media type").WithDetails(map[string]interface{}{"supported": handlerTypes.produces})
+	}
+
+	return nil
+}
+
+// requestMediaType : Return media type of request body, without its parameters. Only the UTF-8 charset is accepted
+func requestMediaType(r *http.Request) (string, error) {
+
+	mediaType, params, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+
+	if err != nil {
+		return "", err
+	}
+
+	if charset, ok := params["charset"]; ok && !strings.EqualFold(charset, "utf-8") {
+		return "", mime.ErrInvalidMediaParameter
+	}
+
+	return mediaType, nil
+}
+
+// negotiateResponseMediaType : Return the first of produced media types accepted by the Accept header, by order of preference of the client, empty if none is
+func negotiateResponseMediaType(accept string, produced []string) string {
+
+	bestMediaType := ""
+	bestQuality := 0.0
+
+	for _, mediaType := range produced {
+
+		quality := acceptQuality(accept, mediaType)
+
+		if quality > bestQuality {
+			bestMediaType = mediaType
+			bestQuality = quality
+		}
+	}
+
+	return bestMediaType
+}
+
+// acceptQuality : Return quality given to media type by the Accept header (RFC 7231), the one of its most specific matching range. 0 means not acceptable
+func acceptQuality(accept string, mediaType string) float64 {
+
+	quality := 0.0
+	specificity := -1
+
+	for _, mediaRange := range strings.Split(accept, ",") {
+
+		rangeType, params, err := mime.ParseMediaType(strings.TrimSpace(mediaRange))
+
+		if err != nil {
+			continue
+		}
+
+		rangeSpecificity := 0
+
+		switch {
+		case rangeType == mediaType:
+			rangeSpecificity = 2
+		case strings.HasSuffix(rangeType, "/*") && strings.HasPrefix(mediaType, strings.TrimSuffix(rangeType, "*")):
+			rangeSpecificity = 1
+		case rangeType == "*/*":
+			rangeSpecificity = 0
+		default:
+			continue
+		}
+
+		if rangeSpecificity <= specificity {
+			continue
+		}
+
+		specificity = rangeSpecificity
+		quality = 1.0
+
+		if q, ok := params["q"]; ok {
+			quality, err = strconv.ParseFloat(q, 64)
+
+			if err != nil {
+				quality = 0
+			}
+		}
+	}
+
+	return quality
+}
+
+// containsMediaType : Check mediaType is one of mediaTypes
+func containsMediaType(mediaTypes []string, mediaType string) bool {
+
+	for _, candidate := range mediaTypes {
+		if candidate == mediaType {
+			return true
+		}
+	}
+
+	return false
+}
+
+// decodeBody : Decode request body into v with the decoder of its Content-Type, checked by CustomHandle (See negotiateMediaTypes).
+// Empty bodies, whose Content-Type is not checked, are decoded as JSON
+func decodeBody(r *http.Request, v interface{}) error {
+
+	mediaType, err := requestMediaType(r)
+
+	if err != nil {
+		mediaType = MediaTypeJSON
+	}
+
+	decode, ok := decoders[mediaType]
+
+	if !ok {
+		return errUnsupportedMediaType
+	}
+
+	return decode(r.Body, v)
+}
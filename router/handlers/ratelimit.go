@@ -0,0 +1,207 @@
+package router
+
+import (
+	sha256 "crypto/sha256"
+	hex "encoding/hex"
+	math "math"
+	net "net"
+	http "net/http"
+	strconv "strconv"
+	strings "strings"
+	time "time"
+	models "wave-messaging-management-service/models"
+
+	mux "github.com/gorilla/mux"
+)
+
+// tokenBucketScript : Refill bucket according to elapsed time then take one token.
+// Return {allowed (0 or 1), seconds to wait before next token}
+const tokenBucketScript = `
+local rate = tonumber(ARGV[1])
+local burst = tonumber(ARGV[2])
+local now = tonumber(ARGV[3])
+
+local bucket = redis.call("HMGET", KEYS[1], "tokens", "timestamp")
+local tokens = tonumber(bucket[1]) or burst
+local timestamp = tonumber(bucket[2]) or now
+
+tokens = math.min(burst, tokens + math.max(0, now - timestamp) / 1000 * rate)
+
+local allowed = 0
+local retryAfter = 0
+
+if tokens >= 1 then
+	tokens = tokens - 1
+	allowed = 1
+else
+	retryAfter = math.ceil((1 - tokens) / rate)
+end
+
+redis.call("HMSET", KEYS[1], "tokens", tostring(tokens), "timestamp", now)
+redis.call("EXPIRE", KEYS[1], math.ceil(burst / rate) + 1)
+
+return {allowed, retryAfter}
+`
+
+// checkRateLimit : Take one token from the bucket of request endpoint and caller, and from the bucket of request endpoint and IP,
+// so that callers sending a new token with each request are still limited. Return seconds to wait before retrying if a bucket is empty, 0 otherwise
+func checkRateLimit(env *models.Env, r *http.Request) int {
+
+	if !env.Config.RateLimit.Enabled {
+		return 0
+	}
+
+	endpoint := r.Method + " " + r.URL.Path
+
+	if route := mux.CurrentRoute(r); route != nil {
+		if template, err := route.GetPathTemplate(); err == nil {
+			endpoint = r.Method + " " + template
+		}
+	}
+
+	rule := &env.Config.RateLimit.Default
+
+	if endpointRule, ok := env.Config.RateLimit.Endpoints[endpoint]; ok && endpointRule != nil {
+		rule = endpointRule
+	}
+
+	if rule.Rate <= 0 {
+		return 0
+	}
+
+	if !env.Config.RateLimit.DisableIPBuckets {
+
+		ipFactor := env.Config.RateLimit.IPFactor
+
+		if ipFactor <= 0 {
+			ipFactor = models.DefaultRateLimitIPFactor
+		}
+
+		// An IP may be shared by many callers (NAT, proxies), its bucket is larger. It is taken from first, so that requests it refuses
+		// don't empty the bucket of their caller
+		retryAfter := takeRateLimitToken(env, "ratelimit:"+endpoint+":source:"+rateLimitIP(env, r), rule.Rate*ipFactor, rateLimitBurst(rule)*ipFactor)
+
+		if retryAfter > 0 {
+			return retryAfter
+		}
+	}
+
+	return takeRateLimitToken(env, "ratelimit:"+endpoint+":"+rateLimitCaller(env, r), rule.Rate, rateLimitBurst(rule))
+}
+
+// checkGuestRateLimit : Take one token from the guest creations bucket of request IP, whether or not rate limiting is enabled,
+// as guests are unauthenticated and each of them costs a passhash. Return seconds to wait before retrying if bucket is empty, 0 otherwise
+func checkGuestRateLimit(env *models.Env, r *http.Request) int {
+
+	rule := env.Config.Guest.RateLimit
+
+	if rule.Rate <= 0 {
+		rule = models.RateLimitRule{Rate: models.DefaultGuestRate, Burst: models.DefaultGuestBurst}
+	}
+
+	return takeRateLimitToken(env, "ratelimit:guests:"+rateLimitIP(env, r), rule.Rate, rateLimitBurst(&rule))
+}
+
+// rateLimitBurst : Return capacity of the buckets of rule
+func rateLimitBurst(rule *models.RateLimitRule) float64 {
+
+	if rule.Burst < 1 {
+		return math.Ceil(rule.Rate)
+	}
+
+	return float64(rule.Burst)
+}
+
+// takeRateLimitToken : Take one token from bucket key, refilled by rate tokens per second up to burst.
+// Return seconds to wait before retrying if bucket is empty, 0 otherwise
+func takeRateLimitToken(env *models.Env, key string, rate float64, burst float64) int {
+
+	result, err := env.Redis.EvalInts(env.TraceContext(), tokenBucketScript, []string{key}, rate, int(math.Ceil(burst)), time.Now().UnixNano()/int64(time.Millisecond))
+
+	// Redis failures must not block the API
+	if err != nil || len(result) != 2 {
+		env.Logger.WithError(err).Error("Failed to check rate limit")
+		return 0
+	}
+
+	if result[0] == 1 {
+		return 0
+	}
+
+	if result[1] < 1 {
+		return 1
+	}
+
+	return result[1]
+}
+
+// rateLimitCaller : Identify caller by its user token or API key (Hashed, so that they are not stored in clear), by its IP otherwise
+func rateLimitCaller(env *models.Env, r *http.Request) string {
+
+	for _, header := range []string{"token", "apiKey"} {
+
+		if value := r.Header.Get(header); value != "" {
+			hash := sha256.Sum256([]byte(value))
+			return header + ":" + hex.EncodeToString(hash[:])
+		}
+	}
+
+	return "ip:" + rateLimitIP(env, r)
+}
+
+// rateLimitIP : Return IP of the client of request. Behind trusted proxies, it is the last IP of X-Forwarded-For which is not a trusted proxy
+// (Earlier ones being set by the client itself), X-Real-IP when there is no X-Forwarded-For. Peer IP is used otherwise
+func rateLimitIP(env *models.Env, r *http.Request) string {
+
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+
+	if err != nil {
+		host = r.RemoteAddr
+	}
+
+	config := &env.Config.RateLimit
+
+	if !config.IsTrustedProxy(net.ParseIP(host)) {
+		return host
+	}
+
+	forwardedFor := r.Header.Values("X-Forwarded-For")
+
+	if len(forwardedFor) == 0 {
+
+		if realIP := net.ParseIP(strings.TrimSpace(r.Header.Get("X-Real-IP"))); realIP != nil {
+			return realIP.String()
+		}
+
+		return host
+	}
+
+	// Proxies append the IP of their peer, possibly as another header line
+	hops := strings.Split(strings.Join(forwardedFor, ","), ",")
+
+	for i := len(hops) - 1; i >= 0; i-- {
+
+		ip := net.ParseIP(strings.TrimSpace(hops[i]))
+
+		// Malformed hops can't be told apart from spoofed ones, the last trusted hop is the client as far as the service knows
+		if ip == nil {
+			return host
+		}
+
+		host = ip.String()
+
+		if !config.IsTrustedProxy(ip) {
+			return host
+		}
+	}
+
+	return host
+}
+
+// writeRateLimitResponse : Reply 429 Too Many Requests with Retry-After header
+func writeRateLimitResponse(w http.ResponseWriter, retryAfter int) {
+
+	w.Header().Set("Retry-After", strconv.Itoa(retryAfter))
+
+	writeErrorResponse(w, models.NewHTTPError(http.StatusTooManyRequests, models.CodeRateLimited, "Too many requests").WithDetails(map[string]interface{}{"retryAfter": retryAfter}))
+}
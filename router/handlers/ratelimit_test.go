@@ -0,0 +1,147 @@
+package router
+
+import (
+	fmt "fmt"
+	http "net/http"
+	httptest "net/http/httptest"
+	strconv "strconv"
+	testing "testing"
+	models "wave-messaging-management-service/models"
+	memory "wave-messaging-management-service/models/memory"
+
+	logrus "github.com/sirupsen/logrus"
+)
+
+// newRateLimitEnv : Return environment rate limiting every endpoint to a single request per bucket, buckets being never refilled
+func newRateLimitEnv(config models.RateLimitConfig) *models.Env {
+
+	redis := memory.NewRedis()
+
+	taken := map[string]int{}
+
+	redis.HandleScript(tokenBucketScript, func(redis *memory.Redis, keys []string, args ...interface{}) ([]int, error) {
+
+		burst, err := strconv.Atoi(fmt.Sprint(args[1]))
+
+		if err != nil {
+			return nil, err
+		}
+
+		if taken[keys[0]] >= burst {
+			return []int{0, 1}, nil
+		}
+
+		taken[keys[0]]++
+
+		return []int{1, 0}, nil
+	})
+
+	config.Enabled = true
+	config.Default = models.RateLimitRule{Rate: 1, Burst: 1}
+	config.IPFactor = 1
+
+	return &models.Env{
+		Redis:  redis,
+		Logger: logrus.NewEntry(logrus.New()),
+		Config: models.Config{RateLimit: config},
+	}
+}
+
+// newRateLimitRequest : Return request of token sent by peer, through the proxies of forwardedFor when not empty
+func newRateLimitRequest(peer string, forwardedFor string, token string) *http.Request {
+
+	r := httptest.NewRequest(http.MethodPost, "/v1/profiles", nil)
+	r.RemoteAddr = peer + ":40000"
+	r.Header.Set("token", token)
+
+	if forwardedFor != "" {
+		r.Header.Set("X-Forwarded-For", forwardedFor)
+	}
+
+	return r
+}
+
+func TestRateLimitTrustedProxy(t *testing.T) {
+
+	env := newRateLimitEnv(models.RateLimitConfig{TrustedProxies: []string{"10.0.0.0/8", "192.0.2.1"}})
+
+	// Clients behind the load balancer get their own IP bucket
+	if retryAfter := checkRateLimit(env, newRateLimitRequest("10.0.0.1", "198.51.100.1", "alice")); retryAfter != 0 {
+		t.Fatalf("First request of 198.51.100.1 was refused")
+	}
+
+	if retryAfter := checkRateLimit(env, newRateLimitRequest("10.0.0.2", "198.51.100.2", "bob")); retryAfter != 0 {
+		t.Fatalf("First request of 198.51.100.2 was refused")
+	}
+
+	// IPs prepended by the client itself are ignored, the last hop which is not a trusted proxy is the client
+	if retryAfter := checkRateLimit(env, newRateLimitRequest("10.0.0.1", "203.0.113.7, 198.51.100.1, 192.0.2.1", "carol")); retryAfter == 0 {
+		t.Fatalf("Second request of 198.51.100.1 was accepted")
+	}
+}
+
+func TestRateLimitUntrustedProxy(t *testing.T) {
+
+	env := newRateLimitEnv(models.RateLimitConfig{TrustedProxies: []string{"10.0.0.0/8"}})
+
+	if retryAfter := checkRateLimit(env, newRateLimitRequest("198.51.100.1", "203.0.113.1", "alice")); retryAfter != 0 {
+		t.Fatalf("First request of 198.51.100.1 was refused")
+	}
+
+	// Forwarded IPs of peers which are not trusted proxies are spoofed
+	if retryAfter := checkRateLimit(env, newRateLimitRequest("198.51.100.1", "203.0.113.2", "bob")); retryAfter == 0 {
+		t.Fatalf("Second request of 198.51.100.1 was accepted")
+	}
+}
+
+func TestRateLimitIPBuckets(t *testing.T) {
+
+	env := newRateLimitEnv(models.RateLimitConfig{})
+
+	if retryAfter := checkRateLimit(env, newRateLimitRequest("198.51.100.1", "", "alice")); retryAfter != 0 {
+		t.Fatalf("First request of alice was refused")
+	}
+
+	// Bucket of bob is not empty, the one of their IP is
+	if retryAfter := checkRateLimit(env, newRateLimitRequest("198.51.100.1", "", "bob")); retryAfter == 0 {
+		t.Fatalf("Second request of 198.51.100.1 was accepted")
+	}
+}
+
+func TestRateLimitIPBucketsDisabled(t *testing.T) {
+
+	env := newRateLimitEnv(models.RateLimitConfig{DisableIPBuckets: true})
+
+	if retryAfter := checkRateLimit(env, newRateLimitRequest("198.51.100.1", "", "alice")); retryAfter != 0 {
+		t.Fatalf("First request of alice was refused")
+	}
+
+	if retryAfter := checkRateLimit(env, newRateLimitRequest("198.51.100.1", "", "bob")); retryAfter != 0 {
+		t.Fatalf("First request of bob was refused")
+	}
+
+	// Callers are still limited
+	if retryAfter := checkRateLimit(env, newRateLimitRequest("198.51.100.1", "", "bob")); retryAfter == 0 {
+		t.Fatalf("Second request of bob was accepted")
+	}
+}
+
+func TestGuestRateLimit(t *testing.T) {
+
+	// Guest creations are limited even when rate limiting is disabled
+	env := newRateLimitEnv(models.RateLimitConfig{})
+	env.Config.RateLimit.Enabled = false
+	env.Config.Guest.RateLimit = models.RateLimitRule{Rate: 1, Burst: 1}
+
+	if retryAfter := checkGuestRateLimit(env, newRateLimitRequest("198.51.100.1", "", "")); retryAfter != 0 {
+		t.Fatalf("First guest of 198.51.100.1 was refused")
+	}
+
+	if retryAfter := checkGuestRateLimit(env, newRateLimitRequest("198.51.100.1", "", "")); retryAfter == 0 {
+		t.Fatalf("Second guest of 198.51.100.1 was accepted")
+	}
+
+	if retryAfter := checkGuestRateLimit(env, newRateLimitRequest("198.51.100.2", "", "")); retryAfter != 0 {
+		t.Fatalf("First guest of 198.51.100.2 was refused")
+	}
+}
@@ -0,0 +1,41 @@
+package router
+
+import (
+	http "net/http"
+	models "wave-messaging-management-service/models"
+
+	logrus "github.com/sirupsen/logrus"
+)
+
+// RecoverPanic : Wrap next so that its panics are logged with their stack (Reported, see Error Reporting) and answered with a 500 status,
+// instead of dropping the connection. Covers routes outside CustomHandle (Health checks, metrics) and response writers wrapping handlers
+func RecoverPanic(env *models.Env, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+
+		recorder := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+
+		defer func() {
+			if recovered := recover(); recovered != nil {
+				recoverHandlerPanic(env, recorder, r, recovered)
+			}
+		}()
+
+		next.ServeHTTP(recorder, r)
+	})
+}
+
+// recoverHandlerPanic : Log recovered panic of a handler with the request, and answer with a 500 status unless a response was already started.
+// Return error of the request, for logs and traces
+func recoverHandlerPanic(env *models.Env, recorder *statusRecorder, r *http.Request, recovered interface{}) error {
+
+	env.WithLogFields(logrus.Fields{"method": r.Method, "path": r.URL.Path}).LogPanic(recovered)
+
+	err := internalError("Internal error")
+
+	// Headers can't be changed once sent, client gets a truncated response
+	if !recorder.wroteHeader {
+		writeErrorResponse(recorder, err)
+	}
+
+	return err
+}
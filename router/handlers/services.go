@@ -0,0 +1,409 @@
+package router
+
+import (
+	http "net/http"
+	strconv "strconv"
+	auth "wave-messaging-management-service/auth"
+	models "wave-messaging-management-service/models"
+	utils "wave-messaging-management-service/utils"
+	validation "wave-messaging-management-service/validation"
+
+	mux "github.com/gorilla/mux"
+	logrus "github.com/sirupsen/logrus"
+	gocustomhttpresponse "github.com/terryvogelsang/gocustomhttpresponse"
+	logruswrapper "github.com/terryvogelsang/logruswrapper"
+)
+
+// checkService : Check internal service calling the endpoint was granted scope, return environment scoped to its tenant
+// (Logs holding the service name) and the service as audit actor. Services present either a client certificate (Mutual TLS) or an API key
+func checkService(env *models.Env, r *http.Request, scope string) (*models.Env, models.AuditActor, error) {
+
+	// Client certificate takes precedence, header is not even looked at
+	if r.TLS != nil && len(r.TLS.VerifiedChains) > 0 {
+
+		identity, err := auth.CheckClientCertificate(env, r.TLS, scope)
+
+		// If an error occurs, certificate is unknown or lacks scope
+		if err != nil {
+			env.Logger.WithError(err).Info("Client certificate refused")
+			return nil, models.AuditActor{}, credentialsError(err)
+		}
+
+		env = env.ForTenant(identity.TenantID).WithLogFields(logrus.Fields{"service": identity.ServiceName})
+
+		env.Logger.WithField("scope", scope).Info("Service authenticated by certificate")
+
+		actor := models.ServiceActor(identity.ServiceName)
+		env.IdentifyCaller(actor)
+
+		return env, actor, nil
+	}
+
+	// Retrieve API key from request header
+	apiKey, err := auth.CheckAPIKey(env, r.Header.Get("apiKey"), scope)
+
+	// If an error occurs, API key is invalid or lacks scope
+	if err != nil {
+		env.Logger.WithError(err).Info("API key refused")
+		return nil, models.AuditActor{}, credentialsError(err)
+	}
+
+	env = env.ForTenant(apiKey.TenantID).WithLogFields(logrus.Fields{"service": apiKey.ServiceName})
+
+	env.Logger.WithField("scope", scope).Info("Service authenticated")
+
+	actor := models.ServiceActor(apiKey.ServiceName)
+	env.IdentifyCaller(actor)
+
+	return env, actor, nil
+}
+
+// credentialsError : Return error of refused service credentials, answered with a 403 status when they lack scope and 401 otherwise
+func credentialsError(err error) error {
+
+	if err == auth.ErrMissingScope {
+		return forbidden("Credentials lack the scope of the endpoint")
+	}
+
+	return invalidCredentials("Invalid API key or client certificate")
+}
+
+// checkTenantUser : Check internal Wave user belongs to the tenant of a tenant scoped service
+func checkTenantUser(env *models.Env, internalWaveUserID string) error {
+
+	err := auth.CheckTenantUser(env, internalWaveUserID)
+
+	if err != nil {
+		return forbidden(err.Error())
+	}
+
+	return nil
+}
+
+// conversationsError : Return error of a failed creation of group conversations, answered with a 400 status when they are invalid
+func conversationsError(env *models.Env, err error) error {
+
+	switch err := err.(type) {
+	case *auth.UnknownUsersError:
+		return invalidRequest("Unknown users").WithDetails(map[string]interface{}{"unknownUserIDs": err.UserIDs})
+	case *auth.MappingsError:
+		return internalError("Failed to get mappings of some users").WithDetails(map[string]interface{}{"failedUserIDs": err.FailedUserIDs})
+	}
+
+	if err == auth.ErrTooManyConversations {
+		return invalidRequest(err.Error()).WithDetails(map[string]interface{}{"maxConversations": models.MaxBulkGroupConversations})
+	}
+
+	env.Logger.WithError(err).Error("Failed to add group conversations")
+
+	return internalError("Failed to add group conversations")
+}
+
+// tenantUserID : Namespace user ID of identityProvider the way its verified users are (See auth.NamespaceUserID), within the tenant
+// of a tenant scoped service, the identity provider one otherwise
+func tenantUserID(env *models.Env, identityProvider *models.IdentityProviderConfig, originalUserID string) string {
+
+	tenantID := env.TenantID
+
+	if tenantID == "" {
+		tenantID = identityProvider.Tenant
+	}
+
+	return auth.NamespaceUserID(identityProvider, tenantID, originalUserID)
+}
+
+// GetServiceMappingForUsers : Get internal wave user IDs on behalf of an internal service
+func GetServiceMappingForUsers(env *models.Env, w http.ResponseWriter, r *http.Request) error {
+
+	env, _, err := checkService(env, r, models.APIKeyScopeMappingsRead)
+
+	if err != nil {
+		return err
+	}
+
+	reqBody := utils.MappingRequestBody{}
+
+	err = decodeBody(r, &reqBody)
+
+	if err != nil {
+		return invalidRequest(err.Error())
+	}
+
+	// Users are looked up within the identity provider named by header, default one otherwise
+	identityProvider, _, err := auth.SelectIdentityProvider(&env.Config, r.Header.Get("identityProvider"), "")
+
+	if err != nil {
+		return invalidRequest(err.Error())
+	}
+
+	mappings, err := getMappings(env, identityProvider, reqBody.UserIDs)
+
+	if err != nil {
+		return err
+	}
+
+	log := logruswrapper.NewEntry("MessagingService", "/services/mappings", logruswrapper.CodeSuccess)
+
+	gocustomhttpresponse.WriteResponse(mappings, log, w)
+
+	return nil
+}
+
+// AddServiceGroupConversations : Create many group conversations on behalf of an internal service (e.g. Migration of existing rooms).
+// Nothing is written if one of their users is unknown, quotas are not enforced (See auth.CreateGroupConversations)
+func AddServiceGroupConversations(env *models.Env, w http.ResponseWriter, r *http.Request) error {
+
+	env, actor, err := checkService(env, r, models.APIKeyScopeConversationsWrite)
+
+	if err != nil {
+		return err
+	}
+
+	reqBody := utils.BulkGroupConversationsBody{}
+
+	err = decodeBody(r, &reqBody)
+
+	if err != nil {
+		return invalidRequest(err.Error())
+	}
+
+	// Check required fields
+	_, err = validation.ValidateStruct(reqBody)
+
+	if err != nil {
+		return invalidRequest(err.Error())
+	}
+
+	// Users are looked up within the identity provider named by header, default one otherwise
+	identityProvider, _, err := auth.SelectIdentityProvider(&env.Config, r.Header.Get("identityProvider"), "")
+
+	if err != nil {
+		return invalidRequest(err.Error())
+	}
+
+	groupConversations, err := auth.CreateGroupConversations(env, actor, identityProvider, reqBody.Conversations)
+
+	if err != nil {
+		return conversationsError(env, err)
+	}
+
+	log := logruswrapper.NewEntry("MessagingService", "/services/conversations/group/bulk", logruswrapper.CodeSuccess)
+
+	gocustomhttpresponse.WriteResponse(groupConversations, log, w)
+
+	return nil
+}
+
+// GetServiceClientACL : Get VerneMQ ACL of one MQTT client on behalf of an internal service
+func GetServiceClientACL(env *models.Env, w http.ResponseWriter, r *http.Request) error {
+
+	env, _, err := checkService(env, r, models.APIKeyScopeACLRead)
+
+	if err != nil {
+		return err
+	}
+
+	verneMQACL, err := auth.GetClientACL(env, mux.Vars(r)["clientID"])
+
+	if err == auth.ErrOtherTenant {
+		return forbidden(err.Error())
+	}
+
+	if err != nil {
+		env.Logger.WithError(err).Error("Failed to get client ACL")
+		return internalError("Failed to get client ACL")
+	}
+
+	if notModified(w, r, verneMQACL) {
+		return nil
+	}
+
+	log := logruswrapper.NewEntry("MessagingService", "/services/acls", logruswrapper.CodeSuccess)
+
+	gocustomhttpresponse.WriteResponse(verneMQACL, log, w)
+
+	return nil
+}
+
+// AuthorizeServicePublishing : Grant publishing rights on a MQTT topic to a user (On all its devices) on behalf of an internal service
+func AuthorizeServicePublishing(env *models.Env, w http.ResponseWriter, r *http.Request) error {
+
+	env, actor, err := checkService(env, r, models.APIKeyScopeACLWrite)
+
+	if err != nil {
+		return err
+	}
+
+	reqBody := utils.PublishACLBody{}
+
+	err = decodeBody(r, &reqBody)
+
+	if err != nil {
+		return invalidRequest(err.Error())
+	}
+
+	// Check required fields
+	_, err = validation.ValidateStruct(reqBody)
+
+	if err != nil {
+		return invalidRequest(err.Error())
+	}
+
+	_, err = auth.AuthorizePublishing(env, actor, reqBody.UserID, reqBody.Topic)
+
+	if _, ok := err.(*auth.TopicError); ok || err == auth.ErrOtherTenant {
+		env.Logger.WithError(err).Info("Publishing refused")
+		return forbidden(err.Error())
+	}
+
+	if err != nil {
+		env.Logger.WithError(err).Error("Failed to authorize publishing")
+		return internalError("Failed to authorize publishing")
+	}
+
+	log := logruswrapper.NewEntry("MessagingService", "/services/acls/publish", logruswrapper.CodeSuccess)
+
+	gocustomhttpresponse.WriteResponse(nil, log, w)
+
+	return nil
+}
+
+// InvalidateServiceAuthCache : Remove a token from auth cache on behalf of an internal service (e.g. identity service after a token change).
+// MQTT credentials are kept, token is verified again on its next use
+func InvalidateServiceAuthCache(env *models.Env, w http.ResponseWriter, r *http.Request) error {
+
+	env, actor, err := checkService(env, r, models.APIKeyScopeAuthCacheWrite)
+
+	if err != nil {
+		return err
+	}
+
+	reqBody := utils.TokenBody{}
+
+	err = decodeBody(r, &reqBody)
+
+	if err != nil {
+		return invalidRequest(err.Error())
+	}
+
+	// Check required fields
+	_, err = validation.ValidateStruct(reqBody)
+
+	if err != nil {
+		return invalidRequest(err.Error())
+	}
+
+	err = env.AuthProvider.InvalidateToken(reqBody.Token)
+
+	if err != nil {
+		env.Logger.WithError(err).Error("Failed to invalidate token")
+		return internalError("Failed to invalidate token")
+	}
+
+	audit(env, actor, models.AuditAuthCacheInvalidate, auth.TokenFingerprint(reqBody.Token), nil)
+
+	log := logruswrapper.NewEntry("MessagingService", "/services/authcache/invalidate", logruswrapper.CodeSuccess)
+
+	gocustomhttpresponse.WriteResponse(nil, log, w)
+
+	return nil
+}
+
+// RevokeServiceCredentials : Revoke tokens and application users on behalf of an internal service (e.g. identity service).
+// Revoked tokens and users are denied on every endpoint, their MQTT credentials are rotated and sessions disconnected
+func RevokeServiceCredentials(env *models.Env, w http.ResponseWriter, r *http.Request) error {
+
+	env, actor, err := checkService(env, r, models.APIKeyScopeRevocationsWrite)
+
+	if err != nil {
+		return err
+	}
+
+	reqBody := utils.RevocationBody{}
+
+	err = decodeBody(r, &reqBody)
+
+	if err != nil {
+		return invalidRequest(err.Error())
+	}
+
+	// Check TTL
+	_, err = validation.ValidateStruct(reqBody)
+
+	if err != nil {
+		return invalidRequest(err.Error())
+	}
+
+	// Users are revoked within the identity provider named by header, default one otherwise
+	identityProvider, _, err := auth.SelectIdentityProvider(&env.Config, r.Header.Get("identityProvider"), "")
+
+	if err != nil {
+		return invalidRequest(err.Error())
+	}
+
+	for _, token := range reqBody.Tokens {
+
+		err = auth.RevokeToken(env, token, reqBody.TTL)
+
+		if err != nil {
+			env.Logger.WithError(err).Error("Failed to revoke token")
+			return internalError("Failed to revoke token")
+		}
+
+		audit(env, actor, models.AuditTokenRevoke, auth.TokenFingerprint(token), map[string]string{"ttl": strconv.Itoa(reqBody.TTL)})
+	}
+
+	for _, userID := range reqBody.UserIDs {
+
+		userID = tenantUserID(env, identityProvider, userID)
+
+		err = auth.RevokeUser(env, userID, reqBody.TTL)
+
+		if err != nil {
+			env.Logger.WithError(err).Error("Failed to revoke user")
+			return internalError("Failed to revoke user")
+		}
+
+		audit(env, actor, models.AuditUserRevoke, userID, map[string]string{"ttl": strconv.Itoa(reqBody.TTL)})
+	}
+
+	log := logruswrapper.NewEntry("MessagingService", "/services/revocations", logruswrapper.CodeSuccess)
+
+	gocustomhttpresponse.WriteResponse(nil, log, w)
+
+	return nil
+}
+
+// RestoreServiceUser : Lift revocation of an application user on behalf of an internal service
+func RestoreServiceUser(env *models.Env, w http.ResponseWriter, r *http.Request) error {
+
+	env, actor, err := checkService(env, r, models.APIKeyScopeRevocationsWrite)
+
+	if err != nil {
+		return err
+	}
+
+	// Users are restored within the identity provider named by header, default one otherwise
+	identityProvider, _, err := auth.SelectIdentityProvider(&env.Config, r.Header.Get("identityProvider"), "")
+
+	if err != nil {
+		return invalidRequest(err.Error())
+	}
+
+	userID := tenantUserID(env, identityProvider, mux.Vars(r)["userID"])
+
+	err = auth.RestoreUser(env, userID)
+
+	if err != nil {
+		env.Logger.WithError(err).Error("Failed to restore user")
+		return internalError("Failed to restore user")
+	}
+
+	audit(env, actor, models.AuditUserRestore, userID, nil)
+
+	log := logruswrapper.NewEntry("MessagingService", "/services/revocations/users", logruswrapper.CodeSuccess)
+
+	gocustomhttpresponse.WriteResponse(nil, log, w)
+
+	return nil
+}
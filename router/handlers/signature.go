@@ -0,0 +1,57 @@
+package router
+
+import (
+	bytes "bytes"
+	ioutil "io/ioutil"
+	http "net/http"
+	auth "wave-messaging-management-service/auth"
+	models "wave-messaging-management-service/models"
+)
+
+const (
+	// SignatureHeader : HTTP header holding hex encoded HMAC-SHA256 request signature
+	SignatureHeader = "X-Wave-Signature"
+
+	// SignatureTimestampHeader : HTTP header holding signed request timestamp (Unix seconds)
+	SignatureTimestampHeader = "X-Wave-Timestamp"
+)
+
+// VerifySignature : Refuse requests not signed with the configured secret, and all requests when no secret is configured (Fail closed).
+// Meant to be chained before webhooks and internal services handlers, so that broker callbacks can't be spoofed
+func VerifySignature(env *models.Env, w http.ResponseWriter, r *http.Request) error {
+
+	// Signing explicitly disabled
+	if env.Config.RequestSigning.Disabled {
+		return nil
+	}
+
+	if env.Config.RequestSigning.Secret == "" {
+		env.Logger.Error("Signed request refused : requestSigning.secret is not configured")
+		return forbidden("Request signing is not configured")
+	}
+
+	body, err := ioutil.ReadAll(r.Body)
+
+	if err != nil {
+		return invalidRequest(err.Error())
+	}
+
+	// Following handlers read the body again
+	r.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+	// Request URI as sent by the client, re-encoded from the parsed URL for requests which were not received by a server
+	requestURI := r.RequestURI
+
+	if requestURI == "" {
+		requestURI = r.URL.RequestURI()
+	}
+
+	err = auth.VerifyRequestSignature(&env.Config.RequestSigning, r.Header.Get(SignatureTimestampHeader), r.Header.Get(SignatureHeader), r.Method, requestURI, body)
+
+	if err != nil {
+		env.Logger.WithError(err).Info("Request signature refused")
+		return invalidCredentials("Invalid request signature")
+	}
+
+	return nil
+}
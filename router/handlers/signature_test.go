@@ -0,0 +1,61 @@
+package router
+
+import (
+	http "net/http"
+	httptest "net/http/httptest"
+	strconv "strconv"
+	strings "strings"
+	testing "testing"
+	time "time"
+	auth "wave-messaging-management-service/auth"
+	models "wave-messaging-management-service/models"
+
+	logrus "github.com/sirupsen/logrus"
+)
+
+// newSignedRequest : Return request to target signed as if it was sent to signedMethod signedTarget
+func newSignedRequest(secret string, method string, target string, signedMethod string, signedTarget string, body string) *http.Request {
+
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+
+	r := httptest.NewRequest(method, target, strings.NewReader(body))
+	r.Header.Set(SignatureTimestampHeader, timestamp)
+	r.Header.Set(SignatureHeader, auth.SignRequest(secret, timestamp, signedMethod, signedTarget, []byte(body)))
+
+	return r
+}
+
+func TestVerifySignatureRequestURI(t *testing.T) {
+
+	env := &models.Env{
+		Logger: logrus.NewEntry(logrus.New()),
+		Config: models.Config{RequestSigning: models.RequestSigningConfig{Secret: "secret"}},
+	}
+
+	body := `{"reason":"spam"}`
+
+	err := VerifySignature(env, httptest.NewRecorder(), newSignedRequest("secret", http.MethodPost, "/v1/admin/users/alice/suspend?notify=true", http.MethodPost, "/v1/admin/users/alice/suspend?notify=true", body))
+
+	if err != nil {
+		t.Fatalf("Signature of request was refused : %v", err)
+	}
+
+	replays := []struct {
+		name   string
+		method string
+		target string
+	}{
+		{"path", http.MethodPost, "/v1/admin/users/bob/suspend?notify=true"},
+		{"query", http.MethodPost, "/v1/admin/users/alice/suspend?notify=false"},
+		{"method", http.MethodDelete, "/v1/admin/users/alice/suspend?notify=true"},
+	}
+
+	for _, replay := range replays {
+
+		err := VerifySignature(env, httptest.NewRecorder(), newSignedRequest("secret", replay.method, replay.target, http.MethodPost, "/v1/admin/users/alice/suspend?notify=true", body))
+
+		if err == nil {
+			t.Errorf("Signature was accepted on another %s", replay.name)
+		}
+	}
+}
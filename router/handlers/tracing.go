@@ -0,0 +1,49 @@
+package router
+
+import (
+	context "context"
+	http "net/http"
+	models "wave-messaging-management-service/models"
+
+	otel "go.opentelemetry.io/otel"
+	attribute "go.opentelemetry.io/otel/attribute"
+	codes "go.opentelemetry.io/otel/codes"
+	propagation "go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.12.0"
+	trace "go.opentelemetry.io/otel/trace"
+)
+
+// startRequestSpan : Start span of request handled by handler, continuing trace of the caller when its headers hold one
+func startRequestSpan(handler string, r *http.Request, requestID string) (context.Context, trace.Span) {
+
+	ctx := otel.GetTextMapPropagator().Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+
+	return models.Tracer.Start(ctx, handler,
+		trace.WithSpanKind(trace.SpanKindServer),
+		trace.WithAttributes(
+			semconv.HTTPMethodKey.String(r.Method),
+			semconv.HTTPTargetKey.String(r.URL.Path),
+			attribute.String(models.LogFieldRequestID, requestID),
+		),
+	)
+}
+
+// endRequestSpan : End span of request with its response status code
+func endRequestSpan(span trace.Span, recorder *statusRecorder) {
+
+	span.SetAttributes(semconv.HTTPStatusCodeKey.Int(recorder.status))
+
+	if recorder.status >= http.StatusInternalServerError {
+		span.SetStatus(codes.Error, http.StatusText(recorder.status))
+	}
+
+	span.End()
+}
+
+// failRequestSpan : Record error code returned by a handler on span of request
+func failRequestSpan(ctx context.Context, err error) {
+
+	span := trace.SpanFromContext(ctx)
+	span.RecordError(err)
+	span.SetStatus(codes.Error, err.Error())
+}
@@ -0,0 +1,110 @@
+package router
+
+import (
+	context "context"
+	json "encoding/json"
+	http "net/http"
+	auth "wave-messaging-management-service/auth"
+	models "wave-messaging-management-service/models"
+	utils "wave-messaging-management-service/utils"
+)
+
+// OnOfflineMessage : VerneMQ on_offline_message webhook.
+// Notify recipient devices through push notifications when a message is queued for an offline client
+func OnOfflineMessage(env *models.Env, w http.ResponseWriter, r *http.Request) error {
+
+	offlineMessage := &models.OfflineMessage{}
+
+	// Broker payloads are decoded leniently, so that fields added by VerneMQ upgrades are ignored
+	err := json.NewDecoder(r.Body).Decode(offlineMessage)
+
+	if err != nil {
+		return invalidRequest(err.Error())
+	}
+
+	// Do not hold the broker while providers are called
+	env.Workers.Go(func() {
+		env.Guard(func() {
+			err := env.Notifier.NotifyOfflineMessage(offlineMessage)
+
+			if err != nil {
+				env.Logger.WithError(err).Error("Failed to notify offline message")
+			}
+		})
+	})
+
+	return writeWebhookResponse(w)
+}
+
+// OnClientRegister : VerneMQ on_register webhook. Publish connection of the MQTT client as a real-time event
+func OnClientRegister(env *models.Env, w http.ResponseWriter, r *http.Request) error {
+
+	registration := &models.ClientRegistration{}
+
+	err := json.NewDecoder(r.Body).Decode(registration)
+
+	if err != nil {
+		return invalidRequest(err.Error())
+	}
+
+	// Connections are not held while the tenant of the user is looked up, past the request context
+	env = env.WithTraceContext(context.Background())
+
+	env.Workers.Go(func() {
+		env.Guard(func() {
+			publishClientEvent(env, models.EventClientConnect, registration.ClientID, registration.Username, map[string]string{"username": registration.Username, "peerAddr": registration.PeerAddr})
+		})
+	})
+
+	return writeWebhookResponse(w)
+}
+
+// OnClientGone : VerneMQ on_client_offline and on_client_gone webhooks. Publish disconnection of the MQTT client as a real-time event
+func OnClientGone(env *models.Env, w http.ResponseWriter, r *http.Request) error {
+
+	departure := &models.ClientDeparture{}
+
+	err := json.NewDecoder(r.Body).Decode(departure)
+
+	if err != nil {
+		return invalidRequest(err.Error())
+	}
+
+	env = env.WithTraceContext(context.Background())
+
+	env.Workers.Go(func() {
+		env.Guard(func() {
+
+			// Payloads of these hooks have no username, which is found in the client ACL while it exists
+			username := ""
+
+			verneMQACL, err := env.Store.GetClientACL(env.TraceContext(), departure.ClientID)
+
+			if err == nil {
+				username = verneMQACL.Username
+			}
+
+			publishClientEvent(env, models.EventClientDisconnect, departure.ClientID, username, map[string]string{"username": username})
+		})
+	})
+
+	return writeWebhookResponse(w)
+}
+
+// publishClientEvent : Publish connection event of MQTT client clientID, in the tenant of username (Internal Wave user ID)
+func publishClientEvent(env *models.Env, eventType string, clientID string, username string, details map[string]string) {
+	env.Events.Publish(&models.Event{
+		Type:     eventType,
+		Target:   clientID,
+		TenantID: auth.GetUserTenant(env, username),
+		Details:  details,
+	})
+}
+
+// writeWebhookResponse : VerneMQ webhooks expect a plain {"result": "ok"} body
+func writeWebhookResponse(w http.ResponseWriter) error {
+
+	w.Header().Set("Content-Type", "application/json")
+
+	return json.NewEncoder(w).Encode(utils.WebhookResponse{Result: "ok"})
+}
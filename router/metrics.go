@@ -0,0 +1,39 @@
+package router
+
+import (
+	http "net/http"
+	models "wave-messaging-management-service/models"
+	handlers "wave-messaging-management-service/router/handlers"
+
+	promhttp "github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// listenMetrics : Serve Prometheus metrics on their own address when enabled, so that they are never reachable through the API port
+func listenMetrics(env *models.Env) {
+
+	config := env.Config.Metrics
+
+	if !config.Enabled {
+		return
+	}
+
+	address := config.Address
+
+	if address == "" {
+		address = models.DefaultMetricsAddress
+	}
+
+	serveMux := http.NewServeMux()
+	serveMux.Handle("/metrics", promhttp.Handler())
+
+	server := &http.Server{
+		Addr:    address,
+		Handler: handlers.RecoverPanic(env, serveMux),
+	}
+
+	go func() {
+		env.Logger.WithError(server.ListenAndServe()).Error("Metrics server stopped")
+	}()
+
+	env.Logger.WithField("address", address).Info("Metrics server enabled")
+}
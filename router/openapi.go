@@ -0,0 +1,438 @@
+package router
+
+import (
+	json "encoding/json"
+	http "net/http"
+	reflect "reflect"
+	regexp "regexp"
+	sort "sort"
+	strconv "strconv"
+	strings "strings"
+	time "time"
+	models "wave-messaging-management-service/models"
+	handlers "wave-messaging-management-service/router/handlers"
+	utils "wave-messaging-management-service/utils"
+
+	mux "github.com/gorilla/mux"
+)
+
+const (
+	// OpenAPIPath : Path of the OpenAPI document
+	OpenAPIPath = "/openapi.json"
+
+	// SwaggerUIPath : Path of the Swagger UI, when enabled
+	SwaggerUIPath = "/docs"
+
+	// securityUserToken : Endpoints authenticated with a user token (token header)
+	securityUserToken = "userToken"
+
+	// securityAPIKey : Endpoints authenticated with a service API key (apiKey header) or a client certificate
+	securityAPIKey = "apiKey"
+)
+
+// openAPIOperation : Documentation of an endpoint. Its request and response bodies schemas are derived from the types of request and response.
+// Responses are JSON unless produces is set (Schema of response being the one of each streamed item)
+type openAPIOperation struct {
+	id       string
+	summary  string
+	tag      string
+	security string
+	signed   bool
+	query    []string
+	headers  []string
+	request  interface{}
+	response interface{}
+	produces string
+}
+
+// openAPIOperations : Documented endpoints, by method and route template.
+// Routes missing from this list are documented without summary nor bodies
+var openAPIOperations = map[string]*openAPIOperation{
+	"POST /v1/profiles":                               {id: "AddVerneMQACL", summary: "Provision MQTT credentials of the token owner", tag: "profiles", security: securityUserToken, response: models.MQTTAuthInfos{}},
+	"POST /v1/profiles/mappings":                      {id: "GetMappingForUsers", summary: "Get internal Wave user IDs of application users", tag: "profiles", security: securityUserToken, request: utils.MappingRequestBody{}, response: []models.Mapping{}},
+	"POST /v1/profiles/logout":                        {id: "ForceLogout", summary: "Revoke MQTT credentials of the token owner", tag: "profiles", security: securityUserToken},
+	"POST /v1/profiles/devices":                       {id: "RegisterDevice", summary: "Register a device", tag: "profiles", security: securityUserToken, request: utils.DeviceBody{}, response: models.MQTTAuthInfos{}},
+	"DELETE /v1/profiles/devices/{clientID}":          {id: "DeregisterDevice", summary: "Remove a device and disconnect its session", tag: "profiles", security: securityUserToken},
+	"POST /v1/profiles/pushtokens":                    {id: "RegisterPushToken", summary: "Register a push token", tag: "notifications", security: securityUserToken, request: utils.PushTokenBody{}},
+	"DELETE /v1/profiles/pushtokens":                  {id: "UnregisterPushTokenByQuery", summary: "Unregister a push token passed as query parameter (Web Push subscription URLs)", tag: "notifications", security: securityUserToken},
+	"DELETE /v1/profiles/pushtokens/{pushToken}":      {id: "UnregisterPushToken", summary: "Unregister a push token", tag: "notifications", security: securityUserToken},
+	"GET /v1/profiles/notifications/preferences":      {id: "GetNotificationPreferences", summary: "Get notification preferences of the token owner", tag: "notifications", security: securityUserToken, response: models.NotificationPreferences{}},
+	"PUT /v1/profiles/notifications/preferences":      {id: "SetNotificationPreferences", summary: "Set notification preferences of the token owner", tag: "notifications", security: securityUserToken, request: models.NotificationPreferences{}, response: models.NotificationPreferences{}},
+	"POST /v1/conversations/group":                    {id: "AddGroupConversation", summary: "Create a group conversation", tag: "conversations", security: securityUserToken, request: utils.GroupConversationBody{}},
+	"POST /v1/graphql":                                {id: "QueryGraphQL", summary: "Query conversations, members and presence of the token owner with GraphQL", tag: "graphql", security: securityUserToken, request: utils.GraphQLBody{}},
+	"POST /v1/guests":                                 {id: "AddGuest", summary: "Get short-lived subscribe-only MQTT credentials", tag: "guests", response: models.GuestMQTTAuthInfos{}},
+	"POST /v1/services/mappings":                      {id: "GetServiceMappingForUsers", summary: "Get internal Wave user IDs of application users", tag: "services", security: securityAPIKey, signed: true, request: utils.MappingRequestBody{}, response: []models.Mapping{}},
+	"POST /v1/services/acls/publish":                  {id: "AuthorizeServicePublishing", summary: "Grant publishing rights on a topic to a user", tag: "services", security: securityAPIKey, signed: true, request: utils.PublishACLBody{}},
+	"POST /v1/services/conversations/group/bulk":      {id: "AddServiceGroupConversations", summary: "Create many group conversations on behalf of users", tag: "services", security: securityAPIKey, signed: true, request: utils.BulkGroupConversationsBody{}, response: []models.GroupConversation{}},
+	"GET /v1/services/acls/{clientID}":                {id: "GetServiceClientACL", summary: "Get VerneMQ ACL of a MQTT client", tag: "services", security: securityAPIKey, signed: true, response: models.VerneMQACL{}},
+	"POST /v1/services/authcache/invalidate":          {id: "InvalidateServiceAuthCache", summary: "Remove a token from auth cache", tag: "services", security: securityAPIKey, signed: true, request: utils.TokenBody{}},
+	"POST /v1/services/revocations":                   {id: "RevokeServiceCredentials", summary: "Revoke tokens and application users", tag: "services", security: securityAPIKey, signed: true, request: utils.RevocationBody{}},
+	"DELETE /v1/services/revocations/users/{userID}":  {id: "RestoreServiceUser", summary: "Lift revocation of an application user", tag: "services", security: securityAPIKey, signed: true},
+	"GET /v1/admin/users":                             {id: "ListUsers", summary: "List and search users", tag: "admin", security: securityAPIKey, signed: true, query: []string{"search", "cursor", "offset", "limit"}, response: models.UsersPage{}},
+	"GET /v1/admin/users/{id}/acl":                    {id: "GetUserACL", summary: "Get effective ACLs of an internal Wave user", tag: "admin", security: securityAPIKey, signed: true, response: models.UserACL{}},
+	"GET /v1/admin/users/{id}/conversations":          {id: "ListUserConversations", summary: "List group conversations of an internal Wave user", tag: "admin", security: securityAPIKey, signed: true, query: []string{"cursor", "offset", "limit"}, response: models.GroupConversationsPage{}},
+	"POST /v1/admin/users/{id}/suspend":               {id: "SuspendUser", summary: "Revoke all access of an internal Wave user", tag: "admin", security: securityAPIKey, signed: true, response: models.Mapping{}},
+	"POST /v1/admin/users/{id}/disconnect":            {id: "DisconnectUser", summary: "Disconnect sessions of an internal Wave user", tag: "admin", security: securityAPIKey, signed: true, response: models.UserSessions{}},
+	"POST /v1/admin/users/{id}/restore":               {id: "RestoreUserACLs", summary: "Restore removed VerneMQ ACLs of an internal Wave user", tag: "admin", security: securityAPIKey, signed: true, response: models.UserACL{}},
+	"GET /v1/admin/users/{id}/quotas":                 {id: "GetUserQuotas", summary: "Get quotas of an internal Wave user, with its usage", tag: "admin", security: securityAPIKey, signed: true, response: models.UserQuotas{}},
+	"PUT /v1/admin/users/{id}/quotas":                 {id: "SetUserQuotas", summary: "Override quotas of an internal Wave user", tag: "admin", security: securityAPIKey, signed: true, request: models.QuotaOverride{}},
+	"DELETE /v1/admin/conversations/group/{id}":       {id: "RemoveGroupConversation", summary: "Remove a group conversation, revoking access of its members", tag: "admin", security: securityAPIKey, signed: true, response: models.GroupConversation{}},
+	"POST /v1/admin/conversations/group/{id}/restore": {id: "RestoreGroupConversation", summary: "Restore a removed group conversation", tag: "admin", security: securityAPIKey, signed: true, response: models.GroupConversation{}},
+	"POST /v1/admin/broadcasts":                       {id: "Broadcast", summary: "Publish a system message to users", tag: "admin", security: securityAPIKey, signed: true, request: utils.BroadcastBody{}, response: models.BroadcastReport{}},
+	"GET /v1/admin/usage":                             {id: "GetUsageReport", summary: "Get usage aggregated per tenant or per user", tag: "admin", security: securityAPIKey, signed: true, query: []string{"userID", "tenantID", "from", "to", "groupBy"}, response: models.UsageReport{}},
+	"GET /v1/admin/audit":                             {id: "GetAuditLog", summary: "Query the audit log", tag: "admin", security: securityAPIKey, signed: true, query: []string{"actorType", "actorID", "target", "action", "tenantID", "from", "to", "cursor", "offset", "limit"}, response: models.AuditPage{}},
+	"GET /v1/admin/logging":                           {id: "GetLogging", summary: "Get logging settings of the instance", tag: "admin", security: securityAPIKey, signed: true, response: models.LoggingConfig{}},
+	"PUT /v1/admin/logging":                           {id: "SetLogging", summary: "Change logging settings of the instance", tag: "admin", security: securityAPIKey, signed: true, request: models.LoggingConfig{}, response: models.LoggingConfig{}},
+	"GET /v1/admin/events":                            {id: "StreamEvents", summary: "Stream real-time events of the instance (Server-sent events)", tag: "admin", security: securityAPIKey, signed: true, query: []string{"types"}, headers: []string{handlers.LastEventIDHeader}, produces: handlers.MediaTypeEventStream, response: models.Event{}},
+	"POST /v1/admin/webhooks":                         {id: "CreateWebhook", summary: "Register an outbound webhook receiving lifecycle events", tag: "admin", security: securityAPIKey, signed: true, request: utils.WebhookBody{}, response: models.Webhook{}},
+	"GET /v1/admin/webhooks":                          {id: "ListWebhooks", summary: "List outbound webhooks", tag: "admin", security: securityAPIKey, signed: true, response: models.Webhooks{}},
+	"DELETE /v1/admin/webhooks/{id}":                  {id: "RemoveWebhook", summary: "Remove an outbound webhook, its deliveries and dead letters", tag: "admin", security: securityAPIKey, signed: true},
+	"GET /v1/admin/webhooks/{id}/deliveries":          {id: "ListWebhookDeliveries", summary: "List deliveries of an outbound webhook", tag: "admin", security: securityAPIKey, signed: true, query: []string{"cursor", "offset", "limit"}, response: models.WebhookDeliveriesPage{}},
+	"GET /v1/admin/webhooks/deadletters":              {id: "ListWebhookDeadLetters", summary: "List webhook deliveries dead-lettered after their last attempt", tag: "admin", security: securityAPIKey, signed: true, query: []string{"webhookID", "cursor", "offset", "limit"}, response: models.WebhookDeadLettersPage{}},
+	"POST /v1/admin/webhooks/deadletters/{id}/replay": {id: "ReplayWebhookDeadLetter", summary: "Deliver a dead-lettered event again", tag: "admin", security: securityAPIKey, signed: true, response: models.WebhookDelivery{}},
+	"DELETE /v1/admin/webhooks/deadletters/{id}":      {id: "DiscardWebhookDeadLetter", summary: "Discard a dead-lettered webhook delivery", tag: "admin", security: securityAPIKey, signed: true},
+	"POST /v1/webhooks/offlinemessage":                {id: "OnOfflineMessage", summary: "VerneMQ on_offline_message webhook", tag: "webhooks", signed: true, request: models.OfflineMessage{}, response: utils.WebhookResponse{}},
+	"POST /v1/webhooks/register":                      {id: "OnClientRegister", summary: "VerneMQ on_register webhook", tag: "webhooks", signed: true, request: models.ClientRegistration{}, response: utils.WebhookResponse{}},
+	"POST /v1/webhooks/clientgone":                    {id: "OnClientGone", summary: "VerneMQ on_client_offline and on_client_gone webhook", tag: "webhooks", signed: true, request: models.ClientDeparture{}, response: utils.WebhookResponse{}},
+}
+
+var (
+	// pathParameterRegex : Matches parameters of route templates
+	pathParameterRegex = regexp.MustCompile(`{([^}:]+)(:[^}]+)?}`)
+
+	// timeType : Type of dates, documented as strings
+	timeType = reflect.TypeOf(time.Time{})
+)
+
+// swaggerUIPage : Swagger UI page of the OpenAPI document, assets being loaded from a CDN
+const swaggerUIPage = `<!DOCTYPE html>
+<html>
+<head>
+<title>Wave Messaging Management Service</title>
+<link rel="stylesheet" href="https://unpkg.com/swagger-ui-dist@5/swagger-ui.css">
+</head>
+<body>
+<div id="swagger-ui"></div>
+<script src="https://unpkg.com/swagger-ui-dist@5/swagger-ui-bundle.js"></script>
+<script>SwaggerUIBundle({url: "` + OpenAPIPath + `", dom_id: "#swagger-ui"})</script>
+</body>
+</html>
+`
+
+// serveOpenAPI : Serve OpenAPI document of the versioned endpoints registered on r, and Swagger UI when enabled.
+// Document is generated once, so that it must be called after every route is registered
+func serveOpenAPI(env *models.Env, r *mux.Router) {
+
+	config := env.Config.OpenAPI
+
+	if !config.Enabled {
+		return
+	}
+
+	document, err := newOpenAPIDocument(r, config.ServerURL)
+
+	if err != nil {
+		env.Logger.WithError(err).Fatal("Failed to generate OpenAPI document")
+	}
+
+	data, err := json.MarshalIndent(document, "", "  ")
+
+	if err != nil {
+		env.Logger.WithError(err).Fatal("Failed to generate OpenAPI document")
+	}
+
+	r.HandleFunc(OpenAPIPath, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(data)
+	}).Methods("GET")
+
+	if !config.SwaggerUI {
+		return
+	}
+
+	r.HandleFunc(SwaggerUIPath, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write([]byte(swaggerUIPage))
+	}).Methods("GET")
+}
+
+// newOpenAPIDocument : Return OpenAPI 3 document of the endpoints of every API version registered on r
+func newOpenAPIDocument(r *mux.Router, serverURL string) (map[string]interface{}, error) {
+
+	schemas := &openAPISchemas{schemas: map[string]interface{}{}}
+	paths := map[string]map[string]interface{}{}
+
+	err := r.Walk(func(route *mux.Route, router *mux.Router, ancestors []*mux.Route) error {
+
+		// Subrouters prefixes have no methods
+		methods, err := route.GetMethods()
+
+		if err != nil {
+			return nil
+		}
+
+		template, err := route.GetPathTemplate()
+
+		if err != nil || !isVersionedPath(template) {
+			return nil
+		}
+
+		queries, _ := route.GetQueriesTemplates()
+
+		if paths[template] == nil {
+			paths[template] = map[string]interface{}{}
+		}
+
+		for _, method := range methods {
+			paths[template][strings.ToLower(method)] = newOpenAPIOperation(schemas, method, template, queries)
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	document := map[string]interface{}{
+		"openapi": "3.0.3",
+		"info": map[string]interface{}{
+			"title":       "Wave Messaging Management Service",
+			"version":     apiVersions[len(apiVersions)-1].name,
+			"description": "Response bodies are the payload of the standard response envelope, along with its code",
+		},
+		"paths": paths,
+		"components": map[string]interface{}{
+			"schemas": schemas.schemas,
+			"securitySchemes": map[string]interface{}{
+				securityUserToken: map[string]interface{}{"type": "apiKey", "in": "header", "name": "token"},
+				securityAPIKey:    map[string]interface{}{"type": "apiKey", "in": "header", "name": "apiKey"},
+			},
+		},
+	}
+
+	if serverURL != "" {
+		document["servers"] = []map[string]interface{}{{"url": serverURL}}
+	}
+
+	return document, nil
+}
+
+// isVersionedPath : Return true if route template is under the prefix of an API version, leaving out legacy paths
+func isVersionedPath(template string) bool {
+
+	for _, version := range apiVersions {
+		if strings.HasPrefix(template, "/"+version.name+"/") {
+			return true
+		}
+	}
+
+	return false
+}
+
+// newOpenAPIOperation : Return OpenAPI operation of route, documented as set in openAPIOperations
+func newOpenAPIOperation(schemas *openAPISchemas, method string, template string, queries []string) map[string]interface{} {
+
+	documentation, ok := openAPIOperations[method+" "+template]
+
+	if !ok {
+		documentation = &openAPIOperation{}
+	}
+
+	parameters := []map[string]interface{}{}
+
+	for _, match := range pathParameterRegex.FindAllStringSubmatch(template, -1) {
+		parameters = append(parameters, newOpenAPIParameter(match[1], "path", true))
+	}
+
+	// Queries matched by the route are required, documented ones optional
+	for _, query := range queries {
+		parameters = append(parameters, newOpenAPIParameter(strings.SplitN(query, "=", 2)[0], "query", true))
+	}
+
+	for _, query := range documentation.query {
+		parameters = append(parameters, newOpenAPIParameter(query, "query", false))
+	}
+
+	for _, header := range documentation.headers {
+		parameters = append(parameters, newOpenAPIParameter(header, "header", false))
+	}
+
+	if documentation.security == securityUserToken {
+		parameters = append(parameters, newOpenAPIParameter("identityProvider", "header", false))
+	}
+
+	if documentation.signed {
+		parameters = append(parameters, newOpenAPIParameter(handlers.SignatureHeader, "header", false), newOpenAPIParameter(handlers.SignatureTimestampHeader, "header", false))
+	}
+
+	if handlers.IdempotentHandlers[documentation.id] {
+		parameters = append(parameters, newOpenAPIParameter(handlers.IdempotencyKeyHeader, "header", false))
+	}
+
+	success := map[string]interface{}{"description": "Success"}
+
+	if documentation.response != nil {
+
+		produces := documentation.produces
+
+		if produces == "" {
+			produces = handlers.MediaTypeJSON
+		}
+
+		success["content"] = map[string]interface{}{produces: map[string]interface{}{"schema": schemas.schemaOf(reflect.TypeOf(documentation.response))}}
+	}
+
+	operation := map[string]interface{}{
+		"summary":    documentation.summary,
+		"parameters": parameters,
+		"responses": map[string]interface{}{
+			"200": success,
+			// Every error has the same body, its code telling errors apart
+			"default": map[string]interface{}{
+				"description": "Error",
+				"content":     map[string]interface{}{"application/json": map[string]interface{}{"schema": schemas.schemaOf(reflect.TypeOf(handlers.ErrorResponse{}))}},
+			},
+		},
+	}
+
+	if documentation.id != "" {
+		operation["operationId"] = documentation.id
+	}
+
+	if documentation.tag != "" {
+		operation["tags"] = []string{documentation.tag}
+	}
+
+	if documentation.security != "" {
+		operation["security"] = []map[string][]string{{documentation.security: {}}}
+	}
+
+	if documentation.request != nil {
+		operation["requestBody"] = map[string]interface{}{
+			"required": true,
+			"content":  map[string]interface{}{"application/json": map[string]interface{}{"schema": schemas.schemaOf(reflect.TypeOf(documentation.request))}},
+		}
+	}
+
+	return operation
+}
+
+// newOpenAPIParameter : Return string parameter called name, in path, query or header
+func newOpenAPIParameter(name string, in string, required bool) map[string]interface{} {
+	return map[string]interface{}{"name": name, "in": in, "required": required, "schema": map[string]string{"type": "string"}}
+}
+
+// openAPISchemas : Components schemas of the OpenAPI document, by type name
+type openAPISchemas struct {
+	schemas map[string]interface{}
+}
+
+// schemaOf : Return schema of type t, named struct types being referenced from components
+func (openAPI *openAPISchemas) schemaOf(t reflect.Type) map[string]interface{} {
+
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	if t == timeType {
+		return map[string]interface{}{"type": "string", "format": "date-time"}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Int64, reflect.Uint64:
+		return map[string]interface{}{"type": "integer", "format": "int64"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return map[string]interface{}{"type": "string", "format": "byte"}
+		}
+		return map[string]interface{}{"type": "array", "items": openAPI.schemaOf(t.Elem())}
+	case reflect.Map:
+		return map[string]interface{}{"type": "object", "additionalProperties": openAPI.schemaOf(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return openAPI.structSchema(t)
+		}
+		// Registered before its fields, so that recursive types end
+		if _, ok := openAPI.schemas[t.Name()]; !ok {
+			openAPI.schemas[t.Name()] = map[string]interface{}{}
+			openAPI.schemas[t.Name()] = openAPI.structSchema(t)
+		}
+		return map[string]interface{}{"$ref": "#/components/schemas/" + t.Name()}
+	}
+
+	return map[string]interface{}{}
+}
+
+// structSchema : Return object schema of struct type t from its JSON fields, embedded structs fields included.
+// Fields validated as required are required, oneof and min validations become enum and minimum
+func (openAPI *openAPISchemas) structSchema(t reflect.Type) map[string]interface{} {
+
+	properties := map[string]interface{}{}
+	required := []string{}
+
+	for i := 0; i < t.NumField(); i++ {
+
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+
+		if field.PkgPath != "" || tag == "-" {
+			continue
+		}
+
+		name := strings.Split(tag, ",")[0]
+
+		if field.Anonymous && name == "" {
+
+			embedded := openAPI.structSchema(field.Type)
+
+			for embeddedName, property := range embedded["properties"].(map[string]interface{}) {
+				properties[embeddedName] = property
+			}
+
+			if embeddedRequired, ok := embedded["required"].([]string); ok {
+				required = append(required, embeddedRequired...)
+			}
+
+			continue
+		}
+
+		if name == "" {
+			name = field.Name
+		}
+
+		property := openAPI.schemaOf(field.Type)
+
+		for _, rule := range strings.Split(field.Tag.Get("validate"), ",") {
+			switch {
+			case rule == "required":
+				required = append(required, name)
+			case strings.HasPrefix(rule, "oneof="):
+				property["enum"] = strings.Fields(strings.TrimPrefix(rule, "oneof="))
+			case strings.HasPrefix(rule, "min="):
+				if minimum, err := strconv.Atoi(strings.TrimPrefix(rule, "min=")); err == nil && property["type"] == "integer" {
+					property["minimum"] = minimum
+				}
+			}
+		}
+
+		properties[name] = property
+	}
+
+	schema := map[string]interface{}{"type": "object", "properties": properties}
+
+	if len(required) > 0 {
+		sort.Strings(required)
+		schema["required"] = required
+	}
+
+	return schema
+}
@@ -3,11 +3,13 @@ package router
 import (
 	fmt "fmt"
 	http "net/http"
+	time "time"
+	auth "wave-messaging-management-service/auth"
 	models "wave-messaging-management-service/models"
 	handlers "wave-messaging-management-service/router/handlers"
 
 	mux "github.com/gorilla/mux"
-	cors "github.com/rs/cors"
+	autocert "golang.org/x/crypto/acme/autocert"
 )
 
 const (
@@ -15,28 +17,173 @@ const (
 	PORT int = 8085
 )
 
-// Listen : Defines all router routing rules and handlers.
-// Serves the API at defined port constant.
-func Listen(env *models.Env) {
+// NewServer : Defines all router routing rules and handlers.
+// Returned server serves the API at defined port constant (See Serve), over TLS when configured
+func NewServer(env *models.Env) *http.Server {
 
 	r := mux.NewRouter().StrictSlash(false)
 
-	v1 := r.PathPrefix("/v1").Subrouter()
+	// Liveness probe, neither rate limited nor touching dependencies
+	r.HandleFunc("/healthz", handlers.Healthz).Methods("GET")
+
+	// Readiness probe, checking MongoDB, Redis and optionally the authentication endpoint
+	r.HandleFunc("/readyz", handlers.Readyz(env)).Methods("GET")
+
+	// Versioned API, with unversioned legacy paths when enabled
+	mountAPIVersions(env, r)
+
+	// OpenAPI document of the versioned API, generated from its routes
+	serveOpenAPI(env, r)
+
+	corsHandler, err := newCORSHandler(&env.Config.CORS)
+
+	if err != nil {
+		env.Logger.WithError(err).Fatal("Invalid CORS config")
+	}
+
+	// Profiling endpoints and Prometheus metrics, on their own addresses
+	listenDebug(env)
+	listenMetrics(env)
+
+	config := env.Config.Server
+
+	maxHeaderBytes := config.MaxHeaderBytes
+
+	if maxHeaderBytes <= 0 {
+		maxHeaderBytes = models.DefaultServerMaxHeaderBytes
+	}
+
+	// Slow clients are disconnected instead of holding goroutines
+	server := &http.Server{
+		Addr:              ":" + fmt.Sprintf("%d", PORT),
+		Handler:           handlers.RecoverPanic(env, compressHandler(&env.Config.Compression, corsHandler.Handler(r))),
+		ReadHeaderTimeout: milliseconds(config.ReadHeaderTimeout, models.DefaultServerReadHeaderTimeout),
+		ReadTimeout:       milliseconds(config.ReadTimeout, models.DefaultServerReadTimeout),
+		WriteTimeout:      milliseconds(config.WriteTimeout, models.DefaultServerWriteTimeout),
+		IdleTimeout:       milliseconds(config.IdleTimeout, models.DefaultServerIdleTimeout),
+		MaxHeaderBytes:    maxHeaderBytes,
+	}
+
+	// Plain HTTP unless server certificates are configured
+	if !env.Config.TLS.Enabled() {
+		return server
+	}
+
+	var certManager *autocert.Manager
+
+	// Certificates are obtained from an ACME CA unless certificate files are set
+	if env.Config.TLS.CertFile == "" {
+		certManager = auth.NewCertManager(&env.Config.TLS.Autocert)
+		listenACMEChallenges(env, certManager)
+	}
+
+	tlsConfig, err := auth.NewServerTLSConfig(&env.Config.TLS, certManager)
+
+	if err != nil {
+		env.Logger.WithError(err).Fatal("Invalid TLS config")
+	}
+
+	server.TLSConfig = tlsConfig
+
+	return server
+}
+
+// Serve : Serve the API with server until it fails or is shut down (Returning nil then).
+// Without certificate files, TLS certificates come from the server TLS config (ACME certificates)
+func Serve(env *models.Env, server *http.Server) error {
+
+	var err error
+
+	if server.TLSConfig == nil {
+		err = server.ListenAndServe()
+	} else {
+		err = server.ListenAndServeTLS(env.Config.TLS.CertFile, env.Config.TLS.KeyFile)
+	}
+
+	if err == http.ErrServerClosed {
+		return nil
+	}
+
+	return err
+}
+
+// milliseconds : Milliseconds to duration, defaultMilliseconds if not set
+func milliseconds(value int, defaultMilliseconds int) time.Duration {
+
+	if value <= 0 {
+		value = defaultMilliseconds
+	}
+
+	return time.Duration(value) * time.Millisecond
+}
+
+// routesV1 : Defines routes of version 1 of the API on v1
+func routesV1(env *models.Env, v1 *mux.Router) {
 
 	// HelloWorld Endpoint
 	aclV1 := v1.PathPrefix("/profiles").Subrouter()
 	aclV1.Handle("", handlers.CustomHandle(env, handlers.AddVerneMQACL)).Methods("POST")
 	aclV1.Handle("/mappings", handlers.CustomHandle(env, handlers.GetMappingForUsers)).Methods("POST")
+	aclV1.Handle("/logout", handlers.CustomHandle(env, handlers.ForceLogout)).Methods("POST")
+	aclV1.Handle("/devices", handlers.CustomHandle(env, handlers.RegisterDevice)).Methods("POST")
+	aclV1.Handle("/devices/{clientID}", handlers.CustomHandle(env, handlers.DeregisterDevice)).Methods("DELETE")
+	aclV1.Handle("/pushtokens", handlers.CustomHandle(env, handlers.RegisterPushToken)).Methods("POST")
+	// Web Push tokens are subscription URLs and can't be passed as path segment
+	aclV1.Handle("/pushtokens", handlers.CustomHandle(env, handlers.UnregisterPushToken)).Methods("DELETE").Queries("pushToken", "{pushToken}")
+	aclV1.Handle("/pushtokens/{pushToken}", handlers.CustomHandle(env, handlers.UnregisterPushToken)).Methods("DELETE")
+	aclV1.Handle("/notifications/preferences", handlers.CustomHandle(env, handlers.GetNotificationPreferences)).Methods("GET")
+	aclV1.Handle("/notifications/preferences", handlers.CustomHandle(env, handlers.SetNotificationPreferences)).Methods("PUT")
 
 	conversationsV1 := v1.PathPrefix("/conversations").Subrouter()
 	conversationsV1.Handle("/group", handlers.CustomHandle(env, handlers.AddGroupConversation)).Methods("POST")
 
-	corsHandler := cors.New(cors.Options{
-		AllowedHeaders:   []string{"X-Requested-With"},
-		AllowedOrigins:   []string{"*"},
-		AllowCredentials: true,
-		AllowedMethods:   []string{"GET", "HEAD", "POST", "PUT", "OPTIONS"},
-	})
+	// GraphQL reads of the token owner inbox
+	v1.Handle("/graphql", handlers.CustomHandle(env, handlers.QueryGraphQL)).Methods("POST")
+
+	// Guests endpoint, no token required
+	guestsV1 := v1.PathPrefix("/guests").Subrouter()
+	guestsV1.Handle("", handlers.CustomHandle(env, handlers.AddGuest)).Methods("POST")
+
+	// Internal services endpoints (Signed requests), authenticated with API keys or client certificates instead of user tokens
+	servicesV1 := v1.PathPrefix("/services").Subrouter()
+	servicesV1.Handle("/mappings", handlers.CustomHandle(env, handlers.VerifySignature, handlers.GetServiceMappingForUsers)).Methods("POST")
+	servicesV1.Handle("/acls/publish", handlers.CustomHandle(env, handlers.VerifySignature, handlers.AuthorizeServicePublishing)).Methods("POST")
+	servicesV1.Handle("/conversations/group/bulk", handlers.CustomHandle(env, handlers.VerifySignature, handlers.AddServiceGroupConversations)).Methods("POST")
+	servicesV1.Handle("/acls/{clientID}", handlers.CustomHandle(env, handlers.VerifySignature, handlers.GetServiceClientACL)).Methods("GET")
+	servicesV1.Handle("/authcache/invalidate", handlers.CustomHandle(env, handlers.VerifySignature, handlers.InvalidateServiceAuthCache)).Methods("POST")
+	servicesV1.Handle("/revocations", handlers.CustomHandle(env, handlers.VerifySignature, handlers.RevokeServiceCredentials)).Methods("POST")
+	servicesV1.Handle("/revocations/users/{userID}", handlers.CustomHandle(env, handlers.VerifySignature, handlers.RestoreServiceUser)).Methods("DELETE")
+
+	// Admin endpoints, authenticated like internal services with admin scopes
+	adminV1 := v1.PathPrefix("/admin").Subrouter()
+	adminV1.Handle("/users", handlers.CustomHandle(env, handlers.VerifySignature, handlers.ListUsers)).Methods("GET")
+	adminV1.Handle("/users/{id}/acl", handlers.CustomHandle(env, handlers.VerifySignature, handlers.GetUserACL)).Methods("GET")
+	adminV1.Handle("/users/{id}/conversations", handlers.CustomHandle(env, handlers.VerifySignature, handlers.ListUserConversations)).Methods("GET")
+	adminV1.Handle("/users/{id}/suspend", handlers.CustomHandle(env, handlers.VerifySignature, handlers.SuspendUser)).Methods("POST")
+	adminV1.Handle("/users/{id}/disconnect", handlers.CustomHandle(env, handlers.VerifySignature, handlers.DisconnectUser)).Methods("POST")
+	adminV1.Handle("/users/{id}/restore", handlers.CustomHandle(env, handlers.VerifySignature, handlers.RestoreUserACLs)).Methods("POST")
+	adminV1.Handle("/users/{id}/quotas", handlers.CustomHandle(env, handlers.VerifySignature, handlers.GetUserQuotas)).Methods("GET")
+	adminV1.Handle("/users/{id}/quotas", handlers.CustomHandle(env, handlers.VerifySignature, handlers.SetUserQuotas)).Methods("PUT")
+	adminV1.Handle("/conversations/group/{id}", handlers.CustomHandle(env, handlers.VerifySignature, handlers.RemoveGroupConversation)).Methods("DELETE")
+	adminV1.Handle("/conversations/group/{id}/restore", handlers.CustomHandle(env, handlers.VerifySignature, handlers.RestoreGroupConversation)).Methods("POST")
+	adminV1.Handle("/broadcasts", handlers.CustomHandle(env, handlers.VerifySignature, handlers.Broadcast)).Methods("POST")
+	adminV1.Handle("/usage", handlers.CustomHandle(env, handlers.VerifySignature, handlers.GetUsageReport)).Methods("GET")
+	adminV1.Handle("/audit", handlers.CustomHandle(env, handlers.VerifySignature, handlers.GetAuditLog)).Methods("GET")
+	adminV1.Handle("/logging", handlers.CustomHandle(env, handlers.VerifySignature, handlers.GetLogging)).Methods("GET")
+	adminV1.Handle("/logging", handlers.CustomHandle(env, handlers.VerifySignature, handlers.SetLogging)).Methods("PUT")
+	// Server-sent events stream of real-time events, for ops dashboards
+	adminV1.Handle("/events", handlers.CustomHandle(env, handlers.VerifySignature, handlers.StreamEvents)).Methods("GET")
+	adminV1.Handle("/webhooks", handlers.CustomHandle(env, handlers.VerifySignature, handlers.CreateWebhook)).Methods("POST")
+	adminV1.Handle("/webhooks", handlers.CustomHandle(env, handlers.VerifySignature, handlers.ListWebhooks)).Methods("GET")
+	adminV1.Handle("/webhooks/deadletters", handlers.CustomHandle(env, handlers.VerifySignature, handlers.ListWebhookDeadLetters)).Methods("GET")
+	adminV1.Handle("/webhooks/deadletters/{id}/replay", handlers.CustomHandle(env, handlers.VerifySignature, handlers.ReplayWebhookDeadLetter)).Methods("POST")
+	adminV1.Handle("/webhooks/deadletters/{id}", handlers.CustomHandle(env, handlers.VerifySignature, handlers.DiscardWebhookDeadLetter)).Methods("DELETE")
+	adminV1.Handle("/webhooks/{id}", handlers.CustomHandle(env, handlers.VerifySignature, handlers.RemoveWebhook)).Methods("DELETE")
+	adminV1.Handle("/webhooks/{id}/deliveries", handlers.CustomHandle(env, handlers.VerifySignature, handlers.ListWebhookDeliveries)).Methods("GET")
 
-	http.ListenAndServe(":"+fmt.Sprintf("%d", PORT), corsHandler.Handler(r))
+	// VerneMQ Webhooks (Signed requests)
+	webhooksV1 := v1.PathPrefix("/webhooks").Subrouter()
+	webhooksV1.Handle("/offlinemessage", handlers.CustomHandle(env, handlers.VerifySignature, handlers.OnOfflineMessage)).Methods("POST")
+	webhooksV1.Handle("/register", handlers.CustomHandle(env, handlers.VerifySignature, handlers.OnClientRegister)).Methods("POST")
+	webhooksV1.Handle("/clientgone", handlers.CustomHandle(env, handlers.VerifySignature, handlers.OnClientGone)).Methods("POST")
 }
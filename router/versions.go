@@ -0,0 +1,125 @@
+package router
+
+import (
+	fmt "fmt"
+	http "net/http"
+	time "time"
+	models "wave-messaging-management-service/models"
+
+	mux "github.com/gorilla/mux"
+)
+
+// apiVersion : Version of the API, its routes being mounted under /{name}
+type apiVersion struct {
+	name   string
+	routes func(env *models.Env, r *mux.Router)
+}
+
+// apiVersions : Served API versions, oldest first. Unversioned legacy paths are aliases of the oldest one.
+// A new version gets its own routes function, reusing handlers of the previous version for unchanged endpoints,
+// while clients of the previous version keep being served until it is removed from this list
+var apiVersions = []apiVersion{
+	{name: "v1", routes: routesV1},
+}
+
+// deprecationHeaders : Headers announcing deprecation of API paths, computed once from their config
+type deprecationHeaders struct {
+	deprecation string
+	sunset      string
+	link        string
+}
+
+// mountAPIVersions : Mount routes of every API version under its prefix, and unversioned legacy paths when enabled.
+// Responses of deprecated versions and legacy paths hold Deprecation, Sunset and Link headers
+func mountAPIVersions(env *models.Env, r *mux.Router) {
+
+	config := env.Config.API
+
+	for _, version := range apiVersions {
+
+		versionRouter := r.PathPrefix("/" + version.name).Subrouter()
+
+		if deprecation, ok := config.Deprecations[version.name]; ok {
+			versionRouter.Use(deprecate(env, version.name, deprecation, ""))
+		}
+
+		version.routes(env, versionRouter)
+	}
+
+	if !config.LegacyPaths {
+		return
+	}
+
+	// Router without prefix, so that route templates are the unversioned paths
+	oldest := apiVersions[0]
+	legacyRouter := r.NewRoute().Subrouter()
+	legacyRouter.Use(deprecate(env, "legacy", &config.Legacy, "/"+oldest.name))
+	oldest.routes(env, legacyRouter)
+}
+
+// deprecate : Return middleware adding deprecation headers of config to responses, and a successor-version link
+// to the same path under successorPrefix if set. The service refuses to start with invalid dates
+func deprecate(env *models.Env, name string, config *models.DeprecationConfig, successorPrefix string) mux.MiddlewareFunc {
+
+	headers, err := newDeprecationHeaders(config)
+
+	if err != nil {
+		env.Logger.WithError(err).WithField("version", name).Fatal("Invalid API deprecation config")
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+
+			w.Header().Set("Deprecation", headers.deprecation)
+
+			if headers.sunset != "" {
+				w.Header().Set("Sunset", headers.sunset)
+			}
+
+			if headers.link != "" {
+				w.Header().Add("Link", headers.link)
+			}
+
+			if successorPrefix != "" {
+				w.Header().Add("Link", fmt.Sprintf("<%s%s>; rel=\"successor-version\"", successorPrefix, r.URL.Path))
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// newDeprecationHeaders : Return headers of config. Deprecation is a Unix timestamp (RFC 9745), "true" without date,
+// and Sunset an HTTP date (RFC 8594)
+func newDeprecationHeaders(config *models.DeprecationConfig) (*deprecationHeaders, error) {
+
+	headers := &deprecationHeaders{deprecation: "true"}
+
+	if config.Since != "" {
+
+		since, err := time.Parse(time.RFC3339, config.Since)
+
+		if err != nil {
+			return nil, err
+		}
+
+		headers.deprecation = fmt.Sprintf("@%d", since.Unix())
+	}
+
+	if config.Sunset != "" {
+
+		sunset, err := time.Parse(time.RFC3339, config.Sunset)
+
+		if err != nil {
+			return nil, err
+		}
+
+		headers.sunset = sunset.UTC().Format(http.TimeFormat)
+	}
+
+	if config.Link != "" {
+		headers.link = fmt.Sprintf("<%s>; rel=\"deprecation\"", config.Link)
+	}
+
+	return headers, nil
+}
@@ -0,0 +1,151 @@
+package rpc
+
+import (
+	context "context"
+	tls "crypto/tls"
+	net "net"
+	strings "strings"
+	time "time"
+	auth "wave-messaging-management-service/auth"
+	models "wave-messaging-management-service/models"
+
+	uuid "github.com/satori/go.uuid"
+	logrus "github.com/sirupsen/logrus"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	credentials "google.golang.org/grpc/credentials"
+	metadata "google.golang.org/grpc/metadata"
+	status "google.golang.org/grpc/status"
+)
+
+const (
+	// requestIDMetadata : Metadata holding the ID of a call, generated when the caller did not set one (gRPC metadata keys are lowercase)
+	requestIDMetadata = "x-request-id"
+
+	// apiKeyMetadata : Metadata holding the API key of the calling service, when it does not present a client certificate
+	apiKeyMetadata = "apikey"
+)
+
+// envKey : Context key of the execution environment of a call
+type envKey struct{}
+
+// Server : MessagingService implementation, running the operations of the HTTP service endpoints (See auth/services.go)
+type Server struct {
+	UnimplementedMessagingServiceServer
+}
+
+// NewServer : Return gRPC server of the MessagingService. Calls are served over TLS with the certificate files of the HTTP API when set,
+// client certificates being verified against its client CA (Mutual TLS). ACME certificates are not supported, calls are then served in clear
+func NewServer(env *models.Env) (*grpc.Server, error) {
+
+	options := []grpc.ServerOption{grpc.UnaryInterceptor(unaryInterceptor(env))}
+
+	if env.Config.TLS.CertFile != "" {
+
+		tlsConfig, err := auth.NewServerTLSConfig(&env.Config.TLS, nil)
+
+		if err != nil {
+			return nil, err
+		}
+
+		certificate, err := tls.LoadX509KeyPair(env.Config.TLS.CertFile, env.Config.TLS.KeyFile)
+
+		if err != nil {
+			return nil, err
+		}
+
+		tlsConfig.Certificates = []tls.Certificate{certificate}
+		tlsConfig.NextProtos = []string{"h2"}
+
+		options = append(options, grpc.Creds(credentials.NewTLS(tlsConfig)))
+	}
+
+	server := grpc.NewServer(options...)
+
+	RegisterMessagingServiceServer(server, &Server{})
+
+	return server, nil
+}
+
+// Serve : Serve gRPC API on configured address until server is stopped, return nil on graceful stop
+func Serve(env *models.Env, server *grpc.Server) error {
+
+	address := env.Config.GRPC.Address
+
+	if address == "" {
+		address = models.DefaultGRPCAddress
+	}
+
+	listener, err := net.Listen("tcp", address)
+
+	if err != nil {
+		return err
+	}
+
+	env.Logger.WithField("address", address).Info("gRPC API listening")
+
+	err = server.Serve(listener)
+
+	if err == grpc.ErrServerStopped {
+		return nil
+	}
+
+	return err
+}
+
+// unaryInterceptor : Run calls in their own execution environment, as HTTP handlers are (See CustomHandle) :
+// Config is refreshed, calls are given the configured handler timeout of their method, their panics are logged and answered with an Internal status.
+// Calls are logged with their duration and status code
+func unaryInterceptor(sharedEnv *models.Env) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp interface{}, err error) {
+
+		start := time.Now()
+		name := info.FullMethod[strings.LastIndex(info.FullMethod, "/")+1:]
+
+		requestID := metadataValue(ctx, requestIDMetadata)
+
+		if requestID == "" {
+			requestID = uuid.NewV4().String()
+		}
+
+		grpc.SetHeader(ctx, metadata.Pairs(requestIDMetadata, requestID))
+
+		ctx, cancel := context.WithTimeout(ctx, sharedEnv.CurrentConfig().Server.HandlerTimeoutOf(name))
+		defer cancel()
+
+		env := sharedEnv.ForRequest(ctx, requestID, name)
+
+		defer func() {
+
+			if recovered := recover(); recovered != nil {
+				env.LogPanic(recovered)
+				resp, err = nil, status.Error(codes.Internal, "Internal error")
+			}
+
+			env.Logger.WithFields(logrus.Fields{
+				"method":   info.FullMethod,
+				"code":     status.Code(err).String(),
+				"duration": time.Since(start).Seconds(),
+			}).Info("gRPC call")
+		}()
+
+		return handler(context.WithValue(ctx, envKey{}, env), req)
+	}
+}
+
+// callEnv : Return execution environment of the call of ctx (See unaryInterceptor)
+func callEnv(ctx context.Context) *models.Env {
+	return ctx.Value(envKey{}).(*models.Env)
+}
+
+// metadataValue : Return first value of incoming metadata key, empty if not set
+func metadataValue(ctx context.Context, key string) string {
+
+	md, ok := metadata.FromIncomingContext(ctx)
+
+	if !ok || len(md.Get(key)) == 0 {
+		return ""
+	}
+
+	return md.Get(key)[0]
+}
@@ -0,0 +1,235 @@
+package rpc
+
+import (
+	context "context"
+	auth "wave-messaging-management-service/auth"
+	models "wave-messaging-management-service/models"
+	utils "wave-messaging-management-service/utils"
+
+	logrus "github.com/sirupsen/logrus"
+	codes "google.golang.org/grpc/codes"
+	credentials "google.golang.org/grpc/credentials"
+	peer "google.golang.org/grpc/peer"
+	status "google.golang.org/grpc/status"
+)
+
+// checkService : Check internal service making the call was granted scope, return environment scoped to its tenant and the service as audit actor.
+// Services present either a client certificate (Mutual TLS) or an API key in metadata, as on HTTP service endpoints. Calls are not signed
+func checkService(ctx context.Context, scope string) (*models.Env, models.AuditActor, error) {
+
+	env := callEnv(ctx)
+
+	// Client certificate takes precedence, metadata is not even looked at
+	if p, ok := peer.FromContext(ctx); ok {
+
+		if tlsInfo, ok := p.AuthInfo.(credentials.TLSInfo); ok && len(tlsInfo.State.VerifiedChains) > 0 {
+
+			identity, err := auth.CheckClientCertificate(env, &tlsInfo.State, scope)
+
+			// If an error occurs, certificate is unknown or lacks scope
+			if err != nil {
+				env.Logger.WithError(err).Info("Client certificate refused")
+				return nil, models.AuditActor{}, credentialsError(err)
+			}
+
+			return identifyService(env, identity.TenantID, identity.ServiceName, scope)
+		}
+	}
+
+	apiKey, err := auth.CheckAPIKey(env, metadataValue(ctx, apiKeyMetadata), scope)
+
+	// If an error occurs, API key is invalid or lacks scope
+	if err != nil {
+		env.Logger.WithError(err).Info("API key refused")
+		return nil, models.AuditActor{}, credentialsError(err)
+	}
+
+	return identifyService(env, apiKey.TenantID, apiKey.ServiceName, scope)
+}
+
+// identifyService : Return environment scoped to tenant of authenticated service, and the service as audit actor
+func identifyService(env *models.Env, tenantID string, serviceName string, scope string) (*models.Env, models.AuditActor, error) {
+
+	env = env.ForTenant(tenantID).WithLogFields(logrus.Fields{"service": serviceName})
+
+	env.Logger.WithField("scope", scope).Info("Service authenticated")
+
+	actor := models.ServiceActor(serviceName)
+	env.IdentifyCaller(actor)
+
+	return env, actor, nil
+}
+
+// credentialsError : Return status of refused service credentials, PermissionDenied when they lack scope and Unauthenticated otherwise
+func credentialsError(err error) error {
+
+	if err == auth.ErrMissingScope {
+		return status.Error(codes.PermissionDenied, "Credentials lack the scope of the method")
+	}
+
+	return status.Error(codes.Unauthenticated, "Invalid API key or client certificate")
+}
+
+// selectIdentityProvider : Return identity provider named by request, default one when empty
+func selectIdentityProvider(env *models.Env, name string) (*models.IdentityProviderConfig, error) {
+
+	identityProvider, _, err := auth.SelectIdentityProvider(&env.Config, name, "")
+
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	return identityProvider, nil
+}
+
+// GetMappings : Get internal Wave user IDs of application users on behalf of an internal service
+func (server *Server) GetMappings(ctx context.Context, req *GetMappingsRequest) (*GetMappingsResponse, error) {
+
+	env, _, err := checkService(ctx, models.APIKeyScopeMappingsRead)
+
+	if err != nil {
+		return nil, err
+	}
+
+	identityProvider, err := selectIdentityProvider(env, req.IdentityProvider)
+
+	if err != nil {
+		return nil, err
+	}
+
+	mappings, err := auth.GetMappings(env, identityProvider, req.UserIds)
+
+	if err != nil {
+		env.Logger.WithError(err).Error("Failed to get mappings")
+		return nil, status.Error(codes.Internal, "Failed to get mappings")
+	}
+
+	resp := &GetMappingsResponse{Mappings: make([]*Mapping, len(mappings))}
+
+	for i, mapping := range mappings {
+		resp.Mappings[i] = &Mapping{OriginalUserId: mapping.OriginalUserID, InternalWaveUserId: mapping.InternalWaveUserID}
+	}
+
+	return resp, nil
+}
+
+// GetClientACL : Get VerneMQ ACL of one MQTT client on behalf of an internal service
+func (server *Server) GetClientACL(ctx context.Context, req *GetClientACLRequest) (*ACL, error) {
+
+	env, _, err := checkService(ctx, models.APIKeyScopeACLRead)
+
+	if err != nil {
+		return nil, err
+	}
+
+	verneMQACL, err := auth.GetClientACL(env, req.ClientId)
+
+	if err == auth.ErrOtherTenant {
+		return nil, status.Error(codes.PermissionDenied, err.Error())
+	}
+
+	if err != nil {
+		env.Logger.WithError(err).Error("Failed to get client ACL")
+		return nil, status.Error(codes.Internal, "Failed to get client ACL")
+	}
+
+	acl := &ACL{
+		Mountpoint: verneMQACL.Mountpoint,
+		ClientId:   verneMQACL.ClientID,
+		Username:   verneMQACL.Username,
+		DeviceName: verneMQACL.DeviceName,
+	}
+
+	for _, pattern := range verneMQACL.PublishACL {
+		acl.PublishPatterns = append(acl.PublishPatterns, pattern.Pattern)
+	}
+
+	for _, pattern := range verneMQACL.SubscribeACL {
+		acl.SubscribePatterns = append(acl.SubscribePatterns, pattern.Pattern)
+	}
+
+	if verneMQACL.ExpiresAt != nil {
+		acl.ExpiresAt = verneMQACL.ExpiresAt.Unix()
+	}
+
+	return acl, nil
+}
+
+// AuthorizePublishing : Grant publishing rights on a MQTT topic to a user (On all its devices) on behalf of an internal service
+func (server *Server) AuthorizePublishing(ctx context.Context, req *AuthorizePublishingRequest) (*AuthorizePublishingResponse, error) {
+
+	env, actor, err := checkService(ctx, models.APIKeyScopeACLWrite)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if req.UserId == "" || req.Topic == "" {
+		return nil, status.Error(codes.InvalidArgument, "User ID and topic are required")
+	}
+
+	pattern, err := auth.AuthorizePublishing(env, actor, req.UserId, req.Topic)
+
+	if _, ok := err.(*auth.TopicError); ok || err == auth.ErrOtherTenant {
+		env.Logger.WithError(err).Info("Publishing refused")
+		return nil, status.Error(codes.PermissionDenied, err.Error())
+	}
+
+	if err != nil {
+		env.Logger.WithError(err).Error("Failed to authorize publishing")
+		return nil, status.Error(codes.Internal, "Failed to authorize publishing")
+	}
+
+	return &AuthorizePublishingResponse{Pattern: pattern}, nil
+}
+
+// CreateGroupConversations : Create many group conversations on behalf of an internal service, nothing is created if one of their users is unknown
+func (server *Server) CreateGroupConversations(ctx context.Context, req *CreateGroupConversationsRequest) (*CreateGroupConversationsResponse, error) {
+
+	env, actor, err := checkService(ctx, models.APIKeyScopeConversationsWrite)
+
+	if err != nil {
+		return nil, err
+	}
+
+	conversations := make([]utils.BulkGroupConversation, len(req.Conversations))
+
+	for i, conversation := range req.Conversations {
+
+		if conversation.CreatorId == "" {
+			return nil, status.Error(codes.InvalidArgument, "Creator ID is required")
+		}
+
+		conversations[i] = utils.BulkGroupConversation{CreatorID: conversation.CreatorId, Members: conversation.Members, Name: conversation.Name}
+	}
+
+	identityProvider, err := selectIdentityProvider(env, req.IdentityProvider)
+
+	if err != nil {
+		return nil, err
+	}
+
+	groupConversations, err := auth.CreateGroupConversations(env, actor, identityProvider, conversations)
+
+	if _, ok := err.(*auth.UnknownUsersError); ok || err == auth.ErrTooManyConversations {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	if err != nil {
+		env.Logger.WithError(err).Error("Failed to add group conversations")
+		return nil, status.Error(codes.Internal, "Failed to add group conversations")
+	}
+
+	resp := &CreateGroupConversationsResponse{Conversations: make([]*GroupConversation, len(groupConversations))}
+
+	for i, groupConv := range groupConversations {
+		resp.Conversations[i] = &GroupConversation{
+			GroupConversationId: groupConv.GroupConversationID,
+			Name:                groupConv.Name,
+			CreatorId:           groupConv.CreatorID,
+			Members:             groupConv.Members,
+		}
+	}
+
+	return resp, nil
+}
@@ -0,0 +1,184 @@
+package utils
+
+import (
+	base64 "encoding/base64"
+	json "encoding/json"
+	errors "errors"
+	fmt "fmt"
+	http "net/http"
+	url "net/url"
+	strconv "strconv"
+)
+
+var (
+	// ErrInvalidCursor : Cursor was not returned by a list endpoint
+	ErrInvalidCursor = errors.New("Invalid cursor")
+
+	// ErrInvalidOffset : Offset is not a positive integer
+	ErrInvalidOffset = errors.New("Invalid offset")
+
+	// ErrInvalidLimit : Limit is not a positive integer
+	ErrInvalidLimit = errors.New("Invalid limit")
+
+	// ErrCursorAndOffset : Both cursor and offset were given
+	ErrCursorAndOffset = errors.New("Cursor and offset are mutually exclusive")
+)
+
+// Pagination : Requested page of a list endpoint, Limit being bounded by the endpoint maximum
+type Pagination struct {
+	Offset int
+	Limit  int
+}
+
+// Page : Pagination of a listed page, embedded in pages returned by list endpoints.
+// Total counts all listed items, NextCursor and PreviousCursor are left out on the last and first pages
+type Page struct {
+	Total          int    `json:"total"`
+	Offset         int    `json:"offset"`
+	Limit          int    `json:"limit"`
+	NextCursor     string `json:"nextCursor,omitempty"`
+	PreviousCursor string `json:"previousCursor,omitempty"`
+}
+
+// pageCursor : Position encoded in opaque cursors, so that clients don't depend on how pages are fetched
+type pageCursor struct {
+	Offset int `json:"o"`
+}
+
+// ParsePagination : Return pagination of query parameters cursor (Returned by a previous page) or offset, and limit.
+// Limit defaults to defaultLimit and is bounded by maxLimit
+func ParsePagination(query url.Values, defaultLimit int, maxLimit int) (*Pagination, error) {
+
+	pagination := &Pagination{Limit: defaultLimit}
+
+	cursor := query.Get("cursor")
+	offset := query.Get("offset")
+
+	if cursor != "" && offset != "" {
+		return nil, ErrCursorAndOffset
+	}
+
+	if cursor != "" {
+
+		decoded, err := DecodeCursor(cursor)
+
+		if err != nil {
+			return nil, err
+		}
+
+		pagination.Offset = decoded
+	}
+
+	if offset != "" {
+
+		decoded, err := strconv.Atoi(offset)
+
+		if err != nil || decoded < 0 {
+			return nil, ErrInvalidOffset
+		}
+
+		pagination.Offset = decoded
+	}
+
+	if limit := query.Get("limit"); limit != "" {
+
+		decoded, err := strconv.Atoi(limit)
+
+		if err != nil || decoded < 0 {
+			return nil, ErrInvalidLimit
+		}
+
+		if decoded > 0 {
+			pagination.Limit = decoded
+		}
+	}
+
+	if pagination.Limit > maxLimit {
+		pagination.Limit = maxLimit
+	}
+
+	return pagination, nil
+}
+
+// NewPage : Return page of pagination among total items, with cursors of its next and previous pages
+func NewPage(pagination *Pagination, total int) Page {
+
+	page := Page{
+		Total:  total,
+		Offset: pagination.Offset,
+		Limit:  pagination.Limit,
+	}
+
+	if pagination.Offset+pagination.Limit < total {
+		page.NextCursor = EncodeCursor(pagination.Offset + pagination.Limit)
+	}
+
+	if pagination.Offset > 0 {
+
+		previous := pagination.Offset - pagination.Limit
+
+		if previous < 0 {
+			previous = 0
+		}
+
+		page.PreviousCursor = EncodeCursor(previous)
+	}
+
+	return page
+}
+
+// EncodeCursor : Return opaque cursor of the page starting at offset
+func EncodeCursor(offset int) string {
+
+	data, _ := json.Marshal(pageCursor{Offset: offset})
+
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// DecodeCursor : Return offset of the page of cursor
+func DecodeCursor(cursor string) (int, error) {
+
+	data, err := base64.RawURLEncoding.DecodeString(cursor)
+
+	if err != nil {
+		return 0, ErrInvalidCursor
+	}
+
+	decoded := &pageCursor{}
+
+	err = json.Unmarshal(data, decoded)
+
+	if err != nil || decoded.Offset < 0 {
+		return 0, ErrInvalidCursor
+	}
+
+	return decoded.Offset, nil
+}
+
+// WritePageLinks : Add Link headers (RFC 8288) of the first, previous and next pages to the response, so that clients can follow them.
+// Links keep the path and query parameters of the request, offset being replaced by the cursor of the page
+func WritePageLinks(w http.ResponseWriter, r *http.Request, page Page) {
+
+	links := []struct {
+		rel    string
+		cursor string
+	}{
+		{rel: "first", cursor: EncodeCursor(0)},
+		{rel: "prev", cursor: page.PreviousCursor},
+		{rel: "next", cursor: page.NextCursor},
+	}
+
+	for _, link := range links {
+
+		if link.cursor == "" {
+			continue
+		}
+
+		query := r.URL.Query()
+		query.Del("offset")
+		query.Set("cursor", link.cursor)
+		query.Set("limit", strconv.Itoa(page.Limit))
+
+		w.Header().Add("Link", fmt.Sprintf("<%s?%s>; rel=\"%s\"", r.URL.Path, query.Encode(), link.rel))
+	}
+}
@@ -1,7 +1,7 @@
 package utils
 
 import (
-	log "log"
+	logrus "github.com/sirupsen/logrus"
 )
 
 // MappingRequestBody : Request Body on Mapping Request
@@ -15,14 +15,91 @@ type GroupConversationBody struct {
 	Name    string   `json:"name"`
 }
 
+// BulkGroupConversationsBody : Request Body on Bulk Group Creation by an internal service
+type BulkGroupConversationsBody struct {
+	Conversations []BulkGroupConversation `json:"conversations" validate:"required,dive"`
+}
+
+// BulkGroupConversation : Group conversation of a bulk creation, CreatorID and Members being application user IDs
+type BulkGroupConversation struct {
+	CreatorID string   `json:"creatorID" validate:"required"`
+	Members   []string `json:"members"`
+	Name      string   `json:"name"`
+}
+
+// GraphQLBody : Request Body on GraphQL Query
+type GraphQLBody struct {
+	Query         string                 `json:"query" validate:"required"`
+	OperationName string                 `json:"operationName"`
+	Variables     map[string]interface{} `json:"variables"`
+}
+
+// DeviceBody : Request Body on Device Registration
+type DeviceBody struct {
+	DeviceName string `json:"deviceName"`
+}
+
+// PushTokenBody : Request Body on Push Token Registration
+type PushTokenBody struct {
+	Token          string `json:"token" validate:"required"`
+	Platform       string `json:"platform" validate:"required,oneof=fcm apns webpush"`
+	DeviceClientID string `json:"deviceClientID"`
+	AppVersion     string `json:"appVersion"`
+	Locale         string `json:"locale"`
+	Keys           struct {
+		P256dh string `json:"p256dh"`
+		Auth   string `json:"auth"`
+	} `json:"keys"`
+}
+
+// PublishACLBody : Request Body on Publish ACL Grant by an internal service
+type PublishACLBody struct {
+	UserID string `json:"userID" validate:"required"`
+	Topic  string `json:"topic" validate:"required"`
+}
+
+// TokenBody : Request Body on Token Cache Invalidation by an internal service
+type TokenBody struct {
+	Token string `json:"token" validate:"required"`
+}
+
+// RevocationBody : Request Body on Tokens & Users Revocation by an internal service
+// Revocations expire after TTL seconds (Never if 0), e.g. when revoked tokens expire anyway
+type RevocationBody struct {
+	Tokens  []string `json:"tokens"`
+	UserIDs []string `json:"userIDs"`
+	TTL     int      `json:"ttl" validate:"min=0"`
+}
+
+// BroadcastBody : Request Body on System Message Broadcast by an operator
+// Message is sent to UserIDs (Internal Wave user IDs) only, to all users if empty
+type BroadcastBody struct {
+	Message string            `json:"message" validate:"required"`
+	Data    map[string]string `json:"data"`
+	UserIDs []string          `json:"userIDs"`
+}
+
+// WebhookBody : Request Body on outbound webhook registration by an operator
+// URL receives lifecycle events of Events types
+type WebhookBody struct {
+	URL    string   `json:"url" validate:"required,url,startswith=http"`
+	Events []string `json:"events" validate:"required,min=1,dive,oneof=conversation.created member.added user.provisioned"`
+}
+
 // AuthCheckerBody : Response Body from Auth Checker
 type AuthCheckerBody struct {
 	OriginalUserID string `json:"userID" bson:"userID"`
+	TenantID       string `json:"tenantID" bson:"tenantID"`
+}
+
+// WebhookResponse : Response Body to VerneMQ Webhooks
+type WebhookResponse struct {
+	Result string `json:"result"`
 }
 
-// PanicOnError : Prints the error & exits the program
+// PanicOnError : Logs the error & exits the program
 func PanicOnError(err error, msg string) {
 	if err != nil {
-		log.Panicf("%s: %s\n", msg, err)
+		logrus.WithError(err).Panic(msg)
 	}
 }
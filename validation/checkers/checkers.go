@@ -1,22 +1,45 @@
 package checkers
 
 import (
-	regexp "regexp"
+	auth "wave-messaging-management-service/auth"
 	models "wave-messaging-management-service/models"
+
+	logrus "github.com/sirupsen/logrus"
 )
 
-// IsTokenValid : Checks if parameter matches regex
-func IsTokenValid(env *models.Env, s string) (bool, error) {
+// ValidateToken : Run token through configured validator chain, stopping at first failure.
+// Returned error holds the code of failing validator, to be used as response code.
+// Without chain, token is checked against tokenValidationRegex
+func ValidateToken(env *models.Env, token string) error {
+
+	// Patterns of the chain are compiled once per loaded config
+	configs := env.Config.TokenValidatorChain()
 
-	// Refresh config to get actual environment values
-	err := env.RefreshConfig()
+	// Claims are read from token without identity provider prefix
+	_, unprefixedToken, err := auth.SelectIdentityProvider(&env.Config, "", token)
 
 	if err != nil {
-		return false, err
+		return err
 	}
 
-	// RegexToken : Regex validation for token (Provided by use through environment variable)
-	RegexToken := regexp.MustCompile(env.Config.TokenValidationRegex)
+	for _, config := range configs {
+
+		validator, err := NewTokenValidator(config)
+
+		if err != nil {
+			return err
+		}
+
+		err = validator.Validate(token, unprefixedToken)
+
+		if validationErr, ok := err.(*ValidationError); ok {
+			env.Logger.WithFields(logrus.Fields{"validator": config.Type, "reason": validationErr.Reason}).Info("Token refused")
+		}
+
+		if err != nil {
+			return err
+		}
+	}
 
-	return RegexToken.MatchString(s), nil
+	return nil
 }
@@ -0,0 +1,239 @@
+package checkers
+
+import (
+	base64 "encoding/base64"
+	json "encoding/json"
+	fmt "fmt"
+	regexp "regexp"
+	strings "strings"
+	time "time"
+	models "wave-messaging-management-service/models"
+)
+
+const (
+	// ValidatorTypeRegex : Token must match Pattern
+	ValidatorTypeRegex = models.TokenValidatorTypeRegex
+
+	// ValidatorTypeLength : Token length must be within Min and Max (Not checked if 0)
+	ValidatorTypeLength = "length"
+
+	// ValidatorTypeCharset : Token characters must belong to Charset (Predefined name or explicit characters)
+	ValidatorTypeCharset = "charset"
+
+	// ValidatorTypeExpiry : Token must be a JWT whose exp claim is not past, give or take Leeway seconds
+	ValidatorTypeExpiry = "expiry"
+
+	// ValidatorTypeIssuer : Token must be a JWT whose iss claim is one of Issuers
+	ValidatorTypeIssuer = "issuer"
+)
+
+const (
+	// CodeInvalidTokenFormat : Token doesn't match regex
+	CodeInvalidTokenFormat = "INVALID_TOKEN_FORMAT"
+
+	// CodeInvalidTokenLength : Token is too short or too long
+	CodeInvalidTokenLength = "INVALID_TOKEN_LENGTH"
+
+	// CodeInvalidTokenCharset : Token holds unexpected characters
+	CodeInvalidTokenCharset = "INVALID_TOKEN_CHARSET"
+
+	// CodeInvalidTokenClaims : Token claims can't be read
+	CodeInvalidTokenClaims = "INVALID_TOKEN_CLAIMS"
+
+	// CodeTokenExpired : Token exp claim is past
+	CodeTokenExpired = "TOKEN_EXPIRED"
+
+	// CodeInvalidTokenIssuer : Token iss claim is not accepted
+	CodeInvalidTokenIssuer = "INVALID_TOKEN_ISSUER"
+)
+
+var (
+	// charsets : Predefined charsets of charset validator
+	charsets = map[string]string{
+		"base64":    "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789+/=",
+		"base64url": "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789-_=",
+		"jwt":       "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789-_.",
+		"hex":       "0123456789abcdefABCDEF",
+	}
+)
+
+// ValidationError : Token refused by a validator, Code is returned in response
+type ValidationError struct {
+	Code   string
+	Reason string
+}
+
+// Error : Return validator code, so that it is used as response code
+func (err *ValidationError) Error() string {
+	return err.Code
+}
+
+// TokenValidator : One link of the token validator chain
+type TokenValidator interface {
+
+	// Validate : Return a *ValidationError if token is refused.
+	// Claims based validators read unprefixedToken, stripped from identity provider prefix
+	Validate(token string, unprefixedToken string) error
+}
+
+// NewTokenValidator : Return validator described by config
+func NewTokenValidator(config *models.TokenValidatorConfig) (TokenValidator, error) {
+
+	switch config.Type {
+
+	case ValidatorTypeRegex:
+		pattern, err := config.Regexp()
+
+		if err != nil {
+			return nil, err
+		}
+
+		return &RegexValidator{Pattern: pattern}, nil
+
+	case ValidatorTypeLength:
+		return &LengthValidator{Min: config.Min, Max: config.Max}, nil
+
+	case ValidatorTypeCharset:
+		charset, ok := charsets[config.Charset]
+
+		if !ok {
+			charset = config.Charset
+		}
+
+		return &CharsetValidator{Charset: charset}, nil
+
+	case ValidatorTypeExpiry:
+		return &ExpiryValidator{Leeway: time.Duration(config.Leeway) * time.Second}, nil
+
+	case ValidatorTypeIssuer:
+		return &IssuerValidator{Issuers: config.Issuers}, nil
+	}
+
+	return nil, fmt.Errorf("Unknown token validator %s", config.Type)
+}
+
+// RegexValidator : Check token against a regular expression
+type RegexValidator struct {
+	Pattern *regexp.Regexp
+}
+
+// Validate : Token must match pattern
+func (validator *RegexValidator) Validate(token string, unprefixedToken string) error {
+
+	if !validator.Pattern.MatchString(token) {
+		return &ValidationError{Code: CodeInvalidTokenFormat, Reason: "token doesn't match " + validator.Pattern.String()}
+	}
+
+	return nil
+}
+
+// LengthValidator : Check token length
+type LengthValidator struct {
+	Min int
+	Max int
+}
+
+// Validate : Token length must be within bounds
+func (validator *LengthValidator) Validate(token string, unprefixedToken string) error {
+
+	if len(token) < validator.Min || (validator.Max > 0 && len(token) > validator.Max) {
+		return &ValidationError{Code: CodeInvalidTokenLength, Reason: fmt.Sprintf("token length %d out of [%d, %d]", len(token), validator.Min, validator.Max)}
+	}
+
+	return nil
+}
+
+// CharsetValidator : Check token characters
+type CharsetValidator struct {
+	Charset string
+}
+
+// Validate : Every token character must belong to charset
+func (validator *CharsetValidator) Validate(token string, unprefixedToken string) error {
+
+	for _, char := range token {
+		if !strings.ContainsRune(validator.Charset, char) {
+			return &ValidationError{Code: CodeInvalidTokenCharset, Reason: fmt.Sprintf("unexpected character %q", char)}
+		}
+	}
+
+	return nil
+}
+
+// ExpiryValidator : Check JWT exp claim, refusing expired tokens before they reach the verifier
+type ExpiryValidator struct {
+	Leeway time.Duration
+}
+
+// Validate : Token exp claim must not be past
+func (validator *ExpiryValidator) Validate(token string, unprefixedToken string) error {
+
+	claims, err := readClaims(unprefixedToken)
+
+	if err != nil {
+		return err
+	}
+
+	exp, ok := claims["exp"].(float64)
+
+	if !ok {
+		return &ValidationError{Code: CodeInvalidTokenClaims, Reason: "token has no exp claim"}
+	}
+
+	if time.Unix(int64(exp), 0).Add(validator.Leeway).Before(time.Now()) {
+		return &ValidationError{Code: CodeTokenExpired, Reason: "token expired"}
+	}
+
+	return nil
+}
+
+// IssuerValidator : Check JWT iss claim
+type IssuerValidator struct {
+	Issuers []string
+}
+
+// Validate : Token iss claim must be one of accepted issuers
+func (validator *IssuerValidator) Validate(token string, unprefixedToken string) error {
+
+	claims, err := readClaims(unprefixedToken)
+
+	if err != nil {
+		return err
+	}
+
+	issuer, _ := claims["iss"].(string)
+
+	for _, accepted := range validator.Issuers {
+		if issuer == accepted {
+			return nil
+		}
+	}
+
+	return &ValidationError{Code: CodeInvalidTokenIssuer, Reason: "issuer " + issuer + " not accepted"}
+}
+
+// readClaims : Decode JWT payload without verifying signature, which remains up to the verifier
+func readClaims(token string) (map[string]interface{}, error) {
+
+	parts := strings.Split(token, ".")
+
+	if len(parts) != 3 {
+		return nil, &ValidationError{Code: CodeInvalidTokenClaims, Reason: "token is not a JWT"}
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(parts[1], "="))
+
+	if err != nil {
+		return nil, &ValidationError{Code: CodeInvalidTokenClaims, Reason: err.Error()}
+	}
+
+	claims := map[string]interface{}{}
+
+	err = json.Unmarshal(payload, &claims)
+
+	if err != nil {
+		return nil, &ValidationError{Code: CodeInvalidTokenClaims, Reason: err.Error()}
+	}
+
+	return claims, nil
+}
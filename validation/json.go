@@ -0,0 +1,86 @@
+package validation
+
+import (
+	bytes "bytes"
+	json "encoding/json"
+	errors "errors"
+	io "io"
+	ioutil "io/ioutil"
+)
+
+const (
+	// MaxJSONDepth : Maximum nesting of objects and arrays in request bodies, far above the one of expected bodies
+	MaxJSONDepth = 16
+)
+
+// DecodeJSON : Strictly decode JSON body into v. Unknown fields, nesting deeper than MaxJSONDepth and data after the JSON value are refused.
+// Body size is expected to be limited by the caller (See Server config)
+func DecodeJSON(body io.Reader, v interface{}) error {
+
+	data, err := ioutil.ReadAll(body)
+
+	if err != nil {
+		return err
+	}
+
+	err = checkJSONDepth(data, MaxJSONDepth)
+
+	if err != nil {
+		return err
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+
+	err = decoder.Decode(v)
+
+	if err != nil {
+		return err
+	}
+
+	// A single value is expected
+	_, err = decoder.Token()
+
+	if err != io.EOF {
+		return errors.New("Unexpected data after JSON value")
+	}
+
+	return nil
+}
+
+// checkJSONDepth : Check objects and arrays of JSON data are not nested deeper than maxDepth, before allocating them
+func checkJSONDepth(data []byte, maxDepth int) error {
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	depth := 0
+
+	for {
+
+		token, err := decoder.Token()
+
+		if err == io.EOF {
+			return nil
+		}
+
+		if err != nil {
+			return err
+		}
+
+		delim, ok := token.(json.Delim)
+
+		if !ok {
+			continue
+		}
+
+		switch delim {
+		case '{', '[':
+			depth++
+
+			if depth > maxDepth {
+				return errors.New("JSON nested too deeply")
+			}
+		case '}', ']':
+			depth--
+		}
+	}
+}